@@ -0,0 +1,297 @@
+package lyra
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var primitives = map[reflect.Kind]types.PrimitiveTypeName{
+	reflect.Bool:    types.Bool,
+	reflect.String:  types.String,
+	reflect.Int:     types.Int,
+	reflect.Int8:    types.Int8,
+	reflect.Int16:   types.Int16,
+	reflect.Int32:   types.Int32,
+	reflect.Int64:   types.Int64,
+	reflect.Uint:    types.UInt,
+	reflect.Uint8:   types.UInt8,
+	reflect.Uint16:  types.UInt16,
+	reflect.Uint32:  types.UInt32,
+	reflect.Uint64:  types.UInt64,
+	reflect.Float32: types.Float32,
+	reflect.Float64: types.Float,
+}
+
+// synthesize returns the Lyra type of a Go type, declaring struct types in the
+// prelude the first time they are seen
+func (vm *VM) synthesize(t reflect.Type) (types.Type, error) {
+	if t == nil {
+		return nil, fmt.Errorf("cannot bind nil")
+	}
+	if synthesized, ok := vm.types[t]; ok {
+		return synthesized, nil
+	}
+	if name, ok := primitives[t.Kind()]; ok {
+		return types.PrimitiveType{Name: name}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return vm.synthesize(t.Elem())
+	case reflect.Slice, reflect.Array:
+		element, err := vm.synthesize(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return types.ArrayType{ElementType: element}, nil
+	case reflect.Struct:
+		return vm.synthesizeStruct(t)
+	case reflect.Map:
+		return nil, fmt.Errorf("%s: Lyra has no map type yet", t)
+	}
+	return nil, fmt.Errorf("%s has no Lyra equivalent", t)
+}
+
+func (vm *VM) synthesizeStruct(t reflect.Type) (types.Type, error) {
+	if t.Name() == "" {
+		return nil, fmt.Errorf("anonymous struct %s needs a named type", t)
+	}
+	name := typeName(t)
+	if other, ok := vm.names[name]; ok {
+		return nil, fmt.Errorf("%s and %s both map to the Lyra type %s", other, t, name)
+	}
+	decl := types.StructType{Name: name, Fields: make(map[string]types.StructField)}
+	// registered before the fields so recursive types through slices resolve
+	vm.types[t] = types.UnresolvedType{Name: name}
+	vm.names[name] = t
+
+	for _, field := range exportedFields(t) {
+		fieldType, err := vm.synthesize(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", field.Name, t, err)
+		}
+		lyraName := fieldName(field)
+		decl.Fields[lyraName] = types.StructField{Name: lyraName, Type: fieldType}
+	}
+	vm.prelude = append(vm.prelude, &ast.TypeDeclStmt{Name: name, IsPublic: true, Type: decl})
+	return vm.types[t], nil
+}
+
+// typeName is the Lyra name of a Go type: Lyra type names start uppercase
+func typeName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+func exportedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && field.Tag.Get("lyra") != "-" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldName is the `lyra:"name"` tag of a Go field, or its name in snake_case
+// with acronyms kept together (HTTPPort -> http_port)
+func fieldName(field reflect.StructField) string {
+	if tag := field.Tag.Get("lyra"); tag != "" {
+		return tag
+	}
+	runes := []rune(field.Name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			startsWord := i > 0 && (unicode.IsLower(runes[i-1]) ||
+				i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))
+			if startsWord {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// toLyra converts a Go value to the interpreter's representation
+func toLyra(v reflect.Value) (interp.Value, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, fmt.Errorf("nil %s has no Lyra value", v.Type())
+		}
+		return toLyra(v.Elem())
+	case reflect.Slice, reflect.Array:
+		elements := make([]interp.Value, v.Len())
+		for i := range elements {
+			element, err := toLyra(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return &interp.ArrayValue{Elements: elements}, nil
+	case reflect.Struct:
+		fields := make(map[string]interp.Value)
+		for _, field := range exportedFields(v.Type()) {
+			value, err := toLyra(v.FieldByIndex(field.Index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			fields[fieldName(field)] = value
+		}
+		return &interp.StructValue{Type: typeName(v.Type()), Fields: fields}, nil
+	case reflect.Invalid:
+		return nil, fmt.Errorf("cannot convert nil")
+	}
+	return nil, fmt.Errorf("%s has no Lyra equivalent", v.Type())
+}
+
+// fromLyra converts a value to plain Go: numbers, bools and strings as
+// themselves, arrays and tuples as []any and structs as map[string]any
+func fromLyra(value interp.Value) any {
+	switch v := value.(type) {
+	case *interp.TupleValue:
+		return fromLyra(&interp.ArrayValue{Elements: v.Elements})
+	case *interp.ArrayValue:
+		elements := make([]any, len(v.Elements))
+		for i, element := range v.Elements {
+			elements[i] = fromLyra(element)
+		}
+		return elements
+	case *interp.StructValue:
+		fields := make(map[string]any, len(v.Fields))
+		for name, field := range v.Fields {
+			fields[name] = fromLyra(field)
+		}
+		return fields
+	case interp.Unit:
+		return nil
+	}
+	return value
+}
+
+// decode stores value into the Go value target points to
+func decode(value interp.Value, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", target)
+	}
+	return decodeValue(value, v.Elem())
+}
+
+func decodeValue(value interp.Value, v reflect.Value) error {
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(fromLyra(value)))
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(value, v.Elem())
+	}
+
+	mismatch := fmt.Errorf("cannot store %s in %s", interp.FormatValue(value), v.Type())
+	switch val := value.(type) {
+	case bool:
+		if v.Kind() != reflect.Bool {
+			return mismatch
+		}
+		v.SetBool(val)
+	case string:
+		if v.Kind() != reflect.String {
+			return mismatch
+		}
+		v.SetString(val)
+	case int64:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(val) {
+				return fmt.Errorf("%d overflows %s", val, v.Type())
+			}
+			v.SetInt(val)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if val < 0 || v.OverflowUint(uint64(val)) {
+				return fmt.Errorf("%d overflows %s", val, v.Type())
+			}
+			v.SetUint(uint64(val))
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(val))
+		default:
+			return mismatch
+		}
+	case float64:
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return mismatch
+		}
+		v.SetFloat(val)
+	case *interp.ArrayValue:
+		switch v.Kind() {
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(v.Type(), len(val.Elements), len(val.Elements)))
+		case reflect.Array:
+			if v.Len() != len(val.Elements) {
+				return fmt.Errorf("cannot store %d elements in %s", len(val.Elements), v.Type())
+			}
+		default:
+			return mismatch
+		}
+		for i, element := range val.Elements {
+			if err := decodeValue(element, v.Index(i)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	case *interp.StructValue:
+		if v.Kind() != reflect.Struct {
+			return mismatch
+		}
+		for _, field := range exportedFields(v.Type()) {
+			fieldValue, ok := val.Fields[fieldName(field)]
+			if !ok {
+				continue
+			}
+			if err := decodeValue(fieldValue, v.FieldByIndex(field.Index)); err != nil {
+				return fmt.Errorf("field %s: %w", fieldName(field), err)
+			}
+		}
+	case *interp.TupleValue:
+		// a tuple fills the exported fields of a struct in order, the way an extern
+		// returns one as its results in order
+		if v.Kind() != reflect.Struct {
+			return decodeValue(&interp.ArrayValue{Elements: val.Elements}, v)
+		}
+		fields := exportedFields(v.Type())
+		if len(fields) != len(val.Elements) {
+			return fmt.Errorf("cannot store %d elements in %s with %d fields", len(val.Elements), v.Type(), len(fields))
+		}
+		for i, element := range val.Elements {
+			if err := decodeValue(element, v.FieldByIndex(fields[i].Index)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+	default:
+		return mismatch
+	}
+	return nil
+}
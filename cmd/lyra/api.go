@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/apidiff"
+)
+
+// lyra api module/
+func runAPI(args []string) error {
+	flags := flag.NewFlagSet("api", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: lyra api module/")
+	}
+
+	api, err := apidiff.LoadDir(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, symbol := range apidiff.Surface(api) {
+		fmt.Println(symbol)
+	}
+	return nil
+}
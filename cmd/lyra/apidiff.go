@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/apidiff"
+)
+
+// lyra apidiff old/ new/
+func runAPIDiff(args []string) error {
+	flags := flag.NewFlagSet("apidiff", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("usage: lyra apidiff old/ new/")
+	}
+
+	old, err := apidiff.LoadDir(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := apidiff.LoadDir(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	changes := apidiff.Diff(old, new)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if apidiff.Breaking(changes) {
+		return errors.New("breaking API changes")
+	}
+	return nil
+}
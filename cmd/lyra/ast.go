@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/printer"
+)
+
+// lyra ast [-typed] <file>
+func runAST(args []string) error {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	typed := flags.Bool("typed", false, "print the program with every expression annotated by its checked type")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: lyra ast [-typed] <file>")
+	}
+
+	source, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return err
+	}
+	for _, err := range result.Errors {
+		fmt.Fprintf(os.Stderr, "%s:%v\n", flags.Arg(0), err)
+	}
+
+	if !*typed {
+		result.Program.Print("")
+		return nil
+	}
+	return printer.WriteTyped(os.Stdout, result.Program)
+}
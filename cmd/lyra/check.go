@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/project"
+)
+
+// lyra check [--trace] [--cache dir] [--stats] [-j n] files...
+//
+// A dir/... argument checks every .lyra file under dir. Files are checked in
+// parallel, each after the files of the modules it uses, but reported in the
+// order they are given, sorted within a dir/...
+func runCheck(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	jobs := flags.Int("j", runtime.GOMAXPROCS(0), "number of files to check in parallel")
+	trace := flags.Bool("trace", false, "print every checker decision: rule, expected and resulting type, generic bindings")
+	cacheDir := flags.String("cache", "", "directory of a cache of functions checked by earlier runs; unchanged functions are not checked again")
+	stats := flags.Bool("stats", false, "print how many method lookups the checker made and how many the method cache answered")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: lyra check [--trace] [--cache dir] [--stats] [-j n] files...")
+	}
+	if *trace && *cacheDir != "" {
+		return errors.New("--trace and --cache cannot be combined: cached functions are not checked")
+	}
+	var cache *analyzer.Cache
+	if *cacheDir != "" {
+		var err error
+		if cache, err = analyzer.OpenCache(*cacheDir); err != nil {
+			return err
+		}
+	}
+
+	files, err := expandFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+	analyze := analyzer.Analyze
+	if *trace {
+		analyze = analyzer.AnalyzeTraced
+	}
+	if cache != nil {
+		analyze = func(source []byte) (*analyzer.Result, error) { return analyzer.AnalyzeCached(source, cache) }
+	}
+
+	failed := 0
+	var methods symbols.MethodCacheStats
+	err = analyzer.AnalyzeFiles(files, *jobs, analyze, moduleImports, func(file analyzer.FileResult) error {
+		if file.Err != nil {
+			return file.Err
+		}
+		if *trace {
+			fmt.Printf("== %s\n", file.Path)
+			if err := checker.WriteTrace(os.Stdout, file.Result.Trace); err != nil {
+				return err
+			}
+		}
+		fileMethods := file.Result.Table.MethodCacheStats()
+		methods.Hits += fileMethods.Hits
+		methods.Misses += fileMethods.Misses
+		methods.Invalidations += fileMethods.Invalidations
+		for _, err := range file.Result.Errors {
+			fmt.Printf("%s:%v\n", file.Path, err)
+			var typeErr checker.TypeError
+			if !errors.As(err, &typeErr) || typeErr.Severity <= diagnostics.Error {
+				failed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "lyra: %d functions checked, %d reused from %s\n", cache.Checked, cache.Reused, *cacheDir)
+	}
+	if *stats {
+		fmt.Fprintf(os.Stderr, "lyra: %d method lookups, %d answered from the cache (%.0f%%), %d entries invalidated\n",
+			methods.Hits+methods.Misses, methods.Hits, 100*methods.HitRate(), methods.Invalidations)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d errors", failed)
+	}
+	return nil
+}
+
+// expandFiles replaces each dir/... argument with the .lyra files under dir, in
+// lexical order, skipping hidden directories
+func expandFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		dir, ok := strings.CutSuffix(filepath.ToSlash(arg), "/...")
+		if !ok {
+			files = append(files, arg)
+			continue
+		}
+		if dir == "" {
+			dir = "/"
+		}
+		err := filepath.WalkDir(filepath.FromSlash(dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && path != filepath.FromSlash(dir) && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.HasSuffix(path, ".lyra") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// moduleImports returns the files of the modules a file uses: the .lyra files of
+// the directory each names under the root of the file's project
+func moduleImports(path string, source []byte) []string {
+	root, err := project.FindRoot(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	collected, err := analyzer.Collect(source)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, stmt := range collected.Program.Statements {
+		if use, ok := stmt.(*ast.UseStmt); ok {
+			dir := filepath.Join(append([]string{root}, use.Module...)...)
+			matches, _ := filepath.Glob(filepath.Join(dir, "*.lyra"))
+			files = append(files, matches...)
+		}
+	}
+	return files
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// lyra delete [-w] <file> <line>:<col>
+func runDelete(args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: lyra delete [-w] <file> <line>:<col>")
+	}
+	path, position := flags.Arg(0), flags.Arg(1)
+
+	var line, col int
+	if _, err := fmt.Sscanf(position, "%d:%d", &line, &col); err != nil {
+		return fmt.Errorf("invalid position %q, expected <line>:<col>", position)
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return err
+	}
+
+	ref, ok := result.Index.ReferenceAt(line, col)
+	if !ok {
+		return fmt.Errorf("%s:%d:%d: no symbol found", path, line, col)
+	}
+	edits, blocking, err := refactor.SafeDelete(source, result.Program, result.Index, ref.Target)
+	if err != nil {
+		return err
+	}
+	if len(blocking) > 0 {
+		for _, r := range blocking {
+			where := "at module level"
+			if r.Enclosing != "" {
+				where = "in " + r.Enclosing
+			}
+			fmt.Printf("%s:%d:%d: %s %s\n", path, r.Location.StartLine, r.Location.StartCol, r.Kind, where)
+		}
+		return fmt.Errorf("%s is still used in %d places", ref.Target.Name, len(blocking))
+	}
+
+	deleted, err := refactor.Apply(source, edits)
+	if err != nil {
+		return err
+	}
+	if !*write {
+		_, err := os.Stdout.Write(deleted)
+		return err
+	}
+	return os.WriteFile(path, deleted, 0o644)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/configeval"
+)
+
+// lyra eval [--out=json|yaml] file.lyra
+func runEval(args []string) error {
+	flags := flag.NewFlagSet("eval", flag.ContinueOnError)
+	out := flags.String("out", "json", "output format: json or yaml")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: lyra eval [--out=json|yaml] file.lyra")
+	}
+	if *out != "json" && *out != "yaml" {
+		return fmt.Errorf("unknown output format %q: expected json or yaml", *out)
+	}
+
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	value, err := configeval.Evaluate(result)
+	if err != nil {
+		return fmt.Errorf("%s:%w", path, err)
+	}
+	if *out == "yaml" {
+		return configeval.WriteYAML(os.Stdout, value)
+	}
+	return configeval.WriteJSON(os.Stdout, value)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// lyra explain [-json] [code]
+func runExplain(args []string) error {
+	flags := flag.NewFlagSet("explain", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the explanation as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		for _, code := range diagnostics.Codes() {
+			explanation, _ := diagnostics.Explain(code)
+			fmt.Printf("%s  %s\n", code, explanation.Title)
+		}
+		return nil
+	}
+
+	explanation, err := diagnostics.Explain(diagnostics.Code(strings.ToUpper(flags.Arg(0))))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanation)
+	}
+	fmt.Print(explanation.Markdown())
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/export"
+)
+
+// lyra export-db [-o lyra.db | -sql] <files...>
+func runExportDB(args []string) error {
+	flags := flag.NewFlagSet("export-db", flag.ContinueOnError)
+	output := flags.String("o", "lyra.db", "SQLite database to create (requires the sqlite3 shell)")
+	sqlOnly := flags.Bool("sql", false, "print the SQL script to stdout instead of creating a database")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: lyra export-db [-o lyra.db | -sql] <files...>")
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	files := make([]export.File, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		file := export.File{Path: path, Result: result}
+		if absolute, err := filepath.Abs(path); err == nil {
+			if relative, err := filepath.Rel(root, absolute); err == nil {
+				file.Module = export.ModuleOf(filepath.ToSlash(relative))
+			}
+		}
+		files = append(files, file)
+	}
+
+	if *sqlOnly {
+		return export.WriteSQLite(os.Stdout, files)
+	}
+
+	var script bytes.Buffer
+	if err := export.WriteSQLite(&script, files); err != nil {
+		return err
+	}
+	if _, err := os.Stat(*output); err == nil {
+		return fmt.Errorf("%s already exists", *output)
+	}
+	sqlite := exec.Command("sqlite3", *output)
+	sqlite.Stdin = &script
+	sqlite.Stderr = os.Stderr
+	if err := sqlite.Run(); err != nil {
+		return fmt.Errorf("running sqlite3 (use -sql to write the script instead): %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/format"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// lyra fmt [-w] [-width n] [-types] <files...>
+func runFmt(args []string) error {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the files instead of stdout")
+	width := flags.Int("width", format.DefaultOptions.Width, "line width before signatures are wrapped")
+	canonicalTypes := flags.Bool("types", false, "also rewrite type annotations in canonical form (analyzes the files)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: lyra fmt [-w] [-width n] [-types] <files...>")
+	}
+
+	opts := format.DefaultOptions
+	opts.Width = *width
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted := source
+		if *canonicalTypes {
+			if formatted, err = canonicalAnnotations(source); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		formatted = format.Source(formatted, opts)
+		if !*write {
+			os.Stdout.Write(formatted)
+			continue
+		}
+		if bytes.Equal(source, formatted) {
+			continue
+		}
+		if err := os.WriteFile(path, formatted, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// canonicalAnnotations rewrites the type annotations of source in canonical form
+func canonicalAnnotations(source []byte) ([]byte, error) {
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return nil, err
+	}
+	edits, err := refactor.CanonicalAnnotations(source, result.Program)
+	if err != nil {
+		return nil, err
+	}
+	return refactor.Apply(source, edits)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/Lyra-Language/lyra/pkg/highlight"
+	"github.com/Lyra-Language/lyra/pkg/parser"
+)
+
+// lyra highlight-spec [-dir editors]
+func runHighlightSpec(args []string) error {
+	flags := flag.NewFlagSet("highlight-spec", flag.ContinueOnError)
+	dir := flags.String("dir", ".", "directory to write lyra.tmLanguage.json and highlights.scm to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	kinds, err := parser.NodeKinds()
+	if err != nil {
+		return err
+	}
+	spec := highlight.FromKinds(kinds)
+
+	var queries bytes.Buffer
+	if err := highlight.WriteQueries(&queries, spec); err != nil {
+		return err
+	}
+	grammar, err := json.MarshalIndent(highlight.TextMate(spec), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(*dir, "lyra.tmLanguage.json"), append(grammar, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(*dir, "highlights.scm"), queries.Bytes(), 0o644)
+}
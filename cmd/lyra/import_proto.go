@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Lyra-Language/lyra/pkg/protoimport"
+)
+
+// lyra import-proto [-o out.lyra] schema.proto
+func runImportProto(args []string) error {
+	flags := flag.NewFlagSet("import-proto", flag.ContinueOnError)
+	out := flags.String("o", "", "write the declarations to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: lyra import-proto [-o out.lyra] schema.proto")
+	}
+
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	file, err := protoimport.Parse(source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	declarations, err := protoimport.Generate(file, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("%s:\n%w", path, err)
+	}
+	if *out == "" {
+		_, err = fmt.Print(declarations)
+		return err
+	}
+	return os.WriteFile(*out, []byte(declarations), 0o644)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/export"
+)
+
+// lyra index [-format scip] [-o index.scip] <files...>
+func runIndex(args []string) error {
+	flags := flag.NewFlagSet("index", flag.ContinueOnError)
+	format := flags.String("format", "scip", "index format (scip)")
+	output := flags.String("o", "index.scip", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "scip" {
+		return fmt.Errorf("unsupported index format %q", *format)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("usage: lyra index [-format scip] [-o index.scip] <files...>")
+	}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	files := make([]export.File, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, export.File{Path: filepath.ToSlash(relative), Module: export.ModuleOf(filepath.ToSlash(relative)), Result: result})
+	}
+
+	out, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := export.WriteSCIP(out, "file://"+filepath.ToSlash(root), files); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/lint"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// lyra lint [-config lyra-lint.json] [-fix] files...
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	configPath := flags.String("config", lint.DefaultConfigFile, "lint config file")
+	fix := flags.Bool("fix", false, "apply the automatic fixes and rewrite the files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: lyra lint [-config lyra-lint.json] [-fix] files...")
+	}
+	config, err := lint.LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	warnings := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		var edits []refactor.TextEdit
+		for _, diagnostic := range lint.Run(result, config) {
+			if *fix && diagnostic.Fix != nil {
+				edits = append(edits, diagnostic.Fix.Edits...)
+				continue
+			}
+			fmt.Printf("%s:%v\n", path, diagnostic)
+			warnings++
+		}
+		if len(edits) > 0 {
+			fixed, err := refactor.Apply(source, edits)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := os.WriteFile(path, fixed, 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	if warnings > 0 {
+		return fmt.Errorf("%d lint warnings", warnings)
+	}
+	return nil
+}
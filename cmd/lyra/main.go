@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"ast", "print the AST of a file (-typed adds checked types)", runAST},
+	{"refs", "list references to the symbol at a position", runRefs},
+	{"delete", "delete the function or variable at a position if it is unused", runDelete},
+	{"export-db", "export symbols, references and calls to SQLite", runExportDB},
+	{"index", "write a code intelligence index (SCIP)", runIndex},
+	{"fmt", "format source files (-types also canonicalizes annotations)", runFmt},
+	{"check", "type check files, or every file under dir/... in parallel (--trace prints every checker decision, --cache reuses unchanged functions)", runCheck},
+	{"explain", "explain a diagnostic code (e.g. LYR0012)", runExplain},
+	{"test", "run the test_ functions of Lyra files", runTest},
+	{"repl", "evaluate declarations and expressions interactively", runREPL},
+	{"script", "evaluate a file showing the value and type of each top-level expression", runScript},
+	{"eval", "evaluate a configuration file to JSON or YAML (--out=yaml)", runEval},
+	{"schema", "generate a JSON Schema from struct and data types", runSchema},
+	{"import-proto", "generate struct and data declarations from a .proto schema", runImportProto},
+	{"highlight-spec", "generate a TextMate grammar and highlights.scm from the parser", runHighlightSpec},
+	{"lint", "report likely maintenance problems", runLint},
+	{"metrics", "report function complexity metrics (-json for dashboards)", runMetrics},
+	{"traits", "report which types derive, implement or miss each trait (-json for the editor)", runTraits},
+	{"api", "print the public API of a module, own and re-exported symbols with their signatures", runAPI},
+	{"apidiff", "compare the public API of two versions of a module", runAPIDiff},
+	{"publish", "check a release against the package manifest (--check)", runPublish},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args); err != nil {
+				fmt.Fprintln(os.Stderr, "lyra:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "lyra: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lyra <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/lint"
+)
+
+// fileMetrics is the JSON report of a file
+type fileMetrics struct {
+	Path      string                 `json:"path"`
+	Functions []lint.FunctionMetrics `json:"functions"`
+}
+
+// lyra metrics [-json] files...
+func runMetrics(args []string) error {
+	flags := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the metrics as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: lyra metrics [-json] files...")
+	}
+
+	var report []fileMetrics
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		report = append(report, fileMetrics{Path: path, Functions: lint.Metrics(result.Program)})
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FUNCTION\tCOMPLEXITY\tCLAUSES\tNESTING\tPARAMETERS")
+	for _, file := range report {
+		for _, m := range file.Functions {
+			fmt.Fprintf(w, "%s:%d %s\t%d\t%d\t%d\t%d\n", file.Path, m.Line, m.Name, m.Complexity, m.Clauses, m.Nesting, m.Parameters)
+		}
+	}
+	return w.Flush()
+}
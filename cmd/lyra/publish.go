@@ -0,0 +1,133 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/apidiff"
+	"github.com/Lyra-Language/lyra/pkg/manifest"
+)
+
+// lyra publish --check [dir]
+func runPublish(args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	check := flags.Bool("check", false, "check the manifest version against the API changes since the last release")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("usage: lyra publish --check [dir]")
+	}
+	if !*check {
+		return errors.New("there is no package registry to publish to yet; use --check to verify the version")
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+
+	m, err := manifest.Load(filepath.Join(dir, manifest.FileName))
+	if err != nil {
+		return err
+	}
+	tags, err := git(dir, "tag", "--list", "v*")
+	if err != nil {
+		return err
+	}
+	tagList := strings.Fields(string(tags))
+	for _, tag := range tagList {
+		if version, err := manifest.ParseVersion(tag); err == nil && version == m.Version {
+			return fmt.Errorf("%s %s is already released (tag %s)", m.Name, m.Version, tag)
+		}
+	}
+	last, tag, ok := m.Version.LastRelease(tagList)
+	if !ok {
+		fmt.Printf("%s %s: first release, nothing to compare\n", m.Name, m.Version)
+		return nil
+	}
+
+	released, err := os.MkdirTemp("", "lyra-release-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(released)
+	if err := extractRelease(dir, tag, released); err != nil {
+		return err
+	}
+	old, err := apidiff.LoadDir(released)
+	if err != nil {
+		return fmt.Errorf("%s: %w", tag, err)
+	}
+	new, err := apidiff.LoadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	changes := apidiff.Diff(old, new)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if apidiff.Breaking(changes) && !m.Version.IsMajorBump(last) {
+		return fmt.Errorf("breaking API changes since %s need a major version bump, not %s", last, m.Version)
+	}
+	fmt.Printf("%s %s: version is compatible with the changes since %s\n", m.Name, m.Version, last)
+	return nil
+}
+
+// extractRelease writes the files of dir at tag to target
+func extractRelease(dir, tag, target string) error {
+	prefix, err := git(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return err
+	}
+	archive, err := git(dir, "archive", "--format=tar", tag+":"+strings.TrimSpace(string(prefix)))
+	if err != nil {
+		return err
+	}
+	reader := tar.NewReader(bytes.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".lyra") {
+			continue
+		}
+		path := filepath.Join(target, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(target)+string(filepath.Separator)) {
+			return fmt.Errorf("%s: invalid path %s", tag, header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
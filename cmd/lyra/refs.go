@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+)
+
+// lyra refs [-kind read,write,call,definition] <file> <line>:<col>
+func runRefs(args []string) error {
+	flags := flag.NewFlagSet("refs", flag.ContinueOnError)
+	kindList := flags.String("kind", "", "comma-separated reference kinds to keep (definition, read, write, call)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return fmt.Errorf("usage: lyra refs [-kind kinds] <file> <line>:<col>")
+	}
+	path, position := flags.Arg(0), flags.Arg(1)
+
+	var kinds []refs.Kind
+	if *kindList != "" {
+		for _, name := range strings.Split(*kindList, ",") {
+			kind, err := refs.ParseKind(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	var line, col int
+	if _, err := fmt.Sscanf(position, "%d:%d", &line, &col); err != nil {
+		return fmt.Errorf("invalid position %q, expected <line>:<col>", position)
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return err
+	}
+
+	ref, ok := result.Index.ReferenceAt(line, col)
+	if !ok {
+		return fmt.Errorf("%s:%d:%d: no symbol found", path, line, col)
+	}
+	for _, r := range result.Index.References(ref.Target, kinds...) {
+		fmt.Printf("%s:%d:%d: %s\n", path, r.Location.StartLine, r.Location.StartCol, r.Kind)
+	}
+	return nil
+}
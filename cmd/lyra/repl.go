@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/Lyra-Language/lyra/pkg/repl"
+)
+
+// lyra repl [-history file]
+func runREPL(args []string) error {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	historyPath := flags.String("history", defaultHistoryPath(), "file keeping previous entries (empty for none)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: lyra repl [-history file]")
+	}
+	history, err := repl.LoadHistory(*historyPath)
+	if err != nil {
+		return err
+	}
+	return repl.New(os.Stdin, os.Stdout, history).Run()
+}
+
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".lyra_history")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/jsonschema"
+)
+
+// lyra schema [-types A,B] file.lyra
+func runSchema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ContinueOnError)
+	selected := flags.String("types", "", "comma-separated types to describe (default: every pub struct and data type)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: lyra schema [-types A,B] file.lyra")
+	}
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := result.FirstError(); err != nil {
+		return fmt.Errorf("%s:%w", path, err)
+	}
+
+	names := jsonschema.PublicTypes(result.Table)
+	if *selected != "" {
+		names = strings.Split(*selected, ",")
+	}
+	schema, err := jsonschema.Generate(result.Table, names...)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
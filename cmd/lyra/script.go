@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// lyra script file.lyra
+//
+// Evaluates a file top to bottom like a notebook: every top-level expression
+// statement is printed followed by a comment with its value and checked type.
+func runScript(args []string) error {
+	flags := flag.NewFlagSet("script", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: lyra script file.lyra")
+	}
+	path := flags.Arg(0)
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := result.FirstError(); err != nil {
+		return fmt.Errorf("%s:%w", path, err)
+	}
+
+	in := interp.New(result.Program, result.Table)
+	in.SetOutput(os.Stdout)
+	in.SetOwnership(result.Ownership)
+	in.ObserveExpressions(func(stmt *ast.ExpressionStmt, value interp.Value) {
+		fmt.Println(annotate(source, stmt, value))
+	})
+	if err := in.Init(); err != nil {
+		return fmt.Errorf("%s:%w", path, err)
+	}
+	return nil
+}
+
+// annotate renders an expression statement as `source // value : Type`
+func annotate(source []byte, stmt *ast.ExpressionStmt, value interp.Value) string {
+	text, err := refactor.Text(source, stmt.Expression.GetLocation())
+	if err != nil {
+		text = "?"
+	}
+	typeName := "?"
+	if t := stmt.Expression.GetType(); t != nil {
+		typeName = t.GetName()
+	}
+	return fmt.Sprintf("%d: %s // %s : %s", stmt.Location.StartLine, strings.TrimSpace(text), interp.FormatValue(value), typeName)
+}
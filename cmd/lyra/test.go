@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/coverage"
+	"github.com/Lyra-Language/lyra/pkg/testrunner"
+)
+
+// lyra test [-run regexp] [-junit report.xml] [-coverprofile lcov.info] [-parallel n] [-v] [-json] [files...]
+func runTest(args []string) error {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	run := flags.String("run", "", "only run tests whose name matches the regular expression")
+	junit := flags.String("junit", "", "write a JUnit XML report to this file")
+	coverprofile := flags.String("coverprofile", "", "write an lcov coverage report to this file")
+	parallel := flags.Int("parallel", runtime.GOMAXPROCS(0), "maximum pure tests run at once")
+	verbose := flags.Bool("v", false, "print every test, not just failures")
+	jsonOutput := flags.Bool("json", false, "print the results as JSON lines instead of text")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := testrunner.Options{Parallel: *parallel, Cover: *coverprofile != ""}
+	if *run != "" {
+		pattern, err := regexp.Compile(*run)
+		if err != nil {
+			return fmt.Errorf("invalid -run pattern: %w", err)
+		}
+		opts.Run = pattern
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		found, err := lyraFiles(".")
+		if err != nil {
+			return err
+		}
+		paths = found
+	}
+
+	var results []testrunner.FileResult
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		analysis, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		result := testrunner.RunFile(path, analysis, opts)
+		if result.Err == nil && len(result.Tests) == 0 {
+			continue
+		}
+		if !*jsonOutput {
+			report(result, *verbose)
+		}
+		results = append(results, result)
+	}
+
+	if *jsonOutput {
+		if err := testrunner.WriteJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	}
+
+	if *junit != "" {
+		out, err := os.Create(*junit)
+		if err != nil {
+			return err
+		}
+		if err := testrunner.WriteJUnit(out, results); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+
+	if *coverprofile != "" {
+		if err := writeCoverage(*coverprofile, results); err != nil {
+			return err
+		}
+	}
+
+	for _, result := range results {
+		if !result.Passed() {
+			return errors.New("tests failed")
+		}
+	}
+	return nil
+}
+
+// writeCoverage writes the coverage of results as lcov, keyed by absolute path
+// so editors can match it to open documents
+func writeCoverage(path string, results []testrunner.FileResult) error {
+	var files []coverage.File
+	for _, result := range results {
+		if result.Coverage == nil {
+			continue
+		}
+		source, err := filepath.Abs(result.Path)
+		if err != nil {
+			return err
+		}
+		files = append(files, coverage.File{Path: source, Profile: result.Coverage})
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := coverage.WriteLCOV(out, files); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func report(file testrunner.FileResult, verbose bool) {
+	if file.Err != nil {
+		fmt.Printf("FAIL\t%s\t%.3fs\n\t%v\n", file.Path, file.Duration.Seconds(), file.Err)
+		return
+	}
+	for _, test := range file.Tests {
+		switch {
+		case !test.Passed():
+			fmt.Printf("--- FAIL: %s (%.3fs)\n\t%v\n", test.Name, test.Duration.Seconds(), test.Err)
+		case verbose:
+			fmt.Printf("--- PASS: %s (%.3fs)\n", test.Name, test.Duration.Seconds())
+		}
+	}
+	status := "ok"
+	if !file.Passed() {
+		status = "FAIL"
+	}
+	fmt.Printf("%s\t%s\t%.3fs\n", status, file.Path, file.Duration.Seconds())
+}
+
+// lyraFiles lists the .lyra files under root
+func lyraFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".lyra") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
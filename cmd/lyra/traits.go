@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// fileTraits is the JSON report of a file, in the shape of the lyra/traitMatrix
+// view of the language server
+type fileTraits struct {
+	Path   string      `json:"path"`
+	Traits []string    `json:"traits"`
+	Rows   []traitsRow `json:"rows"`
+}
+
+type traitsRow struct {
+	Type   string                         `json:"type"`
+	Line   int                            `json:"line"`
+	Status map[string]checker.TraitStatus `json:"status"` // trait -> derived, implemented or missing
+}
+
+// lyra traits [-json] files...
+func runTraits(args []string) error {
+	flags := flag.NewFlagSet("traits", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the matrix as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: lyra traits [-json] files...")
+	}
+
+	var report []fileTraits
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		file := fileTraits{Path: path, Traits: checker.DerivableTraits, Rows: []traitsRow{}}
+		for _, stmt := range result.Program.Statements {
+			decl, ok := stmt.(*ast.TypeDeclStmt)
+			if !ok {
+				continue
+			}
+			row := traitsRow{Type: decl.Name, Line: decl.Location.StartLine, Status: make(map[string]checker.TraitStatus)}
+			for _, trait := range file.Traits {
+				row.Status[trait] = checker.TraitStatusOf(result.Table, decl, trait)
+			}
+			file.Rows = append(file.Rows, row)
+		}
+		report = append(report, file)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\t"+strings.Join(checker.DerivableTraits, "\t"))
+	for _, file := range report {
+		for _, row := range file.Rows {
+			fmt.Fprintf(w, "%s:%d %s", file.Path, row.Line, row.Type)
+			for _, trait := range file.Traits {
+				fmt.Fprintf(w, "\t%s", row.Status[trait])
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,206 @@
+package analyzer
+
+/*
+Analyzer runs the front-end passes over a single source file:
+parse -> collect (AST + symbol table) -> check (expression types) -> ownership (moves and
+drop points) -> reference index.
+Both the CLI and the language server go through here so they see the same results.
+*/
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/collector"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/ownership"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/resolver"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/parser"
+)
+
+// Result holds everything known about an analyzed source file
+type Result struct {
+	Source    []byte
+	Program   *ast.Program
+	Table     *symbols.SymbolTable
+	Index     *refs.Index
+	Ownership *ownership.Analysis
+	Errors    []error
+	Trace     []checker.TraceEntry // decisions of the checker; only recorded by AnalyzeTraced
+	Matches   []checker.Match      // functions matching a data type by constructor
+}
+
+// Prelude holds declarations supplied by a Go program embedding Lyra: type
+// declarations and bindings of host values (ast.HostValueExpr). The source sees
+// them as if they were declared before it.
+type Prelude []ast.AstNode
+
+// Analyze parses, collects and checks source, tracks ownership, then indexes its references
+func Analyze(source []byte) (*Result, error) {
+	return AnalyzeWith(source, nil)
+}
+
+// AnalyzeWith is Analyze with the declarations of a prelude in scope
+func AnalyzeWith(source []byte, prelude Prelude) (*Result, error) {
+	return analyze(context.Background(), source, prelude, checking{}, nil)
+}
+
+// AnalyzeContext is Analyze giving up with ctx.Err() once ctx is done, which is
+// checked between passes and between the top-level statements the checker
+// checks, for callers that may no longer want the result
+func AnalyzeContext(ctx context.Context, source []byte) (*Result, error) {
+	return analyze(ctx, source, nil, checking{}, nil)
+}
+
+// AnalyzeInteractive is AnalyzeContext checking large array literals in part
+// (see checker.Checker.SampleArrays), for editors analyzing a file as it is typed
+func AnalyzeInteractive(ctx context.Context, source []byte) (*Result, error) {
+	return analyze(ctx, source, nil, checking{sample: true}, nil)
+}
+
+// AnalyzeTraced is Analyze recording every decision of the checker in Result.Trace,
+// for debugging surprising inference
+func AnalyzeTraced(source []byte) (*Result, error) {
+	return analyze(context.Background(), source, nil, checking{trace: true}, nil)
+}
+
+// checking says how checkProgram runs the checker
+type checking struct {
+	trace  bool // record every decision in Result.Trace
+	sample bool // check large array literals in part
+}
+
+func analyze(ctx context.Context, source []byte, prelude Prelude, mode checking, cache *Cache) (*Result, error) {
+	collected, err := collect(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(prelude) > 0 {
+		collected.Errors = append(collected.Errors, declarePrelude(collected.Program, collected.Table, prelude)...)
+	}
+	return checkProgram(ctx, source, collected.Program, collected.Table, collected.Errors, mode, cache)
+}
+
+// Collected is a source file parsed and collected but not yet checked, for
+// callers checking several files against one symbol table
+type Collected struct {
+	Source  []byte
+	Program *ast.Program
+	Table   *symbols.SymbolTable // the declarations of this file alone
+	Errors  []error
+}
+
+// Collect parses and collects source without checking it
+func Collect(source []byte) (*Collected, error) {
+	return collect(context.Background(), source)
+}
+
+func collect(ctx context.Context, source []byte) (*Collected, error) {
+	tree, err := parser.Parse(string(source))
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	program, table, errs := collector.NewCollector(source).Collect(tree.RootNode())
+	return &Collected{Source: source, Program: program, Table: table, Errors: errs}, nil
+}
+
+// CheckWith checks a collected file against table, which declares everything
+// the file may use, then tracks ownership and indexes its references
+func (c *Collected) CheckWith(table *symbols.SymbolTable) *Result {
+	result, _ := checkProgram(context.Background(), c.Source, c.Program, table, c.Errors, checking{}, nil)
+	return result
+}
+
+// checkProgram resolves the type references of a collected program and runs the
+// checker and ownership analysis on it, adding what they report to the
+// collector's errors. It gives up with ctx.Err() once ctx is done.
+func checkProgram(ctx context.Context, source []byte, program *ast.Program, table *symbols.SymbolTable, errs []error, mode checking, cache *Cache) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	errs = append(errs, resolver.Resolve(program, table)...)
+	check := checker.NewChecker(program, table)
+	if mode.trace {
+		check.EnableTrace()
+	}
+	if mode.sample {
+		check.SampleArrays()
+	}
+	var cacheable map[*ast.FunctionDefStmt]string
+	if cache != nil {
+		cacheable = cache.reuse(source, program, refs.Build(program, table), check)
+	}
+	typeErrors, err := check.CheckContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, typeError := range typeErrors {
+		errs = append(errs, typeError)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	owned := ownership.Analyze(program)
+	for _, moveError := range owned.Errors {
+		errs = append(errs, moveError)
+	}
+	if cache != nil {
+		errs = cache.merge(errs, cacheable, table)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return &Result{
+		Source:    source,
+		Program:   program,
+		Table:     table,
+		Index:     refs.Build(program, table),
+		Ownership: owned,
+		Errors:    errs,
+		Trace:     check.Trace(),
+		Matches:   check.Matches(),
+	}, nil
+}
+
+// FirstError returns the first error that stops the program from running,
+// ignoring warnings and informational diagnostics
+func (r *Result) FirstError() error {
+	for _, err := range r.Errors {
+		var typeErr checker.TypeError
+		if errors.As(err, &typeErr) && typeErr.Severity > diagnostics.Error {
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
+// declarePrelude registers the prelude and puts it ahead of the program so its
+// bindings are evaluated first
+func declarePrelude(program *ast.Program, table *symbols.SymbolTable, prelude Prelude) []error {
+	var errs []error
+	for _, decl := range prelude {
+		var err error
+		switch d := decl.(type) {
+		case *ast.TypeDeclStmt:
+			err = table.RegisterType(d)
+		case *ast.VarDeclStmt:
+			err = table.RegisterVariable(d)
+		case *ast.FunctionDefStmt:
+			err = table.RegisterFunction(d)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	program.Statements = append(append([]ast.AstNode{}, prelude...), program.Statements...)
+	return errs
+}
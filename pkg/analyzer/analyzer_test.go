@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// countdown is a context cancelled once Err has been asked left times
+type countdown struct {
+	context.Context
+	left int
+}
+
+func (c *countdown) Err() error {
+	if c.left == 0 {
+		return context.Canceled
+	}
+	c.left--
+	return nil
+}
+
+func TestCheckProgram_CancelledWhileChecking(t *testing.T) {
+	source, program, table := mathProgram(t, 0, "n")
+	// asked before resolving and before checking half, cancelled before quarter
+	ctx := &countdown{Context: context.Background(), left: 2}
+	result, err := checkProgram(ctx, source, program, table, nil, checking{}, nil)
+	if !errors.Is(err, context.Canceled) || result != nil {
+		t.Fatalf("Expected the check to give up with context.Canceled. Got %v, %v", result, err)
+	}
+	body := func(i int) ast.Expression { return program.Statements[i].(*ast.FunctionDefStmt).Clauses[0].Body }
+	if body(0).GetType() == nil {
+		t.Fatalf("Expected half to be checked before the cancellation")
+	}
+	if body(1).GetType() != nil || body(2).GetType() != nil {
+		t.Fatalf("Expected quarter and bad to be left unchecked. Got %v and %v", body(1).GetType(), body(2).GetType())
+	}
+}
@@ -0,0 +1,268 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FileResult is the analysis of one file of a batch; Err is set if the file
+// could not be read or parsed, or is part of an import cycle
+type FileResult struct {
+	Path   string
+	Result *Result
+	Err    error
+}
+
+// Importer returns the paths of the files a file imports from with use, given
+// the file's path and source. Paths outside the batch are ignored.
+type Importer func(path string, source []byte) []string
+
+// ImportCycleError is a file that imports itself through other files: Files
+// lists them from the file back to it
+type ImportCycleError struct {
+	Files []string
+}
+
+func (e ImportCycleError) Error() string {
+	return "import cycle: " + strings.Join(e.Files, " -> ")
+}
+
+// AnalyzeFiles analyzes files on workers goroutines and calls report with each
+// file's result in the order of files, as soon as it and every file before it
+// are done, so the output is the same whatever the number of workers. A file is
+// analyzed after the files it imports, as imports lists them; of the files
+// ready, the earliest is analyzed first, being reported first. The files of an
+// import cycle are reported with an ImportCycleError and not analyzed. It stops
+// at the first error report returns.
+func AnalyzeFiles(files []string, workers int, analyze func(source []byte) (*Result, error), imports Importer, report func(FileResult) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	results := make([]FileResult, len(files))
+	sources := make([][]byte, len(files))
+	for i, path := range files {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			results[i] = FileResult{Path: path, Err: err}
+			continue
+		}
+		sources[i] = source
+	}
+	deps := importGraph(files, sources, imports)
+	for i, cycle := range importCycles(deps) {
+		if cycle == nil || results[i].Err != nil {
+			continue
+		}
+		paths := make([]string, len(cycle))
+		for k, j := range cycle {
+			paths[k] = files[j]
+		}
+		results[i] = FileResult{Path: files[i], Err: fmt.Errorf("%s: %w", files[i], ImportCycleError{Files: paths})}
+	}
+
+	s := newSchedule(deps)
+	done := make(chan int, len(files))
+	for i := range files {
+		if results[i].Err != nil {
+			s.finish(i)
+			done <- i
+		}
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i, ok := s.next()
+				if !ok {
+					return
+				}
+				results[i] = analyzeSource(files[i], sources[i], analyze)
+				s.finish(i)
+				done <- i
+			}
+		}()
+	}
+
+	var err error
+	finished := make([]bool, len(files))
+	next := 0
+	for range files {
+		if err != nil {
+			break
+		}
+		finished[<-done] = true
+		for ; next < len(files) && finished[next]; next++ {
+			if err = report(results[next]); err != nil {
+				s.stop()
+				break
+			}
+		}
+	}
+	wg.Wait()
+	return err
+}
+
+func analyzeSource(path string, source []byte, analyze func(source []byte) (*Result, error)) FileResult {
+	result, err := analyze(source)
+	if err != nil {
+		return FileResult{Path: path, Err: fmt.Errorf("%s: %w", path, err)}
+	}
+	return FileResult{Path: path, Result: result}
+}
+
+// importGraph returns the indexes of the files each file imports, in order and
+// without itself. Paths are compared absolute.
+func importGraph(files []string, sources [][]byte, imports Importer) [][]int {
+	deps := make([][]int, len(files))
+	if imports == nil {
+		return deps
+	}
+	index := make(map[string]int, len(files))
+	for i, path := range files {
+		index[absolute(path)] = i
+	}
+	for i, path := range files {
+		if sources[i] == nil {
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, imported := range imports(path, sources[i]) {
+			if j, ok := index[absolute(imported)]; ok && j != i && !seen[j] {
+				seen[j] = true
+				deps[i] = append(deps[i], j)
+			}
+		}
+		sort.Ints(deps[i])
+	}
+	return deps
+}
+
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// importCycles returns, for each file importing itself through others, the
+// shortest such cycle from the file back to it, nil for the other files
+func importCycles(deps [][]int) [][]int {
+	cycles := make([][]int, len(deps))
+	for start := range deps {
+		// breadth first from start, following imports, until one leads back
+		from := map[int]int{start: -1}
+		pending := []int{start}
+		for len(pending) > 0 && cycles[start] == nil {
+			i := pending[0]
+			pending = pending[1:]
+			for _, j := range deps[i] {
+				if j == start {
+					cycle := []int{start}
+					for k := i; k != start; k = from[k] {
+						cycle = append(cycle, k)
+					}
+					cycle = append(cycle[:1], reversed(cycle[1:])...)
+					cycles[start] = append(cycle, start)
+					break
+				}
+				if _, seen := from[j]; !seen {
+					from[j] = i
+					pending = append(pending, j)
+				}
+			}
+		}
+	}
+	return cycles
+}
+
+func reversed(indexes []int) []int {
+	out := make([]int, len(indexes))
+	for i, index := range indexes {
+		out[len(indexes)-1-i] = index
+	}
+	return out
+}
+
+// schedule hands out the files of a batch once the files they import are done,
+// the earliest ready first
+type schedule struct {
+	mu         sync.Mutex
+	ready      *sync.Cond
+	waiting    []int   // files each file still waits for
+	dependents [][]int // files importing each file
+	queued     []int   // ready and not handed out, in order
+	handed     []bool  // handed out or finished without analysis
+	left       int     // files not finished
+	stopped    bool
+}
+
+func newSchedule(deps [][]int) *schedule {
+	s := &schedule{waiting: make([]int, len(deps)), dependents: make([][]int, len(deps)), handed: make([]bool, len(deps)), left: len(deps)}
+	s.ready = sync.NewCond(&s.mu)
+	for i, imported := range deps {
+		s.waiting[i] = len(imported)
+		for _, j := range imported {
+			s.dependents[j] = append(s.dependents[j], i)
+		}
+	}
+	for i := range deps {
+		if s.waiting[i] == 0 {
+			s.queued = append(s.queued, i)
+		}
+	}
+	return s
+}
+
+// next waits for a ready file, reporting false once none are left or the
+// batch is stopped
+func (s *schedule) next() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.stopped || s.left == 0 {
+			return 0, false
+		}
+		for len(s.queued) > 0 {
+			i := s.queued[0]
+			s.queued = s.queued[1:]
+			if !s.handed[i] {
+				s.handed[i] = true
+				return i, true
+			}
+		}
+		s.ready.Wait()
+	}
+}
+
+// finish marks file i done, readying the files waiting only for it
+func (s *schedule) finish(i int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handed[i] = true
+	s.left--
+	for _, dependent := range s.dependents[i] {
+		if s.waiting[dependent]--; s.waiting[dependent] == 0 {
+			s.queued = append(s.queued, dependent)
+			sort.Ints(s.queued)
+		}
+	}
+	s.ready.Broadcast()
+}
+
+// stop ends the batch, no more files being handed out
+func (s *schedule) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	s.ready.Broadcast()
+}
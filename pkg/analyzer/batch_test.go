@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeFiles_ReportsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, strconv.Itoa(i)+".lyra")
+		// later files are quicker, so with several workers they finish first
+		if err := os.WriteFile(path, []byte(strconv.Itoa(20-i)), 0o644); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+		files = append(files, path)
+	}
+	analyze := func(source []byte) (*Result, error) {
+		delay, _ := strconv.Atoi(string(source))
+		time.Sleep(time.Duration(delay) * time.Millisecond)
+		return &Result{Source: source}, nil
+	}
+
+	for _, workers := range []int{1, 4, 50} {
+		var reported []string
+		err := AnalyzeFiles(files, workers, analyze, nil, func(file FileResult) error {
+			if file.Err != nil {
+				return file.Err
+			}
+			reported = append(reported, file.Path)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("AnalyzeFiles error with %d workers: %v", workers, err)
+		}
+		if len(reported) != len(files) {
+			t.Fatalf("Expected %d files reported with %d workers. Got %d", len(files), workers, len(reported))
+		}
+		for i := range files {
+			if reported[i] != files[i] {
+				t.Fatalf("Expected file %d to be %s with %d workers. Got %s", i, files[i], workers, reported[i])
+			}
+		}
+	}
+}
+
+func TestAnalyzeFiles_StopsAtReportError(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{filepath.Join(dir, "missing.lyra"), filepath.Join(dir, "other.lyra")}
+	calls := 0
+	err := AnalyzeFiles(files, 2, Analyze, nil, func(file FileResult) error {
+		calls++
+		return file.Err
+	})
+	if !errors.Is(err, os.ErrNotExist) || calls != 1 {
+		t.Fatalf("Expected to stop at the missing first file. Got %v after %d reports", err, calls)
+	}
+}
+
+// importingFiles writes a file per name, its source the names of the files it
+// imports, one per line, returning the paths and an Importer reading them
+func importingFiles(t *testing.T, imports map[string][]string, names ...string) ([]string, Importer) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range names {
+		path := filepath.Join(dir, name+".lyra")
+		if err := os.WriteFile(path, []byte(strings.Join(imports[name], "\n")), 0o644); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+		files = append(files, path)
+	}
+	importer := func(path string, source []byte) []string {
+		var paths []string
+		for _, name := range strings.Fields(string(source)) {
+			paths = append(paths, filepath.Join(dir, name+".lyra"))
+		}
+		return paths
+	}
+	return files, importer
+}
+
+func TestAnalyzeFiles_ImportsFirst(t *testing.T) {
+	// main imports shapes and util, shapes imports util
+	files, importer := importingFiles(t, map[string][]string{"main": {"shapes", "util"}, "shapes": {"util"}}, "main", "shapes", "util")
+	for _, workers := range []int{1, 4} {
+		var mu sync.Mutex
+		var analyzed []string
+		analyze := func(source []byte) (*Result, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			analyzed = append(analyzed, string(source))
+			return &Result{Source: source}, nil
+		}
+		var reported []string
+		err := AnalyzeFiles(files, workers, analyze, importer, func(file FileResult) error {
+			reported = append(reported, filepath.Base(file.Path))
+			return file.Err
+		})
+		if err != nil {
+			t.Fatalf("AnalyzeFiles error with %d workers: %v", workers, err)
+		}
+		// util imports nothing, shapes imports util and main imports both
+		expected := []string{"", "util", "shapes\nutil"}
+		if strings.Join(analyzed, "|") != strings.Join(expected, "|") {
+			t.Fatalf("Expected util, shapes then main analyzed with %d workers. Got sources %q", workers, analyzed)
+		}
+		if strings.Join(reported, " ") != "main.lyra shapes.lyra util.lyra" {
+			t.Fatalf("Expected the files reported in the order given with %d workers. Got %v", workers, reported)
+		}
+	}
+}
+
+func TestAnalyzeFiles_ReportsImportCycles(t *testing.T) {
+	// a imports b, b imports a, c imports a
+	files, importer := importingFiles(t, map[string][]string{"a": {"b"}, "b": {"a"}, "c": {"a"}}, "a", "b", "c")
+	analyze := func(source []byte) (*Result, error) { return &Result{Source: source}, nil }
+	results := make(map[string]FileResult)
+	err := AnalyzeFiles(files, 2, analyze, importer, func(file FileResult) error {
+		results[filepath.Base(file.Path)] = file
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeFiles error: %v", err)
+	}
+	for name, cycle := range map[string][]string{"a.lyra": {files[0], files[1], files[0]}, "b.lyra": {files[1], files[0], files[1]}} {
+		var cycleErr ImportCycleError
+		if !errors.As(results[name].Err, &cycleErr) || strings.Join(cycleErr.Files, " ") != strings.Join(cycle, " ") {
+			t.Fatalf("Expected %s to report the cycle %v. Got %v", name, cycle, results[name].Err)
+		}
+		if results[name].Result != nil {
+			t.Fatalf("Expected %s not to be analyzed", name)
+		}
+	}
+	if results["c.lyra"].Err != nil || results["c.lyra"].Result == nil {
+		t.Fatalf("Expected c, importing the cycle, to be analyzed. Got %v", results["c.lyra"].Err)
+	}
+}
@@ -0,0 +1,477 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/ownership"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// cacheVersion is bumped whenever the layout of the cache file changes
+const cacheVersion = 2
+
+// buildKey identifies the build of lyra, so that a cache is only read by the
+// checker that wrote it: the module version of a release, the commit of a build
+// from a clean checkout, or else the hash of the executable
+var buildKey = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.GoVersion + " " + info.Main.Version
+	}
+	if ok {
+		var revision string
+		modified := true
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if revision != "" && !modified {
+			return info.GoVersion + " " + revision
+		}
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(executable)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+})
+
+// CacheFile is the name of the cache file in the cache directory
+const CacheFile = "check-cache.json"
+
+// Cache holds what earlier checks reported for each function, keyed by the
+// function's fingerprint, so a warm run can skip functions that have not
+// changed. A fingerprint covers the function's own text, the text of the
+// module's other declarations and the signatures of all functions, and the text
+// of every function it refers to, transitively. A cache may be shared by
+// analyses running in parallel.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string][]cachedError
+	used    map[string]bool
+
+	Reused  int // functions whose diagnostics came from the cache
+	Checked int // functions checked and added to the cache
+}
+
+// cachedError is a type or move error of a function, its locations made relative
+// to the function's first line so that moving the function does not invalidate it
+type cachedError struct {
+	Move     bool                 `json:"move,omitempty"`
+	Code     diagnostics.Code     `json:"code,omitempty"`
+	Severity diagnostics.Severity `json:"severity,omitempty"`
+	Message  string               `json:"message,omitempty"`
+	Name     string               `json:"name,omitempty"` // moved value
+	Location ast.Location         `json:"location"`
+	Moved    ast.Location         `json:"moved,omitempty"`
+	Expected *cachedType          `json:"expected,omitempty"`
+	Actual   *cachedType          `json:"actual,omitempty"`
+	Related  []checker.Related    `json:"related,omitempty"`
+}
+
+// cachedType is a type an error expected or found: its kind and the parts of
+// that kind, a signature being a function type declared by a function. Struct
+// and data types are kept by name and looked up again in the symbol table of
+// the program being checked.
+type cachedType struct {
+	Kind      string           `json:"kind"`
+	Name      string           `json:"name,omitempty"`
+	ID        int              `json:"id,omitempty"`       // of a type variable
+	Elements  []*cachedType    `json:"elements,omitempty"` // of an array, tuple or function parameters
+	Modifiers []types.Modifier `json:"modifiers,omitempty"`
+	Returns   *cachedType      `json:"returns,omitempty"`
+}
+
+// cacheType stores t, nil if t is nil or of a kind the cache cannot store
+func cacheType(t types.Type) *cachedType {
+	switch t := t.(type) {
+	case types.PrimitiveType:
+		return &cachedType{Kind: "primitive", Name: string(t.Name)}
+	case types.UnresolvedType:
+		return &cachedType{Kind: "unresolved", Name: t.Name}
+	case types.GenericType:
+		return &cachedType{Kind: "generic", Name: t.Name}
+	case types.HoleType:
+		return &cachedType{Kind: "hole"}
+	case types.TypeVar:
+		return &cachedType{Kind: "var", Name: t.Origin, ID: t.ID}
+	case types.StructType:
+		return &cachedType{Kind: "struct", Name: t.Name}
+	case types.DataType:
+		return &cachedType{Kind: "data", Name: t.Name}
+	case types.ArrayType:
+		return &cachedType{Kind: "array", Elements: []*cachedType{cacheType(t.ElementType)}}
+	case types.TupleType:
+		cached := &cachedType{Kind: "tuple"}
+		for _, element := range t.Elements {
+			cached.Elements = append(cached.Elements, cacheType(element))
+		}
+		return cached
+	case types.FunctionType:
+		cached := &cachedType{Kind: "function", Returns: cacheType(t.ReturnType)}
+		for _, param := range t.ParameterTypes {
+			cached.Elements = append(cached.Elements, cacheType(param.Type))
+			cached.Modifiers = append(cached.Modifiers, param.Modifier)
+		}
+		return cached
+	case *types.FunctionType:
+		if t != nil {
+			cached := cacheType(*t)
+			cached.Kind = "signature"
+			return cached
+		}
+	}
+	return nil
+}
+
+// restore rebuilds the type t was cached from, looking struct and data types up in table
+func (t *cachedType) restore(table *symbols.SymbolTable) types.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind {
+	case "primitive":
+		return types.PrimitiveType{Name: types.PrimitiveTypeName(t.Name)}
+	case "generic":
+		return types.GenericType{Name: t.Name}
+	case "hole":
+		return types.HoleType{}
+	case "var":
+		return types.TypeVar{ID: t.ID, Origin: t.Name}
+	case "struct", "data":
+		if decl, ok := table.Types[t.Name]; ok {
+			return decl.Type
+		}
+	case "array":
+		if len(t.Elements) == 1 {
+			return types.ArrayType{ElementType: t.Elements[0].restore(table)}
+		}
+	case "tuple":
+		tuple := types.TupleType{}
+		for _, element := range t.Elements {
+			tuple.Elements = append(tuple.Elements, element.restore(table))
+		}
+		return tuple
+	case "function", "signature":
+		function := types.FunctionType{ReturnType: t.Returns.restore(table)}
+		for i, param := range t.Elements {
+			var modifier types.Modifier
+			if i < len(t.Modifiers) {
+				modifier = t.Modifiers[i]
+			}
+			function.ParameterTypes = append(function.ParameterTypes, types.ParameterType{Modifier: modifier, Type: param.restore(table)})
+		}
+		if t.Kind == "signature" {
+			return &function
+		}
+		return function
+	}
+	return types.UnresolvedType{Name: t.Name}
+}
+
+type cacheFile struct {
+	Version int                      `json:"version"`
+	Build   string                   `json:"build"` // see buildKey
+	Entries map[string][]cachedError `json:"entries"`
+}
+
+// OpenCache reads the cache in dir. A missing cache, or one written by another
+// build of lyra, starts out empty.
+func OpenCache(dir string) (*Cache, error) {
+	c := &Cache{path: filepath.Join(dir, CacheFile), entries: make(map[string][]cachedError), used: make(map[string]bool)}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.path, err)
+	}
+	if file.Version == cacheVersion && file.Build == buildKey() && file.Entries != nil {
+		c.entries = file.Entries
+	}
+	return c, nil
+}
+
+// Save writes the entries used since the cache was opened, dropping the rest
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file := cacheFile{Version: cacheVersion, Build: buildKey(), Entries: make(map[string][]cachedError, len(c.used))}
+	for key := range c.used {
+		file.Entries[key] = c.entries[key]
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// AnalyzeCached is Analyze, skipping the type checking of functions the cache
+// has seen with the same fingerprint and reporting their cached errors instead.
+// The expressions of skipped functions have no types, so the result is meant for
+// reporting diagnostics, as lyra check does, rather than for running.
+func AnalyzeCached(source []byte, cache *Cache) (*Result, error) {
+	return analyze(context.Background(), source, nil, checking{}, cache)
+}
+
+// Fingerprints returns the fingerprint of each function of program, see Cache.
+// Functions without source text, such as those of a prelude, have none.
+func Fingerprints(source []byte, program *ast.Program, index *refs.Index) map[string]string {
+	lines := lineOffsets(source)
+	module := sha256.New()
+	own := make(map[string][]byte)
+	for _, stmt := range program.Statements {
+		text, ok := sourceText(source, lines, stmt.GetLocation())
+		fn, isFunction := stmt.(*ast.FunctionDefStmt)
+		if !isFunction {
+			module.Write(text)
+			module.Write([]byte{0})
+			continue
+		}
+		fmt.Fprintf(module, "def %s<%s>: %s pure=%v async=%v\x00", fn.Name, strings.Join(fn.GenericParams, ","),
+			signatureString(fn.Signature), fn.IsPure, fn.IsAsync)
+		if ok {
+			own[fn.Name] = text
+		}
+	}
+
+	// the functions each function refers to, by call or as a value
+	uses := make(map[string][]string)
+	for _, ref := range index.All() {
+		if ref.Target.Kind == refs.TargetFunction && ref.Kind != refs.Definition && ref.Enclosing != "" {
+			uses[ref.Enclosing] = append(uses[ref.Enclosing], ref.Target.Name)
+		}
+	}
+
+	context := module.Sum(nil)
+	fingerprints := make(map[string]string, len(own))
+	for name := range own {
+		reached := map[string]bool{name: true}
+		pending := []string{name}
+		for len(pending) > 0 {
+			next := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			for _, used := range uses[next] {
+				if !reached[used] {
+					reached[used] = true
+					pending = append(pending, used)
+				}
+			}
+		}
+		names := make([]string, 0, len(reached))
+		for used := range reached {
+			names = append(names, used)
+		}
+		sort.Strings(names)
+
+		h := sha256.New()
+		h.Write(context)
+		h.Write(own[name])
+		for _, used := range names {
+			fmt.Fprintf(h, "\x00%s\x00", used)
+			h.Write(own[used])
+		}
+		fingerprints[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	return fingerprints
+}
+
+// skippable reports whether the checker's Skip applies to fn
+func skippable(fn *ast.FunctionDefStmt) bool {
+	if fn.Signature == nil || fn.IsExtern() {
+		return false
+	}
+	_, returnHole := fn.Signature.ReturnType.(types.HoleType)
+	return !returnHole
+}
+
+// reuse tells check to skip the functions the cache knows, returning the
+// fingerprint of every function whose errors can be cached
+func (c *Cache) reuse(source []byte, program *ast.Program, index *refs.Index, check *checker.Checker) map[*ast.FunctionDefStmt]string {
+	fingerprints := Fingerprints(source, program, index)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cacheable := make(map[*ast.FunctionDefStmt]string)
+	for _, stmt := range program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || fingerprints[fn.Name] == "" || !skippable(fn) {
+			continue
+		}
+		cacheable[fn] = fingerprints[fn.Name]
+		if _, known := c.entries[fingerprints[fn.Name]]; known {
+			check.Skip(fn.Name)
+		}
+	}
+	return cacheable
+}
+
+// merge replaces the errors found in skipped functions with their cached errors
+// and records those of the functions just checked. Errors are ordered by the
+// statement they belong to, type errors before move errors, so a warm run
+// reports them in the same order as a cold one.
+func (c *Cache) merge(errs []error, cacheable map[*ast.FunctionDefStmt]string, table *symbols.SymbolTable) []error {
+	type placed struct {
+		err    error
+		move   bool
+		anchor ast.Location
+	}
+	within := func(loc ast.Location) *ast.FunctionDefStmt {
+		for fn := range cacheable {
+			if loc.StartLine >= fn.Location.StartLine && loc.StartLine <= fn.Location.EndLine {
+				return fn
+			}
+		}
+		return nil
+	}
+
+	var merged []placed
+	found := make(map[*ast.FunctionDefStmt][]error)
+	entries := make(map[*ast.FunctionDefStmt][]cachedError)
+	for _, err := range errs {
+		var typeErr checker.TypeError
+		var moveErr ownership.MoveError
+		var fn *ast.FunctionDefStmt
+		var entry cachedError
+		switch {
+		case errors.As(err, &typeErr):
+			fn = within(typeErr.Location)
+			entry = cachedError{Code: typeErr.Code, Severity: typeErr.Severity, Message: typeErr.Message, Location: typeErr.Location,
+				Expected: cacheType(typeErr.Expected), Actual: cacheType(typeErr.Actual), Related: typeErr.Related}
+		case errors.As(err, &moveErr):
+			fn = within(moveErr.Used)
+			entry = cachedError{Move: true, Name: moveErr.Name, Location: moveErr.Used, Moved: moveErr.Moved}
+		}
+		if fn == nil {
+			merged = append(merged, placed{err, entry.Move, entry.Location})
+			continue
+		}
+		found[fn] = append(found[fn], err)
+		entries[fn] = append(entries[fn], entry.shift(1-fn.Location.StartLine))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for fn, key := range cacheable {
+		c.used[key] = true
+		if cached, known := c.entries[key]; known {
+			c.Reused++
+			for _, entry := range cached {
+				merged = append(merged, placed{entry.restore(fn.Location.StartLine-1, table), entry.Move, fn.Location})
+			}
+			continue
+		}
+		c.Checked++
+		c.entries[key] = entries[fn]
+		for i, err := range found[fn] {
+			merged = append(merged, placed{err, entries[fn][i].Move, fn.Location})
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.move != b.move {
+			return !a.move
+		}
+		return a.anchor.StartLine < b.anchor.StartLine ||
+			(a.anchor.StartLine == b.anchor.StartLine && a.anchor.StartCol < b.anchor.StartCol)
+	})
+	result := make([]error, len(merged))
+	for i, p := range merged {
+		result[i] = p.err
+	}
+	return result
+}
+
+// shift moves the locations of e by lines
+func (e cachedError) shift(lines int) cachedError {
+	e.Location.StartLine += lines
+	e.Location.EndLine += lines
+	if e.Move {
+		e.Moved.StartLine += lines
+		e.Moved.EndLine += lines
+	}
+	if e.Related != nil {
+		related := make([]checker.Related, len(e.Related))
+		for i, r := range e.Related {
+			r.Location.StartLine += lines
+			r.Location.EndLine += lines
+			related[i] = r
+		}
+		e.Related = related
+	}
+	return e
+}
+
+// restore rebuilds the error e was cached from, lines further down
+func (e cachedError) restore(lines int, table *symbols.SymbolTable) error {
+	e = e.shift(lines)
+	if e.Move {
+		return ownership.MoveError{Name: e.Name, Moved: e.Moved, Used: e.Location}
+	}
+	return checker.TypeError{Code: e.Code, Severity: e.Severity, Message: e.Message, Location: e.Location,
+		Expected: e.Expected.restore(table), Actual: e.Actual.restore(table), Related: e.Related}
+}
+
+func signatureString(sig *types.FunctionType) string {
+	if sig == nil {
+		return "?"
+	}
+	return sig.GetName()
+}
+
+func lineOffsets(source []byte) []int {
+	offsets := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// sourceText returns the text at loc, false if loc is not within source
+func sourceText(source []byte, lines []int, loc ast.Location) ([]byte, bool) {
+	if loc.StartLine < 1 || loc.EndLine > len(lines) || loc.EndLine < loc.StartLine {
+		return nil, false
+	}
+	start, end := lines[loc.StartLine-1]+loc.StartCol-1, lines[loc.EndLine-1]+loc.EndCol-1
+	if start < 0 || end > len(source) || end < start {
+		return nil, false
+	}
+	return source[start:end], true
+}
@@ -0,0 +1,241 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func at(line, col, length int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+}
+
+func ident(name string, loc ast.Location) *ast.IdentifierExpr {
+	return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}}, Name: name}
+}
+
+// mathProgram builds the source and AST of, below `offset` blank lines:
+//
+//	def half: (Int) -> Int = (n) => n
+//	def quarter: (Int) -> Int = (n) => half(half(n))
+//	def bad: (Int) -> String = (n) => m
+//
+// with half's body replaced by halfBody, which must be one character long
+func mathProgram(t *testing.T, offset int, halfBody string) ([]byte, *ast.Program, *symbols.SymbolTable) {
+	source := strings.Repeat("\n", offset) + "def half: (Int) -> Int = (n) => " + halfBody + "\n" +
+		"def quarter: (Int) -> Int = (n) => half(half(n))\n" +
+		"def bad: (Int) -> String = (n) => m\n"
+	line := func(n int) int { return offset + n }
+	function := func(name string, n, length int, returns types.Type, body ast.Expression) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{
+			AstBase:      ast.AstBase{Location: at(line(n), 1, length)},
+			Name:         name,
+			NameLocation: at(line(n), 5, len(name)),
+			Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: returns},
+			Clauses: []*ast.FunctionClause{{
+				Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+				Body:       body,
+			}},
+		}
+	}
+	var halfExpr ast.Expression = ident("n", at(line(1), 33, 1))
+	if halfBody != "n" {
+		halfExpr = &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(line(1), 33, 1)}}, Value: 0}
+	}
+	half := function("half", 1, 33, intType, halfExpr)
+	quarter := function("quarter", 2, 48, intType, &ast.CallExpr{
+		ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(line(2), 36, 13)}},
+		Callee:   ident("half", at(line(2), 36, 4)),
+		Arguments: []ast.Expression{&ast.CallExpr{
+			ExprBase:  ast.ExprBase{AstBase: ast.AstBase{Location: at(line(2), 41, 7)}},
+			Callee:    ident("half", at(line(2), 41, 4)),
+			Arguments: []ast.Expression{ident("n", at(line(2), 46, 1))},
+		}},
+	})
+	bad := function("bad", 3, 35, types.PrimitiveType{Name: types.String}, ident("m", at(line(3), 35, 1)))
+
+	table := symbols.NewSymbolTable()
+	for _, fn := range []*ast.FunctionDefStmt{half, quarter, bad} {
+		if err := table.RegisterFunction(fn); err != nil {
+			t.Fatalf("RegisterFunction error: %v", err)
+		}
+	}
+	return []byte(source), &ast.Program{Statements: []ast.AstNode{half, quarter, bad}}, table
+}
+
+func TestCache_ReusesUnchangedFunctions(t *testing.T) {
+	dir := t.TempDir()
+	run := func(offset int, halfBody string) (*Result, *Cache) {
+		cache, err := OpenCache(dir)
+		if err != nil {
+			t.Fatalf("OpenCache error: %v", err)
+		}
+		source, program, table := mathProgram(t, offset, halfBody)
+		result, err := checkProgram(context.Background(), source, program, table, nil, checking{}, cache)
+		if err != nil {
+			t.Fatalf("checkProgram error: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save error: %v", err)
+		}
+		return result, cache
+	}
+
+	cold, cache := run(0, "n")
+	if cache.Checked != 3 || cache.Reused != 0 {
+		t.Fatalf("Expected a cold run to check every function. Got %d checked, %d reused", cache.Checked, cache.Reused)
+	}
+	if len(cold.Errors) != 1 || !strings.HasPrefix(cold.Errors[0].Error(), "3:35: ") {
+		t.Fatalf("Expected bad's undefined name at 3:35. Got %v", cold.Errors)
+	}
+
+	// moving every function down two lines keeps their fingerprints
+	warm, cache := run(2, "n")
+	if cache.Checked != 0 || cache.Reused != 3 {
+		t.Fatalf("Expected a warm run to reuse every function. Got %d checked, %d reused", cache.Checked, cache.Reused)
+	}
+	expected := strings.Replace(cold.Errors[0].Error(), "3:35: ", "5:35: ", 1)
+	if len(warm.Errors) != 1 || warm.Errors[0].Error() != expected {
+		t.Fatalf("Expected the cached error moved with its function: %s. Got %v", expected, warm.Errors)
+	}
+
+	// changing half rechecks it and quarter, which calls it, but not bad
+	_, cache = run(2, "0")
+	if cache.Checked != 2 || cache.Reused != 1 {
+		t.Fatalf("Expected half and quarter rechecked. Got %d checked, %d reused", cache.Checked, cache.Reused)
+	}
+}
+
+func TestCache_IgnoresOtherBuilds(t *testing.T) {
+	dir := t.TempDir()
+	check := func() *Cache {
+		cache, err := OpenCache(dir)
+		if err != nil {
+			t.Fatalf("OpenCache error: %v", err)
+		}
+		source, program, table := mathProgram(t, 0, "n")
+		if _, err := checkProgram(context.Background(), source, program, table, nil, checking{}, cache); err != nil {
+			t.Fatalf("checkProgram error: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save error: %v", err)
+		}
+		return cache
+	}
+	check()
+
+	path := filepath.Join(dir, CacheFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil || file.Build != buildKey() {
+		t.Fatalf("Expected the cache to record the build %q. Got %q, %v", buildKey(), file.Build, err)
+	}
+	file.Build = "an older lyra"
+	if data, err = json.Marshal(file); err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	if cache := check(); cache.Checked != 3 || cache.Reused != 0 {
+		t.Fatalf("Expected a cache written by another build to be ignored. Got %d checked, %d reused", cache.Checked, cache.Reused)
+	}
+}
+
+func TestCache_WarmRunReportsColdErrors(t *testing.T) {
+	dir := t.TempDir()
+	// mathProgram and, below it:
+	//
+	//	def wrong: (Int) -> Int = (n) => half(true)
+	run := func() (*Result, *Cache) {
+		cache, err := OpenCache(dir)
+		if err != nil {
+			t.Fatalf("OpenCache error: %v", err)
+		}
+		source, program, table := mathProgram(t, 0, "n")
+		source = append(source, "def wrong: (Int) -> Int = (n) => half(true)\n"...)
+		wrong := &ast.FunctionDefStmt{
+			AstBase:      ast.AstBase{Location: at(4, 1, 43)},
+			Name:         "wrong",
+			NameLocation: at(4, 5, 5),
+			Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+			Clauses: []*ast.FunctionClause{{
+				Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+				Body: &ast.CallExpr{
+					ExprBase:  ast.ExprBase{AstBase: ast.AstBase{Location: at(4, 34, 10)}},
+					Callee:    ident("half", at(4, 34, 4)),
+					Arguments: []ast.Expression{&ast.BooleanLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(4, 39, 4)}}, Value: true}},
+				},
+			}},
+		}
+		if err := table.RegisterFunction(wrong); err != nil {
+			t.Fatalf("RegisterFunction error: %v", err)
+		}
+		program.Statements = append(program.Statements, wrong)
+		result, err := checkProgram(context.Background(), source, program, table, nil, checking{}, cache)
+		if err != nil {
+			t.Fatalf("checkProgram error: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save error: %v", err)
+		}
+		return result, cache
+	}
+
+	cold, _ := run()
+	warm, cache := run()
+	if cache.Reused != 4 {
+		t.Fatalf("Expected a warm run to reuse every function. Got %d checked, %d reused", cache.Checked, cache.Reused)
+	}
+	if len(cold.Errors) != 2 || !reflect.DeepEqual(cold.Errors, warm.Errors) {
+		t.Fatalf("Expected the warm run to report the errors of the cold one\n%#v\nGot\n%#v", cold.Errors, warm.Errors)
+	}
+	if typeErr := cold.Errors[1].(checker.TypeError); typeErr.Expected == nil || typeErr.Actual == nil {
+		t.Fatalf("Expected half(true) to report the expected and actual types. Got %#v", typeErr)
+	}
+}
+
+func TestCachedError_RoundTrip(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{"x": {Name: "x", Type: intType}}}}
+	table := symbols.NewSymbolTable()
+	if err := table.RegisterType(point); err != nil {
+		t.Fatalf("RegisterType error: %v", err)
+	}
+	original := checker.TypeError{
+		Code:     diagnostics.TypeMismatch,
+		Severity: diagnostics.Error,
+		Message:  "mismatch",
+		Location: at(3, 5, 4),
+		Expected: &types.FunctionType{ParameterTypes: []types.ParameterType{{Modifier: types.Own, Type: point.Type}}, ReturnType: types.TupleType{Elements: []types.Type{intType, types.GenericType{Name: "t"}}}},
+		Actual:   types.ArrayType{ElementType: types.TypeVar{ID: 3, Origin: "t"}},
+		Related:  []checker.Related{{Location: at(4, 1, 3), Message: "used here"}},
+	}
+	entry := cachedError{Code: original.Code, Severity: original.Severity, Message: original.Message, Location: original.Location,
+		Expected: cacheType(original.Expected), Actual: cacheType(original.Actual), Related: original.Related}
+	data, err := json.Marshal(entry.shift(-2))
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	var read cachedError
+	if err := json.Unmarshal(data, &read); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if restored := read.restore(2, table); !reflect.DeepEqual(restored, original) {
+		t.Fatalf("Expected the cached error to restore to\n%#v\nGot\n%#v", original, restored)
+	}
+}
@@ -242,6 +242,14 @@ func (c *Checker) checkIdentifier(node *sitter.Node) types.Type {
 	if ty, ok := c.table.Types[name]; ok {
 		return ty.Type
 	}
+	if len(c.table.LookupConstructor(name)) > 0 {
+		ctor, err := c.table.ResolveConstructor(name, nil)
+		if err != nil {
+			c.error(node, "%s", err)
+			return nil
+		}
+		return ctor.Signature
+	}
 
 	c.error(node, "undefined: %s", name)
 	return nil
@@ -529,6 +537,16 @@ func (c *Checker) checkMemberAccess(node *sitter.Node) types.Type {
 		}
 	}
 
+	// Qualified constructor access: Maybe.Nil
+	if objNode.Kind() == "user_defined_type_name" {
+		typeName := c.nodeText(objNode)
+		if ctor, ok := c.table.LookupQualifiedConstructor(typeName, memberName); ok {
+			return ctor.Signature
+		}
+		c.error(node, "data type %s has no constructor %s", typeName, memberName)
+		return nil
+	}
+
 	objType := c.CheckExpression(objNode)
 	if objType == nil {
 		return nil
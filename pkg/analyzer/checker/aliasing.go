@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkAliasing warns about calls that mutate an array visible outside the call
+// through a mut or own parameter:
+//
+//   - a pure function passing a module-level var array, which it cannot own, to a
+//     mut or own parameter
+//   - one array passed to two parameters of a call, at least one mut or own, so
+//     the callee sees the mutation through the other
+//
+// The analysis is conservative: it only follows arrays named directly by an
+// argument, so a mutation through a struct field or a data constructor payload
+// goes unreported.
+func (c *Checker) checkAliasing(call *ast.CallExpr, fnType types.FunctionType) {
+	passed := make(map[string]int) // array name -> first parameter it was passed to
+	for i, argument := range call.Arguments {
+		ident, ok := argument.(*ast.IdentifierExpr)
+		if !ok || !isArray(c.resolve(argument.GetType())) {
+			continue
+		}
+		modifier := fnType.ParameterTypes[i].Modifier
+		mutates := modifier == types.Mut || modifier == types.Own
+
+		if mutates && c.pure && c.sharedVar(ident.Name) {
+			c.warning(diagnostics.SharedMutation, ident.Location,
+				"pure function %s mutates %s, declared outside it, through %s parameter %d of %s",
+				c.function, ident.Name, modifier, i+1, call.Callee.GetName())
+		}
+
+		first, seen := passed[ident.Name]
+		if !seen {
+			passed[ident.Name] = i
+			continue
+		}
+		firstModifier := fnType.ParameterTypes[first].Modifier
+		if mutates || firstModifier == types.Mut || firstModifier == types.Own {
+			c.warning(diagnostics.SharedMutation, ident.Location,
+				"%s is passed to parameters %d and %d of %s, so a mutation through one is visible through the other",
+				ident.Name, first+1, i+1, call.Callee.GetName())
+		}
+	}
+}
+
+// sharedVar reports whether name refers to a module-level var rather than a
+// binding of the clause being checked
+func (c *Checker) sharedVar(name string) bool {
+	if _, local := c.env[name]; local {
+		return false
+	}
+	named, ok := c.table.GlobalScope.Lookup(name)
+	if !ok {
+		return false
+	}
+	decl, ok := named.(*ast.VarDeclStmt)
+	return ok && decl.IsMutable()
+}
+
+func isArray(t types.Type) bool {
+	_, ok := t.(types.ArrayType)
+	return ok
+}
@@ -0,0 +1,103 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// Sampling checks the first eagerArrayElements elements of an array literal,
+// then about sampledArrayElements more spread evenly over the rest and the
+// last, and reports at most arrayErrorLimit errors in the elements of one literal
+const (
+	eagerArrayElements   = 1000
+	sampledArrayElements = 1000
+	arrayErrorLimit      = 20
+)
+
+// SampleArrays makes the checker check large array literals in part, for
+// editors analyzing generated data files of thousands of elements as the user
+// types. Elements left unchecked have no type and report nothing, so lyra check
+// checks every element.
+func (c *Checker) SampleArrays() {
+	c.sample = true
+}
+
+// checkArrayLiteral checks each element against the element type expected of
+// the array, or else against the type of the first element
+func (c *Checker) checkArrayLiteral(array *ast.ArrayLiteralExpr, expected types.Type) types.Type {
+	var elementType types.Type
+	if expectedArray, ok := c.resolve(expected).(types.ArrayType); ok {
+		elementType = expectedArray.ElementType
+	}
+	reported, unreported := 0, 0
+	for _, i := range c.arrayElements(len(array.Elements)) {
+		element := array.Elements[i]
+		before := len(c.errors)
+		t := c.CheckExpression(element, elementType)
+		switch {
+		case elementType == nil:
+			elementType = t
+		case t != nil && !c.assignable(elementType, t):
+			c.typeError(diagnostics.TypeMismatch, element.GetLocation(), elementType, t,
+				"element %d of the array is %s but the array holds %s", i+1, typeString(t), typeString(elementType))
+		}
+		if c.sample && len(c.errors) > before {
+			if reported == arrayErrorLimit {
+				c.errors = c.errors[:before]
+				unreported++
+				continue
+			}
+			reported++
+		}
+	}
+	if unreported > 0 {
+		c.info(diagnostics.TypeMismatch, array.Location, "%d more elements of the array have errors; lyra check reports them all", unreported)
+	}
+	return types.ArrayType{ElementType: elementType}
+}
+
+// arrayElements returns the indexes of the elements of an array literal of n
+// elements to check: all of them unless sampling
+func (c *Checker) arrayElements(n int) []int {
+	if !c.sample || n <= eagerArrayElements+sampledArrayElements {
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes
+	}
+	indexes := make([]int, 0, eagerArrayElements+sampledArrayElements+1)
+	for i := 0; i < eagerArrayElements; i++ {
+		indexes = append(indexes, i)
+	}
+	stride := (n - eagerArrayElements + sampledArrayElements - 1) / sampledArrayElements
+	for i := eagerArrayElements + stride - 1; i < n-1; i += stride {
+		indexes = append(indexes, i)
+	}
+	return append(indexes, n-1)
+}
+
+// checkTupleLiteral checks each element against the element type expected of
+// it when a tuple of as many elements is expected
+func (c *Checker) checkTupleLiteral(tuple *ast.TupleLiteralExpr, expected types.Type) types.Type {
+	expectedTuple, ok := c.resolve(expected).(types.TupleType)
+	if ok && len(expectedTuple.Elements) != len(tuple.Elements) {
+		expectedTuple = types.TupleType{} // the caller reports the tuple is of the wrong size
+	}
+	elements := make([]types.Type, len(tuple.Elements))
+	for i, element := range tuple.Elements {
+		var elementType types.Type
+		if i < len(expectedTuple.Elements) {
+			elementType = expectedTuple.Elements[i]
+		}
+		t := c.CheckExpression(element, elementType)
+		elements[i] = t
+		if elementType != nil && t != nil && !c.assignable(elementType, t) {
+			c.typeError(diagnostics.TypeMismatch, element.GetLocation(), elementType, t,
+				"element %d of the tuple is %s but %s is expected", i+1, typeString(t), typeString(elementType))
+			elements[i] = elementType
+		}
+	}
+	return types.TupleType{Elements: elements}
+}
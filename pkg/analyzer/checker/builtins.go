@@ -0,0 +1,198 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// Builtins are functions every program can call without declaring them. Their
+// typing rules don't fit a single signature, so calls to them are checked here:
+//
+//	assert(cond: Bool, message: String?) -> Unit
+//	debug(value: t) -> t    prints the value and returns it unchanged
+//	todo() -> Never         marks unfinished code; each site is reported as information
+//	to_json(value: t) -> String    t must be serializable (see checkDerives)
+//	from_json(json: String) -> t   t is the expected type, which must be deserializable
+//	render(template: String, value: s) -> String    s must be a struct (see checkTemplate)
+//
+// A parameter, variable or function of the same name shadows the builtin.
+var Builtins = []string{"assert", "debug", "todo", "to_json", "from_json", "render"}
+
+// BuiltinConstants are the values every program can use without declaring them,
+// shadowed like the builtin functions:
+//
+//	Inf: Float    positive infinity; -Inf is negative infinity
+//	NaN: Float    not a number, which compares unequal to everything, itself included
+var BuiltinConstants = map[string]types.Type{"Inf": floatType, "NaN": floatType}
+
+// checkNaNComparison warns about a comparison with NaN, which is false whatever
+// the other side (true for !=)
+func (c *Checker) checkNaNComparison(expr *ast.BooleanBinaryOpExpr) {
+	for _, side := range []ast.Expression{expr.Left, expr.Right} {
+		if ident, ok := side.(*ast.IdentifierExpr); ok && ident.Name == "NaN" && !c.shadowed("NaN") {
+			c.warning(diagnostics.NaNComparison, expr.Location,
+				"comparison with NaN is always %t: NaN is unequal to everything, itself included; test x != x instead",
+				expr.Operator == ast.BooleanBinaryOpNEq)
+			return
+		}
+	}
+}
+
+func (c *Checker) checkBuiltinCall(call *ast.CallExpr, expected types.Type) (types.Type, bool) {
+	ident, ok := call.Callee.(*ast.IdentifierExpr)
+	if !ok || c.shadowed(ident.Name) {
+		return nil, false
+	}
+
+	switch ident.Name {
+	case "assert":
+		if !c.builtinArity(call, 1, 2) {
+			return unitType, true
+		}
+		c.expectBool(call.Arguments[0], "assert condition must be Bool")
+		if len(call.Arguments) == 2 {
+			message := call.Arguments[1]
+			if t := c.CheckExpression(message, stringType); t != nil && !c.assignable(stringType, t) {
+				c.typeError(diagnostics.ArgumentType, message.GetLocation(), stringType, t,
+					"argument 2: expected %s but got %s", typeString(stringType), typeString(t))
+			}
+		}
+		return unitType, true
+
+	case "debug":
+		if !c.builtinArity(call, 1, 1) {
+			return nil, true
+		}
+		return c.CheckExpression(call.Arguments[0], expected), true
+
+	case "todo":
+		c.builtinArity(call, 0, 0)
+		where := "at top level"
+		if c.function != "" {
+			where = "in " + c.function
+		}
+		c.info(diagnostics.UnfinishedCode, call.Location, "unfinished code %s", where)
+		return neverType, true
+
+	case "to_json":
+		if !c.builtinArity(call, 1, 1) {
+			return stringType, true
+		}
+		if t := c.CheckExpression(call.Arguments[0], nil); t != nil && !c.serializable(t, Serialize) {
+			c.error(diagnostics.InvalidDerive, call.Arguments[0].GetLocation(), "to_json: %s does not derive Serialize", typeString(t))
+		}
+		return stringType, true
+
+	case "from_json":
+		if c.builtinArity(call, 1, 1) {
+			text := call.Arguments[0]
+			if t := c.CheckExpression(text, stringType); t != nil && !c.assignable(stringType, t) {
+				c.typeError(diagnostics.ArgumentType, text.GetLocation(), stringType, t,
+					"argument 1: expected %s but got %s", typeString(stringType), typeString(t))
+			}
+		}
+		if expected == nil {
+			c.error(diagnostics.TypeMismatch, call.Location, "from_json needs an expected type, e.g. let order: Order = from_json(text)")
+			return nil, true
+		}
+		if !c.serializable(expected, Deserialize) {
+			c.error(diagnostics.InvalidDerive, call.Location, "from_json: %s does not derive Deserialize", typeString(expected))
+		}
+		return expected, true
+
+	case "render":
+		if c.builtinArity(call, 2, 2) {
+			c.checkTemplate(call.Arguments[0], call.Arguments[1])
+		}
+		return stringType, true
+	}
+	return nil, false
+}
+
+// checkTemplate checks the arguments of render. Each {field} placeholder of the
+// template is replaced by the field of value it names, so value must be a
+// struct; if the template is a string literal, or an immutable variable bound
+// to one, every placeholder must name one of its fields. Templates spanning
+// lines are best written as multiline strings, which read like heredocs:
+//
+//	let card = """
+//		{name} <{email}>
+//		"""
+//	render(card, user)
+func (c *Checker) checkTemplate(template, value ast.Expression) {
+	if t := c.CheckExpression(template, stringType); t != nil && !c.assignable(stringType, t) {
+		c.typeError(diagnostics.ArgumentType, template.GetLocation(), stringType, t,
+			"argument 1: expected %s but got %s", typeString(stringType), typeString(t))
+	}
+	valueType := c.CheckExpression(value, nil)
+	if valueType == nil {
+		return
+	}
+	structType, ok := c.resolve(valueType).(types.StructType)
+	if !ok {
+		c.error(diagnostics.NotAStruct, value.GetLocation(), "render: %s is not a struct, so a template has no fields to name", typeString(valueType))
+		return
+	}
+	text, ok := c.templateText(template)
+	if !ok {
+		return
+	}
+	parts, err := ast.ParseTemplate(text)
+	if err != nil {
+		c.error(diagnostics.InvalidLiteral, template.GetLocation(), "render: %s", err)
+		return
+	}
+	for _, part := range parts {
+		if _, isField := structType.Fields[part.Field]; part.Field != "" && !isField {
+			c.error(diagnostics.UnknownField, template.GetLocation(), "render: placeholder %s names no field of %s", part.Text, structType.Name)
+		}
+	}
+}
+
+// templateText returns the value of a template known before the program runs
+func (c *Checker) templateText(template ast.Expression) (string, bool) {
+	switch t := template.(type) {
+	case *ast.StringLiteralExpr:
+		return t.Value, true
+	case *ast.RawStringLiteralExpr:
+		return t.Value, true
+	case *ast.MultilineStringLiteralExpr:
+		return t.Value, true
+	case *ast.IdentifierExpr:
+		if _, local := c.env[t.Name]; local {
+			return "", false
+		}
+		named, _ := c.table.GlobalScope.Lookup(t.Name)
+		if decl, ok := named.(*ast.VarDeclStmt); ok && !decl.IsMutable() {
+			if _, isIdentifier := decl.Value.(*ast.IdentifierExpr); !isIdentifier {
+				return c.templateText(decl.Value)
+			}
+		}
+	}
+	return "", false
+}
+
+// shadowed reports whether a builtin's name has been bound by the program
+func (c *Checker) shadowed(name string) bool {
+	if _, ok := c.env[name]; ok {
+		return true
+	}
+	_, ok := c.table.GlobalScope.Lookup(name)
+	return ok
+}
+
+func (c *Checker) builtinArity(call *ast.CallExpr, min, max int) bool {
+	if n := len(call.Arguments); n < min || n > max {
+		expected := min
+		if n > max {
+			expected = max
+		}
+		c.error(diagnostics.ArgumentCount, call.Location, "expected %d arguments but got %d", expected, n)
+		for _, argument := range call.Arguments {
+			c.CheckExpression(argument, nil)
+		}
+		return false
+	}
+	return true
+}
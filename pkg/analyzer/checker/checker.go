@@ -0,0 +1,854 @@
+package checker
+
+/*
+Checker type checks a collected program. It walks the AST (not the parse tree),
+records the checked type of every expression on the expression itself (SetType) and
+accumulates TypeErrors rather than stopping at the first one.
+*/
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var (
+	intType    = types.PrimitiveType{Name: types.Int}
+	floatType  = types.PrimitiveType{Name: types.Float}
+	stringType = types.PrimitiveType{Name: types.String}
+	boolType   = types.PrimitiveType{Name: types.Bool}
+	unitType   = types.PrimitiveType{Name: types.Unit}
+	neverType  = types.PrimitiveType{Name: types.Never}
+)
+
+type Checker struct {
+	program  *ast.Program
+	table    *symbols.SymbolTable
+	env      map[string]types.Type   // parameters and pattern bindings of the clause being checked
+	others   map[string]ast.Location // names only the other clauses of the function bind
+	function string                  // name of the function being checked, if any
+	pure     bool                    // whether that function is declared pure
+	errors   []TypeError
+	skip     map[string]bool    // functions left unchecked, see Skip
+	sample   bool               // whether large array literals are checked in part, see SampleArrays
+	vars     int                // type variables made so far
+	solved   types.Substitution // what the lambdas being checked solved of their parameters; nil outside lambdas
+	lambdas  int                // type variables made before the outermost of them, which names its own a, b, ...
+	matches  []Match            // functions matching a data type by constructor, see Matches
+	// unchecked holds the top-level bindings of the program not checked yet,
+	// which an earlier read of one declared without a type checks ahead
+	unchecked map[*ast.VarDeclStmt]bool
+
+	trace        []TraceEntry // recorded decisions; nil unless EnableTrace was called
+	traceDepth   int
+	traceCurrent []int // trace entries of the expressions being checked, innermost last
+}
+
+type TypeError struct {
+	Code     diagnostics.Code
+	Severity diagnostics.Severity
+	Message  string
+	Location ast.Location
+	Expected types.Type
+	Actual   types.Type
+	Related  []Related // other locations the error involves, in order
+}
+
+// Related is a location an error involves besides its own
+type Related struct {
+	Location ast.Location
+	Message  string
+}
+
+func (e TypeError) Error() string {
+	if e.Severity > diagnostics.Error {
+		return fmt.Sprintf("%d:%d: %s: %s [%s]", e.Location.StartLine, e.Location.StartCol, e.Severity, e.Message, e.Code)
+	}
+	return fmt.Sprintf("%d:%d: %s [%s]", e.Location.StartLine, e.Location.StartCol, e.Message, e.Code)
+}
+
+func NewChecker(program *ast.Program, table *symbols.SymbolTable) *Checker {
+	return &Checker{
+		program: program,
+		table:   table,
+		env:     make(map[string]types.Type),
+		errors:  make([]TypeError, 0),
+	}
+}
+
+// Skip leaves the named functions unchecked, for callers that reuse what an
+// earlier check reported for them. Their expressions are left without types. A
+// function whose return type is a hole is checked regardless, as its callers need
+// the type filled in.
+func (c *Checker) Skip(names ...string) {
+	if c.skip == nil {
+		c.skip = make(map[string]bool, len(names))
+	}
+	for _, name := range names {
+		c.skip[name] = true
+	}
+}
+
+// Check runs type checking on the entire program
+func (c *Checker) Check() []TypeError {
+	errors, _ := c.CheckContext(context.Background())
+	return errors
+}
+
+// CheckContext is Check giving up with ctx.Err() once ctx is done, which is
+// checked before each top-level statement and before the initialization order
+func (c *Checker) CheckContext(ctx context.Context) ([]TypeError, error) {
+	c.unchecked = make(map[*ast.VarDeclStmt]bool)
+	for _, stmt := range c.program.Statements {
+		if decl, ok := stmt.(*ast.VarDeclStmt); ok {
+			c.unchecked[decl] = true
+		}
+	}
+	for _, stmt := range c.program.Statements {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		c.checkStatement(stmt)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.checkInitializationOrder()
+	return c.errors, nil
+}
+
+func (c *Checker) checkStatement(stmt ast.AstNode) {
+	switch s := stmt.(type) {
+	case *ast.VarDeclStmt:
+		if c.unchecked[s] || c.unchecked == nil {
+			c.checkVarDecl(s)
+		}
+	case *ast.DestructuringDeclStmt:
+		c.checkDestructuring(s)
+	case *ast.VarAssignStmt:
+		c.checkVarAssign(s)
+	case *ast.FunctionDefStmt:
+		c.checkFunctionDef(s)
+	case *ast.ExpressionStmt:
+		c.CheckExpression(s.Expression, nil)
+	case *ast.ReturnStmt:
+		c.CheckExpression(s.Value, nil)
+	case *ast.TypeDeclStmt:
+		c.checkFieldDefaults(s)
+		c.checkDerives(s)
+	case *ast.ImplStmt:
+		c.checkImpl(s)
+	}
+}
+
+func (c *Checker) checkVarDecl(decl *ast.VarDeclStmt) {
+	delete(c.unchecked, decl)
+	if isHole(decl.Type) {
+		c.fillVarHole(decl)
+		return
+	}
+	valueType := c.CheckExpression(decl.Value, decl.Type)
+	if decl.Type == nil || valueType == nil {
+		return
+	}
+	if !c.assignable(decl.Type, valueType) {
+		c.typeError(diagnostics.TypeMismatch, decl.Value.GetLocation(), decl.Type, valueType,
+			"cannot use %s as %s in declaration of %s", typeString(valueType), typeString(decl.Type), decl.Name)
+	}
+}
+
+// checkFieldDefaults checks the default value of each field of a struct against
+// the type of the field, with the fields of the struct in scope
+func (c *Checker) checkFieldDefaults(decl *ast.TypeDeclStmt) {
+	structType, ok := decl.Type.(types.StructType)
+	if !ok {
+		return
+	}
+	outer := c.env
+	c.env = make(map[string]types.Type, len(outer)+len(structType.Fields))
+	for name, t := range outer {
+		c.env[name] = t
+	}
+	for name, field := range structType.Fields {
+		c.env[name] = field.Type
+	}
+	for _, name := range decl.FieldOrder() {
+		field := structType.Fields[name]
+		value, ok := field.DefaultValue.(ast.Expression)
+		if !ok {
+			continue
+		}
+		valueType := c.CheckExpression(value, field.Type)
+		if field.Type != nil && valueType != nil && !c.assignable(field.Type, valueType) {
+			c.typeError(diagnostics.TypeMismatch, value.GetLocation(), field.Type, valueType,
+				"cannot use %s as %s in the default of %s.%s", typeString(valueType), typeString(field.Type), decl.Name, name)
+		}
+	}
+	c.env = outer
+}
+
+func (c *Checker) checkVarAssign(assign *ast.VarAssignStmt) {
+	named, ok := c.table.GlobalScope.Lookup(assign.Name)
+	decl, isVar := named.(*ast.VarDeclStmt)
+	if !ok || !isVar {
+		c.error(diagnostics.UndefinedName, assign.NameLocation, "undefined: %s", assign.Name)
+		c.CheckExpression(assign.Value, nil)
+		return
+	}
+	if !decl.IsMutable() {
+		c.error(diagnostics.AssignToImmutable, assign.NameLocation, "cannot assign to %s: declared with %s", assign.Name, decl.Keyword)
+	}
+	declared := decl.Type
+	if declared == nil {
+		declared = c.inferredType(decl)
+	}
+	valueType := c.CheckExpression(assign.Value, declared)
+	if declared != nil && valueType != nil && !c.assignable(declared, valueType) {
+		c.typeError(diagnostics.TypeMismatch, assign.Value.GetLocation(), declared, valueType,
+			"cannot assign %s to %s of type %s", typeString(valueType), assign.Name, typeString(declared))
+	}
+}
+
+func (c *Checker) checkFunctionDef(fn *ast.FunctionDefStmt) {
+	c.function, c.pure = fn.Name, fn.IsPure
+	defer func() { c.function, c.pure = "", false }()
+	if fn.IsExtern() {
+		c.checkExtern(fn)
+		return
+	}
+	if c.skip[fn.Name] && (fn.Signature == nil || !isHole(fn.Signature.ReturnType)) {
+		return
+	}
+	c.checkClauses(fn)
+}
+
+// checkNestedFunction checks a function defined in a clause body. It sees the
+// bindings of the enclosing clause, which it captures, and the functions defined
+// beside it, and is pure if declared so or if the enclosing function is.
+func (c *Checker) checkNestedFunction(fn *ast.FunctionDefStmt) {
+	pure, others := c.pure, c.others
+	c.pure = pure || fn.IsPure
+	defer func() { c.pure, c.others = pure, others }()
+	c.checkClauses(fn)
+}
+
+// checkClauses checks the clauses of fn in the environment it is defined in
+func (c *Checker) checkClauses(fn *ast.FunctionDefStmt) {
+	var returnType types.Type
+	if fn.Signature != nil {
+		returnType = fn.Signature.ReturnType
+	}
+	c.checkClauseArity(fn)
+	c.checkUnreachableClauses(fn)
+	c.checkExhaustive(fn)
+	c.checkClauseBindings(fn)
+	returnHole := c.checkSignatureHoles(fn)
+	if returnHole {
+		returnType = nil
+	}
+
+	var bodies []types.Type
+	for i, clause := range fn.Clauses {
+		outer := c.env
+		c.others = otherClauseBindings(fn, clause)
+		c.env = make(map[string]types.Type, len(outer))
+		for name, t := range outer {
+			c.env[name] = t
+		}
+
+		for i, param := range clause.Parameters {
+			var paramType types.Type
+			if fn.Signature != nil && i < len(fn.Signature.ParameterTypes) {
+				paramType = fn.Signature.ParameterTypes[i].Type
+			}
+			c.bindPattern(param, paramType)
+		}
+		if clause.Guard != nil {
+			// in the scope of the parameters, before the functions the clause defines
+			c.expectBool(clause.Guard.Condition, fmt.Sprintf("the guard of a clause of %s must be Bool", fn.Name))
+			clause.Guard.SetType(boolType)
+		}
+		for _, nested := range clause.Functions {
+			if nested.Signature != nil {
+				c.env[nested.Name] = nested.Signature
+			}
+		}
+		for _, nested := range clause.Functions {
+			c.checkNestedFunction(nested)
+		}
+		body := c.CheckExpression(clause.Body, returnType)
+		if returnType != nil && body != nil && !c.assignable(returnType, body) {
+			c.typeError(diagnostics.TypeMismatch, clause.Location, returnType, body,
+				"clause %d of %s returns %s but %s is declared to return %s", i+1, fn.Name, typeString(body), fn.Name, typeString(returnType))
+		}
+		bodies = append(bodies, body)
+
+		c.env = outer
+		c.others = nil
+	}
+	if returnHole {
+		c.fillReturnHole(fn, bodies)
+	}
+}
+
+// checkClauseArity reports the clauses of fn taking another number of parameters
+// than its signature declares, or than its first clause when it has none
+func (c *Checker) checkClauseArity(fn *ast.FunctionDefStmt) {
+	if fn.Signature == nil && len(fn.Clauses) > 0 {
+		arity := len(fn.Clauses[0].Parameters)
+		for i, clause := range fn.Clauses[1:] {
+			if len(clause.Parameters) != arity {
+				c.error(diagnostics.ArgumentCount, clause.Location, "clause %d of %s takes %d parameters but clause 1 takes %d",
+					i+2, fn.Name, len(clause.Parameters), arity)
+			}
+		}
+		return
+	}
+	for i, clause := range fn.Clauses {
+		if arity := len(fn.Signature.ParameterTypes); len(clause.Parameters) != arity {
+			c.error(diagnostics.ArgumentCount, clause.Location, "clause %d of %s takes %d parameters but %s is declared with %d",
+				i+1, fn.Name, len(clause.Parameters), fn.Name, arity)
+		}
+	}
+}
+
+// bindPattern adds the names bound by a parameter pattern to the clause environment
+func (c *Checker) bindPattern(pattern ast.Pattern, t types.Type) {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		c.env[p.Name] = t
+	case *ast.AsPattern:
+		c.env[p.Name] = t
+		c.bindPattern(p.Pattern, t)
+	case *ast.StructPattern:
+		c.bindStructPattern(p, t)
+	case *ast.LiteralPattern:
+		c.checkLiteralPattern(p, t)
+	case *ast.RangePattern:
+		c.checkRangePattern(p, t)
+	case *ast.ConstructorPattern:
+		c.bindConstructorPattern(p, t)
+	case *ast.ArrayPattern:
+		c.bindArrayPattern(p, t)
+	case *ast.TuplePattern:
+		tuple, ok := c.resolve(t).(types.TupleType)
+		if t != nil && !isGeneric(t) && (!ok || len(tuple.Elements) != len(p.Elements)) {
+			c.error(diagnostics.TypeMismatch, p.Location, "tuple pattern %s cannot match %s", p.GetName(), typeString(t))
+		}
+		for i, element := range p.Elements {
+			var elementType types.Type
+			if ok && i < len(tuple.Elements) {
+				elementType = tuple.Elements[i]
+			}
+			c.bindPattern(element, elementType)
+		}
+	}
+}
+
+// CheckExpression returns the type of an expression and records it on the expression.
+// expected is the type the context expects, if known; it disambiguates constructors.
+func (c *Checker) CheckExpression(expr ast.Expression, expected types.Type) types.Type {
+	if expr == nil {
+		return nil
+	}
+	entry := c.traceStart(expr, expected)
+	t := c.checkExpression(expr, expected)
+	if t != nil {
+		expr.SetType(t)
+	}
+	c.traceEnd(entry, t)
+	return t
+}
+
+func (c *Checker) checkExpression(expr ast.Expression, expected types.Type) types.Type {
+	switch e := expr.(type) {
+	// Literals
+	case *ast.IntegerLiteralExpr:
+		return c.checkIntegerLiteral(e, expected)
+	case *ast.FloatLiteralExpr:
+		return floatType
+	case *ast.StringLiteralExpr, *ast.RawStringLiteralExpr, *ast.MultilineStringLiteralExpr:
+		return stringType
+	case *ast.BooleanLiteralExpr:
+		return boolType
+	case *ast.HostValueExpr:
+		return e.Type
+	case *ast.HoleExpr:
+		return c.checkHole(e, expected)
+
+	// Identifiers
+	case *ast.IdentifierExpr:
+		return c.checkIdentifier(e, expected)
+
+	// Compound expressions
+	case *ast.CallExpr:
+		if c.pure {
+			defer c.checkPureCall(e)
+		}
+		if t, ok := c.checkBuiltinCall(e, expected); ok {
+			c.traceRule("builtin %s", e.Callee.GetName())
+			return t
+		}
+		return c.checkCall(e, expected)
+	case *ast.BinaryOpExpr:
+		return c.checkBinaryOp(e)
+	case *ast.BooleanBinaryOpExpr:
+		return c.checkBooleanBinaryOp(e)
+	case *ast.IfThenExpr:
+		return c.checkIf(e.Condition, e.Then, e.Else, expected)
+	case *ast.IfBlockExpr:
+		return c.checkIf(e.Condition, e.Then, e.Else, expected)
+	case *ast.GuardExpr:
+		c.expectBool(e.Condition, "guard condition must be Bool")
+		return boolType
+	case *ast.MemberAccessExpr:
+		return c.checkMemberAccess(e, expected)
+	case *ast.StructLiteralExpr:
+		return c.checkStructLiteral(e)
+	case *ast.ArrayLiteralExpr:
+		return c.checkArrayLiteral(e, expected)
+	case *ast.TupleLiteralExpr:
+		return c.checkTupleLiteral(e, expected)
+	case *ast.LambdaExpr:
+		return c.checkLambda(e, expected)
+	}
+	return nil
+}
+
+func (c *Checker) checkIdentifier(ident *ast.IdentifierExpr, expected types.Type) types.Type {
+	name := ident.Name
+
+	// Check the clause environment first
+	if t, ok := c.env[name]; ok {
+		c.traceRule("local")
+		return t
+	}
+
+	// Check quick lookup tables
+	if fn, ok := c.table.Functions[name]; ok {
+		c.traceRule("function")
+		return fn.Signature
+	}
+	if named, ok := c.table.GlobalScope.Lookup(name); ok {
+		if decl, ok := named.(*ast.VarDeclStmt); ok {
+			c.traceRule("global %s", decl.Keyword)
+			if decl.Type == nil {
+				return c.inferredType(decl)
+			}
+			return decl.Type
+		}
+	}
+	if len(c.table.LookupConstructor(name)) > 0 {
+		c.traceRule("constructor")
+		ctor, err := c.table.ResolveConstructor(name, expected)
+		if err != nil {
+			c.error(diagnostics.AmbiguousConstructor, ident.Location, "%s", err)
+			return nil
+		}
+		return constructorType(ctor)
+	}
+
+	if t, ok := BuiltinConstants[name]; ok {
+		c.traceRule("builtin constant")
+		return t
+	}
+
+	if bound, ok := c.others[name]; ok {
+		c.error(diagnostics.UndefinedName, ident.Location, "undefined: %s (bound by the clause at %d:%d, which this clause cannot see)",
+			name, bound.StartLine, bound.StartCol)
+		return nil
+	}
+	c.error(diagnostics.UndefinedName, ident.Location, "undefined: %s", name)
+	return nil
+}
+
+// constructorType is the type of a constructor used as a value: nullary
+// constructors are values of their data type, the rest are functions
+func constructorType(ctor *ast.DataConstructorDecl) types.Type {
+	if ctor.Signature == nil {
+		return nil
+	}
+	if len(ctor.Signature.ParameterTypes) == 0 {
+		return ctor.Signature.ReturnType
+	}
+	return ctor.Signature
+}
+
+func (c *Checker) checkCall(call *ast.CallExpr, expected types.Type) types.Type {
+	calleeType := c.CheckExpression(call.Callee, expected)
+	if calleeType == nil {
+		for _, argument := range call.Arguments {
+			c.CheckExpression(argument, nil)
+		}
+		return nil
+	}
+
+	fnType, ok := functionType(calleeType)
+	if !ok {
+		c.error(diagnostics.NotCallable, call.Callee.GetLocation(), "cannot call non-function type %s", typeString(calleeType))
+		return nil
+	}
+
+	// Check argument count
+	if len(call.Arguments) != len(fnType.ParameterTypes) {
+		c.error(diagnostics.ArgumentCount, call.Location, "expected %d arguments but got %d", len(fnType.ParameterTypes), len(call.Arguments))
+		return fnType.ReturnType
+	}
+	c.checkArgumentModifiers(call, fnType)
+
+	if instance := types.Instantiate(fnType, c.freshVar).(types.FunctionType); types.HasTypeVars(instance) {
+		return c.checkGenericCall(call, fnType, instance)
+	}
+
+	// Check each argument type
+	for i, argument := range call.Arguments {
+		expectedType := fnType.ParameterTypes[i].Type
+		argType := c.CheckExpression(argument, expectedType)
+		if argType == nil || expectedType == nil {
+			continue
+		}
+		// a generic function fits where one of its instances does
+		if instance := c.genericValue(argument, argType); types.HasTypeVars(instance) {
+			if err := types.Unify(expectedType, instance, types.Substitution{}); err != nil {
+				c.typeError(diagnostics.ArgumentType, argument.GetLocation(), expectedType, argType,
+					"argument %d: expected %s but got %s", i+1, typeString(expectedType), typeString(argType))
+			}
+			continue
+		}
+		if !c.assignable(expectedType, argType) {
+			c.typeError(diagnostics.ArgumentType, argument.GetLocation(), expectedType, argType,
+				"argument %d: expected %s but got %s", i+1, typeString(expectedType), typeString(argType))
+		}
+	}
+	c.checkAliasing(call, fnType)
+	return fnType.ReturnType
+}
+
+func functionType(t types.Type) (types.FunctionType, bool) {
+	switch ft := t.(type) {
+	case *types.FunctionType:
+		return *ft, true
+	case types.FunctionType:
+		return ft, true
+	}
+	return types.FunctionType{}, false
+}
+
+func (c *Checker) checkBinaryOp(expr *ast.BinaryOpExpr) types.Type {
+	leftType := c.CheckExpression(expr.Left, nil)
+	rightType := c.CheckExpression(expr.Right, nil)
+	if leftType == nil || rightType == nil {
+		return nil
+	}
+
+	switch expr.Operator {
+	case "+", "-", "*", "/", "%", "**":
+		if c.solved != nil {
+			// operands of one type, a lambda parameter taking the type of the other operand
+			if types.HasTypeVars(leftType) || types.HasTypeVars(rightType) {
+				c.assignable(rightType, leftType)
+			}
+			leftType, rightType = c.solved.Apply(leftType), c.solved.Apply(rightType)
+			if types.HasTypeVars(leftType) || types.HasTypeVars(rightType) {
+				return leftType
+			}
+		}
+		if isGeneric(leftType) || isGeneric(rightType) {
+			return leftType
+		}
+		if !leftType.IsNumericType() || !rightType.IsNumericType() || !types.TypesEqual(leftType, rightType) {
+			c.typeError(diagnostics.InvalidOperands, expr.Location, leftType, rightType,
+				"cannot perform arithmetic on %s and %s", typeString(leftType), typeString(rightType))
+			return nil
+		}
+		return leftType
+
+	case "<=>": // spaceship operator
+		return intType
+
+	case "++": // array concatenation
+		leftArray, leftIsArray := leftType.(types.ArrayType)
+		rightArray, rightIsArray := rightType.(types.ArrayType)
+		if !leftIsArray || !rightIsArray {
+			c.typeError(diagnostics.InvalidOperands, expr.Location, types.ArrayType{}, leftType,
+				"cannot concatenate %s and %s", typeString(leftType), typeString(rightType))
+			return nil
+		}
+		if !c.assignable(leftArray.ElementType, rightArray.ElementType) {
+			c.typeError(diagnostics.InvalidOperands, expr.Location, leftArray.ElementType, rightArray.ElementType,
+				"cannot concatenate arrays with different element types: %s and %s",
+				typeString(leftArray.ElementType), typeString(rightArray.ElementType))
+			return nil
+		}
+		return leftType
+	}
+	c.error(diagnostics.InvalidOperands, expr.Location, "unknown binary operator: %s", expr.Operator)
+	return nil
+}
+
+func (c *Checker) checkBooleanBinaryOp(expr *ast.BooleanBinaryOpExpr) types.Type {
+	switch expr.Operator {
+	case ast.BooleanBinaryOpAnd, ast.BooleanBinaryOpOr:
+		c.expectBool(expr.Left, "expected Bool for logical operator")
+		c.expectBool(expr.Right, "expected Bool for logical operator")
+		return boolType
+	}
+
+	leftType := c.CheckExpression(expr.Left, nil)
+	rightType := c.CheckExpression(expr.Right, leftType)
+	c.checkNaNComparison(expr)
+	if leftType != nil && rightType != nil && !c.assignable(leftType, rightType) {
+		c.typeError(diagnostics.IncomparableTypes, expr.Location, leftType, rightType,
+			"cannot compare %s with %s", typeString(leftType), typeString(rightType))
+	}
+	return boolType
+}
+
+func (c *Checker) checkIf(condition, then, otherwise ast.Expression, expected types.Type) types.Type {
+	c.expectBool(condition, "if condition must be Bool")
+
+	thenType := c.CheckExpression(then, expected)
+	if otherwise == nil {
+		return thenType
+	}
+	if expected == nil && !isNever(thenType) {
+		expected = thenType
+	}
+	elseType := c.CheckExpression(otherwise, expected)
+	if isNever(thenType) {
+		return elseType
+	}
+	// Both branches must have same type
+	if thenType != nil && elseType != nil && !c.assignable(thenType, elseType) {
+		c.typeError(diagnostics.BranchTypeMismatch, otherwise.GetLocation(), thenType, elseType,
+			"if branches have different types: %s vs %s", typeString(thenType), typeString(elseType))
+	}
+	return thenType
+}
+
+func (c *Checker) expectBool(expr ast.Expression, message string) {
+	t := c.CheckExpression(expr, boolType)
+	if t != nil && !c.assignable(boolType, t) {
+		c.typeError(diagnostics.ConditionNotBool, expr.GetLocation(), boolType, t, "%s", message)
+	}
+}
+
+func (c *Checker) checkMemberAccess(expr *ast.MemberAccessExpr, expected types.Type) types.Type {
+	// Type.Constructor
+	if ident, ok := expr.Object.(*ast.IdentifierExpr); ok {
+		if _, isBound := c.env[ident.Name]; !isBound {
+			if decl, isType := c.table.Types[ident.Name]; isType {
+				if _, isData := decl.Type.(types.DataType); isData {
+					ctor, found := c.table.LookupQualifiedConstructor(ident.Name, expr.Member)
+					if !found {
+						c.error(diagnostics.UnknownConstructor, expr.MemberLocation, "data type %s has no constructor %s", ident.Name, expr.Member)
+						return nil
+					}
+					return constructorType(ctor)
+				}
+			}
+		}
+	}
+
+	objectType := c.CheckExpression(expr.Object, nil)
+	if objectType == nil {
+		return nil
+	}
+	structType, isStruct := c.resolve(objectType).(types.StructType)
+	if field, ok := structType.Fields[expr.Member]; isStruct && ok {
+		return field.Type
+	}
+	// point.show(): a method of a trait implemented for the type
+	if method, ok := c.methodType(expr, objectType); ok {
+		return method
+	}
+	if !isStruct {
+		c.error(diagnostics.UnknownField, expr.MemberLocation, "%s has no field or method %s", typeString(objectType), expr.Member)
+		return nil
+	}
+	c.error(diagnostics.UnknownField, expr.MemberLocation, "struct %s has no field or method %s", structType.Name, expr.Member)
+	return nil
+}
+
+func (c *Checker) checkStructLiteral(expr *ast.StructLiteralExpr) types.Type {
+	if decl, ok := c.table.Types[expr.TypeName]; ok {
+		structType, ok := decl.Type.(types.StructType)
+		if !ok {
+			c.error(diagnostics.NotAStruct, expr.Location, "%s is not a struct", expr.TypeName)
+			return nil
+		}
+		c.checkFields(expr, structType.Name, structType.Fields)
+		return structType
+	}
+
+	// record-style constructor: Node { left, value, right }
+	ctor, err := c.table.ResolveConstructor(expr.TypeName, nil)
+	if err != nil {
+		c.error(diagnostics.UndefinedName, expr.Location, "undefined: %s", expr.TypeName)
+		return nil
+	}
+	decl, ok := c.table.Types[ctor.DataType]
+	if !ok {
+		return nil
+	}
+	dataType, ok := decl.Type.(types.DataType)
+	if !ok {
+		return nil
+	}
+	c.checkFields(expr, ctor.Name, dataType.Constructors[ctor.Name].Fields)
+	return dataType
+}
+
+// checkFields checks the fields of a struct literal against the declared fields
+func (c *Checker) checkFields(expr *ast.StructLiteralExpr, name string, fields map[string]types.StructField) {
+	given := make(map[string]bool, len(expr.Fields))
+	for _, field := range expr.Fields {
+		given[field.Name] = true
+		declared, ok := fields[field.Name]
+		if !ok {
+			c.error(diagnostics.UnknownField, field.NameLocation, "%s has no field %s", name, field.Name)
+			c.CheckExpression(field.Value, nil)
+			continue
+		}
+		valueType := c.CheckExpression(field.Value, declared.Type)
+		if valueType != nil && declared.Type != nil && !c.assignable(declared.Type, valueType) {
+			c.typeError(diagnostics.TypeMismatch, field.Value.GetLocation(), declared.Type, valueType,
+				"field %s: expected %s but got %s", field.Name, typeString(declared.Type), typeString(valueType))
+		}
+	}
+	for _, fieldName := range sortedFieldNames(fields) {
+		if !given[fieldName] && fields[fieldName].DefaultValue == nil {
+			c.error(diagnostics.MissingField, expr.Location, "missing field %s in %s literal", fieldName, name)
+		}
+	}
+}
+
+func sortedFieldNames(fields map[string]types.StructField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve follows a named type reference to its declaration
+func (c *Checker) resolve(t types.Type) types.Type {
+	// following aliases at most once each, as one may name itself
+	for range len(c.table.Types) + 1 {
+		unresolved, ok := t.(types.UnresolvedType)
+		if !ok {
+			return t
+		}
+		decl, ok := c.table.Types[unresolved.Name]
+		if !ok {
+			return t
+		}
+		t = decl.Type
+		if !decl.IsAlias {
+			return t
+		}
+	}
+	return t
+}
+
+// assignable reports whether a value of type actual can be used where expected is
+// required. Never fits anywhere; generic types are accepted until generic
+// instantiation is checked.
+func (c *Checker) assignable(expected, actual types.Type) bool {
+	ok := c.isAssignable(expected, actual)
+	c.traceAssign(expected, actual, ok)
+	return ok
+}
+
+func (c *Checker) isAssignable(expected, actual types.Type) bool {
+	if c.solved != nil && (types.HasTypeVars(expected) || types.HasTypeVars(actual)) {
+		// a lambda parameter is what it is used as
+		expected, actual = c.resolve(expected), c.resolve(actual)
+		if types.Unify(expected, actual, c.solved) != nil {
+			return false
+		}
+		expected, actual = c.solved.Apply(expected), c.solved.Apply(actual)
+		if types.HasTypeVars(expected) || types.HasTypeVars(actual) {
+			return true
+		}
+	}
+	if expected == nil || actual == nil || isGeneric(expected) || isGeneric(actual) || isNever(actual) {
+		return true
+	}
+	expected, actual = c.resolve(expected), c.resolve(actual)
+	switch expected.(type) {
+	case types.StructType, types.DataType:
+		return expected.GetName() == actual.GetName()
+	}
+	if fn, ok := functionType(expected); ok {
+		expected = fn
+	}
+	if fn, ok := functionType(actual); ok {
+		actual = fn
+	}
+	return types.TypesEqual(expected, actual)
+}
+
+func isNever(t types.Type) bool {
+	primitive, ok := t.(types.PrimitiveType)
+	return ok && primitive.Name == types.Never
+}
+
+// isGeneric reports whether t is a generic parameter or a hole that could not
+// be filled; both are accepted wherever a type is checked
+func isGeneric(t types.Type) bool {
+	_, ok := t.(types.GenericType)
+	return ok || isHole(t)
+}
+
+// Helper methods
+func (c *Checker) error(code diagnostics.Code, loc ast.Location, format string, args ...any) {
+	c.errors = append(c.errors, TypeError{
+		Code:     code,
+		Severity: diagnostics.Error,
+		Message:  fmt.Sprintf(format, args...),
+		Location: loc,
+	})
+}
+
+func (c *Checker) warning(code diagnostics.Code, loc ast.Location, format string, args ...any) {
+	c.errors = append(c.errors, TypeError{
+		Code:     code,
+		Severity: diagnostics.Warning,
+		Message:  fmt.Sprintf(format, args...),
+		Location: loc,
+	})
+}
+
+func (c *Checker) info(code diagnostics.Code, loc ast.Location, format string, args ...any) {
+	c.errors = append(c.errors, TypeError{
+		Code:     code,
+		Severity: diagnostics.Information,
+		Message:  fmt.Sprintf(format, args...),
+		Location: loc,
+	})
+}
+
+func (c *Checker) typeError(code diagnostics.Code, loc ast.Location, expected, actual types.Type, format string, args ...any) {
+	c.errors = append(c.errors, TypeError{
+		Code:     code,
+		Severity: diagnostics.Error,
+		Message:  fmt.Sprintf(format, args...),
+		Location: loc,
+		Expected: expected,
+		Actual:   actual,
+	})
+}
+
+func typeString(t types.Type) string {
+	if t == nil {
+		return "<unknown>"
+	}
+	return t.GetName()
+}
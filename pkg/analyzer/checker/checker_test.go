@@ -0,0 +1,1709 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func ident(name string) *ast.IdentifierExpr {
+	return &ast.IdentifierExpr{Name: name}
+}
+
+func params(names ...string) []ast.Pattern {
+	patterns := make([]ast.Pattern, len(names))
+	for i, name := range names {
+		patterns[i] = &ast.IdentifierPattern{Name: name}
+	}
+	return patterns
+}
+
+func check(t *testing.T, statements ...ast.AstNode) []TypeError {
+	t.Helper()
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			if err := table.RegisterFunction(s); err != nil {
+				t.Fatalf("RegisterFunction error: %v", err)
+			}
+		case *ast.VarDeclStmt:
+			if err := table.RegisterVariable(s); err != nil {
+				t.Fatalf("RegisterVariable error: %v", err)
+			}
+		case *ast.DestructuringDeclStmt:
+			for _, binding := range s.Bindings {
+				if err := table.RegisterVariable(binding); err != nil {
+					t.Fatalf("RegisterVariable error: %v", err)
+				}
+			}
+		case *ast.TypeDeclStmt:
+			if err := table.RegisterType(s); err != nil {
+				t.Fatalf("RegisterType error: %v", err)
+			}
+		case *ast.ImplStmt:
+			if err := table.RegisterImpl(s); err != nil {
+				t.Fatalf("RegisterImpl error: %v", err)
+			}
+		}
+	}
+	return NewChecker(&ast.Program{Statements: statements}, table).Check()
+}
+
+// def sum: (Int, Int) -> Int = (a, b) => a + b
+func sumFunction() (*ast.FunctionDefStmt, *ast.BinaryOpExpr) {
+	body := &ast.BinaryOpExpr{Left: ident("a"), Operator: "+", Right: ident("b")}
+	return &ast.FunctionDefStmt{
+		Name: "sum",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}},
+			ReturnType:     intType,
+		},
+		Clauses: []*ast.FunctionClause{{Parameters: params("a", "b"), Body: body}},
+	}, body
+}
+
+func TestChecker_AnnotatesExpressionTypes(t *testing.T) {
+	sum, body := sumFunction()
+	call := &ast.CallExpr{Callee: ident("sum"), Arguments: []ast.Expression{
+		&ast.IntegerLiteralExpr{Value: 1}, &ast.IntegerLiteralExpr{Value: 2},
+	}}
+	total := &ast.VarDeclStmt{Keyword: "let", Name: "total", Type: intType, Value: call}
+
+	if errors := check(t, sum, total); len(errors) > 0 {
+		t.Fatalf("Checker type errors: %v", errors)
+	}
+	if !types.TypesEqual(body.GetType(), intType) || !types.TypesEqual(body.Left.GetType(), intType) {
+		t.Fatalf("Expected a + b and a to be Int. Got %v and %v", body.GetType(), body.Left.GetType())
+	}
+	if !types.TypesEqual(call.GetType(), intType) {
+		t.Fatalf("Expected sum(1, 2) to be Int. Got %v", call.GetType())
+	}
+}
+
+func TestChecker_TypeErrors(t *testing.T) {
+	answer := &ast.VarDeclStmt{Keyword: "let", Name: "the_answer", Type: intType, Value: &ast.StringLiteralExpr{Value: "42"}}
+	errors := check(t, answer)
+	if len(errors) != 1 || !strings.Contains(errors[0].Message, "cannot use String as Int") || errors[0].Code != diagnostics.TypeMismatch {
+		t.Fatalf("Expected a String/Int mismatch. Got %v", errors)
+	}
+}
+
+func TestChecker_FieldDefaults(t *testing.T) {
+	// let limit: Int = 3
+	// struct Box { size: Int = limit, double: Int = size * 2, label: String = size }
+	limit := &ast.VarDeclStmt{Keyword: "let", Name: "limit", Type: intType, Value: &ast.IntegerLiteralExpr{Value: 3}}
+	size := ident("limit")
+	double := &ast.BinaryOpExpr{Left: ident("size"), Operator: "*", Right: &ast.IntegerLiteralExpr{Value: 2}}
+	box := &ast.TypeDeclStmt{Name: "Box", Type: types.StructType{Name: "Box", Fields: map[string]types.StructField{
+		"size":   {Name: "size", Type: intType, DefaultValue: size},
+		"double": {Name: "double", Type: intType, DefaultValue: double},
+		"label":  {Name: "label", Type: stringType, DefaultValue: ident("size")},
+	}}}
+	errors := check(t, limit, box)
+	if len(errors) != 1 || errors[0].Code != diagnostics.TypeMismatch || !strings.Contains(errors[0].Message, "cannot use Int as String in the default of Box.label") {
+		t.Fatalf("Expected only the String default of Box.label to mismatch. Got %v", errors)
+	}
+	if !types.TypesEqual(size.GetType(), intType) || !types.TypesEqual(double.GetType(), intType) || !types.TypesEqual(double.Left.GetType(), intType) {
+		t.Fatalf("Expected the defaults to be typed Int. Got %v and %v", size.GetType(), double.GetType())
+	}
+}
+
+func TestChecker_AliasChains(t *testing.T) {
+	// type Count = Int
+	// type Total = Count
+	// type Loop = Loop
+	// let n: Total = 1
+	// let s: Total = "one"
+	// let l: Loop = 1
+	alias := func(name string, aliased types.Type) *ast.TypeDeclStmt {
+		return &ast.TypeDeclStmt{Name: name, Type: aliased, IsAlias: true}
+	}
+	errors := check(t,
+		alias("Count", intType),
+		alias("Total", types.UnresolvedType{Name: "Count"}),
+		alias("Loop", types.UnresolvedType{Name: "Loop"}),
+		&ast.VarDeclStmt{Keyword: "let", Name: "n", Type: types.UnresolvedType{Name: "Total"}, Value: &ast.IntegerLiteralExpr{Value: 1}},
+		&ast.VarDeclStmt{Keyword: "let", Name: "s", Type: types.UnresolvedType{Name: "Total"}, Value: &ast.StringLiteralExpr{Value: "one"}},
+		&ast.VarDeclStmt{Keyword: "let", Name: "l", Type: types.UnresolvedType{Name: "Loop"}, Value: &ast.IntegerLiteralExpr{Value: 1}},
+	)
+	if len(errors) != 2 || errors[0].Code != diagnostics.TypeMismatch || !strings.Contains(errors[0].Message, "in declaration of s") {
+		t.Fatalf("Expected Total to stand for Int through Count. Got %v", errors)
+	}
+	// the resolver reports Loop; following it here just has to end
+	if !strings.Contains(errors[1].Message, "in declaration of l") {
+		t.Fatalf("Expected Loop to fit nothing. Got %v", errors[1])
+	}
+}
+
+func TestChecker_CallArguments(t *testing.T) {
+	sum, _ := sumFunction()
+	call := &ast.CallExpr{Callee: ident("sum"), Arguments: []ast.Expression{
+		&ast.IntegerLiteralExpr{Value: 1}, &ast.BooleanLiteralExpr{Value: true},
+	}}
+	errors := check(t, sum, &ast.ExpressionStmt{Expression: call})
+	if len(errors) != 1 || errors[0].Message != "argument 2: expected Int but got Bool" {
+		t.Fatalf("Expected an argument type error. Got %v", errors)
+	}
+}
+
+func TestChecker_Builtins(t *testing.T) {
+	sum, _ := sumFunction()
+	debugged := &ast.CallExpr{Callee: ident("debug"), Arguments: []ast.Expression{
+		&ast.CallExpr{Callee: ident("sum"), Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}, &ast.IntegerLiteralExpr{Value: 2}}},
+	}}
+	unfinished := &ast.FunctionDefStmt{
+		Name:      "later",
+		Signature: &types.FunctionType{ReturnType: intType},
+		Clauses:   []*ast.FunctionClause{{Body: &ast.CallExpr{Callee: ident("todo")}}},
+	}
+	errors := check(t, sum, unfinished,
+		&ast.VarDeclStmt{Keyword: "let", Name: "three", Type: intType, Value: debugged},
+		&ast.ExpressionStmt{Expression: &ast.CallExpr{Callee: ident("assert"), Arguments: []ast.Expression{
+			&ast.IntegerLiteralExpr{Value: 1}, &ast.StringLiteralExpr{Value: "one"},
+		}}},
+	)
+
+	if !types.TypesEqual(debugged.GetType(), intType) {
+		t.Fatalf("Expected debug(sum(1, 2)) to be Int. Got %v", debugged.GetType())
+	}
+	if len(errors) != 2 {
+		t.Fatalf("Expected a todo site and an assert error. Got %v", errors)
+	}
+	if errors[0].Code != diagnostics.UnfinishedCode || errors[0].Severity != diagnostics.Information || errors[0].Message != "unfinished code in later" {
+		t.Fatalf("Expected todo() to be reported as information. Got %v", errors[0])
+	}
+	if errors[1].Code != diagnostics.ConditionNotBool {
+		t.Fatalf("Expected assert to require a Bool condition. Got %v", errors[1])
+	}
+}
+
+func TestChecker_SharedMutation(t *testing.T) {
+	arrayType := types.ArrayType{ElementType: intType}
+	seen := &ast.VarDeclStmt{Keyword: "var", Name: "seen", Type: arrayType, Value: &ast.CallExpr{Callee: ident("todo")}}
+	// def push: (mut Array<Int>, Array<Int>) -> Unit
+	push := &ast.FunctionDefStmt{
+		Name: "push",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Modifier: types.Mut, Type: arrayType}, {Type: arrayType}},
+			ReturnType:     unitType,
+		},
+		Clauses: []*ast.FunctionClause{{Parameters: params("xs", "ys"), Body: &ast.CallExpr{Callee: ident("todo")}}},
+	}
+	visit := func(name string, pure bool, args ...string) *ast.FunctionDefStmt {
+		arguments := make([]ast.Expression, len(args))
+		for i, arg := range args {
+			arguments[i] = ident(arg)
+		}
+		return &ast.FunctionDefStmt{
+			Name:      name,
+			IsPure:    pure,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: arrayType}}, ReturnType: unitType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params("local"), Body: &ast.CallExpr{Callee: ident("push"), Arguments: arguments}}},
+		}
+	}
+
+	var warnings []string
+	for _, err := range check(t, seen, push,
+		visit("pure_shared", true, "seen", "local"),
+		visit("pure_local", true, "local", "seen"),
+		visit("impure_shared", false, "seen", "local"),
+		visit("aliased", false, "local", "local"),
+	) {
+		if err.Code == diagnostics.SharedMutation && err.Severity == diagnostics.Warning {
+			warnings = append(warnings, err.Message)
+		}
+	}
+	expected := []string{
+		"pure function pure_shared mutates seen, declared outside it, through mut parameter 1 of push",
+		"local is passed to parameters 1 and 2 of push, so a mutation through one is visible through the other",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected warnings %q. Got %q", expected, warnings)
+	}
+}
+
+func TestChecker_PureCalls(t *testing.T) {
+	signature := &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType}
+	function := func(name string, pure bool, body ast.Expression) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{Name: name, IsPure: pure, Signature: signature,
+			Clauses: []*ast.FunctionClause{{Parameters: params("x"), Body: body}}}
+	}
+	call := func(name string) *ast.CallExpr {
+		return &ast.CallExpr{Callee: ident(name), Arguments: []ast.Expression{ident("x")}}
+	}
+	double := function("double", true, &ast.BinaryOpExpr{Left: ident("x"), Operator: "*", Right: &ast.IntegerLiteralExpr{Value: 2}})
+	log := function("log", false, call("debug"))
+	fetch := function("fetch", false, &ast.CallExpr{Callee: ident("todo")})
+	fetch.IsAsync = true
+	now := &ast.FunctionDefStmt{Name: "now", Extern: "go:now", Signature: signature}
+	// pure def nested: (Int) -> Int = (x) => { def twice: (Int) -> Int = (y) => debug(y)  twice(x) }
+	twice := &ast.FunctionDefStmt{Name: "twice", Signature: signature,
+		Clauses: []*ast.FunctionClause{{Parameters: params("y"), Body: &ast.CallExpr{Callee: ident("debug"), Arguments: []ast.Expression{ident("y")}}}}}
+	nested := function("nested", true, call("twice"))
+	nested.Clauses[0].Functions = []*ast.FunctionDefStmt{twice}
+
+	var messages []string
+	for _, err := range check(t, double, log, fetch, now, nested,
+		function("calls_pure", true, call("double")),
+		function("calls_debug", true, call("debug")),
+		function("calls_impure", true, call("log")),
+		function("calls_async", true, call("fetch")),
+		function("calls_extern", true, call("now")),
+		function("impure_caller", false, call("log")),
+	) {
+		if err.Code == diagnostics.PurityViolation {
+			messages = append(messages, err.Message)
+		}
+	}
+	expected := []string{
+		"pure function nested calls debug, which prints",
+		"pure function calls_debug calls debug, which prints",
+		"pure function calls_impure calls log, which is not declared pure",
+		"pure function calls_async calls fetch, which is async",
+		"pure function calls_extern calls now, which is implemented by the host",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestChecker_ArgumentModifiers(t *testing.T) {
+	arrayType := types.ArrayType{ElementType: intType}
+	// def push: (mut Array<Int>, Int) -> Unit
+	// def peek: (ref Array<Int>) -> Int
+	push := &ast.FunctionDefStmt{
+		Name: "push",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Modifier: types.Mut, Type: arrayType}, {Type: intType}},
+			ReturnType:     unitType,
+		},
+		Clauses: []*ast.FunctionClause{{Parameters: params("xs", "x"), Body: &ast.CallExpr{Callee: ident("todo")}}},
+	}
+	peek := &ast.FunctionDefStmt{
+		Name:      "peek",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Modifier: types.Ref, Type: arrayType}}, ReturnType: intType},
+		Clauses:   []*ast.FunctionClause{{Parameters: params("xs"), Body: &ast.CallExpr{Callee: ident("todo")}}},
+	}
+	// def name: (mut Array<Int>) -> Unit = (xs) => callee(modifiers xs, ...)
+	calling := func(name, callee string, modifiers ...types.Modifier) *ast.FunctionDefStmt {
+		call := &ast.CallExpr{Callee: ident(callee), Arguments: []ast.Expression{ident("xs")}, Modifiers: modifiers}
+		if callee == "push" {
+			call.Arguments = append(call.Arguments, &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{Type: intType}, Value: 1})
+		}
+		return &ast.FunctionDefStmt{
+			Name:      name,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Modifier: types.Mut, Type: arrayType}}, ReturnType: unitType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params("xs"), Body: call}},
+		}
+	}
+
+	var messages []string
+	for _, err := range check(t, push, peek,
+		calling("marked", "push", types.Mut, ""),
+		calling("unmarked", "push"),
+		calling("borrowed", "peek", types.Ref),
+		calling("unborrowed", "peek"),
+		calling("mismarked", "peek", types.Mut),
+		calling("overmarked", "push", types.Mut, types.Ref),
+	) {
+		if err.Code == diagnostics.ArgumentModifier {
+			messages = append(messages, err.Message)
+		}
+	}
+	expected := []string{
+		"argument 1 of push is passed to a mut parameter; mark it mut xs",
+		"argument 1 of peek is passed to a ref parameter; mark it ref xs",
+		"argument 1 of peek is marked mut but its parameter is ref; mark it ref",
+		"argument 2 of push is marked ref but its parameter is not; remove the marker",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected errors %q. Got %q", expected, messages)
+	}
+}
+
+// impl Show for Point { def show: (Point) -> String = (p) => "point" }
+func showImpl(trait, method string) *ast.ImplStmt {
+	point := types.UnresolvedType{Name: "Point"}
+	return &ast.ImplStmt{Trait: trait, TypeName: "Point", Methods: []*ast.FunctionDefStmt{{
+		Name:      method,
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: point}}, ReturnType: stringType},
+		Clauses:   []*ast.FunctionClause{{Parameters: params("p"), Body: &ast.StringLiteralExpr{Value: "point"}}},
+	}}}
+}
+
+func TestChecker_TraitMethods(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType},
+	}}}
+	// def describe: (Point) -> String = (p) => p.<method>()
+	describe := func(method string) *ast.FunctionDefStmt {
+		call := &ast.CallExpr{Callee: &ast.MemberAccessExpr{Object: ident("p"), Member: method}}
+		return &ast.FunctionDefStmt{
+			Name:      "describe",
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.UnresolvedType{Name: "Point"}}}, ReturnType: stringType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params("p"), Body: call}},
+		}
+	}
+
+	if errs := check(t, point, showImpl("Show", "show"), describe("show")); len(errs) > 0 {
+		t.Fatalf("Expected p.show() to call the show method of Show. Got %v", errs)
+	}
+
+	errs := check(t, point, showImpl("Show", "show"), describe("debug"))
+	if len(errs) != 1 || errs[0].Code != diagnostics.UnknownField || errs[0].Message != "struct Point has no field or method debug" {
+		t.Fatalf("Expected an unknown method error. Got %v", errs)
+	}
+
+	errs = check(t, point, showImpl("Show", "show"), showImpl("Debug", "show"), describe("show"))
+	if len(errs) != 1 || errs[0].Code != diagnostics.AmbiguousMethod {
+		t.Fatalf("Expected an ambiguous method error. Got %v", errs)
+	}
+	if expected := "Point.show is ambiguous: Point implements it for each of Show, Debug; rename the method in all but one"; errs[0].Message != expected {
+		t.Fatalf("Expected %q. Got %q", expected, errs[0].Message)
+	}
+
+	noReceiver := showImpl("Show", "show")
+	noReceiver.Methods[0].Signature.ParameterTypes[0].Type = intType
+	errs = check(t, point, noReceiver)
+	if len(errs) == 0 || errs[0].Message != "method show of Show for Point must take a Point first, the value it is called on" {
+		t.Fatalf("Expected a missing receiver error. Got %v", errs)
+	}
+}
+
+func TestChecker_Externs(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point"}}
+	extern := func(name, target string, result types.Type, params ...types.Type) *ast.FunctionDefStmt {
+		signature := &types.FunctionType{ReturnType: result}
+		for _, param := range params {
+			signature.ParameterTypes = append(signature.ParameterTypes, types.ParameterType{Type: param})
+		}
+		return &ast.FunctionDefStmt{Name: name, Extern: target, Signature: signature}
+	}
+	errs := check(t, point,
+		extern("now", "go:time.UnixNano", intType),
+		extern("log", "go:log.Print", unitType, stringType),
+		extern("plot", "go:draw.Plot", unitType, types.UnresolvedType{Name: "Point"}),
+		extern("origin", "draw.Origin", unitType),
+	)
+	var messages []string
+	for _, err := range errs {
+		if err.Code != diagnostics.InvalidExtern {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		messages = append(messages, err.Message)
+	}
+	expected := []string{
+		"extern plot: parameter 1 of type Point cannot be passed to Go",
+		`extern origin: target "draw.Origin" must have the form "go:package.Name"`,
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestChecker_Derives(t *testing.T) {
+	// @derive(Serialize) struct Order = { id: Int, customer: Customer }
+	order := &ast.TypeDeclStmt{Name: "Order", Derives: []string{Serialize}, Type: types.StructType{Name: "Order", Fields: map[string]types.StructField{
+		"id":       {Name: "id", Type: intType},
+		"customer": {Name: "customer", Type: types.UnresolvedType{Name: "Customer"}},
+	}}}
+	// @derive(Show) struct Customer = { name: String }
+	customer := &ast.TypeDeclStmt{Name: "Customer", Derives: []string{"Show"}, Type: types.StructType{Name: "Customer", Fields: map[string]types.StructField{
+		"name": {Name: "name", Type: stringType},
+	}}}
+	// let text: String = to_json(Customer { name: "Ada" })
+	text := &ast.VarDeclStmt{Keyword: "let", Name: "text", Type: stringType, Value: &ast.CallExpr{Callee: ident("to_json"), Arguments: []ast.Expression{
+		&ast.StructLiteralExpr{TypeName: "Customer", Fields: []*ast.StructLiteralField{{Name: "name", Value: &ast.StringLiteralExpr{Value: "Ada"}}}},
+	}}}
+	// let decoded = from_json(text)
+	decoded := &ast.VarDeclStmt{Keyword: "let", Name: "decoded", Value: &ast.CallExpr{Callee: ident("from_json"), Arguments: []ast.Expression{ident("text")}}}
+
+	var messages []string
+	for _, err := range check(t, order, customer, text, decoded) {
+		messages = append(messages, err.Message)
+	}
+	expected := []string{
+		"Order derives Serialize but field customer has type Customer, which does not",
+		"cannot derive Show for Customer: only Serialize and Deserialize are derivable",
+		"to_json: Customer does not derive Serialize",
+		"from_json needs an expected type, e.g. let order: Order = from_json(text)",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestTraitStatusOf(t *testing.T) {
+	// @derive(Serialize) struct Order = { id: Int }
+	order := &ast.TypeDeclStmt{Name: "Order", Derives: []string{Serialize}, Type: types.StructType{Name: "Order", Fields: map[string]types.StructField{
+		"id": {Name: "id", Type: intType},
+	}}}
+	// type Id = Int
+	id := &ast.TypeDeclStmt{Name: "Id", Type: intType}
+	// type Receipt = Order
+	receipt := &ast.TypeDeclStmt{Name: "Receipt", Type: types.UnresolvedType{Name: "Order"}}
+	// type Handler = (Int) -> Int
+	handler := &ast.TypeDeclStmt{Name: "Handler", Type: types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType}}
+	table := symbols.NewSymbolTable()
+	for _, decl := range []*ast.TypeDeclStmt{order, id, receipt, handler} {
+		table.RegisterType(decl)
+	}
+
+	for _, tc := range []struct {
+		decl     *ast.TypeDeclStmt
+		trait    string
+		expected TraitStatus
+	}{
+		{order, Serialize, TraitDerived},
+		{order, Deserialize, TraitMissing},
+		{id, Deserialize, TraitImplemented},
+		{receipt, Serialize, TraitImplemented},
+		{receipt, Deserialize, TraitMissing},
+		{handler, Serialize, TraitMissing},
+	} {
+		if status := TraitStatusOf(table, tc.decl, tc.trait); status != tc.expected {
+			t.Fatalf("Expected %s to have %s %s. Got %s", tc.decl.Name, tc.trait, tc.expected, status)
+		}
+	}
+}
+
+func TestChecker_Trace(t *testing.T) {
+	// def first<t>: (t, t) -> t = (a, b) => a
+	generic := types.GenericType{Name: "t"}
+	first := &ast.FunctionDefStmt{Name: "first", GenericParams: []string{"t"},
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: generic}, {Type: generic}}, ReturnType: generic},
+		Clauses:   []*ast.FunctionClause{{Parameters: params("a", "b"), Body: ident("a")}},
+	}
+	// let x: Int = first(1, "2")
+	x := &ast.VarDeclStmt{Keyword: "let", Name: "x", Type: intType, Value: &ast.CallExpr{Callee: ident("first"), Arguments: []ast.Expression{
+		&ast.IntegerLiteralExpr{Value: 1}, &ast.StringLiteralExpr{Value: "2"},
+	}}}
+	table := symbols.NewSymbolTable()
+	table.RegisterFunction(first)
+	table.RegisterVariable(x)
+	checker := NewChecker(&ast.Program{Statements: []ast.AstNode{first, x}}, table)
+	checker.EnableTrace()
+	checker.Check()
+
+	var b strings.Builder
+	if err := WriteTrace(&b, checker.Trace()); err != nil {
+		t.Fatalf("WriteTrace error: %v", err)
+	}
+	expected := `0:0 IdentifierExpr a [local] expected t => t
+0:0 assign t <- t: accepted
+0:0 CallExpr first(1, "2") [call] expected Int => Int
+  0:0 IdentifierExpr first [function] expected Int => (t, t) -> t
+  0:0 IntegerLiteralExpr 1 [literal] => Int
+  0:0 unify t <- Int: solved {t := Int}
+  0:0 StringLiteralExpr "2" [literal] expected Int => String
+  0:0 unify Int <- String: rejected
+0:0 assign Int <- Int: accepted
+`
+	if b.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, b.String())
+	}
+}
+
+func TestChecker_Holes(t *testing.T) {
+	// def double: (Int) -> ? = (n) => n * 2
+	double := &ast.FunctionDefStmt{Name: "double",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: types.HoleType{}},
+		Clauses:   []*ast.FunctionClause{{Parameters: params("n"), Body: &ast.BinaryOpExpr{Left: ident("n"), Operator: "*", Right: &ast.IntegerLiteralExpr{Value: 2}}}},
+	}
+	// let seven: _ = 7
+	seven := &ast.VarDeclStmt{Keyword: "let", Name: "seven", Type: types.HoleType{}, Value: &ast.IntegerLiteralExpr{Value: 7}}
+	// def half: (?) -> Int = (n) => ???
+	half := &ast.FunctionDefStmt{Name: "half",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.HoleType{}}}, ReturnType: intType},
+		Clauses:   []*ast.FunctionClause{{Parameters: params("n"), Body: &ast.HoleExpr{}}},
+	}
+
+	var messages []string
+	for _, err := range check(t, double, seven, half) {
+		if err.Code != diagnostics.TypedHole {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		messages = append(messages, err.Severity.String()+": "+err.Message)
+	}
+	expected := []string{
+		"info: hole in the return type of double has type Int",
+		"info: hole in the type of seven has type Int",
+		"error: cannot infer the type of parameter 1 of half: parameter types must be written",
+		"info: hole has type Int, candidates in scope: double(…), half(…), seven",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	if !types.TypesEqual(double.Signature.ReturnType, intType) || !types.TypesEqual(seven.Type, intType) {
+		t.Fatalf("Expected the holes filled with Int. Got %v and %v", double.Signature.ReturnType, seven.Type)
+	}
+}
+
+func TestChecker_ClauseReturnTypes(t *testing.T) {
+	// def describe: (Int) -> String = { (0) => "zero", (n) => n, (n) => todo() }
+	second := ast.Location{StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 10}
+	describe := &ast.FunctionDefStmt{Name: "describe",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: stringType},
+		Clauses: []*ast.FunctionClause{
+			{Parameters: []ast.Pattern{&ast.LiteralPattern{Value: "0"}}, Body: &ast.StringLiteralExpr{Value: "zero"}},
+			{AstBase: ast.AstBase{Location: second}, Parameters: params("n"), Body: ident("n")},
+			{Parameters: params("n"), Body: &ast.CallExpr{Callee: ident("todo")}},
+		},
+	}
+	var errs []TypeError
+	for _, err := range check(t, describe) {
+		if err.Severity == diagnostics.Error {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 1 || errs[0].Message != "clause 2 of describe returns Int but describe is declared to return String" || errs[0].Location != second {
+		t.Fatalf("Expected the second clause reported. Got %v", errs)
+	}
+	if !types.TypesEqual(errs[0].Expected, stringType) || !types.TypesEqual(errs[0].Actual, intType) {
+		t.Fatalf("Expected String expected and Int found. Got %v, %v", errs[0].Expected, errs[0].Actual)
+	}
+}
+
+func TestChecker_ClauseArity(t *testing.T) {
+	// def f: (Int) -> Int = { (a, b) => a, (a) => a }
+	first := ast.Location{StartLine: 2, StartCol: 2, EndLine: 2, EndCol: 13}
+	f := &ast.FunctionDefStmt{Name: "f",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{
+			{AstBase: ast.AstBase{Location: first}, Parameters: params("a", "b"), Body: ident("a")},
+			{Parameters: params("a"), Body: ident("a")},
+		},
+	}
+	errs := check(t, f)
+	if len(errs) != 1 || errs[0].Code != diagnostics.ArgumentCount || errs[0].Location != first ||
+		errs[0].Message != "clause 1 of f takes 2 parameters but f is declared with 1" {
+		t.Fatalf("Expected the first clause reported. Got %v", errs)
+	}
+
+	// without a signature the clauses must agree with the first
+	g := &ast.FunctionDefStmt{Name: "g", Clauses: []*ast.FunctionClause{
+		{Parameters: params("a"), Body: ident("a")},
+		{Parameters: params("a", "b"), Body: ident("b")},
+	}}
+	errs = check(t, g)
+	if len(errs) != 1 || errs[0].Message != "clause 2 of g takes 2 parameters but clause 1 takes 1" {
+		t.Fatalf("Expected the second clause reported. Got %v", errs)
+	}
+}
+
+func TestChecker_ArrayLiterals(t *testing.T) {
+	// let small: [Int8] = [1, 300]; let mixed = [1, "two"]
+	overflow := &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: 1, StartCol: 25}}}, Value: 300}
+	small := &ast.VarDeclStmt{Keyword: "let", Name: "small", Type: types.ArrayType{ElementType: types.PrimitiveType{Name: types.Int8}},
+		Value: &ast.ArrayLiteralExpr{Elements: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}, overflow}}}
+	mixedArray := &ast.ArrayLiteralExpr{Elements: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}, &ast.StringLiteralExpr{Value: "two"}}}
+	mixed := &ast.VarDeclStmt{Keyword: "let", Name: "mixed", Value: mixedArray}
+	errs := check(t, small, mixed)
+	if len(errs) != 2 || errs[0].Code != diagnostics.IntegerOverflow || errs[0].Location != overflow.Location {
+		t.Fatalf("Expected 300 to overflow the Int8 elements. Got %v", errs)
+	}
+	if errs[1].Message != "element 2 of the array is String but the array holds Int" {
+		t.Fatalf("Expected the String element reported. Got %v", errs[1])
+	}
+	if !types.TypesEqual(mixedArray.GetType(), types.ArrayType{ElementType: intType}) {
+		t.Fatalf("Expected [Int]. Got %v", mixedArray.GetType())
+	}
+}
+
+func TestChecker_SampleArrays(t *testing.T) {
+	// a generated array of 5000 Ints whose every hundredth element is a String
+	data := func() *ast.ArrayLiteralExpr {
+		array := &ast.ArrayLiteralExpr{}
+		for i := 0; i < 5000; i++ {
+			var element ast.Expression = &ast.IntegerLiteralExpr{Value: int64(i)}
+			if i%100 == 99 {
+				element = &ast.StringLiteralExpr{Value: "oops"}
+			}
+			array.Elements = append(array.Elements, element)
+		}
+		return array
+	}
+	run := func(sample bool) ([]TypeError, *ast.ArrayLiteralExpr) {
+		array := data()
+		decl := &ast.VarDeclStmt{Keyword: "let", Name: "data", Type: types.ArrayType{ElementType: intType}, Value: array}
+		table := symbols.NewSymbolTable()
+		table.RegisterVariable(decl)
+		checker := NewChecker(&ast.Program{Statements: []ast.AstNode{decl}}, table)
+		if sample {
+			checker.SampleArrays()
+		}
+		return checker.Check(), array
+	}
+
+	errs, array := run(false)
+	if len(errs) != 50 {
+		t.Fatalf("Expected every String element reported when checking in full. Got %d errors", len(errs))
+	}
+	if array.Elements[4000].GetType() == nil {
+		t.Fatalf("Expected every element typed when checking in full")
+	}
+
+	errs, array = run(true)
+	if len(errs) != 21 || errs[20].Severity != diagnostics.Information ||
+		errs[20].Message != "30 more elements of the array have errors; lyra check reports them all" {
+		t.Fatalf("Expected 20 errors and a note of the 30 more sampled. Got %d: %v", len(errs), errs)
+	}
+	if array.Elements[999].GetType() == nil || array.Elements[1003].GetType() == nil || array.Elements[4999].GetType() == nil {
+		t.Fatalf("Expected the first thousand elements, the samples and the last checked")
+	}
+	if array.Elements[4000].GetType() != nil {
+		t.Fatalf("Expected element 4001 left unchecked")
+	}
+}
+
+func TestChecker_Guards(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType},
+	}}}
+	// def sign: (Point) -> Int = { (Point { x }) if x => 1, (Point { x }) if x > 0 => 1, (p) => 0 }
+	guarded := func(guard ast.Expression) *ast.FunctionClause {
+		return &ast.FunctionClause{
+			Parameters: []ast.Pattern{&ast.StructPattern{TypeName: "Point", Fields: []*ast.StructPatternField{{Name: "x"}}}},
+			Guard:      &ast.GuardExpr{Condition: guard},
+			Body:       &ast.IntegerLiteralExpr{Value: 1},
+		}
+	}
+	positive := &ast.BooleanBinaryOpExpr{Left: ident("x"), Operator: ast.BooleanBinaryOpGT, Right: &ast.IntegerLiteralExpr{Value: 0}}
+	sign := &ast.FunctionDefStmt{Name: "sign",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.UnresolvedType{Name: "Point"}}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{
+			guarded(ident("x")),
+			guarded(positive),
+			{Parameters: params("p"), Body: &ast.IntegerLiteralExpr{Value: 0}},
+		},
+	}
+	errs := check(t, point, sign)
+	if len(errs) != 1 || errs[0].Code != diagnostics.ConditionNotBool || errs[0].Message != "the guard of a clause of sign must be Bool" {
+		t.Fatalf("Expected the Int guard reported. Got %v", errs)
+	}
+	if !types.TypesEqual(positive.Left.GetType(), intType) || !types.TypesEqual(sign.Clauses[1].Guard.GetType(), boolType) {
+		t.Fatalf("Expected x typed Int in the guard and the guard Bool. Got %v, %v", positive.Left.GetType(), sign.Clauses[1].Guard.GetType())
+	}
+}
+
+func TestChecker_UnreachableClauses(t *testing.T) {
+	literal := func(value string) ast.Pattern { return &ast.LiteralPattern{Value: value} }
+	clause := func(line int, param ast.Pattern, guard ast.Expression) *ast.FunctionClause {
+		clause := &ast.FunctionClause{
+			AstBase:    ast.AstBase{Location: ast.Location{StartLine: line, StartCol: 2, EndLine: line, EndCol: 20}},
+			Parameters: []ast.Pattern{param},
+			Body:       &ast.IntegerLiteralExpr{Value: 0},
+		}
+		if guard != nil {
+			clause.Guard = &ast.GuardExpr{Condition: guard}
+		}
+		return clause
+	}
+	small := func() ast.Expression {
+		return &ast.BooleanBinaryOpExpr{Left: ident("n"), Operator: ast.BooleanBinaryOpLT, Right: &ast.IntegerLiteralExpr{Value: 2}}
+	}
+	// def fib: (Int) -> Int = {
+	//	(0) => 0,
+	//	(n) if n < 2 => 0,
+	//	(1) => 0,
+	//	(0) => 0,
+	//	(n) if n < 2 => 0,
+	//	(n) => 0,
+	//	(2) => 0,
+	// }
+	fib := &ast.FunctionDefStmt{Name: "fib",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{
+			clause(2, literal("0"), nil),
+			clause(3, params("n")[0], small()),
+			clause(4, literal("1"), nil),
+			clause(5, literal("0"), nil),
+			clause(6, params("n")[0], small()),
+			clause(7, params("n")[0], nil),
+			clause(8, literal("2"), nil),
+		},
+	}
+
+	var messages []string
+	for _, err := range check(t, fib) {
+		if err.Code != diagnostics.UnreachableClause || err.Severity != diagnostics.Warning {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"5:2: warning: unreachable clause of fib: the clause at 2:2 matches (0) first [LYR0026]",
+		"6:2: warning: unreachable clause of fib: the clause at 3:2 matches (n) first [LYR0026]",
+		"8:2: warning: unreachable clause of fib: the clause at 7:2 matches (2) first [LYR0026]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestChecker_AsPatterns(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType},
+	}}}
+	pointType := types.UnresolvedType{Name: "Point"}
+	// def keep: (Point) -> Point = (p @ Point { x }) if x > 0 => p
+	guard := &ast.BooleanBinaryOpExpr{Left: ident("x"), Operator: ast.BooleanBinaryOpGT, Right: &ast.IntegerLiteralExpr{Value: 0}}
+	body := ident("p")
+	keep := &ast.FunctionDefStmt{Name: "keep",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: pointType}}, ReturnType: pointType},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.AsPattern{Name: "p", Pattern: &ast.StructPattern{TypeName: "Point", Fields: []*ast.StructPatternField{{Name: "x"}}}}},
+			Guard:      &ast.GuardExpr{Condition: guard},
+			Body:       body,
+		}},
+	}
+	if errs := check(t, point, keep); len(errs) > 0 {
+		t.Fatalf("Checker errors: %v", errs)
+	}
+	if typeString(body.GetType()) != "Point" || typeString(guard.Left.GetType()) != "Int" {
+		t.Fatalf("Expected p to bind the Point and x its field. Got %s and %s", typeString(body.GetType()), typeString(guard.Left.GetType()))
+	}
+}
+
+func TestChecker_RangePatterns(t *testing.T) {
+	span := func(low, high string) ast.Pattern { return &ast.RangePattern{Low: low, High: high} }
+	classify := func(param types.PrimitiveTypeName, patterns ...ast.Pattern) *ast.FunctionDefStmt {
+		fn := &ast.FunctionDefStmt{Name: "classify",
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.PrimitiveType{Name: param}}}, ReturnType: intType},
+		}
+		for _, pattern := range patterns {
+			fn.Clauses = append(fn.Clauses, &ast.FunctionClause{Parameters: []ast.Pattern{pattern}, Body: &ast.IntegerLiteralExpr{Value: 0}})
+		}
+		return fn
+	}
+	messages := func(errs []TypeError) string {
+		var messages []string
+		for _, err := range errs {
+			messages = append(messages, fmt.Sprintf("%s %s", err.Code, err.Message))
+		}
+		return strings.Join(messages, "\n")
+	}
+
+	// (0..=9), (10..=99) leave 100..=255 of a UInt8; a final (n) covers them
+	errs := check(t, classify(types.UInt8, span("0", "9"), span("10", "99")))
+	if expected := "LYR0039 the clauses of classify do not match every UInt8: 100..=255 not covered"; messages(errs) != expected {
+		t.Fatalf("Expected %q. Got %q", expected, messages(errs))
+	}
+	if errs := check(t, classify(types.UInt8, span("0", "9"), span("10", "99"), params("n")[0])); len(errs) > 0 {
+		t.Fatalf("Expected a final binding to cover the rest. Got %v", errs)
+	}
+	if errs := check(t, classify(types.Int8, span("-128", "-1"), &ast.LiteralPattern{Value: "0"}, span("1", "127"))); len(errs) > 0 {
+		t.Fatalf("Expected ranges covering every Int8. Got %v", errs)
+	}
+	// an Int has too many values to list, so partial clauses go unreported
+	if errs := check(t, classify(types.Int, span("0", "9"))); len(errs) > 0 {
+		t.Fatalf("Expected no coverage warning for Int. Got %v", errs)
+	}
+
+	errs = check(t, classify(types.Int8, span("0", "9"), &ast.LiteralPattern{Value: "5"}, span("'a'", "'z'"), span("0", "300"), params("n")[0]))
+	expected := strings.Join([]string{
+		"LYR0026 unreachable clause of classify: the clause at 0:0 matches (5) first",
+		"LYR0003 range pattern 'a'..='z' matches characters but the value is Int8",
+		"LYR0027 300 overflows Int8, which holds -128 to 127",
+	}, "\n")
+	if messages(errs) != expected {
+		t.Fatalf("Expected %q. Got %q", expected, messages(errs))
+	}
+
+	errs = check(t, classify(types.String, span("'a'", "'z'"), span("'z'", "'a'"), span("0", "9"), params("c")[0]))
+	expected = strings.Join([]string{
+		"LYR0026 range pattern 'z'..='a' matches nothing: 'z' is greater than 'a'",
+		"LYR0003 range pattern 0..=9 matches integers but the value is String",
+	}, "\n")
+	if messages(errs) != expected {
+		t.Fatalf("Expected %q. Got %q", expected, messages(errs))
+	}
+}
+
+func TestChecker_PatternTypes(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType}, "y": {Name: "y", Type: intType},
+	}}}
+	size := &ast.TypeDeclStmt{Name: "Size", Type: types.StructType{Name: "Size"}}
+	fields := func(typeName string, names ...string) *ast.StructPattern {
+		pattern := &ast.StructPattern{TypeName: typeName}
+		for _, name := range names {
+			pattern.Fields = append(pattern.Fields, &ast.StructPatternField{Name: name})
+		}
+		return pattern
+	}
+	literal := func(text string) ast.Pattern { return &ast.LiteralPattern{Value: text} }
+	// describe: (Int8, Point) -> Int
+	describe := &ast.FunctionDefStmt{Name: "describe",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{
+			{Type: types.PrimitiveType{Name: types.Int8}}, {Type: types.UnresolvedType{Name: "Point"}},
+		}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{
+			{Parameters: []ast.Pattern{literal(`"hello"`), fields("Point")}, Body: &ast.IntegerLiteralExpr{Value: 0}},
+			{Parameters: []ast.Pattern{literal("300"), fields("Size")}, Body: &ast.IntegerLiteralExpr{Value: 0}},
+			{Parameters: []ast.Pattern{literal("1"), &ast.StructPattern{TypeName: "Point", Fields: []*ast.StructPatternField{
+				{Name: "x", Pattern: literal("true")}, {Name: "z"},
+			}}}, Body: &ast.IntegerLiteralExpr{Value: 0}},
+			// the names nested patterns bind take the types of the fields they match
+			{Parameters: []ast.Pattern{literal("2"), &ast.StructPattern{TypeName: "Point", Fields: []*ast.StructPatternField{
+				{Name: "x", Pattern: &ast.AsPattern{Name: "across", Pattern: &ast.IdentifierPattern{Name: "left"}}}, {Name: "y"},
+			}}}, Body: &ast.BinaryOpExpr{Left: ident("across"), Operator: "+", Right: &ast.BinaryOpExpr{Left: ident("left"), Operator: "+", Right: ident("y")}}},
+			{Parameters: []ast.Pattern{params("n")[0], &ast.TuplePattern{Elements: params("a", "b")}}, Body: &ast.IntegerLiteralExpr{Value: 0}},
+		},
+	}
+	errs := check(t, point, size, describe)
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, fmt.Sprintf("%s %s", err.Code, err.Message))
+	}
+	expected := strings.Join([]string{
+		`LYR0003 pattern "hello" is String but the value is Int8`,
+		"LYR0027 300 overflows Int8, which holds -128 to 127",
+		"LYR0003 pattern Size {  } matches Size but the value is Point",
+		"LYR0003 pattern true is Bool but the value is Int",
+		"LYR0013 Point has no field z",
+		"LYR0003 tuple pattern (a, b) cannot match Point",
+	}, "\n")
+	if got := strings.Join(messages, "\n"); got != expected {
+		t.Fatalf("Expected %q. Got %q", expected, got)
+	}
+}
+
+func TestChecker_DataTypeCoverage(t *testing.T) {
+	shape := &ast.TypeDeclStmt{Name: "Shape", Type: types.DataType{Name: "Shape", Constructors: map[string]types.DataTypeConstructor{
+		"Circle": {Name: "Circle", Fields: map[string]types.StructField{"radius": {Name: "radius", Type: intType}}},
+		"Square": {Name: "Square", Fields: map[string]types.StructField{"side": {Name: "side", Type: intType}}},
+		"Empty":  {Name: "Empty"},
+	}}}
+	circle := func(radius ast.Pattern) ast.Pattern {
+		return &ast.StructPattern{TypeName: "Circle", Fields: []*ast.StructPatternField{{Name: "radius", Pattern: radius}}}
+	}
+	// def area: (Shape, Int) -> Int = { (<pattern>, scale) => 0, ... }
+	area := func(patterns ...ast.Pattern) *ast.FunctionDefStmt {
+		fn := &ast.FunctionDefStmt{Name: "area", Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: types.UnresolvedType{Name: "Shape"}}, {Type: intType}},
+			ReturnType:     intType,
+		}}
+		for _, pattern := range patterns {
+			fn.Clauses = append(fn.Clauses, &ast.FunctionClause{
+				Parameters: []ast.Pattern{pattern, params("scale")[0]},
+				Body:       &ast.IntegerLiteralExpr{Value: 0},
+			})
+		}
+		return fn
+	}
+
+	// Circle { radius: 0 } refines its field, so it leaves Circle uncovered
+	errs := check(t, shape, area(circle(&ast.LiteralPattern{Value: "0"}), &ast.StructPattern{TypeName: "Square"}))
+	if len(errs) != 1 || errs[0].Code != diagnostics.NonExhaustiveClauses {
+		t.Fatalf("Expected a coverage warning. Got %v", errs)
+	}
+	if expected := "the clauses of area do not match every Shape: Circle, Empty not covered"; errs[0].Message != expected {
+		t.Fatalf("Expected %q. Got %q", expected, errs[0].Message)
+	}
+
+	errs = check(t, shape, area(circle(nil), &ast.StructPattern{TypeName: "Square"}, &ast.StructPattern{TypeName: "Empty"}))
+	if len(errs) > 0 {
+		t.Fatalf("Expected every constructor covered. Got %v", errs)
+	}
+	errs = check(t, shape, area(&ast.AsPattern{Name: "c", Pattern: circle(params("r")[0])}, params("_")[0]))
+	if len(errs) > 0 {
+		t.Fatalf("Expected a wildcard to cover the rest. Got %v", errs)
+	}
+}
+
+func TestChecker_ConstructorAndArrayPatterns(t *testing.T) {
+	maybe, _ := maybeTable()
+	maybeType := types.UnresolvedType{Name: "Maybe"}
+	table := func() *symbols.SymbolTable {
+		_, table := maybeTable()
+		return table
+	}
+	function := func(name string, param types.Type, clauses ...*ast.FunctionClause) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{Name: name, Clauses: clauses,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: param}}, ReturnType: intType}}
+	}
+	clause := func(pattern ast.Pattern, body ast.Expression) *ast.FunctionClause {
+		return &ast.FunctionClause{Parameters: []ast.Pattern{pattern}, Body: body}
+	}
+	some := func(arguments ...ast.Pattern) ast.Pattern {
+		return &ast.ConstructorPattern{Name: "Some", Arguments: arguments}
+	}
+	messages := func(fns ...*ast.FunctionDefStmt) []string {
+		statements := []ast.AstNode{maybe}
+		for _, fn := range fns {
+			statements = append(statements, fn)
+		}
+		var messages []string
+		for _, err := range NewChecker(&ast.Program{Statements: statements}, table()).Check() {
+			messages = append(messages, fmt.Sprintf("%s %s", err.Code, err.Message))
+		}
+		return messages
+	}
+
+	// def or_zero: (Maybe) -> Int = { (Some(n)) => n, (_) => 0 }
+	n := ident("n")
+	orZero := function("or_zero", maybeType, clause(some(params("n")[0]), n), clause(&ast.WildcardPattern{}, &ast.IntegerLiteralExpr{Value: 0}))
+	// def head: (Array<Int>) -> Int = { ([x, ...rest]) => x, ([]) => 0 }
+	x, rest := ident("x"), &ast.IdentifierPattern{Name: "rest"}
+	head := function("head", types.ArrayType{ElementType: intType},
+		clause(&ast.ArrayPattern{Elements: params("x"), Rest: rest}, x),
+		clause(&ast.ArrayPattern{}, &ast.IntegerLiteralExpr{Value: 0}))
+	if errs := messages(orZero, head); len(errs) > 0 {
+		t.Fatalf("Checker errors: %v", errs)
+	}
+	if typeString(n.GetType()) != "Int" || typeString(x.GetType()) != "Int" {
+		t.Fatalf("Expected n and x to bind Ints. Got %s and %s", typeString(n.GetType()), typeString(x.GetType()))
+	}
+
+	unwrap := function("unwrap", maybeType, clause(some(params("n")[0]), ident("n")), clause(some(params("a", "b")...), ident("a")))
+	first := function("first", types.ArrayType{ElementType: intType},
+		clause(&ast.ArrayPattern{Elements: params("x"), Rest: rest}, ident("x")),
+		clause(&ast.ArrayPattern{Elements: params("y")}, ident("y")))
+	count := function("count", intType, clause(&ast.ArrayPattern{}, &ast.IntegerLiteralExpr{Value: 0}))
+	expected := []string{
+		"LYR0039 the clauses of unwrap do not match every Maybe: None not covered",
+		"LYR0006 pattern Some(a, b): Some takes 1 arguments but the pattern has 2",
+		"LYR0026 unreachable clause of first: the clause at 0:0 matches ([y]) first",
+		"LYR0003 array pattern [] cannot match Int",
+	}
+	if got := messages(unwrap, first, count); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, got)
+	}
+}
+
+// maybeTable declares data Maybe { Some(Int), None } with its constructors
+func maybeTable() (*ast.TypeDeclStmt, *symbols.SymbolTable) {
+	maybe := &ast.TypeDeclStmt{Name: "Maybe", Type: types.DataType{Name: "Maybe", Constructors: map[string]types.DataTypeConstructor{
+		"Some": {Name: "Some", Params: []types.Type{intType}},
+		"None": {Name: "None"},
+	}}}
+	maybeType := types.UnresolvedType{Name: "Maybe"}
+	table := symbols.NewSymbolTable()
+	table.RegisterType(maybe)
+	table.RegisterConstructor(&ast.DataConstructorDecl{Name: "Some", DataType: "Maybe",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: maybeType}})
+	table.RegisterConstructor(&ast.DataConstructorDecl{Name: "None", DataType: "Maybe", Signature: &types.FunctionType{ReturnType: maybeType}})
+	return maybe, table
+}
+
+func TestChecker_ConstructorNames(t *testing.T) {
+	maybe, table := maybeTable()
+	maybeType := types.UnresolvedType{Name: "Maybe"}
+	// let nothing: Maybe = None
+	none := ident("None")
+	nothing := &ast.VarDeclStmt{Keyword: "let", Name: "nothing", Type: maybeType, Value: none}
+	// let wrap = Some
+	some := ident("Some")
+	wrap := &ast.VarDeclStmt{Keyword: "let", Name: "wrap", Value: some}
+	// let one: Maybe = Some(1)
+	call := &ast.CallExpr{Callee: ident("Some"), Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}}}
+	one := &ast.VarDeclStmt{Keyword: "let", Name: "one", Type: maybeType, Value: call}
+	for _, decl := range []*ast.VarDeclStmt{nothing, wrap, one} {
+		table.RegisterVariable(decl)
+	}
+
+	if errs := NewChecker(&ast.Program{Statements: []ast.AstNode{maybe, nothing, wrap, one}}, table).Check(); len(errs) > 0 {
+		t.Fatalf("Checker errors: %v", errs)
+	}
+	if typeString(none.GetType()) != "Maybe" {
+		t.Fatalf("Expected None to be a value of Maybe. Got %s", typeString(none.GetType()))
+	}
+	if typeString(some.GetType()) != "(Int) -> Maybe" {
+		t.Fatalf("Expected Some to be a function producing Maybe. Got %s", typeString(some.GetType()))
+	}
+	if typeString(call.GetType()) != "Maybe" {
+		t.Fatalf("Expected Some(1) to be a Maybe. Got %s", typeString(call.GetType()))
+	}
+}
+
+func TestChecker_SharedConstructors(t *testing.T) {
+	// data Maybe { Some(Int), None, Nil } and data List { Cons(Int), Nil }
+	maybe, table := maybeTable()
+	maybe.Type.(types.DataType).Constructors["Nil"] = types.DataTypeConstructor{Name: "Nil"}
+	list := &ast.TypeDeclStmt{Name: "List", Type: types.DataType{Name: "List", Constructors: map[string]types.DataTypeConstructor{
+		"Cons": {Name: "Cons", Params: []types.Type{intType}},
+		"Nil":  {Name: "Nil"},
+	}}}
+	table.RegisterType(list)
+	for _, dataType := range []string{"Maybe", "List"} {
+		table.RegisterConstructor(&ast.DataConstructorDecl{Name: "Nil", DataType: dataType,
+			Signature: &types.FunctionType{ReturnType: types.UnresolvedType{Name: dataType}}})
+	}
+	let := func(name string, t types.Type, value ast.Expression) *ast.VarDeclStmt {
+		decl := &ast.VarDeclStmt{Keyword: "let", Name: name, Type: t, Value: value}
+		table.RegisterVariable(decl)
+		return decl
+	}
+	qualified := func(dataType, ctor string) *ast.MemberAccessExpr {
+		return &ast.MemberAccessExpr{Object: ident(dataType), Member: ctor}
+	}
+
+	expected := ident("Nil")
+	maybeNil := qualified("Maybe", "Nil")
+	statements := []ast.AstNode{maybe, list,
+		let("unknown", nil, ident("Nil")),                          // let unknown = Nil
+		let("empty", types.UnresolvedType{Name: "List"}, expected), // let empty: List = Nil
+		let("nothing", nil, maybeNil),                              // let nothing = Maybe.Nil
+		let("missing", nil, qualified("Maybe", "Cons")),            // let missing = Maybe.Cons
+	}
+	var got []string
+	for _, err := range NewChecker(&ast.Program{Statements: statements}, table).Check() {
+		got = append(got, fmt.Sprintf("%s %s", err.Code, err.Message))
+	}
+	want := []string{
+		`LYR0002 ambiguous constructor "Nil": candidates are Maybe.Nil (line 0), List.Nil (line 0); qualify it (e.g. Maybe.Nil) or annotate the expected type`,
+		"LYR0012 data type Maybe has no constructor Cons",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Expected %q. Got %q", want, got)
+	}
+	if typeString(expected.GetType()) != "List" || typeString(maybeNil.GetType()) != "Maybe" {
+		t.Fatalf("Expected Nil resolved to List by the annotation and Maybe.Nil to Maybe. Got %s and %s",
+			typeString(expected.GetType()), typeString(maybeNil.GetType()))
+	}
+}
+
+func TestChecker_IntegerLiteralRanges(t *testing.T) {
+	literal := func(text string) *ast.IntegerLiteralExpr {
+		value, err := ast.ParseInteger(text)
+		if errors.Is(err, strconv.ErrRange) {
+			return &ast.IntegerLiteralExpr{Text: text} // as the collector keeps it
+		}
+		if err != nil {
+			t.Fatalf("ParseInteger(%q) error: %v", text, err)
+		}
+		return &ast.IntegerLiteralExpr{Value: value}
+	}
+	declare := func(name string, t types.PrimitiveTypeName, value ast.Expression) *ast.VarDeclStmt {
+		return &ast.VarDeclStmt{Keyword: "let", Name: name, Type: types.PrimitiveType{Name: t}, Value: value}
+	}
+	var messages []string
+	for _, err := range check(t,
+		declare("small", types.Int8, literal("127")),
+		declare("mask", types.Int8, literal("0xFF")),
+		declare("byte", types.UInt8, literal("0b1111_1111")),
+		declare("mode", types.UInt16, literal("0o7_7_7")),
+		declare("negative", types.UInt32, literal("-1")),
+		declare("million", types.Int32, literal("1_000_000")),
+		declare("all", types.UInt64, literal("0xFFFF_FFFF_FFFF_FFFF")),
+		declare("signed", types.Int64, literal("0xFFFF_FFFF_FFFF_FFFF")),
+		declare("beyond", types.UInt64, literal("18446744073709551616")),
+	) {
+		if err.Code != diagnostics.IntegerOverflow {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		messages = append(messages, err.Message)
+	}
+	expected := []string{
+		"255 overflows Int8, which holds -128 to 127",
+		"-1 overflows UInt32, which holds 0 to 4294967295",
+		"0xFFFF_FFFF_FFFF_FFFF overflows Int64, which holds -9223372036854775808 to 9223372036854775807",
+		"18446744073709551616 overflows UInt64, which holds 0 to 18446744073709551615",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+
+	for _, text := range []string{"1__0", "_1", "1_", "0xG", "0x8000_0000_0000_0000"} {
+		if _, err := ast.ParseInteger(text); err == nil {
+			t.Fatalf("Expected ParseInteger(%q) to fail", text)
+		}
+	}
+	if value, err := ast.ParseInteger("010"); err != nil || value != 10 {
+		t.Fatalf("Expected a leading zero to stay decimal. Got %d, %v", value, err)
+	}
+	if value, err := ast.ParseUnsigned("0xFFFF_FFFF_FFFF_FFFF"); err != nil || value != math.MaxUint64 {
+		t.Fatalf("Expected the largest UInt64. Got %d, %v", value, err)
+	}
+	if _, err := ast.ParseUnsigned("0x1_0000_0000_0000_0000"); !errors.Is(err, strconv.ErrRange) {
+		t.Fatalf("Expected a range error past 64 bits. Got %v", err)
+	}
+}
+
+func TestChecker_FloatConstants(t *testing.T) {
+	floatType := types.PrimitiveType{Name: types.Float}
+	// let ratio: Float = 1.5e-3
+	ratio := &ast.VarDeclStmt{Keyword: "let", Name: "ratio", Type: floatType, Value: &ast.FloatLiteralExpr{Value: 1.5e-3, Text: "1.5e-3"}}
+	// def unbounded: (Float) -> Bool = (x) => x == Inf
+	// def missing: (Float) -> Bool = (x) => x != NaN
+	// def shadowed: (Float) -> Bool = (NaN) => NaN == NaN
+	compare := func(name, param string, left ast.Expression, op ast.BooleanBinaryOp, right string) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{Name: name,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: floatType}}, ReturnType: boolType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params(param), Body: &ast.BooleanBinaryOpExpr{Left: left, Operator: op, Right: ident(right)}}},
+		}
+	}
+	var messages []string
+	for _, err := range check(t, ratio,
+		compare("unbounded", "x", ident("x"), ast.BooleanBinaryOpEq, "Inf"),
+		compare("missing", "x", ident("x"), ast.BooleanBinaryOpNEq, "NaN"),
+		compare("shadowed", "NaN", ident("NaN"), ast.BooleanBinaryOpEq, "NaN"),
+	) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"0:0: warning: comparison with NaN is always true: NaN is unequal to everything, itself included; test x != x instead [LYR0028]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	if got := ratio.Value.GetName(); got != "1.5e-3" {
+		t.Fatalf("Expected the literal as written. Got %s", got)
+	}
+}
+
+func TestChecker_RenderTemplates(t *testing.T) {
+	intType := types.PrimitiveType{Name: types.Int}
+	stringType := types.PrimitiveType{Name: types.String}
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType}, "y": {Name: "y", Type: intType},
+	}}}
+	pointType := types.UnresolvedType{Name: "Point"}
+	// let pair = """
+	//	({x}, {y})
+	//	"""
+	pair := &ast.VarDeclStmt{Keyword: "let", Name: "pair", Type: stringType, Value: &ast.MultilineStringLiteralExpr{Value: "({x}, {y})"}}
+	render := func(name string, template ast.Expression, paramType types.Type) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{Name: name,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: paramType}}, ReturnType: stringType},
+			Clauses: []*ast.FunctionClause{{Parameters: params("p"), Body: &ast.CallExpr{
+				Callee: ident("render"), Arguments: []ast.Expression{template, ident("p")},
+			}}},
+		}
+	}
+	var messages []string
+	for _, err := range check(t, point, pair,
+		render("show", ident("pair"), pointType),
+		render("depth", &ast.StringLiteralExpr{Value: "{x}, {z}"}, pointType),
+		render("broken", &ast.RawStringLiteralExpr{Value: "{x"}, pointType),
+		render("number", &ast.StringLiteralExpr{Value: "{x}"}, intType),
+	) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"0:0: render: placeholder {z} names no field of Point [LYR0013]",
+		"0:0: render: unclosed { in template: write {{ for a brace [LYR0029]",
+		"0:0: render: Int is not a struct, so a template has no fields to name [LYR0014]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestChecker_InitializationCycles(t *testing.T) {
+	at := func(name string, line, col int) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + len(name)}}}, Name: name}
+	}
+	let := func(name string, line int, value ast.Expression) *ast.VarDeclStmt {
+		return &ast.VarDeclStmt{Keyword: "let", Name: name, NameLocation: ast.Location{StartLine: line, StartCol: 5, EndLine: line, EndCol: 5 + len(name)}, Type: intType, Value: value}
+	}
+	def := func(name, param string, body ast.Expression) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{Name: name,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params(param), Body: body}},
+		}
+	}
+	one := &ast.IntegerLiteralExpr{Value: 1}
+	// let total: Int = grow(1)
+	// def grow: (Int) -> Int = (n) => n + rate
+	// let rate: Int = total / 10
+	// let tenth: Int = rate
+	// def count: (Int) -> Int = (n) => count(n)
+	// def shadow: (Int) -> Int = (total) => total
+	// let shadowed: Int = shadow(count(1))
+	statements := []ast.AstNode{
+		let("total", 1, &ast.CallExpr{Callee: at("grow", 1, 18), Arguments: []ast.Expression{one}}),
+		def("grow", "n", &ast.BinaryOpExpr{Left: ident("n"), Operator: "+", Right: at("rate", 2, 38)}),
+		let("rate", 3, &ast.BinaryOpExpr{Left: at("total", 3, 17), Operator: "/", Right: &ast.IntegerLiteralExpr{Value: 10}}),
+		let("tenth", 4, at("rate", 4, 18)),
+		def("count", "n", &ast.CallExpr{Callee: ident("count"), Arguments: []ast.Expression{ident("n")}}),
+		def("shadow", "total", ident("total")),
+		let("shadowed", 7, &ast.CallExpr{Callee: ident("shadow"), Arguments: []ast.Expression{
+			&ast.CallExpr{Callee: ident("count"), Arguments: []ast.Expression{one}},
+		}}),
+	}
+
+	errors := check(t, statements...)
+	if len(errors) != 1 || errors[0].Code != diagnostics.InitializationCycle {
+		t.Fatalf("Expected one initialization cycle. Got %v", errors)
+	}
+	expected := "1:5: initialization cycle: total calls grow at 1:18, grow refers to rate at 2:38, rate refers to total at 3:17; " +
+		"break it at rate -> total, e.g. by making rate a function [LYR0032]"
+	if errors[0].Error() != expected {
+		t.Fatalf("Expected %q. Got %q", expected, errors[0].Error())
+	}
+	var related []string
+	for _, r := range errors[0].Related {
+		related = append(related, fmt.Sprintf("%d:%d %s", r.Location.StartLine, r.Location.StartCol, r.Message))
+	}
+	if strings.Join(related, "; ") != "1:18 total calls grow; 2:38 grow refers to rate; 3:17 rate refers to total" {
+		t.Fatalf("Expected the references of the cycle related in order. Got %q", related)
+	}
+}
+
+func TestChecker_UseBeforeDeclaration(t *testing.T) {
+	at := func(name string, line, col int) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + len(name)}}}, Name: name}
+	}
+	let := func(name string, line int, lazy bool, value ast.Expression) *ast.VarDeclStmt {
+		return &ast.VarDeclStmt{Keyword: "let", IsLazy: lazy, Name: name, NameLocation: ast.Location{StartLine: line, StartCol: 5, EndLine: line, EndCol: 5 + len(name)}, Type: intType, Value: value}
+	}
+	// let area: Int = side * side
+	// let perimeter: Int = grow(4)
+	// def grow: (Int) -> Int = (n) => n * width
+	// lazy let side: Int = width
+	// let width: Int = 4
+	// let height: Int = width
+	statements := []ast.AstNode{
+		let("area", 1, false, &ast.BinaryOpExpr{Left: at("side", 1, 18), Operator: "*", Right: at("side", 1, 25)}),
+		let("perimeter", 2, false, &ast.CallExpr{Callee: at("grow", 2, 22), Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 4}}}),
+		&ast.FunctionDefStmt{Name: "grow",
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params("n"), Body: &ast.BinaryOpExpr{Left: ident("n"), Operator: "*", Right: at("width", 3, 38)}}},
+		},
+		let("side", 4, true, at("width", 4, 22)),
+		let("width", 5, false, &ast.IntegerLiteralExpr{Value: 4}),
+		let("height", 6, false, at("width", 6, 19)),
+	}
+
+	var messages []string
+	for _, err := range check(t, statements...) {
+		if err.Code != diagnostics.UseBeforeDeclaration {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"1:18: width is used through side before its declaration at 5:5; declare it earlier or make it lazy [LYR0033]",
+		"2:22: width is used through grow before its declaration at 5:5; declare it earlier or make it lazy [LYR0033]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestChecker_ClauseBindings(t *testing.T) {
+	loc := func(line, col, length int) ast.Location {
+		return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+	}
+	param := func(name string, line, col int) ast.Pattern {
+		return &ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: loc(line, col, len(name))}, Name: name}
+	}
+	at := func(name string, line, col int) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(line, col, len(name))}}, Name: name}
+	}
+	compare := func(left *ast.IdentifierExpr, op ast.BooleanBinaryOp, right ast.Expression) *ast.GuardExpr {
+		return &ast.GuardExpr{Condition: &ast.BooleanBinaryOpExpr{Left: left, Operator: op, Right: right}}
+	}
+	// let limit: Int = 10
+	// def pick: (Int, Int) -> Int = {
+	//	(a, b) if a < b => a,
+	//	(limit, limit) if limit > 0 => 0,
+	//	(c, d) if a > c => d,
+	// }
+	limit := &ast.VarDeclStmt{Keyword: "let", Name: "limit", Type: intType, Value: &ast.IntegerLiteralExpr{Value: 10}}
+	pick := &ast.FunctionDefStmt{Name: "pick",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{
+			{Parameters: []ast.Pattern{param("a", 3, 3), param("b", 3, 6)}, Guard: compare(at("a", 3, 12), ast.BooleanBinaryOpLT, at("b", 3, 16)), Body: at("a", 3, 21)},
+			{Parameters: []ast.Pattern{param("limit", 4, 3), param("limit", 4, 10)}, Guard: compare(at("limit", 4, 20), ast.BooleanBinaryOpGT, &ast.IntegerLiteralExpr{Value: 0}), Body: &ast.IntegerLiteralExpr{Value: 0}},
+			{Parameters: []ast.Pattern{param("c", 5, 3), param("d", 5, 6)}, Guard: compare(at("a", 5, 12), ast.BooleanBinaryOpGT, at("c", 5, 16)), Body: at("d", 5, 21)},
+		},
+	}
+
+	var messages []string
+	for _, err := range check(t, limit, pick) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"4:10: warning: limit is already bound at 4:3 by this clause; the guard and body see only this binding [LYR0034]",
+		"4:20: warning: the guard reads the parameter limit bound at 4:10, which shadows the top-level limit [LYR0034]",
+		"5:12: undefined: a (bound by the clause at 3:3, which this clause cannot see) [LYR0001]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+}
+
+func TestChecker_NestedFunctions(t *testing.T) {
+	loc := func(line, col, length int) ast.Location {
+		return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+	}
+	param := func(name string) ast.Pattern {
+		return &ast.IdentifierPattern{Name: name}
+	}
+	at := func(name string, line, col int) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(line, col, len(name))}}, Name: name}
+	}
+	unary := &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType}
+	nested := func(name string, body ast.Expression) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{Name: name, Signature: unary, Clauses: []*ast.FunctionClause{{Parameters: []ast.Pattern{param("x")}, Body: body}}}
+	}
+	// def scale: (Int) -> Int = {
+	//	(n) => {
+	//		def shift: (Int) -> Int = { (x) => x + n }
+	//		def twice: (Int) -> Int = { (x) => shift(shift(x)) }
+	//		twice(n)
+	//	}
+	// }
+	// let lost: Int = twice(1)
+	scale := &ast.FunctionDefStmt{Name: "scale", Signature: unary,
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{param("n")},
+			Functions: []*ast.FunctionDefStmt{
+				nested("shift", &ast.BinaryOpExpr{Left: at("x", 3, 39), Operator: "+", Right: at("n", 3, 43)}),
+				nested("twice", &ast.CallExpr{Callee: at("shift", 4, 38), Arguments: []ast.Expression{&ast.CallExpr{Callee: at("shift", 4, 44), Arguments: []ast.Expression{at("x", 4, 50)}}}}),
+			},
+			Body: &ast.CallExpr{Callee: at("twice", 5, 3), Arguments: []ast.Expression{at("n", 5, 9)}},
+		}},
+	}
+	lost := &ast.VarDeclStmt{Keyword: "let", Name: "lost", Type: intType,
+		Value: &ast.CallExpr{Callee: at("twice", 8, 18), Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}}}}
+
+	var messages []string
+	for _, err := range check(t, scale, lost) {
+		messages = append(messages, err.Error())
+	}
+	if len(messages) != 1 || !strings.HasPrefix(messages[0], "8:18: undefined: twice") {
+		t.Fatalf("Expected only twice to be undefined outside scale. Got %q", messages)
+	}
+}
+
+func TestChecker_Destructuring(t *testing.T) {
+	loc := func(line, col, length int) ast.Location {
+		return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+	}
+	ident := func(name string, line, col int) *ast.IdentifierPattern {
+		return &ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: loc(line, col, len(name))}, Name: name}
+	}
+	tuple := func(line int, names ...string) *ast.TuplePattern {
+		pattern := &ast.TuplePattern{PatternBase: ast.PatternBase{Location: loc(line, 5, 3*len(names))}}
+		for i, name := range names {
+			pattern.Elements = append(pattern.Elements, ident(name, line, 6+3*i))
+		}
+		return pattern
+	}
+	destructure := func(pattern ast.Pattern, callee string) *ast.DestructuringDeclStmt {
+		decl := &ast.DestructuringDeclStmt{Keyword: "let", Pattern: pattern,
+			Value: &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: callee}, Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}, &ast.IntegerLiteralExpr{Value: 2}}}}
+		var declare func(p ast.Pattern)
+		declare = func(p ast.Pattern) {
+			switch p := p.(type) {
+			case *ast.IdentifierPattern:
+				decl.Bindings = append(decl.Bindings, &ast.VarDeclStmt{Keyword: "let", Name: p.Name, NameLocation: p.Location})
+			case *ast.TuplePattern:
+				for _, element := range p.Elements {
+					declare(element)
+				}
+			case *ast.StructPattern:
+				for _, field := range p.Fields {
+					decl.Bindings = append(decl.Bindings, &ast.VarDeclStmt{Keyword: "let", Name: field.Name, NameLocation: field.NameLocation})
+				}
+			}
+		}
+		declare(pattern)
+		return decl
+	}
+	binary := &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}}
+	// extern def divmod: (Int, Int) -> (Int, Int) = "go:divmod"
+	// struct Size { w: Int, h: Int }
+	// def measure: (Int, Int) -> Size = { }
+	// let (q, r) = divmod(1, 2)
+	// let (a, b, c) = divmod(1, 2)
+	// let (x, y) = measure(1, 2)
+	// let Size { w, depth } = measure(1, 2)
+	// let flag: Bool = w
+	divmodSignature := *binary
+	divmodSignature.ReturnType = types.TupleType{Elements: []types.Type{intType, intType}}
+	divmod := &ast.FunctionDefStmt{Name: "divmod", Extern: "go:divmod", Signature: &divmodSignature}
+	size := &ast.TypeDeclStmt{Name: "Size", Type: types.StructType{Name: "Size", Fields: map[string]types.StructField{
+		"w": {Name: "w", Type: intType},
+		"h": {Name: "h", Type: intType},
+	}}}
+	measureSignature := *binary
+	measureSignature.ReturnType = types.UnresolvedType{Name: "Size"}
+	measure := &ast.FunctionDefStmt{Name: "measure", Signature: &measureSignature}
+	sizePattern := &ast.StructPattern{PatternBase: ast.PatternBase{Location: loc(7, 5, 16)}, TypeName: "Size", Fields: []*ast.StructPatternField{
+		{Name: "w", NameLocation: loc(7, 12, 1)},
+		{Name: "depth", NameLocation: loc(7, 15, 5)},
+	}}
+	flag := &ast.VarDeclStmt{Keyword: "let", Name: "flag", Type: boolType, Value: &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(8, 18, 1)}}, Name: "w"}}
+
+	statements := []ast.AstNode{divmod, size, measure,
+		destructure(tuple(4, "q", "r"), "divmod"),
+		destructure(tuple(5, "a", "b", "c"), "divmod"),
+		destructure(tuple(6, "x", "y"), "measure"),
+		destructure(sizePattern, "measure"),
+		flag,
+	}
+	var messages []string
+	for _, err := range check(t, statements...) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"5:5: cannot destructure into (a, b, c): divmod is declared to return (Int, Int), which has 2 elements, not 3 [LYR0003]",
+		"6:5: cannot destructure into (x, y): measure is declared to return Size, which is not a tuple [LYR0003]",
+		"7:15: struct Size has no field depth [LYR0013]",
+		"8:18: cannot use Int as Bool in declaration of flag [LYR0003]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	q := statements[3].(*ast.DestructuringDeclStmt).Bindings[0]
+	if !types.TypesEqual(q.Type, intType) {
+		t.Fatalf("Expected q to be typed Int from the tuple. Got %v", q.Type)
+	}
+}
+
+func TestChecker_TupleLiterals(t *testing.T) {
+	loc := func(line, col, length int) ast.Location {
+		return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+	}
+	tuple := func(line, col int, elements ...ast.Expression) *ast.TupleLiteralExpr {
+		return &ast.TupleLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(line, col, 8)}}, Elements: elements}
+	}
+	literal := func(line, col int, value int64) *ast.IntegerLiteralExpr {
+		return &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(line, col, 1)}}, Value: value}
+	}
+	pairType := types.TupleType{Elements: []types.Type{intType, intType}}
+	// def divmod: (Int, Int) -> (Int, Int) = (a, b) => (a / b, a % b)
+	// let (q, r) = divmod(7, 2)
+	// let named: (Int, String) = (1, 2)
+	// let triple: (Int, Int) = (1, 2, 3)
+	divmod := &ast.FunctionDefStmt{Name: "divmod",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}, ReturnType: pairType},
+		Clauses: []*ast.FunctionClause{{Parameters: params("a", "b"), Body: tuple(1, 42,
+			&ast.BinaryOpExpr{Left: ident("a"), Operator: "/", Right: ident("b")},
+			&ast.BinaryOpExpr{Left: ident("a"), Operator: "%", Right: ident("b")},
+		)}},
+	}
+	q := &ast.VarDeclStmt{Keyword: "let", Name: "q"}
+	r := &ast.VarDeclStmt{Keyword: "let", Name: "r"}
+	quotient := &ast.DestructuringDeclStmt{Keyword: "let", Bindings: []*ast.VarDeclStmt{q, r},
+		Pattern: &ast.TuplePattern{Elements: []ast.Pattern{&ast.IdentifierPattern{Name: "q"}, &ast.IdentifierPattern{Name: "r"}}},
+		Value:   &ast.CallExpr{Callee: ident("divmod"), Arguments: []ast.Expression{literal(2, 21, 7), literal(2, 24, 2)}},
+	}
+	named := &ast.VarDeclStmt{Keyword: "let", Name: "named", Type: types.TupleType{Elements: []types.Type{intType, stringType}},
+		Value: tuple(3, 28, literal(3, 29, 1), literal(3, 32, 2))}
+	triple := &ast.VarDeclStmt{Keyword: "let", Name: "triple", Type: pairType,
+		Value: tuple(4, 26, literal(4, 27, 1), literal(4, 30, 2), literal(4, 33, 3))}
+
+	var messages []string
+	for _, err := range check(t, divmod, quotient, named, triple) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"3:32: element 2 of the tuple is Int but String is expected [LYR0003]",
+		"4:26: cannot use (Int, Int, Int) as (Int, Int) in declaration of triple [LYR0003]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	if body := divmod.Clauses[0].Body; !types.TypesEqual(body.GetType(), pairType) {
+		t.Fatalf("Expected the body of divmod to be (Int, Int). Got %v", body.GetType())
+	}
+	if !types.TypesEqual(q.Type, intType) || !types.TypesEqual(r.Type, intType) {
+		t.Fatalf("Expected q and r typed Int from the tuple divmod builds. Got %v and %v", q.Type, r.Type)
+	}
+}
+
+func TestChecker_Lambdas(t *testing.T) {
+	lambda := func(body ast.Expression, names ...string) *ast.LambdaExpr {
+		return &ast.LambdaExpr{Parameters: params(names...), Body: body}
+	}
+	declare := func(name string, t types.Type, value ast.Expression) *ast.VarDeclStmt {
+		return &ast.VarDeclStmt{Keyword: "let", Name: name, Type: t, Value: value}
+	}
+	one := &ast.IntegerLiteralExpr{Value: 1}
+	// let inc = (x) => x + 1
+	// let id = (x) => x
+	// let add = (x, y) => x + y
+	// let positive = (n) => n > 0
+	// let constant = (x) => (y) => x
+	// let shout: (String) -> String = (s) => s
+	// let two: Int = inc(1)
+	// let mixed = (b) => if b then b + 1 else 0
+	statements := []ast.AstNode{
+		declare("inc", nil, lambda(&ast.BinaryOpExpr{Left: ident("x"), Operator: "+", Right: one}, "x")),
+		declare("id", nil, lambda(ident("x"), "x")),
+		declare("add", nil, lambda(&ast.BinaryOpExpr{Left: ident("x"), Operator: "+", Right: ident("y")}, "x", "y")),
+		declare("positive", nil, lambda(&ast.BooleanBinaryOpExpr{Left: ident("n"), Operator: ast.BooleanBinaryOpGT, Right: &ast.IntegerLiteralExpr{Value: 0}}, "n")),
+		declare("constant", nil, lambda(lambda(ident("x"), "y"), "x")),
+		declare("shout", &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: stringType}}, ReturnType: stringType}, lambda(ident("s"), "s")),
+		declare("two", intType, &ast.CallExpr{Callee: ident("inc"), Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}}}),
+		declare("mixed", nil, lambda(&ast.IfThenExpr{
+			Condition: ident("b"),
+			Then:      &ast.BinaryOpExpr{Left: ident("b"), Operator: "+", Right: &ast.IntegerLiteralExpr{Value: 1}},
+			Else:      &ast.IntegerLiteralExpr{Value: 0},
+		}, "b")),
+	}
+	var messages []string
+	for _, err := range check(t, statements...) {
+		messages = append(messages, err.Message)
+	}
+	if expected := []string{"cannot perform arithmetic on Bool and Int"}; strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	for i, expected := range []string{
+		"(Int) -> Int",
+		"(a) -> a",
+		"(a, a) -> a",
+		"(Int) -> Bool",
+		"(a) -> (b) -> a",
+		"(String) -> String",
+	} {
+		value := statements[i].(*ast.VarDeclStmt).Value
+		if got := typeString(value.GetType()); got != expected {
+			t.Fatalf("Expected %s to be %s. Got %s", statements[i].(*ast.VarDeclStmt).Name, expected, got)
+		}
+	}
+	if x := statements[0].(*ast.VarDeclStmt).Value.(*ast.LambdaExpr).Body.(*ast.BinaryOpExpr).Left; typeString(x.GetType()) != "Int" {
+		t.Fatalf("Expected the uses of x in inc typed Int. Got %s", typeString(x.GetType()))
+	}
+}
+
+func TestChecker_Inference(t *testing.T) {
+	generic := types.GenericType{Name: "t"}
+	at := func(line, col int) ast.ExprBase {
+		return ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + 1}}}
+	}
+	call := func(name string, args ...ast.Expression) *ast.CallExpr {
+		return &ast.CallExpr{Callee: ident(name), Arguments: args}
+	}
+	integer := func(v int64) *ast.IntegerLiteralExpr { return &ast.IntegerLiteralExpr{Value: v} }
+	// def pair<t>: (t, t) -> t = (a, b) => a
+	// def empty<t>: () -> [t] = { }
+	// let p = pair(1, 2)
+	// let s: String = p
+	// let bad = pair(1, "a")
+	// let early: Bool = later
+	// lazy let later = pair(3, 4)
+	// let none = empty()
+	// var count = 0
+	// count = "x"
+	pair := &ast.FunctionDefStmt{Name: "pair", GenericParams: []string{"t"},
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: generic}, {Type: generic}}, ReturnType: generic},
+		Clauses:   []*ast.FunctionClause{{Parameters: params("a", "b"), Body: ident("a")}},
+	}
+	empty := &ast.FunctionDefStmt{Name: "empty", GenericParams: []string{"t"},
+		Signature: &types.FunctionType{ReturnType: types.ArrayType{ElementType: generic}}}
+	none := &ast.VarDeclStmt{Keyword: "let", Name: "none", Value: call("empty")}
+	statements := []ast.AstNode{pair, empty,
+		&ast.VarDeclStmt{Keyword: "let", Name: "p", Value: call("pair", integer(1), integer(2))},
+		&ast.VarDeclStmt{Keyword: "let", Name: "s", Type: stringType, Value: &ast.IdentifierExpr{ExprBase: at(4, 17), Name: "p"}},
+		&ast.VarDeclStmt{Keyword: "let", Name: "bad", Value: call("pair", integer(1), &ast.StringLiteralExpr{ExprBase: at(5, 19), Value: "a"})},
+		&ast.VarDeclStmt{Keyword: "let", Name: "early", Type: boolType, Value: &ast.IdentifierExpr{ExprBase: at(6, 19), Name: "later"}},
+		&ast.VarDeclStmt{Keyword: "let", IsLazy: true, Name: "later", Value: call("pair", integer(3), integer(4))},
+		none,
+		&ast.VarDeclStmt{Keyword: "var", Name: "count", Value: integer(0)},
+		&ast.VarAssignStmt{Name: "count", Value: &ast.StringLiteralExpr{ExprBase: at(10, 9), Value: "x"}},
+	}
+	var messages []string
+	for _, err := range check(t, statements...) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"4:17: cannot use Int as String in declaration of s [LYR0003]",
+		"5:19: argument 2: expected Int but got String [LYR0007]",
+		"6:19: cannot use Int as Bool in declaration of early [LYR0003]",
+		"10:9: cannot assign String to count of type Int [LYR0003]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	if got := none.Value.GetType(); !types.TypesEqual(got, types.ArrayType{ElementType: generic}) {
+		t.Fatalf("Expected empty() to stay generic as [t]. Got %v", got)
+	}
+}
+
+func TestChecker_GenericInstantiation(t *testing.T) {
+	at := func(line, col int) ast.ExprBase {
+		return ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + 1}}}
+	}
+	generic := func(name string) types.Type { return types.GenericType{Name: name} }
+	function := func(name string, generics []string, ret types.Type, params ...types.Type) *ast.FunctionDefStmt {
+		signature := &types.FunctionType{ReturnType: ret}
+		for _, param := range params {
+			signature.ParameterTypes = append(signature.ParameterTypes, types.ParameterType{Type: param})
+		}
+		return &ast.FunctionDefStmt{Name: name, GenericParams: generics, Signature: signature}
+	}
+	arrow := func(param, ret types.Type) types.Type {
+		return types.FunctionType{ParameterTypes: []types.ParameterType{{Type: param}}, ReturnType: ret}
+	}
+	// def id<t>: (t) -> t = (x) => x
+	// def show<t>: (t) -> String
+	// def apply<a, b>: ((a) -> b, a) -> b
+	// def twice: ((Int) -> Int, Int) -> Int
+	// let n: Int = id(1)
+	// let s: String = apply(id, 2)
+	// let m: Int = twice(id, 3)
+	// let wrong: Int = twice(show, 4)
+	id := function("id", []string{"t"}, generic("t"), generic("t"))
+	id.Clauses = []*ast.FunctionClause{{Parameters: params("x"), Body: ident("x")}}
+	show := function("show", []string{"t"}, stringType, generic("t"))
+	apply := function("apply", []string{"a", "b"}, generic("b"), arrow(generic("a"), generic("b")), generic("a"))
+	twice := function("twice", nil, intType, arrow(intType, intType), intType)
+	call := func(line, col int, name string, args ...ast.Expression) *ast.CallExpr {
+		return &ast.CallExpr{ExprBase: at(line, col), Callee: ident(name), Arguments: args}
+	}
+	integer := func(v int64) *ast.IntegerLiteralExpr { return &ast.IntegerLiteralExpr{Value: v} }
+	n := call(5, 14, "id", integer(1))
+	statements := []ast.AstNode{id, show, apply, twice,
+		&ast.VarDeclStmt{Keyword: "let", Name: "n", Type: intType, Value: n},
+		&ast.VarDeclStmt{Keyword: "let", Name: "s", Type: stringType, Value: call(6, 17, "apply", ident("id"), integer(2))},
+		&ast.VarDeclStmt{Keyword: "let", Name: "m", Type: intType, Value: call(7, 14, "twice", ident("id"), integer(3))},
+		&ast.VarDeclStmt{Keyword: "let", Name: "wrong", Type: intType, Value: call(8, 18, "twice", &ast.IdentifierExpr{ExprBase: at(8, 24), Name: "show"}, integer(4))},
+	}
+	var messages []string
+	for _, err := range check(t, statements...) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"6:17: cannot use Int as String in declaration of s [LYR0003]",
+		"8:24: argument 1: expected (Int) -> Int but got (t) -> String [LYR0007]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	if !types.TypesEqual(n.GetType(), intType) || !types.TypesEqual(n.TypeArguments["t"], intType) {
+		t.Fatalf("Expected id(1) to instantiate t as Int. Got %v with %v", n.GetType(), n.TypeArguments)
+	}
+}
+
+func TestChecker_Matches(t *testing.T) {
+	maybe, table := maybeTable()
+	maybeType := types.UnresolvedType{Name: "Maybe"}
+	clauses := func(name string, patterns ...ast.Pattern) *ast.FunctionDefStmt {
+		fn := &ast.FunctionDefStmt{Name: name, Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: maybeType}}, ReturnType: intType}}
+		for _, pattern := range patterns {
+			fn.Clauses = append(fn.Clauses, &ast.FunctionClause{Parameters: []ast.Pattern{pattern}, Body: &ast.IntegerLiteralExpr{Value: 0}})
+		}
+		table.RegisterFunction(fn)
+		return fn
+	}
+	// def or_zero: (Maybe) -> Int = (Some(n)) => n | (None) => 0
+	orZero := clauses("or_zero", &ast.ConstructorPattern{Name: "Some", Arguments: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}}}, &ast.ConstructorPattern{Name: "None"})
+	// def is_one: (Maybe) -> Int = (Some(1)) => 1 | (m) => 0
+	isOne := clauses("is_one", &ast.ConstructorPattern{Name: "Some", Arguments: []ast.Pattern{&ast.LiteralPattern{Value: int64(1)}}}, &ast.IdentifierPattern{Name: "m"})
+
+	check := NewChecker(&ast.Program{Statements: []ast.AstNode{maybe, orZero, isOne}}, table)
+	check.Check()
+	matches := check.Matches()
+	if len(matches) != 1 || matches[0].Function != "or_zero" || matches[0].DataType != "Maybe" || fmt.Sprint(matches[0].Covered) != "[None Some]" {
+		t.Fatalf("Expected only or_zero to depend on the constructors of Maybe. Got %+v", matches)
+	}
+	if !matches[0].Handles("Some") || matches[0].Handles("Other") {
+		t.Fatalf("Expected or_zero to handle Some and not Other")
+	}
+}
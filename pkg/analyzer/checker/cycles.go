@@ -0,0 +1,304 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// dependency is an edge of the initialization graph: evaluating from needs the
+// value of to, or calls it, at a reference
+type dependency struct {
+	from, to string
+	calls    bool
+	at       ast.Location
+}
+
+func (d dependency) String() string {
+	if d.calls {
+		return fmt.Sprintf("%s calls %s at %d:%d", d.from, d.to, d.at.StartLine, d.at.StartCol)
+	}
+	return fmt.Sprintf("%s refers to %s at %d:%d", d.from, d.to, d.at.StartLine, d.at.StartCol)
+}
+
+// checkInitializationOrder reports each cycle of top-level bindings whose
+// initializers need the values of one another, directly or through the
+// functions they call: whichever is evaluated first reads one not yet
+// initialized. The error is at the first binding of the cycle, with each edge
+// related, and suggests breaking the edge leaving the binding declared last.
+// Outside cycles, it reports the bindings read before their declaration, unless
+// they are lazy.
+func (c *Checker) checkInitializationOrder() {
+	bindings := make(map[string]*ast.VarDeclStmt)
+	functions := make(map[string]*ast.FunctionDefStmt)
+	declared := make(map[string]int)
+	for i, stmt := range c.program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			bindings[s.Name], declared[s.Name] = s, i
+		case *ast.FunctionDefStmt:
+			functions[s.Name], declared[s.Name] = s, i
+		}
+	}
+	if len(bindings) == 0 {
+		return
+	}
+
+	graph := make(map[string][]dependency)
+	dependencies := func(from string, expr ast.Expression, bound map[string]bool) {
+		walkExpression(expr, func(e ast.Expression) {
+			switch e := e.(type) {
+			case *ast.CallExpr:
+				if callee, ok := e.Callee.(*ast.IdentifierExpr); ok && functions[callee.Name] != nil && !bound[callee.Name] {
+					graph[from] = append(graph[from], dependency{from, callee.Name, true, callee.Location})
+				}
+			case *ast.IdentifierExpr:
+				if bindings[e.Name] != nil && !bound[e.Name] {
+					graph[from] = append(graph[from], dependency{from, e.Name, false, e.Location})
+				}
+			}
+		})
+	}
+	for _, stmt := range c.program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			dependencies(s.Name, s.Value, nil)
+		case *ast.FunctionDefStmt:
+			var visit func(fn *ast.FunctionDefStmt, outer map[string]bool)
+			visit = func(fn *ast.FunctionDefStmt, outer map[string]bool) {
+				for _, clause := range fn.Clauses {
+					bound := make(map[string]bool, len(outer))
+					for name := range outer {
+						bound[name] = true
+					}
+					for _, param := range clause.Parameters {
+						bindsNames(param, bound)
+					}
+					if clause.Guard != nil {
+						dependencies(s.Name, clause.Guard.Condition, bound)
+					}
+					for _, nested := range clause.Functions {
+						bound[nested.Name] = true
+					}
+					// calling s may run its nested functions, so what they need, s needs
+					for _, nested := range clause.Functions {
+						visit(nested, bound)
+					}
+					dependencies(s.Name, clause.Body, bound)
+				}
+			}
+			visit(s, nil)
+		}
+	}
+
+	reported := make(map[string]bool)
+	for _, stmt := range c.program.Statements {
+		decl, ok := stmt.(*ast.VarDeclStmt)
+		if !ok || reported[decl.Name] {
+			continue
+		}
+		cycle := shortestCycle(graph, decl.Name)
+		if cycle == nil {
+			continue
+		}
+		names := make([]string, len(cycle))
+		seen := false
+		for i, edge := range cycle {
+			names[i] = edge.String()
+			seen = seen || reported[edge.to]
+		}
+		if seen {
+			continue // the same cycle, found from an earlier binding
+		}
+		var breakAt dependency
+		for _, edge := range cycle {
+			reported[edge.from] = true
+			if bindings[edge.from] != nil && (breakAt.from == "" || declared[edge.from] > declared[breakAt.from]) {
+				breakAt = edge
+			}
+		}
+		c.errors = append(c.errors, TypeError{
+			Code:     diagnostics.InitializationCycle,
+			Severity: diagnostics.Error,
+			Message: fmt.Sprintf("initialization cycle: %s; break it at %s -> %s, e.g. by making %s a function",
+				strings.Join(names, ", "), breakAt.from, breakAt.to, breakAt.from),
+			Location: decl.NameLocation,
+			Related:  cycleEdges(cycle),
+		})
+	}
+
+	for _, stmt := range c.program.Statements {
+		decl, ok := stmt.(*ast.VarDeclStmt)
+		if !ok || decl.IsLazy || reported[decl.Name] {
+			continue
+		}
+		for _, path := range readsAhead(graph, bindings, declared, decl.Name) {
+			read := bindings[path[len(path)-1].to]
+			through := ""
+			if len(path) > 1 {
+				via := make([]string, len(path)-1)
+				for i, edge := range path[1:] {
+					via[i] = edge.from
+				}
+				through = " through " + strings.Join(via, ", ")
+			}
+			c.error(diagnostics.UseBeforeDeclaration, path[0].at,
+				"%s is used%s before its declaration at %d:%d; declare it earlier or make it lazy",
+				read.Name, through, read.NameLocation.StartLine, read.NameLocation.StartCol)
+		}
+	}
+}
+
+// readsAhead returns the paths by which initializing the binding start reads a
+// binding declared after it that is not lazy, in the order they are found. The
+// functions it calls and the lazy bindings it reads are evaluated along with it,
+// so the paths go through them.
+func readsAhead(graph map[string][]dependency, bindings map[string]*ast.VarDeclStmt, declared map[string]int, start string) [][]dependency {
+	var found [][]dependency
+	reached := map[string]dependency{start: {}}
+	queue := []string{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, edge := range graph[node] {
+			if _, ok := reached[edge.to]; ok {
+				continue
+			}
+			reached[edge.to] = edge
+			if decl := bindings[edge.to]; decl == nil || decl.IsLazy {
+				queue = append(queue, edge.to)
+				continue
+			}
+			if declared[edge.to] > declared[start] {
+				path := []dependency{edge}
+				for at := edge.from; at != start; at = reached[at].from {
+					path = append([]dependency{reached[at]}, path...)
+				}
+				found = append(found, path)
+			}
+		}
+	}
+	return found
+}
+
+// shortestCycle returns the edges of the shortest path from start back to
+// itself, nil if there is none. Edges are followed in source order, so the same
+// program always finds the same cycle.
+func shortestCycle(graph map[string][]dependency, start string) []dependency {
+	reached := map[string]dependency{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, edge := range graph[node] {
+			if edge.to == start {
+				cycle := []dependency{edge}
+				for at := node; at != start; at = reached[at].from {
+					cycle = append([]dependency{reached[at]}, cycle...)
+				}
+				return cycle
+			}
+			if _, ok := reached[edge.to]; !ok {
+				reached[edge.to] = edge
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return nil
+}
+
+// cycleEdges relates each reference of a cycle to the error reporting it
+func cycleEdges(cycle []dependency) []Related {
+	related := make([]Related, len(cycle))
+	for i, edge := range cycle {
+		verb := "refers to"
+		if edge.calls {
+			verb = "calls"
+		}
+		related[i] = Related{Location: edge.at, Message: fmt.Sprintf("%s %s %s", edge.from, verb, edge.to)}
+	}
+	return related
+}
+
+// bindsNames adds the names a parameter pattern binds to bound
+func bindsNames(pattern ast.Pattern, bound map[string]bool) {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		bound[p.Name] = true
+	case *ast.AsPattern:
+		bound[p.Name] = true
+		bindsNames(p.Pattern, bound)
+	case *ast.StructPattern:
+		for _, field := range p.Fields {
+			if field.Pattern == nil {
+				bound[field.Name] = true
+				continue
+			}
+			bindsNames(field.Pattern, bound)
+		}
+	case *ast.TuplePattern:
+		for _, element := range p.Elements {
+			bindsNames(element, bound)
+		}
+	case *ast.ConstructorPattern:
+		for _, argument := range p.Arguments {
+			bindsNames(argument, bound)
+		}
+	case *ast.ArrayPattern:
+		for _, element := range p.Elements {
+			bindsNames(element, bound)
+		}
+		if p.Rest != nil {
+			bindsNames(p.Rest, bound)
+		}
+	}
+}
+
+// walkExpression calls visit for expr and every expression within it
+func walkExpression(expr ast.Expression, visit func(ast.Expression)) {
+	if expr == nil {
+		return
+	}
+	visit(expr)
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		walkExpression(e.Callee, visit)
+		for _, argument := range e.Arguments {
+			walkExpression(argument, visit)
+		}
+	case *ast.BinaryOpExpr:
+		walkExpression(e.Left, visit)
+		walkExpression(e.Right, visit)
+	case *ast.BooleanBinaryOpExpr:
+		walkExpression(e.Left, visit)
+		walkExpression(e.Right, visit)
+	case *ast.GuardExpr:
+		walkExpression(e.Condition, visit)
+	case *ast.IfThenExpr:
+		walkExpression(e.Condition, visit)
+		walkExpression(e.Then, visit)
+		walkExpression(e.Else, visit)
+	case *ast.IfBlockExpr:
+		walkExpression(e.Condition, visit)
+		walkExpression(e.Then, visit)
+		walkExpression(e.Else, visit)
+	case *ast.MemberAccessExpr:
+		walkExpression(e.Object, visit)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			walkExpression(field.Value, visit)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			walkExpression(element, visit)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			walkExpression(element, visit)
+		}
+	case *ast.LambdaExpr:
+		walkExpression(e.Body, visit)
+	}
+}
@@ -0,0 +1,112 @@
+package checker
+
+import (
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// The traits @derive can synthesize: Serialize enables to_json, Deserialize from_json
+const (
+	Serialize   = "Serialize"
+	Deserialize = "Deserialize"
+)
+
+// DerivableTraits lists the traits @derive accepts, in documentation order
+var DerivableTraits = []string{Serialize, Deserialize}
+
+// TraitStatus is whether and how a declared type implements a trait
+type TraitStatus string
+
+const (
+	TraitDerived     TraitStatus = "derived"     // named by the @derive of the type
+	TraitImplemented TraitStatus = "implemented" // the type aliases a type implementing the trait
+	TraitMissing     TraitStatus = "missing"
+)
+
+// TraitStatusOf reports whether decl implements trait, as lyra traits and the
+// trait matrix of the language server show it
+func TraitStatusOf(table *symbols.SymbolTable, decl *ast.TypeDeclStmt, trait string) TraitStatus {
+	if decl.DerivesTrait(trait) {
+		return TraitDerived
+	}
+	switch decl.Type.(type) {
+	case types.StructType, types.DataType:
+		return TraitMissing
+	}
+	c := &Checker{table: table}
+	if decl.Type != nil && c.serializable(decl.Type, trait) {
+		return TraitImplemented
+	}
+	return TraitMissing
+}
+
+// checkDerives validates the @derive attribute of a type declaration: only
+// derivable traits, and every field and constructor argument must implement them
+func (c *Checker) checkDerives(decl *ast.TypeDeclStmt) {
+	for _, trait := range decl.Derives {
+		if trait != Serialize && trait != Deserialize {
+			c.error(diagnostics.InvalidDerive, decl.Location, "cannot derive %s for %s: only Serialize and Deserialize are derivable", trait, decl.Name)
+			continue
+		}
+		switch t := decl.Type.(type) {
+		case types.StructType:
+			c.checkDerivedFields(decl, trait, "", t.Fields)
+		case types.DataType:
+			names := make([]string, 0, len(t.Constructors))
+			for name := range t.Constructors {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				ctor := t.Constructors[name]
+				c.checkDerivedFields(decl, trait, name+".", ctor.Fields)
+				for i, param := range ctor.Params {
+					if !c.serializable(param, trait) {
+						c.error(diagnostics.InvalidDerive, decl.Location, "%s derives %s but argument %d of %s has type %s, which does not",
+							decl.Name, trait, i+1, name, typeString(param))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *Checker) checkDerivedFields(decl *ast.TypeDeclStmt, trait, prefix string, fields map[string]types.StructField) {
+	for _, name := range sortedFieldNames(fields) {
+		if t := fields[name].Type; !c.serializable(t, trait) {
+			c.error(diagnostics.InvalidDerive, decl.Location, "%s derives %s but field %s%s has type %s, which does not",
+				decl.Name, trait, prefix, name, typeString(t))
+		}
+	}
+}
+
+// serializable reports whether values of t implement trait: numbers, Bool,
+// String and Unit do, arrays when their elements do, and declared
+// types when they derive it. Generic parameters are accepted here and checked
+// where the type is used.
+func (c *Checker) serializable(t types.Type, trait string) bool {
+	switch t := t.(type) {
+	case types.PrimitiveType:
+		return t.Name != types.Never
+	case types.ArrayType:
+		return c.serializable(t.ElementType, trait)
+	case types.GenericType:
+		return true
+	case types.UnresolvedType:
+		return c.derives(t.Name, trait)
+	case types.StructType:
+		return c.derives(t.Name, trait)
+	case types.DataType:
+		return c.derives(t.Name, trait)
+	}
+	return false
+}
+
+func (c *Checker) derives(name, trait string) bool {
+	decl, ok := c.table.Types[name]
+	return ok && decl.DerivesTrait(trait)
+}
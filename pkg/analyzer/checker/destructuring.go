@@ -0,0 +1,91 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkDestructuring checks the value of a destructuring declaration against its
+// pattern and gives each binding the type of the part it binds. A binding whose
+// part does not fit the pattern is left without a type.
+func (c *Checker) checkDestructuring(decl *ast.DestructuringDeclStmt) {
+	valueType := c.CheckExpression(decl.Value, nil)
+	if valueType == nil {
+		return
+	}
+	bindings := make(map[string]*ast.VarDeclStmt, len(decl.Bindings))
+	for _, binding := range decl.Bindings {
+		bindings[binding.Name] = binding
+	}
+	c.destructure(decl.Pattern, valueType, c.valueSource(decl.Value, valueType), bindings)
+}
+
+// valueSource describes the value of a destructuring declaration for its errors:
+// by the declared return type of the function called, if it is a call
+func (c *Checker) valueSource(value ast.Expression, t types.Type) string {
+	if call, ok := value.(*ast.CallExpr); ok {
+		if callee, ok := call.Callee.(*ast.IdentifierExpr); ok {
+			if fn, ok := c.table.Functions[callee.Name]; ok && fn.Signature != nil {
+				return fmt.Sprintf("%s is declared to return %s", callee.Name, typeString(fn.Signature.ReturnType))
+			}
+		}
+	}
+	return "the value is " + typeString(t)
+}
+
+// destructure binds the names of pattern to the parts of a value of type t,
+// described by source, reporting the parts the pattern does not fit
+func (c *Checker) destructure(pattern ast.Pattern, t types.Type, source string, bindings map[string]*ast.VarDeclStmt) {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		if binding, ok := bindings[p.Name]; ok {
+			binding.Type = t
+		}
+	case *ast.AsPattern:
+		if binding, ok := bindings[p.Name]; ok {
+			binding.Type = t
+		}
+		c.destructure(p.Pattern, t, source, bindings)
+	case *ast.TuplePattern:
+		tuple, ok := c.resolve(t).(types.TupleType)
+		if !ok {
+			c.error(diagnostics.TypeMismatch, p.Location, "cannot destructure into %s: %s, which is not a tuple", p.GetName(), source)
+			return
+		}
+		if len(tuple.Elements) != len(p.Elements) {
+			c.error(diagnostics.TypeMismatch, p.Location, "cannot destructure into %s: %s, which has %d elements, not %d",
+				p.GetName(), source, len(tuple.Elements), len(p.Elements))
+			return
+		}
+		for i, element := range p.Elements {
+			c.destructure(element, tuple.Elements[i], fmt.Sprintf("element %d is %s", i+1, typeString(tuple.Elements[i])), bindings)
+		}
+	case *ast.StructPattern:
+		structType, ok := c.resolve(t).(types.StructType)
+		if !ok || structType.Name != p.TypeName {
+			c.error(diagnostics.TypeMismatch, p.Location, "cannot destructure into %s: %s, which is not the struct %s", p.GetName(), source, p.TypeName)
+			return
+		}
+		for _, field := range p.Fields {
+			declared, ok := structType.Fields[field.Name]
+			if !ok {
+				c.error(diagnostics.UnknownField, field.NameLocation, "struct %s has no field %s", structType.Name, field.Name)
+				continue
+			}
+			if field.Pattern == nil {
+				if binding, ok := bindings[field.Name]; ok {
+					binding.Type = declared.Type
+				}
+				continue
+			}
+			c.destructure(field.Pattern, declared.Type, fmt.Sprintf("field %s is %s", field.Name, typeString(declared.Type)), bindings)
+		}
+	case *ast.LiteralPattern:
+		c.error(diagnostics.TypeMismatch, p.Location, "cannot destructure into %s: a declaration cannot match literals; use a function clause", p.GetName())
+	case *ast.RangePattern, *ast.ConstructorPattern, *ast.ArrayPattern:
+		c.error(diagnostics.TypeMismatch, p.GetLocation(), "cannot destructure into %s: it does not match every %s; use a function clause", p.GetName(), typeString(t))
+	}
+}
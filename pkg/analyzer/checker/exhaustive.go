@@ -0,0 +1,301 @@
+package checker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// MissingConstructors returns the constructors of the data type t that covered
+// does not name, sorted by name; ok is false when t is not a data type. It is
+// the basis of exhaustiveness: a match covering every constructor leaves none.
+func MissingConstructors(table *symbols.SymbolTable, t types.Type, covered []string) (missing []types.DataTypeConstructor, ok bool) {
+	if unresolved, isNamed := t.(types.UnresolvedType); isNamed {
+		if decl, found := table.Types[unresolved.Name]; found {
+			t = decl.Type
+		}
+	}
+	dataType, ok := t.(types.DataType)
+	if !ok {
+		return nil, false
+	}
+	seen := make(map[string]bool, len(covered))
+	for _, name := range covered {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(dataType.Constructors))
+	for name := range dataType.Constructors {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ctor := dataType.Constructors[name]
+		ctor.Name = name
+		missing = append(missing, ctor)
+	}
+	return missing, true
+}
+
+// IntegerRange is the integers from Low to High inclusive
+type IntegerRange struct {
+	Low, High int64
+}
+
+func (r IntegerRange) String() string {
+	if r.Low == r.High {
+		return fmt.Sprint(r.Low)
+	}
+	return fmt.Sprintf("%d..=%d", r.Low, r.High)
+}
+
+// MissingIntegers returns the values of the integer type t that no pattern of
+// patterns matches, as ranges in ascending order; ok is false when t is not an
+// integer type narrower than 64 bits, whose values clauses cannot hope to list.
+// Literal and range patterns cover their values and a binding covers them all.
+func MissingIntegers(table *symbols.SymbolTable, t types.Type, patterns []ast.Pattern) (missing []IntegerRange, ok bool) {
+	if unresolved, isNamed := t.(types.UnresolvedType); isNamed {
+		if decl, found := table.Types[unresolved.Name]; found {
+			t = decl.Type
+		}
+	}
+	primitive, _ := t.(types.PrimitiveType)
+	bounds, ok := integerRanges[primitive.Name]
+	if !ok || bounds.max >= math.MaxInt64 {
+		return nil, false
+	}
+	high := int64(bounds.max)
+	var covered []IntegerRange
+	for _, pattern := range patterns {
+		if as, isAs := pattern.(*ast.AsPattern); isAs {
+			pattern = as.Pattern
+		}
+		switch p := pattern.(type) {
+		case *ast.IdentifierPattern, *ast.WildcardPattern:
+			return nil, true
+		case *ast.LiteralPattern:
+			if value, err := ast.ParseInteger(fmt.Sprint(p.Value)); err == nil {
+				covered = append(covered, IntegerRange{value, value})
+			}
+		case *ast.RangePattern:
+			if low, high, err := p.Bounds(); err == nil && !p.IsCharacter() && low <= high {
+				covered = append(covered, IntegerRange{low, high})
+			}
+		}
+	}
+	sort.Slice(covered, func(i, j int) bool { return covered[i].Low < covered[j].Low })
+	next := bounds.min // the least value not known to be covered
+	for _, r := range covered {
+		if r.Low > next {
+			missing = append(missing, IntegerRange{next, min(r.Low-1, high)})
+		}
+		next = max(next, r.High+1)
+	}
+	if next <= high {
+		missing = append(missing, IntegerRange{next, high})
+	}
+	return missing, true
+}
+
+// Coverage is what a list of patterns leaves unmatched of the values of a type:
+// the constructors of a data type or the integers of a small integer type
+type Coverage struct {
+	Constructors []types.DataTypeConstructor
+	Integers     []IntegerRange
+}
+
+// Complete reports whether the patterns match every value
+func (c Coverage) Complete() bool {
+	return len(c.Constructors) == 0 && len(c.Integers) == 0
+}
+
+// Missing names what the patterns leave unmatched: constructors or ranges
+func (c Coverage) Missing() []string {
+	var missing []string
+	for _, ctor := range c.Constructors {
+		missing = append(missing, ctor.Name)
+	}
+	for _, r := range c.Integers {
+		missing = append(missing, r.String())
+	}
+	return missing
+}
+
+// CoverPatterns analyses patterns matching values of type t: those of the
+// unguarded clauses of a function at one parameter, or the arms of a match. A
+// binding covers every value; a struct or constructor pattern covers its
+// constructor when its fields or arguments match any value; literals and ranges
+// cover integers. ok is false when t
+// is neither a data type nor an integer type narrower than 64 bits.
+func CoverPatterns(table *symbols.SymbolTable, t types.Type, patterns []ast.Pattern) (coverage Coverage, ok bool) {
+	if integers, isInteger := MissingIntegers(table, t, patterns); isInteger {
+		return Coverage{Integers: integers}, true
+	}
+	covered, catchAll := coveredConstructors(patterns)
+	if catchAll {
+		_, ok = MissingConstructors(table, t, nil)
+		return Coverage{}, ok
+	}
+	ctors, ok := MissingConstructors(table, t, covered)
+	return Coverage{Constructors: ctors}, ok
+}
+
+// coveredConstructors returns the constructors patterns match whatever their
+// fields or arguments, in order, and whether a binding among them matches every value
+func coveredConstructors(patterns []ast.Pattern) (covered []string, catchAll bool) {
+	for _, pattern := range patterns {
+		if as, isAs := pattern.(*ast.AsPattern); isAs {
+			pattern = as.Pattern
+		}
+		switch p := pattern.(type) {
+		case *ast.IdentifierPattern, *ast.WildcardPattern:
+			return nil, true
+		case *ast.StructPattern:
+			if !refinesFields(p) {
+				covered = append(covered, p.TypeName)
+			}
+		case *ast.ConstructorPattern:
+			if !anyRefutable(p.Arguments) {
+				_, name, qualified := strings.Cut(p.Name, ".")
+				if !qualified {
+					name = p.Name
+				}
+				covered = append(covered, name)
+			}
+		}
+	}
+	return covered, false
+}
+
+// Match is a function whose clauses match a parameter of a data type by
+// constructor, without a clause binding it, so adding a constructor to the type
+// leaves the function inexhaustive
+type Match struct {
+	Function string
+	Location ast.Location // of the function's name
+	DataType string
+	Covered  []string // the constructors its unguarded clauses match, sorted
+}
+
+// Handles reports whether the match covers the constructor named ctor
+func (m Match) Handles(ctor string) bool {
+	i := sort.SearchStrings(m.Covered, ctor)
+	return i < len(m.Covered) && m.Covered[i] == ctor
+}
+
+// Matches returns the functions checked whose clauses match a data type by
+// constructor, in the order they were checked
+func (c *Checker) Matches() []Match {
+	return c.matches
+}
+
+// recordMatch notes that the clauses of fn match values of t with patterns, if
+// they do so by constructor. The data type is known by name, so a match on a
+// type declared elsewhere is recorded as well.
+func (c *Checker) recordMatch(fn *ast.FunctionDefStmt, t types.Type, patterns []ast.Pattern) {
+	var dataType string
+	switch t := t.(type) {
+	case types.UnresolvedType:
+		dataType = t.Name
+	case types.DataType:
+		dataType = t.Name
+	default:
+		return
+	}
+	if decl, declared := c.table.Types[dataType]; declared {
+		if _, isData := decl.Type.(types.DataType); !isData {
+			return
+		}
+	}
+	covered, catchAll := coveredConstructors(patterns)
+	if catchAll || len(covered) == 0 {
+		return
+	}
+	sort.Strings(covered)
+	c.matches = append(c.matches, Match{Function: fn.Name, Location: fn.NameLocation, DataType: dataType, Covered: covered})
+}
+
+// refinesFields reports whether a field of pattern only matches some values
+func refinesFields(pattern *ast.StructPattern) bool {
+	for _, field := range pattern.Fields {
+		if refutable(field.Pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkExhaustive warns when the clauses of fn leave values of a parameter
+// unmatched, which fail at run time. It considers functions whose clauses all
+// match on the same parameter and bind the others, counting only unguarded
+// clauses as covering anything.
+func (c *Checker) checkExhaustive(fn *ast.FunctionDefStmt) {
+	if fn.Signature == nil || len(fn.Clauses) == 0 {
+		return
+	}
+	column := -1
+	for _, clause := range fn.Clauses {
+		for i, param := range clause.Parameters {
+			if !refutable(param) {
+				continue
+			}
+			if column >= 0 && column != i {
+				return
+			}
+			column = i
+		}
+	}
+	if column < 0 || column >= len(fn.Signature.ParameterTypes) {
+		return
+	}
+	var patterns []ast.Pattern
+	for _, clause := range fn.Clauses {
+		if clause.Guard == nil && column < len(clause.Parameters) {
+			patterns = append(patterns, clause.Parameters[column])
+		}
+	}
+	t := fn.Signature.ParameterTypes[column].Type
+	c.recordMatch(fn, t, patterns)
+	coverage, ok := CoverPatterns(c.table, t, patterns)
+	if !ok || coverage.Complete() {
+		return
+	}
+	missing := coverage.Missing()
+	if len(missing) > maxListedMissing {
+		missing = append(missing[:maxListedMissing], fmt.Sprintf("and %d more", len(missing)-maxListedMissing))
+	}
+	c.warning(diagnostics.NonExhaustiveClauses, fn.NameLocation, "the clauses of %s do not match every %s: %s not covered",
+		fn.Name, typeString(t), strings.Join(missing, ", "))
+}
+
+// maxListedMissing is the number of missing values a warning lists
+const maxListedMissing = 5
+
+// refutable reports whether a pattern can fail to match a value of its type
+func refutable(pattern ast.Pattern) bool {
+	switch p := pattern.(type) {
+	case nil, *ast.IdentifierPattern, *ast.WildcardPattern:
+		return false
+	case *ast.AsPattern:
+		return refutable(p.Pattern)
+	case *ast.TuplePattern:
+		return anyRefutable(p.Elements)
+	}
+	return true
+}
+
+func anyRefutable(patterns []ast.Pattern) bool {
+	for _, pattern := range patterns {
+		if refutable(pattern) {
+			return true
+		}
+	}
+	return false
+}
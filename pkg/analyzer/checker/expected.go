@@ -0,0 +1,207 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// Expectation is what the checker expects at a position of a checked program
+type Expectation struct {
+	Type     types.Type            // expected type, nil when the context does not constrain it
+	Locals   map[string]types.Type // parameters and pattern bindings in scope
+	Function string                // enclosing function, "" at top level
+}
+
+// ExpectedAt finds the expected type at a one-based line and column, following
+// the rules of the checker: the annotation of a declaration, the parameter type
+// of a call argument (also between arguments), the return type of a clause
+// body, the field type in a struct literal, Bool for conditions and guards.
+func ExpectedAt(program *ast.Program, table *symbols.SymbolTable, line, col int) Expectation {
+	x := &expectation{table: table, line: line, col: col}
+	x.Locals = make(map[string]types.Type)
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			x.expr(s.Value, s.Type)
+		case *ast.VarAssignStmt:
+			if named, ok := table.GlobalScope.Lookup(s.Name); ok {
+				if decl, ok := named.(*ast.VarDeclStmt); ok {
+					x.expr(s.Value, decl.Type)
+				}
+			}
+		case *ast.ExpressionStmt:
+			x.expr(s.Expression, nil)
+		case *ast.FunctionDefStmt:
+			x.function(s)
+		case *ast.TypeDeclStmt:
+			// default values of struct fields
+			if structType, ok := s.Type.(types.StructType); ok {
+				for _, field := range structType.Fields {
+					if value, ok := field.DefaultValue.(ast.Expression); ok && x.expr(value, field.Type) {
+						break
+					}
+				}
+			}
+		}
+		if x.found {
+			break
+		}
+	}
+	return x.Expectation
+}
+
+type expectation struct {
+	Expectation
+	table     *symbols.SymbolTable
+	line, col int
+	found     bool
+}
+
+func (x *expectation) contains(loc ast.Location) bool {
+	if loc.StartLine == 0 {
+		return false
+	}
+	if x.line < loc.StartLine || x.line > loc.EndLine {
+		return false
+	}
+	if x.line == loc.StartLine && x.col < loc.StartCol {
+		return false
+	}
+	return x.line != loc.EndLine || x.col <= loc.EndCol
+}
+
+func (x *expectation) function(fn *ast.FunctionDefStmt) {
+	var returnType types.Type
+	if fn.Signature != nil {
+		returnType = fn.Signature.ReturnType
+	}
+	for _, clause := range fn.Clauses {
+		inClause := x.contains(clause.Location) || clause.Body != nil && x.contains(clause.Body.GetLocation()) ||
+			clause.Guard != nil && x.contains(clause.Guard.Condition.GetLocation())
+		if !inClause {
+			continue
+		}
+		// bind the parameters the way the checker does
+		binder := NewChecker(&ast.Program{}, x.table)
+		for i, param := range clause.Parameters {
+			var paramType types.Type
+			if fn.Signature != nil && i < len(fn.Signature.ParameterTypes) {
+				paramType = fn.Signature.ParameterTypes[i].Type
+			}
+			binder.bindPattern(param, paramType)
+		}
+		x.Locals, x.Function, x.found = binder.env, fn.Name, true
+		if clause.Guard != nil && x.expr(clause.Guard.Condition, boolType) {
+			return
+		}
+		if !x.expr(clause.Body, returnType) {
+			x.Type = nil
+		}
+		return
+	}
+}
+
+// expr narrows the expectation to the innermost expression containing the position
+func (x *expectation) expr(expr ast.Expression, expected types.Type) bool {
+	if expr == nil || !x.contains(expr.GetLocation()) {
+		return false
+	}
+	x.found = true
+	x.Type = expected
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		fn, ok := functionType(e.Callee.GetType())
+		param := func(i int) types.Type {
+			if ok && i < len(fn.ParameterTypes) {
+				return fn.ParameterTypes[i].Type
+			}
+			return nil
+		}
+		if x.expr(e.Callee, nil) {
+			return true
+		}
+		index := 0
+		for i, argument := range e.Arguments {
+			if x.expr(argument, param(i)) {
+				return true
+			}
+			if end := argument.GetLocation(); end.EndLine < x.line || end.EndLine == x.line && end.EndCol <= x.col {
+				index = i + 1
+			}
+		}
+		// between arguments, e.g. right after "(" or ","
+		x.Type = param(index)
+	case *ast.BinaryOpExpr:
+		_ = x.expr(e.Left, e.GetType()) || x.expr(e.Right, e.GetType())
+	case *ast.BooleanBinaryOpExpr:
+		if e.Operator == ast.BooleanBinaryOpAnd || e.Operator == ast.BooleanBinaryOpOr {
+			_ = x.expr(e.Left, boolType) || x.expr(e.Right, boolType)
+		} else {
+			_ = x.expr(e.Left, nil) || x.expr(e.Right, e.Left.GetType())
+		}
+	case *ast.IfThenExpr:
+		_ = x.expr(e.Condition, boolType) || x.expr(e.Then, expected) || x.expr(e.Else, expected)
+	case *ast.IfBlockExpr:
+		_ = x.expr(e.Condition, boolType) || x.expr(e.Then, expected) || x.expr(e.Else, expected)
+	case *ast.GuardExpr:
+		x.expr(e.Condition, boolType)
+	case *ast.MemberAccessExpr:
+		x.expr(e.Object, nil)
+	case *ast.StructLiteralExpr:
+		var fields map[string]types.StructField
+		if decl, ok := x.table.Types[e.TypeName]; ok {
+			if structType, ok := decl.Type.(types.StructType); ok {
+				fields = structType.Fields
+			}
+		}
+		for _, field := range e.Fields {
+			if x.expr(field.Value, fields[field.Name].Type) {
+				break
+			}
+		}
+	case *ast.ArrayLiteralExpr:
+		var elementType types.Type
+		if array, ok := expected.(types.ArrayType); ok {
+			elementType = array.ElementType
+		} else if array, ok := e.GetType().(types.ArrayType); ok {
+			elementType = array.ElementType
+		}
+		for _, element := range e.Elements {
+			if x.expr(element, elementType) {
+				break
+			}
+		}
+	case *ast.TupleLiteralExpr:
+		tuple, ok := expected.(types.TupleType)
+		if !ok {
+			tuple, _ = e.GetType().(types.TupleType)
+		}
+		for i, element := range e.Elements {
+			var elementType types.Type
+			if i < len(tuple.Elements) {
+				elementType = tuple.Elements[i]
+			}
+			if x.expr(element, elementType) {
+				break
+			}
+		}
+	case *ast.LambdaExpr:
+		fn, _ := functionType(expected)
+		if fn.ReturnType == nil {
+			fn, _ = functionType(e.GetType())
+		}
+		x.expr(e.Body, fn.ReturnType)
+	}
+	return true
+}
+
+// Fits reports whether a value of type actual is a good match where expected is
+// required: unlike the checker's assignability, unknown, generic and Never types
+// never match, so only real candidates are ranked first
+func Fits(table *symbols.SymbolTable, expected, actual types.Type) bool {
+	if expected == nil || actual == nil || isGeneric(expected) || isGeneric(actual) || isNever(actual) {
+		return false
+	}
+	return NewChecker(&ast.Program{}, table).isAssignable(expected, actual)
+}
@@ -0,0 +1,66 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// ExternPrefix starts the target of an extern implemented in Go
+const ExternPrefix = "go:"
+
+// checkExtern validates an extern declaration: a go:package.Name target and a
+// signature whose values convert to Go. The host registers the implementation
+// with the interpreter, which checks the Go types when it starts.
+func (c *Checker) checkExtern(fn *ast.FunctionDefStmt) {
+	name, ok := strings.CutPrefix(fn.Extern, ExternPrefix)
+	if !ok || name == "" || strings.ContainsAny(name, " \t\n") {
+		c.error(diagnostics.InvalidExtern, fn.NameLocation,
+			"extern %s: target %q must have the form \"go:package.Name\"", fn.Name, fn.Extern)
+	}
+	if len(fn.GenericParams) > 0 {
+		c.error(diagnostics.InvalidExtern, fn.NameLocation, "extern %s cannot be generic", fn.Name)
+	}
+	if fn.Signature == nil {
+		return
+	}
+	for i, param := range fn.Signature.ParameterTypes {
+		if !Marshallable(c.resolve(param.Type), false) {
+			c.error(diagnostics.InvalidExtern, fn.NameLocation,
+				"extern %s: parameter %d of type %s cannot be passed to Go", fn.Name, i+1, typeString(param.Type))
+		}
+	}
+	if !Marshallable(c.resolve(fn.Signature.ReturnType), true) {
+		c.error(diagnostics.InvalidExtern, fn.NameLocation,
+			"extern %s: result type %s cannot be returned from Go", fn.Name, typeString(fn.Signature.ReturnType))
+	}
+}
+
+// Marshallable reports whether values of t convert to and from Go values: the
+// numeric types, Bool and String, and for results Unit and tuples of the others,
+// returned as several Go results
+func Marshallable(t types.Type, result bool) bool {
+	if tuple, ok := t.(types.TupleType); ok && result {
+		for _, element := range tuple.Elements {
+			if !Marshallable(element, false) {
+				return false
+			}
+		}
+		return true
+	}
+	p, ok := t.(types.PrimitiveType)
+	if !ok {
+		return false
+	}
+	switch p.Name {
+	case types.Bool, types.String:
+		return true
+	case types.Unit:
+		return result
+	case types.Never:
+		return false
+	}
+	return p.IsNumericType()
+}
@@ -0,0 +1,120 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// binding is a name a parameter pattern binds, where it binds it
+type binding struct {
+	name string
+	loc  ast.Location
+}
+
+// patternBindings returns the names pattern binds in order: an identifier, the
+// fields of a struct pattern, shorthand ones under their own name, the elements
+// of a tuple or array pattern and its rest, the arguments of a constructor
+// pattern, or the name of an as pattern and then those it wraps binds
+func patternBindings(pattern ast.Pattern) []binding {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		return []binding{{p.Name, p.Location}}
+	case *ast.AsPattern:
+		return append([]binding{{p.Name, p.NameLocation}}, patternBindings(p.Pattern)...)
+	case *ast.StructPattern:
+		var bindings []binding
+		for _, field := range p.Fields {
+			if field.Pattern == nil {
+				bindings = append(bindings, binding{field.Name, field.NameLocation})
+				continue
+			}
+			bindings = append(bindings, patternBindings(field.Pattern)...)
+		}
+		return bindings
+	case *ast.TuplePattern:
+		var bindings []binding
+		for _, element := range p.Elements {
+			bindings = append(bindings, patternBindings(element)...)
+		}
+		return bindings
+	case *ast.ConstructorPattern:
+		var bindings []binding
+		for _, argument := range p.Arguments {
+			bindings = append(bindings, patternBindings(argument)...)
+		}
+		return bindings
+	case *ast.ArrayPattern:
+		var bindings []binding
+		for _, element := range p.Elements {
+			bindings = append(bindings, patternBindings(element)...)
+		}
+		if p.Rest != nil {
+			bindings = append(bindings, patternBindings(p.Rest)...)
+		}
+		return bindings
+	}
+	return nil
+}
+
+// checkClauseBindings warns about each parameter that binds a name another
+// parameter of its clause already binds, as the guard and body only see the
+// last, and about each guard reading a parameter that shadows a top-level name:
+// the guard compares the argument, not the binding or function.
+func (c *Checker) checkClauseBindings(fn *ast.FunctionDefStmt) {
+	for _, clause := range fn.Clauses {
+		bound := make(map[string]ast.Location)
+		for _, param := range clause.Parameters {
+			for _, b := range patternBindings(param) {
+				if first, ok := bound[b.name]; ok {
+					c.warning(diagnostics.ShadowedParameter, b.loc,
+						"%s is already bound at %d:%d by this clause; the guard and body see only this binding",
+						b.name, first.StartLine, first.StartCol)
+				}
+				bound[b.name] = b.loc
+			}
+		}
+		if clause.Guard == nil {
+			continue
+		}
+		warned := make(map[string]bool)
+		walkExpression(clause.Guard.Condition, func(e ast.Expression) {
+			ident, ok := e.(*ast.IdentifierExpr)
+			if !ok || warned[ident.Name] {
+				return
+			}
+			param, isParam := bound[ident.Name]
+			if _, global := c.table.GlobalScope.Lookup(ident.Name); !isParam || !global {
+				return
+			}
+			warned[ident.Name] = true
+			c.warning(diagnostics.ShadowedParameter, ident.Location,
+				"the guard reads the parameter %s bound at %d:%d, which shadows the top-level %s",
+				ident.Name, param.StartLine, param.StartCol, ident.Name)
+		})
+	}
+}
+
+// otherClauseBindings returns the names the clauses of fn other than clause
+// bind and not clause itself, with where they are first bound
+func otherClauseBindings(fn *ast.FunctionDefStmt, clause *ast.FunctionClause) map[string]ast.Location {
+	own := make(map[string]bool)
+	for _, param := range clause.Parameters {
+		for _, b := range patternBindings(param) {
+			own[b.name] = true
+		}
+	}
+	others := make(map[string]ast.Location)
+	for _, other := range fn.Clauses {
+		if other == clause {
+			continue
+		}
+		for _, param := range other.Parameters {
+			for _, b := range patternBindings(param) {
+				if _, seen := others[b.name]; !seen && !own[b.name] {
+					others[b.name] = b.loc
+				}
+			}
+		}
+	}
+	return others
+}
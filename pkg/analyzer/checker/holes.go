@@ -0,0 +1,124 @@
+package checker
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// maxCandidates caps the candidates listed for a hole
+const maxCandidates = 8
+
+func isHole(t types.Type) bool {
+	_, ok := t.(types.HoleType)
+	return ok
+}
+
+// checkHole reports the type expected where ??? stands and the bindings in scope
+// that have it. The hole is Never, so it fits anywhere like todo().
+func (c *Checker) checkHole(hole *ast.HoleExpr, expected types.Type) types.Type {
+	if expected == nil || isHole(expected) {
+		c.info(diagnostics.TypedHole, hole.Location, "hole has unknown type: annotate the context to learn more")
+		return neverType
+	}
+	message := "hole has type " + typeString(expected)
+	if candidates := c.candidates(expected); len(candidates) > 0 {
+		if len(candidates) > maxCandidates {
+			candidates = append(candidates[:maxCandidates], "…")
+		}
+		message += ", candidates in scope: " + strings.Join(candidates, ", ")
+	}
+	c.info(diagnostics.TypedHole, hole.Location, "%s", message)
+	return neverType
+}
+
+// candidates lists the bindings of type t: parameters and pattern bindings,
+// variables and constructors by name, and functions returning t as name(…)
+func (c *Checker) candidates(t types.Type) []string {
+	if isGeneric(t) {
+		return nil
+	}
+	fits := func(actual types.Type) bool { return Fits(c.table, t, actual) }
+	var locals, globals []string
+	for name, bound := range c.env {
+		if fits(bound) {
+			locals = append(locals, name)
+		}
+	}
+	for name, fn := range c.table.Functions {
+		if _, shadowed := c.env[name]; shadowed || fn.Signature == nil {
+			continue
+		}
+		switch {
+		case fits(fn.Signature):
+			globals = append(globals, name)
+		case fits(fn.Signature.ReturnType):
+			globals = append(globals, name+"(…)")
+		}
+	}
+	for name, named := range c.table.GlobalScope.Visible() {
+		if _, shadowed := c.env[name]; shadowed {
+			continue
+		}
+		if decl, ok := named.(*ast.VarDeclStmt); ok && fits(decl.Type) {
+			globals = append(globals, name)
+		}
+	}
+	for name, ctors := range c.table.Constructors {
+		for _, ctor := range ctors {
+			if fits(constructorType(ctor)) {
+				globals = append(globals, name)
+			} else if ctor.Signature != nil && len(ctor.Signature.ParameterTypes) > 0 && fits(ctor.Signature.ReturnType) {
+				globals = append(globals, name+"(…)")
+			}
+		}
+	}
+	sort.Strings(locals)
+	sort.Strings(globals)
+	return append(locals, globals...)
+}
+
+// fillVarHole infers the type of a declaration annotated with a hole
+func (c *Checker) fillVarHole(decl *ast.VarDeclStmt) {
+	valueType := c.CheckExpression(decl.Value, nil)
+	if valueType == nil {
+		c.error(diagnostics.TypedHole, decl.NameLocation, "cannot fill the hole in the type of %s", decl.Name)
+		return
+	}
+	decl.Type = valueType
+	c.info(diagnostics.TypedHole, decl.NameLocation, "hole in the type of %s has type %s", decl.Name, typeString(valueType))
+}
+
+// checkSignatureHoles reports holes in parameter types, which are not inferred,
+// and says whether the return type is a hole to fill from the clause bodies
+func (c *Checker) checkSignatureHoles(fn *ast.FunctionDefStmt) bool {
+	if fn.Signature == nil {
+		return false
+	}
+	for i, param := range fn.Signature.ParameterTypes {
+		if isHole(param.Type) {
+			c.error(diagnostics.TypedHole, fn.NameLocation, "cannot infer the type of parameter %d of %s: parameter types must be written", i+1, fn.Name)
+		}
+	}
+	return isHole(fn.Signature.ReturnType)
+}
+
+// fillReturnHole sets the return type of fn from the types of its clause bodies
+func (c *Checker) fillReturnHole(fn *ast.FunctionDefStmt, bodies []types.Type) {
+	var filled types.Type
+	for _, t := range bodies {
+		if t != nil && !isNever(t) {
+			filled = t
+			break
+		}
+	}
+	if filled == nil {
+		c.error(diagnostics.TypedHole, fn.NameLocation, "cannot fill the hole in the return type of %s", fn.Name)
+		return
+	}
+	fn.Signature.ReturnType = filled
+	c.info(diagnostics.TypedHole, fn.NameLocation, "hole in the return type of %s has type %s", fn.Name, typeString(filled))
+}
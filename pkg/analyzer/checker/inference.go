@@ -0,0 +1,84 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// freshVar returns a type variable no other use has, for the generic parameter origin
+func (c *Checker) freshVar(origin string) types.TypeVar {
+	c.vars++
+	return types.TypeVar{ID: c.vars, Origin: origin}
+}
+
+// checkGenericCall checks the arguments of a call to a generic function. The
+// call instantiates the generic parameters with fresh variables, which the
+// arguments solve in order, so a later argument must agree with what an earlier
+// one decided. The call has the return type with the parameters solved; those
+// no argument decided stay generic.
+func (c *Checker) checkGenericCall(call *ast.CallExpr, fnType, instance types.FunctionType) types.Type {
+	solved := types.Substitution{}
+	for i, argument := range call.Arguments {
+		parameter := solved.Apply(instance.ParameterTypes[i].Type)
+		var expected types.Type
+		if !types.HasTypeVars(parameter) {
+			expected = parameter
+		}
+		argType := c.CheckExpression(argument, expected)
+		if argType == nil {
+			continue
+		}
+		argType = c.genericValue(argument, argType)
+		err := types.Unify(parameter, argType, solved)
+		c.traceUnify(parameter, argType, solved, err == nil)
+		if err != nil {
+			expected := types.Generalize(parameter, solved)
+			c.typeError(diagnostics.ArgumentType, argument.GetLocation(), expected, argType,
+				"argument %d: expected %s but got %s", i+1, typeString(expected), typeString(argType))
+		}
+	}
+	for _, v := range typeVars(instance) {
+		if t, ok := solved[v.ID]; ok && v.Origin != "" {
+			if call.TypeArguments == nil {
+				call.TypeArguments = make(map[string]types.Type)
+			}
+			call.TypeArguments[v.Origin] = types.Generalize(t, solved)
+		}
+	}
+	c.checkAliasing(call, fnType)
+	return types.Generalize(instance.ReturnType, solved)
+}
+
+// genericValue instantiates the type of an argument naming a generic function,
+// so that the call solves its generic parameters like those of its own callee:
+// apply(identity, 1) passes identity as (Int) -> Int
+func (c *Checker) genericValue(argument ast.Expression, t types.Type) types.Type {
+	ident, ok := argument.(*ast.IdentifierExpr)
+	if !ok {
+		return t
+	}
+	if _, local := c.env[ident.Name]; local {
+		return t
+	}
+	if fn, ok := c.table.Functions[ident.Name]; ok && len(fn.GenericParams) > 0 {
+		return types.Instantiate(t, c.freshVar)
+	}
+	return t
+}
+
+// inferredType is the type of a top-level binding declared without one: that of
+// its value, which is checked first if the binding is read before its
+// declaration. A binding read while its own value is checked has no type yet.
+func (c *Checker) inferredType(decl *ast.VarDeclStmt) types.Type {
+	if decl.Value == nil {
+		return nil
+	}
+	if c.unchecked[decl] {
+		env, others, function, pure := c.env, c.others, c.function, c.pure
+		c.env, c.others, c.function, c.pure = make(map[string]types.Type), nil, "", false
+		c.checkVarDecl(decl)
+		c.env, c.others, c.function, c.pure = env, others, function, pure
+	}
+	return decl.Value.GetType()
+}
@@ -0,0 +1,57 @@
+package checker
+
+import (
+	"math"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// integerRange is the values an integer type holds, from min to max
+type integerRange struct {
+	min int64
+	max uint64
+}
+
+func (r integerRange) holds(value int64) bool {
+	return value >= r.min && (value < 0 || uint64(value) <= r.max)
+}
+
+// integerRanges are the values each integer type holds
+var integerRanges = map[types.PrimitiveTypeName]integerRange{
+	types.Int:    {math.MinInt64, math.MaxInt64},
+	types.Int8:   {math.MinInt8, math.MaxInt8},
+	types.Int16:  {math.MinInt16, math.MaxInt16},
+	types.Int32:  {math.MinInt32, math.MaxInt32},
+	types.Int64:  {math.MinInt64, math.MaxInt64},
+	types.UInt:   {0, math.MaxUint64},
+	types.UInt8:  {0, math.MaxUint8},
+	types.UInt16: {0, math.MaxUint16},
+	types.UInt32: {0, math.MaxUint32},
+	types.UInt64: {0, math.MaxUint64},
+}
+
+// checkIntegerLiteral types an integer literal as the integer type expected of
+// it, if any, reporting a value the type cannot hold (300 as an Int8), and as
+// Int otherwise
+func (c *Checker) checkIntegerLiteral(literal *ast.IntegerLiteralExpr, expected types.Type) types.Type {
+	primitive, _ := c.resolve(expected).(types.PrimitiveType)
+	bounds, ok := integerRanges[primitive.Name]
+	if !ok {
+		primitive, bounds = intType, integerRanges[types.Int]
+	}
+	if literal.Text != "" {
+		// past the Int64 values, so held by UInt64 at most
+		if value, err := ast.ParseUnsigned(literal.Text); err != nil || value > bounds.max {
+			c.error(diagnostics.IntegerOverflow, literal.Location, "%s overflows %s, which holds %d to %d",
+				literal.Text, primitive.Name, bounds.min, bounds.max)
+		}
+		return primitive
+	}
+	if !bounds.holds(literal.Value) {
+		c.error(diagnostics.IntegerOverflow, literal.Location, "%d overflows %s, which holds %d to %d",
+			literal.Value, primitive.Name, bounds.min, bounds.max)
+	}
+	return primitive
+}
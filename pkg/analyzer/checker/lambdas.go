@@ -0,0 +1,52 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkLambda checks a lambda in the scope it is written in. Its parameters take
+// the types of a function type of as many parameters expected of it; the others
+// get fresh type variables, which unifying them where the body uses them solves,
+// as the arguments of a generic call solve its parameters. What the body leaves
+// unsolved stays generic, so (x) => x is (a) -> a and (x) => x + 1 is (Int) -> Int.
+func (c *Checker) checkLambda(lambda *ast.LambdaExpr, expected types.Type) types.Type {
+	expectedFn, _ := functionType(c.resolve(expected))
+	if len(expectedFn.ParameterTypes) != len(lambda.Parameters) {
+		expectedFn = types.FunctionType{}
+	}
+	outermost := c.solved == nil
+	if outermost {
+		c.solved, c.lambdas = types.Substitution{}, c.vars
+		defer func() { c.solved = nil }()
+	}
+	outer := c.env
+	c.env = make(map[string]types.Type, len(outer)+len(lambda.Parameters))
+	for name, t := range outer {
+		c.env[name] = t
+	}
+	defer func() { c.env = outer }()
+
+	fn := &types.FunctionType{ParameterTypes: make([]types.ParameterType, len(lambda.Parameters))}
+	for i, param := range lambda.Parameters {
+		var paramType types.Type
+		if i < len(expectedFn.ParameterTypes) {
+			paramType = expectedFn.ParameterTypes[i].Type
+		}
+		if paramType == nil || isGeneric(paramType) {
+			paramType = c.freshVar(string(rune('a' + (c.vars-c.lambdas)%26)))
+		}
+		fn.ParameterTypes[i] = types.ParameterType{Type: paramType}
+		c.bindPattern(param, paramType)
+	}
+	fn.ReturnType = c.CheckExpression(lambda.Body, expectedFn.ReturnType)
+	if !outermost {
+		return c.solved.Apply(fn)
+	}
+	walkExpression(lambda.Body, func(expr ast.Expression) {
+		if t := expr.GetType(); types.HasTypeVars(t) {
+			expr.SetType(types.Generalize(t, c.solved))
+		}
+	})
+	return types.Generalize(fn, c.solved)
+}
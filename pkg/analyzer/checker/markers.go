@@ -0,0 +1,33 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkArgumentModifiers requires the arguments passed to a mut or ref parameter
+// to repeat its modifier at the call (push(mut stack, 1)), so that what a call may
+// change shows where it is written, and rejects a marker the parameter does not
+// declare. Arguments to own parameters need no marker: moving is checked by the
+// ownership analysis.
+func (c *Checker) checkArgumentModifiers(call *ast.CallExpr, fnType types.FunctionType) {
+	for i, argument := range call.Arguments {
+		marker, modifier := call.Modifier(i), fnType.ParameterTypes[i].Modifier
+		switch {
+		case marker == modifier:
+		case marker == "" && (modifier == types.Mut || modifier == types.Ref):
+			c.error(diagnostics.ArgumentModifier, argument.GetLocation(),
+				"argument %d of %s is passed to a %s parameter; mark it %s %s",
+				i+1, call.Callee.GetName(), modifier, modifier, argument.GetName())
+		case marker != "" && modifier == "":
+			c.error(diagnostics.ArgumentModifier, argument.GetLocation(),
+				"argument %d of %s is marked %s but its parameter is not; remove the marker",
+				i+1, call.Callee.GetName(), marker)
+		case marker != "":
+			c.error(diagnostics.ArgumentModifier, argument.GetLocation(),
+				"argument %d of %s is marked %s but its parameter is %s; mark it %s",
+				i+1, call.Callee.GetName(), marker, modifier, modifier)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkImpl checks the methods of a trait implementation, each of which takes
+// the implementing type first: the receiver a method call binds
+func (c *Checker) checkImpl(impl *ast.ImplStmt) {
+	for _, method := range impl.Methods {
+		if method.Signature == nil {
+			continue
+		}
+		if params := method.Signature.ParameterTypes; len(params) == 0 || c.resolve(params[0].Type).GetName() != impl.TypeName {
+			c.error(diagnostics.TypeMismatch, method.NameLocation,
+				"method %s of %s must take a %s first, the value it is called on", method.Name, impl.GetName(), impl.TypeName)
+		}
+		c.checkFunctionDef(method)
+	}
+}
+
+// methodType resolves object.member to a method of a trait the type of object
+// implements, returning the method's type with the receiver bound: the
+// parameters after the first. It reports false if no trait defines the method.
+func (c *Checker) methodType(expr *ast.MemberAccessExpr, objectType types.Type) (types.Type, bool) {
+	typeName := c.resolve(objectType).GetName()
+	impls := c.table.LookupMethod(typeName, expr.Member)
+	switch len(impls) {
+	case 0:
+		return nil, false
+	case 1:
+	default:
+		traits := make([]string, len(impls))
+		for i, impl := range impls {
+			traits[i] = impl.Trait
+		}
+		c.error(diagnostics.AmbiguousMethod, expr.MemberLocation,
+			"%s.%s is ambiguous: %s implements it for each of %s; rename the method in all but one",
+			typeName, expr.Member, typeName, strings.Join(traits, ", "))
+		return nil, true
+	}
+	signature := impls[0].Method(expr.Member).Signature
+	if signature == nil || len(signature.ParameterTypes) == 0 {
+		return nil, true // reported by checkImpl
+	}
+	return types.FunctionType{ParameterTypes: signature.ParameterTypes[1:], ReturnType: signature.ReturnType}, true
+}
@@ -0,0 +1,141 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// bindConstructorPattern binds the arguments of Some(x) to the parameter types
+// of the constructor, which the type of the matched value picks among those of
+// the same name
+func (c *Checker) bindConstructorPattern(p *ast.ConstructorPattern, t types.Type) {
+	ctor, err := c.table.ResolveConstructor(p.Name, t)
+	if err != nil {
+		code := diagnostics.UnknownConstructor
+		var ambiguous *symbols.AmbiguousConstructorError
+		if errors.As(err, &ambiguous) {
+			code = diagnostics.AmbiguousConstructor
+		}
+		c.error(code, p.Location, "%s", err)
+		for _, argument := range p.Arguments {
+			c.bindPattern(argument, nil)
+		}
+		return
+	}
+	var params []types.ParameterType
+	if ctor.Signature != nil {
+		params = ctor.Signature.ParameterTypes
+	}
+	if len(params) != len(p.Arguments) {
+		c.error(diagnostics.ArgumentCount, p.Location, "pattern %s: %s takes %d arguments but the pattern has %d",
+			p.GetName(), ctor.Name, len(params), len(p.Arguments))
+	}
+	for i, argument := range p.Arguments {
+		var argumentType types.Type
+		if i < len(params) {
+			argumentType = params[i].Type
+		}
+		c.bindPattern(argument, argumentType)
+	}
+}
+
+// bindArrayPattern binds the elements of [x, ...rest] to the element type of
+// the matched array and the rest to the array type
+func (c *Checker) bindArrayPattern(p *ast.ArrayPattern, t types.Type) {
+	array, ok := c.resolve(t).(types.ArrayType)
+	if !ok && t != nil {
+		c.error(diagnostics.TypeMismatch, p.Location, "array pattern %s cannot match %s", p.GetName(), typeString(t))
+		t = nil
+	}
+	for _, element := range p.Elements {
+		c.bindPattern(element, array.ElementType)
+	}
+	if p.Rest != nil {
+		c.bindPattern(p.Rest, t)
+	}
+}
+
+// bindStructPattern binds the fields of Point { x, y } to their types in the
+// struct, or in the record constructor of the matched data type the pattern names
+func (c *Checker) bindStructPattern(p *ast.StructPattern, t types.Type) {
+	fields, known := c.structPatternFields(p, t)
+	for _, field := range p.Fields {
+		var fieldType types.Type
+		if known {
+			declared, ok := fields[field.Name]
+			if !ok {
+				c.error(diagnostics.UnknownField, field.NameLocation, "%s has no field %s", p.TypeName, field.Name)
+			}
+			fieldType = declared.Type
+		}
+		if field.Pattern == nil {
+			c.env[field.Name] = fieldType
+			continue
+		}
+		c.bindPattern(field.Pattern, fieldType)
+	}
+}
+
+// structPatternFields returns the fields of the struct or record constructor a
+// struct pattern names, reporting a name that is neither or that a value of
+// type t cannot have
+func (c *Checker) structPatternFields(p *ast.StructPattern, t types.Type) (map[string]types.StructField, bool) {
+	if data, ok := c.resolve(t).(types.DataType); ok {
+		if ctor, ok := data.Constructors[p.TypeName]; ok {
+			return ctor.Fields, true
+		}
+	}
+	structType, ok := c.resolve(types.UnresolvedType{Name: p.TypeName}).(types.StructType)
+	if !ok {
+		ctors := c.table.LookupConstructor(p.TypeName)
+		switch {
+		case len(ctors) == 0:
+			c.error(diagnostics.UnknownType, p.Location, "pattern %s names no struct or record constructor", p.GetName())
+		case t != nil && !isGeneric(t):
+			c.error(diagnostics.TypeMismatch, p.Location, "pattern %s matches %s but the value is %s", p.GetName(), ctors[0].DataType, typeString(t))
+		}
+		return nil, false
+	}
+	if !c.assignable(t, structType) {
+		c.error(diagnostics.TypeMismatch, p.Location, "pattern %s matches %s but the value is %s", p.GetName(), structType.Name, typeString(t))
+	}
+	return structType.Fields, true
+}
+
+// checkLiteralPattern checks a literal pattern against the type of the value it
+// matches, as the literal would be checked as an argument of that type
+func (c *Checker) checkLiteralPattern(p *ast.LiteralPattern, t types.Type) {
+	if t == nil {
+		return
+	}
+	text := fmt.Sprint(p.Value)
+	var literalType types.Type
+	var tooLarge *ast.RangeError
+	if value, err := ast.ParseInteger(text); err == nil || errors.As(err, &tooLarge) {
+		primitive, _ := c.resolve(t).(types.PrimitiveType)
+		if _, ok := integerRanges[primitive.Name]; ok {
+			literal := &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: p.Location}}, Value: value}
+			if tooLarge != nil {
+				literal.Text = text
+			}
+			c.checkIntegerLiteral(literal, primitive)
+			return
+		}
+		literalType = intType
+	} else if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		literalType = stringType
+	} else if text == "true" || text == "false" {
+		literalType = boolType
+	} else {
+		literalType = floatType
+	}
+	if !c.assignable(t, literalType) {
+		c.error(diagnostics.TypeMismatch, p.Location, "pattern %s is %s but the value is %s", text, typeString(literalType), typeString(t))
+	}
+}
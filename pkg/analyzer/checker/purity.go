@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkPureCall reports a call the pure function being checked may not make: to
+// debug(), which prints, or to a function or method not declared pure, async
+// or implemented by the host. Calls through parameters and locals are assumed
+// pure, and the functions a pure function defines in its body are checked as
+// pure themselves.
+func (c *Checker) checkPureCall(call *ast.CallExpr) {
+	switch callee := call.Callee.(type) {
+	case *ast.IdentifierExpr:
+		if _, local := c.env[callee.Name]; local {
+			return
+		}
+		if fn, ok := c.table.Functions[callee.Name]; ok {
+			c.checkPureCallee(call, callee.Name, fn)
+			return
+		}
+		if callee.Name == "debug" && !c.shadowed(callee.Name) {
+			c.error(diagnostics.PurityViolation, call.Location, "pure function %s calls debug, which prints", c.function)
+		}
+	case *ast.MemberAccessExpr:
+		objectType := c.resolve(callee.Object.GetType())
+		if objectType == nil {
+			return
+		}
+		if structType, ok := objectType.(types.StructType); ok {
+			if _, isField := structType.Fields[callee.Member]; isField {
+				return
+			}
+		}
+		if impls := c.table.LookupMethod(objectType.GetName(), callee.Member); len(impls) == 1 {
+			c.checkPureCallee(call, objectType.GetName()+"."+callee.Member, impls[0].Method(callee.Member))
+		}
+	}
+}
+
+func (c *Checker) checkPureCallee(call *ast.CallExpr, name string, fn *ast.FunctionDefStmt) {
+	reason := ""
+	switch {
+	case fn.IsExtern():
+		reason = "is implemented by the host"
+	case fn.IsAsync:
+		reason = "is async"
+	case !fn.IsPure:
+		reason = "is not declared pure"
+	default:
+		return
+	}
+	c.error(diagnostics.PurityViolation, call.Location, "pure function %s calls %s, which %s", c.function, name, reason)
+}
@@ -0,0 +1,46 @@
+package checker
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// checkRangePattern checks a range pattern against the type of the value it
+// matches: integer bounds the type can hold for an integer type, character
+// bounds for a String, and a low bound no greater than the high one
+func (c *Checker) checkRangePattern(p *ast.RangePattern, t types.Type) {
+	if t == nil {
+		return
+	}
+	primitive, _ := c.resolve(t).(types.PrimitiveType)
+	integerBounds, isInteger := integerRanges[primitive.Name]
+	switch {
+	case isInteger && p.IsCharacter():
+		c.error(diagnostics.TypeMismatch, p.Location, "range pattern %s matches characters but the value is %s", p.GetName(), primitive.Name)
+		return
+	case primitive.Name == types.String && !p.IsCharacter():
+		c.error(diagnostics.TypeMismatch, p.Location, "range pattern %s matches integers but the value is String", p.GetName())
+		return
+	case !isInteger && primitive.Name != types.String:
+		c.error(diagnostics.TypeMismatch, p.Location, "range pattern %s cannot match %s; ranges match integers and characters", p.GetName(), typeString(t))
+		return
+	}
+	low, high, err := p.Bounds()
+	if err != nil {
+		c.error(diagnostics.InvalidLiteral, p.Location, "range pattern %s: %v", p.GetName(), err)
+		return
+	}
+	if isInteger {
+		for _, bound := range []int64{low, high} {
+			if !integerBounds.holds(bound) {
+				c.error(diagnostics.IntegerOverflow, p.Location, "%d overflows %s, which holds %d to %d",
+					bound, primitive.Name, integerBounds.min, integerBounds.max)
+				return
+			}
+		}
+	}
+	if low > high {
+		c.warning(diagnostics.UnreachableClause, p.Location, "range pattern %s matches nothing: %s is greater than %s", p.GetName(), p.Low, p.High)
+	}
+}
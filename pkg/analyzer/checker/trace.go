@@ -0,0 +1,227 @@
+package checker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// TraceEntry is one checker decision: the rule that typed an expression, an
+// assignability check between an expected and an actual type, or the
+// unification of a parameter of a generic call with its argument
+type TraceEntry struct {
+	Depth    int               // nesting of the expression within its statement
+	Rule     string            // e.g. "literal", "local", "call", "builtin to_json", "assign", "unify"
+	Node     string            // expression kind and text, e.g. "CallExpr sum(a, b)"; empty for assign and unify
+	Location ast.Location      // of the expression; assign and unify entries use the enclosing expression's
+	Expected string            // type the context expected, if any
+	Result   string            // checked type, "" when the expression could not be typed
+	Bindings map[string]string // generic parameter -> type it was matched with
+	Note     string            // e.g. "rejected" for a failed assignability check
+}
+
+// EnableTrace makes the checker record every decision, returned by Trace
+func (c *Checker) EnableTrace() {
+	c.trace = make([]TraceEntry, 0)
+}
+
+// Trace returns the recorded decisions in the order they were made, or nil
+// when tracing is off
+func (c *Checker) Trace() []TraceEntry {
+	return c.trace
+}
+
+// traceStart records expr before it is checked and returns its entry, -1 when not tracing
+func (c *Checker) traceStart(expr ast.Expression, expected types.Type) int {
+	if c.trace == nil {
+		return -1
+	}
+	entry := TraceEntry{Depth: c.traceDepth, Rule: rule(expr), Node: kindOf(expr), Location: expr.GetLocation()}
+	if name := expr.GetName(); name != "" {
+		entry.Node += " " + name
+	}
+	if expected != nil {
+		entry.Expected = expected.GetName()
+	}
+	c.trace = append(c.trace, entry)
+	c.traceDepth++
+	c.traceCurrent = append(c.traceCurrent, len(c.trace)-1)
+	return len(c.trace) - 1
+}
+
+func (c *Checker) traceEnd(i int, t types.Type) {
+	if i < 0 {
+		return
+	}
+	if t != nil {
+		c.trace[i].Result = t.GetName()
+	}
+	c.traceDepth--
+	c.traceCurrent = c.traceCurrent[:len(c.traceCurrent)-1]
+}
+
+// traceRule refines the rule of the expression being checked, e.g. which scope
+// an identifier was found in
+func (c *Checker) traceRule(format string, args ...any) {
+	if n := len(c.traceCurrent); n > 0 {
+		c.trace[c.traceCurrent[n-1]].Rule = fmt.Sprintf(format, args...)
+	}
+}
+
+// traceAssign records an assignability check and the generic bindings it implies
+func (c *Checker) traceAssign(expected, actual types.Type, ok bool) {
+	if c.trace == nil || expected == nil || actual == nil {
+		return
+	}
+	entry := TraceEntry{Depth: c.traceDepth, Rule: "assign", Expected: expected.GetName(), Result: actual.GetName(), Note: "accepted"}
+	if n := len(c.traceCurrent); n > 0 {
+		entry.Location = c.trace[c.traceCurrent[n-1]].Location
+	}
+	if !ok {
+		entry.Note = "rejected"
+	}
+	switch {
+	case isGeneric(expected) && !isGeneric(actual):
+		entry.Bindings = map[string]string{expected.GetName(): actual.GetName()}
+		entry.Note = "generic: accepted until instantiation is checked"
+	case isGeneric(actual) && !isGeneric(expected):
+		entry.Bindings = map[string]string{actual.GetName(): expected.GetName()}
+		entry.Note = "generic: accepted until instantiation is checked"
+	}
+	c.trace = append(c.trace, entry)
+}
+
+// traceUnify records the unification of a parameter of a generic call with its
+// argument and the generic parameters it solved
+func (c *Checker) traceUnify(parameter, argument types.Type, solved types.Substitution, ok bool) {
+	if c.trace == nil {
+		return
+	}
+	entry := TraceEntry{Depth: c.traceDepth, Rule: "unify", Expected: parameter.GetName(), Result: argument.GetName(), Note: "solved"}
+	if n := len(c.traceCurrent); n > 0 {
+		entry.Location = c.trace[c.traceCurrent[n-1]].Location
+	}
+	if !ok {
+		entry.Note = "rejected"
+	}
+	for _, v := range typeVars(parameter) {
+		if t, isSolved := solved[v.ID]; isSolved {
+			if entry.Bindings == nil {
+				entry.Bindings = make(map[string]string)
+			}
+			entry.Bindings[v.GetName()] = solved.Apply(t).GetName()
+		}
+	}
+	c.trace = append(c.trace, entry)
+}
+
+// typeVars returns the type variables in t
+func typeVars(t types.Type) []types.TypeVar {
+	switch t := t.(type) {
+	case types.TypeVar:
+		return []types.TypeVar{t}
+	case types.ArrayType:
+		return typeVars(t.ElementType)
+	case types.TupleType:
+		var vars []types.TypeVar
+		for _, element := range t.Elements {
+			vars = append(vars, typeVars(element)...)
+		}
+		return vars
+	case *types.FunctionType:
+		if t != nil {
+			return typeVars(*t)
+		}
+	case types.FunctionType:
+		var vars []types.TypeVar
+		for _, parameter := range t.ParameterTypes {
+			vars = append(vars, typeVars(parameter.Type)...)
+		}
+		return append(vars, typeVars(t.ReturnType)...)
+	}
+	return nil
+}
+
+// rule is the default rule for an expression kind
+func rule(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteralExpr, *ast.FloatLiteralExpr, *ast.StringLiteralExpr, *ast.RawStringLiteralExpr, *ast.MultilineStringLiteralExpr, *ast.BooleanLiteralExpr:
+		return "literal"
+	case *ast.HostValueExpr:
+		return "host value"
+	case *ast.HoleExpr:
+		return "hole"
+	case *ast.IdentifierExpr:
+		return "identifier"
+	case *ast.CallExpr:
+		return "call"
+	case *ast.BinaryOpExpr:
+		return "operator " + e.Operator
+	case *ast.BooleanBinaryOpExpr:
+		return "operator " + string(e.Operator)
+	case *ast.IfThenExpr, *ast.IfBlockExpr:
+		return "if"
+	case *ast.GuardExpr:
+		return "guard"
+	case *ast.MemberAccessExpr:
+		return "member access"
+	case *ast.StructLiteralExpr:
+		return "struct literal"
+	case *ast.ArrayLiteralExpr:
+		return "array literal"
+	case *ast.TupleLiteralExpr:
+		return "tuple literal"
+	case *ast.LambdaExpr:
+		return "lambda"
+	}
+	return "unknown"
+}
+
+func kindOf(expr ast.Expression) string {
+	name := fmt.Sprintf("%T", expr)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// WriteTrace renders a trace as an indented outline, one decision per line:
+//
+//	3:27 BinaryOpExpr a + b [operator +] expected Int => Int
+//	  3:27 IdentifierExpr a [local] => Int
+func WriteTrace(w io.Writer, trace []TraceEntry) error {
+	var b strings.Builder
+	for _, entry := range trace {
+		b.WriteString(strings.Repeat("  ", entry.Depth))
+		fmt.Fprintf(&b, "%d:%d ", entry.Location.StartLine, entry.Location.StartCol)
+		if entry.Rule == "assign" || entry.Rule == "unify" {
+			fmt.Fprintf(&b, "%s %s <- %s: %s", entry.Rule, entry.Expected, entry.Result, entry.Note)
+		} else {
+			fmt.Fprintf(&b, "%s [%s]", entry.Node, entry.Rule)
+			if entry.Expected != "" {
+				b.WriteString(" expected " + entry.Expected)
+			}
+			result := entry.Result
+			if result == "" {
+				result = "?"
+			}
+			b.WriteString(" => " + result)
+		}
+		if len(entry.Bindings) > 0 {
+			names := make([]string, 0, len(entry.Bindings))
+			for name := range entry.Bindings {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			bindings := make([]string, len(names))
+			for i, name := range names {
+				bindings[i] = name + " := " + entry.Bindings[name]
+			}
+			b.WriteString(" {" + strings.Join(bindings, ", ") + "}")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
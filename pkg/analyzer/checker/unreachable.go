@@ -0,0 +1,160 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// checkUnreachableClauses warns about each clause of fn that an earlier clause
+// always matches first: a repeated literal, like a second `(0) =>` in fib, or a
+// literal after a parameter that matches anything. An earlier clause with a guard
+// only covers a later one with the same patterns and the same guard.
+func (c *Checker) checkUnreachableClauses(fn *ast.FunctionDefStmt) {
+	for j, later := range fn.Clauses {
+		for _, earlier := range fn.Clauses[:j] {
+			if !coversClause(earlier, later) {
+				continue
+			}
+			c.warning(diagnostics.UnreachableClause, later.Location,
+				"unreachable clause of %s: the clause at %d:%d matches %s first",
+				fn.Name, earlier.Location.StartLine, earlier.Location.StartCol, clauseParameters(later))
+			break
+		}
+	}
+}
+
+// coversClause reports whether every call later matches is matched by earlier
+func coversClause(earlier, later *ast.FunctionClause) bool {
+	if len(earlier.Parameters) != len(later.Parameters) {
+		return false
+	}
+	if earlier.Guard != nil {
+		if later.Guard == nil || earlier.Guard.Condition.GetName() != later.Guard.Condition.GetName() {
+			return false
+		}
+		// the guards only mean the same if they see the same bindings
+		for i, param := range earlier.Parameters {
+			if param == nil || later.Parameters[i] == nil || param.GetName() != later.Parameters[i].GetName() {
+				return false
+			}
+		}
+		return true
+	}
+	for i, param := range earlier.Parameters {
+		if !coversPattern(param, later.Parameters[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// coversPattern reports whether every value later matches is matched by earlier
+func coversPattern(earlier, later ast.Pattern) bool {
+	if as, ok := later.(*ast.AsPattern); ok {
+		later = as.Pattern // the name matches whatever its pattern does
+	}
+	switch e := earlier.(type) {
+	case *ast.AsPattern:
+		return coversPattern(e.Pattern, later)
+	case *ast.IdentifierPattern, *ast.WildcardPattern:
+		return true
+	case *ast.LiteralPattern:
+		l, ok := later.(*ast.LiteralPattern)
+		return ok && sameLiteral(e.Value, l.Value)
+	case *ast.RangePattern:
+		return coversRange(e, later)
+	case *ast.StructPattern:
+		l, ok := later.(*ast.StructPattern)
+		if !ok || e.TypeName != l.TypeName {
+			return false
+		}
+		fields := make(map[string]ast.Pattern, len(l.Fields))
+		for _, field := range l.Fields {
+			fields[field.Name] = field.Pattern
+		}
+		for _, field := range e.Fields {
+			if field.Pattern == nil {
+				continue // shorthand binds the field, whatever it holds
+			}
+			laterField, ok := fields[field.Name]
+			if !ok || laterField == nil || !coversPattern(field.Pattern, laterField) {
+				return false
+			}
+		}
+		return true
+	case *ast.TuplePattern:
+		l, ok := later.(*ast.TuplePattern)
+		return ok && len(e.Elements) == len(l.Elements) && coversAll(e.Elements, l.Elements)
+	case *ast.ConstructorPattern:
+		l, ok := later.(*ast.ConstructorPattern)
+		return ok && e.Name == l.Name && len(e.Arguments) == len(l.Arguments) && coversAll(e.Arguments, l.Arguments)
+	case *ast.ArrayPattern:
+		// [x, ...rest] covers the arrays with at least one element, [x] only those with one
+		l, ok := later.(*ast.ArrayPattern)
+		if !ok || len(l.Elements) < len(e.Elements) {
+			return false
+		}
+		if e.Rest == nil && (l.Rest != nil || len(l.Elements) != len(e.Elements)) {
+			return false
+		}
+		return coversAll(e.Elements, l.Elements[:len(e.Elements)])
+	}
+	return false
+}
+
+// coversAll reports whether each pattern of earlier covers the one of later at
+// its position
+func coversAll(earlier, later []ast.Pattern) bool {
+	for i, pattern := range earlier {
+		if !coversPattern(pattern, later[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// coversRange reports whether the range earlier holds every value later matches:
+// a literal or a range of the same kind inside it
+func coversRange(earlier *ast.RangePattern, later ast.Pattern) bool {
+	low, high, err := earlier.Bounds()
+	if err != nil {
+		return false
+	}
+	switch l := later.(type) {
+	case *ast.LiteralPattern:
+		text := fmt.Sprint(l.Value)
+		if strings.HasPrefix(text, "'") != earlier.IsCharacter() {
+			return false
+		}
+		value, _, err := (&ast.RangePattern{Low: text, High: text}).Bounds()
+		return err == nil && low <= value && value <= high
+	case *ast.RangePattern:
+		laterLow, laterHigh, err := l.Bounds()
+		// an empty range is reported by checkRangePattern
+		return err == nil && l.IsCharacter() == earlier.IsCharacter() && low <= laterLow && laterLow <= laterHigh && laterHigh <= high
+	}
+	return false
+}
+
+// sameLiteral compares literal patterns by value, so 0xFF is 255
+func sameLiteral(a, b any) bool {
+	x, errX := ast.ParseInteger(fmt.Sprint(a))
+	y, errY := ast.ParseInteger(fmt.Sprint(b))
+	if errX == nil && errY == nil {
+		return x == y
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func clauseParameters(clause *ast.FunctionClause) string {
+	params := make([]string, len(clause.Parameters))
+	for i, param := range clause.Parameters {
+		if param != nil {
+			params[i] = param.GetName()
+		}
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
@@ -8,9 +8,11 @@ The AST nodes serve as the source of truth - the symbol table just indexes them.
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Lyra-Language/lyra/pkg/ast"
 	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
 	"github.com/Lyra-Language/lyra/pkg/types"
 
 	sitter "github.com/tree-sitter/go-tree-sitter"
@@ -22,6 +24,8 @@ type Collector struct {
 	table  *symbols.SymbolTable
 	ast    *ast.Program
 	errors []error
+
+	typeNames []ast.TypeName // named by the annotations parsed since takeTypeNames
 }
 
 func NewCollector(source []byte) *Collector {
@@ -39,6 +43,22 @@ func (c *Collector) Collect(root *sitter.Node) (*ast.Program, *symbols.SymbolTab
 	return c.ast, c.table, c.errors
 }
 
+// Error is a problem found while collecting, like a malformed literal or a name
+// declared twice
+type Error struct {
+	Code     diagnostics.Code
+	Location ast.Location
+	Message  string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s [%s]", e.Location.StartLine, e.Location.StartCol, e.Message, e.Code)
+}
+
+func (c *Collector) error(code diagnostics.Code, loc ast.Location, format string, args ...any) {
+	c.errors = append(c.errors, Error{Code: code, Location: loc, Message: fmt.Sprintf(format, args...)})
+}
+
 func (c *Collector) walkProgram(node *sitter.Node) {
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
@@ -49,10 +69,24 @@ func (c *Collector) walkProgram(node *sitter.Node) {
 			stmt = c.collectTypeDeclaration(child)
 		case "function_definition":
 			stmt = c.collectFunctionDef(child)
+		case "impl_declaration":
+			stmt = c.collectImpl(child)
 		case "declaration", "const_declaration":
-			stmt = c.collectVariableDeclaration(child)
+			if child.ChildByFieldName("pattern") != nil {
+				stmt = c.collectDestructuringDeclaration(child)
+			} else {
+				stmt = c.collectVariableDeclaration(child)
+			}
+		case "var_reassignment":
+			if assign := c.collectVarAssignment(child); assign != nil {
+				stmt = assign
+			}
 		case "expression_statement":
 			stmt = c.collectExpressionStatement(child)
+		case "use_declaration":
+			if use := c.collectUse(child); use != nil {
+				stmt = use
+			}
 		}
 
 		if stmt != nil {
@@ -78,6 +112,17 @@ func (c *Collector) nodeLocation(node *sitter.Node) ast.Location {
 	}
 }
 
+// collectVisibility reads the modifier of a declaration, pub, pub(module) or
+// pub(package)
+func (c *Collector) collectVisibility(node *sitter.Node) ast.Visibility {
+	visibility, err := ast.ParseVisibility(c.nodeText(node))
+	if err != nil {
+		c.error(diagnostics.MalformedSyntax, c.nodeLocation(node), "%s", err)
+		return ast.VisibilityFile
+	}
+	return visibility
+}
+
 func (c *Collector) collectGenericParams(node *sitter.Node) []string {
 	params := make([]string, 0)
 	for i := uint(0); i < node.ChildCount(); i++ {
@@ -89,8 +134,9 @@ func (c *Collector) collectGenericParams(node *sitter.Node) []string {
 	return params
 }
 
-func (c *Collector) collectDataConstructor(node *sitter.Node) (string, types.DataTypeConstructor) {
+func (c *Collector) collectDataConstructor(node *sitter.Node) (string, ast.Location, types.DataTypeConstructor) {
 	var name string
+	var nameLoc ast.Location
 	ctor := types.DataTypeConstructor{
 		Params: make([]types.Type, 0),
 		Fields: make(map[string]types.StructField),
@@ -101,6 +147,7 @@ func (c *Collector) collectDataConstructor(node *sitter.Node) (string, types.Dat
 		switch child.Kind() {
 		case "data_type_constructor_name":
 			name = c.nodeText(child)
+			nameLoc = c.nodeLocation(child)
 		case "generic_type", "user_defined_type_name", "signed_integer_type", "string_type", "boolean_type", "float_type":
 			ctor.Params = append(ctor.Params, c.parseType(child))
 		case "struct_type_body":
@@ -109,7 +156,7 @@ func (c *Collector) collectDataConstructor(node *sitter.Node) (string, types.Dat
 	}
 
 	ctor.Name = name
-	return name, ctor
+	return name, nameLoc, ctor
 }
 
 func (c *Collector) collectStructFields(node *sitter.Node) map[string]types.StructField {
@@ -134,17 +181,33 @@ func (c *Collector) collectStructFields(node *sitter.Node) map[string]types.Stru
 	return fields
 }
 
-func (c *Collector) collectFunctionSignature(node *sitter.Node) (name string, genericParams []string, sig *types.FunctionType, isPure, isAsync bool) {
+// collectStructFieldLocations maps each struct member name to the location of the name
+func (c *Collector) collectStructFieldLocations(node *sitter.Node) map[string]ast.Location {
+	locations := make(map[string]ast.Location)
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		if child.Kind() == "struct_member" {
+			if nameNode := child.ChildByFieldName("field_name"); nameNode != nil {
+				locations[c.nodeText(nameNode)] = c.nodeLocation(nameNode)
+			}
+		}
+	}
+	return locations
+}
+
+func (c *Collector) collectFunctionSignature(node *sitter.Node) (name string, nameLoc ast.Location, genericParams []string, sig *types.FunctionType, sigLoc ast.Location, isPure, isAsync bool) {
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
 		text := c.nodeText(child)
 		switch child.Kind() {
 		case "identifier":
 			name = text
+			nameLoc = c.nodeLocation(child)
 		case "generic_parameters":
 			genericParams = c.collectGenericParams(child)
 		case "function_type":
 			sig = c.parseFunctionType(child)
+			sigLoc = c.nodeLocation(child)
 		default:
 			switch text {
 			case "pure":
@@ -154,7 +217,7 @@ func (c *Collector) collectFunctionSignature(node *sitter.Node) (name string, ge
 			}
 		}
 	}
-	return name, genericParams, sig, isPure, isAsync
+	return name, nameLoc, genericParams, sig, sigLoc, isPure, isAsync
 }
 
 func (c *Collector) parseType(node *sitter.Node) types.Type {
@@ -171,16 +234,32 @@ func (c *Collector) parseType(node *sitter.Node) types.Type {
 	case "boolean_type":
 		return types.PrimitiveType{Name: types.Bool}
 	case "user_defined_type_name":
-		return types.UnresolvedType{Name: c.nodeText(node)}
+		name := c.nodeText(node)
+		c.typeNames = append(c.typeNames, ast.TypeName{Name: name, Location: c.nodeLocation(node)})
+		return types.UnresolvedType{Name: name}
 	case "generic_type":
-		return types.GenericType{Name: c.nodeText(node)}
+		name := c.nodeText(node)
+		c.typeNames = append(c.typeNames, ast.TypeName{Name: name, Location: c.nodeLocation(node)})
+		return types.GenericType{Name: name}
 	case "array_type":
 		return c.parseArrayType(node)
+	case "tuple_type":
+		return c.parseTupleType(node)
+	case "type_hole":
+		return types.HoleType{}
 	}
-	c.errors = append(c.errors, fmt.Errorf("parseType: unknown type node kind: %s", node.Kind()))
+	c.error(diagnostics.MalformedSyntax, c.nodeLocation(node), "unknown type node kind: %s", node.Kind())
 	return nil
 }
 
+// takeTypeNames returns the user-defined types and generic parameters named by
+// the annotations parsed since it was last called
+func (c *Collector) takeTypeNames() []ast.TypeName {
+	names := c.typeNames
+	c.typeNames = nil
+	return names
+}
+
 func (c *Collector) parseArrayType(node *sitter.Node) types.Type {
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
@@ -191,6 +270,15 @@ func (c *Collector) parseArrayType(node *sitter.Node) types.Type {
 	return types.ArrayType{}
 }
 
+// parseTupleType reads (A, B, ...), whose named children are the element types
+func (c *Collector) parseTupleType(node *sitter.Node) types.Type {
+	tuple := types.TupleType{Elements: make([]types.Type, 0, node.NamedChildCount())}
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		tuple.Elements = append(tuple.Elements, c.parseType(node.NamedChild(i)))
+	}
+	return tuple
+}
+
 func (c *Collector) parseFunctionType(node *sitter.Node) *types.FunctionType {
 	ft := &types.FunctionType{
 		ParameterTypes: make([]types.ParameterType, 0),
@@ -221,7 +309,7 @@ func (c *Collector) parseParameterType(node *sitter.Node) types.ParameterType {
 	}
 	typeNode := node.ChildByFieldName("type")
 	if typeNode == nil {
-		c.errors = append(c.errors, fmt.Errorf("parseParameterType: type node is nil"))
+		c.error(diagnostics.MalformedSyntax, c.nodeLocation(node), "parameter type is missing")
 		return types.ParameterType{}
 	}
 	return types.ParameterType{
@@ -242,21 +330,126 @@ func (c *Collector) collectParameterPatterns(node *sitter.Node) []ast.Pattern {
 }
 
 func (c *Collector) collectPattern(node *sitter.Node) ast.Pattern {
-	pattern := node.ChildByFieldName("pattern")
-	if pattern != nil {
-		loc := c.nodeLocation(pattern)
-		switch pattern.Kind() {
-		case "identifier":
-			return &ast.IdentifierPattern{
-				PatternBase: ast.PatternBase{Location: loc},
-				Name:        c.nodeText(pattern),
+	return c.parsePattern(node.ChildByFieldName("pattern"))
+}
+
+func (c *Collector) parsePattern(pattern *sitter.Node) ast.Pattern {
+	if pattern == nil {
+		return nil
+	}
+	loc := c.nodeLocation(pattern)
+	switch pattern.Kind() {
+	case "wildcard_pattern":
+		return &ast.WildcardPattern{PatternBase: ast.PatternBase{Location: loc}}
+	case "identifier":
+		if c.nodeText(pattern) == "_" {
+			return &ast.WildcardPattern{PatternBase: ast.PatternBase{Location: loc}}
+		}
+		return &ast.IdentifierPattern{
+			PatternBase: ast.PatternBase{Location: loc},
+			Name:        c.nodeText(pattern),
+		}
+	case "literal_pattern":
+		text := c.nodeText(pattern)
+		if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+			c.unescape(text, loc) // the pattern keeps its text; this reports bad escapes
+		}
+		return &ast.LiteralPattern{
+			PatternBase: ast.PatternBase{Location: loc},
+			Value:       text,
+		}
+	case "as_pattern":
+		name, inner := pattern.ChildByFieldName("name"), c.parsePattern(pattern.ChildByFieldName("pattern"))
+		if name == nil || inner == nil {
+			return inner
+		}
+		return &ast.AsPattern{
+			PatternBase:  ast.PatternBase{Location: loc},
+			Name:         c.nodeText(name),
+			NameLocation: c.nodeLocation(name),
+			Pattern:      inner,
+		}
+	case "range_pattern":
+		low, high := pattern.ChildByFieldName("low"), pattern.ChildByFieldName("high")
+		if low == nil || high == nil {
+			return nil
+		}
+		return &ast.RangePattern{
+			PatternBase: ast.PatternBase{Location: loc},
+			Low:         c.nodeText(low),
+			High:        c.nodeText(high),
+		}
+	case "struct_pattern":
+		return c.parseStructPattern(pattern)
+	case "tuple_pattern":
+		tuple := &ast.TuplePattern{PatternBase: ast.PatternBase{Location: loc}}
+		for i := uint(0); i < pattern.NamedChildCount(); i++ {
+			if element := c.parsePattern(pattern.NamedChild(i)); element != nil {
+				tuple.Elements = append(tuple.Elements, element)
 			}
-		case "literal_pattern":
-			return &ast.LiteralPattern{
-				PatternBase: ast.PatternBase{Location: loc},
-				Value:       c.nodeText(pattern),
+		}
+		return tuple
+	case "constructor_pattern":
+		return c.parseConstructorPattern(pattern)
+	case "array_pattern":
+		array := &ast.ArrayPattern{PatternBase: ast.PatternBase{Location: loc}}
+		for i := uint(0); i < pattern.NamedChildCount(); i++ {
+			child := pattern.NamedChild(i)
+			if child.Kind() != "rest_pattern" {
+				if element := c.parsePattern(child); element != nil {
+					array.Elements = append(array.Elements, element)
+				}
+				continue
+			}
+			array.Rest = &ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: c.nodeLocation(child)}, Name: "_"}
+			if name := child.ChildByFieldName("name"); name != nil {
+				array.Rest = &ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: c.nodeLocation(name)}, Name: c.nodeText(name)}
 			}
 		}
+		return array
 	}
 	return nil
 }
+
+// parseConstructorPattern reads Some(x): the constructor's name, possibly
+// qualified, then a pattern for each argument
+func (c *Collector) parseConstructorPattern(node *sitter.Node) *ast.ConstructorPattern {
+	pattern := &ast.ConstructorPattern{PatternBase: ast.PatternBase{Location: c.nodeLocation(node)}}
+	if name := node.ChildByFieldName("constructor"); name != nil {
+		pattern.Name = c.nodeText(name)
+	}
+	for i := uint(0); i < node.ChildCount(); i++ {
+		if node.FieldNameForChild(uint32(i)) != "argument" {
+			continue
+		}
+		if argument := c.parsePattern(node.Child(i)); argument != nil {
+			pattern.Arguments = append(pattern.Arguments, argument)
+		}
+	}
+	return pattern
+}
+
+func (c *Collector) parseStructPattern(node *sitter.Node) *ast.StructPattern {
+	pattern := &ast.StructPattern{
+		PatternBase: ast.PatternBase{Location: c.nodeLocation(node)},
+		Fields:      make([]*ast.StructPatternField, 0),
+	}
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		switch child.Kind() {
+		case "user_defined_type_name", "data_type_constructor_name":
+			pattern.TypeName = c.nodeText(child)
+		case "struct_pattern_member":
+			nameNode := child.ChildByFieldName("field_name")
+			if nameNode == nil {
+				continue
+			}
+			pattern.Fields = append(pattern.Fields, &ast.StructPatternField{
+				Name:         c.nodeText(nameNode),
+				NameLocation: c.nodeLocation(nameNode),
+				Pattern:      c.parsePattern(child.ChildByFieldName("pattern")),
+			})
+		}
+	}
+	return pattern
+}
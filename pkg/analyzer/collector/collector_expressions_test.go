@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/parser"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// collectSource parses and collects source, failing the test if it does not parse
+func collectSource(t *testing.T, source string) (*ast.Program, *symbols.SymbolTable, []error) {
+	t.Helper()
+	tree, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	return NewCollector([]byte(source)).Collect(tree.RootNode())
+}
+
+func TestCollector_TupleLiteral(t *testing.T) {
+	source := `def divmod: (Int, Int) -> (Int, Int) = (a, b) => (a / b, a % b)`
+
+	_, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	funcDef, ok := table.Functions["divmod"]
+	if !ok {
+		t.Fatalf("\"divmod\" not found in functions")
+	}
+	tuple, ok := funcDef.Clauses[0].Body.(*ast.TupleLiteralExpr)
+	if !ok {
+		t.Fatalf("\"divmod\" body is not a TupleLiteralExpr. Got %T", funcDef.Clauses[0].Body)
+	}
+	if len(tuple.Elements) != 2 || tuple.GetName() != "(a / b, a % b)" {
+		t.Fatalf("Expected the tuple (a / b, a %% b). Got %s", tuple.GetName())
+	}
+}
+
+func TestCollector_Lambda(t *testing.T) {
+	source := `let inc: (Int) -> Int = (x) => x + 1`
+
+	program, _, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	varDecl := program.Statements[0].(*ast.VarDeclStmt)
+	lambda, ok := varDecl.Value.(*ast.LambdaExpr)
+	if !ok {
+		t.Fatalf("\"inc\" value is not a LambdaExpr. Got %T", varDecl.Value)
+	}
+	if len(lambda.Parameters) != 1 || lambda.Parameters[0].GetName() != "x" {
+		t.Fatalf("Expected the parameter x. Got %v", lambda.Parameters)
+	}
+	if _, ok := lambda.Body.(*ast.BinaryOpExpr); !ok {
+		t.Fatalf("Expected the body x + 1. Got %T", lambda.Body)
+	}
+}
+
+func TestCollector_ArrayLiteral(t *testing.T) {
+	source := "let primes: [Int] = [2, 3, 5]\nlet none: [Int] = []"
+
+	program, _, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	primes, ok := program.Statements[0].(*ast.VarDeclStmt).Value.(*ast.ArrayLiteralExpr)
+	if !ok || len(primes.Elements) != 3 || primes.GetName() != "[2, 3, 5]" {
+		t.Fatalf("Expected the array [2, 3, 5]. Got %v", program.Statements[0].(*ast.VarDeclStmt).Value)
+	}
+	none, ok := program.Statements[1].(*ast.VarDeclStmt).Value.(*ast.ArrayLiteralExpr)
+	if !ok || len(none.Elements) != 0 {
+		t.Fatalf("Expected the empty array. Got %v", program.Statements[1].(*ast.VarDeclStmt).Value)
+	}
+}
+
+func TestCollector_FieldNameLocations(t *testing.T) {
+	source := "let p: Point = Point { x, y: 2 }\nlet n: Int = p.y"
+
+	program, _, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	literal, ok := program.Statements[0].(*ast.VarDeclStmt).Value.(*ast.StructLiteralExpr)
+	if !ok || literal.TypeName != "Point" || len(literal.Fields) != 2 {
+		t.Fatalf("Expected the literal Point { x, y: 2 }. Got %v", program.Statements[0].(*ast.VarDeclStmt).Value)
+	}
+	x, y := literal.Fields[0], literal.Fields[1]
+	if !x.Shorthand || x.Value.GetName() != "x" || x.NameLocation != (ast.Location{StartLine: 1, StartCol: 24, EndLine: 1, EndCol: 25}) {
+		t.Fatalf("Expected the shorthand x at 1:24. Got %+v", x)
+	}
+	if y.Shorthand || y.NameLocation != (ast.Location{StartLine: 1, StartCol: 27, EndLine: 1, EndCol: 28}) {
+		t.Fatalf("Expected y: 2 named at 1:27. Got %+v", y)
+	}
+
+	member, ok := program.Statements[1].(*ast.VarDeclStmt).Value.(*ast.MemberAccessExpr)
+	if !ok || member.Member != "y" || member.MemberLocation != (ast.Location{StartLine: 2, StartCol: 16, EndLine: 2, EndCol: 17}) {
+		t.Fatalf("Expected the member y at 2:16. Got %+v", program.Statements[1].(*ast.VarDeclStmt).Value)
+	}
+}
+
+func TestCollector_CallMarkers(t *testing.T) {
+	source := "let size: Int = push(mut stack, 1)"
+
+	program, _, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	call, ok := program.Statements[0].(*ast.VarDeclStmt).Value.(*ast.CallExpr)
+	if !ok || len(call.Arguments) != 2 {
+		t.Fatalf("Expected a call with two arguments. Got %v", program.Statements[0].(*ast.VarDeclStmt).Value)
+	}
+	if call.Modifier(0) != types.Modifier("mut") || call.Modifier(1) != "" {
+		t.Fatalf("Expected stack passed mut and 1 unmarked. Got %v", call.Modifiers)
+	}
+	if call.GetName() != "push(mut stack, 1)" {
+		t.Fatalf("Expected push(mut stack, 1). Got %s", call.GetName())
+	}
+}
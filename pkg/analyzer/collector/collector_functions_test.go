@@ -3,6 +3,7 @@ package collector
 import (
 	"testing"
 
+	"github.com/Lyra-Language/lyra/pkg/ast"
 	"github.com/Lyra-Language/lyra/pkg/parser"
 	"github.com/Lyra-Language/lyra/pkg/types"
 )
@@ -146,3 +147,145 @@ func TestCollector_FunctionDefinitionWithMultipleClausesAndGuard(t *testing.T) {
 		t.Fatalf("\"fib\" return type is not Int. Got %v", funcDef.Signature.ReturnType)
 	}
 }
+
+func TestCollector_ExternFunction(t *testing.T) {
+	source := `extern def now: () -> Int = "go:time.UnixNano"`
+
+	_, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	funcDef, ok := table.Functions["now"]
+	if !ok {
+		t.Fatalf("\"now\" not found in functions")
+	}
+	if !funcDef.IsExtern() || funcDef.Extern != "go:time.UnixNano" {
+		t.Fatalf("Expected \"now\" bound to go:time.UnixNano unquoted. Got %q", funcDef.Extern)
+	}
+	if len(funcDef.Clauses) != 0 {
+		t.Fatalf("An extern function has no clauses. Got %d", len(funcDef.Clauses))
+	}
+	if funcDef.Signature == nil || !types.TypesEqual(funcDef.Signature.ReturnType, intType) {
+		t.Fatalf("Expected the signature () -> Int. Got %v", funcDef.Signature)
+	}
+}
+
+func TestCollector_TypeHoles(t *testing.T) {
+	source := "def pick: (_, Int) -> ? = (a, b) => ???"
+
+	_, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	funcDef := table.Functions["pick"]
+	if funcDef == nil || funcDef.Signature == nil {
+		t.Fatalf("\"pick\" has no signature")
+	}
+	if _, ok := funcDef.Signature.ParameterTypes[0].Type.(types.HoleType); !ok {
+		t.Fatalf("Expected _ read as a hole. Got %v", funcDef.Signature.ParameterTypes[0].Type)
+	}
+	if _, ok := funcDef.Signature.ReturnType.(types.HoleType); !ok {
+		t.Fatalf("Expected ? read as a hole. Got %v", funcDef.Signature.ReturnType)
+	}
+	if _, ok := funcDef.Clauses[0].Body.(*ast.HoleExpr); !ok {
+		t.Fatalf("Expected ??? read as a HoleExpr. Got %T", funcDef.Clauses[0].Body)
+	}
+}
+
+func TestCollector_NestedFunctions(t *testing.T) {
+	source := `
+		def quadruple: (Int) -> Int = (x) => {
+			def twice: (Int) -> Int = (y) => y + y
+			twice(twice(x))
+		}
+	`
+
+	program, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+	if len(program.Statements) != 1 {
+		t.Fatalf("Expected 1 statement, got %d", len(program.Statements))
+	}
+	if _, ok := table.Functions["twice"]; ok {
+		t.Fatalf("\"twice\" is only seen by the clause defining it")
+	}
+
+	clause := table.Functions["quadruple"].Clauses[0]
+	if len(clause.Functions) != 1 || clause.Functions[0].Name != "twice" {
+		t.Fatalf("Expected twice defined in the clause. Got %v", clause.Functions)
+	}
+	if clause.Body == nil || clause.Body.GetName() != "twice(twice(x))" {
+		t.Fatalf("Expected the block's result as the body. Got %v", clause.Body)
+	}
+}
+
+func TestCollector_DuplicateNestedFunction(t *testing.T) {
+	source := `
+		def outer: (Int) -> Int = (x) => {
+			def inner: (Int) -> Int = (y) => y
+			def inner: (Int) -> Int = (y) => y + 1
+			inner(x)
+		}
+	`
+
+	_, _, errors := collectSource(t, source)
+	if len(errors) != 1 {
+		t.Fatalf("Expected inner reported as defined twice. Got %v", errors)
+	}
+}
+
+func TestCollector_ClausePatterns(t *testing.T) {
+	source := `
+		data Shape = Circle(Float) | Empty
+		def describe: (Int) -> String = {
+			(0..=9) => "digit",
+			(_) => "other",
+		}
+		def radius: (Shape) -> Float = {
+			(Circle(r)) => r,
+			(Empty) => 0.0,
+		}
+		def head: ([Int]) -> Int = {
+			([x, ...rest]) => x,
+			([]) => 0,
+		}
+		def same: (Shape) -> Shape = (s @ Circle(_)) => s
+	`
+
+	_, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+	pattern := func(name string, clause int) ast.Pattern {
+		t.Helper()
+		funcDef, ok := table.Functions[name]
+		if !ok || len(funcDef.Clauses) <= clause || len(funcDef.Clauses[clause].Parameters) != 1 {
+			t.Fatalf("Expected %q to have a clause %d with one parameter. Got %v", name, clause, funcDef)
+		}
+		return funcDef.Clauses[clause].Parameters[0]
+	}
+
+	if r, ok := pattern("describe", 0).(*ast.RangePattern); !ok || r.Low != "0" || r.High != "9" {
+		t.Fatalf("Expected the range 0..=9. Got %v", pattern("describe", 0))
+	}
+	if _, ok := pattern("describe", 1).(*ast.WildcardPattern); !ok {
+		t.Fatalf("Expected a wildcard. Got %T", pattern("describe", 1))
+	}
+	if c, ok := pattern("radius", 0).(*ast.ConstructorPattern); !ok || c.Name != "Circle" || len(c.Arguments) != 1 || c.Arguments[0].GetName() != "r" {
+		t.Fatalf("Expected the constructor pattern Circle(r). Got %v", pattern("radius", 0))
+	}
+	a, ok := pattern("head", 0).(*ast.ArrayPattern)
+	if !ok || len(a.Elements) != 1 || a.Rest == nil || a.Rest.Name != "rest" {
+		t.Fatalf("Expected the array pattern [x, ...rest]. Got %v", pattern("head", 0))
+	}
+	if empty, ok := pattern("head", 1).(*ast.ArrayPattern); !ok || len(empty.Elements) != 0 || empty.Rest != nil {
+		t.Fatalf("Expected the empty array pattern. Got %v", pattern("head", 1))
+	}
+	as, ok := pattern("same", 0).(*ast.AsPattern)
+	if !ok || as.Name != "s" || as.GetName() != "s @ Circle(_)" {
+		t.Fatalf("Expected the as pattern s @ Circle(_). Got %v", pattern("same", 0))
+	}
+}
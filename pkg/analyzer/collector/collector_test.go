@@ -95,3 +95,127 @@ func TestCollector_VariableDeclaration(t *testing.T) {
 		t.Fatalf("\"the_answer\" has no init value")
 	}
 }
+
+func TestCollector_UnsignedIntegerLiteral(t *testing.T) {
+	source := `let mask: UInt64 = 0xFFFF_FFFF_FFFF_FFFF`
+
+	tree, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	collector := NewCollector([]byte(source))
+	program, _, errors := collector.Collect(tree.RootNode())
+	if len(errors) > 0 {
+		t.Fatalf("Expected the range left to the checker. Got %v", errors)
+	}
+
+	varDecl := program.Statements[0].(*ast.VarDeclStmt)
+	literal, ok := varDecl.Value.(*ast.IntegerLiteralExpr)
+	if !ok || literal.Text != "0xFFFF_FFFF_FFFF_FFFF" || literal.Value != -1 {
+		t.Fatalf("Expected the literal kept as written with all 64 bits set. Got %#v", varDecl.Value)
+	}
+}
+
+func TestCollector_StructFieldLocations(t *testing.T) {
+	source := "struct Point {\n\tx: Int,\n\ty: Int = 0,\n}"
+
+	_, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	structDecl := table.Types["Point"]
+	if structDecl == nil {
+		t.Fatalf("\"Point\" not found in types")
+	}
+	if structDecl.NameLocation != (ast.Location{StartLine: 1, StartCol: 8, EndLine: 1, EndCol: 13}) {
+		t.Fatalf("Expected \"Point\" named at 1:8. Got %v", structDecl.NameLocation)
+	}
+	expected := map[string]ast.Location{
+		"x": {StartLine: 2, StartCol: 2, EndLine: 2, EndCol: 3},
+		"y": {StartLine: 3, StartCol: 2, EndLine: 3, EndCol: 3},
+	}
+	for name, loc := range expected {
+		if structDecl.FieldLocations[name] != loc {
+			t.Fatalf("Expected field %s named at %v. Got %v", name, loc, structDecl.FieldLocations[name])
+		}
+	}
+}
+
+func TestCollector_LazyDeclaration(t *testing.T) {
+	source := "lazy let table: Int = build()\nlet eager: Int = build()"
+
+	program, _, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	lazy, eager := program.Statements[0].(*ast.VarDeclStmt), program.Statements[1].(*ast.VarDeclStmt)
+	if !lazy.IsLazy || lazy.Keyword != "let" || lazy.Name != "table" {
+		t.Fatalf("Expected a lazy let named table. Got %+v", lazy)
+	}
+	if eager.IsLazy {
+		t.Fatalf("\"eager\" is not lazy")
+	}
+}
+
+func TestCollector_DestructuringDeclaration(t *testing.T) {
+	source := "let (q, _) = divmod(7, 2)\nlet Size { w, h: height } = measure(x)"
+
+	program, table, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+	if len(program.Statements) != 2 {
+		t.Fatalf("Expected 2 statements, got %d", len(program.Statements))
+	}
+
+	tuple, ok := program.Statements[0].(*ast.DestructuringDeclStmt)
+	if !ok {
+		t.Fatalf("Expected a DestructuringDeclStmt. Got %T", program.Statements[0])
+	}
+	if _, ok := tuple.Pattern.(*ast.TuplePattern); !ok || tuple.Keyword != "let" || tuple.Value == nil {
+		t.Fatalf("Expected let (q, _) with a value. Got %+v", tuple)
+	}
+	if len(tuple.Bindings) != 1 || tuple.Bindings[0].Name != "q" {
+		t.Fatalf("Expected only q bound, _ binding nothing. Got %v", tuple.Bindings)
+	}
+
+	size := program.Statements[1].(*ast.DestructuringDeclStmt)
+	pattern, ok := size.Pattern.(*ast.StructPattern)
+	if !ok || pattern.TypeName != "Size" || len(pattern.Fields) != 2 {
+		t.Fatalf("Expected the pattern Size { w, h: height }. Got %v", size.Pattern)
+	}
+	if len(size.Bindings) != 2 || size.Bindings[0].Name != "w" || size.Bindings[1].Name != "height" {
+		t.Fatalf("Expected w and height bound. Got %v", size.Bindings)
+	}
+	for _, name := range []string{"q", "w", "height"} {
+		if _, ok := table.GlobalScope.Lookup(name); !ok {
+			t.Fatalf("%q not found in global scope", name)
+		}
+	}
+	if _, ok := table.GlobalScope.Lookup("h"); ok {
+		t.Fatalf("\"h\" names the field, not a variable")
+	}
+}
+
+func TestCollector_VariableReassignment(t *testing.T) {
+	source := "var count: Int = 0\ncount = count + 1"
+
+	program, _, errors := collectSource(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("Collector errors: %v", errors)
+	}
+
+	assign, ok := program.Statements[1].(*ast.VarAssignStmt)
+	if !ok {
+		t.Fatalf("Expected a VarAssignStmt. Got %T", program.Statements[1])
+	}
+	if assign.Name != "count" || assign.NameLocation != (ast.Location{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 6}) {
+		t.Fatalf("Expected count written at 2:1. Got %s at %v", assign.Name, assign.NameLocation)
+	}
+	if assign.Value == nil || assign.Value.GetName() != "count + 1" {
+		t.Fatalf("Expected the value count + 1. Got %v", assign.Value)
+	}
+}
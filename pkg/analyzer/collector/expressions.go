@@ -1,9 +1,13 @@
 package collector
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
 	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
@@ -32,25 +36,56 @@ func (c *Collector) collectExpression(node *sitter.Node) ast.Expression {
 
 	switch node.Kind() {
 	case "integer", "integer_literal":
-		value, _ := strconv.ParseInt(c.nodeText(node), 10, 64)
-		return &ast.IntegerLiteralExpr{
-			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
-			Value:    value,
+		text := c.nodeText(node)
+		literal := &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}}}
+		value, err := ast.ParseInteger(text)
+		var tooLarge *ast.RangeError
+		switch {
+		case err == nil:
+			literal.Value = value
+		case errors.As(err, &tooLarge):
+			// the checker reports it unless an unsigned type holds it
+			unsigned, _ := ast.ParseUnsigned(text)
+			literal.Value, literal.Text = int64(unsigned), text
+		default:
+			c.error(diagnostics.InvalidLiteral, loc, "%s", err)
 		}
+		return literal
 
 	case "float", "float_literal":
-		value, _ := strconv.ParseFloat(c.nodeText(node), 64)
+		text := c.nodeText(node)
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			c.error(diagnostics.InvalidLiteral, loc, "invalid float literal %s", text)
+		}
 		return &ast.FloatLiteralExpr{
 			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
 			Value:    value,
+			Text:     text,
 		}
 
 	case "string", "string_literal":
+		text := c.nodeText(node)
 		return &ast.StringLiteralExpr{
 			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
-			Value:    c.nodeText(node),
+			Value:    c.unescape(text, loc),
+			Text:     text,
 		}
 
+	case "raw_string_literal":
+		text := c.nodeText(node)
+		if strings.HasPrefix(text, `r"""`) {
+			return c.multilineString(text, loc)
+		}
+		return &ast.RawStringLiteralExpr{
+			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
+			Value:    c.stringValue(text, loc, ast.RawString),
+			Text:     text,
+		}
+
+	case "multiline_string_literal":
+		return c.multilineString(c.nodeText(node), loc)
+
 	case "boolean", "boolean_literal":
 		value := c.nodeText(node) == "true"
 		return &ast.BooleanLiteralExpr{
@@ -71,6 +106,60 @@ func (c *Collector) collectExpression(node *sitter.Node) ast.Expression {
 			Operator: ast.BooleanBinaryOp(c.nodeText(node.ChildByFieldName("operator"))),
 			Right:    c.collectExpression(node.ChildByFieldName("right")),
 		}
+
+	case "binary_expression":
+		return c.collectBinary(node)
+
+	case "if_then_else":
+		return c.collectIfThenElse(node)
+
+	case "call_expression":
+		return c.collectCall(node)
+
+	case "member_expression":
+		return c.collectMemberAccess(node)
+
+	case "struct_literal":
+		return c.collectStructLiteral(node)
+
+	case "array_literal":
+		array := &ast.ArrayLiteralExpr{
+			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
+			Elements: make([]ast.Expression, 0, node.NamedChildCount()),
+		}
+		for i := uint(0); i < node.ChildCount(); i++ {
+			if child := node.Child(i); child.IsNamed() {
+				array.Elements = append(array.Elements, c.collectExpression(child))
+			}
+		}
+		return array
+
+	case "tuple_literal":
+		tuple := &ast.TupleLiteralExpr{
+			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
+			Elements: make([]ast.Expression, 0, node.NamedChildCount()),
+		}
+		for i := uint(0); i < node.ChildCount(); i++ {
+			if child := node.Child(i); child.IsNamed() {
+				tuple.Elements = append(tuple.Elements, c.collectExpression(child))
+			}
+		}
+		return tuple
+
+	case "lambda":
+		lambda := &ast.LambdaExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}}}
+		if parameters := node.ChildByFieldName("parameters"); parameters != nil {
+			lambda.Parameters = c.collectParameterPatterns(parameters)
+		}
+		body := node.ChildByFieldName("body")
+		if body != nil && body.Kind() == "block" {
+			body = body.ChildByFieldName("result")
+		}
+		lambda.Body = c.collectExpression(body)
+		return lambda
+
+	case "hole_expression":
+		return &ast.HoleExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}}}
 	}
 
 	// For wrapper nodes, recurse into the first named child
@@ -83,3 +172,174 @@ func (c *Collector) collectExpression(node *sitter.Node) ast.Expression {
 
 	return nil
 }
+
+// collectBinary collects `left op right`; the operator is the anonymous child between
+// the operands. Comparisons and logical operators become BooleanBinaryOpExprs.
+func (c *Collector) collectBinary(node *sitter.Node) ast.Expression {
+	var left, right ast.Expression
+	var operator string
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		switch {
+		case !child.IsNamed():
+			operator = c.nodeText(child)
+		case left == nil:
+			left = c.collectExpression(child)
+		default:
+			right = c.collectExpression(child)
+		}
+	}
+
+	base := ast.ExprBase{AstBase: ast.AstBase{Location: c.nodeLocation(node)}}
+	switch ast.BooleanBinaryOp(operator) {
+	case ast.BooleanBinaryOpLT, ast.BooleanBinaryOpLTE, ast.BooleanBinaryOpGT, ast.BooleanBinaryOpGTE,
+		ast.BooleanBinaryOpEq, ast.BooleanBinaryOpNEq, ast.BooleanBinaryOpAnd, ast.BooleanBinaryOpOr:
+		return &ast.BooleanBinaryOpExpr{ExprBase: base, Left: left, Operator: ast.BooleanBinaryOp(operator), Right: right}
+	}
+	return &ast.BinaryOpExpr{ExprBase: base, Left: left, Operator: operator, Right: right}
+}
+
+// collectIfThenElse collects `if cond then a else b`; its named children are the
+// condition and the branches in order
+func (c *Collector) collectIfThenElse(node *sitter.Node) *ast.IfThenExpr {
+	var parts []ast.Expression
+	for i := uint(0); i < node.ChildCount(); i++ {
+		if child := node.Child(i); child.IsNamed() {
+			parts = append(parts, c.collectExpression(child))
+		}
+	}
+	expr := &ast.IfThenExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: c.nodeLocation(node)}}}
+	if len(parts) > 0 {
+		expr.Condition = parts[0]
+	}
+	if len(parts) > 1 {
+		expr.Then = parts[1]
+	}
+	if len(parts) > 2 {
+		expr.Else = parts[2]
+	}
+	return expr
+}
+
+func (c *Collector) collectCall(node *sitter.Node) *ast.CallExpr {
+	call := &ast.CallExpr{
+		ExprBase:  ast.ExprBase{AstBase: ast.AstBase{Location: c.nodeLocation(node)}},
+		Arguments: make([]ast.Expression, 0),
+	}
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		switch {
+		case child.Kind() == "argument_list":
+			for j := uint(0); j < child.ChildCount(); j++ {
+				arg := child.Child(j)
+				if !arg.IsNamed() {
+					continue
+				}
+				// a marked argument (mut x) holds its marker and its value
+				modifier := types.Modifier("")
+				if marker, value := arg.ChildByFieldName("modifier"), arg.ChildByFieldName("value"); marker != nil && value != nil {
+					modifier, arg = types.Modifier(c.nodeText(marker)), value
+				}
+				if modifier != "" && call.Modifiers == nil {
+					call.Modifiers = make([]types.Modifier, len(call.Arguments))
+				}
+				if call.Modifiers != nil {
+					call.Modifiers = append(call.Modifiers, modifier)
+				}
+				call.Arguments = append(call.Arguments, c.collectExpression(arg))
+			}
+		case child.IsNamed() && call.Callee == nil:
+			call.Callee = c.collectExpression(child)
+		}
+	}
+	return call
+}
+
+func (c *Collector) collectMemberAccess(node *sitter.Node) *ast.MemberAccessExpr {
+	var objectNode, memberNode *sitter.Node
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		if child.Kind() == "identifier" && objectNode != nil {
+			memberNode = child
+		} else if child.IsNamed() && objectNode == nil {
+			objectNode = child
+		}
+	}
+
+	expr := &ast.MemberAccessExpr{
+		ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: c.nodeLocation(node)}},
+		Object:   c.collectExpression(objectNode),
+	}
+	if memberNode != nil {
+		expr.Member = c.nodeText(memberNode)
+		expr.MemberLocation = c.nodeLocation(memberNode)
+	}
+	return expr
+}
+
+func (c *Collector) collectStructLiteral(node *sitter.Node) *ast.StructLiteralExpr {
+	expr := &ast.StructLiteralExpr{
+		ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: c.nodeLocation(node)}},
+		Fields:   make([]*ast.StructLiteralField, 0),
+	}
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		switch child.Kind() {
+		case "user_defined_type_name", "data_type_constructor_name":
+			expr.TypeName = c.nodeText(child)
+		case "struct_literal_member":
+			nameNode := child.ChildByFieldName("field_name")
+			if nameNode == nil {
+				continue
+			}
+			field := &ast.StructLiteralField{
+				Name:         c.nodeText(nameNode),
+				NameLocation: c.nodeLocation(nameNode),
+			}
+			if valueNode := child.ChildByFieldName("value"); valueNode != nil {
+				field.Value = c.collectExpression(valueNode)
+			} else {
+				field.Shorthand = true
+				field.Value = &ast.IdentifierExpr{
+					ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: field.NameLocation}},
+					Name:     field.Name,
+				}
+			}
+			expr.Fields = append(expr.Fields, field)
+		}
+	}
+	return expr
+}
+
+func (c *Collector) multilineString(text string, loc ast.Location) *ast.MultilineStringLiteralExpr {
+	return &ast.MultilineStringLiteralExpr{
+		ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}},
+		Value:    c.stringValue(text, loc, ast.MultilineString),
+		Text:     text,
+		Raw:      strings.HasPrefix(text, "r"),
+	}
+}
+
+// unescape returns the value of a quoted literal at loc, reporting an invalid
+// escape sequence at its own column
+func (c *Collector) unescape(text string, loc ast.Location) string {
+	return c.stringValue(text, loc, ast.Unescape)
+}
+
+// stringValue returns the value of a string literal at loc as read by read,
+// reporting an error at the column it points to
+func (c *Collector) stringValue(text string, loc ast.Location, read func(text string) (string, error)) string {
+	value, err := read(text)
+	var escapeErr *ast.EscapeError
+	if !errors.As(err, &escapeErr) {
+		return value
+	}
+	at := ast.Location{StartLine: loc.StartLine, StartCol: loc.StartCol + escapeErr.Offset}
+	if newline := strings.LastIndexByte(text[:escapeErr.Offset], '\n'); newline >= 0 {
+		at.StartLine += strings.Count(text[:escapeErr.Offset], "\n")
+		at.StartCol = escapeErr.Offset - newline
+	}
+	at.EndLine, at.EndCol = at.StartLine, at.StartCol+2
+	c.error(diagnostics.InvalidLiteral, at, "%s", escapeErr.Message)
+	return text
+}
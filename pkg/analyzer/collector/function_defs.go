@@ -1,29 +1,70 @@
 package collector
 
 import (
-	"fmt"
+	"strconv"
 
 	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
 	"github.com/Lyra-Language/lyra/pkg/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
 func (c *Collector) collectFunctionDef(node *sitter.Node) *ast.FunctionDefStmt {
+	astNode := c.functionDef(node)
+	if err := c.table.RegisterFunction(astNode); err != nil {
+		c.error(diagnostics.DuplicateDeclaration, astNode.NameLocation, "%s", err)
+	}
+	return astNode
+}
+
+// collectImpl collects `impl Trait for Type { def ... }`; its methods are only
+// reached through the type, so they are not declared as functions
+func (c *Collector) collectImpl(node *sitter.Node) *ast.ImplStmt {
+	impl := &ast.ImplStmt{AstBase: ast.AstBase{Location: c.nodeLocation(node)}}
+	if trait := node.ChildByFieldName("trait"); trait != nil {
+		impl.Trait = c.nodeText(trait)
+	}
+	if typeName := node.ChildByFieldName("type"); typeName != nil {
+		impl.TypeName, impl.TypeLocation = c.nodeText(typeName), c.nodeLocation(typeName)
+	}
+	for i := uint(0); i < node.NamedChildCount(); i++ {
+		if child := node.NamedChild(i); child.Kind() == "function_definition" {
+			impl.Methods = append(impl.Methods, c.functionDef(child))
+		}
+	}
+	if err := c.table.RegisterImpl(impl); err != nil {
+		c.error(diagnostics.DuplicateDeclaration, impl.TypeLocation, "%s", err)
+	}
+	return impl
+}
+
+// functionDef collects a function definition without declaring it, for nested
+// functions, which are only seen by the clause defining them
+func (c *Collector) functionDef(node *sitter.Node) *ast.FunctionDefStmt {
 	var name string
+	var nameLoc ast.Location
 	var genericParams []string
 	var signature *types.FunctionType
+	var signatureLoc ast.Location
 	var clauses []*ast.FunctionClause
-	isPublic := false
+	visibility := ast.VisibilityFile
 	isPure := false
 	isAsync := false
+	var extern string
 
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
 		switch child.Kind() {
 		case "visibility":
-			isPublic = true
+			visibility = c.collectVisibility(child)
+		case "extern_target":
+			// extern def now: () -> Int = "go:time.UnixNano"
+			extern = c.nodeText(child)
+			if unquoted, err := strconv.Unquote(extern); err == nil {
+				extern = unquoted
+			}
 		case "function_signature":
-			name, genericParams, signature, isPure, isAsync = c.collectFunctionSignature(child)
+			name, nameLoc, genericParams, signature, signatureLoc, isPure, isAsync = c.collectFunctionSignature(child)
 		case "function_clause":
 			clauses = append(clauses, c.collectFunctionClause(child))
 		case "function_clause_list":
@@ -36,20 +77,20 @@ func (c *Collector) collectFunctionDef(node *sitter.Node) *ast.FunctionDefStmt {
 	}
 
 	astNode := &ast.FunctionDefStmt{
-		AstBase:       ast.AstBase{Location: c.nodeLocation(node)},
-		Name:          name,
-		GenericParams: genericParams,
-		Signature:     signature,
-		Clauses:       clauses,
-		IsPublic:      isPublic,
-		IsPure:        isPure,
-		IsAsync:       isAsync,
-	}
-
-	if err := c.table.RegisterFunction(astNode); err != nil {
-		c.errors = append(c.errors, err)
+		AstBase:           ast.AstBase{Location: c.nodeLocation(node)},
+		Name:              name,
+		NameLocation:      nameLoc,
+		GenericParams:     genericParams,
+		Signature:         signature,
+		SignatureLocation: signatureLoc,
+		TypeNames:         c.takeTypeNames(),
+		Clauses:           clauses,
+		IsPublic:          visibility == ast.VisibilityPublic,
+		Visibility:        visibility,
+		IsPure:            isPure,
+		IsAsync:           isAsync,
+		Extern:            extern,
 	}
-
 	return astNode
 }
 
@@ -66,7 +107,7 @@ func (c *Collector) collectFunctionClause(node *sitter.Node) *ast.FunctionClause
 	if guardNode != nil {
 		guardExpressionNode := guardNode.ChildByFieldName("guard_expression")
 		if guardExpressionNode == nil {
-			c.errors = append(c.errors, fmt.Errorf("guard expression is missing"))
+			c.error(diagnostics.MalformedSyntax, c.nodeLocation(guardNode), "guard expression is missing")
 		} else {
 			guard = &ast.GuardExpr{
 				ExprBase:  ast.ExprBase{AstBase: ast.AstBase{Location: c.nodeLocation(guardNode)}},
@@ -74,7 +115,12 @@ func (c *Collector) collectFunctionClause(node *sitter.Node) *ast.FunctionClause
 			}
 		}
 	}
+	var functions []*ast.FunctionDefStmt
 	bodyNode := node.ChildByFieldName("body")
+	if bodyNode != nil && bodyNode.Kind() == "block" {
+		functions = c.collectNestedFunctions(bodyNode)
+		bodyNode = bodyNode.ChildByFieldName("result")
+	}
 	if bodyNode != nil {
 		body = c.collectExpression(bodyNode)
 	}
@@ -83,6 +129,32 @@ func (c *Collector) collectFunctionClause(node *sitter.Node) *ast.FunctionClause
 		AstBase:    ast.AstBase{Location: c.nodeLocation(node)},
 		Parameters: parameters,
 		Guard:      guard,
+		Functions:  functions,
 		Body:       body,
 	}
 }
+
+// collectNestedFunctions collects the functions defined in the block of a clause
+// body. The type names they mention are their own, so those the enclosing
+// definition has named so far are set aside meanwhile.
+func (c *Collector) collectNestedFunctions(block *sitter.Node) []*ast.FunctionDefStmt {
+	enclosing := c.takeTypeNames()
+	defer func() { c.typeNames = append(enclosing, c.typeNames...) }()
+
+	var functions []*ast.FunctionDefStmt
+	defined := make(map[string]bool)
+	for i := uint(0); i < block.ChildCount(); i++ {
+		child := block.Child(i)
+		if child.Kind() != "function_definition" {
+			continue
+		}
+		fn := c.functionDef(child)
+		if defined[fn.Name] {
+			c.error(diagnostics.DuplicateDeclaration, fn.NameLocation, "function %s is already defined in this block", fn.Name)
+			continue
+		}
+		defined[fn.Name] = true
+		functions = append(functions, fn)
+	}
+	return functions
+}
@@ -2,57 +2,91 @@ package collector
 
 import (
 	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
 	"github.com/Lyra-Language/lyra/pkg/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
 func (c *Collector) collectTypeDeclaration(node *sitter.Node) *ast.TypeDeclStmt {
-	// type_declaration contains struct_type, data_type, trait_declaration, etc.
+	// type_declaration contains attributes, then struct_type, data_type, trait_declaration, etc.
+	var derives []string
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
+		var decl *ast.TypeDeclStmt
 		switch child.Kind() {
+		case "attribute":
+			derives = append(derives, c.collectDerives(child)...)
 		case "struct_type":
-			return c.collectStructType(child)
+			decl = c.collectStructType(child)
 		case "data_type":
-			return c.collectDataType(child)
+			decl = c.collectDataType(child)
+		case "type_alias":
+			decl = c.collectTypeAlias(child)
+		}
+		if decl != nil {
+			decl.Derives = derives
+			return decl
 		}
 	}
 	return nil
 }
 
+// collectDerives returns the traits of a @derive(Serialize, Deserialize) attribute
+func (c *Collector) collectDerives(node *sitter.Node) []string {
+	name := node.ChildByFieldName("name")
+	if name == nil || c.nodeText(name) != "derive" {
+		return nil
+	}
+	var traits []string
+	if arguments := node.ChildByFieldName("arguments"); arguments != nil {
+		for i := uint(0); i < arguments.NamedChildCount(); i++ {
+			traits = append(traits, c.nodeText(arguments.NamedChild(i)))
+		}
+	}
+	return traits
+}
+
 func (c *Collector) collectStructType(node *sitter.Node) *ast.TypeDeclStmt {
 	var name string
+	var nameLoc ast.Location
 	var genericParams []string
 	fields := make(map[string]types.StructField)
-	isPublic := false
+	fieldLocations := make(map[string]ast.Location)
+	visibility := ast.VisibilityFile
 
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
 		switch child.Kind() {
 		case "visibility":
-			isPublic = true
+			visibility = c.collectVisibility(child)
 		case "struct_name":
 			name = c.nodeText(child)
+			nameLoc = c.nodeLocation(child)
 		case "generic_parameters":
 			genericParams = c.collectGenericParams(child)
 		case "struct_type_body":
 			fields = c.collectStructFields(child)
+			fieldLocations = c.collectStructFieldLocations(child)
 		}
 	}
 
 	astNode := &ast.TypeDeclStmt{
 		AstBase:       ast.AstBase{Location: c.nodeLocation(node)},
 		Name:          name,
+		NameLocation:  nameLoc,
 		GenericParams: genericParams,
 		Type: types.StructType{
 			Name:   name,
 			Fields: fields,
 		},
-		IsPublic: isPublic,
+		IsPublic:       visibility == ast.VisibilityPublic,
+		Visibility:     visibility,
+		FieldLocations: fieldLocations,
+		TypeNames:      c.takeTypeNames(),
 	}
 
 	if err := c.table.RegisterType(astNode); err != nil {
-		c.errors = append(c.errors, err)
+		c.error(diagnostics.DuplicateDeclaration, astNode.NameLocation, "%s", err)
 	}
 
 	return astNode
@@ -60,39 +94,107 @@ func (c *Collector) collectStructType(node *sitter.Node) *ast.TypeDeclStmt {
 
 func (c *Collector) collectDataType(node *sitter.Node) *ast.TypeDeclStmt {
 	var name string
+	var nameLoc ast.Location
 	var genericParams []string
 	constructors := make(map[string]types.DataTypeConstructor)
-	isPublic := false
+	constructorLocations := make(map[string]ast.Location)
+	constructorNameLocations := make(map[string]ast.Location)
+	visibility := ast.VisibilityFile
 
 	for i := uint(0); i < node.ChildCount(); i++ {
 		child := node.Child(i)
 		switch child.Kind() {
 		case "visibility":
-			isPublic = true
+			visibility = c.collectVisibility(child)
 		case "data_type_name":
 			name = c.nodeText(child)
+			nameLoc = c.nodeLocation(child)
 		case "generic_parameters":
 			genericParams = c.collectGenericParams(child)
 		case "data_type_constructor":
-			ctorName, ctor := c.collectDataConstructor(child)
+			ctorName, nameLoc, ctor := c.collectDataConstructor(child)
 			constructors[ctorName] = ctor
+			constructorLocations[ctorName] = c.nodeLocation(child)
+			constructorNameLocations[ctorName] = nameLoc
 		}
 	}
 
+	dataType := types.DataType{
+		Name:         name,
+		Constructors: constructors,
+	}
 	astNode := &ast.TypeDeclStmt{
 		AstBase:       ast.AstBase{Location: c.nodeLocation(node)},
 		Name:          name,
+		NameLocation:  nameLoc,
 		GenericParams: genericParams,
-		Type: types.DataType{
-			Name:         name,
-			Constructors: constructors,
-		},
-		IsPublic: isPublic,
+		Type:          dataType,
+		IsPublic:      visibility == ast.VisibilityPublic,
+		Visibility:    visibility,
+		TypeNames:     c.takeTypeNames(),
 	}
 
 	if err := c.table.RegisterType(astNode); err != nil {
-		c.errors = append(c.errors, err)
+		c.error(diagnostics.DuplicateDeclaration, astNode.NameLocation, "%s", err)
+	}
+
+	for ctorName, ctor := range constructors {
+		ctorNode := &ast.DataConstructorDecl{
+			AstBase:      ast.AstBase{Location: constructorLocations[ctorName]},
+			Name:         ctorName,
+			NameLocation: constructorNameLocations[ctorName],
+			DataType:     name,
+			Signature:    constructorSignature(ctor, dataType),
+		}
+		if err := c.table.RegisterConstructor(ctorNode); err != nil {
+			c.error(diagnostics.DuplicateDeclaration, ctorNode.NameLocation, "%s", err)
+		}
+	}
+
+	return astNode
+}
+
+// collectTypeAlias reads type Name = T, whose name stands for T wherever it is
+// written
+func (c *Collector) collectTypeAlias(node *sitter.Node) *ast.TypeDeclStmt {
+	astNode := &ast.TypeDeclStmt{
+		AstBase: ast.AstBase{Location: c.nodeLocation(node)},
+		IsAlias: true,
+	}
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		if child.Kind() == "visibility" {
+			astNode.Visibility = c.collectVisibility(child)
+		}
+	}
+	if name := node.ChildByFieldName("name"); name != nil {
+		astNode.Name = c.nodeText(name)
+		astNode.NameLocation = c.nodeLocation(name)
 	}
+	astNode.Type = c.parseType(node.ChildByFieldName("type"))
+	if astNode.Type == nil {
+		c.error(diagnostics.MalformedSyntax, astNode.Location, "type alias %s names no type", astNode.Name)
+	}
+	astNode.IsPublic = astNode.Visibility == ast.VisibilityPublic
+	astNode.TypeNames = c.takeTypeNames()
 
+	if err := c.table.RegisterType(astNode); err != nil {
+		c.error(diagnostics.DuplicateDeclaration, astNode.NameLocation, "%s", err)
+	}
 	return astNode
 }
+
+// constructorSignature synthesizes the function type of a data constructor:
+// its positional params become parameters and the result is the data type.
+// Record-style constructors (Node { left: Tree, ... }) are built with literal
+// syntax, so they take no positional parameters.
+func constructorSignature(ctor types.DataTypeConstructor, dataType types.DataType) *types.FunctionType {
+	sig := &types.FunctionType{
+		ParameterTypes: make([]types.ParameterType, 0, len(ctor.Params)),
+		ReturnType:     dataType,
+	}
+	for _, param := range ctor.Params {
+		sig.ParameterTypes = append(sig.ParameterTypes, types.ParameterType{Type: param})
+	}
+	return sig
+}
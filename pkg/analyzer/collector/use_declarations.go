@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// collectUse collects `pub use geometry.shapes.Circle`: the identifiers of the
+// path but the last name the module, the last the symbol it brings in
+func (c *Collector) collectUse(node *sitter.Node) *ast.UseStmt {
+	use := &ast.UseStmt{AstBase: ast.AstBase{Location: c.nodeLocation(node)}}
+	var path []*sitter.Node
+	for i := uint(0); i < node.ChildCount(); i++ {
+		child := node.Child(i)
+		switch child.Kind() {
+		case "visibility":
+			use.IsPublic = true
+		case "use_path":
+			for j := uint(0); j < child.ChildCount(); j++ {
+				if segment := child.Child(j); segment.Kind() == "identifier" {
+					path = append(path, segment)
+				}
+			}
+		}
+	}
+	if len(path) < 2 {
+		return nil // the grammar asks for a module and a symbol
+	}
+	for _, segment := range path[:len(path)-1] {
+		use.Module = append(use.Module, c.nodeText(segment))
+	}
+	use.Name = c.nodeText(path[len(path)-1])
+	use.NameLocation = c.nodeLocation(path[len(path)-1])
+	return use
+}
@@ -2,32 +2,125 @@ package collector
 
 import (
 	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
 	"github.com/Lyra-Language/lyra/pkg/types"
 	sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
 func (c *Collector) collectVariableDeclaration(node *sitter.Node) *ast.VarDeclStmt {
 	keyword := c.nodeText(node.ChildByFieldName("keyword"))
-	name := c.nodeText(node.ChildByFieldName("name"))
+	isLazy := false
+	for i := uint(0); i < node.ChildCount(); i++ {
+		if node.Child(i).Kind() == "lazy" {
+			isLazy = true
+		}
+	}
+	nameNode := node.ChildByFieldName("name")
+	name := c.nodeText(nameNode)
 
 	var varType types.Type
+	var typeLoc ast.Location
 	if typeAnnotation := node.ChildByFieldName("type_annotation"); typeAnnotation != nil {
-		varType = c.parseType(typeAnnotation.ChildByFieldName("type"))
+		typeNode := typeAnnotation.ChildByFieldName("type")
+		varType = c.parseType(typeNode)
+		if typeNode != nil {
+			typeLoc = c.nodeLocation(typeNode)
+		}
 	}
 
 	initExpr := c.collectExpression(node.ChildByFieldName("value"))
 
 	astNode := &ast.VarDeclStmt{
-		AstBase: ast.AstBase{Location: c.nodeLocation(node)},
-		Keyword: keyword,
-		Name:    name,
-		Type:    varType,
-		Value:   initExpr,
+		AstBase:      ast.AstBase{Location: c.nodeLocation(node)},
+		Keyword:      keyword,
+		IsLazy:       isLazy,
+		Name:         name,
+		NameLocation: c.nodeLocation(nameNode),
+		Type:         varType,
+		TypeLocation: typeLoc,
+		TypeNames:    c.takeTypeNames(),
+		Value:        initExpr,
 	}
 
 	if err := c.table.RegisterVariable(astNode); err != nil {
-		c.errors = append(c.errors, err)
+		c.error(diagnostics.DuplicateDeclaration, astNode.NameLocation, "%s", err)
 	}
 
 	return astNode
 }
+
+// collectDestructuringDeclaration collects let (q, r) = value, declaring each
+// name the pattern binds; `_` binds nothing
+func (c *Collector) collectDestructuringDeclaration(node *sitter.Node) *ast.DestructuringDeclStmt {
+	keyword := c.nodeText(node.ChildByFieldName("keyword"))
+	decl := &ast.DestructuringDeclStmt{
+		AstBase: ast.AstBase{Location: c.nodeLocation(node)},
+		Keyword: keyword,
+		Pattern: c.parsePattern(node.ChildByFieldName("pattern")),
+		Value:   c.collectExpression(node.ChildByFieldName("value")),
+	}
+	var declare func(pattern ast.Pattern)
+	bind := func(name string, loc ast.Location) {
+		if name == "_" {
+			return
+		}
+		binding := &ast.VarDeclStmt{AstBase: ast.AstBase{Location: loc}, Keyword: keyword, Name: name, NameLocation: loc}
+		if err := c.table.RegisterVariable(binding); err != nil {
+			c.error(diagnostics.DuplicateDeclaration, loc, "%s", err)
+			return
+		}
+		decl.Bindings = append(decl.Bindings, binding)
+	}
+	declare = func(pattern ast.Pattern) {
+		switch p := pattern.(type) {
+		case *ast.IdentifierPattern:
+			bind(p.Name, p.Location)
+		case *ast.AsPattern:
+			bind(p.Name, p.NameLocation)
+			declare(p.Pattern)
+		case *ast.TuplePattern:
+			for _, element := range p.Elements {
+				declare(element)
+			}
+		case *ast.ConstructorPattern:
+			for _, argument := range p.Arguments {
+				declare(argument)
+			}
+		case *ast.ArrayPattern:
+			for _, element := range p.Elements {
+				declare(element)
+			}
+			if p.Rest != nil {
+				declare(p.Rest)
+			}
+		case *ast.StructPattern:
+			for _, field := range p.Fields {
+				if field.Pattern == nil {
+					bind(field.Name, field.NameLocation)
+					continue
+				}
+				declare(field.Pattern)
+			}
+		}
+	}
+	if decl.Pattern == nil {
+		c.error(diagnostics.MalformedSyntax, decl.Location, "destructuring declaration is missing a pattern")
+		return decl
+	}
+	declare(decl.Pattern)
+	return decl
+}
+
+func (c *Collector) collectVarAssignment(node *sitter.Node) *ast.VarAssignStmt {
+	nameNode := node.ChildByFieldName("name")
+	if nameNode == nil {
+		c.error(diagnostics.MalformedSyntax, c.nodeLocation(node), "var reassignment is missing a name")
+		return nil
+	}
+	return &ast.VarAssignStmt{
+		AstBase:      ast.AstBase{Location: c.nodeLocation(node)},
+		Name:         c.nodeText(nameNode),
+		NameLocation: c.nodeLocation(nameNode),
+		Value:        c.collectExpression(node.ChildByFieldName("value")),
+	}
+}
@@ -0,0 +1,326 @@
+package ownership
+
+/*
+Ownership tracks the values a scope owns: the own parameters of a clause (and the
+names their patterns bind) and the top-level let/var bindings of the module. Passing
+an owned name to an own parameter moves it; any later use is reported as a MoveError
+with both locations.
+
+For clauses it also computes where each owned value is used for the last time, so an
+interpreter or code generator can drop it there deterministically instead of waiting
+for the clause to return. Module bindings live as long as the program and are never
+dropped early. Ownership runs after the checker, whose expression types give the
+parameter modifiers of callees.
+*/
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// MoveError reports the use of a value after it was moved
+type MoveError struct {
+	Name  string
+	Moved ast.Location // where the value was passed to an own parameter
+	Used  ast.Location // the later use
+}
+
+func (e MoveError) Error() string {
+	return fmt.Sprintf("%d:%d: use of moved value %s (moved at %d:%d) [%s]",
+		e.Used.StartLine, e.Used.StartCol, e.Name, e.Moved.StartLine, e.Moved.StartCol, diagnostics.UseAfterMove)
+}
+
+// Analysis holds the drop points of the owned values of every clause
+type Analysis struct {
+	// DropAfter lists the owned values last used by an operand of an expression;
+	// they are dropped once the expression has been evaluated. A use that moves or
+	// returns the value is not a drop.
+	DropAfter map[ast.Expression][]string
+	// DropOnEntry lists the owned values a clause or if branch never uses; they are
+	// dropped before it is evaluated
+	DropOnEntry map[any][]string // *ast.FunctionClause or a branch ast.Expression
+	Errors      []MoveError
+}
+
+// Analyze computes the moves and drop points of a checked program
+func Analyze(program *ast.Program) *Analysis {
+	a := &Analysis{
+		DropAfter:   make(map[ast.Expression][]string),
+		DropOnEntry: make(map[any][]string),
+		Errors:      make([]MoveError, 0),
+	}
+
+	module := &scope{owned: make(map[string]bool), moved: make(map[string]ast.Location), analysis: a}
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			module.forward(s.Value)
+			if s.Keyword != "const" {
+				module.owned[s.Name] = true
+			}
+		case *ast.VarAssignStmt:
+			module.forward(s.Value)
+			delete(module.moved, s.Name) // reassigning gives the name a new value
+		case *ast.ExpressionStmt:
+			module.forward(s.Expression)
+		case *ast.FunctionDefStmt:
+			for _, clause := range s.Clauses {
+				a.analyzeClause(s, clause)
+			}
+		}
+	}
+	return a
+}
+
+// scope is the owned names of a clause or the module and what has been moved so far
+type scope struct {
+	owned    map[string]bool
+	moved    map[string]ast.Location
+	moves    map[*ast.IdentifierExpr]bool // identifiers passed to own parameters
+	analysis *Analysis
+}
+
+func (a *Analysis) analyzeClause(fn *ast.FunctionDefStmt, clause *ast.FunctionClause) {
+	s := &scope{
+		owned:    make(map[string]bool),
+		moved:    make(map[string]ast.Location),
+		moves:    make(map[*ast.IdentifierExpr]bool),
+		analysis: a,
+	}
+	for i, param := range clause.Parameters {
+		if fn.Signature != nil && i < len(fn.Signature.ParameterTypes) && fn.Signature.ParameterTypes[i].Modifier == types.Own {
+			bindNames(param, s.owned)
+		}
+	}
+	if len(s.owned) == 0 {
+		return
+	}
+
+	if clause.Guard != nil {
+		s.forward(clause.Guard.Condition)
+	}
+	s.forward(clause.Body)
+
+	live := make(map[string]bool)
+	s.backward(clause.Body, nil, live)
+	if clause.Guard != nil {
+		s.backward(clause.Guard.Condition, clause.Guard, live)
+	}
+	s.dropOnEntry(clause, live, s.owned)
+}
+
+// bindNames adds the names a pattern binds to names
+func bindNames(pattern ast.Pattern, names map[string]bool) {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		names[p.Name] = true
+	case *ast.AsPattern:
+		names[p.Name] = true
+		bindNames(p.Pattern, names)
+	case *ast.StructPattern:
+		for _, field := range p.Fields {
+			if field.Pattern == nil {
+				names[field.Name] = true
+			} else {
+				bindNames(field.Pattern, names)
+			}
+		}
+	case *ast.TuplePattern:
+		for _, element := range p.Elements {
+			bindNames(element, names)
+		}
+	case *ast.ConstructorPattern:
+		for _, argument := range p.Arguments {
+			bindNames(argument, names)
+		}
+	case *ast.ArrayPattern:
+		for _, element := range p.Elements {
+			bindNames(element, names)
+		}
+		if p.Rest != nil {
+			bindNames(p.Rest, names)
+		}
+	}
+}
+
+// forward walks expr in evaluation order, recording moves and reporting uses of
+// moved values. A value moved in either branch of an if is moved after it.
+func (s *scope) forward(expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		if moved, ok := s.moved[e.Name]; ok && s.owned[e.Name] {
+			s.analysis.Errors = append(s.analysis.Errors, MoveError{Name: e.Name, Moved: moved, Used: e.Location})
+		}
+	case *ast.CallExpr:
+		s.forward(e.Callee)
+		modifiers := parameterModifiers(e.Callee.GetType())
+		for i, argument := range e.Arguments {
+			s.forward(argument)
+			ident, ok := argument.(*ast.IdentifierExpr)
+			if ok && s.owned[ident.Name] && i < len(modifiers) && modifiers[i] == types.Own {
+				if _, already := s.moved[ident.Name]; !already {
+					s.moved[ident.Name] = ident.Location
+				}
+				if s.moves != nil {
+					s.moves[ident] = true
+				}
+			}
+		}
+	case *ast.BinaryOpExpr:
+		s.forward(e.Left)
+		s.forward(e.Right)
+	case *ast.BooleanBinaryOpExpr:
+		s.forward(e.Left)
+		s.forward(e.Right)
+	case *ast.GuardExpr:
+		s.forward(e.Condition)
+	case *ast.IfThenExpr:
+		s.forwardIf(e.Condition, e.Then, e.Else)
+	case *ast.IfBlockExpr:
+		s.forwardIf(e.Condition, e.Then, e.Else)
+	case *ast.MemberAccessExpr:
+		s.forward(e.Object)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			s.forward(field.Value)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			s.forward(element)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			s.forward(element)
+		}
+	}
+}
+
+func (s *scope) forwardIf(condition, then, otherwise ast.Expression) {
+	s.forward(condition)
+	before := s.moved
+	merged := make(map[string]ast.Location, len(before))
+	for _, branch := range []ast.Expression{then, otherwise} {
+		s.moved = make(map[string]ast.Location, len(before))
+		for name, loc := range before {
+			s.moved[name] = loc
+		}
+		s.forward(branch)
+		for name, loc := range s.moved {
+			if _, ok := merged[name]; !ok {
+				merged[name] = loc
+			}
+		}
+	}
+	s.moved = merged
+}
+
+// backward walks expr against evaluation order with the owned names used after it
+// (live); the first use it meets of a name that is not live is that name's last use.
+// parent is the expression consuming the value of expr, nil when it is returned.
+func (s *scope) backward(expr, parent ast.Expression, live map[string]bool) {
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		if s.owned[e.Name] && !live[e.Name] {
+			live[e.Name] = true
+			if !s.moves[e] && parent != nil {
+				s.analysis.DropAfter[parent] = append(s.analysis.DropAfter[parent], e.Name)
+			}
+		}
+	case *ast.CallExpr:
+		for i := len(e.Arguments) - 1; i >= 0; i-- {
+			s.backward(e.Arguments[i], e, live)
+		}
+		s.backward(e.Callee, e, live)
+	case *ast.BinaryOpExpr:
+		s.backward(e.Right, e, live)
+		s.backward(e.Left, e, live)
+	case *ast.BooleanBinaryOpExpr:
+		s.backward(e.Right, e, live)
+		s.backward(e.Left, e, live)
+	case *ast.GuardExpr:
+		s.backward(e.Condition, e, live)
+	case *ast.IfThenExpr:
+		s.backwardIf(e, e.Condition, e.Then, e.Else, parent, live)
+	case *ast.IfBlockExpr:
+		s.backwardIf(e, e.Condition, e.Then, e.Else, parent, live)
+	case *ast.MemberAccessExpr:
+		s.backward(e.Object, e, live)
+	case *ast.StructLiteralExpr:
+		// a value stored in a field lives on in the struct
+		for i := len(e.Fields) - 1; i >= 0; i-- {
+			s.backward(e.Fields[i].Value, nil, live)
+		}
+	case *ast.ArrayLiteralExpr:
+		// and one stored in an element in the array
+		for i := len(e.Elements) - 1; i >= 0; i-- {
+			s.backward(e.Elements[i], nil, live)
+		}
+	case *ast.TupleLiteralExpr:
+		for i := len(e.Elements) - 1; i >= 0; i-- {
+			s.backward(e.Elements[i], nil, live)
+		}
+	}
+}
+
+// backwardIf joins the branches: a name used in only one of them is dropped on
+// entry to the other
+func (s *scope) backwardIf(expr, condition, then, otherwise, parent ast.Expression, live map[string]bool) {
+	branches := []ast.Expression{then, otherwise}
+	lives := make([]map[string]bool, len(branches))
+	joined := make(map[string]bool, len(live))
+	for i, branch := range branches {
+		lives[i] = make(map[string]bool, len(live))
+		for name := range live {
+			lives[i][name] = true
+		}
+		s.backward(branch, parent, lives[i])
+		for name := range lives[i] {
+			joined[name] = true
+		}
+	}
+	for i, branch := range branches {
+		if branch != nil {
+			s.dropOnEntry(branch, lives[i], joined)
+		}
+	}
+	for name := range joined {
+		live[name] = true
+	}
+	s.backward(condition, expr, live)
+}
+
+// dropOnEntry records the names of candidates that are not live on entry to node
+func (s *scope) dropOnEntry(node any, live, candidates map[string]bool) {
+	var names []string
+	for name := range candidates {
+		if s.owned[name] && !live[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	s.analysis.DropOnEntry[node] = names
+}
+
+func parameterModifiers(t types.Type) []types.Modifier {
+	var fn types.FunctionType
+	switch ft := t.(type) {
+	case *types.FunctionType:
+		fn = *ft
+	case types.FunctionType:
+		fn = ft
+	default:
+		return nil
+	}
+	modifiers := make([]types.Modifier, len(fn.ParameterTypes))
+	for i, parameter := range fn.ParameterTypes {
+		modifiers[i] = parameter.Modifier
+	}
+	return modifiers
+}
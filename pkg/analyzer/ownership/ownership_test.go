@@ -0,0 +1,105 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var fileType = types.UnresolvedType{Name: "File"}
+
+func at(line, col int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + 1}
+}
+
+func use(name string, line, col int) *ast.IdentifierExpr {
+	return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(line, col)}}, Name: name}
+}
+
+// call calls a function taking a single File with the given modifier, as typed by the checker
+func call(name string, modifier types.Modifier, argument ast.Expression) *ast.CallExpr {
+	callee := &ast.IdentifierExpr{Name: name}
+	callee.SetType(&types.FunctionType{ParameterTypes: []types.ParameterType{{Modifier: modifier, Type: fileType}}, ReturnType: types.PrimitiveType{Name: types.Int}})
+	return &ast.CallExpr{Callee: callee, Arguments: []ast.Expression{argument}}
+}
+
+func ownFunction(clause *ast.FunctionClause) *ast.FunctionDefStmt {
+	return &ast.FunctionDefStmt{
+		Name:      "finish",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Modifier: types.Own, Type: fileType}}},
+		Clauses:   []*ast.FunctionClause{clause},
+	}
+}
+
+func TestAnalyze_UseAfterMove(t *testing.T) {
+	// (f) => close(f) + size(f)
+	moved, used := use("f", 1, 22), use("f", 1, 33)
+	body := &ast.BinaryOpExpr{Left: call("close", types.Own, moved), Operator: "+", Right: call("size", types.Ref, used)}
+	analysis := Analyze(&ast.Program{Statements: []ast.AstNode{
+		ownFunction(&ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "f"}}, Body: body}),
+	}})
+
+	if len(analysis.Errors) != 1 {
+		t.Fatalf("Expected a single use after move. Got %v", analysis.Errors)
+	}
+	if err := analysis.Errors[0]; err.Moved != moved.Location || err.Used != used.Location {
+		t.Fatalf("Expected the move at 1:22 and the use at 1:33. Got %v", err)
+	}
+	if err := analysis.Errors[0].Error(); err != "1:33: use of moved value f (moved at 1:22) [LYR0018]" {
+		t.Fatalf("Unexpected message %q", err)
+	}
+}
+
+func TestAnalyze_DropPoints(t *testing.T) {
+	// (f, g) => if ready(f) then size(f) else 0      g is never used
+	ready, size := call("ready", types.Ref, use("f", 1, 20)), call("size", types.Ref, use("f", 1, 35))
+	otherwise := &ast.IntegerLiteralExpr{Value: 0}
+	clause := &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "f"}, &ast.IdentifierPattern{Name: "g"}},
+		Body:       &ast.IfThenExpr{Condition: ready, Then: size, Else: otherwise},
+	}
+	fn := ownFunction(clause)
+	fn.Signature.ParameterTypes = append(fn.Signature.ParameterTypes, types.ParameterType{Modifier: types.Own, Type: fileType})
+	analysis := Analyze(&ast.Program{Statements: []ast.AstNode{fn}})
+
+	if len(analysis.Errors) != 0 {
+		t.Fatalf("Unexpected errors %v", analysis.Errors)
+	}
+	if drops := analysis.DropAfter[size]; len(drops) != 1 || drops[0] != "f" {
+		t.Fatalf("Expected f to be dropped after size(f) in the then branch. Got %v", analysis.DropAfter)
+	}
+	if _, ok := analysis.DropAfter[ready]; ok {
+		t.Fatalf("Expected f to stay alive after the condition")
+	}
+	if drops := analysis.DropOnEntry[otherwise]; len(drops) != 1 || drops[0] != "f" {
+		t.Fatalf("Expected f to be dropped on entry to the else branch. Got %v", analysis.DropOnEntry)
+	}
+	if drops := analysis.DropOnEntry[clause]; len(drops) != 1 || drops[0] != "g" {
+		t.Fatalf("Expected the unused g to be dropped on entry. Got %v", analysis.DropOnEntry)
+	}
+}
+
+func TestAnalyze_ModuleMoveAndReassign(t *testing.T) {
+	file := &ast.VarDeclStmt{Keyword: "var", Name: "log", Type: fileType, Value: &ast.IntegerLiteralExpr{Value: 0}}
+	analysis := Analyze(&ast.Program{Statements: []ast.AstNode{
+		file,
+		&ast.ExpressionStmt{Expression: call("close", types.Own, use("log", 2, 7))},
+		&ast.VarAssignStmt{Name: "log", Value: &ast.IntegerLiteralExpr{Value: 1}},
+		&ast.ExpressionStmt{Expression: call("close", types.Own, use("log", 4, 7))},
+		&ast.ExpressionStmt{Expression: call("size", types.Ref, use("log", 5, 6))},
+	}})
+	if len(analysis.Errors) != 1 || analysis.Errors[0].Moved.StartLine != 4 || analysis.Errors[0].Used.StartLine != 5 {
+		t.Fatalf("Expected only the use on line 5 after the move on line 4. Got %v", analysis.Errors)
+	}
+}
+
+func TestAnalyze_ReturnedValueIsNotDropped(t *testing.T) {
+	// (f) => f
+	analysis := Analyze(&ast.Program{Statements: []ast.AstNode{
+		ownFunction(&ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "f"}}, Body: use("f", 1, 10)}),
+	}})
+	if len(analysis.DropAfter) != 0 || len(analysis.DropOnEntry) != 0 {
+		t.Fatalf("Expected the returned f to be moved to the caller. Got %v and %v", analysis.DropAfter, analysis.DropOnEntry)
+	}
+}
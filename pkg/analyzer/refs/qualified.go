@@ -0,0 +1,80 @@
+package refs
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// QualifiedName names a declaration, or a member of one, from outside its module:
+// geometry.shapes:Point.x is the field x of the struct Point declared in the module
+// geometry.shapes, and sum the function sum of the module being analyzed. The
+// String form is stable, so tools may store and compare it, and
+// ParseQualifiedName reads it back.
+type QualifiedName struct {
+	Module      []string // ["geometry", "shapes"]; empty for the module being analyzed
+	Declaration string
+	Member      string // field or constructor, "" for the declaration itself
+}
+
+// Qualify returns the qualified name of target, declared in module. Locals have
+// none, since they cannot be named outside the function binding them.
+func Qualify(module []string, target Target) (QualifiedName, bool) {
+	switch target.Kind {
+	case TargetFunction, TargetVariable, TargetType:
+		return QualifiedName{Module: module, Declaration: target.Name}, true
+	case TargetField, TargetConstructor:
+		return QualifiedName{Module: module, Declaration: target.Container, Member: target.Name}, true
+	}
+	return QualifiedName{}, false
+}
+
+// ModulePath returns the dotted path of the module, "geometry.shapes"
+func (q QualifiedName) ModulePath() string {
+	return strings.Join(q.Module, ".")
+}
+
+// Local returns the name within its module, Point.x for geometry.shapes:Point.x
+func (q QualifiedName) Local() string {
+	if q.Member == "" {
+		return q.Declaration
+	}
+	return q.Declaration + "." + q.Member
+}
+
+func (q QualifiedName) String() string {
+	if len(q.Module) == 0 {
+		return q.Local()
+	}
+	return q.ModulePath() + ":" + q.Local()
+}
+
+// ParseQualifiedName parses the String form of a QualifiedName
+func ParseQualifiedName(s string) (QualifiedName, error) {
+	var q QualifiedName
+	local := s
+	if module, rest, found := strings.Cut(s, ":"); found {
+		q.Module = strings.Split(module, ".")
+		local = rest
+	}
+	q.Declaration, q.Member, _ = strings.Cut(local, ".")
+	parts := append(append([]string{}, q.Module...), q.Declaration)
+	if strings.Contains(local, ".") {
+		parts = append(parts, q.Member)
+	}
+	for _, part := range parts {
+		if !isIdentifier(part) {
+			return QualifiedName{}, fmt.Errorf("invalid qualified name %q (expected module.path:Declaration.member)", s)
+		}
+	}
+	return q, nil
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,619 @@
+package refs
+
+/*
+The reference index records every place a symbol is mentioned in a collected program.
+It is built from the AST after collection and is what rename, find-references and
+similar editor features query instead of re-walking the tree.
+*/
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+type TargetKind int
+
+const (
+	TargetField TargetKind = iota
+	TargetFunction
+	TargetVariable // top-level let/var/const
+	TargetLocal    // clause parameters and pattern bindings
+	TargetType
+	TargetConstructor
+)
+
+// Target identifies the symbol a reference points at
+type Target struct {
+	Kind      TargetKind
+	Container string // owning declaration, e.g. the struct of a field or the function of a local
+	Name      string
+	Binding   ast.Location // binding site of a local; locals are only unique by where they're bound
+}
+
+// FieldTarget is the target of a struct field
+func FieldTarget(structName, fieldName string) Target {
+	return Target{Kind: TargetField, Container: structName, Name: fieldName}
+}
+
+// FunctionTarget is the target of a top-level function
+func FunctionTarget(name string) Target {
+	return Target{Kind: TargetFunction, Name: name}
+}
+
+// VariableTarget is the target of a top-level variable
+func VariableTarget(name string) Target {
+	return Target{Kind: TargetVariable, Name: name}
+}
+
+// TypeTarget is the target of a struct or data type
+func TypeTarget(name string) Target {
+	return Target{Kind: TargetType, Name: name}
+}
+
+// ConstructorTarget is the target of a data constructor
+func ConstructorTarget(dataType, name string) Target {
+	return Target{Kind: TargetConstructor, Container: dataType, Name: name}
+}
+
+// Kind classifies how a reference uses its target
+type Kind int
+
+const (
+	Definition Kind = iota
+	Read
+	Write
+	Call
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Definition:
+		return "definition"
+	case Read:
+		return "read"
+	case Write:
+		return "write"
+	case Call:
+		return "call"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// ParseKind parses the String form of a Kind
+func ParseKind(s string) (Kind, error) {
+	for _, kind := range []Kind{Definition, Read, Write, Call} {
+		if kind.String() == s {
+			return kind, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown reference kind %q (expected definition, read, write or call)", s)
+}
+
+// Reference is a single mention of a target
+type Reference struct {
+	Target    Target
+	Kind      Kind
+	Location  ast.Location // location of the referencing name only
+	Enclosing string       // top-level function or Type.method the reference appears in, "" at module level
+	Shorthand bool         // `{ x }` field shorthand, where the name doubles as the value/binding
+}
+
+// Index maps targets to their references, in the order they were found
+type Index struct {
+	refs map[Target][]Reference
+	all  []Reference
+}
+
+// References returns the references to target. With no kinds given every
+// reference is returned, including the definition; otherwise only those kinds.
+func (ix *Index) References(target Target, kinds ...Kind) []Reference {
+	return Filter(ix.refs[target], kinds...)
+}
+
+// All returns every indexed reference in the order they were found
+func (ix *Index) All() []Reference {
+	return ix.all
+}
+
+// ReferenceAt returns the reference whose name covers the given 1-based line and column
+func (ix *Index) ReferenceAt(line, col int) (Reference, bool) {
+	for _, ref := range ix.all {
+		if contains(ref.Location, line, col) {
+			return ref, true
+		}
+	}
+	return Reference{}, false
+}
+
+// Filter keeps the references of the given kinds; no kinds keeps everything
+func Filter(references []Reference, kinds ...Kind) []Reference {
+	if len(kinds) == 0 {
+		return references
+	}
+	filtered := make([]Reference, 0, len(references))
+	for _, ref := range references {
+		for _, kind := range kinds {
+			if ref.Kind == kind {
+				filtered = append(filtered, ref)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func contains(loc ast.Location, line, col int) bool {
+	if line < loc.StartLine || line > loc.EndLine {
+		return false
+	}
+	if line == loc.StartLine && col < loc.StartCol {
+		return false
+	}
+	if line == loc.EndLine && col > loc.EndCol {
+		return false
+	}
+	return true
+}
+
+func (ix *Index) add(ref Reference) {
+	ix.refs[ref.Target] = append(ix.refs[ref.Target], ref)
+	ix.all = append(ix.all, ref)
+}
+
+// Build walks the program and indexes every reference it can attribute
+func Build(program *ast.Program, table *symbols.SymbolTable) *Index {
+	b := &builder{
+		index: &Index{refs: make(map[Target][]Reference)},
+		table: table,
+		env:   make(map[string]binding),
+	}
+	for _, stmt := range program.Statements {
+		b.visitStatement(stmt)
+	}
+	return b.index
+}
+
+// binding is what the builder knows about a name in the current scope
+type binding struct {
+	target Target
+	typ    types.Type
+}
+
+type builder struct {
+	index    *Index
+	table    *symbols.SymbolTable
+	function string             // name of the function being walked, if any
+	env      map[string]binding // names bound in the current scope
+}
+
+func (b *builder) add(ref Reference) {
+	ref.Enclosing = b.function
+	b.index.add(ref)
+}
+
+func (b *builder) visitStatement(stmt ast.AstNode) {
+	switch s := stmt.(type) {
+	case *ast.TypeDeclStmt:
+		b.visitTypeDecl(s)
+	case *ast.VarDeclStmt:
+		b.visitTypeNames(s.TypeNames)
+		b.visitExpression(s.Value)
+		target := VariableTarget(s.Name)
+		b.add(Reference{Target: target, Kind: Definition, Location: s.NameLocation})
+		b.env[s.Name] = binding{target: target, typ: s.Type}
+	case *ast.DestructuringDeclStmt:
+		b.visitExpression(s.Value)
+		for _, decl := range s.Bindings {
+			target := VariableTarget(decl.Name)
+			b.add(Reference{Target: target, Kind: Definition, Location: decl.NameLocation})
+			b.env[decl.Name] = binding{target: target, typ: decl.Type}
+		}
+	case *ast.VarAssignStmt:
+		b.visitExpression(s.Value)
+		if bound, ok := b.lookup(s.Name); ok {
+			b.add(Reference{Target: bound.target, Kind: Write, Location: s.NameLocation})
+		}
+	case *ast.FunctionDefStmt:
+		b.visitFunctionDef(s)
+	case *ast.ImplStmt:
+		b.visitImpl(s)
+	case *ast.ExpressionStmt:
+		b.visitExpression(s.Expression)
+	case *ast.ReturnStmt:
+		b.visitExpression(s.Value)
+	}
+}
+
+func (b *builder) visitTypeDecl(decl *ast.TypeDeclStmt) {
+	if decl.NameLocation != (ast.Location{}) {
+		b.add(Reference{Target: TypeTarget(decl.Name), Kind: Definition, Location: decl.NameLocation})
+	}
+	b.visitTypeNames(decl.TypeNames)
+	if _, ok := decl.Type.(types.DataType); ok {
+		var ctors []*ast.DataConstructorDecl
+		for _, candidates := range b.table.Constructors {
+			for _, ctor := range candidates {
+				if ctor.DataType == decl.Name {
+					ctors = append(ctors, ctor)
+				}
+			}
+		}
+		// declaration order
+		sort.Slice(ctors, func(i, j int) bool {
+			a, b := ctors[i].Location, ctors[j].Location
+			if a.StartLine != b.StartLine || a.StartCol != b.StartCol {
+				return a.StartLine < b.StartLine || a.StartLine == b.StartLine && a.StartCol < b.StartCol
+			}
+			return ctors[i].Name < ctors[j].Name
+		})
+		for _, ctor := range ctors {
+			loc := ctor.NameLocation
+			if loc == (ast.Location{}) {
+				loc = ctor.Location
+			}
+			b.add(Reference{Target: ConstructorTarget(decl.Name, ctor.Name), Kind: Definition, Location: loc})
+		}
+	}
+	structType, ok := decl.Type.(types.StructType)
+	if !ok {
+		return
+	}
+	fields := decl.FieldOrder()
+	for _, name := range fields {
+		if loc, ok := decl.FieldLocations[name]; ok {
+			b.add(Reference{Target: FieldTarget(decl.Name, name), Kind: Definition, Location: loc})
+		}
+	}
+	// default values may refer to sibling fields by name
+	for _, name := range fields {
+		expr, ok := structType.Fields[name].DefaultValue.(ast.Expression)
+		if !ok {
+			continue
+		}
+		b.visitDefaultValue(decl.Name, structType, expr)
+	}
+}
+
+// visitDefaultValue walks the default value of a field with the fields of its
+// struct in scope, hiding the globals they are named after
+func (b *builder) visitDefaultValue(structName string, structType types.StructType, expr ast.Expression) {
+	outer := b.env
+	b.env = make(map[string]binding, len(outer)+len(structType.Fields))
+	for name, bound := range outer {
+		b.env[name] = bound
+	}
+	for name, field := range structType.Fields {
+		b.env[name] = binding{target: FieldTarget(structName, name), typ: field.Type}
+	}
+	b.visitExpression(expr)
+	b.env = outer
+}
+
+func (b *builder) visitFunctionDef(fn *ast.FunctionDefStmt) {
+	b.add(Reference{Target: FunctionTarget(fn.Name), Kind: Definition, Location: fn.NameLocation})
+
+	b.function = fn.Name
+	defer func() { b.function = "" }()
+	b.visitTypeNames(fn.TypeNames)
+	b.visitClauses(fn, nil)
+}
+
+// visitImpl indexes the type an impl is for and walks its methods, each with its
+// receiver, the first parameter, bound to a value of that type. The references
+// in a method are enclosed by Type.method.
+func (b *builder) visitImpl(impl *ast.ImplStmt) {
+	if _, declared := b.table.Types[impl.TypeName]; declared && impl.TypeLocation != (ast.Location{}) {
+		b.add(Reference{Target: TypeTarget(impl.TypeName), Kind: Read, Location: impl.TypeLocation})
+	}
+	defer func() { b.function = "" }()
+	for _, method := range impl.Methods {
+		b.function = impl.TypeName + "." + method.Name
+		b.visitTypeNames(method.TypeNames)
+		b.visitClauses(method, types.UnresolvedType{Name: impl.TypeName})
+	}
+}
+
+// visitClauses walks the clauses of fn in the current scope. A nested function is
+// a local of the clause defining it, bound at its name, and its references are
+// enclosed by the top-level function. receiver is the type of the first
+// parameter of a method, nil for other functions.
+func (b *builder) visitClauses(fn *ast.FunctionDefStmt, receiver types.Type) {
+	for _, clause := range fn.Clauses {
+		outer := b.env
+		b.env = make(map[string]binding, len(outer))
+		for name, bound := range outer {
+			b.env[name] = bound
+		}
+
+		for i, param := range clause.Parameters {
+			var paramType types.Type
+			if fn.Signature != nil && i < len(fn.Signature.ParameterTypes) {
+				paramType = fn.Signature.ParameterTypes[i].Type
+			}
+			if i == 0 && receiver != nil {
+				paramType = receiver
+			}
+			b.visitPattern(param, paramType)
+		}
+		if clause.Guard != nil {
+			b.visitExpression(clause.Guard.Condition)
+		}
+		for _, nested := range clause.Functions {
+			var signature types.Type
+			if nested.Signature != nil {
+				signature = nested.Signature
+			}
+			b.bindLocal(nested.Name, nested.NameLocation, signature, false)
+		}
+		for _, nested := range clause.Functions {
+			b.visitTypeNames(nested.TypeNames)
+			b.visitClauses(nested, nil)
+		}
+		b.visitExpression(clause.Body)
+
+		b.env = outer
+	}
+}
+
+func (b *builder) bindLocal(name string, loc ast.Location, t types.Type, shorthand bool) {
+	target := Target{Kind: TargetLocal, Container: b.function, Name: name, Binding: loc}
+	b.add(Reference{Target: target, Kind: Definition, Location: loc, Shorthand: shorthand})
+	b.env[name] = binding{target: target, typ: t}
+}
+
+func (b *builder) visitPattern(pattern ast.Pattern, t types.Type) {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		b.bindLocal(p.Name, p.Location, t, false)
+	case *ast.AsPattern:
+		b.bindLocal(p.Name, p.NameLocation, t, false)
+		b.visitPattern(p.Pattern, t)
+	case *ast.StructPattern:
+		structType, ok := b.structType(types.UnresolvedType{Name: p.TypeName})
+		b.visitTypeName(p.TypeName, p.Location, t)
+		for _, field := range p.Fields {
+			var fieldType types.Type
+			if ok {
+				fieldType = structType.Fields[field.Name].Type
+				b.add(Reference{
+					Target:    FieldTarget(p.TypeName, field.Name),
+					Kind:      Read,
+					Location:  field.NameLocation,
+					Shorthand: field.Pattern == nil,
+				})
+			}
+			if field.Pattern == nil {
+				b.bindLocal(field.Name, field.NameLocation, fieldType, true)
+				continue
+			}
+			b.visitPattern(field.Pattern, fieldType)
+		}
+	case *ast.TuplePattern:
+		tuple, ok := t.(types.TupleType)
+		for i, element := range p.Elements {
+			var elementType types.Type
+			if ok && i < len(tuple.Elements) {
+				elementType = tuple.Elements[i]
+			}
+			b.visitPattern(element, elementType)
+		}
+	case *ast.ConstructorPattern:
+		b.visitTypeName(p.Name, p.Location, t)
+		var params []types.ParameterType
+		if ctor, err := b.table.ResolveConstructor(p.Name, t); err == nil && ctor.Signature != nil {
+			params = ctor.Signature.ParameterTypes
+		}
+		for i, argument := range p.Arguments {
+			var argumentType types.Type
+			if i < len(params) {
+				argumentType = params[i].Type
+			}
+			b.visitPattern(argument, argumentType)
+		}
+	case *ast.ArrayPattern:
+		array, _ := t.(types.ArrayType)
+		for _, element := range p.Elements {
+			b.visitPattern(element, array.ElementType)
+		}
+		if p.Rest != nil {
+			b.visitPattern(p.Rest, t)
+		}
+	}
+}
+
+func (b *builder) lookup(name string) (binding, bool) {
+	if bound, ok := b.env[name]; ok {
+		return bound, true
+	}
+	if fn, ok := b.table.Functions[name]; ok {
+		return binding{target: FunctionTarget(name), typ: fn.Signature}, true
+	}
+	return binding{}, false
+}
+
+func (b *builder) visitExpression(expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		if bound, ok := b.lookup(e.Name); ok {
+			b.add(Reference{Target: bound.target, Kind: Read, Location: e.Location})
+		} else if target, ok := b.constructor(e.Name, e.GetType()); ok {
+			b.add(Reference{Target: target, Kind: Read, Location: e.Location})
+		}
+	case *ast.CallExpr:
+		switch callee := e.Callee.(type) {
+		case *ast.IdentifierExpr:
+			if bound, ok := b.lookup(callee.Name); ok {
+				b.add(Reference{Target: bound.target, Kind: Call, Location: callee.Location})
+			} else if target, ok := b.constructor(callee.Name, e.GetType()); ok {
+				b.add(Reference{Target: target, Kind: Call, Location: callee.Location})
+			}
+		case *ast.MemberAccessExpr:
+			if !b.qualifiedConstructor(callee, Call) {
+				b.visitExpression(callee)
+			}
+		default:
+			b.visitExpression(e.Callee)
+		}
+		for _, argument := range e.Arguments {
+			b.visitExpression(argument)
+		}
+	case *ast.MemberAccessExpr:
+		if b.qualifiedConstructor(e, Read) {
+			return
+		}
+		b.visitExpression(e.Object)
+		if structType, ok := b.structType(b.typeOf(e.Object)); ok {
+			if _, isField := structType.Fields[e.Member]; isField {
+				b.add(Reference{Target: FieldTarget(structType.Name, e.Member), Kind: Read, Location: e.MemberLocation})
+			}
+		}
+	case *ast.StructLiteralExpr:
+		_, isStruct := b.structType(types.UnresolvedType{Name: e.TypeName})
+		b.visitTypeName(e.TypeName, e.Location, e.GetType())
+		for _, field := range e.Fields {
+			if isStruct {
+				b.add(Reference{
+					Target:    FieldTarget(e.TypeName, field.Name),
+					Kind:      Write,
+					Location:  field.NameLocation,
+					Shorthand: field.Shorthand,
+				})
+			}
+			b.visitExpression(field.Value)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			b.visitExpression(element)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			b.visitExpression(element)
+		}
+	case *ast.LambdaExpr:
+		outer := b.env
+		b.env = make(map[string]binding, len(outer)+len(e.Parameters))
+		for name, bound := range outer {
+			b.env[name] = bound
+		}
+		fn, _ := e.GetType().(*types.FunctionType)
+		for i, param := range e.Parameters {
+			var paramType types.Type
+			if fn != nil && i < len(fn.ParameterTypes) {
+				paramType = fn.ParameterTypes[i].Type
+			}
+			b.visitPattern(param, paramType)
+		}
+		b.visitExpression(e.Body)
+		b.env = outer
+	case *ast.IfThenExpr:
+		b.visitExpression(e.Condition)
+		b.visitExpression(e.Then)
+		b.visitExpression(e.Else)
+	case *ast.IfBlockExpr:
+		b.visitExpression(e.Condition)
+		b.visitExpression(e.Then)
+		b.visitExpression(e.Else)
+	case *ast.BooleanBinaryOpExpr:
+		b.visitExpression(e.Left)
+		b.visitExpression(e.Right)
+	case *ast.BinaryOpExpr:
+		b.visitExpression(e.Left)
+		b.visitExpression(e.Right)
+	case *ast.GuardExpr:
+		b.visitExpression(e.Condition)
+	}
+}
+
+// visitTypeNames indexes the declared types named in annotations, e.g. `: [Point]`
+func (b *builder) visitTypeNames(names []ast.TypeName) {
+	for _, name := range names {
+		if _, declared := b.table.Types[name.Name]; declared {
+			b.add(Reference{Target: TypeTarget(name.Name), Kind: Read, Location: name.Location})
+		}
+	}
+}
+
+// visitTypeName indexes the name a struct literal or pattern starts with, which
+// is a struct type or a record-style data constructor
+func (b *builder) visitTypeName(name string, loc ast.Location, t types.Type) {
+	if loc == (ast.Location{}) {
+		return
+	}
+	nameLoc := ast.Location{StartLine: loc.StartLine, StartCol: loc.StartCol, EndLine: loc.StartLine, EndCol: loc.StartCol + len(name)}
+	if _, ok := b.structType(types.UnresolvedType{Name: name}); ok {
+		b.add(Reference{Target: TypeTarget(name), Kind: Read, Location: nameLoc})
+	} else if target, ok := b.constructor(name, t); ok {
+		b.add(Reference{Target: target, Kind: Read, Location: nameLoc})
+	}
+}
+
+// qualifiedConstructor indexes `Shape.Circle`, a constructor qualified by its data
+// type, reporting false if access is not one
+func (b *builder) qualifiedConstructor(access *ast.MemberAccessExpr, kind Kind) bool {
+	ident, ok := access.Object.(*ast.IdentifierExpr)
+	if !ok {
+		return false
+	}
+	if _, bound := b.lookup(ident.Name); bound {
+		return false
+	}
+	ctor, ok := b.table.LookupQualifiedConstructor(ident.Name, access.Member)
+	if !ok {
+		return false
+	}
+	b.add(Reference{Target: TypeTarget(ident.Name), Kind: Read, Location: ident.Location})
+	b.add(Reference{Target: ConstructorTarget(ctor.DataType, ctor.Name), Kind: kind, Location: access.MemberLocation})
+	return true
+}
+
+// constructor resolves the name of a data constructor, using the expected type
+// to choose between constructors of the same name
+func (b *builder) constructor(name string, expected types.Type) (Target, bool) {
+	ctor, err := b.table.ResolveConstructor(name, expected)
+	if err != nil {
+		return Target{}, false
+	}
+	return ConstructorTarget(ctor.DataType, ctor.Name), true
+}
+
+// typeOf returns the best known type of an expression: the checked type if the
+// checker has run, otherwise the declared type of a bound name
+func (b *builder) typeOf(expr ast.Expression) types.Type {
+	if expr == nil {
+		return nil
+	}
+	if t := expr.GetType(); t != nil {
+		return t
+	}
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		if bound, ok := b.lookup(e.Name); ok {
+			return bound.typ
+		}
+	case *ast.StructLiteralExpr:
+		return types.UnresolvedType{Name: e.TypeName}
+	case *ast.MemberAccessExpr:
+		if structType, ok := b.structType(b.typeOf(e.Object)); ok {
+			return structType.Fields[e.Member].Type
+		}
+	}
+	return nil
+}
+
+// structType resolves t to a declared struct type, following unresolved names
+func (b *builder) structType(t types.Type) (types.StructType, bool) {
+	switch st := t.(type) {
+	case types.StructType:
+		return st, true
+	case types.UnresolvedType:
+		if decl, ok := b.table.Types[st.Name]; ok {
+			structType, ok := decl.Type.(types.StructType)
+			return structType, ok
+		}
+	}
+	return types.StructType{}, false
+}
@@ -0,0 +1,281 @@
+package refs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func at(line, col, length int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+}
+
+func ident(name string, loc ast.Location) *ast.IdentifierExpr {
+	return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}}, Name: name}
+}
+
+// counterProgram builds the AST of:
+//
+//	def inc: (Int) -> Int = (n) => n
+//	var count: Int = 0
+//	count = inc(count)
+func counterProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	table := symbols.NewSymbolTable()
+	inc := &ast.FunctionDefStmt{
+		Name:         "inc",
+		NameLocation: at(1, 5, 3),
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: intType}},
+			ReturnType:     intType,
+		},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: at(1, 26, 1)}, Name: "n"}},
+			Body:       ident("n", at(1, 32, 1)),
+		}},
+	}
+	count := &ast.VarDeclStmt{Keyword: "var", Name: "count", NameLocation: at(2, 5, 5), Type: intType, Value: &ast.IntegerLiteralExpr{Value: 0}}
+	assign := &ast.VarAssignStmt{
+		Name:         "count",
+		NameLocation: at(3, 1, 5),
+		Value: &ast.CallExpr{
+			Callee:    ident("inc", at(3, 9, 3)),
+			Arguments: []ast.Expression{ident("count", at(3, 13, 5))},
+		},
+	}
+	if err := table.RegisterFunction(inc); err != nil {
+		t.Fatalf("RegisterFunction error: %v", err)
+	}
+	return &ast.Program{Statements: []ast.AstNode{inc, count, assign}}, table
+}
+
+func TestIndex_ReferenceKinds(t *testing.T) {
+	program, table := counterProgram(t)
+	index := Build(program, table)
+
+	countRefs := index.References(VariableTarget("count"))
+	if len(countRefs) != 3 {
+		t.Fatalf("Expected 3 references to count, got %d", len(countRefs))
+	}
+
+	writes := index.References(VariableTarget("count"), Write)
+	if len(writes) != 1 || writes[0].Location != at(3, 1, 5) {
+		t.Fatalf("Expected a single write of count at 3:1. Got %v", writes)
+	}
+
+	calls := index.References(FunctionTarget("inc"), Call)
+	if len(calls) != 1 || calls[0].Location != at(3, 9, 3) {
+		t.Fatalf("Expected a single call of inc at 3:9. Got %v", calls)
+	}
+
+	ref, ok := index.ReferenceAt(1, 32)
+	if !ok {
+		t.Fatalf("Expected a reference at 1:32")
+	}
+	if ref.Target.Kind != TargetLocal || ref.Target.Name != "n" || ref.Kind != Read {
+		t.Fatalf("Expected a read of local n at 1:32. Got %+v", ref)
+	}
+	if defs := index.References(ref.Target, Definition); len(defs) != 1 || defs[0].Location != at(1, 26, 1) {
+		t.Fatalf("Expected n to be defined at 1:26. Got %v", defs)
+	}
+}
+
+// shapesProgram builds the AST of:
+//
+//	struct Point { x: Int }
+//	data Shape = Circle(Int) | Dot
+//	let p: Point = Point { x: 1 }
+//	let s: Shape = Circle(1)
+//	def norm: (Point) -> Int = (q) => 0
+func shapesProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	table := symbols.NewSymbolTable()
+	point := &ast.TypeDeclStmt{
+		Name:           "Point",
+		NameLocation:   at(1, 8, 5),
+		Type:           types.StructType{Name: "Point", Fields: map[string]types.StructField{"x": {Name: "x", Type: intType}}},
+		FieldLocations: map[string]ast.Location{"x": at(1, 16, 1)},
+	}
+	shapeType := types.DataType{Name: "Shape", Constructors: map[string]types.DataTypeConstructor{
+		"Circle": {Name: "Circle", Params: []types.Type{intType}},
+		"Dot":    {Name: "Dot"},
+	}}
+	shape := &ast.TypeDeclStmt{Name: "Shape", NameLocation: at(2, 6, 5), Type: shapeType}
+	p := &ast.VarDeclStmt{Keyword: "let", Name: "p", NameLocation: at(3, 5, 1), Type: types.UnresolvedType{Name: "Point"}, TypeLocation: at(3, 8, 5),
+		TypeNames: []ast.TypeName{{Name: "Point", Location: at(3, 8, 5)}},
+		Value: &ast.StructLiteralExpr{
+			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(3, 16, 14)}},
+			TypeName: "Point",
+			Fields:   []*ast.StructLiteralField{{Name: "x", NameLocation: at(3, 24, 1), Value: &ast.IntegerLiteralExpr{Value: 1}}},
+		}}
+	s := &ast.VarDeclStmt{Keyword: "let", Name: "s", NameLocation: at(4, 5, 1), Type: types.UnresolvedType{Name: "Shape"}, TypeLocation: at(4, 8, 5),
+		TypeNames: []ast.TypeName{{Name: "Shape", Location: at(4, 8, 5)}},
+		Value:     &ast.CallExpr{Callee: ident("Circle", at(4, 16, 6)), Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 1}}}}
+	for _, decl := range []*ast.TypeDeclStmt{point, shape} {
+		if err := table.RegisterType(decl); err != nil {
+			t.Fatalf("RegisterType error: %v", err)
+		}
+	}
+	for name, loc := range map[string]ast.Location{"Circle": at(2, 14, 11), "Dot": at(2, 28, 3)} {
+		if err := table.RegisterConstructor(&ast.DataConstructorDecl{AstBase: ast.AstBase{Location: loc}, Name: name, DataType: "Shape"}); err != nil {
+			t.Fatalf("RegisterConstructor error: %v", err)
+		}
+	}
+	norm := &ast.FunctionDefStmt{
+		Name:         "norm",
+		NameLocation: at(5, 5, 4),
+		Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.UnresolvedType{Name: "Point"}}}, ReturnType: intType},
+		TypeNames:    []ast.TypeName{{Name: "Point", Location: at(5, 12, 5)}},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: at(5, 29, 1)}, Name: "q"}},
+			Body:       &ast.IntegerLiteralExpr{Value: 0},
+		}},
+	}
+	return &ast.Program{Statements: []ast.AstNode{point, shape, p, s, norm}}, table
+}
+
+func TestIndex_TypesAndConstructors(t *testing.T) {
+	program, table := shapesProgram(t)
+	index := Build(program, table)
+
+	pointRefs := index.References(TypeTarget("Point"))
+	expected := []ast.Location{at(1, 8, 5), at(3, 8, 5), at(3, 16, 5), at(5, 12, 5)} // declaration, annotation, literal, signature
+	if len(pointRefs) != len(expected) {
+		t.Fatalf("Expected %d references to Point. Got %v", len(expected), pointRefs)
+	}
+	for i, ref := range pointRefs {
+		if ref.Location != expected[i] {
+			t.Fatalf("Expected reference %d to Point at %v. Got %v", i, expected[i], ref.Location)
+		}
+	}
+	if pointRefs[3].Enclosing != "norm" {
+		t.Fatalf("Expected the signature's reference to Point to be enclosed by norm. Got %q", pointRefs[3].Enclosing)
+	}
+
+	ref, ok := index.ReferenceAt(4, 18)
+	if !ok || ref.Target != ConstructorTarget("Shape", "Circle") || ref.Kind != Call {
+		t.Fatalf("Expected a call of Shape.Circle at 4:18. Got %+v", ref)
+	}
+	if defs := index.References(ref.Target, Definition); len(defs) != 1 || defs[0].Location != at(2, 14, 11) {
+		t.Fatalf("Expected Circle to be defined at 2:14. Got %v", defs)
+	}
+	if defs := index.References(ConstructorTarget("Shape", "Dot"), Definition); len(defs) != 1 {
+		t.Fatalf("Expected Dot to be defined once, even though it is never used. Got %v", defs)
+	}
+}
+
+func TestIndex_DeclarationOrder(t *testing.T) {
+	// struct Box { a: Int, b: Int, c: Int, d: Int }
+	// data Step = North | East | South | West
+	fields := map[string]types.StructField{}
+	locations := map[string]ast.Location{}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		fields[name] = types.StructField{Name: name, Type: intType}
+		locations[name] = at(1, 14+8*i, 1)
+	}
+	box := &ast.TypeDeclStmt{Name: "Box", Type: types.StructType{Name: "Box", Fields: fields}, FieldLocations: locations}
+	step := &ast.TypeDeclStmt{Name: "Step", Type: types.DataType{Name: "Step", Constructors: map[string]types.DataTypeConstructor{}}}
+	table := symbols.NewSymbolTable()
+	table.RegisterType(box)
+	table.RegisterType(step)
+	for i, name := range []string{"North", "East", "South", "West"} {
+		table.RegisterConstructor(&ast.DataConstructorDecl{AstBase: ast.AstBase{Location: at(2, 13+8*i, len(name))}, Name: name, DataType: "Step"})
+	}
+
+	expected := []string{"a", "b", "c", "d", "North", "East", "South", "West"}
+	for range 10 {
+		var got []string
+		for _, ref := range Build(&ast.Program{Statements: []ast.AstNode{box, step}}, table).All() {
+			got = append(got, ref.Target.Name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Fatalf("Expected the fields and constructors in declaration order %v. Got %v", expected, got)
+		}
+	}
+}
+
+func TestIndex_ImplMethods(t *testing.T) {
+	// struct Point { x: Int }
+	// let k: Int = 2
+	// impl Scale for Point { def scaled = (p) => p.x * k }
+	point := &ast.TypeDeclStmt{Name: "Point", NameLocation: at(1, 8, 5),
+		Type:           types.StructType{Name: "Point", Fields: map[string]types.StructField{"x": {Name: "x", Type: intType}}},
+		FieldLocations: map[string]ast.Location{"x": at(1, 16, 1)}}
+	k := &ast.VarDeclStmt{Keyword: "let", Name: "k", NameLocation: at(2, 5, 1), Type: intType, Value: &ast.IntegerLiteralExpr{Value: 2}}
+	scaled := &ast.FunctionDefStmt{Name: "scaled", NameLocation: at(3, 28, 6), Clauses: []*ast.FunctionClause{{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: at(3, 38, 1)}, Name: "p"}},
+		Body: &ast.BinaryOpExpr{
+			Left:     &ast.MemberAccessExpr{Object: ident("p", at(3, 44, 1)), Member: "x", MemberLocation: at(3, 46, 1)},
+			Operator: "*",
+			Right:    ident("k", at(3, 50, 1)),
+		},
+	}}}
+	impl := &ast.ImplStmt{Trait: "Scale", TypeName: "Point", TypeLocation: at(3, 16, 5), Methods: []*ast.FunctionDefStmt{scaled}}
+	table := symbols.NewSymbolTable()
+	table.RegisterType(point)
+	table.RegisterVariable(k)
+	index := Build(&ast.Program{Statements: []ast.AstNode{point, k, impl}}, table)
+
+	if reads := index.References(TypeTarget("Point"), Read); len(reads) != 1 || reads[0].Location != at(3, 16, 5) {
+		t.Fatalf("Expected the impl to read Point at 3:16. Got %v", reads)
+	}
+	if reads := index.References(FieldTarget("Point", "x"), Read); len(reads) != 1 || reads[0].Location != at(3, 46, 1) {
+		t.Fatalf("Expected p.x in the method to read Point.x through its receiver. Got %v", reads)
+	}
+	receiver := Target{Kind: TargetLocal, Container: "Point.scaled", Name: "p", Binding: at(3, 38, 1)}
+	if reads := index.References(receiver, Read); len(reads) != 1 || reads[0].Location != at(3, 44, 1) {
+		t.Fatalf("Expected the receiver p to be read at 3:44. Got %v", reads)
+	}
+	if reads := index.References(VariableTarget("k"), Read); len(reads) != 1 || reads[0].Enclosing != "Point.scaled" {
+		t.Fatalf("Expected k to be read in Point.scaled. Got %v", reads)
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for _, kind := range []Kind{Definition, Read, Write, Call} {
+		parsed, err := ParseKind(kind.String())
+		if err != nil || parsed != kind {
+			t.Fatalf("ParseKind(%q) = %v, %v", kind.String(), parsed, err)
+		}
+	}
+	if _, err := ParseKind("mutation"); err == nil {
+		t.Fatalf("Expected an error for an unknown kind")
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	shapes := []string{"geometry", "shapes"}
+	tests := []struct {
+		target Target
+		text   string
+	}{
+		{FunctionTarget("area"), "geometry.shapes:area"},
+		{TypeTarget("Point"), "geometry.shapes:Point"},
+		{FieldTarget("Point", "x"), "geometry.shapes:Point.x"},
+		{ConstructorTarget("Shape", "Circle"), "geometry.shapes:Shape.Circle"},
+	}
+	for _, test := range tests {
+		q, ok := Qualify(shapes, test.target)
+		if !ok || q.String() != test.text {
+			t.Fatalf("Expected %s. Got %s (%v)", test.text, q, ok)
+		}
+		parsed, err := ParseQualifiedName(test.text)
+		if err != nil || parsed.String() != test.text || parsed.ModulePath() != "geometry.shapes" {
+			t.Fatalf("ParseQualifiedName(%q) = %v, %v", test.text, parsed, err)
+		}
+	}
+	if q, _ := Qualify(nil, FieldTarget("Point", "x")); q.String() != "Point.x" {
+		t.Fatalf("Expected Point.x in the root module. Got %s", q)
+	}
+	if _, ok := Qualify(nil, Target{Kind: TargetLocal, Container: "area", Name: "r"}); ok {
+		t.Fatalf("Expected locals to have no qualified name")
+	}
+	for _, text := range []string{"", "geometry.:Point", "Point.x.y", "geometry:", "1st", "a:b:c"} {
+		if _, err := ParseQualifiedName(text); err == nil {
+			t.Fatalf("Expected an error parsing %q", text)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/collector"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// containment is a field through which the struct from holds the struct to by value
+type containment struct {
+	from, field, to string
+}
+
+// checkFinite reports each struct of program that contains itself by value,
+// through its own fields, tuples or other structs, at the field starting the
+// shortest such path. Data types, arrays and functions hold what they refer to
+// indirectly, so recursion through them is fine.
+func (r *resolver) checkFinite(program *ast.Program) {
+	for _, stmt := range program.Statements {
+		decl, ok := stmt.(*ast.TypeDeclStmt)
+		if !ok {
+			continue
+		}
+		if _, ok := decl.Type.(types.StructType); !ok {
+			continue
+		}
+		cycle := r.containmentCycle(decl.Name)
+		if cycle == nil {
+			continue
+		}
+		path := make([]string, len(cycle))
+		for i, edge := range cycle {
+			path[i] = edge.from + "." + edge.field
+		}
+		loc, ok := decl.FieldLocations[cycle[0].field]
+		if !ok {
+			loc = decl.NameLocation
+		}
+		r.errors = append(r.errors, collector.Error{
+			Code:     diagnostics.InfiniteType,
+			Location: loc,
+			Message: fmt.Sprintf("infinite type: %s contains itself by value through %s; hold it in an array or a data type instead",
+				decl.Name, strings.Join(path, ", ")),
+		})
+	}
+}
+
+// containmentCycle returns the shortest path of fields by which the struct start
+// holds itself by value, nil if there is none
+func (r *resolver) containmentCycle(start string) []containment {
+	reached := map[string]containment{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, edge := range r.contained(name) {
+			if edge.to == start {
+				cycle := []containment{edge}
+				for at := name; at != start; at = reached[at].from {
+					cycle = append([]containment{reached[at]}, cycle...)
+				}
+				return cycle
+			}
+			if _, ok := reached[edge.to]; !ok {
+				reached[edge.to] = edge
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return nil
+}
+
+// contained returns the structs the declared struct name holds by value, by
+// field in declaration order
+func (r *resolver) contained(name string) []containment {
+	decl, ok := r.table.Types[name]
+	if !ok {
+		return nil
+	}
+	st, ok := decl.Type.(types.StructType)
+	if !ok {
+		return nil
+	}
+	fields := make([]string, 0, len(st.Fields))
+	for field := range st.Fields {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := decl.FieldLocations[fields[i]], decl.FieldLocations[fields[j]]
+		if a.StartLine != b.StartLine || a.StartCol != b.StartCol {
+			return a.StartLine < b.StartLine || a.StartLine == b.StartLine && a.StartCol < b.StartCol
+		}
+		return fields[i] < fields[j]
+	})
+	var edges []containment
+	for _, field := range fields {
+		for _, to := range r.byValue(st.Fields[field].Type) {
+			edges = append(edges, containment{name, field, to})
+		}
+	}
+	return edges
+}
+
+// byValue returns the names of the structs a value of type t holds in place
+func (r *resolver) byValue(t types.Type) []string {
+	switch t := t.(type) {
+	case types.UnresolvedType:
+		if decl, ok := r.table.Types[t.Name]; ok {
+			if decl.IsAlias && !r.expanding[t.Name] {
+				r.expanding[t.Name] = true
+				defer delete(r.expanding, t.Name)
+				return r.byValue(decl.Type)
+			}
+			if _, ok := decl.Type.(types.StructType); ok {
+				return []string{t.Name}
+			}
+		}
+	case types.StructType:
+		return []string{t.Name}
+	case types.TupleType:
+		var names []string
+		for _, element := range t.Elements {
+			names = append(names, r.byValue(element)...)
+		}
+		return names
+	}
+	return nil
+}
+
+// checkAliases reports each type alias of program that names itself, directly or
+// through other aliases, at its name: it would stand for a type without end
+func (r *resolver) checkAliases(program *ast.Program) {
+	for _, stmt := range program.Statements {
+		decl, ok := stmt.(*ast.TypeDeclStmt)
+		if !ok || !decl.IsAlias {
+			continue
+		}
+		cycle := r.aliasCycle(decl.Name)
+		if cycle == nil {
+			continue
+		}
+		r.errors = append(r.errors, collector.Error{
+			Code:     diagnostics.InfiniteType,
+			Location: decl.NameLocation,
+			Message: fmt.Sprintf("infinite type: alias %s names itself through %s; declare a struct or data type instead",
+				decl.Name, strings.Join(cycle, " -> ")),
+		})
+	}
+}
+
+// aliasCycle returns the shortest path of aliases from start back to it, nil if
+// it does not name itself
+func (r *resolver) aliasCycle(start string) []string {
+	reached := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, to := range r.aliasesNamed(r.table.Types[name].Type) {
+			if to == start {
+				cycle := []string{start}
+				for at := name; at != start; at = reached[at] {
+					cycle = append([]string{at}, cycle...)
+				}
+				return append([]string{start}, cycle...)
+			}
+			if _, ok := reached[to]; !ok {
+				reached[to] = name
+				queue = append(queue, to)
+			}
+		}
+	}
+	return nil
+}
+
+// aliasesNamed returns the aliases t names, in the order it names them
+func (r *resolver) aliasesNamed(t types.Type) []string {
+	switch t := t.(type) {
+	case types.UnresolvedType:
+		if decl, ok := r.table.Types[t.Name]; ok && decl.IsAlias {
+			return []string{t.Name}
+		}
+	case types.ArrayType:
+		return r.aliasesNamed(t.ElementType)
+	case types.TupleType:
+		var names []string
+		for _, element := range t.Elements {
+			names = append(names, r.aliasesNamed(element)...)
+		}
+		return names
+	case types.FunctionType:
+		var names []string
+		for _, parameter := range t.ParameterTypes {
+			names = append(names, r.aliasesNamed(parameter.Type)...)
+		}
+		return append(names, r.aliasesNamed(t.ReturnType)...)
+	case *types.FunctionType:
+		return r.aliasesNamed(*t)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package resolver
+
+/*
+Resolver replaces the named type references the collector leaves behind
+(types.UnresolvedType) with what they name once every declaration is known: the
+struct or data type declared in the symbol table, a generic parameter of the
+enclosing declaration, or a primitive type like Unit that the grammar reads as a
+name; a type alias stands for the type it names. It resolves the annotations of
+bindings and function signatures; references inside type declarations stay by
+name, as types may be recursive or mutually recursive, and are only checked to
+exist. A name that resolves to
+nothing is reported as an unknown type at each place it is written, and a struct
+containing itself by value, which would need infinite room, as an infinite type,
+as is an alias naming itself.
+*/
+
+import (
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/collector"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// Resolve resolves the type references of program against table in place and
+// returns an error for each name that is not a type
+func Resolve(program *ast.Program, table *symbols.SymbolTable) []error {
+	r := &resolver{table: table, expanding: make(map[string]bool)}
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			r.begin(nil)
+			s.Type = r.resolve(s.Type)
+			r.report(s.TypeNames)
+		case *ast.FunctionDefStmt:
+			r.resolveFunctionDef(s, nil)
+		case *ast.ImplStmt:
+			for _, method := range s.Methods {
+				r.resolveFunctionDef(method, nil)
+			}
+		case *ast.TypeDeclStmt:
+			r.begin(s.GenericParams)
+			r.check(s.Type)
+			r.report(s.TypeNames)
+		}
+	}
+	r.checkAliases(program)
+	r.checkFinite(program)
+	return r.errors
+}
+
+type resolver struct {
+	table    *symbols.SymbolTable
+	generics map[string]bool // generic parameters of the declaration being resolved
+	unknown  map[string]bool // names it uses that are not types
+	errors   []error
+
+	expanding map[string]bool // aliases whose types are being resolved or followed
+}
+
+// resolveFunctionDef resolves the signature of fn and of the functions nested in
+// it, which also see the generic parameters of the functions enclosing them
+func (r *resolver) resolveFunctionDef(fn *ast.FunctionDefStmt, enclosing []string) {
+	generics := append(append([]string(nil), enclosing...), fn.GenericParams...)
+	r.begin(generics)
+	if fn.Signature != nil {
+		*fn.Signature = r.resolveFunction(*fn.Signature)
+	}
+	r.report(fn.TypeNames)
+	for _, clause := range fn.Clauses {
+		for _, nested := range clause.Functions {
+			r.resolveFunctionDef(nested, generics)
+		}
+	}
+}
+
+// begin starts resolving a declaration with generic parameters
+func (r *resolver) begin(generics []string) {
+	r.generics = make(map[string]bool, len(generics))
+	for _, name := range generics {
+		r.generics[name] = true
+	}
+	r.unknown = make(map[string]bool)
+}
+
+// resolve returns t with the named references in it replaced by what they name
+func (r *resolver) resolve(t types.Type) types.Type {
+	switch t := t.(type) {
+	case types.UnresolvedType:
+		if r.generics[t.Name] {
+			return types.GenericType{Name: t.Name}
+		}
+		if decl, ok := r.table.Types[t.Name]; ok && decl.Type != nil {
+			if decl.IsAlias {
+				return r.expand(t, decl)
+			}
+			return decl.Type
+		}
+		if primitive(t.Name) {
+			return types.PrimitiveType{Name: types.PrimitiveTypeName(t.Name)}
+		}
+		r.unknown[t.Name] = true
+	case types.ArrayType:
+		t.ElementType = r.resolve(t.ElementType)
+		return t
+	case types.TupleType:
+		elements := make([]types.Type, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = r.resolve(element)
+		}
+		t.Elements = elements
+		return t
+	case types.FunctionType:
+		return r.resolveFunction(t)
+	case *types.FunctionType:
+		resolved := r.resolveFunction(*t)
+		return &resolved
+	}
+	return t
+}
+
+// expand returns the type the alias decl stands for, resolved outside the generic
+// parameters of the declaration naming it, or t if the alias names itself, which
+// checkAliases reports at its declaration
+func (r *resolver) expand(t types.UnresolvedType, decl *ast.TypeDeclStmt) types.Type {
+	if r.expanding[decl.Name] {
+		return t
+	}
+	generics := r.generics
+	r.expanding[decl.Name], r.generics = true, nil
+	defer func() {
+		delete(r.expanding, decl.Name)
+		r.generics = generics
+	}()
+	return r.resolve(decl.Type)
+}
+
+func (r *resolver) resolveFunction(fn types.FunctionType) types.FunctionType {
+	parameters := make([]types.ParameterType, len(fn.ParameterTypes))
+	for i, parameter := range fn.ParameterTypes {
+		parameter.Type = r.resolve(parameter.Type)
+		parameters[i] = parameter
+	}
+	fn.ParameterTypes = parameters
+	fn.ReturnType = r.resolve(fn.ReturnType)
+	return fn
+}
+
+// check records the names used by the fields and constructors of a declared type
+// that are not types, leaving them as they are
+func (r *resolver) check(t types.Type) {
+	switch t := t.(type) {
+	case types.UnresolvedType:
+		if _, ok := r.table.Types[t.Name]; !ok && !r.generics[t.Name] && !primitive(t.Name) {
+			r.unknown[t.Name] = true
+		}
+	case types.ArrayType:
+		r.check(t.ElementType)
+	case types.TupleType:
+		for _, element := range t.Elements {
+			r.check(element)
+		}
+	case types.FunctionType:
+		for _, parameter := range t.ParameterTypes {
+			r.check(parameter.Type)
+		}
+		r.check(t.ReturnType)
+	case *types.FunctionType:
+		r.check(*t)
+	case types.StructType:
+		for _, field := range t.Fields {
+			r.check(field.Type)
+		}
+	case types.DataType:
+		for _, ctor := range t.Constructors {
+			for _, param := range ctor.Params {
+				r.check(param)
+			}
+			for _, field := range ctor.Fields {
+				r.check(field.Type)
+			}
+		}
+	}
+}
+
+func primitive(name string) bool {
+	for _, primitive := range types.PrimitiveTypeNames {
+		if string(primitive) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// report adds an error at each place the declaration names an unknown type
+func (r *resolver) report(names []ast.TypeName) {
+	for _, name := range names {
+		if r.unknown[name.Name] {
+			r.errors = append(r.errors, collector.Error{
+				Code:     diagnostics.UnknownType,
+				Location: name.Location,
+				Message:  fmt.Sprintf("unknown type %s", name.Name),
+			})
+		}
+	}
+}
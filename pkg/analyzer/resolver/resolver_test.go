@@ -0,0 +1,202 @@
+package resolver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func at(line, col, length int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+}
+
+func TestResolve_ReplacesNamedTypes(t *testing.T) {
+	// struct Point { x: Int, near: [Point] }
+	// let origin: Point = ...
+	// def first: ([Point], t) -> t = ...
+	point := types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x":    {Name: "x", Type: types.PrimitiveType{Name: types.Int}},
+		"near": {Name: "near", Type: types.ArrayType{ElementType: types.UnresolvedType{Name: "Point"}}},
+	}}
+	decl := &ast.TypeDeclStmt{Name: "Point", Type: point, TypeNames: []ast.TypeName{{Name: "Point", Location: at(1, 31, 5)}}}
+	origin := &ast.VarDeclStmt{Keyword: "let", Name: "origin", Type: types.UnresolvedType{Name: "Point"}}
+	first := &ast.FunctionDefStmt{Name: "first", GenericParams: []string{"t"}, Signature: &types.FunctionType{
+		ParameterTypes: []types.ParameterType{
+			{Type: types.ArrayType{ElementType: types.UnresolvedType{Name: "Point"}}},
+			{Type: types.UnresolvedType{Name: "t"}},
+		},
+		ReturnType: types.UnresolvedType{Name: "Unit"},
+	}}
+	table := symbols.NewSymbolTable()
+	table.RegisterType(decl)
+
+	if errs := Resolve(&ast.Program{Statements: []ast.AstNode{decl, origin, first}}, table); len(errs) != 0 {
+		t.Fatalf("Expected every name to resolve. Got %v", errs)
+	}
+	if _, ok := origin.Type.(types.StructType); !ok {
+		t.Fatalf("Expected origin to be a StructType. Got %#v", origin.Type)
+	}
+	array := first.Signature.ParameterTypes[0].Type.(types.ArrayType)
+	if _, ok := array.ElementType.(types.StructType); !ok {
+		t.Fatalf("Expected [Point] to hold a StructType. Got %#v", array.ElementType)
+	}
+	if _, ok := first.Signature.ParameterTypes[1].Type.(types.GenericType); !ok {
+		t.Fatalf("Expected t to be a generic parameter. Got %#v", first.Signature.ParameterTypes[1].Type)
+	}
+	if !types.TypesEqual(first.Signature.ReturnType, types.PrimitiveType{Name: types.Unit}) {
+		t.Fatalf("Expected Unit to be primitive. Got %#v", first.Signature.ReturnType)
+	}
+	if _, ok := decl.Type.(types.StructType).Fields["near"].Type.(types.ArrayType).ElementType.(types.UnresolvedType); !ok {
+		t.Fatalf("Expected the recursive field to stay by name")
+	}
+}
+
+func TestResolve_ReportsUnknownTypes(t *testing.T) {
+	// struct Line { from: Pt, to: Pt }
+	// def length: (Line, Shape) -> Int = ...
+	line := &ast.TypeDeclStmt{Name: "Line",
+		Type: types.StructType{Name: "Line", Fields: map[string]types.StructField{
+			"from": {Name: "from", Type: types.UnresolvedType{Name: "Pt"}},
+			"to":   {Name: "to", Type: types.UnresolvedType{Name: "Pt"}},
+		}},
+		TypeNames: []ast.TypeName{{Name: "Pt", Location: at(1, 21, 2)}, {Name: "Pt", Location: at(1, 29, 2)}},
+	}
+	length := &ast.FunctionDefStmt{Name: "length",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: types.UnresolvedType{Name: "Line"}}, {Type: types.UnresolvedType{Name: "Shape"}}},
+			ReturnType:     types.PrimitiveType{Name: types.Int},
+		},
+		TypeNames: []ast.TypeName{{Name: "Line", Location: at(2, 16, 4)}, {Name: "Shape", Location: at(2, 22, 5)}},
+	}
+	table := symbols.NewSymbolTable()
+	table.RegisterType(line)
+
+	var messages []string
+	for _, err := range Resolve(&ast.Program{Statements: []ast.AstNode{line, length}}, table) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"1:21: unknown type Pt [LYR0035]",
+		"1:29: unknown type Pt [LYR0035]",
+		"2:22: unknown type Shape [LYR0035]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	if _, ok := length.Signature.ParameterTypes[1].Type.(types.UnresolvedType); !ok {
+		t.Fatalf("Expected the unknown Shape to stay by name")
+	}
+}
+
+func TestResolve_RecursiveTypes(t *testing.T) {
+	named := func(name string) types.Type { return types.UnresolvedType{Name: name} }
+	structDecl := func(name string, line int, fields ...string) *ast.TypeDeclStmt {
+		decl := &ast.TypeDeclStmt{Name: name, NameLocation: at(line, 8, len(name)), FieldLocations: map[string]ast.Location{}}
+		st := types.StructType{Name: name, Fields: map[string]types.StructField{}}
+		for i := 0; i < len(fields); i += 2 {
+			var fieldType types.Type = named(fields[i+1])
+			if strings.HasPrefix(fields[i+1], "(") {
+				fieldType = types.TupleType{Elements: []types.Type{types.PrimitiveType{Name: types.Int}, named(strings.Trim(fields[i+1], "()"))}}
+			}
+			st.Fields[fields[i]] = types.StructField{Name: fields[i], Type: fieldType}
+			decl.FieldLocations[fields[i]] = at(line, 20+10*i, len(fields[i]))
+		}
+		decl.Type = st
+		return decl
+	}
+	// data Tree<t> = Node { left: Tree, value: t, right: Tree } | Leaf
+	// struct Forest { trees: [Forest], root: Tree }
+	// struct Ring { id: Int, pair: (Int, Link) }
+	// struct Link { next: Ring }
+	// struct Holder { ring: Ring }
+	// struct Selfish { me: Selfish }
+	tree := &ast.TypeDeclStmt{Name: "Tree", GenericParams: []string{"t"},
+		Type: types.DataType{Name: "Tree", Constructors: map[string]types.DataTypeConstructor{
+			"Node": {Name: "Node", Fields: map[string]types.StructField{
+				"left":  {Name: "left", Type: named("Tree")},
+				"value": {Name: "value", Type: named("t")},
+				"right": {Name: "right", Type: named("Tree")},
+			}},
+			"Leaf": {Name: "Leaf"},
+		}}}
+	forest := structDecl("Forest", 2, "root", "Tree")
+	forest.Type.(types.StructType).Fields["trees"] = types.StructField{Name: "trees", Type: types.ArrayType{ElementType: named("Forest")}}
+	ring := structDecl("Ring", 3, "id", "Int", "pair", "(Link)")
+	link := structDecl("Link", 4, "next", "Ring")
+	holder := structDecl("Holder", 5, "ring", "Ring")
+	selfish := structDecl("Selfish", 6, "me", "Selfish")
+	root := &ast.VarDeclStmt{Keyword: "let", Name: "root", Type: named("Tree")}
+	statements := []ast.AstNode{tree, forest, ring, link, holder, selfish, root}
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements[:6] {
+		table.RegisterType(stmt.(*ast.TypeDeclStmt))
+	}
+
+	var messages []string
+	for _, err := range Resolve(&ast.Program{Statements: statements}, table) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"3:40: infinite type: Ring contains itself by value through Ring.pair, Link.next; hold it in an array or a data type instead [LYR0036]",
+		"4:20: infinite type: Link contains itself by value through Link.next, Ring.pair; hold it in an array or a data type instead [LYR0036]",
+		"6:20: infinite type: Selfish contains itself by value through Selfish.me; hold it in an array or a data type instead [LYR0036]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	node := root.Type.(types.DataType).Constructors["Node"]
+	if _, ok := node.Fields["left"].Type.(types.UnresolvedType); !ok {
+		t.Fatalf("Expected the recursive constructor field to stay by name. Got %#v", node.Fields["left"].Type)
+	}
+}
+
+func TestResolve_Aliases(t *testing.T) {
+	named := func(name string) types.Type { return types.UnresolvedType{Name: name} }
+	floatType := types.PrimitiveType{Name: types.Float}
+	alias := func(name string, line int, aliased types.Type) *ast.TypeDeclStmt {
+		return &ast.TypeDeclStmt{Name: name, NameLocation: at(line, 6, len(name)), Type: aliased, IsAlias: true}
+	}
+	// type Point = (Float, Float)
+	// type Segment = (Point, Point)
+	// type Loop = [Knot]
+	// type Knot = (Int, Loop)
+	// def mid: <t>(Segment, t) -> Point = ...
+	point := alias("Point", 1, types.TupleType{Elements: []types.Type{floatType, floatType}})
+	segment := alias("Segment", 2, types.TupleType{Elements: []types.Type{named("Point"), named("Point")}})
+	loop := alias("Loop", 3, types.ArrayType{ElementType: named("Knot")})
+	knot := alias("Knot", 4, types.TupleType{Elements: []types.Type{types.PrimitiveType{Name: types.Int}, named("Loop")}})
+	mid := &ast.FunctionDefStmt{Name: "mid", GenericParams: []string{"t"}, Signature: &types.FunctionType{
+		ParameterTypes: []types.ParameterType{{Type: named("Segment")}, {Type: named("t")}},
+		ReturnType:     named("Point"),
+	}}
+	statements := []ast.AstNode{point, segment, loop, knot, mid}
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements[:4] {
+		table.RegisterType(stmt.(*ast.TypeDeclStmt))
+	}
+
+	var messages []string
+	for _, err := range Resolve(&ast.Program{Statements: statements}, table) {
+		messages = append(messages, err.Error())
+	}
+	expected := []string{
+		"3:6: infinite type: alias Loop names itself through Loop -> Knot -> Loop; declare a struct or data type instead [LYR0036]",
+		"4:6: infinite type: alias Knot names itself through Knot -> Loop -> Knot; declare a struct or data type instead [LYR0036]",
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, messages)
+	}
+	pair := types.TupleType{Elements: []types.Type{floatType, floatType}}
+	if !types.TypesEqual(mid.Signature.ParameterTypes[0].Type, types.TupleType{Elements: []types.Type{pair, pair}}) {
+		t.Fatalf("Expected Segment to stand for a tuple of two points. Got %#v", mid.Signature.ParameterTypes[0].Type)
+	}
+	if !types.TypesEqual(mid.Signature.ReturnType, pair) {
+		t.Fatalf("Expected Point to stand for (Float, Float). Got %#v", mid.Signature.ReturnType)
+	}
+	if _, ok := segment.Type.(types.TupleType).Elements[0].(types.UnresolvedType); !ok {
+		t.Fatalf("Expected the declaration of Segment to keep Point by name")
+	}
+}
@@ -0,0 +1,342 @@
+package apidiff
+
+/*
+Apidiff compares the public API of two versions of a module: its pub functions
+and types, with the fields of pub structs and the constructors of pub data types,
+and the symbols of other modules it re-exports with `pub use`. Each difference is classified as breaking (code using the old version may no
+longer compile) or compatible. Declarations pub(package) or pub(module) are not
+part of the API: other packages cannot use them.
+
+Breaking: removing a symbol or narrowing it to private, pub(package) or
+pub(module), changing a function signature, a field type or a constructor,
+adding a field without a default (struct literals must give it), adding a
+constructor (matches are no longer exhaustive) and removing a field default.
+*/
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+type SymbolKind string
+
+const (
+	Function    SymbolKind = "function"
+	Struct      SymbolKind = "struct"
+	Data        SymbolKind = "data"
+	Field       SymbolKind = "field"
+	Constructor SymbolKind = "constructor"
+	Alias       SymbolKind = "alias"
+)
+
+// Symbol is a top-level declaration or a member of one
+type Symbol struct {
+	Kind       SymbolKind
+	Name       string // the refs.QualifiedName within the module: Point.x, Maybe.Some
+	Signature  string // function and field types, constructor parameters, aliased types
+	Public     bool
+	Visibility ast.Visibility // of the declaration; ast.VisibilityPublic when Public
+	HasDefault bool           // fields only
+	From       string         // module a re-exported symbol is declared in, "geometry.shapes"
+}
+
+func (s Symbol) String() string {
+	text := string(s.Kind) + " " + s.Name
+	if s.Signature != "" {
+		text += ": " + s.Signature
+	}
+	if s.From != "" {
+		text += " (from " + s.From + ")"
+	}
+	return text
+}
+
+// API is the symbols of a module by name
+type API map[string]Symbol
+
+// Collect gathers the symbols of the programs of a module
+func Collect(programs ...*ast.Program) API {
+	api := make(API)
+	for _, program := range programs {
+		for _, stmt := range program.Statements {
+			switch s := stmt.(type) {
+			case *ast.FunctionDefStmt:
+				signature := "?"
+				if s.Signature != nil {
+					signature = s.Signature.GetName()
+				}
+				if len(s.GenericParams) > 0 {
+					signature = "<" + strings.Join(s.GenericParams, ", ") + ">" + signature
+				}
+				api[s.Name] = Symbol{Kind: Function, Name: s.Name, Signature: signature, Public: s.IsPublic, Visibility: symbols.VisibilityOf(s)}
+			case *ast.TypeDeclStmt:
+				collectType(api, s)
+			}
+		}
+	}
+	return api
+}
+
+func collectType(api API, decl *ast.TypeDeclStmt) {
+	visibility := symbols.VisibilityOf(decl)
+	if decl.IsAlias {
+		api[decl.Name] = Symbol{Kind: Alias, Name: decl.Name, Signature: typeName(decl.Type), Public: decl.IsPublic, Visibility: visibility}
+		return
+	}
+	switch t := decl.Type.(type) {
+	case types.StructType:
+		api[decl.Name] = Symbol{Kind: Struct, Name: decl.Name, Public: decl.IsPublic, Visibility: visibility}
+		for name, field := range t.Fields {
+			member := refs.QualifiedName{Declaration: decl.Name, Member: name}.String()
+			api[member] = Symbol{
+				Kind:       Field,
+				Name:       member,
+				Signature:  typeName(field.Type),
+				Public:     decl.IsPublic,
+				Visibility: visibility,
+				HasDefault: field.DefaultValue != nil,
+			}
+		}
+	case types.DataType:
+		api[decl.Name] = Symbol{Kind: Data, Name: decl.Name, Public: decl.IsPublic, Visibility: visibility}
+		for name, ctor := range t.Constructors {
+			member := refs.QualifiedName{Declaration: decl.Name, Member: name}.String()
+			api[member] = Symbol{Kind: Constructor, Name: member, Signature: constructorSignature(ctor), Public: decl.IsPublic, Visibility: visibility}
+		}
+	}
+}
+
+func constructorSignature(ctor types.DataTypeConstructor) string {
+	if ctor.Fields != nil {
+		fields := make([]string, 0, len(ctor.Fields))
+		for name, field := range ctor.Fields {
+			fields = append(fields, name+": "+typeName(field.Type))
+		}
+		sort.Strings(fields)
+		return "{ " + strings.Join(fields, ", ") + " }"
+	}
+	params := make([]string, len(ctor.Params))
+	for i, param := range ctor.Params {
+		params[i] = typeName(param)
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+func typeName(t types.Type) string {
+	if t == nil {
+		return "?"
+	}
+	return t.GetName()
+}
+
+type ChangeKind string
+
+const (
+	Removed  ChangeKind = "removed"
+	Added    ChangeKind = "added"
+	Changed  ChangeKind = "changed"
+	Narrowed ChangeKind = "narrowed" // pub made private, pub(package) or pub(module)
+	Widened  ChangeKind = "widened"  // private made pub
+)
+
+// Change is a difference between two versions of the API
+type Change struct {
+	Kind     ChangeKind
+	Symbol   Symbol // the new symbol, or the old one if it was removed
+	Old, New string // signatures, for changed symbols
+	Detail   string // why a change is breaking, if not obvious from its kind
+	Breaking bool
+}
+
+func (c Change) String() string {
+	class := "compatible"
+	if c.Breaking {
+		class = "BREAKING"
+	}
+	text := fmt.Sprintf("%-10s %s %s %s", class, c.Kind, c.Symbol.Kind, c.Symbol.Name)
+	if c.Kind == Changed && c.Old != c.New {
+		text += fmt.Sprintf(": %s -> %s", c.Old, c.New)
+	}
+	if c.Detail != "" {
+		text += " (" + c.Detail + ")"
+	}
+	return text
+}
+
+// Diff lists the changes from old to new in symbol order
+func Diff(old, new API) []Change {
+	names := make(map[string]bool)
+	for name := range old {
+		names[name] = true
+	}
+	for name := range new {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	changes := make([]Change, 0)
+	for _, name := range sorted {
+		if change, ok := diffSymbol(old[name], new[name]); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// diffSymbol compares the two versions of a symbol; the zero Symbol is a missing one
+func diffSymbol(before, after Symbol) (Change, bool) {
+	existed, exists := before.Name != "", after.Name != ""
+	switch {
+	case existed && before.Public && !exists:
+		return Change{Kind: Removed, Symbol: before, Breaking: true}, true
+	case existed && before.Public && !after.Public:
+		change := Change{Kind: Narrowed, Symbol: after, Breaking: true}
+		if after.Visibility != ast.VisibilityFile {
+			change.Detail = "now " + after.Visibility.String()
+		}
+		return change, true
+	case (!existed || !before.Public) && exists && after.Public:
+		change := Change{Kind: Added, Symbol: after}
+		if existed {
+			change.Kind = Widened
+		}
+		switch {
+		case after.Kind == Field && !after.HasDefault && !existed:
+			change.Breaking, change.Detail = true, "struct literals must give it"
+		case after.Kind == Constructor && !existed:
+			change.Breaking, change.Detail = true, "matches on the type are no longer exhaustive"
+		}
+		return change, true
+	case !after.Public:
+		return Change{}, false
+	}
+
+	if before.Kind != after.Kind || before.Signature != after.Signature {
+		return Change{Kind: Changed, Symbol: after, Old: string(before.Kind) + " " + before.Signature, New: string(after.Kind) + " " + after.Signature, Breaking: true}, true
+	}
+	if before.HasDefault != after.HasDefault {
+		change := Change{Kind: Changed, Symbol: after, Detail: "default added"}
+		if before.HasDefault {
+			change.Breaking, change.Detail = true, "default removed"
+		}
+		return change, true
+	}
+	return Change{}, false
+}
+
+// Breaking reports whether any of changes is breaking
+func Breaking(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Surface returns the public symbols of api, own and re-exported, by name
+func Surface(api API) []Symbol {
+	var public []Symbol
+	for _, symbol := range api {
+		if symbol.Public {
+			public = append(public, symbol)
+		}
+	}
+	sort.Slice(public, func(i, j int) bool { return public[i].Name < public[j].Name })
+	return public
+}
+
+// Reexport adds to api the symbols its programs re-export with `pub use`, with
+// their members, loading the API of each module named through load
+func Reexport(api API, programs []*ast.Program, load func(module []string) (API, error)) error {
+	for _, program := range programs {
+		for _, stmt := range program.Statements {
+			use, ok := stmt.(*ast.UseStmt)
+			if !ok || !use.IsPublic {
+				continue
+			}
+			other, err := load(use.Module)
+			if err != nil {
+				return fmt.Errorf("pub use %s.%s: %w", use.ModulePath(), use.Name, err)
+			}
+			symbol, ok := other[use.Name]
+			if !ok || !symbol.Public {
+				return fmt.Errorf("pub use %s.%s: %s has no public symbol %s", use.ModulePath(), use.Name, use.ModulePath(), use.Name)
+			}
+			if _, declared := api[use.Name]; declared {
+				return fmt.Errorf("pub use %s.%s: %s is already declared", use.ModulePath(), use.Name, use.Name)
+			}
+			for name, member := range other {
+				if q, err := refs.ParseQualifiedName(name); err != nil || q.Declaration != use.Name {
+					continue
+				}
+				if member.From == "" {
+					member.From = use.ModulePath()
+				}
+				api[name] = member
+			}
+		}
+	}
+	return nil
+}
+
+// LoadDir analyzes the .lyra files under dir and collects their API. Modules
+// named by `pub use` are directories next to dir: geometry.shapes is
+// ../geometry/shapes.
+func LoadDir(dir string) (API, error) {
+	return loadModule(filepath.Clean(dir), make(map[string]bool))
+}
+
+// loadModule is LoadDir, with the modules whose re-exports are being resolved
+func loadModule(dir string, loading map[string]bool) (API, error) {
+	if loading[dir] {
+		return nil, fmt.Errorf("%s re-exports from itself through a cycle of pub use", dir)
+	}
+	loading[dir] = true
+	defer delete(loading, dir)
+
+	var programs []*ast.Program
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".lyra") {
+			return nil
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result, err := analyzer.Analyze(source)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		programs = append(programs, result.Program)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	api := Collect(programs...)
+	err = Reexport(api, programs, func(module []string) (API, error) {
+		return loadModule(filepath.Join(append([]string{filepath.Dir(dir)}, module...)...), loading)
+	})
+	return api, err
+}
@@ -0,0 +1,151 @@
+package apidiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func function(name string, public bool, params ...types.Type) *ast.FunctionDefStmt {
+	signature := &types.FunctionType{ReturnType: intType}
+	for _, param := range params {
+		signature.ParameterTypes = append(signature.ParameterTypes, types.ParameterType{Type: param})
+	}
+	return &ast.FunctionDefStmt{Name: name, IsPublic: public, Signature: signature}
+}
+
+func point(fields ...types.StructField) *ast.TypeDeclStmt {
+	decl := types.StructType{Name: "Point", Fields: make(map[string]types.StructField)}
+	for _, field := range fields {
+		decl.Fields[field.Name] = field
+	}
+	return &ast.TypeDeclStmt{Name: "Point", IsPublic: true, Type: decl}
+}
+
+func shape(constructors ...string) *ast.TypeDeclStmt {
+	decl := types.DataType{Name: "Shape", Constructors: make(map[string]types.DataTypeConstructor)}
+	for _, name := range constructors {
+		decl.Constructors[name] = types.DataTypeConstructor{Name: name, Params: []types.Type{intType}}
+	}
+	return &ast.TypeDeclStmt{Name: "Shape", IsPublic: true, Type: decl}
+}
+
+func program(stmts ...ast.AstNode) *ast.Program {
+	return &ast.Program{Statements: stmts}
+}
+
+func TestDiff_ClassifiesChanges(t *testing.T) {
+	x := types.StructField{Name: "x", Type: intType}
+	old := Collect(program(
+		function("area", true, intType),
+		function("scale", true, intType),
+		function("helper", false),
+		function("internal", true),
+		point(x),
+		shape("Circle"),
+	))
+	new := Collect(program(
+		function("area", true, intType, intType),
+		function("helper", true),
+		function("internal", false),
+		function("added", true),
+		point(x, types.StructField{Name: "y", Type: intType}, types.StructField{Name: "z", Type: intType, DefaultValue: 0}),
+		shape("Circle", "Square"),
+	))
+
+	expected := []struct {
+		kind     ChangeKind
+		symbol   string
+		breaking bool
+	}{
+		{Added, "Point.y", true},
+		{Added, "Point.z", false},
+		{Added, "Shape.Square", true},
+		{Added, "added", false},
+		{Changed, "area", true},
+		{Widened, "helper", false},
+		{Narrowed, "internal", true},
+		{Removed, "scale", true},
+	}
+	changes := Diff(old, new)
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes. Got %v", len(expected), changes)
+	}
+	for i, want := range expected {
+		got := changes[i]
+		if got.Kind != want.kind || got.Symbol.Name != want.symbol || got.Breaking != want.breaking {
+			t.Fatalf("Expected change %d to be %v of %s (breaking %v). Got %v", i, want.kind, want.symbol, want.breaking, got)
+		}
+	}
+	if !Breaking(changes) {
+		t.Fatalf("Expected the changes to be breaking")
+	}
+}
+
+func TestDiff_NarrowedToPackage(t *testing.T) {
+	restricted := function("area", false, intType)
+	restricted.Visibility = ast.VisibilityPackage
+	changes := Diff(Collect(program(function("area", true, intType))), Collect(program(restricted)))
+	if len(changes) != 1 || changes[0].Kind != Narrowed || !changes[0].Breaking || changes[0].Detail != "now pub(package)" {
+		t.Fatalf("Expected area narrowed to pub(package). Got %v", changes)
+	}
+	if surface := Surface(Collect(program(restricted))); len(surface) != 0 {
+		t.Fatalf("Expected no pub(package) symbol in the surface. Got %v", surface)
+	}
+}
+
+func TestDiff_UnchangedAPIIsCompatible(t *testing.T) {
+	api := Collect(program(function("area", true, intType), point(types.StructField{Name: "x", Type: intType})))
+	if changes := Diff(api, api); len(changes) != 0 {
+		t.Fatalf("Expected no changes. Got %v", changes)
+	}
+}
+
+func TestReexport_AddsSymbolsOfOtherModules(t *testing.T) {
+	shapes := Collect(program(shape("Circle"), function("unit", true), function("helper", false)))
+	load := func(module []string) (API, error) {
+		if len(module) == 2 && module[0] == "geometry" && module[1] == "shapes" {
+			return shapes, nil
+		}
+		return nil, fmt.Errorf("no module %v", module)
+	}
+	use := func(name string) *ast.UseStmt {
+		return &ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: name, IsPublic: true}
+	}
+
+	programs := []*ast.Program{program(function("area", true, intType), use("Shape"), &ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: "unit"})}
+	api := Collect(programs...)
+	if err := Reexport(api, programs, load); err != nil {
+		t.Fatalf("Reexport error: %v", err)
+	}
+	var surface []string
+	for _, symbol := range Surface(api) {
+		surface = append(surface, symbol.String())
+	}
+	expected := []string{
+		"data Shape (from geometry.shapes)",
+		"constructor Shape.Circle: (Int) (from geometry.shapes)",
+		"function area: (Int) -> Int",
+	}
+	if strings.Join(surface, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %q", expected, surface)
+	}
+
+	// dropping the re-export breaks users of Shape
+	changes := Diff(api, Collect(programs...))
+	if len(changes) != 2 || changes[0].Kind != Removed || changes[0].Symbol.Name != "Shape" || !Breaking(changes) {
+		t.Fatalf("Expected Shape and Shape.Circle removed. Got %v", changes)
+	}
+
+	for _, uses := range [][]ast.AstNode{{use("helper")}, {use("Missing")}, {function("Shape", true), use("Shape")}} {
+		programs := []*ast.Program{program(uses...)}
+		if err := Reexport(Collect(programs...), programs, load); err == nil {
+			t.Fatalf("Expected an error re-exporting %v", uses)
+		}
+	}
+}
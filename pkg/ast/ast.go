@@ -11,6 +11,12 @@ type Location struct {
 	EndCol    int
 }
 
+// TypeName is a user-defined type named in a type annotation, e.g. the Point of `: [Point]`
+type TypeName struct {
+	Name     string
+	Location Location
+}
+
 func (l *Location) ToString() string {
 	return fmt.Sprintf("%s:%d:%d-%d:%d", l.File, l.StartLine, l.StartCol, l.EndLine, l.EndCol)
 }
@@ -1,13 +1,20 @@
 package ast
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/Lyra-Language/lyra/pkg/types"
 )
 
 type Expression interface {
 	exprNode()
+	GetLocation() Location
+	GetType() types.Type
+	SetType(t types.Type)
 	GetName() string
 	Print(indent string)
 }
@@ -20,6 +27,8 @@ type ExprBase struct {
 
 func (e *ExprBase) exprNode()             {}
 func (e *ExprBase) GetLocation() Location { return e.Location }
+func (e *ExprBase) GetType() types.Type   { return e.Type }
+func (e *ExprBase) SetType(t types.Type)  { e.Type = t }
 func (e *ExprBase) GetName() string       { return "" }
 func (e *ExprBase) Print(indent string)   {}
 
@@ -27,9 +36,15 @@ func (e *ExprBase) Print(indent string)   {}
 type IntegerLiteralExpr struct {
 	ExprBase
 	Value int64
+	// Text is set to the literal as written when it is past the Int64 values,
+	// which only the unsigned types may hold; Value then holds its low 64 bits
+	Text string
 }
 
 func (i *IntegerLiteralExpr) GetName() string {
+	if i.Text != "" {
+		return i.Text
+	}
 	return fmt.Sprintf("%d", i.Value)
 }
 
@@ -37,12 +52,76 @@ func (i *IntegerLiteralExpr) Print(indent string) {
 	fmt.Printf("%sIntegerLiteralExpr(%d)\n", indent, i.Value)
 }
 
+// ParseInteger parses the source text of an integer literal: decimal, or hex,
+// octal or binary after 0x, 0o or 0b, with _ allowed between digits (1_000_000).
+// Unlike Go, a leading zero does not make a literal octal. A literal past the
+// Int64 values fails with a *RangeError.
+func ParseInteger(text string) (int64, error) {
+	clean, base, err := integerDigits(text)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseInt(clean, base, 64)
+	return value, integerError(text, err)
+}
+
+// ParseUnsigned parses the source text of an integer literal as ParseInteger
+// does, for the UInt64 values past the Int64 ones
+func ParseUnsigned(text string) (uint64, error) {
+	clean, base, err := integerDigits(text)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseUint(clean, base, 64)
+	return value, integerError(text, err)
+}
+
+// RangeError is the error of a well-formed integer literal too large to parse
+type RangeError struct {
+	Text string
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("integer literal %s does not fit in 64 bits", e.Text)
+}
+
+func (e *RangeError) Unwrap() error {
+	return strconv.ErrRange
+}
+
+// integerDigits returns the text of an integer literal as strconv parses it, in
+// base, or 0 for strconv to tell the base from a 0x, 0o or 0b prefix
+func integerDigits(text string) (string, int, error) {
+	digits := strings.TrimLeft(text, "+-")
+	if len(digits) >= 2 && digits[0] == '0' && strings.ContainsRune("xXoObB", rune(digits[1])) {
+		return text, 0, nil
+	}
+	if strings.HasPrefix(digits, "_") || strings.HasSuffix(digits, "_") || strings.Contains(digits, "__") {
+		return "", 0, fmt.Errorf("invalid integer literal %s: _ must separate digits", text)
+	}
+	return strings.ReplaceAll(text, "_", ""), 10, nil
+}
+
+func integerError(text string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, strconv.ErrRange):
+		return &RangeError{Text: text}
+	}
+	return fmt.Errorf("invalid integer literal %s", text)
+}
+
 type FloatLiteralExpr struct {
 	ExprBase
 	Value float64
+	Text  string // as written (1e-9, 0.10, 1_000.5), so printers reproduce it exactly
 }
 
 func (f *FloatLiteralExpr) GetName() string {
+	if f.Text != "" {
+		return f.Text
+	}
 	return fmt.Sprintf("%f", f.Value)
 }
 
@@ -52,17 +131,187 @@ func (f *FloatLiteralExpr) Print(indent string) {
 
 type StringLiteralExpr struct {
 	ExprBase
-	Value string
+	Value string // with its escape sequences replaced by the characters they stand for
+	Text  string // as written, quotes included
 }
 
 func (s *StringLiteralExpr) GetName() string {
-	return s.Value
+	if s.Text != "" {
+		return s.Text
+	}
+	return strconv.Quote(s.Value)
+}
+
+// EscapeError is an invalid escape sequence, Offset bytes into a literal's text
+type EscapeError struct {
+	Offset  int
+	Message string
+}
+
+func (e *EscapeError) Error() string { return e.Message }
+
+// Unescape returns the value of a string ("...") or character ('...') literal
+// from its source text, replacing the escape sequences \n, \t, \r, \0, \\, \",
+// \' and \u{...}, the last with one to six hex digits naming a Unicode code point
+func Unescape(text string) (string, error) {
+	if len(text) < 2 || (text[0] != '"' && text[0] != '\'') || text[len(text)-1] != text[0] {
+		return "", &EscapeError{Message: fmt.Sprintf("%s is not a quoted literal", text)}
+	}
+	return unescapeBody(text[1:len(text)-1], 1)
+}
+
+// unescapeBody replaces the escape sequences of the body of a literal found
+// offset bytes into its text, to which the offsets of errors are relative
+func unescapeBody(body string, offset int) (string, error) {
+	var value strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			value.WriteByte(body[i])
+			continue
+		}
+		offset := offset + i
+		if i+1 == len(body) {
+			return "", &EscapeError{Offset: offset, Message: "unfinished escape sequence"}
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			value.WriteByte('\n')
+		case 't':
+			value.WriteByte('\t')
+		case 'r':
+			value.WriteByte('\r')
+		case '0':
+			value.WriteByte(0)
+		case '\\', '"', '\'':
+			value.WriteByte(body[i])
+		case 'u':
+			end := strings.IndexByte(body[i:], '}')
+			if !strings.HasPrefix(body[i:], "u{") || end < 0 {
+				return "", &EscapeError{Offset: offset, Message: `\u must be followed by a code point in braces, like \u{1F600}`}
+			}
+			digits := body[i+2 : i+end]
+			code, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(code)) {
+				return "", &EscapeError{Offset: offset, Message: fmt.Sprintf(`\u{%s} is not a Unicode code point`, digits)}
+			}
+			value.WriteRune(rune(code))
+			i += end
+		default:
+			return "", &EscapeError{Offset: offset, Message: fmt.Sprintf(`unknown escape sequence \%c`, body[i])}
+		}
+	}
+	return value.String(), nil
 }
 
 func (s *StringLiteralExpr) Print(indent string) {
 	fmt.Printf("%sStringLiteralExpr(%s)\n", indent, s.Value)
 }
 
+// RawStringLiteralExpr is a string written r"C:\dir", whose text between the
+// quotes is its value: there are no escape sequences
+type RawStringLiteralExpr struct {
+	ExprBase
+	Value string
+	Text  string // as written, r and quotes included
+}
+
+func (s *RawStringLiteralExpr) GetName() string {
+	if s.Text != "" {
+		return s.Text
+	}
+	return `r"` + s.Value + `"`
+}
+
+func (s *RawStringLiteralExpr) Print(indent string) {
+	fmt.Printf("%sRawStringLiteralExpr(%s)\n", indent, s.Value)
+}
+
+// RawString returns the value of a raw string literal from its source text
+func RawString(text string) (string, error) {
+	if len(text) < 3 || !strings.HasPrefix(text, `r"`) || !strings.HasSuffix(text, `"`) {
+		return "", &EscapeError{Message: fmt.Sprintf("%s is not a raw string literal", text)}
+	}
+	body := text[2 : len(text)-1]
+	if i := strings.IndexAny(body, "\"\n"); i >= 0 {
+		return "", &EscapeError{Offset: 2 + i, Message: `a raw string cannot hold " or a line break: use a multiline string r""" … """`}
+	}
+	return body, nil
+}
+
+// MultilineStringLiteralExpr is a string spanning lines between """ delimiters,
+// raw if written r""" … """
+type MultilineStringLiteralExpr struct {
+	ExprBase
+	Value string // the lines with their common indentation stripped
+	Text  string // as written, delimiters included
+	Raw   bool   // escape sequences are not replaced
+}
+
+func (s *MultilineStringLiteralExpr) GetName() string { return s.Text }
+
+func (s *MultilineStringLiteralExpr) Print(indent string) {
+	fmt.Printf("%sMultilineStringLiteralExpr(%q)\n", indent, s.Value)
+}
+
+// MultilineString returns the value of a multiline string literal from its
+// source text. The opening """ ends its line and the closing """ stands on a
+// line of its own, and neither line is part of the value. The indentation of
+// the closing """ is stripped from every line, so the string can be indented
+// with the code around it:
+//
+//	let usage = """
+//		lyra check [files]
+//		  -w  number of workers
+//		"""
+//
+// is "lyra check [files]\n  -w  number of workers". A line indented less than
+// the closing """ is an error, unless it is blank: blank lines are empty. Escape
+// sequences are replaced after stripping, except in a raw string (r""").
+func MultilineString(text string) (string, error) {
+	open := `"""`
+	raw := strings.HasPrefix(text, `r"""`)
+	if raw {
+		open = `r"""`
+	}
+	if len(text) < len(open)+3 || !strings.HasPrefix(text, open) || !strings.HasSuffix(text, `"""`) {
+		return "", &EscapeError{Message: fmt.Sprintf("%s is not a multiline string literal", text)}
+	}
+	body := strings.ReplaceAll(text[len(open):len(text)-3], "\r\n", "\n")
+	if !strings.HasPrefix(body, "\n") {
+		return "", &EscapeError{Offset: len(open), Message: `the text of a multiline string starts on the line after the opening """`}
+	}
+	body, offset := body[1:], len(open)+1
+	closing := strings.LastIndexByte(body, '\n') + 1
+	indent := body[closing:]
+	if strings.Trim(indent, " \t") != "" {
+		return "", &EscapeError{Offset: offset + closing, Message: `the closing """ of a multiline string must stand on a line of its own`}
+	}
+	if closing == 0 {
+		return "", nil
+	}
+
+	lines := strings.Split(body[:closing-1], "\n")
+	for i, line := range lines {
+		switch {
+		case strings.Trim(line, " \t") == "":
+			lines[i] = ""
+		case !strings.HasPrefix(line, indent):
+			return "", &EscapeError{Offset: offset, Message: `line is indented less than the closing """ of its multiline string`}
+		case raw:
+			lines[i] = line[len(indent):]
+		default:
+			value, err := unescapeBody(line[len(indent):], offset+len(indent))
+			if err != nil {
+				return "", err
+			}
+			lines[i] = value
+		}
+		offset += len(line) + 1
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
 type BooleanLiteralExpr struct {
 	ExprBase
 	Value bool
@@ -166,6 +415,29 @@ const (
 	BooleanBinaryOpOr  BooleanBinaryOp = "||"
 )
 
+// BinaryOpExpr represents an arithmetic or concatenation operation (a + b, xs ++ ys)
+type BinaryOpExpr struct {
+	ExprBase
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (b *BinaryOpExpr) GetName() string {
+	return fmt.Sprintf("%s %s %s", b.Left.GetName(), b.Operator, b.Right.GetName())
+}
+
+func (b *BinaryOpExpr) Print(indent string) {
+	fmt.Printf("%sBinaryOpExpr(%s)\n", indent, b.GetName())
+	fmt.Printf("%s  Left: {\n", indent)
+	b.Left.Print(indent + "    ")
+	fmt.Printf("%s  }\n", indent)
+	fmt.Printf("%s  Operator: %s\n", indent, b.Operator)
+	fmt.Printf("%s  Right: {\n", indent)
+	b.Right.Print(indent + "    ")
+	fmt.Printf("%s  }\n", indent)
+}
+
 type GuardExpr struct {
 	ExprBase
 	Condition Expression
@@ -181,3 +453,248 @@ func (g *GuardExpr) Print(indent string) {
 	g.Condition.Print(indent + "    ")
 	fmt.Printf("%s  }\n", indent)
 }
+
+// CallExpr represents a function or constructor call (sum(1, 2), Some(5))
+type CallExpr struct {
+	ExprBase
+	Callee    Expression
+	Arguments []Expression
+	// Modifiers holds the marker written before each argument passed to a mut or
+	// ref parameter (f(mut x)), "" for an unmarked one; nil when none is marked
+	Modifiers []types.Modifier
+	// TypeArguments holds what the generic parameters of the callee stand for in
+	// this call, as the checker solved them from the arguments
+	TypeArguments map[string]types.Type
+}
+
+// Modifier returns the marker written before argument i, "" if there is none
+func (c *CallExpr) Modifier(i int) types.Modifier {
+	if i < len(c.Modifiers) {
+		return c.Modifiers[i]
+	}
+	return ""
+}
+
+func (c *CallExpr) GetName() string {
+	arguments := make([]string, len(c.Arguments))
+	for i, argument := range c.Arguments {
+		arguments[i] = argument.GetName()
+		if modifier := c.Modifier(i); modifier != "" {
+			arguments[i] = string(modifier) + " " + arguments[i]
+		}
+	}
+	return fmt.Sprintf("%s(%s)", c.Callee.GetName(), strings.Join(arguments, ", "))
+}
+
+func (c *CallExpr) Print(indent string) {
+	fmt.Printf("%sCallExpr(%s)\n", indent, c.Callee.GetName())
+	for i, argument := range c.Arguments {
+		fmt.Printf("%s  Argument %d: {\n", indent, i)
+		argument.Print(indent + "    ")
+		fmt.Printf("%s  }\n", indent)
+	}
+}
+
+// MemberAccessExpr represents a field access (point.x)
+type MemberAccessExpr struct {
+	ExprBase
+	Object         Expression
+	Member         string
+	MemberLocation Location // location of the member name, for rename/references
+}
+
+func (m *MemberAccessExpr) GetName() string {
+	return fmt.Sprintf("%s.%s", m.Object.GetName(), m.Member)
+}
+
+func (m *MemberAccessExpr) Print(indent string) {
+	fmt.Printf("%sMemberAccessExpr(%s)\n", indent, m.Member)
+	fmt.Printf("%s  Object: {\n", indent)
+	m.Object.Print(indent + "    ")
+	fmt.Printf("%s  }\n", indent)
+}
+
+// StructLiteralExpr represents a struct literal (Point { x: 1, y })
+type StructLiteralExpr struct {
+	ExprBase
+	TypeName string
+	Fields   []*StructLiteralField
+}
+
+// StructLiteralField is a single `name: value` entry of a struct literal
+type StructLiteralField struct {
+	Name         string
+	NameLocation Location
+	Value        Expression
+	Shorthand    bool // `{ x }` is shorthand for `{ x: x }`
+}
+
+func (s *StructLiteralExpr) GetName() string {
+	fields := make([]string, len(s.Fields))
+	for i, field := range s.Fields {
+		if field.Shorthand || field.Value == nil {
+			fields[i] = field.Name
+		} else {
+			fields[i] = fmt.Sprintf("%s: %s", field.Name, field.Value.GetName())
+		}
+	}
+	return fmt.Sprintf("%s { %s }", s.TypeName, strings.Join(fields, ", "))
+}
+
+func (s *StructLiteralExpr) Print(indent string) {
+	fmt.Printf("%sStructLiteralExpr(%s) {\n", indent, s.TypeName)
+	for _, field := range s.Fields {
+		fmt.Printf("%s  %s:\n", indent, field.Name)
+		if field.Value != nil {
+			field.Value.Print(indent + "    ")
+		}
+	}
+	fmt.Printf("%s}\n", indent)
+}
+
+// ArrayLiteralExpr represents an array literal ([1, 2, 3])
+type ArrayLiteralExpr struct {
+	ExprBase
+	Elements []Expression
+}
+
+func (a *ArrayLiteralExpr) GetName() string {
+	elements := make([]string, len(a.Elements))
+	for i, element := range a.Elements {
+		elements[i] = element.GetName()
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (a *ArrayLiteralExpr) Print(indent string) {
+	fmt.Printf("%sArrayLiteralExpr [\n", indent)
+	for _, element := range a.Elements {
+		element.Print(indent + "  ")
+	}
+	fmt.Printf("%s]\n", indent)
+}
+
+// TupleLiteralExpr represents a tuple literal ((q, r))
+type TupleLiteralExpr struct {
+	ExprBase
+	Elements []Expression
+}
+
+func (t *TupleLiteralExpr) GetName() string {
+	elements := make([]string, len(t.Elements))
+	for i, element := range t.Elements {
+		elements[i] = element.GetName()
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+func (t *TupleLiteralExpr) Print(indent string) {
+	fmt.Printf("%sTupleLiteralExpr (\n", indent)
+	for _, element := range t.Elements {
+		element.Print(indent + "  ")
+	}
+	fmt.Printf("%s)\n", indent)
+}
+
+// LambdaExpr is an anonymous function ((x) => x + 1). Its parameters are
+// patterns, as those of a clause; the checker types them from the function type
+// expected of the lambda, or else infers them from the body.
+type LambdaExpr struct {
+	ExprBase
+	Parameters []Pattern
+	Body       Expression
+}
+
+func (l *LambdaExpr) GetName() string {
+	parameters := make([]string, len(l.Parameters))
+	for i, parameter := range l.Parameters {
+		parameters[i] = parameter.GetName()
+	}
+	body := "?"
+	if l.Body != nil {
+		body = l.Body.GetName()
+	}
+	return "(" + strings.Join(parameters, ", ") + ") => " + body
+}
+
+func (l *LambdaExpr) Print(indent string) {
+	fmt.Printf("%sLambdaExpr(%s)\n", indent, l.GetName())
+}
+
+// HostValueExpr is a value supplied by a Go program embedding Lyra; it has no
+// source and its Type is synthesized from the Go type
+type HostValueExpr struct {
+	ExprBase
+	Value any
+}
+
+func (h *HostValueExpr) GetName() string { return fmt.Sprintf("<host %v>", h.Value) }
+
+func (h *HostValueExpr) Print(indent string) {
+	fmt.Printf("%sHostValueExpr(%v)\n", indent, h.Value)
+}
+
+// HoleExpr is a typed hole (???): a placeholder expression whose expected type
+// the checker reports, along with the bindings in scope that would fit
+type HoleExpr struct {
+	ExprBase
+}
+
+func (h *HoleExpr) GetName() string { return "???" }
+
+func (h *HoleExpr) Print(indent string) {
+	fmt.Printf("%sHoleExpr\n", indent)
+}
+
+// TemplatePart is a piece of a render template: literal text, or a placeholder
+// naming the field of the rendered struct that stands in its place
+type TemplatePart struct {
+	Text  string
+	Field string // set for a placeholder, whose Text is "{field}"
+}
+
+// ParseTemplate splits the value of a template string into text and {field}
+// placeholders. {{ and }} stand for literal braces.
+func ParseTemplate(template string) ([]TemplatePart, error) {
+	var parts []TemplatePart
+	var text strings.Builder
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{") || strings.HasPrefix(template[i:], "}}"):
+			text.WriteByte(template[i])
+			i++
+		case template[i] == '}':
+			return nil, &EscapeError{Offset: i, Message: "unmatched } in template: write }} for a brace"}
+		case template[i] == '{':
+			end := strings.IndexByte(template[i:], '}')
+			if end < 0 {
+				return nil, &EscapeError{Offset: i, Message: "unclosed { in template: write {{ for a brace"}
+			}
+			field := strings.TrimSpace(template[i+1 : i+end])
+			if !isIdentifier(field) {
+				return nil, &EscapeError{Offset: i, Message: fmt.Sprintf("template placeholder {%s} must name a field", field)}
+			}
+			if text.Len() > 0 {
+				parts = append(parts, TemplatePart{Text: text.String()})
+				text.Reset()
+			}
+			parts = append(parts, TemplatePart{Text: template[i : i+end+1], Field: field})
+			i += end
+		default:
+			text.WriteByte(template[i])
+		}
+	}
+	if text.Len() > 0 {
+		parts = append(parts, TemplatePart{Text: text.String()})
+	}
+	return parts, nil
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,97 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUnescape(t *testing.T) {
+	for text, expected := range map[string]string{
+		`"plain"`:              "plain",
+		`"tab\there\n"`:        "tab\there\n",
+		`"quote \" and \\"`:    `quote " and \`,
+		`'\''`:                 "'",
+		`"smile \u{1F600}!"`:   "smile \U0001F600!",
+		`"nul \0 and \u{41}"`:  "nul \x00 and A",
+		`"keeps 'single' too"`: "keeps 'single' too",
+	} {
+		if value, err := Unescape(text); err != nil || value != expected {
+			t.Fatalf("Expected Unescape(%s) = %q. Got %q, %v", text, expected, value, err)
+		}
+	}
+
+	for text, offset := range map[string]int{
+		`"bad \q"`:         5,
+		`"no \u41"`:        4,
+		`"far \u{110000}"`: 5,
+		`"open \u{41"`:     6,
+	} {
+		_, err := Unescape(text)
+		var escapeErr *EscapeError
+		if !errors.As(err, &escapeErr) || escapeErr.Offset != offset {
+			t.Fatalf("Expected Unescape(%s) to fail at offset %d. Got %v", text, offset, err)
+		}
+	}
+}
+
+func TestRawString(t *testing.T) {
+	if value, err := RawString(`r"C:\dir\n"`); err != nil || value != `C:\dir\n` {
+		t.Fatalf(`Expected C:\dir\n unescaped. Got %q, %v`, value, err)
+	}
+	if _, err := RawString("r\"two\nlines\""); err == nil {
+		t.Fatalf("Expected a raw string not to span lines")
+	}
+}
+
+func TestMultilineString(t *testing.T) {
+	for text, expected := range map[string]string{
+		"\"\"\"\n\tlyra check\n\t  -w  workers\n\t\"\"\"": "lyra check\n  -w  workers",
+		"\"\"\"\n\t\tkept\n\n\tless\n\t\"\"\"":            "\tkept\n\nless",
+		"\"\"\"\n  tab\\there\n  \"\"\"":                  "tab\there",
+		"r\"\"\"\n  tab\\there\n  \"\"\"":                 `tab\there`,
+		"\"\"\"\n\"\"\"":                                  "",
+		"\"\"\"\nfirst\n  indented \\u{41}\n\"\"\"":       "first\n  indented A",
+		"\"\"\"\n\t\ttrailing line\n\n\t\t\"\"\"":         "trailing line\n",
+		"\"\"\"\r\n\tcrlf\r\n\t\"\"\"":                    "crlf",
+	} {
+		if value, err := MultilineString(text); err != nil || value != expected {
+			t.Fatalf("Expected MultilineString(%q) = %q. Got %q, %v", text, expected, value, err)
+		}
+	}
+
+	for text, offset := range map[string]int{
+		"\"\"\"text\n\"\"\"":             3,
+		"\"\"\"\n\tone\n  two\n\t\"\"\"": 9,
+		"\"\"\"\n\tbad \\q\n\t\"\"\"":    9,
+		"\"\"\"\n\ttext \"\"\"":          4,
+	} {
+		_, err := MultilineString(text)
+		var escapeErr *EscapeError
+		if !errors.As(err, &escapeErr) || escapeErr.Offset != offset {
+			t.Fatalf("Expected MultilineString(%q) to fail at offset %d. Got %v", text, offset, err)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	parts, err := ParseTemplate("({x}, { y }) {{not}} }}")
+	expected := []TemplatePart{
+		{Text: "("}, {Text: "{x}", Field: "x"}, {Text: ", "}, {Text: "{ y }", Field: "y"}, {Text: ") {not} }"},
+	}
+	if err != nil || len(parts) != len(expected) {
+		t.Fatalf("Expected %v. Got %v, %v", expected, parts, err)
+	}
+	for i := range expected {
+		if parts[i] != expected[i] {
+			t.Fatalf("Expected part %d to be %+v. Got %+v", i, expected[i], parts[i])
+		}
+	}
+
+	for template, offset := range map[string]int{"open {x": 5, "a } b": 2, "{1x}": 0, "{}": 0} {
+		_, err := ParseTemplate(template)
+		var escapeErr *EscapeError
+		if !errors.As(err, &escapeErr) || escapeErr.Offset != offset {
+			t.Fatalf("Expected ParseTemplate(%q) to fail at offset %d. Got %v", template, offset, err)
+		}
+	}
+}
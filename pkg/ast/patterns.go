@@ -1,6 +1,9 @@
 package ast
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Pattern is the interface for all pattern AST nodes
 type Pattern interface {
@@ -25,6 +28,13 @@ type IdentifierPattern struct {
 
 func (p *IdentifierPattern) GetName() string { return p.Name }
 
+// WildcardPattern matches any value without binding it (_)
+type WildcardPattern struct {
+	PatternBase
+}
+
+func (p *WildcardPattern) GetName() string { return "_" }
+
 // LiteralPattern represents a literal pattern (matches a value)
 type LiteralPattern struct {
 	PatternBase
@@ -33,4 +43,132 @@ type LiteralPattern struct {
 
 func (p *LiteralPattern) GetName() string { return fmt.Sprintf("%v", p.Value) }
 
-// TODO: add other patterns (tuple, struct, array, etc.)
+// AsPattern binds the whole value Pattern matches to Name as well as the names
+// Pattern binds (node @ Node { left })
+type AsPattern struct {
+	PatternBase
+	Name         string
+	NameLocation Location
+	Pattern      Pattern
+}
+
+func (p *AsPattern) GetName() string { return p.Name + " @ " + p.Pattern.GetName() }
+
+// RangePattern matches the integers or characters from Low to High inclusive
+// (0..=9, 'a'..='z'); the bounds keep their literal text
+type RangePattern struct {
+	PatternBase
+	Low  string
+	High string
+}
+
+func (p *RangePattern) GetName() string { return p.Low + "..=" + p.High }
+
+// IsCharacter reports whether the bounds are character literals
+func (p *RangePattern) IsCharacter() bool { return strings.HasPrefix(p.Low, "'") }
+
+// Bounds returns the values of the bounds: integers, or the code points of
+// characters
+func (p *RangePattern) Bounds() (low, high int64, err error) {
+	if low, err = rangeBound(p.Low); err != nil {
+		return 0, 0, err
+	}
+	if high, err = rangeBound(p.High); err != nil {
+		return 0, 0, err
+	}
+	return low, high, nil
+}
+
+func rangeBound(text string) (int64, error) {
+	if !strings.HasPrefix(text, "'") {
+		return ParseInteger(text)
+	}
+	value, err := Unescape(text)
+	if err != nil {
+		return 0, err
+	}
+	runes := []rune(value)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("%s is not a single character", text)
+	}
+	return int64(runes[0]), nil
+}
+
+// StructPattern destructures a struct or record constructor (Point { x, y: 0 })
+type StructPattern struct {
+	PatternBase
+	TypeName string
+	Fields   []*StructPatternField
+}
+
+// StructPatternField is a single field of a struct pattern
+type StructPatternField struct {
+	Name         string
+	NameLocation Location
+	Pattern      Pattern // nil for shorthand `{ x }`, which binds the field to x
+}
+
+func (p *StructPattern) GetName() string {
+	fields := make([]string, len(p.Fields))
+	for i, field := range p.Fields {
+		if field.Pattern == nil {
+			fields[i] = field.Name
+		} else {
+			fields[i] = fmt.Sprintf("%s: %s", field.Name, field.Pattern.GetName())
+		}
+	}
+	return fmt.Sprintf("%s { %s }", p.TypeName, strings.Join(fields, ", "))
+}
+
+// TuplePattern destructures a tuple element by element ((q, r))
+type TuplePattern struct {
+	PatternBase
+	Elements []Pattern
+}
+
+func (p *TuplePattern) GetName() string {
+	elements := make([]string, len(p.Elements))
+	for i, element := range p.Elements {
+		elements[i] = element.GetName()
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// ConstructorPattern destructures a positional data constructor argument by
+// argument (Some(x), Circle(_))
+type ConstructorPattern struct {
+	PatternBase
+	Name      string // the constructor, possibly qualified (Maybe.Some)
+	Arguments []Pattern
+}
+
+func (p *ConstructorPattern) GetName() string {
+	arguments := make([]string, len(p.Arguments))
+	for i, argument := range p.Arguments {
+		arguments[i] = argument.GetName()
+	}
+	return p.Name + "(" + strings.Join(arguments, ", ") + ")"
+}
+
+// ArrayPattern destructures the first elements of an array and binds the others
+// to Rest ([x, y, ...rest]). Without Rest the array must have exactly as many
+// elements as the pattern.
+type ArrayPattern struct {
+	PatternBase
+	Elements []Pattern
+	Rest     *IdentifierPattern // nil without ...; named _ for [x, ...]
+}
+
+func (p *ArrayPattern) GetName() string {
+	elements := make([]string, len(p.Elements), len(p.Elements)+1)
+	for i, element := range p.Elements {
+		elements[i] = element.GetName()
+	}
+	switch {
+	case p.Rest != nil && p.Rest.Name == "_":
+		elements = append(elements, "...")
+	case p.Rest != nil:
+		elements = append(elements, "..."+p.Rest.Name)
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
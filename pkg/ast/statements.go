@@ -2,6 +2,8 @@ package ast
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/Lyra-Language/lyra/pkg/types"
 )
@@ -9,10 +11,51 @@ import (
 // TypeDeclarationStmt represents a type declaration (struct, data type, etc.)
 type TypeDeclStmt struct {
 	AstBase
-	Name          string
-	GenericParams []string
-	Type          types.Type
-	IsPublic      bool
+	Name           string
+	NameLocation   Location
+	GenericParams  []string
+	Type           types.Type
+	IsPublic       bool                // pub, part of the package's API
+	Visibility     Visibility          // VisibilityPublic when IsPublic
+	FieldLocations map[string]Location // struct field name -> location of the name
+	Derives        []string            // traits named by @derive(...), e.g. Serialize
+	TypeNames      []TypeName          // types named by field types and constructor parameters
+	IsAlias        bool                // type Name = T: Type is T, which the name stands for
+}
+
+// DerivesTrait reports whether the declaration derives trait
+func (t *TypeDeclStmt) DerivesTrait(trait string) bool {
+	for _, derived := range t.Derives {
+		if derived == trait {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldOrder returns the fields of a struct declaration in the order it declares
+// them, fields without a location last by name
+func (t *TypeDeclStmt) FieldOrder() []string {
+	structType, ok := t.Type.(types.StructType)
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(structType.Fields))
+	for name := range structType.Fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, aKnown := t.FieldLocations[names[i]]
+		b, bKnown := t.FieldLocations[names[j]]
+		if aKnown != bKnown {
+			return aKnown
+		}
+		if a.StartLine != b.StartLine || a.StartCol != b.StartCol {
+			return a.StartLine < b.StartLine || a.StartLine == b.StartLine && a.StartCol < b.StartCol
+		}
+		return names[i] < names[j]
+	})
+	return names
 }
 
 func (t *TypeDeclStmt) GetName() string { return t.Name }
@@ -28,10 +71,34 @@ func (t *TypeDeclStmt) Print(indent string) {
 	}
 	if t.IsPublic {
 		fmt.Printf("%s  IsPublic: true\n", indent)
+	} else if t.Visibility != VisibilityFile {
+		fmt.Printf("%s  Visibility: %s\n", indent, t.Visibility)
+	}
+	if t.Derives != nil {
+		fmt.Printf("%s  Derives: %v\n", indent, t.Derives)
 	}
 	fmt.Printf("%s}\n", indent)
 }
 
+// DataConstructorDecl represents a data type constructor (Some, Nil, Node, ...)
+// It is registered in the symbol table so constructors can be referenced by name
+type DataConstructorDecl struct {
+	AstBase
+	Name         string
+	NameLocation Location
+	DataType     string              // name of the data type declaring this constructor
+	Signature    *types.FunctionType // synthesized: constructor params -> data type
+}
+
+func (d *DataConstructorDecl) GetName() string { return d.Name }
+
+func (d *DataConstructorDecl) Print(indent string) {
+	fmt.Printf("%sDataConstructorDecl(%s.%s)\n", indent, d.DataType, d.Name)
+	if d.Signature != nil {
+		fmt.Printf("%s  Signature: %s\n", indent, d.Signature.GetName())
+	}
+}
+
 // ExpressionStmt wraps an expression used as a statement
 type ExpressionStmt struct {
 	AstBase
@@ -41,10 +108,14 @@ type ExpressionStmt struct {
 // VariableDeclarationStmt represents a let/var/const binding
 type VarDeclStmt struct {
 	AstBase
-	Keyword string // "let", "var", "const"
-	Name    string
-	Type    types.Type // may be nil if needs inference
-	Value   Expression
+	Keyword      string // "let", "var", "const"
+	IsLazy       bool   // lazy let: the value is computed when first read
+	Name         string
+	NameLocation Location
+	Type         types.Type // may be nil if needs inference
+	TypeLocation Location   // location of the annotation, zero if there is none
+	TypeNames    []TypeName // types named by the annotation
+	Value        Expression
 }
 
 func (v *VarDeclStmt) GetName() string { return v.Name }
@@ -54,6 +125,9 @@ func (v *VarDeclStmt) Print(indent string) {
 	if v.Keyword != "" {
 		fmt.Printf("%s  Keyword: %s\n", indent, v.Keyword)
 	}
+	if v.IsLazy {
+		fmt.Printf("%s  Lazy: true\n", indent)
+	}
 	if v.Type != nil {
 		fmt.Printf("%s  Type: %s\n", indent, v.Type.GetName())
 	}
@@ -63,24 +137,104 @@ func (v *VarDeclStmt) Print(indent string) {
 	fmt.Printf("%s}\n", indent)
 }
 
+// DestructuringDeclStmt binds the parts of a tuple or struct value at once, as
+// in let (q, r) = divmod(a, b). Each name the pattern binds is declared by one
+// of Bindings, which share the keyword, have no value of their own and get
+// their types from the checker.
+type DestructuringDeclStmt struct {
+	AstBase
+	Keyword  string
+	Pattern  Pattern
+	Value    Expression
+	Bindings []*VarDeclStmt // in the order the pattern binds them
+}
+
+func (d *DestructuringDeclStmt) Print(indent string) {
+	fmt.Printf("%sDestructuringDeclStmt(%s)\n", indent, d.Pattern.GetName())
+	fmt.Printf("%s  Keyword: %s\n", indent, d.Keyword)
+	if d.Value != nil {
+		fmt.Printf("%s  Value: %s\n", indent, d.Value.GetName())
+	}
+	fmt.Printf("%s}\n", indent)
+}
+
 // IsMutable returns true if this is a var declaration
 func (v *VarDeclStmt) IsMutable() bool { return v.Keyword == "var" }
 
 // IsConstant returns true if this is a const declaration
 func (v *VarDeclStmt) IsConstant() bool { return v.Keyword == "const" }
 
+// VarAssignStmt represents a reassignment of a var binding (x = x + 1)
+type VarAssignStmt struct {
+	AstBase
+	Name         string
+	NameLocation Location
+	Value        Expression
+}
+
+func (v *VarAssignStmt) GetName() string { return v.Name }
+
+func (v *VarAssignStmt) Print(indent string) {
+	fmt.Printf("%sVarAssignStmt(%s)\n", indent, v.Name)
+	if v.Value != nil {
+		fmt.Printf("%s  Value: %s\n", indent, v.Value.GetName())
+	}
+}
+
+// ImplStmt implements a trait for a type:
+//
+//	impl Show for Point { def show: (Point) -> String = (p) => ... }
+//
+// The first parameter of each method is its receiver, which a method call such as
+// point.show() binds to the value it is called on.
+type ImplStmt struct {
+	AstBase
+	Trait        string
+	TypeName     string // the type implementing the trait
+	TypeLocation Location
+	Methods      []*FunctionDefStmt
+}
+
+func (i *ImplStmt) GetName() string { return i.Trait + " for " + i.TypeName }
+
+// Method returns the method named name, nil if the impl has none
+func (i *ImplStmt) Method(name string) *FunctionDefStmt {
+	for _, method := range i.Methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	return nil
+}
+
+func (i *ImplStmt) Print(indent string) {
+	fmt.Printf("%sImplStmt(%s) {\n", indent, i.GetName())
+	for _, method := range i.Methods {
+		method.Print(indent + "  ")
+	}
+	fmt.Printf("%s}\n", indent)
+}
+
 // FunctionDefStmt represents a function definition
 type FunctionDefStmt struct {
 	AstBase
-	Name          string
-	GenericParams []string
-	Signature     *types.FunctionType
-	Clauses       []*FunctionClause
-	IsPublic      bool
-	IsPure        bool
-	IsAsync       bool
+	Name              string
+	NameLocation      Location
+	GenericParams     []string
+	Signature         *types.FunctionType
+	SignatureLocation Location   // location of the signature's function type
+	TypeNames         []TypeName // types and generic parameters named by the signature
+	Clauses           []*FunctionClause
+	IsPublic          bool       // pub, part of the package's API
+	Visibility        Visibility // VisibilityPublic when IsPublic
+	IsPure            bool
+	IsAsync           bool
+	Extern            string // target of an extern declaration ("go:time.UnixNano"), which has no clauses
 }
 
+// IsExtern reports whether the function is implemented by the host
+func (f *FunctionDefStmt) IsExtern() bool { return f.Extern != "" }
+
 func (f *FunctionDefStmt) GetName() string { return f.Name }
 
 func (f *FunctionDefStmt) Print(indent string) {
@@ -99,6 +253,8 @@ func (f *FunctionDefStmt) Print(indent string) {
 	}
 	if f.IsPublic {
 		fmt.Printf("%s  IsPublic: true\n", indent)
+	} else if f.Visibility != VisibilityFile {
+		fmt.Printf("%s  Visibility: %s\n", indent, f.Visibility)
 	}
 	if f.IsPure {
 		fmt.Printf("%s  IsPure: true\n", indent)
@@ -106,6 +262,9 @@ func (f *FunctionDefStmt) Print(indent string) {
 	if f.IsAsync {
 		fmt.Printf("%s  IsAsync: true\n", indent)
 	}
+	if f.Extern != "" {
+		fmt.Printf("%s  Extern: %q\n", indent, f.Extern)
+	}
 	fmt.Printf("%s}\n", indent)
 }
 
@@ -114,6 +273,7 @@ type FunctionClause struct {
 	AstBase
 	Parameters []Pattern
 	Guard      *GuardExpr
+	Functions  []*FunctionDefStmt // defined in the body's block, seen by the body and one another
 	Body       Expression
 }
 
@@ -133,6 +293,9 @@ func (f *FunctionClause) Print(indent string) {
 	} else {
 		fmt.Printf("%s  Guard: nil\n", indent)
 	}
+	for _, fn := range f.Functions {
+		fn.Print(indent + "  ")
+	}
 	if f.Body != nil {
 		fmt.Printf("%s  Body: {\n", indent)
 		f.Body.Print(indent + "    ")
@@ -148,3 +311,25 @@ type ReturnStmt struct {
 	AstBase
 	Value Expression // nil for bare return
 }
+
+// UseStmt brings a symbol of another module into scope: `use shapes.Circle`.
+// With pub, the symbol is re-exported as part of the public API of the module.
+type UseStmt struct {
+	AstBase
+	Module       []string // path of the module, ["geometry", "shapes"] for geometry.shapes
+	Name         string
+	NameLocation Location
+	IsPublic     bool
+}
+
+func (u *UseStmt) GetName() string { return u.Name }
+
+// ModulePath returns the dotted path of the module, "geometry.shapes"
+func (u *UseStmt) ModulePath() string { return strings.Join(u.Module, ".") }
+
+func (u *UseStmt) Print(indent string) {
+	fmt.Printf("%sUseStmt(%s.%s)\n", indent, u.ModulePath(), u.Name)
+	if u.IsPublic {
+		fmt.Printf("%s  IsPublic: true\n", indent)
+	}
+}
@@ -0,0 +1,119 @@
+package symbols
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// TableDiff lists the global names whose declarations differ between two
+// versions of a table, each sorted. A declaration is changed when what other
+// files see of it changes: its kind, visibility, signature, type, fields or
+// constructors, but not its body or location.
+type TableDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether the tables declare the same names the same way
+func (d TableDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Names returns every name the diff lists, sorted
+func (d TableDiff) Names() []string {
+	names := append(append(append([]string{}, d.Added...), d.Removed...), d.Changed...)
+	sort.Strings(names)
+	return names
+}
+
+// Diff compares the global declarations of two versions of a table, as when a
+// file is edited, so that only the files using what changed need checking again
+func Diff(before, after *SymbolTable) TableDiff {
+	var diff TableDiff
+	for _, name := range sortedNames(after.GlobalScope.Symbols) {
+		previous, existed := before.GlobalScope.Symbols[name]
+		switch {
+		case !existed:
+			diff.Added = append(diff.Added, name)
+		case Shape(previous) != Shape(after.GlobalScope.Symbols[name]):
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for _, name := range sortedNames(before.GlobalScope.Symbols) {
+		if _, exists := after.GlobalScope.Symbols[name]; !exists {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	return diff
+}
+
+// Shape renders what other code may depend on of a declaration:
+// `pub def sum<t>: (t, t) -> t`, `pub(module) type Point { x: Int, y: Int = … }`,
+// `data Maybe { None(), Some(Int) }`, `let answer: Int`
+func Shape(node ast.Named) string {
+	visibility := ""
+	if v := VisibilityOf(node); v != ast.VisibilityFile {
+		visibility = v.String() + " "
+	}
+	switch n := node.(type) {
+	case *ast.FunctionDefStmt:
+		generics := ""
+		if len(n.GenericParams) > 0 {
+			generics = "<" + strings.Join(n.GenericParams, ", ") + ">"
+		}
+		signature := "?"
+		if n.Signature != nil {
+			signature = n.Signature.GetName()
+		}
+		return fmt.Sprintf("%sdef %s%s: %s", visibility, n.Name, generics, signature)
+	case *ast.TypeDeclStmt:
+		switch t := n.Type.(type) {
+		case types.StructType:
+			return fmt.Sprintf("%stype %s %s", visibility, n.Name, fieldShapes(t.Fields))
+		case types.DataType:
+			ctors := make([]string, 0, len(t.Constructors))
+			for _, name := range sortedNames(t.Constructors) {
+				ctor := t.Constructors[name]
+				if ctor.Fields != nil {
+					ctors = append(ctors, name+" "+fieldShapes(ctor.Fields))
+					continue
+				}
+				params := make([]string, len(ctor.Params))
+				for i, param := range ctor.Params {
+					params[i] = typeName(param)
+				}
+				ctors = append(ctors, name+"("+strings.Join(params, ", ")+")")
+			}
+			return fmt.Sprintf("%sdata %s { %s }", visibility, n.Name, strings.Join(ctors, ", "))
+		}
+		return fmt.Sprintf("%stype %s = %s", visibility, n.Name, typeName(n.Type))
+	case *ast.VarDeclStmt:
+		return fmt.Sprintf("%s %s: %s", n.Keyword, n.Name, typeName(n.Type))
+	}
+	return node.GetName()
+}
+
+func fieldShapes(fields map[string]types.StructField) string {
+	shapes := make([]string, 0, len(fields))
+	for _, name := range sortedNames(fields) {
+		shape := name + ": " + typeName(fields[name].Type)
+		if fields[name].DefaultValue != nil {
+			shape += " = …"
+		}
+		shapes = append(shapes, shape)
+	}
+	return "{ " + strings.Join(shapes, ", ") + " }"
+}
+
+// typeName is the name of a type, ? when it is unknown
+func typeName(t types.Type) string {
+	if t == nil {
+		return "?"
+	}
+	return t.GetName()
+}
@@ -0,0 +1,75 @@
+package symbols
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// SymbolKind classifies a declaration for tools such as editors, which map it to
+// their own kinds, so that they agree on what a declaration is without each
+// switching on AST and type nodes
+type SymbolKind int
+
+const (
+	SymbolUnknown SymbolKind = iota
+	SymbolFunction
+	SymbolStruct
+	SymbolData
+	SymbolConstructor
+	SymbolTrait
+	SymbolMethod
+	SymbolVariable
+	SymbolConstant
+	SymbolTypeParameter
+	SymbolAlias
+)
+
+var symbolKindNames = [...]string{
+	SymbolUnknown:       "unknown",
+	SymbolFunction:      "function",
+	SymbolStruct:        "struct",
+	SymbolData:          "data",
+	SymbolConstructor:   "constructor",
+	SymbolTrait:         "trait",
+	SymbolMethod:        "method",
+	SymbolVariable:      "variable",
+	SymbolConstant:      "constant",
+	SymbolTypeParameter: "type parameter",
+	SymbolAlias:         "alias",
+}
+
+func (k SymbolKind) String() string {
+	if k < 0 || int(k) >= len(symbolKindNames) {
+		return symbolKindNames[SymbolUnknown]
+	}
+	return symbolKindNames[k]
+}
+
+// KindOf returns the kind of a declaration. Type declarations other than data
+// types and aliases count as structs, and a trait implementation as its trait; the methods of
+// an implementation are functions here, since the node alone does not tell, so
+// callers walking an ast.ImplStmt report them as SymbolMethod.
+func KindOf(node ast.AstNode) SymbolKind {
+	switch n := node.(type) {
+	case *ast.FunctionDefStmt:
+		return SymbolFunction
+	case *ast.TypeDeclStmt:
+		if n.IsAlias {
+			return SymbolAlias
+		}
+		if _, ok := n.Type.(types.DataType); ok {
+			return SymbolData
+		}
+		return SymbolStruct
+	case *ast.DataConstructorDecl:
+		return SymbolConstructor
+	case *ast.ImplStmt:
+		return SymbolTrait
+	case *ast.VarDeclStmt:
+		if n.IsConstant() {
+			return SymbolConstant
+		}
+		return SymbolVariable
+	}
+	return SymbolUnknown
+}
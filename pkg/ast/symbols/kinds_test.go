@@ -0,0 +1,33 @@
+package symbols
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestKindOf(t *testing.T) {
+	cases := []struct {
+		node     ast.AstNode
+		expected SymbolKind
+	}{
+		{&ast.FunctionDefStmt{Name: "area"}, SymbolFunction},
+		{&ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point"}}, SymbolStruct},
+		{&ast.TypeDeclStmt{Name: "Id", Type: types.PrimitiveType{Name: types.Int}}, SymbolStruct},
+		{&ast.TypeDeclStmt{Name: "Shape", Type: types.DataType{Name: "Shape"}}, SymbolData},
+		{&ast.DataConstructorDecl{Name: "Circle", DataType: "Shape"}, SymbolConstructor},
+		{&ast.ImplStmt{Trait: "Show", TypeName: "Point"}, SymbolTrait},
+		{&ast.VarDeclStmt{Keyword: "let", Name: "total"}, SymbolVariable},
+		{&ast.VarDeclStmt{Keyword: "const", Name: "origin"}, SymbolConstant},
+		{&ast.Program{}, SymbolUnknown},
+	}
+	for _, c := range cases {
+		if kind := KindOf(c.node); kind != c.expected {
+			t.Fatalf("Expected %T to be a %s. Got %s", c.node, c.expected, kind)
+		}
+	}
+	if SymbolTypeParameter.String() != "type parameter" || SymbolKind(99).String() != "unknown" {
+		t.Fatalf("Expected kinds to name themselves. Got %q and %q", SymbolTypeParameter, SymbolKind(99))
+	}
+}
@@ -0,0 +1,145 @@
+package symbols
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// IsPublic reports whether a declaration is pub, part of the API of its package
+func IsPublic(node ast.Named) bool {
+	return VisibilityOf(node) == ast.VisibilityPublic
+}
+
+// Public returns a table of the pub declarations of st, with the constructors
+// and trait implementations of its pub types
+func (st *SymbolTable) Public() *SymbolTable {
+	return st.VisibleAt(ast.VisibilityPublic)
+}
+
+// VisibleAt returns a table of the declarations of st seen at least as far as
+// level, with the constructors and trait implementations of its types:
+// VisibleAt(ast.VisibilityModule) is what the other files of its module see
+func (st *SymbolTable) VisibleAt(level ast.Visibility) *SymbolTable {
+	visible := NewSymbolTable()
+	for _, name := range sortedNames(st.GlobalScope.Symbols) {
+		if VisibilityOf(st.GlobalScope.Symbols[name]) >= level {
+			visible.Import(st, name)
+		}
+	}
+	return visible
+}
+
+// Import defines the declaration of name in from in st, with the constructors of
+// a data type and the trait implementations of a type
+func (st *SymbolTable) Import(from *SymbolTable, name string) error {
+	node, ok := from.GlobalScope.Symbols[name]
+	if !ok {
+		return fmt.Errorf("no symbol %s", name)
+	}
+	if err := st.GlobalScope.Define(node); err != nil {
+		return err
+	}
+	st.index(node)
+	for _, ctors := range from.Constructors {
+		for _, ctor := range ctors {
+			if ctor.DataType == name {
+				st.RegisterConstructor(ctor)
+			}
+		}
+	}
+	for _, impl := range from.TraitImpls[name] {
+		st.RegisterImpl(impl)
+	}
+	return nil
+}
+
+// index adds a declaration defined in the global scope to the quick lookup tables
+func (st *SymbolTable) index(node ast.Named) {
+	switch n := node.(type) {
+	case *ast.TypeDeclStmt:
+		st.Types[n.Name] = n
+	case *ast.FunctionDefStmt:
+		st.Functions[n.Name] = n
+	}
+}
+
+// Layer returns the table a file is checked against when it is one of several.
+// Names resolve in layers, each hiding the names of the layers after it: the
+// file's own declarations, then module (the declarations the other files of its
+// module make visible to it), imports (what the file brings in with use) and prelude.
+// The global scope of the result holds the file's declarations, and its parents
+// are a scope per layer, so Lookup walks them in that order. Any layer but the
+// file's may be nil.
+func Layer(file, module, imports, prelude *SymbolTable) *SymbolTable {
+	layered := NewSymbolTable()
+	var scope *Scope
+	layers := []struct {
+		table *SymbolTable
+		kind  ScopeKind
+	}{{prelude, ScopeGlobal}, {imports, ScopeImports}, {module, ScopeModule}, {file, ScopeFile}}
+	for _, layer := range layers {
+		scope = NewScope(scope, layer.kind)
+		if layer.table == nil {
+			continue
+		}
+		for _, name := range sortedNames(layer.table.GlobalScope.Symbols) {
+			node := layer.table.GlobalScope.Symbols[name]
+			scope.Symbols[name] = node
+			// the declaration hides those of the layers before
+			delete(layered.Types, name)
+			delete(layered.Functions, name)
+			layered.removeImpls(name)
+			for ctorName, ctors := range layered.Constructors {
+				kept := ctors[:0:0]
+				for _, ctor := range ctors {
+					if ctor.DataType != name {
+						kept = append(kept, ctor)
+					}
+				}
+				if len(kept) == 0 {
+					delete(layered.Constructors, ctorName)
+				} else {
+					layered.Constructors[ctorName] = kept
+				}
+			}
+			layered.index(node)
+		}
+		for _, ctorName := range sortedNames(layer.table.Constructors) {
+			for _, ctor := range layer.table.Constructors[ctorName] {
+				layered.RegisterConstructor(ctor)
+			}
+		}
+		for _, typeName := range sortedNames(layer.table.TraitImpls) {
+			for _, impl := range layer.table.TraitImpls[typeName] {
+				layered.RegisterImpl(impl)
+			}
+		}
+	}
+	layered.GlobalScope = scope
+	return layered
+}
+
+// Visible returns the symbols a scope and its parents define, each name bound as
+// Lookup resolves it
+func (s *Scope) Visible() map[string]ast.Named {
+	visible := make(map[string]ast.Named)
+	for scope := s; scope != nil; scope = scope.Parent {
+		for name, node := range scope.Symbols {
+			if _, hidden := visible[name]; !hidden {
+				visible[name] = node
+			}
+		}
+	}
+	return visible
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,8 +2,12 @@ package symbols
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
 )
 
 // Scope represents a lexical scope
@@ -22,6 +26,8 @@ const (
 	ScopeFunction
 	ScopeBlock
 	ScopeLoop
+	ScopeFile    // the declarations of one file of several, see Layer
+	ScopeImports // the symbols a file imports with use
 )
 
 func NewScope(parent *Scope, kind ScopeKind) *Scope {
@@ -72,13 +78,56 @@ type SymbolTable struct {
 	// Quick lookup tables - these point to AST nodes directly
 	Types     map[string]*ast.TypeDeclStmt
 	Functions map[string]*ast.FunctionDefStmt
+
+	// Constructors live in their data type's namespace rather than the global scope,
+	// so a constructor may share its name with its type (e.g. Point = Point(Int, Int)).
+	// Keyed by unqualified name; several data types may declare the same name.
+	Constructors map[string][]*ast.DataConstructorDecl
+
+	// TraitImpls holds the trait implementations of each type, by type name, in
+	// the order they were registered
+	TraitImpls map[string][]*ast.ImplStmt
+
+	methods methodCache // LookupMethod results until the implementations of their type change
+}
+
+// methodCache holds the implementations defining each method of each type, for
+// member accesses checked again and again (every hover and completion checks
+// the expressions around it anew)
+type methodCache struct {
+	mu      sync.Mutex
+	entries map[methodKey][]*ast.ImplStmt
+	stats   MethodCacheStats
+}
+
+type methodKey struct {
+	typeName, method string
+}
+
+// MethodCacheStats counts the method lookups of a table: those answered from
+// the cache, those that walked the implementations, and the entries dropped
+// because an implementation of their type was registered or removed
+type MethodCacheStats struct {
+	Hits          int
+	Misses        int
+	Invalidations int
+}
+
+// HitRate is the share of lookups answered from the cache, 0 before any
+func (s MethodCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
 }
 
 func NewSymbolTable() *SymbolTable {
 	return &SymbolTable{
-		GlobalScope: NewScope(nil, ScopeGlobal),
-		Types:       make(map[string]*ast.TypeDeclStmt),
-		Functions:   make(map[string]*ast.FunctionDefStmt),
+		GlobalScope:  NewScope(nil, ScopeGlobal),
+		Types:        make(map[string]*ast.TypeDeclStmt),
+		Functions:    make(map[string]*ast.FunctionDefStmt),
+		Constructors: make(map[string][]*ast.DataConstructorDecl),
+		TraitImpls:   make(map[string][]*ast.ImplStmt),
 	}
 }
 
@@ -100,7 +149,200 @@ func (st *SymbolTable) RegisterFunction(node *ast.FunctionDefStmt) error {
 	return nil
 }
 
+// RegisterConstructor adds a data constructor to the symbol table.
+// Different data types may declare constructors with the same name (Maybe.Nil, Tree.Nil);
+// those are kept side by side and disambiguated by ResolveConstructor.
+func (st *SymbolTable) RegisterConstructor(node *ast.DataConstructorDecl) error {
+	for _, existing := range st.Constructors[node.Name] {
+		if existing.DataType == node.DataType {
+			return fmt.Errorf("constructor %s.%s already defined at %v", node.DataType, node.Name, existing.GetLocation())
+		}
+	}
+	st.Constructors[node.Name] = append(st.Constructors[node.Name], node)
+	return nil
+}
+
+// LookupConstructor returns every constructor declared with the given unqualified name
+func (st *SymbolTable) LookupConstructor(name string) []*ast.DataConstructorDecl {
+	return st.Constructors[name]
+}
+
+// LookupQualifiedConstructor finds a constructor by its data type and name (Maybe.Nil)
+func (st *SymbolTable) LookupQualifiedConstructor(dataType, name string) (*ast.DataConstructorDecl, bool) {
+	for _, ctor := range st.Constructors[name] {
+		if ctor.DataType == dataType {
+			return ctor, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveConstructor resolves a constructor reference using these rules:
+//  1. a qualified name (Maybe.Nil) always resolves to that type's constructor
+//  2. an unqualified name declared by a single data type resolves to it
+//  3. otherwise the expected type (if known) picks the candidate of that data type
+//  4. anything left is ambiguous and reported with the list of candidates
+func (st *SymbolTable) ResolveConstructor(name string, expected types.Type) (*ast.DataConstructorDecl, error) {
+	if dataType, ctorName, ok := strings.Cut(name, "."); ok {
+		if ctor, ok := st.LookupQualifiedConstructor(dataType, ctorName); ok {
+			return ctor, nil
+		}
+		return nil, fmt.Errorf("data type %s has no constructor %s", dataType, ctorName)
+	}
+
+	candidates := st.Constructors[name]
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("undefined constructor: %s", name)
+	case 1:
+		return candidates[0], nil
+	}
+
+	if expected != nil {
+		for _, ctor := range candidates {
+			if ctor.DataType == expected.GetName() {
+				return ctor, nil
+			}
+		}
+	}
+	return nil, &AmbiguousConstructorError{Name: name, Candidates: candidates}
+}
+
+// AmbiguousConstructorError is reported when an unqualified constructor name
+// matches constructors of several data types and nothing disambiguates it
+type AmbiguousConstructorError struct {
+	Name       string
+	Candidates []*ast.DataConstructorDecl
+}
+
+func (e *AmbiguousConstructorError) Error() string {
+	candidates := make([]string, len(e.Candidates))
+	for i, ctor := range e.Candidates {
+		loc := ctor.GetLocation()
+		candidates[i] = fmt.Sprintf("%s.%s (line %d)", ctor.DataType, ctor.Name, loc.StartLine)
+	}
+	return fmt.Sprintf("ambiguous constructor %q: candidates are %s; qualify it (e.g. %s.%s) or annotate the expected type",
+		e.Name, strings.Join(candidates, ", "), e.Candidates[0].DataType, e.Name)
+}
+
+// RegisterImpl adds a trait implementation to the symbol table. A type
+// implements each trait once.
+func (st *SymbolTable) RegisterImpl(node *ast.ImplStmt) error {
+	for _, existing := range st.TraitImpls[node.TypeName] {
+		if existing.Trait == node.Trait {
+			return fmt.Errorf("%s already implements %s at %v", node.TypeName, node.Trait, existing.GetLocation())
+		}
+	}
+	st.TraitImpls[node.TypeName] = append(st.TraitImpls[node.TypeName], node)
+	st.methods.invalidate(node.TypeName)
+	return nil
+}
+
+// removeImpls forgets the trait implementations of a type
+func (st *SymbolTable) removeImpls(typeName string) {
+	delete(st.TraitImpls, typeName)
+	st.methods.invalidate(typeName)
+}
+
+// LookupMethod returns the implementations of typeName defining a method named
+// name, one per trait; more than one makes a call of it ambiguous. Results are
+// cached until an implementation of typeName is registered.
+func (st *SymbolTable) LookupMethod(typeName, name string) []*ast.ImplStmt {
+	st.methods.mu.Lock()
+	defer st.methods.mu.Unlock()
+	key := methodKey{typeName, name}
+	if impls, ok := st.methods.entries[key]; ok {
+		st.methods.stats.Hits++
+		return impls
+	}
+	st.methods.stats.Misses++
+	var impls []*ast.ImplStmt
+	for _, impl := range st.TraitImpls[typeName] {
+		if impl.Method(name) != nil {
+			impls = append(impls, impl)
+		}
+	}
+	if st.methods.entries == nil {
+		st.methods.entries = make(map[methodKey][]*ast.ImplStmt)
+	}
+	st.methods.entries[key] = impls
+	return impls
+}
+
+// MethodCacheStats returns the counters of the method lookups of the table
+func (st *SymbolTable) MethodCacheStats() MethodCacheStats {
+	st.methods.mu.Lock()
+	defer st.methods.mu.Unlock()
+	return st.methods.stats
+}
+
+// invalidate drops the cached methods of a type
+func (m *methodCache) invalidate(typeName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.entries {
+		if key.typeName == typeName {
+			delete(m.entries, key)
+			m.stats.Invalidations++
+		}
+	}
+}
+
 // RegisterVariable adds a variable to the current scope
 func (st *SymbolTable) RegisterVariable(node *ast.VarDeclStmt) error {
 	return st.GlobalScope.Define(node)
 }
+
+// Merge defines the global symbols, constructors and trait implementations of
+// other in st, so that a table can hold the declarations of several files. It
+// returns the symbols of other whose names st already defines; those and their
+// constructors are left out.
+func (st *SymbolTable) Merge(other *SymbolTable) []ast.Named {
+	names := make([]string, 0, len(other.GlobalScope.Symbols))
+	for name := range other.GlobalScope.Symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var clashes []ast.Named
+	clashing := make(map[string]bool)
+	for _, name := range names {
+		node := other.GlobalScope.Symbols[name]
+		if err := st.GlobalScope.Define(node); err != nil {
+			clashes = append(clashes, node)
+			clashing[name] = true
+			continue
+		}
+		switch n := node.(type) {
+		case *ast.TypeDeclStmt:
+			st.Types[name] = n
+		case *ast.FunctionDefStmt:
+			st.Functions[name] = n
+		}
+	}
+
+	ctorNames := make([]string, 0, len(other.Constructors))
+	for name := range other.Constructors {
+		ctorNames = append(ctorNames, name)
+	}
+	sort.Strings(ctorNames)
+	for _, name := range ctorNames {
+		for _, ctor := range other.Constructors[name] {
+			if !clashing[ctor.DataType] {
+				st.RegisterConstructor(ctor)
+			}
+		}
+	}
+
+	typeNames := make([]string, 0, len(other.TraitImpls))
+	for name := range other.TraitImpls {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		for _, impl := range other.TraitImpls[name] {
+			st.RegisterImpl(impl)
+		}
+	}
+	return clashes
+}
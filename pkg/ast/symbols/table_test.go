@@ -0,0 +1,283 @@
+package symbols
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestSymbolTable_RegisterConstructor(t *testing.T) {
+	table := NewSymbolTable()
+	maybe := types.DataType{Name: "Maybe"}
+
+	some := &ast.DataConstructorDecl{
+		Name:     "Some",
+		DataType: "Maybe",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: types.GenericType{Name: "t"}}},
+			ReturnType:     maybe,
+		},
+	}
+	if err := table.RegisterConstructor(some); err != nil {
+		t.Fatalf("RegisterConstructor error: %v", err)
+	}
+
+	ctor, err := table.ResolveConstructor("Some", nil)
+	if err != nil {
+		t.Fatalf("\"Some\" not resolved: %v", err)
+	}
+	if !types.TypesEqual(ctor.Signature.ReturnType, maybe) {
+		t.Fatalf("\"Some\" should return Maybe. Got %v", ctor.Signature.ReturnType)
+	}
+
+	// constructors live in their type's namespace, so a type may share a constructor's name
+	if err := table.RegisterType(&ast.TypeDeclStmt{Name: "Some"}); err != nil {
+		t.Fatalf("RegisterType should not conflict with constructor: %v", err)
+	}
+}
+
+func TestSymbolTable_ConstructorNameConflicts(t *testing.T) {
+	table := NewSymbolTable()
+	maybeNil := &ast.DataConstructorDecl{Name: "Nil", DataType: "Maybe", Signature: &types.FunctionType{ReturnType: types.DataType{Name: "Maybe"}}}
+	treeNil := &ast.DataConstructorDecl{Name: "Nil", DataType: "Tree", Signature: &types.FunctionType{ReturnType: types.DataType{Name: "Tree"}}}
+	for _, ctor := range []*ast.DataConstructorDecl{maybeNil, treeNil} {
+		if err := table.RegisterConstructor(ctor); err != nil {
+			t.Fatalf("RegisterConstructor error: %v", err)
+		}
+	}
+
+	// the same data type may not declare a constructor twice
+	if err := table.RegisterConstructor(&ast.DataConstructorDecl{Name: "Nil", DataType: "Tree"}); err == nil {
+		t.Fatalf("Expected duplicate Tree.Nil to be rejected")
+	}
+
+	ctor, err := table.ResolveConstructor("Tree.Nil", nil)
+	if err != nil || ctor != treeNil {
+		t.Fatalf("Qualified Tree.Nil should resolve to the Tree constructor. Got %v, %v", ctor, err)
+	}
+
+	ctor, err = table.ResolveConstructor("Nil", types.DataType{Name: "Maybe"})
+	if err != nil || ctor != maybeNil {
+		t.Fatalf("Nil with expected type Maybe should resolve to Maybe.Nil. Got %v, %v", ctor, err)
+	}
+
+	_, err = table.ResolveConstructor("Nil", nil)
+	ambiguous, ok := err.(*AmbiguousConstructorError)
+	if !ok {
+		t.Fatalf("Expected AmbiguousConstructorError. Got %v", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Fatalf("Expected 2 candidates. Got %d", len(ambiguous.Candidates))
+	}
+	expectedMessage := `ambiguous constructor "Nil": candidates are Maybe.Nil (line 0), Tree.Nil (line 0); qualify it (e.g. Maybe.Nil) or annotate the expected type`
+	if err.Error() != expectedMessage {
+		t.Fatalf("Unexpected message. Expected %q. Got %q", expectedMessage, err.Error())
+	}
+
+	if _, err := table.ResolveConstructor("Maybe.Leaf", nil); err == nil {
+		t.Fatalf("Expected Maybe.Leaf to be undefined")
+	}
+}
+
+func TestSymbolTable_Merge(t *testing.T) {
+	shapes := NewSymbolTable()
+	shapes.RegisterType(&ast.TypeDeclStmt{Name: "Shape", Type: types.DataType{Name: "Shape"}})
+	shapes.RegisterConstructor(&ast.DataConstructorDecl{Name: "Circle", DataType: "Shape"})
+	shapes.RegisterFunction(&ast.FunctionDefStmt{Name: "area"})
+
+	main := NewSymbolTable()
+	main.RegisterFunction(&ast.FunctionDefStmt{Name: "main"})
+	main.RegisterVariable(&ast.VarDeclStmt{Keyword: "let", Name: "area"})
+
+	merged := NewSymbolTable()
+	if clashes := merged.Merge(shapes); len(clashes) != 0 {
+		t.Fatalf("Expected no clashes merging into an empty table. Got %v", clashes)
+	}
+	clashes := merged.Merge(main)
+	if len(clashes) != 1 || clashes[0].GetName() != "area" {
+		t.Fatalf("Expected area to clash. Got %v", clashes)
+	}
+	if _, ok := merged.Functions["main"]; !ok {
+		t.Fatalf("Expected main merged")
+	}
+	if _, ok := merged.Types["Shape"]; !ok {
+		t.Fatalf("Expected Shape merged")
+	}
+	if ctor, err := merged.ResolveConstructor("Circle", nil); err != nil || ctor.DataType != "Shape" {
+		t.Fatalf("Expected Circle to resolve to Shape.Circle. Got %v, %v", ctor, err)
+	}
+	if _, isFunction := merged.GlobalScope.Symbols["area"].(*ast.FunctionDefStmt); !isFunction {
+		t.Fatalf("Expected the first area declared to be kept")
+	}
+}
+
+func TestSymbolTable_LookupMethodCache(t *testing.T) {
+	table := NewSymbolTable()
+	show := &ast.ImplStmt{Trait: "Show", TypeName: "Point", Methods: []*ast.FunctionDefStmt{{Name: "show"}}}
+	table.RegisterImpl(show)
+
+	for i := 0; i < 3; i++ {
+		if impls := table.LookupMethod("Point", "show"); len(impls) != 1 || impls[0] != show {
+			t.Fatalf("Expected Point.show in Show. Got %v", impls)
+		}
+	}
+	table.LookupMethod("Point", "draw")
+	stats := table.MethodCacheStats()
+	if stats.Hits != 2 || stats.Misses != 2 || stats.HitRate() != 0.5 {
+		t.Fatalf("Expected 2 hits and 2 misses. Got %+v", stats)
+	}
+
+	// an implementation of Point drops its cached methods, found or not
+	draw := &ast.ImplStmt{Trait: "Draw", TypeName: "Point", Methods: []*ast.FunctionDefStmt{{Name: "draw"}}}
+	table.RegisterImpl(draw)
+	if impls := table.LookupMethod("Point", "draw"); len(impls) != 1 || impls[0] != draw {
+		t.Fatalf("Expected Point.draw in Draw once Draw is implemented. Got %v", impls)
+	}
+	stats = table.MethodCacheStats()
+	if stats.Invalidations != 2 || stats.Misses != 3 {
+		t.Fatalf("Expected both entries of Point invalidated. Got %+v", stats)
+	}
+}
+
+func TestLayer(t *testing.T) {
+	prelude := NewSymbolTable()
+	prelude.RegisterVariable(&ast.VarDeclStmt{Keyword: "let", Name: "pi"})
+	prelude.RegisterFunction(&ast.FunctionDefStmt{Name: "print"})
+	prelude.RegisterFunction(&ast.FunctionDefStmt{Name: "area"})
+
+	shapes := NewSymbolTable()
+	shapes.RegisterType(&ast.TypeDeclStmt{Name: "Shape", IsPublic: true, Type: types.DataType{Name: "Shape"}})
+	shapes.RegisterConstructor(&ast.DataConstructorDecl{Name: "Circle", DataType: "Shape"})
+	shapes.RegisterFunction(&ast.FunctionDefStmt{Name: "area", IsPublic: true})
+	shapes.RegisterFunction(&ast.FunctionDefStmt{Name: "helper"})
+	imports := NewSymbolTable()
+	for _, name := range []string{"area", "Shape"} {
+		if err := imports.Import(shapes.Public(), name); err != nil {
+			t.Fatalf("Import error: %v", err)
+		}
+	}
+	if _, err := imports.ResolveConstructor("Circle", nil); err != nil {
+		t.Fatalf("Expected Circle imported with Shape. Got %v", err)
+	}
+	if err := imports.Import(shapes.Public(), "helper"); err == nil {
+		t.Fatalf("Expected the private helper not to be importable")
+	}
+
+	module := NewSymbolTable()
+	module.RegisterType(&ast.TypeDeclStmt{Name: "Shape", IsPublic: true, Type: types.DataType{Name: "Shape"}})
+	module.RegisterConstructor(&ast.DataConstructorDecl{Name: "Square", DataType: "Shape"})
+	module.RegisterFunction(&ast.FunctionDefStmt{Name: "print", IsPublic: true})
+
+	file := NewSymbolTable()
+	file.RegisterVariable(&ast.VarDeclStmt{Keyword: "let", Name: "pi"})
+
+	layered := Layer(file, module, imports, prelude)
+	layers := map[string]ScopeKind{"pi": ScopeFile, "print": ScopeModule, "area": ScopeImports, "Shape": ScopeModule}
+	for name, kind := range layers {
+		named, _ := layered.GlobalScope.Lookup(name)
+		scope := layered.GlobalScope
+		for scope != nil && scope.Symbols[name] != named {
+			scope = scope.Parent
+		}
+		if scope == nil || scope.Kind != kind {
+			t.Fatalf("Expected %s to resolve in the %v layer. Got %v", name, kind, scope)
+		}
+	}
+	if fn := layered.Functions["area"]; fn != shapes.Functions["area"] {
+		t.Fatalf("Expected the imported area to hide the prelude's")
+	}
+	if _, ok := layered.Functions["pi"]; ok {
+		t.Fatalf("Expected no function pi")
+	}
+	if _, err := layered.ResolveConstructor("Circle", nil); err == nil {
+		t.Fatalf("Expected the module's Shape to hide the constructors of the imported one")
+	}
+	if ctor, err := layered.ResolveConstructor("Square", nil); err != nil || ctor.DataType != "Shape" {
+		t.Fatalf("Expected Square to resolve. Got %v, %v", ctor, err)
+	}
+	if visible := layered.GlobalScope.Visible(); len(visible) != 4 || visible["pi"] != file.GlobalScope.Symbols["pi"] {
+		t.Fatalf("Expected the 4 names visible, pi from the file. Got %v", visible)
+	}
+}
+
+func TestScope_Sees(t *testing.T) {
+	declared := NewSymbolTable()
+	declared.RegisterFunction(&ast.FunctionDefStmt{Name: "helper", Visibility: ast.VisibilityModule})
+	declared.RegisterFunction(&ast.FunctionDefStmt{Name: "area", Visibility: ast.VisibilityPackage})
+	declared.RegisterFunction(&ast.FunctionDefStmt{Name: "scale", IsPublic: true})
+	declared.RegisterFunction(&ast.FunctionDefStmt{Name: "secret"})
+	if visible := sortedNames(declared.VisibleAt(ast.VisibilityPackage).Functions); !reflect.DeepEqual(visible, []string{"area", "scale"}) {
+		t.Fatalf("Expected area and scale visible to the package. Got %v", visible)
+	}
+	if public := sortedNames(declared.Public().Functions); !reflect.DeepEqual(public, []string{"scale"}) {
+		t.Fatalf("Expected only scale public. Got %v", public)
+	}
+
+	layered := Layer(NewSymbolTable(), declared, declared, nil)
+	for name, seen := range map[string]bool{"helper": true, "area": true, "scale": true, "secret": false, "unknown": true} {
+		if layered.GlobalScope.Sees(name) != seen {
+			t.Fatalf("Expected Sees(%s) to be %v", name, seen)
+		}
+	}
+	layered = Layer(NewSymbolTable(), nil, declared, nil)
+	if layered.GlobalScope.Sees("helper") || !layered.GlobalScope.Sees("area") {
+		t.Fatalf("Expected imports seen from pub(package) on")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	intType := types.PrimitiveType{Name: types.Int}
+	sum := func(params int, public bool) *ast.FunctionDefStmt {
+		signature := &types.FunctionType{ReturnType: intType}
+		for range params {
+			signature.ParameterTypes = append(signature.ParameterTypes, types.ParameterType{Type: intType})
+		}
+		return &ast.FunctionDefStmt{Name: "sum", IsPublic: public, Signature: signature}
+	}
+	point := func(fields ...string) *ast.TypeDeclStmt {
+		structType := types.StructType{Name: "Point", Fields: map[string]types.StructField{}}
+		for _, field := range fields {
+			structType.Fields[field] = types.StructField{Name: field, Type: intType}
+		}
+		return &ast.TypeDeclStmt{Name: "Point", Type: structType}
+	}
+
+	before := NewSymbolTable()
+	before.RegisterFunction(sum(2, true))
+	before.RegisterType(point("x", "y"))
+	before.RegisterFunction(&ast.FunctionDefStmt{Name: "helper"})
+	before.RegisterVariable(&ast.VarDeclStmt{Keyword: "let", Name: "answer", Type: intType})
+
+	// a new body or location changes nothing
+	same := NewSymbolTable()
+	same.RegisterFunction(&ast.FunctionDefStmt{Name: "sum", IsPublic: true, Signature: sum(2, true).Signature,
+		Clauses: []*ast.FunctionClause{{Body: &ast.IntegerLiteralExpr{Value: 1}}}})
+	same.RegisterType(point("y", "x"))
+	same.RegisterFunction(&ast.FunctionDefStmt{Name: "helper", AstBase: ast.AstBase{Location: ast.Location{StartLine: 9}}})
+	same.RegisterVariable(&ast.VarDeclStmt{Keyword: "let", Name: "answer", Type: intType})
+	if diff := Diff(before, same); !diff.Empty() {
+		t.Fatalf("Expected no differences. Got %+v", diff)
+	}
+
+	after := NewSymbolTable()
+	after.RegisterFunction(sum(3, true))
+	after.RegisterType(point("x", "y", "z"))
+	after.RegisterVariable(&ast.VarDeclStmt{Keyword: "let", Name: "answer", Type: intType})
+	after.RegisterFunction(&ast.FunctionDefStmt{Name: "main"})
+	diff := Diff(before, after)
+	expected := TableDiff{Added: []string{"main"}, Removed: []string{"helper"}, Changed: []string{"Point", "sum"}}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("Expected %+v. Got %+v", expected, diff)
+	}
+	if names := diff.Names(); !reflect.DeepEqual(names, []string{"Point", "helper", "main", "sum"}) {
+		t.Fatalf("Expected the names sorted. Got %v", names)
+	}
+
+	private := NewSymbolTable()
+	private.RegisterFunction(sum(2, false))
+	if diff := Diff(before.Public(), private.Public()); !reflect.DeepEqual(diff.Removed, []string{"sum"}) {
+		t.Fatalf("Expected sum gone from the public declarations. Got %+v", diff)
+	}
+}
@@ -0,0 +1,44 @@
+package symbols
+
+import "github.com/Lyra-Language/lyra/pkg/ast"
+
+// VisibilityOf returns how far beyond the file declaring it a declaration is
+// seen. Functions and types say so with their modifier; other declarations, top-
+// level bindings among them, are private to their file.
+func VisibilityOf(node ast.Named) ast.Visibility {
+	switch n := node.(type) {
+	case *ast.FunctionDefStmt:
+		return visibility(n.IsPublic, n.Visibility)
+	case *ast.TypeDeclStmt:
+		return visibility(n.IsPublic, n.Visibility)
+	}
+	return ast.VisibilityFile
+}
+
+func visibility(isPublic bool, declared ast.Visibility) ast.Visibility {
+	if isPublic {
+		return ast.VisibilityPublic
+	}
+	return declared
+}
+
+// Sees reports whether the file of a layered scope sees the declaration Lookup
+// finds for name: one of the other files of its module must be pub(module) or
+// wider, an import pub(package) or wider. Names no layer declares are seen, as
+// are those of unlayered tables.
+func (s *Scope) Sees(name string) bool {
+	for scope := s; scope != nil; scope = scope.Parent {
+		node, ok := scope.Symbols[name]
+		if !ok {
+			continue
+		}
+		switch scope.Kind {
+		case ScopeModule:
+			return VisibilityOf(node) >= ast.VisibilityModule
+		case ScopeImports:
+			return VisibilityOf(node) >= ast.VisibilityPackage
+		}
+		return true
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Visibility is how far beyond its file a declaration is seen, each level
+// including the ones before: pub(module) by the other files of its module,
+// pub(package) by every module of the package, pub also by other packages, as
+// part of the package's API
+type Visibility int
+
+const (
+	VisibilityFile Visibility = iota
+	VisibilityModule
+	VisibilityPackage
+	VisibilityPublic
+)
+
+// String renders the modifier declaring the visibility, "" for the file's own
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityModule:
+		return "pub(module)"
+	case VisibilityPackage:
+		return "pub(package)"
+	case VisibilityPublic:
+		return "pub"
+	}
+	return ""
+}
+
+// ParseVisibility reads a visibility modifier, pub, pub(module) or pub(package),
+// ignoring the spaces around its scope
+func ParseVisibility(text string) (Visibility, error) {
+	switch strings.Join(strings.Fields(text), "") {
+	case "pub":
+		return VisibilityPublic, nil
+	case "pub(module)":
+		return VisibilityModule, nil
+	case "pub(package)":
+		return VisibilityPackage, nil
+	}
+	return VisibilityFile, fmt.Errorf("unknown visibility %s (expected pub, pub(module) or pub(package))", text)
+}
@@ -0,0 +1,163 @@
+package configeval
+
+/*
+Configeval evaluates a Lyra file as typed configuration (lyra eval): the file
+declares types, constants and helper functions, and its final statement is an
+expression whose value, a struct or data value, is the configuration. Evaluation
+must be pure so the same file always yields the same configuration: calls to
+async and extern functions and to debug() are rejected before anything runs.
+The value is written as JSON or YAML.
+*/
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+)
+
+// ImpureCall is a call that could make the configuration differ between runs
+type ImpureCall struct {
+	Callee   string
+	Reason   string
+	Location ast.Location
+}
+
+func (e ImpureCall) Error() string {
+	return fmt.Sprintf("%d:%d: configuration must be pure: %s %s", e.Location.StartLine, e.Location.StartCol, e.Callee, e.Reason)
+}
+
+// Evaluate checks that the analyzed file is a pure configuration and returns the
+// value of its final expression
+func Evaluate(result *analyzer.Result) (interp.Value, error) {
+	if err := result.FirstError(); err != nil {
+		return nil, err
+	}
+	statements := result.Program.Statements
+	var final *ast.ExpressionStmt
+	if len(statements) > 0 {
+		final, _ = statements[len(statements)-1].(*ast.ExpressionStmt)
+	}
+	if final == nil {
+		return nil, errors.New("a configuration file must end with an expression")
+	}
+	if impure := ImpureCalls(result.Program); len(impure) > 0 {
+		return nil, errors.Join(impure...)
+	}
+
+	in := interp.New(result.Program, result.Table)
+	in.SetOwnership(result.Ownership)
+	var value interp.Value
+	in.ObserveExpressions(func(stmt *ast.ExpressionStmt, v interp.Value) {
+		if stmt == final {
+			value = v
+		}
+	})
+	if err := in.Init(); err != nil {
+		return nil, err
+	}
+	switch value.(type) {
+	case *interp.StructValue, *interp.DataValue:
+		return value, nil
+	}
+	return nil, fmt.Errorf("%d:%d: the configuration must be a struct or data value, not %s",
+		final.Location.StartLine, final.Location.StartCol, interp.FormatValue(value))
+}
+
+// ImpureCalls lists the calls of a program to async and extern functions and to debug()
+func ImpureCalls(program *ast.Program) []error {
+	functions := make(map[string]*ast.FunctionDefStmt)
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*ast.FunctionDefStmt); ok {
+			functions[fn.Name] = fn
+		}
+	}
+
+	var impure []error
+	visit := func(expr ast.Expression) {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		callee, ok := call.Callee.(*ast.IdentifierExpr)
+		if !ok {
+			return
+		}
+		fn, declared := functions[callee.Name]
+		switch {
+		case declared && fn.IsExtern():
+			impure = append(impure, ImpureCall{callee.Name, "is implemented by the host", call.Location})
+		case declared && fn.IsAsync:
+			impure = append(impure, ImpureCall{callee.Name, "is async", call.Location})
+		case !declared && callee.Name == "debug":
+			impure = append(impure, ImpureCall{callee.Name, "prints", call.Location})
+		}
+	}
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			walk(s.Value, visit)
+		case *ast.VarAssignStmt:
+			walk(s.Value, visit)
+		case *ast.ExpressionStmt:
+			walk(s.Expression, visit)
+		case *ast.FunctionDefStmt:
+			for _, clause := range s.Clauses {
+				if clause.Guard != nil {
+					walk(clause.Guard.Condition, visit)
+				}
+				walk(clause.Body, visit)
+			}
+		}
+	}
+	return impure
+}
+
+// walk calls visit for expr and every expression within it
+func walk(expr ast.Expression, visit func(ast.Expression)) {
+	if expr == nil {
+		return
+	}
+	visit(expr)
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		walk(e.Callee, visit)
+		for _, argument := range e.Arguments {
+			walk(argument, visit)
+		}
+	case *ast.BinaryOpExpr:
+		walk(e.Left, visit)
+		walk(e.Right, visit)
+	case *ast.BooleanBinaryOpExpr:
+		walk(e.Left, visit)
+		walk(e.Right, visit)
+	case *ast.GuardExpr:
+		walk(e.Condition, visit)
+	case *ast.IfThenExpr:
+		walk(e.Condition, visit)
+		walk(e.Then, visit)
+		walk(e.Else, visit)
+	case *ast.IfBlockExpr:
+		walk(e.Condition, visit)
+		walk(e.Then, visit)
+		walk(e.Else, visit)
+	case *ast.MemberAccessExpr:
+		walk(e.Object, visit)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			walk(field.Value, visit)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			walk(element, visit)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			walk(element, visit)
+		}
+	case *ast.LambdaExpr:
+		walk(e.Body, visit)
+	}
+}
@@ -0,0 +1,118 @@
+package configeval
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func analyzed(t *testing.T, statements ...ast.AstNode) *analyzer.Result {
+	t.Helper()
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements {
+		var err error
+		switch s := stmt.(type) {
+		case *ast.TypeDeclStmt:
+			err = table.RegisterType(s)
+		case *ast.FunctionDefStmt:
+			err = table.RegisterFunction(s)
+		case *ast.VarDeclStmt:
+			err = table.RegisterVariable(s)
+		}
+		if err != nil {
+			t.Fatalf("register error: %v", err)
+		}
+	}
+	return &analyzer.Result{Program: &ast.Program{Statements: statements}, Table: table}
+}
+
+func TestEvaluate_StructConfig(t *testing.T) {
+	// struct Server { host: String, port: Int, tags: ... }
+	// const base: Int = 8000
+	// Server { host: "localhost", port: base + 80 }
+	server := &ast.TypeDeclStmt{Name: "Server", Type: types.StructType{Name: "Server", Fields: map[string]types.StructField{
+		"host": {Name: "host", Type: types.PrimitiveType{Name: types.String}},
+		"port": {Name: "port", Type: intType},
+	}}}
+	base := &ast.VarDeclStmt{Keyword: "const", Name: "base", Type: intType, Value: &ast.IntegerLiteralExpr{Value: 8000}}
+	config := &ast.ExpressionStmt{Expression: &ast.StructLiteralExpr{TypeName: "Server", Fields: []*ast.StructLiteralField{
+		{Name: "host", Value: &ast.StringLiteralExpr{Value: "localhost"}},
+		{Name: "port", Value: &ast.BinaryOpExpr{Left: &ast.IdentifierExpr{Name: "base"}, Operator: "+", Right: &ast.IntegerLiteralExpr{Value: 80}}},
+	}}}
+
+	value, err := Evaluate(analyzed(t, server, base, config))
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	var out bytes.Buffer
+	if err := WriteJSON(&out, value); err != nil {
+		t.Fatalf("WriteJSON error: %v", err)
+	}
+	if expected := "{\n  \"host\": \"localhost\",\n  \"port\": 8080\n}\n"; out.String() != expected {
+		t.Fatalf("Expected %q. Got %q", expected, out.String())
+	}
+}
+
+func TestEvaluate_RejectsImpureCalls(t *testing.T) {
+	now := &ast.FunctionDefStmt{Name: "now", Extern: "go:time.UnixNano", Signature: &types.FunctionType{ReturnType: intType}}
+	fetch := &ast.FunctionDefStmt{Name: "fetch", IsAsync: true, Signature: &types.FunctionType{ReturnType: intType},
+		Clauses: []*ast.FunctionClause{{Body: &ast.IntegerLiteralExpr{Value: 1}}}}
+	call := func(name string, line int, args ...ast.Expression) *ast.CallExpr {
+		return &ast.CallExpr{
+			ExprBase:  ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: 1}}},
+			Callee:    &ast.IdentifierExpr{Name: name},
+			Arguments: args,
+		}
+	}
+	started := &ast.VarDeclStmt{Keyword: "let", Name: "started", Value: call("now", 3)}
+	config := &ast.ExpressionStmt{Expression: call("debug", 4, call("fetch", 4))}
+
+	_, err := Evaluate(analyzed(t, now, fetch, started, config))
+	if err == nil {
+		t.Fatalf("Expected the impure calls to be rejected")
+	}
+	expected := "3:1: configuration must be pure: now is implemented by the host\n" +
+		"4:1: configuration must be pure: debug prints\n" +
+		"4:1: configuration must be pure: fetch is async"
+	if err.Error() != expected {
+		t.Fatalf("Expected %q. Got %q", expected, err.Error())
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	value := &interp.StructValue{Type: "Deploy", Fields: map[string]interp.Value{
+		"name":     "api: v2",
+		"replicas": int64(3),
+		"ports":    &interp.ArrayValue{Elements: []interp.Value{int64(80), int64(443)}},
+		"strategy": &interp.DataValue{Type: "Strategy", Constructor: "Rolling", Fields: map[string]interp.Value{"surge": 0.25}},
+		"region":   &interp.DataValue{Type: "Region", Constructor: "Europe"},
+		"volumes":  &interp.ArrayValue{},
+	}}
+	var out bytes.Buffer
+	if err := WriteYAML(&out, value); err != nil {
+		t.Fatalf("WriteYAML error: %v", err)
+	}
+	expected := strings.Join([]string{
+		`name: "api: v2"`,
+		`ports:`,
+		`  - 80`,
+		`  - 443`,
+		`region: "Europe"`,
+		`replicas: 3`,
+		`strategy:`,
+		`  Rolling:`,
+		`    surge: 0.25`,
+		`volumes: []`,
+	}, "\n") + "\n"
+	if out.String() != expected {
+		t.Fatalf("Expected\n%s\nGot\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,90 @@
+package configeval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/interp"
+)
+
+// WriteJSON writes value as indented JSON with sorted keys
+func WriteJSON(w io.Writer, value interp.Value) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(interp.Plain(value))
+}
+
+// WriteYAML writes value as block-style YAML with sorted keys; strings are
+// double-quoted so no value is mistaken for another type
+func WriteYAML(w io.Writer, value interp.Value) error {
+	bw := bufio.NewWriter(w)
+	writeYAML(bw, interp.Plain(value), 0)
+	return bw.Flush()
+}
+
+func writeYAML(w *bufio.Writer, value any, indent int) {
+	prefix := strings.Repeat("  ", indent)
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%s{}\n", prefix)
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if scalar, ok := yamlScalar(v[key]); ok {
+				fmt.Fprintf(w, "%s%s: %s\n", prefix, key, scalar)
+				continue
+			}
+			fmt.Fprintf(w, "%s%s:\n", prefix, key)
+			writeYAML(w, v[key], indent+1)
+		}
+	case []any:
+		if len(v) == 0 {
+			fmt.Fprintf(w, "%s[]\n", prefix)
+			return
+		}
+		for _, element := range v {
+			if scalar, ok := yamlScalar(element); ok {
+				fmt.Fprintf(w, "%s- %s\n", prefix, scalar)
+				continue
+			}
+			fmt.Fprintf(w, "%s-\n", prefix)
+			writeYAML(w, element, indent+1)
+		}
+	default:
+		scalar, _ := yamlScalar(v)
+		fmt.Fprintf(w, "%s%s\n", prefix, scalar)
+	}
+}
+
+// yamlScalar renders the values that fit on the line of their key
+func yamlScalar(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "null", true
+	case string:
+		quoted, _ := json.Marshal(v) // JSON strings are valid double-quoted YAML
+		return string(quoted), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true
+	case map[string]any:
+		return "{}", len(v) == 0
+	case []any:
+		return "[]", len(v) == 0
+	}
+	return fmt.Sprint(value), true
+}
@@ -0,0 +1,151 @@
+package coverage
+
+/*
+Coverage records which function clauses and if branches executed while the
+interpreter ran (lyra test -coverprofile) and writes the result as an lcov
+tracefile, which CI services and the language server (lyra/uncovered) read back.
+
+In lcov terms each clause is a line (DA), each function a function record (FN)
+and each if expression a block with a then (0) and an else (1) branch (BRDA).
+*/
+
+import (
+	"sync"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+type SiteKind int
+
+const (
+	SiteClause SiteKind = iota
+	SiteBranch
+)
+
+// Site is a point of the program whose executions are counted
+type Site struct {
+	Kind     SiteKind
+	Function string       // enclosing function, "" at top level
+	Location ast.Location // the clause, or the branch expression
+	Block    int          // for branches: the if expression they belong to
+	Branch   int          // for branches: 0 for then, 1 for else
+}
+
+// Function is a function whose clauses are covered
+type Function struct {
+	Name     string
+	Location ast.Location
+}
+
+// Profile counts the executions of the sites of a program; it implements the
+// interpreter's coverage recorder and is safe for concurrent use
+type Profile struct {
+	Functions []Function
+	Sites     []Site
+
+	mu     sync.Mutex
+	counts []int
+	index  map[any]int // *ast.FunctionClause or branch ast.Expression -> site
+	blocks int
+}
+
+// NewProfile registers the clauses and branches of program
+func NewProfile(program *ast.Program) *Profile {
+	p := &Profile{index: make(map[any]int)}
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			p.Functions = append(p.Functions, Function{Name: s.Name, Location: s.Location})
+			for _, clause := range s.Clauses {
+				p.add(clause, Site{Kind: SiteClause, Function: s.Name, Location: clause.Location})
+				if clause.Guard != nil {
+					p.walk(s.Name, clause.Guard.Condition)
+				}
+				p.walk(s.Name, clause.Body)
+			}
+		case *ast.VarDeclStmt:
+			p.walk("", s.Value)
+		case *ast.VarAssignStmt:
+			p.walk("", s.Value)
+		case *ast.ExpressionStmt:
+			p.walk("", s.Expression)
+		}
+	}
+	p.counts = make([]int, len(p.Sites))
+	return p
+}
+
+func (p *Profile) add(key any, site Site) {
+	p.index[key] = len(p.Sites)
+	p.Sites = append(p.Sites, site)
+}
+
+// walk registers the branches of the if expressions within expr
+func (p *Profile) walk(function string, expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.IfThenExpr:
+		p.walk(function, e.Condition)
+		p.addBranches(function, e.Location, e.Then, e.Else)
+	case *ast.IfBlockExpr:
+		p.walk(function, e.Condition)
+		p.addBranches(function, e.Location, e.Then, e.Else)
+	case *ast.CallExpr:
+		p.walk(function, e.Callee)
+		for _, argument := range e.Arguments {
+			p.walk(function, argument)
+		}
+	case *ast.BinaryOpExpr:
+		p.walk(function, e.Left)
+		p.walk(function, e.Right)
+	case *ast.BooleanBinaryOpExpr:
+		p.walk(function, e.Left)
+		p.walk(function, e.Right)
+	case *ast.MemberAccessExpr:
+		p.walk(function, e.Object)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			p.walk(function, field.Value)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			p.walk(function, element)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			p.walk(function, element)
+		}
+	case *ast.LambdaExpr:
+		p.walk(function, e.Body)
+	}
+}
+
+func (p *Profile) addBranches(function string, loc ast.Location, then, otherwise ast.Expression) {
+	block := p.blocks
+	p.blocks++
+	for i, branch := range []ast.Expression{then, otherwise} {
+		if branch == nil {
+			continue
+		}
+		// branches are reported on the line of their if
+		p.add(branch, Site{Kind: SiteBranch, Function: function, Location: loc, Block: block, Branch: i})
+		p.walk(function, branch)
+	}
+}
+
+// Hit records an execution of a clause or branch
+func (p *Profile) Hit(node any) {
+	i, ok := p.index[node]
+	if !ok {
+		return
+	}
+	p.mu.Lock()
+	p.counts[i]++
+	p.mu.Unlock()
+}
+
+// Count returns the executions of the i-th site
+func (p *Profile) Count(i int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.counts[i]
+}
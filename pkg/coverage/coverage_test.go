@@ -0,0 +1,116 @@
+package coverage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func line(n int) ast.AstBase {
+	return ast.AstBase{Location: ast.Location{StartLine: n, StartCol: 1, EndLine: n, EndCol: 10}}
+}
+
+func ident(name string) *ast.IdentifierExpr   { return &ast.IdentifierExpr{Name: name} }
+func integer(v int64) *ast.IntegerLiteralExpr { return &ast.IntegerLiteralExpr{Value: v} }
+
+func function(name string, n int, clauses ...*ast.FunctionClause) *ast.FunctionDefStmt {
+	intType := types.PrimitiveType{Name: types.Int}
+	signature := &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType}
+	return &ast.FunctionDefStmt{AstBase: line(n), Name: name, Signature: signature, Clauses: clauses}
+}
+
+// clampProgram is:
+//
+//	def clamp: (Int) -> Int = {
+//	    (n) if n < 0 => 0,
+//	    (n) => if n > 10 then 10 else n,
+//	}
+//	def unused: (Int) -> Int = (n) => n
+func clampProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	n := []ast.Pattern{&ast.IdentifierPattern{Name: "n"}}
+	program := &ast.Program{Statements: []ast.AstNode{
+		function("clamp", 1,
+			&ast.FunctionClause{
+				AstBase:    line(2),
+				Parameters: n,
+				Guard:      &ast.GuardExpr{Condition: &ast.BooleanBinaryOpExpr{Left: ident("n"), Operator: ast.BooleanBinaryOpLT, Right: integer(0)}},
+				Body:       integer(0),
+			},
+			&ast.FunctionClause{
+				AstBase:    line(3),
+				Parameters: n,
+				Body: &ast.IfThenExpr{
+					ExprBase:  ast.ExprBase{AstBase: line(3)},
+					Condition: &ast.BooleanBinaryOpExpr{Left: ident("n"), Operator: ast.BooleanBinaryOpGT, Right: integer(10)},
+					Then:      integer(10),
+					Else:      ident("n"),
+				},
+			},
+		),
+		function("unused", 5, &ast.FunctionClause{AstBase: line(5), Parameters: n, Body: ident("n")}),
+	}}
+	table := symbols.NewSymbolTable()
+	for _, stmt := range program.Statements {
+		if err := table.RegisterFunction(stmt.(*ast.FunctionDefStmt)); err != nil {
+			t.Fatalf("RegisterFunction error: %v", err)
+		}
+	}
+	return program, table
+}
+
+func TestProfile_LCOV(t *testing.T) {
+	program, table := clampProgram(t)
+	profile := NewProfile(program)
+	in := interp.New(program, table)
+	in.SetCoverage(profile)
+	for _, arg := range []int64{5, 7} {
+		if _, err := in.Call("clamp", arg); err != nil {
+			t.Fatalf("Call error: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteLCOV(&out, []File{{Path: "/src/clamp.lyra", Profile: profile}}); err != nil {
+		t.Fatalf("WriteLCOV error: %v", err)
+	}
+	expected := []string{
+		"SF:/src/clamp.lyra",
+		"FNDA:2,clamp", "FNDA:0,unused", "FNF:2", "FNH:1",
+		"BRDA:3,0,0,0", "BRDA:3,0,1,2", "BRF:2", "BRH:1",
+		"DA:2,0", "DA:3,2", "DA:5,0", "LF:3", "LH:1",
+		"end_of_record",
+	}
+	for _, record := range expected {
+		if !strings.Contains(out.String(), record+"\n") {
+			t.Fatalf("Expected %q in the report. Got:\n%s", record, out.String())
+		}
+	}
+
+	files, err := ParseLCOV(&out)
+	if err != nil {
+		t.Fatalf("ParseLCOV error: %v", err)
+	}
+	uncovered := files["/src/clamp.lyra"]
+	if len(uncovered.Lines) != 2 || uncovered.Lines[0] != 2 || uncovered.Lines[1] != 5 {
+		t.Fatalf("Expected uncovered clause lines [2 5]. Got %v", uncovered.Lines)
+	}
+	if len(uncovered.Branches) != 1 || uncovered.Branches[0] != 3 {
+		t.Fatalf("Expected the partly covered if on line 3. Got %v", uncovered.Branches)
+	}
+}
+
+func TestProfile_UnreachedIfReportsDash(t *testing.T) {
+	program, _ := clampProgram(t)
+	var out bytes.Buffer
+	if err := WriteLCOV(&out, []File{{Path: "clamp.lyra", Profile: NewProfile(program)}}); err != nil {
+		t.Fatalf("WriteLCOV error: %v", err)
+	}
+	if !strings.Contains(out.String(), "BRDA:3,0,0,-\nBRDA:3,0,1,-\n") {
+		t.Fatalf("Expected never reached branches as -. Got:\n%s", out.String())
+	}
+}
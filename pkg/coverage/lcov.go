@@ -0,0 +1,139 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File is the coverage profile of a source file
+type File struct {
+	Path    string
+	Profile *Profile
+}
+
+// WriteLCOV writes profiles as an lcov tracefile
+func WriteLCOV(w io.Writer, files []File) error {
+	bw := bufio.NewWriter(w)
+	for _, file := range files {
+		writeRecord(bw, file)
+	}
+	return bw.Flush()
+}
+
+func writeRecord(w *bufio.Writer, file File) {
+	p := file.Profile
+	fmt.Fprintf(w, "TN:\nSF:%s\n", file.Path)
+
+	functionHits := make(map[string]int)
+	lineHits := make(map[int]int)
+	for i, site := range p.Sites {
+		if site.Kind == SiteClause {
+			functionHits[site.Function] += p.Count(i)
+			lineHits[site.Location.StartLine] += p.Count(i)
+		}
+	}
+
+	hitFunctions := 0
+	for _, fn := range p.Functions {
+		fmt.Fprintf(w, "FN:%d,%s\n", fn.Location.StartLine, fn.Name)
+	}
+	for _, fn := range p.Functions {
+		fmt.Fprintf(w, "FNDA:%d,%s\n", functionHits[fn.Name], fn.Name)
+		if functionHits[fn.Name] > 0 {
+			hitFunctions++
+		}
+	}
+	fmt.Fprintf(w, "FNF:%d\nFNH:%d\n", len(p.Functions), hitFunctions)
+
+	// an if that never ran reports its branches as "-" rather than 0
+	blockHits := make(map[int]int)
+	for i, site := range p.Sites {
+		if site.Kind == SiteBranch {
+			blockHits[site.Block] += p.Count(i)
+		}
+	}
+	branches, hitBranches := 0, 0
+	for i, site := range p.Sites {
+		if site.Kind != SiteBranch {
+			continue
+		}
+		branches++
+		taken := "-"
+		if blockHits[site.Block] > 0 {
+			taken = strconv.Itoa(p.Count(i))
+		}
+		if p.Count(i) > 0 {
+			hitBranches++
+		}
+		fmt.Fprintf(w, "BRDA:%d,%d,%d,%s\n", site.Location.StartLine, site.Block, site.Branch, taken)
+	}
+	fmt.Fprintf(w, "BRF:%d\nBRH:%d\n", branches, hitBranches)
+
+	lines := make([]int, 0, len(lineHits))
+	for line := range lineHits {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	hitLines := 0
+	for _, line := range lines {
+		fmt.Fprintf(w, "DA:%d,%d\n", line, lineHits[line])
+		if lineHits[line] > 0 {
+			hitLines++
+		}
+	}
+	fmt.Fprintf(w, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hitLines)
+}
+
+// Uncovered lists the lines of a tracefile record that never ran
+type Uncovered struct {
+	Lines    []int // clause lines with no hits
+	Branches []int // lines of ifs with a branch that was never taken
+}
+
+// ParseLCOV reads the uncovered lines of every source file in an lcov tracefile
+func ParseLCOV(r io.Reader) (map[string]Uncovered, error) {
+	result := make(map[string]Uncovered)
+	var path string
+	var current Uncovered
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		switch key {
+		case "SF":
+			path, current = value, Uncovered{}
+		case "DA":
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid DA record %q", value)
+			}
+			if fields[1] == "0" {
+				line, err := strconv.Atoi(fields[0])
+				if err != nil {
+					return nil, fmt.Errorf("invalid DA record %q", value)
+				}
+				current.Lines = append(current.Lines, line)
+			}
+		case "BRDA":
+			fields := strings.Split(value, ",")
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("invalid BRDA record %q", value)
+			}
+			if fields[3] == "0" || fields[3] == "-" {
+				line, err := strconv.Atoi(fields[0])
+				if err != nil {
+					return nil, fmt.Errorf("invalid BRDA record %q", value)
+				}
+				if n := len(current.Branches); n == 0 || current.Branches[n-1] != line {
+					current.Branches = append(current.Branches, line)
+				}
+			}
+		case "end_of_record":
+			result[path] = current
+		}
+	}
+	return result, scanner.Err()
+}
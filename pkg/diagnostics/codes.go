@@ -0,0 +1,118 @@
+package diagnostics
+
+/*
+Diagnostics codes identify each kind of error the analyzer reports (LYR0012) so
+users can look them up with `lyra explain` and editors can offer the explanation
+as a code action. Explanations live in Go alongside the codes so every tool that
+links this package serves the same text.
+*/
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Code identifies a kind of diagnostic
+type Code string
+
+const (
+	UndefinedName        Code = "LYR0001"
+	AmbiguousConstructor Code = "LYR0002"
+	TypeMismatch         Code = "LYR0003"
+	AssignToImmutable    Code = "LYR0004"
+	NotCallable          Code = "LYR0005"
+	ArgumentCount        Code = "LYR0006"
+	ArgumentType         Code = "LYR0007"
+	InvalidOperands      Code = "LYR0008"
+	IncomparableTypes    Code = "LYR0009"
+	BranchTypeMismatch   Code = "LYR0010"
+	ConditionNotBool     Code = "LYR0011"
+	UnknownConstructor   Code = "LYR0012"
+	UnknownField         Code = "LYR0013"
+	NotAStruct           Code = "LYR0014"
+	MissingField         Code = "LYR0015"
+	UnfinishedCode       Code = "LYR0016"
+	SharedMutation       Code = "LYR0017"
+	UseAfterMove         Code = "LYR0018"
+	FunctionTooComplex   Code = "LYR0019"
+	NamingConvention     Code = "LYR0020"
+	RepeatedLiteral      Code = "LYR0021"
+	IncompleteDoc        Code = "LYR0022"
+	InvalidExtern        Code = "LYR0023"
+	InvalidDerive        Code = "LYR0024"
+	TypedHole            Code = "LYR0025"
+	UnreachableClause    Code = "LYR0026"
+	IntegerOverflow      Code = "LYR0027"
+	NaNComparison        Code = "LYR0028"
+	InvalidLiteral       Code = "LYR0029"
+	DuplicateDeclaration Code = "LYR0030"
+	MalformedSyntax      Code = "LYR0031"
+	InitializationCycle  Code = "LYR0032"
+	UseBeforeDeclaration Code = "LYR0033"
+	ShadowedParameter    Code = "LYR0034"
+	UnknownType          Code = "LYR0035"
+	InfiniteType         Code = "LYR0036"
+	ArgumentModifier     Code = "LYR0037"
+	AmbiguousMethod      Code = "LYR0038"
+	NonExhaustiveClauses Code = "LYR0039"
+	PurityViolation      Code = "LYR0040"
+)
+
+// Severity ranks diagnostics; the values match the LSP DiagnosticSeverity
+type Severity int
+
+const (
+	Error       Severity = 1
+	Warning     Severity = 2
+	Information Severity = 3
+	Hint        Severity = 4
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	case Information:
+		return "info"
+	case Hint:
+		return "hint"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Explanation is the extended documentation of a code
+type Explanation struct {
+	Code        Code   `json:"code"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Example     string `json:"example"` // a program that triggers the diagnostic
+	Fix         string `json:"fix"`     // the canonical fix, usually the example corrected
+}
+
+// Explain returns the explanation of a code
+func Explain(code Code) (Explanation, error) {
+	explanation, ok := explanations[code]
+	if !ok {
+		return Explanation{}, fmt.Errorf("unknown diagnostic code %s", code)
+	}
+	explanation.Code = code
+	return explanation, nil
+}
+
+// Codes returns every documented code in order
+func Codes() []Code {
+	codes := make([]Code, 0, len(explanations))
+	for code := range explanations {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// Markdown renders an explanation for terminals and editor hovers
+func (e Explanation) Markdown() string {
+	return fmt.Sprintf("# %s: %s\n\n%s\n\n## Example\n\n```lyra\n%s\n```\n\n## Fix\n\n```lyra\n%s\n```\n",
+		e.Code, e.Title, e.Description, e.Example, e.Fix)
+}
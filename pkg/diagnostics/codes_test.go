@@ -0,0 +1,25 @@
+package diagnostics
+
+import "testing"
+
+func TestExplanations_Complete(t *testing.T) {
+	codes := Codes()
+	if len(codes) != 40 {
+		t.Fatalf("Expected 40 documented codes, got %d", len(codes))
+	}
+	for _, code := range codes {
+		explanation, err := Explain(code)
+		if err != nil {
+			t.Fatalf("Explain(%s) error: %v", code, err)
+		}
+		if explanation.Title == "" || explanation.Description == "" || explanation.Example == "" || explanation.Fix == "" {
+			t.Fatalf("Explanation of %s is incomplete: %+v", code, explanation)
+		}
+	}
+}
+
+func TestExplain_UnknownCode(t *testing.T) {
+	if _, err := Explain("LYR9999"); err == nil {
+		t.Fatalf("Expected an error for an unknown code")
+	}
+}
@@ -0,0 +1,300 @@
+package diagnostics
+
+var explanations = map[Code]Explanation{
+	UndefinedName: {
+		Title:       "undefined name",
+		Description: "A name is used that is not a parameter, pattern binding, top-level variable, function or constructor in scope.",
+		Example:     "def double: (Int) -> Int = (n) => m + m",
+		Fix:         "def double: (Int) -> Int = (n) => n + n",
+	},
+	AmbiguousConstructor: {
+		Title: "ambiguous constructor",
+		Description: "Several data types declare a constructor with this name and nothing tells them apart. " +
+			"Constructors are resolved by qualified name first, then by the expected type.",
+		Example: "data Maybe = Some(Int) | Nil\ndata Tree = Node(Tree, Tree) | Nil\n\ndef empty: () -> Bool = () => Nil == Nil",
+		Fix:     "def empty: () -> Bool = () => Maybe.Nil == Maybe.Nil",
+	},
+	TypeMismatch: {
+		Title:       "mismatched types",
+		Description: "A value's type differs from the declared type of the binding or field it is stored in.",
+		Example:     "let the_answer: Int = \"42\"",
+		Fix:         "let the_answer: Int = 42",
+	},
+	AssignToImmutable: {
+		Title:       "assignment to immutable binding",
+		Description: "Only bindings declared with var can be reassigned; let and const bindings are fixed once declared.",
+		Example:     "let count: Int = 0\ncount = 1",
+		Fix:         "var count: Int = 0\ncount = 1",
+	},
+	NotCallable: {
+		Title:       "call of a non-function",
+		Description: "A value is called with arguments but its type is not a function type. Nullary constructors are values and are used without parentheses.",
+		Example:     "let limit: Int = 10\nlet x: Int = limit(1)",
+		Fix:         "let limit: Int = 10\nlet x: Int = limit",
+	},
+	ArgumentCount: {
+		Title: "wrong number of arguments",
+		Description: "A call passes a different number of arguments than the function's signature declares, or a clause " +
+			"of a function takes a different number of parameters.",
+		Example: "def sum: (Int, Int) -> Int = (a, b) => a + b\nlet x: Int = sum(1)",
+		Fix:     "def sum: (Int, Int) -> Int = (a, b) => a + b\nlet x: Int = sum(1, 2)",
+	},
+	ArgumentType: {
+		Title:       "mismatched argument type",
+		Description: "An argument's type differs from the type of the parameter it is passed to.",
+		Example:     "def sum: (Int, Int) -> Int = (a, b) => a + b\nlet x: Int = sum(1, true)",
+		Fix:         "def sum: (Int, Int) -> Int = (a, b) => a + b\nlet x: Int = sum(1, 2)",
+	},
+	InvalidOperands: {
+		Title: "invalid operands",
+		Description: "Arithmetic operators need two numbers of the same type and `++` needs two arrays of the same element type. " +
+			"There are no implicit conversions between numeric types.",
+		Example: "def half: (Int) -> Float = (n) => n / 2.0",
+		Fix:     "def half: (Float) -> Float = (n) => n / 2.0",
+	},
+	IncomparableTypes: {
+		Title:       "comparison of different types",
+		Description: "Both sides of a comparison must have the same type.",
+		Example:     "def is_zero: (Int) -> Bool = (n) => n == \"0\"",
+		Fix:         "def is_zero: (Int) -> Bool = (n) => n == 0",
+	},
+	BranchTypeMismatch: {
+		Title:       "if branches have different types",
+		Description: "An if expression produces a value, so its then and else branches must have the same type.",
+		Example:     "def sign: (Int) -> Int = (n) => if n < 0 then -1 else \"positive\"",
+		Fix:         "def sign: (Int) -> Int = (n) => if n < 0 then -1 else 1",
+	},
+	ConditionNotBool: {
+		Title:       "condition is not Bool",
+		Description: "If conditions, guards and the operands of && and || must be Bool; numbers are not truthy.",
+		Example:     "def is_set: (Int) -> Bool = (flags) => if flags then true else false",
+		Fix:         "def is_set: (Int) -> Bool = (flags) => if flags != 0 then true else false",
+	},
+	UnknownConstructor: {
+		Title:       "unknown constructor",
+		Description: "A qualified constructor names a data type that does not declare a constructor with that name.",
+		Example:     "data Maybe = Some(Int) | Nil\nlet x: Maybe = Maybe.None",
+		Fix:         "data Maybe = Some(Int) | Nil\nlet x: Maybe = Maybe.Nil",
+	},
+	UnknownField: {
+		Title: "unknown field",
+		Description: "A member access, struct literal, struct pattern or {placeholder} of a render template names a " +
+			"field its struct does not declare.",
+		Example: "struct Point { x: Int, y: Int }\ndef norm: (Point) -> Int = (p) => p.x + p.z",
+		Fix:     "struct Point { x: Int, y: Int }\ndef norm: (Point) -> Int = (p) => p.x + p.y",
+	},
+	NotAStruct: {
+		Title:       "literal of a non-struct type",
+		Description: "Struct literal syntax `Name { ... }` is only available for structs and record-style constructors.",
+		Example:     "data Maybe = Some(Int) | Nil\nlet x: Maybe = Maybe { value: 1 }",
+		Fix:         "data Maybe = Some(Int) | Nil\nlet x: Maybe = Some(1)",
+	},
+	MissingField: {
+		Title:       "missing field in struct literal",
+		Description: "A struct literal must give every field that has no default value.",
+		Example:     "struct Point { x: Int, y: Int = 0 }\nlet origin: Point = Point { y: 0 }",
+		Fix:         "struct Point { x: Int, y: Int = 0 }\nlet origin: Point = Point { x: 0 }",
+	},
+	UnfinishedCode: {
+		Title: "unfinished code",
+		Description: "todo() marks code that is not written yet. It type checks as Never, so it fits anywhere a value is expected, " +
+			"and every site is reported as information so unfinished work stays visible.",
+		Example: "def area: (Shape) -> Float = (shape) => todo()",
+		Fix:     "def area: (Shape) -> Float = (shape) => shape.width * shape.height",
+	},
+	SharedMutation: {
+		Title: "mutation of a shared array",
+		Description: "Passing an array to a mut or own parameter lets the callee change it. A pure function must not change a var " +
+			"declared outside it, and an array passed to two parameters of one call, one of them mut or own, is changed behind the other's back.",
+		Example: "var seen: Array<Int> = []\npure def push: (mut Array<Int>, Int) -> Unit = (xs, x) => todo()\npure def visit: (Int) -> Unit = (x) => push(mut seen, x)",
+		Fix:     "pure def push: (mut Array<Int>, Int) -> Unit = (xs, x) => todo()\ndef visit: (mut Array<Int>, Int) -> Unit = (seen, x) => push(mut seen, x)",
+	},
+	UseAfterMove: {
+		Title: "use of a moved value",
+		Description: "Passing a value you own to an own parameter moves it: the callee now owns it and may drop it. " +
+			"The moved name cannot be used afterwards; pass it as ref or mut if you still need it, or reassign it first.",
+		Example: "def close: (own File) -> Unit = (f) => todo()\ndef size: (ref File) -> Int = (f) => todo()\ndef finish: (own File) -> Int = (f) => if close(f) == close(f) then 0 else size(ref f)",
+		Fix:     "def close: (own File) -> Unit = (f) => todo()\ndef size: (ref File) -> Int = (f) => todo()\ndef finish: (own File) -> Int = (f) => size(ref f)",
+	},
+	FunctionTooComplex: {
+		Title: "function too complex",
+		Description: "A lint: the function is over a limit set in lyra-lint.json for its cyclomatic complexity, number of clauses, " +
+			"depth of nested ifs or number of parameters. Split it into smaller functions or move decisions into clauses. " +
+			"The example is reported with a nesting limit of 3.",
+		Example: "def grade: (Int) -> String = (n) => if n > 90 then \"A\" else if n > 80 then \"B\" else if n > 70 then \"C\" else if n > 60 then \"D\" else \"F\"",
+		Fix: "def grade: (Int) -> String = {\n    (n) if n > 90 => \"A\",\n    (n) if n > 80 => \"B\",\n    (n) if n > 70 => \"C\",\n" +
+			"    (n) if n > 60 => \"D\",\n    (_) => \"F\",\n}",
+	},
+	NamingConvention: {
+		Title: "naming convention",
+		Description: "A lint: types and constructors start with an uppercase letter, generic parameters are lowercase, and " +
+			"functions, variables and parameters are snake_case. Editors offer a fix that renames a snake_case violation everywhere it is used.",
+		Example: "def parseHeader: (String) -> Int = (rawLine) => 0",
+		Fix:     "def parse_header: (String) -> Int = (raw_line) => 0",
+	},
+	RepeatedLiteral: {
+		Title: "repeated literal",
+		Description: "A lint: the same number (other than 0 and 1) or string appears more often than lyra-lint.json allows. " +
+			"A named const says what the value means and keeps its uses in step; editors offer a fix that extracts it.",
+		Example: "def tax: (Float) -> Float = (price) => price * 0.2\ndef gross: (Float) -> Float = (price) => price + price * 0.2\ndef net: (Float) -> Float = (gross) => gross / (1.0 + 0.2)",
+		Fix:     "const vat_rate: Float = 0.2\ndef tax: (Float) -> Float = (price) => price * vat_rate\ndef gross: (Float) -> Float = (price) => price + price * vat_rate\ndef net: (Float) -> Float = (gross) => gross / (1.0 + vat_rate)",
+	},
+	IncompleteDoc: {
+		Title: "incomplete documentation",
+		Description: "A lint: every pub function and type needs a doc comment, the // lines directly above it, and " +
+			"each @param tag in a doc comment must name one of the function's parameters.",
+		Example: "// Area of a rectangle\n// @param w width\n// @param height height\npub def area: (Int, Int) -> Int = (width, height) => width * height",
+		Fix:     "// Area of a rectangle\n// @param width width\n// @param height height\npub def area: (Int, Int) -> Int = (width, height) => width * height",
+	},
+	InvalidExtern: {
+		Title: "invalid extern declaration",
+		Description: "An extern function is implemented by the Go program embedding the interpreter. Its target must have the form " +
+			"\"go:package.Name\" and its parameters and result must be values that convert to Go: integers, floats, Bool and String " +
+			"(and Unit as the result).",
+		Example: "struct Point { x: Int, y: Int }\nextern def plot: (Point) -> Unit = \"go:draw.Plot\"",
+		Fix:     "extern def plot: (Int, Int) -> Unit = \"go:draw.Plot\"",
+	},
+	InvalidDerive: {
+		Title: "invalid derive",
+		Description: "@derive(...) synthesizes a trait implementation for a struct or data type. The derivable traits are " +
+			"Serialize (to_json) and Deserialize (from_json), and every field and constructor argument must itself be " +
+			"(de)serializable: a number, Bool, String, an array of such values, or a type deriving the same trait.",
+		Example: "struct Secret { key: String }\n@derive(Serialize)\nstruct Login { user: String, secret: Secret }",
+		Fix:     "@derive(Serialize)\nstruct Secret { key: String }\n@derive(Serialize)\nstruct Login { user: String, secret: Secret }",
+	},
+	TypedHole: {
+		Title: "typed hole",
+		Description: "A hole stands for code or a type still to be written. In an annotation, _ or ? is filled with the " +
+			"inferred type and reported as information; ??? as an expression reports the type expected there and the " +
+			"bindings in scope that have it, and fails if it is evaluated. A hole the checker cannot fill, like a " +
+			"parameter type, is an error.",
+		Example: "def double: (Int) -> ? = (n) => ???",
+		Fix:     "def double: (Int) -> Int = (n) => n * 2",
+	},
+	UnreachableClause: {
+		Title: "unreachable clause",
+		Description: "Clauses are tried in order, so a clause is never reached if an earlier one matches every call it " +
+			"matches: the same literal pattern again, a literal after a parameter that binds any value, or the same " +
+			"patterns and guard repeated. The warning names the earlier clause.",
+		Example: "def fib: (Int) -> Int = {\n\t(0) => 0,\n\t(n) => fib(n - 2) + fib(n - 1),\n\t(1) => 1,\n}",
+		Fix:     "def fib: (Int) -> Int = {\n\t(0) => 0,\n\t(1) => 1,\n\t(n) => fib(n - 2) + fib(n - 1),\n}",
+	},
+	IntegerOverflow: {
+		Title: "integer literal out of range",
+		Description: "An integer literal takes the integer type expected of it, like the annotated type of a declaration " +
+			"or a parameter type, and must be a value that type holds: Int8 holds -128 to 127, UInt8 0 to 255, and " +
+			"UInt64 0 to 18446744073709551615, past every Int64; Int is the type of a literal expected to be no integer. " +
+			"Literals may be written in hex (0xFF), octal (0o17) or binary (0b1010), with _ between digits.",
+		Example: "let mask: Int8 = 0xFF",
+		Fix:     "let mask: UInt8 = 0xFF",
+	},
+	NaNComparison: {
+		Title: "comparison with NaN",
+		Description: "NaN is unequal to every Float, itself included, and neither less nor greater than any, so comparing " +
+			"with NaN is always false (always true with !=). A value is NaN exactly when it is unequal to itself.",
+		Example: "def is_missing: (Float) -> Bool = (x) => x == NaN",
+		Fix:     "def is_missing: (Float) -> Bool = (x) => x != x",
+	},
+	InvalidLiteral: {
+		Title: "invalid literal",
+		Description: "An integer literal must be digits of its base and a float literal must be a valid number. In either, _ may " +
+			"only separate two digits. In a string or character literal, a backslash starts one of the escape sequences " +
+			"\\n, \\t, \\r, \\0, \\\\, \\\", \\' or \\u{...} with the hex digits of a Unicode code point.",
+		Example: "let million: Int = 1__000_000",
+		Fix:     "let million: Int = 1_000_000",
+	},
+	DuplicateDeclaration: {
+		Title: "duplicate declaration",
+		Description: "Functions, variables and types share one top-level namespace, so each name can only be declared " +
+			"once; a data type cannot declare two constructors of the same name either. The message says where the " +
+			"name was first declared.",
+		Example: "let size: Int = 1\ndef size: () -> Int = () => 2",
+		Fix:     "let size: Int = 1\ndef default_size: () -> Int = () => 2",
+	},
+	MalformedSyntax: {
+		Title: "malformed syntax",
+		Description: "The source parsed, but a construct lacks a part it needs, like a guard without a condition or a " +
+			"parameter without a type. The declaration is kept without that part, so other errors may follow from it.",
+		Example: "def positive: (Int) -> Bool = (n) if => true",
+		Fix:     "def positive: (Int) -> Bool = (n) if n > 0 => true",
+	},
+	InitializationCycle: {
+		Title: "initialization cycle",
+		Description: "Top-level bindings are initialized in order, so a binding cannot need its own value, directly or " +
+			"through other bindings and the functions their initializers call. The message lists the references " +
+			"around the cycle and suggests one to break: a function computes its value when called, not at startup.",
+		Example: "let width: Int = height * 2\nlet height: Int = width / 2",
+		Fix:     "let width: Int = 40\nlet height: Int = width / 2",
+	},
+	UseBeforeDeclaration: {
+		Title: "use before declaration",
+		Description: "Top-level bindings are initialized in the order they are declared, so an initializer cannot read a " +
+			"binding declared after it, directly or through the functions it calls. Declare the binding earlier, or " +
+			"make it lazy: a lazy binding is initialized when first read.",
+		Example: "let area: Int = side * side\nlet side: Int = 4",
+		Fix:     "let area: Int = side * side\nlazy let side: Int = 4",
+	},
+	ShadowedParameter: {
+		Title: "shadowed parameter",
+		Description: "Each clause binds its own parameters, and its guard and body see those alone. A clause that binds " +
+			"a name twice keeps only the last binding, and a guard reading a parameter named like a top-level " +
+			"binding or function reads the argument, not the top-level value. Rename the parameter.",
+		Example: "let limit: Int = 10\ndef under: (Int) -> Bool = (limit) if limit < 10 => true",
+		Fix:     "let limit: Int = 10\ndef under: (Int) -> Bool = (n) if n < limit => true",
+	},
+	UnknownType: {
+		Title: "unknown type",
+		Description: "A type annotation names a type that is neither declared nor a generic parameter of the " +
+			"declaration it is in. Check the spelling, declare the type, or list the name among the generic " +
+			"parameters.",
+		Example: "def area: (Shape) -> Float = (s) => 0.0",
+		Fix:     "struct Shape { width: Float }\ndef area: (Shape) -> Float = (s) => 0.0",
+	},
+	InfiniteType: {
+		Title: "infinite type",
+		Description: "A struct holds its fields by value, so a struct containing itself, directly, through a " +
+			"tuple or through other structs, would need infinite room. Data types and arrays hold their " +
+			"contents indirectly and may refer to the type being declared: store the recursive field in one of " +
+			"them, with a constructor or an empty array to end the recursion. A type alias stands for the type it " +
+			"names, so an alias naming itself, even through an array or other aliases, never ends either: " +
+			"declare a struct or data type instead.",
+		Example: "struct Node { value: Int, next: Node }",
+		Fix:     "struct Node { value: Int, next: [Node] }",
+	},
+	ArgumentModifier: {
+		Title: "argument modifier",
+		Description: "An argument passed to a mut or ref parameter is marked with the parameter's modifier at " +
+			"the call, so a call that may change its argument says so where it is written. An argument " +
+			"marked with a modifier its parameter does not declare is an error too.",
+		Example: "def push: (mut Array<Int>, Int) -> Unit = (xs, x) => todo()\ndef fill: (mut Array<Int>) -> Unit = (xs) => push(xs, 1)",
+		Fix:     "def push: (mut Array<Int>, Int) -> Unit = (xs, x) => todo()\ndef fill: (mut Array<Int>) -> Unit = (xs) => push(mut xs, 1)",
+	},
+	AmbiguousMethod: {
+		Title: "ambiguous method",
+		Description: "A method call such as point.show() looks the method up among the traits the type of point " +
+			"implements. When several of them define a method of that name, the call cannot tell which one is " +
+			"meant. Rename the method in all traits but one.",
+		Example: "impl Show for Point { def show: (Point) -> String = (p) => \"point\" }\n" +
+			"impl Debug for Point { def show: (Point) -> String = (p) => \"Point\" }\nlet text = origin.show()",
+		Fix: "impl Show for Point { def show: (Point) -> String = (p) => \"point\" }\n" +
+			"impl Debug for Point { def debug: (Point) -> String = (p) => \"Point\" }\nlet text = origin.show()",
+	},
+	NonExhaustiveClauses: {
+		Title: "clauses do not match every value",
+		Description: "A call matching none of the clauses of a function fails at run time. When the clauses match on " +
+			"a parameter of a data type, the warning lists the constructors none of them matches, counting a pattern " +
+			"only if its fields match any value; for a small integer type such as Int8, matched with literals and " +
+			"ranges like 0..=9, it lists the values. Guarded clauses cover nothing. Add clauses for what is missing " +
+			"or end with one binding any value.",
+		Example: "def digit: (UInt8) -> Bool = {\n\t(0..=9) => true,\n\t(10..=99) => false,\n}",
+		Fix:     "def digit: (UInt8) -> Bool = {\n\t(0..=9) => true,\n\t(n) => false,\n}",
+	},
+	PurityViolation: {
+		Title: "impure call in a pure function",
+		Description: "A pure function computes its result from its arguments alone, so it may only call functions declared " +
+			"pure: not debug(), which prints, nor async functions or functions implemented by the host. The functions it " +
+			"defines in its body are pure like it, and calls through its parameters are assumed pure.",
+		Example: "def log: (Int) -> Int = (x) => debug(x)\npure def twice: (Int) -> Int = (x) => log(x) * 2",
+		Fix:     "pure def log: (Int) -> Int = (x) => x\npure def twice: (Int) -> Int = (x) => log(x) * 2",
+	},
+}
@@ -0,0 +1,101 @@
+package export
+
+/*
+Export turns analyzed files into formats consumed by other tools: a SQLite database
+for ad-hoc queries and a SCIP index for code intelligence services.
+*/
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// File is an analyzed source file to export
+type File struct {
+	Path   string
+	Module []string // module the file belongs to, empty for the root module
+	Result *analyzer.Result
+}
+
+// ModuleOf returns the module of the file at a slash-separated path relative to
+// the root of a project, the directories leading to it: geometry/shapes/circle.lyra
+// is in geometry.shapes, as `use` names it. Files outside the root have none.
+func ModuleOf(relative string) []string {
+	dir := path.Dir(path.Clean(relative))
+	if dir == "." || dir == ".." || path.IsAbs(dir) || strings.HasPrefix(dir, "../") {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// symbolRow is a top-level symbol of a file (or a field/constructor of one)
+type symbolRow struct {
+	target     refs.Target
+	kind       string
+	typ        types.Type
+	paramCount int // -1 when not applicable
+	isPublic   bool
+	location   ast.Location
+}
+
+// symbolRows lists the top-level symbols of a file in a stable order
+func symbolRows(result *analyzer.Result) []symbolRow {
+	rows := make([]symbolRow, 0)
+	for _, stmt := range result.Program.Statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			paramCount := 0
+			var typ types.Type
+			if s.Signature != nil {
+				paramCount = len(s.Signature.ParameterTypes)
+				typ = s.Signature
+			}
+			rows = append(rows, symbolRow{refs.FunctionTarget(s.Name), "function", typ, paramCount, s.IsPublic, s.NameLocation})
+		case *ast.VarDeclStmt:
+			rows = append(rows, symbolRow{refs.VariableTarget(s.Name), "variable", s.Type, -1, false, s.NameLocation})
+		case *ast.TypeDeclStmt:
+			rows = append(rows, typeRows(result, s)...)
+		}
+	}
+	return rows
+}
+
+func typeRows(result *analyzer.Result, decl *ast.TypeDeclStmt) []symbolRow {
+	if decl.IsAlias {
+		return []symbolRow{{refs.TypeTarget(decl.Name), "alias", decl.Type, -1, decl.IsPublic, decl.Location}}
+	}
+	switch t := decl.Type.(type) {
+	case types.StructType:
+		rows := []symbolRow{{refs.TypeTarget(decl.Name), "struct", nil, -1, decl.IsPublic, decl.Location}}
+		for _, name := range sortedKeys(t.Fields) {
+			rows = append(rows, symbolRow{refs.FieldTarget(decl.Name, name), "field", t.Fields[name].Type, -1, decl.IsPublic, decl.FieldLocations[name]})
+		}
+		return rows
+	case types.DataType:
+		rows := []symbolRow{{refs.TypeTarget(decl.Name), "data", nil, -1, decl.IsPublic, decl.Location}}
+		for _, name := range sortedKeys(t.Constructors) {
+			ctor, ok := result.Table.LookupQualifiedConstructor(decl.Name, name)
+			if !ok {
+				continue
+			}
+			rows = append(rows, symbolRow{refs.ConstructorTarget(decl.Name, name), "constructor", ctor.Signature, len(ctor.Signature.ParameterTypes), decl.IsPublic, ctor.Location})
+		}
+		return rows
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,50 @@
+package export
+
+// Minimal protocol buffers wire-format encoding, enough to emit SCIP indexes
+// without pulling in a protobuf runtime.
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return appendVarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendBytesField(b []byte, field int, value []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = appendVarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func appendStringField(b []byte, field int, value string) []byte {
+	if value == "" {
+		return b
+	}
+	return appendBytesField(b, field, []byte(value))
+}
+
+func appendIntField(b []byte, field int, value int) []byte {
+	if value == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return appendVarint(b, uint64(value))
+}
+
+func appendPackedIntsField(b []byte, field int, values []int) []byte {
+	packed := make([]byte, 0, len(values))
+	for _, v := range values {
+		packed = appendVarint(packed, uint64(v))
+	}
+	return appendBytesField(b, field, packed)
+}
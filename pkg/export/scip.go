@@ -0,0 +1,187 @@
+package export
+
+/*
+SCIP export writes a code intelligence index (https://github.com/sourcegraph/scip)
+for upload to Sourcegraph-style services. Each file becomes a SCIP document with an
+occurrence per indexed reference and symbol information (with hover text) for each
+definition. Symbols use the `scip-lyra` scheme with empty package fields:
+
+	scip-lyra . . . sum().        function
+	scip-lyra . . . the_answer.   variable
+	scip-lyra . . . Point#        struct or data type
+	scip-lyra . . . Point#x.      field
+	scip-lyra . . . Maybe#Some(). constructor
+	local 3                       parameters and pattern bindings
+
+Declarations of a file in a module are prefixed with a namespace per part of
+the module path, so geometry.shapes:Point.x is `scip-lyra . . . geometry/shapes/Point#x.`.
+*/
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// Field numbers and enum values from scip.proto
+const (
+	scipIndexMetadata  = 1
+	scipIndexDocuments = 2
+
+	scipMetadataToolInfo     = 2
+	scipMetadataProjectRoot  = 3
+	scipMetadataTextEncoding = 4
+	scipTextEncodingUTF8     = 1
+
+	scipToolInfoName    = 1
+	scipToolInfoVersion = 2
+
+	scipDocumentRelativePath = 1
+	scipDocumentOccurrences  = 2
+	scipDocumentSymbols      = 3
+	scipDocumentLanguage     = 4
+
+	scipOccurrenceRange       = 1
+	scipOccurrenceSymbol      = 2
+	scipOccurrenceSymbolRoles = 3
+
+	scipSymbolInfoSymbol        = 1
+	scipSymbolInfoDocumentation = 3
+	scipSymbolInfoDisplayName   = 6
+
+	scipRoleDefinition  = 0x1
+	scipRoleWriteAccess = 0x4
+	scipRoleReadAccess  = 0x8
+)
+
+// ToolVersion is recorded in the metadata of emitted indexes
+var ToolVersion = "dev"
+
+// WriteSCIP writes a SCIP index of files; projectRoot is a URI such as file:///repo
+// and file paths are expected to be relative to it
+func WriteSCIP(w io.Writer, projectRoot string, files []File) error {
+	var toolInfo []byte
+	toolInfo = appendStringField(toolInfo, scipToolInfoName, "lyra")
+	toolInfo = appendStringField(toolInfo, scipToolInfoVersion, ToolVersion)
+
+	var metadata []byte
+	metadata = appendBytesField(metadata, scipMetadataToolInfo, toolInfo)
+	metadata = appendStringField(metadata, scipMetadataProjectRoot, projectRoot)
+	metadata = appendIntField(metadata, scipMetadataTextEncoding, scipTextEncodingUTF8)
+
+	var index []byte
+	index = appendBytesField(index, scipIndexMetadata, metadata)
+	for _, file := range files {
+		index = appendBytesField(index, scipIndexDocuments, scipDocument(file))
+	}
+	_, err := w.Write(index)
+	return err
+}
+
+func scipDocument(file File) []byte {
+	var doc []byte
+	doc = appendStringField(doc, scipDocumentRelativePath, file.Path)
+	doc = appendStringField(doc, scipDocumentLanguage, "lyra")
+
+	symbols := &scipSymbols{module: file.Module, locals: make(map[refs.Target]string)}
+	for _, ref := range file.Result.Index.All() {
+		var occurrence []byte
+		occurrence = appendPackedIntsField(occurrence, scipOccurrenceRange, scipRange(ref.Location))
+		occurrence = appendStringField(occurrence, scipOccurrenceSymbol, symbols.name(ref.Target))
+		occurrence = appendIntField(occurrence, scipOccurrenceSymbolRoles, scipRoles(ref.Kind))
+		doc = appendBytesField(doc, scipDocumentOccurrences, occurrence)
+	}
+
+	for _, row := range symbolRows(file.Result) {
+		var info []byte
+		info = appendStringField(info, scipSymbolInfoSymbol, symbols.name(row.target))
+		info = appendStringField(info, scipSymbolInfoDocumentation, "```lyra\n"+hoverText(row)+"\n```")
+		info = appendStringField(info, scipSymbolInfoDisplayName, row.target.Name)
+		doc = appendBytesField(doc, scipDocumentSymbols, info)
+	}
+	return doc
+}
+
+// scipSymbols names the targets of a document in module, numbering its locals
+type scipSymbols struct {
+	module []string
+	locals map[refs.Target]string
+}
+
+func (s *scipSymbols) name(target refs.Target) string {
+	if q, ok := refs.Qualify(s.module, target); ok {
+		return scipSymbol(q, target.Kind)
+	}
+	if name, ok := s.locals[target]; ok {
+		return name
+	}
+	name := fmt.Sprintf("local %d", len(s.locals))
+	s.locals[target] = name
+	return name
+}
+
+// scipSymbol is the global symbol of a qualified name, whose descriptor suffixes
+// depend on the kind of its target
+func scipSymbol(q refs.QualifiedName, kind refs.TargetKind) string {
+	symbol := "scip-lyra . . . "
+	for _, part := range q.Module {
+		symbol += part + "/"
+	}
+	switch kind {
+	case refs.TargetFunction:
+		return symbol + q.Declaration + "()."
+	case refs.TargetVariable:
+		return symbol + q.Declaration + "."
+	case refs.TargetType:
+		return symbol + q.Declaration + "#"
+	case refs.TargetField:
+		return symbol + q.Declaration + "#" + q.Member + "."
+	}
+	return symbol + q.Declaration + "#" + q.Member + "()."
+}
+
+// scipRange is zero-based [startLine, startChar, endLine, endChar], or
+// [line, startChar, endChar] when the range is on a single line
+func scipRange(loc ast.Location) []int {
+	if loc.StartLine == loc.EndLine {
+		return []int{loc.StartLine - 1, loc.StartCol - 1, loc.EndCol - 1}
+	}
+	return []int{loc.StartLine - 1, loc.StartCol - 1, loc.EndLine - 1, loc.EndCol - 1}
+}
+
+func scipRoles(kind refs.Kind) int {
+	switch kind {
+	case refs.Definition:
+		return scipRoleDefinition
+	case refs.Write:
+		return scipRoleWriteAccess
+	}
+	return scipRoleReadAccess
+}
+
+// hoverText renders a symbol the way it would read in source
+func hoverText(row symbolRow) string {
+	typeName := "?"
+	if row.typ != nil {
+		typeName = row.typ.GetName()
+	}
+	visibility := ""
+	if row.isPublic {
+		visibility = "pub "
+	}
+	switch row.kind {
+	case "function":
+		return fmt.Sprintf("%sdef %s: %s", visibility, row.target.Name, typeName)
+	case "variable":
+		return fmt.Sprintf("%s: %s", row.target.Name, typeName)
+	case "struct", "data":
+		return fmt.Sprintf("%s%s %s", visibility, row.kind, row.target.Name)
+	case "alias":
+		return fmt.Sprintf("%stype %s = %s", visibility, row.target.Name, typeName)
+	case "field", "constructor":
+		return fmt.Sprintf("%s.%s: %s", row.target.Container, row.target.Name, typeName)
+	}
+	return row.target.Name
+}
@@ -0,0 +1,102 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+// protoFields decodes the length-delimited and varint fields of a protobuf message
+func protoFields(t *testing.T, data []byte) map[int][][]byte {
+	fields := make(map[int][][]byte)
+	for len(data) > 0 {
+		key, n := decodeVarint(data)
+		data = data[n:]
+		number, wireType := int(key>>3), key&7
+		switch wireType {
+		case 0:
+			_, n = decodeVarint(data)
+			fields[number] = append(fields[number], data[:n])
+			data = data[n:]
+		case 2:
+			length, n := decodeVarint(data)
+			data = data[n:]
+			fields[number] = append(fields[number], data[:length])
+			data = data[length:]
+		default:
+			t.Fatalf("unexpected wire type %d", wireType)
+		}
+	}
+	return fields
+}
+
+func decodeVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i, b := range data {
+		value |= uint64(b&0x7f) << (7 * i)
+		if b < 0x80 {
+			return value, i + 1
+		}
+	}
+	return value, len(data)
+}
+
+func TestWriteSCIP(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSCIP(&out, "file:///repo", []File{{Path: "src/calls.lyra", Result: callsResult(t)}}); err != nil {
+		t.Fatalf("WriteSCIP error: %v", err)
+	}
+
+	index := protoFields(t, out.Bytes())
+	metadata := protoFields(t, index[scipIndexMetadata][0])
+	if root := string(metadata[scipMetadataProjectRoot][0]); root != "file:///repo" {
+		t.Fatalf("Expected project root file:///repo. Got %s", root)
+	}
+	if len(index[scipIndexDocuments]) != 1 {
+		t.Fatalf("Expected 1 document. Got %d", len(index[scipIndexDocuments]))
+	}
+
+	doc := protoFields(t, index[scipIndexDocuments][0])
+	if path := string(doc[scipDocumentRelativePath][0]); path != "src/calls.lyra" {
+		t.Fatalf("Expected relative path src/calls.lyra. Got %s", path)
+	}
+	// 2 function definitions, 2 parameters, 1 read of n in inc and 2 calls plus 1 read in twice
+	if len(doc[scipDocumentOccurrences]) != 8 {
+		t.Fatalf("Expected 8 occurrences. Got %d", len(doc[scipDocumentOccurrences]))
+	}
+
+	calls := 0
+	for _, occurrence := range doc[scipDocumentOccurrences] {
+		fields := protoFields(t, occurrence)
+		if string(fields[scipOccurrenceSymbol][0]) == "scip-lyra . . . inc()." {
+			calls++
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("Expected inc to be defined once and called twice. Got %d occurrences", calls)
+	}
+
+	var symbolNames []string
+	for _, info := range doc[scipDocumentSymbols] {
+		fields := protoFields(t, info)
+		symbolNames = append(symbolNames, string(fields[scipSymbolInfoSymbol][0]))
+	}
+	if len(symbolNames) != 2 || symbolNames[0] != "scip-lyra . . . inc()." || symbolNames[1] != "scip-lyra . . . twice()." {
+		t.Fatalf("Expected symbol information for inc and twice. Got %v", symbolNames)
+	}
+}
+
+func TestWriteSCIP_Module(t *testing.T) {
+	var out bytes.Buffer
+	file := File{Path: "math/ops/calls.lyra", Module: ModuleOf("math/ops/calls.lyra"), Result: callsResult(t)}
+	if err := WriteSCIP(&out, "file:///repo", []File{file}); err != nil {
+		t.Fatalf("WriteSCIP error: %v", err)
+	}
+	doc := protoFields(t, protoFields(t, out.Bytes())[scipIndexDocuments][0])
+	info := protoFields(t, doc[scipDocumentSymbols][0])
+	if symbol := string(info[scipSymbolInfoSymbol][0]); symbol != "scip-lyra . . . math/ops/inc()." {
+		t.Fatalf("Expected scip-lyra . . . math/ops/inc(). Got %s", symbol)
+	}
+	if module := ModuleOf("../calls.lyra"); module != nil {
+		t.Fatalf("Expected no module outside the root. Got %v", module)
+	}
+}
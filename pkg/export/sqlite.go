@@ -0,0 +1,156 @@
+package export
+
+/*
+SQLite export writes the symbols, references and call graph of analyzed files as a
+SQL script. Feed it to the sqlite3 shell (`lyra export-db -o lyra.db ...` does this)
+to get a database for ad-hoc queries, e.g. functions with more than 5 parameters:
+
+	SELECT name, param_count FROM symbols WHERE kind = 'function' AND param_count > 5;
+
+Schema (all positions are 1-based, as in ast.Location):
+
+	files(id, path)
+	symbols(id, file_id, kind, name, container, qualified_name, type, param_count,
+	        is_public, line, col, end_line, end_col)
+	    kind is one of function, struct, data, constructor, field, variable;
+	    container is the owning type of fields and constructors;
+	    qualified_name is the refs.QualifiedName, geometry.shapes:Point.x, which
+	    is the same for a symbol in every database exported from its project;
+	    type is the printed type (the signature for functions and constructors)
+	refs(id, file_id, symbol_id, name, container, kind, enclosing,
+	     line, col, end_line, end_col)
+	    kind is one of definition, read, write, call;
+	    symbol_id is NULL for locals (parameters and pattern bindings);
+	    enclosing is the function the reference appears in, NULL at module level
+	calls(file_id, caller, callee, line, col)
+	    one row per call site between top-level functions
+*/
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+const sqliteSchema = `CREATE TABLE files (
+	id INTEGER PRIMARY KEY,
+	path TEXT NOT NULL UNIQUE
+);
+CREATE TABLE symbols (
+	id INTEGER PRIMARY KEY,
+	file_id INTEGER NOT NULL REFERENCES files(id),
+	kind TEXT NOT NULL,
+	name TEXT NOT NULL,
+	container TEXT,
+	qualified_name TEXT NOT NULL,
+	type TEXT,
+	param_count INTEGER,
+	is_public INTEGER NOT NULL DEFAULT 0,
+	line INTEGER, col INTEGER, end_line INTEGER, end_col INTEGER
+);
+CREATE TABLE refs (
+	id INTEGER PRIMARY KEY,
+	file_id INTEGER NOT NULL REFERENCES files(id),
+	symbol_id INTEGER REFERENCES symbols(id),
+	name TEXT NOT NULL,
+	container TEXT,
+	kind TEXT NOT NULL,
+	enclosing TEXT,
+	line INTEGER, col INTEGER, end_line INTEGER, end_col INTEGER
+);
+CREATE TABLE calls (
+	file_id INTEGER NOT NULL REFERENCES files(id),
+	caller TEXT NOT NULL,
+	callee TEXT NOT NULL,
+	line INTEGER, col INTEGER
+);
+CREATE INDEX symbols_name ON symbols(name);
+CREATE INDEX symbols_qualified_name ON symbols(qualified_name);
+CREATE INDEX refs_symbol ON refs(symbol_id);
+`
+
+// WriteSQLite writes a SQL script creating and populating the index database
+func WriteSQLite(w io.Writer, files []File) error {
+	e := &sqliteExporter{w: w}
+	e.printf("BEGIN TRANSACTION;\n%s", sqliteSchema)
+	for i, file := range files {
+		e.writeFile(i+1, file)
+	}
+	e.printf("COMMIT;\n")
+	return e.err
+}
+
+type sqliteExporter struct {
+	w        io.Writer
+	err      error
+	symbolID int
+}
+
+func (e *sqliteExporter) printf(format string, args ...any) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = fmt.Fprintf(e.w, format, args...)
+}
+
+func (e *sqliteExporter) writeFile(fileID int, file File) {
+	e.printf("INSERT INTO files (id, path) VALUES (%d, %s);\n", fileID, quote(file.Path))
+
+	symbolIDs := make(map[refs.Target]int)
+	for _, row := range symbolRows(file.Result) {
+		e.symbolID++
+		symbolIDs[row.target] = e.symbolID
+		paramCount := "NULL"
+		if row.paramCount >= 0 {
+			paramCount = fmt.Sprint(row.paramCount)
+		}
+		typeName := "NULL"
+		if row.typ != nil {
+			typeName = quote(row.typ.GetName())
+		}
+		qualified, _ := refs.Qualify(file.Module, row.target)
+		e.printf("INSERT INTO symbols (id, file_id, kind, name, container, qualified_name, type, param_count, is_public, line, col, end_line, end_col) VALUES (%d, %d, %s, %s, %s, %s, %s, %s, %d, %s);\n",
+			e.symbolID, fileID, quote(row.kind), quote(row.target.Name), nullable(row.target.Container), quote(qualified.String()),
+			typeName, paramCount, boolInt(row.isPublic), locationValues(row.location))
+	}
+
+	for _, ref := range file.Result.Index.All() {
+		symbolID := "NULL"
+		if id, ok := symbolIDs[ref.Target]; ok {
+			symbolID = fmt.Sprint(id)
+		}
+		e.printf("INSERT INTO refs (file_id, symbol_id, name, container, kind, enclosing, line, col, end_line, end_col) VALUES (%d, %s, %s, %s, %s, %s, %s);\n",
+			fileID, symbolID, quote(ref.Target.Name), nullable(ref.Target.Container), quote(ref.Kind.String()),
+			nullable(ref.Enclosing), locationValues(ref.Location))
+
+		if ref.Kind == refs.Call && ref.Target.Kind == refs.TargetFunction && ref.Enclosing != "" {
+			e.printf("INSERT INTO calls (file_id, caller, callee, line, col) VALUES (%d, %s, %s, %d, %d);\n",
+				fileID, quote(ref.Enclosing), quote(ref.Target.Name), ref.Location.StartLine, ref.Location.StartCol)
+		}
+	}
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func nullable(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return quote(s)
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func locationValues(loc ast.Location) string {
+	return fmt.Sprintf("%d, %d, %d, %d", loc.StartLine, loc.StartCol, loc.EndLine, loc.EndCol)
+}
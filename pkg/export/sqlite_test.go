@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func at(line, col, length int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+}
+
+// callsResult is the analysis of:
+//
+//	def inc: (Int) -> Int = (n) => n
+//	def twice: (Int) -> Int = (n) => inc(inc(n))
+func callsResult(t *testing.T) *analyzer.Result {
+	signature := &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType}
+	param := func(col int) []ast.Pattern {
+		return []ast.Pattern{&ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: at(1, col, 1)}, Name: "n"}}
+	}
+	ident := func(name string, loc ast.Location) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc}}, Name: name}
+	}
+	inc := &ast.FunctionDefStmt{Name: "inc", NameLocation: at(1, 5, 3), Signature: signature, Clauses: []*ast.FunctionClause{
+		{Parameters: param(26), Body: ident("n", at(1, 32, 1))},
+	}}
+	twice := &ast.FunctionDefStmt{Name: "twice", NameLocation: at(2, 5, 5), Signature: signature, Clauses: []*ast.FunctionClause{
+		{Parameters: param(28), Body: &ast.CallExpr{Callee: ident("inc", at(2, 34, 3)), Arguments: []ast.Expression{
+			&ast.CallExpr{Callee: ident("inc", at(2, 38, 3)), Arguments: []ast.Expression{ident("n", at(2, 42, 1))}},
+		}}},
+	}}
+
+	table := symbols.NewSymbolTable()
+	for _, fn := range []*ast.FunctionDefStmt{inc, twice} {
+		if err := table.RegisterFunction(fn); err != nil {
+			t.Fatalf("RegisterFunction error: %v", err)
+		}
+	}
+	program := &ast.Program{Statements: []ast.AstNode{inc, twice}}
+	return &analyzer.Result{Program: program, Table: table, Index: refs.Build(program, table)}
+}
+
+func TestWriteSQLite(t *testing.T) {
+	var script bytes.Buffer
+	if err := WriteSQLite(&script, []File{{Path: "calls.lyra", Result: callsResult(t)}}); err != nil {
+		t.Fatalf("WriteSQLite error: %v", err)
+	}
+	if !strings.Contains(script.String(), "INSERT INTO calls (file_id, caller, callee, line, col) VALUES (1, 'twice', 'inc', 2, 34);") {
+		t.Fatalf("Expected a call edge from twice to inc. Script:\n%s", script.String())
+	}
+
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 shell not installed")
+	}
+	query := exec.Command("sqlite3", ":memory:", "-cmd", script.String(),
+		"SELECT s.name, count(*) FROM refs r JOIN symbols s ON s.id = r.symbol_id WHERE r.kind = 'call' GROUP BY s.name;")
+	output, err := query.CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 error: %v\n%s", err, output)
+	}
+	if strings.TrimSpace(string(output)) != "inc|2" {
+		t.Fatalf("Expected inc to be called twice. Got %q", output)
+	}
+}
@@ -0,0 +1,90 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/parser"
+	sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// corpusWidths exercises both the default layout and aggressive signature wrapping
+var corpusWidths = []int{DefaultOptions.Width, 40}
+
+func corpus(t *testing.T) map[string][]byte {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.lyra"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no corpus files found in testdata: %v", err)
+	}
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile error: %v", err)
+		}
+		files[path] = source
+	}
+	return files
+}
+
+func TestCorpus_Idempotent(t *testing.T) {
+	for path, source := range corpus(t) {
+		for _, width := range corpusWidths {
+			opts := DefaultOptions
+			opts.Width = width
+			once := Source(source, opts)
+			if twice := Source(once, opts); string(twice) != string(once) {
+				t.Fatalf("%s (width %d): format(format(x)) != format(x)\nOnce:\n%s\nTwice:\n%s", path, width, once, twice)
+			}
+		}
+	}
+}
+
+func TestCorpus_StructurePreserved(t *testing.T) {
+	for path, source := range corpus(t) {
+		expected := structure(t, source)
+		for _, width := range corpusWidths {
+			opts := DefaultOptions
+			opts.Width = width
+			formatted := Source(source, opts)
+			if got := structure(t, formatted); got != expected {
+				t.Fatalf("%s (width %d): formatting changed the parse tree\nExpected:\n%s\nGot:\n%s", path, width, expected, got)
+			}
+		}
+	}
+}
+
+// structure renders the named nodes of the parse tree with the text of leaves,
+// ignoring comments and punctuation such as trailing commas
+func structure(t *testing.T, source []byte) string {
+	t.Helper()
+	tree, err := parser.Parse(string(source))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	defer tree.Close()
+	if tree.RootNode().HasError() {
+		t.Fatalf("Parse error in:\n%s", source)
+	}
+
+	var b strings.Builder
+	var walk func(node *sitter.Node, depth int)
+	walk = func(node *sitter.Node, depth int) {
+		if node.Kind() == "comment" {
+			return
+		}
+		b.WriteString(strings.Repeat("  ", depth) + node.Kind())
+		if node.NamedChildCount() == 0 {
+			b.WriteString(" " + string(source[node.StartByte():node.EndByte()]))
+		}
+		b.WriteString("\n")
+		for i := uint(0); i < node.NamedChildCount(); i++ {
+			walk(node.NamedChild(i), depth+1)
+		}
+	}
+	walk(tree.RootNode(), 0)
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package format
+
+/*
+Format lays out Lyra source line by line. It re-indents by bracket depth and
+otherwise keeps the author's layout, applying these policies:
+
+  - blank lines: runs of blank lines collapse to one; blank lines at the start or
+    end of the file and just inside brackets are dropped
+  - comments: a trailing comment stays on the line it was written on, one space
+    after the code; own-line comments are indented like code
+  - signatures: a function definition longer than Options.Width has its signature
+    parameter list wrapped one parameter per line, with a trailing comma
+
+Strings and comments are never rewritten; lines inside a block comment are kept
+verbatim, and so are the lines of a multiline """ string up to and including its
+closing """, whose indentation the string's value depends on.
+*/
+
+import (
+	"strings"
+)
+
+type Options struct {
+	Width    int    // maximum line width before signatures are wrapped
+	Indent   string // one level of indentation
+	TabWidth int    // columns a tab counts for when measuring width
+}
+
+var DefaultOptions = Options{Width: 100, Indent: "\t", TabWidth: 4}
+
+// Source formats src according to opts
+func Source(src []byte, opts Options) []byte {
+	f := &formatter{opts: opts}
+	for _, raw := range strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n") {
+		f.line(raw)
+	}
+	return []byte(f.finish())
+}
+
+type formatter struct {
+	opts Options
+	out  []string
+
+	depth        int  // bracket depth at the start of the next line
+	pendingBlank bool // a blank line was seen and will be emitted before the next code line
+	inComment    bool // inside a /* */ comment spanning lines
+	inString     bool // inside a multiline """ string
+}
+
+func (f *formatter) line(raw string) {
+	if f.inComment {
+		f.out = append(f.out, strings.TrimRight(raw, " \t"))
+		f.inComment = !strings.Contains(raw, "*/")
+		return
+	}
+
+	if f.inString {
+		f.stringLine(raw)
+		return
+	}
+
+	text := strings.TrimSpace(raw)
+	if text == "" {
+		f.pendingBlank = len(f.out) > 0 && !opensBlock(f.out[len(f.out)-1])
+		return
+	}
+
+	s := scan(text)
+	depth := max(f.depth-s.leadingClosers, 0)
+	if f.pendingBlank && s.leadingClosers == 0 {
+		f.out = append(f.out, "")
+	}
+	f.pendingBlank = false
+
+	indent := strings.Repeat(f.opts.Indent, depth)
+	if s.comment != "" && s.code != "" {
+		text = s.code + " " + s.comment
+	}
+	if isFunctionDef(s.code) && f.width(indent+text) > f.opts.Width {
+		f.out = append(f.out, wrapSignature(indent, f.opts.Indent, s)...)
+	} else {
+		f.out = append(f.out, indent+text)
+	}
+
+	f.depth = max(f.depth+s.delta, 0)
+	f.inComment = s.openComment
+	f.inString = s.openString
+}
+
+// stringLine keeps a line of a multiline string as written. On the line of the
+// closing """ only the code after it is laid out.
+func (f *formatter) stringLine(raw string) {
+	if !strings.HasPrefix(strings.TrimLeft(raw, " \t"), `"""`) {
+		f.out = append(f.out, raw)
+		return
+	}
+	end := strings.Index(raw, `"""`) + 3
+	s := scan(strings.TrimSpace(raw[end:]))
+	text := raw[:end] + s.code
+	if s.comment != "" {
+		text = strings.TrimRight(text, " \t") + " " + s.comment
+	}
+	f.out = append(f.out, text)
+	f.depth = max(f.depth+s.delta, 0)
+	f.inComment = s.openComment
+	f.inString = s.openString
+}
+
+func (f *formatter) finish() string {
+	if len(f.out) == 0 {
+		return ""
+	}
+	return strings.Join(f.out, "\n") + "\n"
+}
+
+func (f *formatter) width(line string) int {
+	tabs := strings.Count(line, "\t")
+	return len(line) - tabs + tabs*f.opts.TabWidth
+}
+
+// opensBlock reports whether an emitted line ends with an opening bracket
+func opensBlock(line string) bool {
+	code := scan(strings.TrimSpace(line)).code
+	return strings.HasSuffix(code, "{") || strings.HasSuffix(code, "(") || strings.HasSuffix(code, "[")
+}
+
+func isFunctionDef(code string) bool {
+	code = strings.TrimPrefix(code, "pub ")
+	return strings.HasPrefix(code, "def ")
+}
@@ -0,0 +1,100 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func check(t *testing.T, opts Options, source, expected string) {
+	t.Helper()
+	got := string(Source([]byte(source), opts))
+	if got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+	if again := string(Source([]byte(got), opts)); again != got {
+		t.Fatalf("Formatting is not idempotent. Second pass:\n%s", again)
+	}
+}
+
+func TestFormat_BlankLinesCollapse(t *testing.T) {
+	check(t, DefaultOptions, "\n\nlet a: Int = 1\n\n\n\nlet b: Int = 2\nlet c: Int = 3\n\n\n",
+		"let a: Int = 1\n\nlet b: Int = 2\nlet c: Int = 3\n")
+}
+
+func TestFormat_BlankLinesInsideBlocks(t *testing.T) {
+	source := `struct Point {
+
+x: Int,
+
+    y: Int,
+
+}`
+	expected := "struct Point {\n\tx: Int,\n\n\ty: Int,\n}\n"
+	check(t, DefaultOptions, source, expected)
+}
+
+func TestFormat_FunctionClauses(t *testing.T) {
+	source := `def fib: (Int) -> Int = {
+  (n) if n < 2 => n,
+
+
+  (n) => fib(n-2) + fib(n-1),
+}`
+	expected := "def fib: (Int) -> Int = {\n\t(n) if n < 2 => n,\n\n\t(n) => fib(n-2) + fib(n-1),\n}\n"
+	check(t, DefaultOptions, source, expected)
+}
+
+func TestFormat_TrailingComments(t *testing.T) {
+	source := "let a: Int = 1    // the first\nstruct Point {\n  x: Int, // horizontal\n      // own line\n  y: Int,\n}   // end"
+	expected := "let a: Int = 1 // the first\nstruct Point {\n\tx: Int, // horizontal\n\t// own line\n\ty: Int,\n} // end\n"
+	check(t, DefaultOptions, source, expected)
+}
+
+func TestFormat_CommentsAreNotCode(t *testing.T) {
+	source := "struct Point { // {\n/* (\n  kept   as is\n*/\nx: Int,\n}"
+	expected := "struct Point { // {\n\t/* (\n  kept   as is\n*/\n\tx: Int,\n}\n"
+	check(t, DefaultOptions, source, expected)
+}
+
+func TestFormat_StringsAreNotCode(t *testing.T) {
+	source := "let a: String = \"{ // not a comment\"\nlet b: Char = '('"
+	expected := "let a: String = \"{ // not a comment\"\nlet b: Char = '('\n"
+	check(t, DefaultOptions, source, expected)
+}
+
+func TestFormat_RawAndMultilineStrings(t *testing.T) {
+	source := "let dir: String = r\"C:\\\" // (\n  let usage: String = \"\"\"\n    lyra check {\n\n  \t  -w\n    \"\"\"   // )\nprint(\"\"\"\n  x\n  \"\"\")"
+	expected := "let dir: String = r\"C:\\\" // (\nlet usage: String = \"\"\"\n    lyra check {\n\n  \t  -w\n    \"\"\" // )\nprint(\"\"\"\n  x\n  \"\"\")\n"
+	check(t, DefaultOptions, source, expected)
+}
+
+func TestFormat_LongSignatureWraps(t *testing.T) {
+	opts := DefaultOptions
+	opts.Width = 40
+	source := "pub def clamp: (Int, Int, Int) -> Int = (x, lo, hi) => x // keep"
+	expected := "pub def clamp: (\n\tInt,\n\tInt,\n\tInt,\n) -> Int = (x, lo, hi) => x // keep\n"
+	check(t, opts, source, expected)
+}
+
+func TestFormat_LongGenericSignatureWraps(t *testing.T) {
+	opts := DefaultOptions
+	opts.Width = 30
+	source := "def map<a, b>: ((a) -> b, [a]) -> [b] = (f, xs) => xs"
+	expected := "def map<a, b>: (\n\t(a) -> b,\n\t[a],\n) -> [b] = (f, xs) => xs\n"
+	check(t, opts, source, expected)
+}
+
+func TestFormat_ShortSignatureKept(t *testing.T) {
+	source := "def sum: (Int, Int) -> Int = (a, b) => a + b"
+	check(t, DefaultOptions, source, source+"\n")
+}
+
+func TestFormat_WidthCountsIndentation(t *testing.T) {
+	opts := DefaultOptions
+	opts.Width = len("def f: (Int) -> Int = (n) => n")
+	source := "{\ndef f: (Int) -> Int = (n) => n\n}"
+	got := string(Source([]byte(source), opts))
+	if !strings.Contains(got, "\tdef f: (\n\t\tInt,\n\t) -> Int") {
+		t.Fatalf("Expected the indented signature to wrap. Got:\n%s", got)
+	}
+}
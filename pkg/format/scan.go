@@ -0,0 +1,90 @@
+package format
+
+import "strings"
+
+// scanned is a single trimmed source line split into code and trailing comment
+type scanned struct {
+	code           string // code without the trailing comment, right-trimmed
+	comment        string // trailing comment, "" if none
+	delta          int    // net bracket depth change over the line
+	leadingClosers int    // closing brackets before any other code
+	openComment    bool   // the line ends inside a /* comment
+	openString     bool   // the line ends inside a multiline """ string
+}
+
+// scan splits a trimmed line, skipping over string and character literals
+func scan(line string) scanned {
+	var s scanned
+	sawCode := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], `r"""`) && !identifierByte(line, i-1):
+			i += strings.IndexByte(line[i:], '"') + 3
+			end := strings.Index(line[i:], `"""`)
+			if end < 0 {
+				s.code = line
+				s.openString = true
+				return s
+			}
+			i += end + 2
+		case c == 'r' && strings.HasPrefix(line[i+1:], `"`) && !identifierByte(line, i-1):
+			// a raw string has no escapes: it ends at the next quote
+			end := strings.IndexByte(line[i+2:], '"')
+			if end < 0 {
+				end = len(line) - i - 3
+			}
+			i += end + 2
+		case c == '"' || c == '\'':
+			i = skipLiteral(line, i)
+		case strings.HasPrefix(line[i:], "//"):
+			s.code, s.comment = strings.TrimRight(line[:i], " \t"), line[i:]
+			return s
+		case strings.HasPrefix(line[i:], "/*"):
+			end := strings.Index(line[i+2:], "*/")
+			if end < 0 {
+				s.code, s.comment = strings.TrimRight(line[:i], " \t"), line[i:]
+				s.openComment = true
+				return s
+			}
+			i += end + 3
+			continue
+		case c == '(' || c == '{' || c == '[':
+			s.delta++
+		case c == ')' || c == '}' || c == ']':
+			s.delta--
+			if !sawCode {
+				s.leadingClosers++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' {
+			sawCode = true
+		}
+	}
+	s.code = line
+	return s
+}
+
+// identifierByte reports whether line[i] exists and may be part of an identifier
+func identifierByte(line string, i int) bool {
+	if i < 0 {
+		return false
+	}
+	c := line[i]
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// skipLiteral returns the index of the quote closing the literal opened at start
+func skipLiteral(line string, start int) int {
+	quote := line[start]
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return i
+		}
+	}
+	return len(line) - 1
+}
@@ -0,0 +1,120 @@
+package format
+
+import "strings"
+
+// wrapSignature breaks the parameter list of a function signature, the first
+// parenthesized list after the name's `:`, into one parameter per line:
+//
+//	def f: (
+//		Int,
+//		Int,
+//	) -> Int = ...
+//
+// The trailing comment, if any, stays on the closing line.
+func wrapSignature(indent, unit string, s scanned) []string {
+	code := s.code
+	open := signatureParams(code)
+	if open < 0 {
+		return []string{indent + joinComment(code, s.comment)}
+	}
+	closing := matchingParen(code, open)
+	if closing < 0 {
+		return []string{indent + joinComment(code, s.comment)}
+	}
+	params := splitTopLevel(code[open+1 : closing])
+	if len(params) == 0 {
+		return []string{indent + joinComment(code, s.comment)}
+	}
+
+	lines := []string{indent + code[:open+1]}
+	for _, param := range params {
+		lines = append(lines, indent+unit+param+",")
+	}
+	return append(lines, indent+joinComment(code[closing:], s.comment))
+}
+
+func joinComment(code, comment string) string {
+	if comment == "" {
+		return code
+	}
+	return code + " " + comment
+}
+
+// signatureParams returns the index of the `(` opening the signature parameters,
+// skipping generic parameters such as `def map<a, b>: ((a) -> b, [a]) -> [b]`
+func signatureParams(code string) int {
+	colon := -1
+	angle := 0
+	for i := 0; i < len(code) && colon < 0; i++ {
+		switch code[i] {
+		case '<':
+			angle++
+		case '>':
+			angle--
+		case ':':
+			if angle == 0 {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return -1
+	}
+	rest := strings.TrimLeft(code[colon+1:], " ")
+	if !strings.HasPrefix(rest, "(") {
+		return -1
+	}
+	return len(code) - len(rest)
+}
+
+// matchingParen returns the index of the `)` closing the `(` at open, or -1
+func matchingParen(code string, open int) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '"', '\'':
+			i = skipLiteral(code, i)
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits a parameter list on commas outside nested brackets
+func splitTopLevel(list string) []string {
+	var params []string
+	depth, start := 0, 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '"', '\'':
+			i = skipLiteral(list, i)
+		case '(', '[', '{', '<':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '>':
+			if i == 0 || list[i-1] != '-' { // not an arrow
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				params = appendParam(params, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return appendParam(params, list[start:])
+}
+
+func appendParam(params []string, param string) []string {
+	if param = strings.TrimSpace(param); param != "" {
+		params = append(params, param)
+	}
+	return params
+}
@@ -0,0 +1,216 @@
+package highlight
+
+/*
+Highlight derives syntax highlighting definitions from the node kinds of the
+tree-sitter grammar, so editors without the language server still highlight
+Lyra: a TextMate grammar (VS Code, Sublime, GitHub) and a highlights.scm query
+(Neovim, Helix, Zed). Keywords, operators and punctuation are the anonymous
+tokens of the grammar; named nodes are highlighted by the captures table. Both
+files are regenerated from the parser, so new keywords need no manual update.
+*/
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/parser"
+)
+
+// captures maps named node kinds to highlights.scm capture names
+var captures = map[string]string{
+	"comment":                    "comment",
+	"string":                     "string",
+	"string_literal":             "string",
+	"raw_string_literal":         "string",
+	"multiline_string_literal":   "string",
+	"extern_target":              "string.special",
+	"integer":                    "number",
+	"integer_literal":            "number",
+	"float":                      "number.float",
+	"float_literal":              "number.float",
+	"boolean":                    "boolean",
+	"boolean_literal":            "boolean",
+	"signed_integer_type":        "type.builtin",
+	"unsigned_integer_type":      "type.builtin",
+	"float_type":                 "type.builtin",
+	"string_type":                "type.builtin",
+	"boolean_type":               "type.builtin",
+	"user_defined_type_name":     "type",
+	"struct_name":                "type",
+	"data_type_name":             "type",
+	"generic_type":               "type",
+	"data_type_constructor_name": "constructor",
+	"attribute":                  "attribute",
+	"visibility":                 "keyword.modifier",
+	"pure":                       "keyword.modifier",
+	"async":                      "keyword.modifier",
+	"identifier":                 "variable",
+}
+
+// Spec is the highlighting vocabulary of the grammar
+type Spec struct {
+	Keywords   []string
+	Constants  []string // true, false
+	Operators  []string
+	Brackets   []string
+	Delimiters []string
+	Named      map[string]string // named node kind -> capture
+}
+
+// FromKinds classifies the node kinds of the grammar
+func FromKinds(kinds []parser.NodeKind) *Spec {
+	spec := &Spec{Named: make(map[string]string)}
+	seen := make(map[string]bool)
+	for _, kind := range kinds {
+		key := fmt.Sprint(kind.Named, kind.Name)
+		if seen[key] || kind.Name == "" {
+			continue
+		}
+		seen[key] = true
+		if kind.Named {
+			if capture, ok := captures[kind.Name]; ok {
+				spec.Named[kind.Name] = capture
+			}
+			continue
+		}
+		switch {
+		case kind.Name == "true" || kind.Name == "false":
+			spec.Constants = append(spec.Constants, kind.Name)
+		case isWord(kind.Name):
+			spec.Keywords = append(spec.Keywords, kind.Name)
+		case strings.Contains("()[]{}", kind.Name):
+			spec.Brackets = append(spec.Brackets, kind.Name)
+		case kind.Name == "," || kind.Name == ";" || kind.Name == ":" || kind.Name == ".":
+			spec.Delimiters = append(spec.Delimiters, kind.Name)
+		case strings.ContainsAny(kind.Name, `"'/`+"`"):
+			// quotes and comment markers belong to string and comment nodes
+		default:
+			spec.Operators = append(spec.Operators, kind.Name)
+		}
+	}
+	for _, list := range [][]string{spec.Keywords, spec.Constants, spec.Operators, spec.Brackets, spec.Delimiters} {
+		sort.Strings(list)
+	}
+	return spec
+}
+
+func isWord(s string) bool {
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteQueries writes a tree-sitter highlights.scm
+func WriteQueries(w io.Writer, spec *Spec) error {
+	var b strings.Builder
+	b.WriteString("; Generated by lyra highlight-spec. DO NOT EDIT.\n")
+	group := func(tokens []string, capture string) {
+		if len(tokens) == 0 {
+			return
+		}
+		quoted := make([]string, len(tokens))
+		for i, token := range tokens {
+			quoted[i] = fmt.Sprintf("%q", token)
+		}
+		fmt.Fprintf(&b, "\n[%s] @%s\n", strings.Join(quoted, " "), capture)
+	}
+	// identifier comes first: later patterns take precedence in most editors
+	if capture, ok := spec.Named["identifier"]; ok {
+		fmt.Fprintf(&b, "\n(identifier) @%s\n", capture)
+	}
+	names := make([]string, 0, len(spec.Named))
+	for name := range spec.Named {
+		if name != "identifier" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 0 {
+		b.WriteString("\n")
+	}
+	for _, name := range names {
+		fmt.Fprintf(&b, "(%s) @%s\n", name, spec.Named[name])
+	}
+	group(spec.Keywords, "keyword")
+	group(spec.Constants, "boolean")
+	group(spec.Operators, "operator")
+	group(spec.Brackets, "punctuation.bracket")
+	group(spec.Delimiters, "punctuation.delimiter")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// TextMate returns a TextMate grammar for the source.lyra scope, to be encoded as JSON
+func TextMate(spec *Spec) map[string]any {
+	var patterns []any
+	include := func(name string) { patterns = append(patterns, map[string]any{"include": "#" + name}) }
+	repository := map[string]any{
+		"comments": map[string]any{"patterns": []any{
+			map[string]any{"name": "comment.line.double-slash.lyra", "match": `//.*$`},
+			map[string]any{"name": "comment.block.lyra", "begin": `/\*`, "end": `\*/`},
+		}},
+		"strings": map[string]any{"patterns": []any{
+			map[string]any{"name": "string.quoted.triple.raw.lyra", "begin": `\br"""`, "end": `"""`},
+			map[string]any{"name": "string.quoted.triple.lyra", "begin": `"""`, "end": `"""`,
+				"patterns": []any{map[string]any{"name": "constant.character.escape.lyra", "match": `\\.`}}},
+			map[string]any{"name": "string.quoted.double.raw.lyra", "match": `\br"[^"\n]*"`},
+			map[string]any{"name": "string.quoted.double.lyra", "begin": `"`, "end": `"`,
+				"patterns": []any{map[string]any{"name": "constant.character.escape.lyra", "match": `\\.`}}},
+			map[string]any{"name": "string.quoted.single.lyra", "match": `'(?:[^'\\]|\\.)'`},
+		}},
+		"numbers": map[string]any{"patterns": []any{
+			map[string]any{"name": "constant.numeric.float.lyra", "match": `\b\d[\d_]*(?:\.\d[\d_]*)?(?:[eE][+-]?\d[\d_]*)\b|\b\d[\d_]*\.\d[\d_]*\b`},
+			map[string]any{"name": "constant.numeric.float.lyra", "match": `\b(?:Inf|NaN)\b`},
+			map[string]any{"name": "constant.numeric.integer.lyra", "match": `\b(?:0[xX][0-9a-fA-F_]+|0[oO][0-7_]+|0[bB][01_]+|\d[\d_]*)\b`},
+		}},
+		"attributes": map[string]any{"name": "entity.other.attribute-name.lyra", "match": `@[a-z_][A-Za-z0-9_]*`},
+		"types":      map[string]any{"name": "entity.name.type.lyra", "match": `\b[A-Z][A-Za-z0-9]*\b`},
+		"functions":  map[string]any{"name": "entity.name.function.lyra", "match": `\b[a-z_][A-Za-z0-9_]*(?=\s*\()`},
+	}
+	for _, name := range []string{"comments", "strings", "numbers", "attributes"} {
+		include(name)
+	}
+	if len(spec.Keywords) > 0 {
+		repository["keywords"] = map[string]any{"name": "keyword.control.lyra", "match": wordsPattern(spec.Keywords)}
+		include("keywords")
+	}
+	if len(spec.Constants) > 0 {
+		repository["constants"] = map[string]any{"name": "constant.language.lyra", "match": wordsPattern(spec.Constants)}
+		include("constants")
+	}
+	include("types")
+	include("functions")
+	if len(spec.Operators) > 0 {
+		repository["operators"] = map[string]any{"name": "keyword.operator.lyra", "match": alternation(spec.Operators)}
+		include("operators")
+	}
+	return map[string]any{
+		"$schema":    "https://raw.githubusercontent.com/martinring/tmlanguage/master/tmlanguage.json",
+		"name":       "Lyra",
+		"scopeName":  "source.lyra",
+		"fileTypes":  []string{"lyra"},
+		"patterns":   patterns,
+		"repository": repository,
+	}
+}
+
+func wordsPattern(words []string) string {
+	return `\b(?:` + strings.Join(words, "|") + `)\b`
+}
+
+// alternation matches any of tokens, longest first so => is not read as =
+func alternation(tokens []string) string {
+	sorted := append([]string(nil), tokens...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	quoted := make([]string, len(sorted))
+	for i, token := range sorted {
+		quoted[i] = regexp.QuoteMeta(token)
+	}
+	return strings.Join(quoted, "|")
+}
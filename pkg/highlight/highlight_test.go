@@ -0,0 +1,54 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/parser"
+)
+
+var kinds = []parser.NodeKind{
+	{Name: "def"}, {Name: "pub"}, {Name: "true"}, {Name: "false"},
+	{Name: "="}, {Name: "=>"}, {Name: "("}, {Name: ")"}, {Name: ","}, {Name: `"`},
+	{Name: "identifier", Named: true}, {Name: "integer_literal", Named: true},
+	{Name: "struct_name", Named: true}, {Name: "function_clause", Named: true},
+	{Name: "def"},
+}
+
+func TestWriteQueries(t *testing.T) {
+	var b strings.Builder
+	if err := WriteQueries(&b, FromKinds(kinds)); err != nil {
+		t.Fatalf("WriteQueries error: %v", err)
+	}
+	expected := `; Generated by lyra highlight-spec. DO NOT EDIT.
+
+(identifier) @variable
+
+(integer_literal) @number
+(struct_name) @type
+
+["def" "pub"] @keyword
+
+["false" "true"] @boolean
+
+["=" "=>"] @operator
+
+["(" ")"] @punctuation.bracket
+
+[","] @punctuation.delimiter
+`
+	if b.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, b.String())
+	}
+}
+
+func TestTextMate_FollowsGrammarTokens(t *testing.T) {
+	grammar := TextMate(FromKinds(kinds))
+	repository := grammar["repository"].(map[string]any)
+	if match := repository["keywords"].(map[string]any)["match"]; match != `\b(?:def|pub)\b` {
+		t.Fatalf("Expected the grammar keywords. Got %v", match)
+	}
+	if match := repository["operators"].(map[string]any)["match"]; match != `=>|=` {
+		t.Fatalf("Expected the longest operator first. Got %v", match)
+	}
+}
@@ -0,0 +1,78 @@
+package interp
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// constants are the values of checker.BuiltinConstants
+var constants = map[string]Value{"Inf": math.Inf(1), "NaN": math.NaN()}
+
+// builtins implement the functions the checker types in checker.Builtins
+var builtins = map[string]*Function{
+	"assert": {Name: "assert", Arity: -1, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		if len(args) == 0 {
+			fail(loc, "assert expects a condition")
+		}
+		if cond, ok := args[0].(bool); !ok || !cond {
+			if len(args) > 1 {
+				fail(loc, "assertion failed: %v", args[1])
+			}
+			fail(loc, "assertion failed")
+		}
+		return Unit{}
+	}},
+	"debug": {Name: "debug", Arity: 1, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		in.outputMu.Lock()
+		defer in.outputMu.Unlock()
+		fmt.Fprintf(in.output, "[%d:%d] %s\n", loc.StartLine, loc.StartCol, FormatValue(args[0]))
+		return args[0]
+	}},
+	"todo": {Name: "todo", Arity: 0, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		fail(loc, "not implemented (todo)")
+		return nil
+	}},
+	"to_json": {Name: "to_json", Arity: 1, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		return in.toJSON(args[0], loc)
+	}},
+	// from_json decodes into the type expected where it is called, so evalCall
+	// calls fromJSON with the checked type of the call instead
+	"from_json": {Name: "from_json", Arity: 1},
+	"render": {Name: "render", Arity: 2, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		return render(args[0], args[1], loc)
+	}},
+}
+
+// render replaces the {field} placeholders of template with the fields of value;
+// strings are inserted as they are, other values as FormatValue prints them
+func render(template, value Value, loc ast.Location) Value {
+	text, _ := template.(string)
+	parts, err := ast.ParseTemplate(text)
+	if err != nil {
+		fail(loc, "render: %v", err)
+	}
+	structValue, ok := value.(*StructValue)
+	if !ok {
+		fail(loc, "render: %s is not a struct", FormatValue(value))
+	}
+	var out strings.Builder
+	for _, part := range parts {
+		if part.Field == "" {
+			out.WriteString(part.Text)
+			continue
+		}
+		field, ok := structValue.Fields[part.Field]
+		if !ok {
+			fail(loc, "render: %s has no field %s", structValue.Type, part.Field)
+		}
+		if s, isString := field.(string); isString {
+			out.WriteString(s)
+		} else {
+			out.WriteString(FormatValue(field))
+		}
+	}
+	return out.String()
+}
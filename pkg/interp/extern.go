@@ -0,0 +1,163 @@
+package interp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// RegisterExtern makes the Go function fn the implementation of the extern
+// declarations with target, e.g.
+//
+//	in.RegisterExtern("go:time.UnixNano", func() int64 { return time.Now().UnixNano() })
+//
+// Lyra integers are passed as any Go integer type, floats as float32 or float64,
+// Bool and String as bool and string. A Unit result is no Go result, a tuple
+// result one Go result per element, and a final error result raises a runtime
+// error when it is not nil. Init checks that every
+// extern of the program is registered with a matching Go signature.
+func (in *Interpreter) RegisterExtern(target string, fn any) error {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func {
+		return fmt.Errorf("extern %s: %T is not a function", target, fn)
+	}
+	if value.Type().IsVariadic() {
+		return fmt.Errorf("extern %s: variadic Go functions are not supported", target)
+	}
+	if in.externs == nil {
+		in.externs = make(map[string]reflect.Value)
+	}
+	in.externs[target] = value
+	return nil
+}
+
+// bindExterns checks the Go functions registered for the externs of the program
+func (in *Interpreter) bindExterns() error {
+	for _, stmt := range in.program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || !fn.IsExtern() {
+			continue
+		}
+		impl, ok := in.externs[fn.Extern]
+		if !ok {
+			return &RuntimeError{Message: fmt.Sprintf("extern %s: no Go function registered for %s", fn.Name, fn.Extern), Location: fn.Location}
+		}
+		if err := matchSignature(fn.Signature, impl.Type()); err != nil {
+			return &RuntimeError{Message: fmt.Sprintf("extern %s: %s: %v", fn.Name, impl.Type(), err), Location: fn.Location}
+		}
+	}
+	return nil
+}
+
+func matchSignature(signature *types.FunctionType, goType reflect.Type) error {
+	if signature == nil {
+		return fmt.Errorf("missing signature")
+	}
+	if goType.NumIn() != len(signature.ParameterTypes) {
+		return fmt.Errorf("expected %d parameters", len(signature.ParameterTypes))
+	}
+	for i, param := range signature.ParameterTypes {
+		if !goCompatible(param.Type, goType.In(i)) {
+			return fmt.Errorf("parameter %d: cannot pass %s as %s", i+1, param.Type.GetName(), goType.In(i))
+		}
+	}
+
+	results := goType.NumOut()
+	if results > 0 && goType.Out(results-1) == errorType {
+		results--
+	}
+	if unit, ok := signature.ReturnType.(types.PrimitiveType); ok && unit.Name == types.Unit {
+		if results != 0 {
+			return fmt.Errorf("a Unit result must have no Go result besides an error")
+		}
+		return nil
+	}
+	if tuple, ok := signature.ReturnType.(types.TupleType); ok {
+		if results != len(tuple.Elements) {
+			return fmt.Errorf("expected %d results for %s (and an optional error)", len(tuple.Elements), tuple.GetName())
+		}
+		for i, element := range tuple.Elements {
+			if !goCompatible(element, goType.Out(i)) {
+				return fmt.Errorf("result %d: cannot return %s as %s", i+1, goType.Out(i), element.GetName())
+			}
+		}
+		return nil
+	}
+	if results != 1 {
+		return fmt.Errorf("expected one result (and an optional error)")
+	}
+	if !goCompatible(signature.ReturnType, goType.Out(0)) {
+		return fmt.Errorf("cannot return %s as %s", goType.Out(0), signature.ReturnType.GetName())
+	}
+	return nil
+}
+
+// goCompatible reports whether values of the Lyra type t convert to and from goType
+func goCompatible(t types.Type, goType reflect.Type) bool {
+	p, ok := t.(types.PrimitiveType)
+	if !ok {
+		return false
+	}
+	kind := goType.Kind()
+	switch {
+	case p.Name == types.Bool:
+		return kind == reflect.Bool
+	case p.Name == types.String:
+		return kind == reflect.String
+	case strings.HasPrefix(string(p.Name), "Float"):
+		return kind == reflect.Float32 || kind == reflect.Float64
+	case p.IsNumericType():
+		return reflect.Int <= kind && kind <= reflect.Uint64
+	}
+	return false
+}
+
+func (in *Interpreter) callExtern(fn *ast.FunctionDefStmt, args []Value, loc ast.Location) Value {
+	impl := in.externs[fn.Extern]
+	goType := impl.Type()
+	goArgs := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		goArgs[i] = reflect.ValueOf(arg).Convert(goType.In(i))
+	}
+
+	results := impl.Call(goArgs)
+	if n := len(results); n > 0 && goType.Out(n-1) == errorType {
+		if err, _ := results[n-1].Interface().(error); err != nil {
+			fail(loc, "%s: %v", fn.Name, err)
+		}
+		results = results[:n-1]
+	}
+	if _, ok := fn.Signature.ReturnType.(types.TupleType); ok {
+		elements := make([]Value, len(results))
+		for i, result := range results {
+			elements[i] = lyraValue(result)
+		}
+		return &TupleValue{Elements: elements}
+	}
+	if len(results) == 0 {
+		return Unit{}
+	}
+	return lyraValue(results[0])
+}
+
+// lyraValue converts a Go result to the interpreter's representation
+func lyraValue(v reflect.Value) Value {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.String:
+		return v.String()
+	}
+	return v.Interface()
+}
@@ -0,0 +1,137 @@
+package interp
+
+import (
+	"math"
+	"sort"
+)
+
+// Kinds of inspected values
+const (
+	KindUnit     = "unit"
+	KindInt      = "int"
+	KindFloat    = "float"
+	KindBool     = "bool"
+	KindString   = "string"
+	KindStruct   = "struct"
+	KindData     = "data"
+	KindArray    = "array"
+	KindTuple    = "tuple"
+	KindMap      = "map"
+	KindFunction = "function"
+	KindClosure  = "closure"
+)
+
+// Inspection is the JSON form of a runtime value shown to people and tools: the
+// REPL's :inspect, the returned values of failing tests and debugger views all
+// use it. Fields are only ever added to it, so clients may rely on the ones here.
+// Text is the value as FormatValue renders it; Value holds the value of a number,
+// Bool or String (absent for Inf and NaN, which JSON cannot hold); Fields lists
+// the fields of a struct or record constructor by name, Elements the elements of
+// an array or tuple and the arguments of a positional constructor, Entries the
+// entries of a map in insertion order, and Captured the bindings a closure
+// captured.
+type Inspection struct {
+	Kind        string           `json:"kind"`
+	Text        string           `json:"text"`
+	Type        string           `json:"type,omitempty"`        // struct or data type
+	Constructor string           `json:"constructor,omitempty"` // data constructor
+	Name        string           `json:"name,omitempty"`        // function
+	Arity       int              `json:"arity,omitempty"`       // function; -1 when variadic
+	Value       any              `json:"value,omitempty"`
+	Fields      []InspectedField `json:"fields,omitempty"`
+	Elements    []Inspection     `json:"elements,omitempty"`
+	Entries     []InspectedEntry `json:"entries,omitempty"`
+	Captured    []InspectedField `json:"captured,omitempty"`
+}
+
+// InspectedField is a named part of an inspected value
+type InspectedField struct {
+	Name  string     `json:"name"`
+	Value Inspection `json:"value"`
+}
+
+// InspectedEntry is an entry of an inspected map
+type InspectedEntry struct {
+	Key   Inspection `json:"key"`
+	Value Inspection `json:"value"`
+}
+
+// Inspect describes a value. The functions a closure captured are described
+// without their own captures, since a nested function captures itself.
+func Inspect(v Value) Inspection {
+	return inspect(v, true)
+}
+
+func inspect(v Value, captures bool) Inspection {
+	inspection := Inspection{Text: FormatValue(v)}
+	switch val := v.(type) {
+	case Unit:
+		inspection.Kind = KindUnit
+	case int64:
+		inspection.Kind, inspection.Value = KindInt, val
+	case float64:
+		inspection.Kind = KindFloat
+		if !math.IsInf(val, 0) && !math.IsNaN(val) {
+			inspection.Value = val
+		}
+	case bool:
+		inspection.Kind, inspection.Value = KindBool, val
+	case string:
+		inspection.Kind, inspection.Value = KindString, val
+	case *StructValue:
+		inspection.Kind, inspection.Type = KindStruct, val.Type
+		inspection.Fields = inspectFields(val.Fields, captures)
+	case *DataValue:
+		inspection.Kind, inspection.Type, inspection.Constructor = KindData, val.Type, val.Constructor
+		inspection.Fields = inspectFields(val.Fields, captures)
+		inspection.Elements = inspectElements(val.Args, captures)
+	case *ArrayValue:
+		inspection.Kind = KindArray
+		inspection.Elements = inspectElements(val.Elements, captures)
+	case *TupleValue:
+		inspection.Kind = KindTuple
+		inspection.Elements = inspectElements(val.Elements, captures)
+	case *MapValue:
+		inspection.Kind = KindMap
+		for key, value := range val.Entries {
+			inspection.Entries = append(inspection.Entries, InspectedEntry{Key: inspect(key, captures), Value: inspect(value, captures)})
+		}
+	case *Function:
+		inspection.Kind, inspection.Name, inspection.Arity = KindFunction, val.Name, val.Arity
+		if val.captured != nil {
+			inspection.Kind = KindClosure
+			if captures {
+				inspection.Captured = inspectFields(val.captured, false)
+			}
+		}
+	}
+	return inspection
+}
+
+// inspectFields describes fields sorted by name
+func inspectFields(fields map[string]Value, captures bool) []InspectedField {
+	if len(fields) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	inspected := make([]InspectedField, len(names))
+	for i, name := range names {
+		inspected[i] = InspectedField{Name: name, Value: inspect(fields[name], captures)}
+	}
+	return inspected
+}
+
+func inspectElements(elements []Value, captures bool) []Inspection {
+	if len(elements) == 0 {
+		return nil
+	}
+	inspected := make([]Inspection, len(elements))
+	for i, element := range elements {
+		inspected[i] = inspect(element, captures)
+	}
+	return inspected
+}
@@ -0,0 +1,674 @@
+package interp
+
+/*
+Interp is a tree-walking interpreter over the collected AST. Top-level bindings are
+evaluated once by Init; after that an Interpreter only reads its globals, so Call
+may be used from several goroutines at once (lyra test runs pure tests in parallel).
+A lazy binding is evaluated when Init first reads it, or at the end of Init if
+nothing did, so it may be read before its declaration.
+
+Runtime failures (failed asserts, todo(), no matching clause, ...) unwind the
+evaluation and are returned from Init and Call as *RuntimeError.
+
+Evaluation is deterministic: maps iterate in insertion order (see MapValue) and
+struct fields print sorted by name, so a program prints and returns the same on
+every run.
+*/
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/ownership"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// RuntimeError is an error raised while evaluating a program
+type RuntimeError struct {
+	Message  string
+	Location ast.Location
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Location.StartLine, e.Location.StartCol, e.Message)
+}
+
+type Interpreter struct {
+	program *ast.Program
+	table   *symbols.SymbolTable
+	globals map[string]Value
+	lazy    map[string]*thunk // lazy bindings not yet forced, during Init
+	forcing []string          // the lazy bindings being forced, innermost last
+
+	outputMu sync.Mutex
+	output   io.Writer // where debug() prints
+
+	coverage  Recorder                 // nil unless coverage is recorded
+	ownership *ownership.Analysis      // drop points; nil leaves values to the garbage collector
+	observe   ExpressionObserver       // nil unless the results of top-level expressions are shown
+	externs   map[string]reflect.Value // Go implementations of extern functions by target
+}
+
+// Recorder is told about every clause and if branch the interpreter runs
+type Recorder interface {
+	Hit(node any)
+}
+
+// ExpressionObserver is given the value of every top-level expression statement
+// Init evaluates
+type ExpressionObserver func(stmt *ast.ExpressionStmt, value Value)
+
+func New(program *ast.Program, table *symbols.SymbolTable) *Interpreter {
+	return &Interpreter{
+		program: program,
+		table:   table,
+		globals: make(map[string]Value),
+		output:  os.Stderr,
+	}
+}
+
+// SetOutput sets where debug() prints; stderr by default
+func (in *Interpreter) SetOutput(w io.Writer) {
+	in.output = w
+}
+
+// SetCoverage records executed clauses and branches into r
+func (in *Interpreter) SetCoverage(r Recorder) {
+	in.coverage = r
+}
+
+func (in *Interpreter) hit(node any) {
+	if in.coverage != nil {
+		in.coverage.Hit(node)
+	}
+}
+
+// ObserveExpressions reports the values of top-level expression statements to observe
+func (in *Interpreter) ObserveExpressions(observe ExpressionObserver) {
+	in.observe = observe
+}
+
+// SetOwnership drops owned values at the points computed by the ownership
+// analysis, calling Drop on those that implement Dropper
+func (in *Interpreter) SetOwnership(a *ownership.Analysis) {
+	in.ownership = a
+}
+
+// drop releases owned bindings that are no longer used
+func (in *Interpreter) drop(names []string, bindings env) {
+	for _, name := range names {
+		value, ok := bindings[name]
+		if !ok {
+			continue
+		}
+		if dropper, ok := value.(Dropper); ok {
+			dropper.Drop()
+		}
+		delete(bindings, name)
+	}
+}
+
+func (in *Interpreter) dropOnEntry(node any, bindings env) {
+	if in.ownership != nil {
+		in.drop(in.ownership.DropOnEntry[node], bindings)
+	}
+}
+
+// thunk is a lazy binding waiting for its value to be read
+type thunk struct {
+	decl    *ast.VarDeclStmt
+	forcing bool
+}
+
+// Init evaluates the top-level bindings and statements in order, then the lazy
+// bindings nothing read
+func (in *Interpreter) Init() (err error) {
+	defer recoverRuntimeError(&err)
+	if err := in.bindExterns(); err != nil {
+		return err
+	}
+	in.lazy = make(map[string]*thunk)
+	for _, stmt := range in.program.Statements {
+		if decl, ok := stmt.(*ast.VarDeclStmt); ok && decl.IsLazy {
+			in.lazy[decl.Name] = &thunk{decl: decl}
+		}
+	}
+	for _, stmt := range in.program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			if s.IsLazy {
+				continue
+			}
+			in.globals[s.Name] = in.eval(s.Value, nil)
+		case *ast.DestructuringDeclStmt:
+			in.destructure(s)
+		case *ast.VarAssignStmt:
+			in.globals[s.Name] = in.eval(s.Value, nil)
+		case *ast.ExpressionStmt:
+			value := in.eval(s.Expression, nil)
+			if in.observe != nil {
+				in.observe(s, value)
+			}
+		}
+	}
+	for _, stmt := range in.program.Statements {
+		if decl, ok := stmt.(*ast.VarDeclStmt); ok && in.lazy[decl.Name] != nil {
+			in.force(in.lazy[decl.Name], decl.NameLocation)
+		}
+	}
+	return nil
+}
+
+// destructure evaluates the value of a destructuring declaration and binds the
+// names of its pattern as globals
+func (in *Interpreter) destructure(decl *ast.DestructuringDeclStmt) {
+	value := in.eval(decl.Value, nil)
+	bindings := make(env)
+	if !in.match(decl.Pattern, value, bindings) {
+		fail(decl.Pattern.GetLocation(), "cannot destructure %s into %s", FormatValue(value), decl.Pattern.GetName())
+	}
+	for name, bound := range bindings {
+		in.globals[name] = bound
+	}
+}
+
+// force evaluates a lazy binding read at loc and keeps its value. Reading it
+// again while it is evaluated fails with the cycle of lazy bindings.
+func (in *Interpreter) force(th *thunk, loc ast.Location) Value {
+	name := th.decl.Name
+	if th.forcing {
+		start := 0
+		for in.forcing[start] != name {
+			start++
+		}
+		cycle := append(append([]string(nil), in.forcing[start:]...), name)
+		fail(loc, "initialization cycle: %s", strings.Join(cycle, " -> "))
+	}
+	th.forcing = true
+	in.forcing = append(in.forcing, name)
+	value := in.eval(th.decl.Value, nil)
+	in.forcing = in.forcing[:len(in.forcing)-1]
+	delete(in.lazy, name)
+	in.globals[name] = value
+	return value
+}
+
+// Call calls the top-level function name with args
+func (in *Interpreter) Call(name string, args ...Value) (result Value, err error) {
+	defer recoverRuntimeError(&err)
+	fn, ok := in.table.Functions[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined function %s", name)
+	}
+	return in.callFunction(fn, args, fn.Location), nil
+}
+
+// Global returns the value of a top-level binding
+func (in *Interpreter) Global(name string) (Value, bool) {
+	value, ok := in.globals[name]
+	return value, ok
+}
+
+func recoverRuntimeError(err *error) {
+	if r := recover(); r != nil {
+		runtimeErr, ok := r.(*RuntimeError)
+		if !ok {
+			panic(r)
+		}
+		*err = runtimeErr
+	}
+}
+
+func fail(loc ast.Location, format string, args ...any) {
+	panic(&RuntimeError{Message: fmt.Sprintf(format, args...), Location: loc})
+}
+
+// env holds the parameters and pattern bindings of a clause
+type env map[string]Value
+
+func (in *Interpreter) callFunction(fn *ast.FunctionDefStmt, args []Value, loc ast.Location) Value {
+	if fn.IsExtern() {
+		return in.callExtern(fn, args, loc)
+	}
+	return in.callClauses(fn, args, loc, nil)
+}
+
+// callClauses runs the first clause of fn matching args, with the bindings it
+// captured if it is a nested function
+func (in *Interpreter) callClauses(fn *ast.FunctionDefStmt, args []Value, loc ast.Location, captured env) Value {
+	for _, clause := range fn.Clauses {
+		if len(clause.Parameters) != len(args) {
+			continue
+		}
+		bindings := make(env, len(captured)+len(args))
+		for name, value := range captured {
+			bindings[name] = value
+		}
+		matched := true
+		for i, param := range clause.Parameters {
+			if !in.match(param, args[i], bindings) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		if clause.Guard != nil && !in.evalBool(clause.Guard.Condition, bindings) {
+			continue
+		}
+		in.hit(clause)
+		if in.ownership != nil && clause.Guard != nil {
+			in.drop(in.ownership.DropAfter[clause.Guard], bindings)
+		}
+		in.dropOnEntry(clause, bindings)
+		for _, nested := range clause.Functions {
+			bindings[nested.Name] = in.closure(nested, bindings)
+		}
+		return in.eval(clause.Body, bindings)
+	}
+
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		formatted[i] = FormatValue(arg)
+	}
+	fail(loc, "no clause of %s matches (%s)", fn.Name, strings.Join(formatted, ", "))
+	return nil
+}
+
+func (in *Interpreter) eval(expr ast.Expression, bindings env) Value {
+	value := in.evaluate(expr, bindings)
+	if in.ownership != nil {
+		in.drop(in.ownership.DropAfter[expr], bindings)
+	}
+	return value
+}
+
+func (in *Interpreter) evaluate(expr ast.Expression, bindings env) Value {
+	switch e := expr.(type) {
+	case nil:
+		return Unit{}
+	case *ast.IntegerLiteralExpr:
+		return e.Value
+	case *ast.FloatLiteralExpr:
+		return e.Value
+	case *ast.StringLiteralExpr:
+		return e.Value
+	case *ast.RawStringLiteralExpr:
+		return e.Value
+	case *ast.MultilineStringLiteralExpr:
+		return e.Value
+	case *ast.BooleanLiteralExpr:
+		return e.Value
+	case *ast.HostValueExpr:
+		return e.Value
+	case *ast.HoleExpr:
+		fail(e.Location, "not implemented (hole ???)")
+	case *ast.IdentifierExpr:
+		return in.lookup(e.Name, bindings, e.Location)
+	case *ast.CallExpr:
+		return in.evalCall(e, bindings)
+	case *ast.BinaryOpExpr:
+		return in.evalBinary(e, bindings)
+	case *ast.BooleanBinaryOpExpr:
+		return in.evalBoolean(e, bindings)
+	case *ast.IfThenExpr:
+		if in.evalBool(e.Condition, bindings) {
+			in.hit(e.Then)
+			in.dropOnEntry(e.Then, bindings)
+			return in.eval(e.Then, bindings)
+		}
+		in.hit(e.Else)
+		in.dropOnEntry(e.Else, bindings)
+		return in.eval(e.Else, bindings)
+	case *ast.IfBlockExpr:
+		if in.evalBool(e.Condition, bindings) {
+			in.hit(e.Then)
+			in.dropOnEntry(e.Then, bindings)
+			return in.eval(e.Then, bindings)
+		}
+		in.hit(e.Else)
+		in.dropOnEntry(e.Else, bindings)
+		return in.eval(e.Else, bindings)
+	case *ast.MemberAccessExpr:
+		return in.evalMember(e, bindings)
+	case *ast.StructLiteralExpr:
+		return in.evalStructLiteral(e, bindings)
+	case *ast.ArrayLiteralExpr:
+		array := &ArrayValue{Elements: make([]Value, len(e.Elements))}
+		for i, element := range e.Elements {
+			array.Elements[i] = in.eval(element, bindings)
+		}
+		return array
+	case *ast.LambdaExpr:
+		// a function of one clause, capturing the bindings it is written among
+		fn := &ast.FunctionDefStmt{Name: "lambda", Clauses: []*ast.FunctionClause{{Parameters: e.Parameters, Body: e.Body}}}
+		return &Function{Name: fn.Name, Arity: len(e.Parameters), captured: bindings, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+			return in.callClauses(fn, args, loc, bindings)
+		}}
+	case *ast.TupleLiteralExpr:
+		tuple := &TupleValue{Elements: make([]Value, len(e.Elements))}
+		for i, element := range e.Elements {
+			tuple.Elements[i] = in.eval(element, bindings)
+		}
+		return tuple
+	}
+	fail(expr.GetLocation(), "cannot evaluate %s", expr.GetName())
+	return nil
+}
+
+func (in *Interpreter) evalBool(expr ast.Expression, bindings env) bool {
+	value, ok := in.eval(expr, bindings).(bool)
+	if !ok {
+		fail(expr.GetLocation(), "%s is not a Bool", expr.GetName())
+	}
+	return value
+}
+
+func (in *Interpreter) lookup(name string, bindings env, loc ast.Location) Value {
+	if value, ok := bindings[name]; ok {
+		return value
+	}
+	if value, ok := in.globals[name]; ok {
+		return value
+	}
+	if th, ok := in.lazy[name]; ok {
+		return in.force(th, loc)
+	}
+	if fn, ok := in.table.Functions[name]; ok {
+		return in.functionValue(fn)
+	}
+	if ctors := in.table.LookupConstructor(name); len(ctors) == 1 {
+		return in.constructorValue(ctors[0])
+	}
+	if builtin, ok := builtins[name]; ok {
+		return builtin
+	}
+	if constant, ok := constants[name]; ok {
+		return constant
+	}
+	fail(loc, "undefined: %s", name)
+	return nil
+}
+
+// closure is the value of a nested function, capturing the bindings of the
+// clause defining it, among them the functions defined beside it
+func (in *Interpreter) closure(fn *ast.FunctionDefStmt, captured env) *Function {
+	arity := 0
+	if fn.Signature != nil {
+		arity = len(fn.Signature.ParameterTypes)
+	}
+	return &Function{Name: fn.Name, Arity: arity, captured: captured, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		return in.callClauses(fn, args, loc, captured)
+	}}
+}
+
+func (in *Interpreter) functionValue(fn *ast.FunctionDefStmt) *Function {
+	arity := 0
+	if fn.Signature != nil {
+		arity = len(fn.Signature.ParameterTypes)
+	}
+	return &Function{Name: fn.Name, Arity: arity, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		return in.callFunction(fn, args, loc)
+	}}
+}
+
+// constructorValue is a nullary constructor's value, or a function building the value
+func (in *Interpreter) constructorValue(ctor *ast.DataConstructorDecl) Value {
+	arity := 0
+	if ctor.Signature != nil {
+		arity = len(ctor.Signature.ParameterTypes)
+	}
+	if arity == 0 {
+		return &DataValue{Type: ctor.DataType, Constructor: ctor.Name}
+	}
+	return &Function{Name: ctor.Name, Arity: arity, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		return &DataValue{Type: ctor.DataType, Constructor: ctor.Name, Args: args}
+	}}
+}
+
+func (in *Interpreter) evalCall(call *ast.CallExpr, bindings env) Value {
+	callee, ok := in.eval(call.Callee, bindings).(*Function)
+	if !ok {
+		fail(call.Callee.GetLocation(), "%s is not a function", call.Callee.GetName())
+	}
+	args := make([]Value, len(call.Arguments))
+	for i, argument := range call.Arguments {
+		args[i] = in.eval(argument, bindings)
+	}
+	if callee.Arity >= 0 && len(args) != callee.Arity {
+		fail(call.Location, "%s expects %d arguments but got %d", callee.Name, callee.Arity, len(args))
+	}
+	if callee == builtins["from_json"] {
+		text, _ := args[0].(string)
+		return in.fromJSON(text, call.GetType(), call.Location)
+	}
+	return callee.call(in, args, call.Location)
+}
+
+func (in *Interpreter) evalBinary(expr *ast.BinaryOpExpr, bindings env) Value {
+	left := in.eval(expr.Left, bindings)
+	right := in.eval(expr.Right, bindings)
+
+	switch l := left.(type) {
+	case int64:
+		r, ok := right.(int64)
+		if !ok {
+			break
+		}
+		switch expr.Operator {
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "*":
+			return l * r
+		case "/", "%":
+			if r == 0 {
+				fail(expr.Location, "division by zero")
+			}
+			if expr.Operator == "/" {
+				return l / r
+			}
+			return l % r
+		case "**":
+			return int64(math.Pow(float64(l), float64(r)))
+		case "<=>":
+			return int64(compare(l, r))
+		}
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			break
+		}
+		switch expr.Operator {
+		case "+":
+			return l + r
+		case "-":
+			return l - r
+		case "*":
+			return l * r
+		case "/":
+			return l / r
+		case "%":
+			return math.Mod(l, r)
+		case "**":
+			return math.Pow(l, r)
+		case "<=>":
+			return int64(compare(l, r))
+		}
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			break
+		}
+		switch expr.Operator {
+		case "+", "++":
+			return l + r
+		case "<=>":
+			return int64(strings.Compare(l, r))
+		}
+	}
+	fail(expr.Location, "cannot apply %s to %s and %s", expr.Operator, FormatValue(left), FormatValue(right))
+	return nil
+}
+
+func compare[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (in *Interpreter) evalBoolean(expr *ast.BooleanBinaryOpExpr, bindings env) Value {
+	switch expr.Operator {
+	case ast.BooleanBinaryOpAnd:
+		return in.evalBool(expr.Left, bindings) && in.evalBool(expr.Right, bindings)
+	case ast.BooleanBinaryOpOr:
+		return in.evalBool(expr.Left, bindings) || in.evalBool(expr.Right, bindings)
+	}
+
+	left := in.eval(expr.Left, bindings)
+	right := in.eval(expr.Right, bindings)
+	switch expr.Operator {
+	case ast.BooleanBinaryOpEq:
+		return Equal(left, right)
+	case ast.BooleanBinaryOpNEq:
+		return !Equal(left, right)
+	}
+
+	var order int
+	switch l := left.(type) {
+	case int64:
+		r, ok := right.(int64)
+		if !ok {
+			fail(expr.Location, "cannot compare %s with %s", FormatValue(left), FormatValue(right))
+		}
+		order = compare(l, r)
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			fail(expr.Location, "cannot compare %s with %s", FormatValue(left), FormatValue(right))
+		}
+		order = compare(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			fail(expr.Location, "cannot compare %s with %s", FormatValue(left), FormatValue(right))
+		}
+		order = strings.Compare(l, r)
+	default:
+		fail(expr.Location, "cannot order %s", FormatValue(left))
+	}
+
+	switch expr.Operator {
+	case ast.BooleanBinaryOpLT:
+		return order < 0
+	case ast.BooleanBinaryOpLTE:
+		return order <= 0
+	case ast.BooleanBinaryOpGT:
+		return order > 0
+	case ast.BooleanBinaryOpGTE:
+		return order >= 0
+	}
+	fail(expr.Location, "unknown operator %s", expr.Operator)
+	return nil
+}
+
+func (in *Interpreter) evalMember(expr *ast.MemberAccessExpr, bindings env) Value {
+	// Type.Constructor
+	if ident, ok := expr.Object.(*ast.IdentifierExpr); ok {
+		if _, bound := bindings[ident.Name]; !bound {
+			if ctor, ok := in.table.LookupQualifiedConstructor(ident.Name, expr.Member); ok {
+				return in.constructorValue(ctor)
+			}
+		}
+	}
+
+	var fields map[string]Value
+	var typeName string
+	if objectType := expr.Object.GetType(); objectType != nil {
+		typeName = objectType.GetName()
+	}
+	object := in.eval(expr.Object, bindings)
+	switch object := object.(type) {
+	case *StructValue:
+		fields, typeName = object.Fields, object.Type
+	case *DataValue:
+		fields, typeName = object.Fields, object.Type
+	}
+	if value, ok := fields[expr.Member]; ok {
+		return value
+	}
+	// point.show(): a trait method with point bound as its receiver
+	if impls := in.table.LookupMethod(typeName, expr.Member); len(impls) == 1 {
+		return in.methodValue(impls[0].Method(expr.Member), object)
+	}
+	fail(expr.MemberLocation, "no field or method %s in %s", expr.Member, expr.Object.GetName())
+	return nil
+}
+
+// methodValue is a trait method with its receiver bound, taking the arguments
+// after it
+func (in *Interpreter) methodValue(method *ast.FunctionDefStmt, receiver Value) *Function {
+	arity := 0
+	if method.Signature != nil {
+		arity = len(method.Signature.ParameterTypes) - 1
+	}
+	return &Function{Name: method.Name, Arity: arity, call: func(in *Interpreter, args []Value, loc ast.Location) Value {
+		return in.callFunction(method, append([]Value{receiver}, args...), loc)
+	}}
+}
+
+func (in *Interpreter) evalStructLiteral(expr *ast.StructLiteralExpr, bindings env) Value {
+	fields := make(map[string]Value, len(expr.Fields))
+	for _, field := range expr.Fields {
+		fields[field.Name] = in.eval(field.Value, bindings)
+	}
+
+	if decl, ok := in.table.Types[expr.TypeName]; ok {
+		if structType, ok := decl.Type.(types.StructType); ok {
+			in.applyDefaults(fields, structType.Fields, decl.FieldOrder())
+		}
+		return &StructValue{Type: expr.TypeName, Fields: fields}
+	}
+	ctor, err := in.table.ResolveConstructor(expr.TypeName, nil)
+	if err != nil {
+		fail(expr.Location, "%s", err)
+	}
+	if decl, ok := in.table.Types[ctor.DataType]; ok {
+		if dataType, ok := decl.Type.(types.DataType); ok {
+			declared := dataType.Constructors[ctor.Name].Fields
+			in.applyDefaults(fields, declared, sortedNames(declared))
+		}
+	}
+	return &DataValue{Type: ctor.DataType, Constructor: ctor.Name, Fields: fields}
+}
+
+// applyDefaults fills in omitted fields from their declared default values,
+// in the given order, each default seeing the fields set before it and none
+// of the bindings at the literal
+func (in *Interpreter) applyDefaults(fields map[string]Value, declared map[string]types.StructField, order []string) {
+	for _, name := range order {
+		if _, given := fields[name]; given {
+			continue
+		}
+		if expr, ok := declared[name].DefaultValue.(ast.Expression); ok {
+			set := make(env, len(fields))
+			for name, value := range fields {
+				set[name] = value
+			}
+			fields[name] = in.eval(expr, set)
+		}
+	}
+}
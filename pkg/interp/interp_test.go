@@ -0,0 +1,709 @@
+package interp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/ownership"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func ident(name string) *ast.IdentifierExpr   { return &ast.IdentifierExpr{Name: name} }
+func integer(v int64) *ast.IntegerLiteralExpr { return &ast.IntegerLiteralExpr{Value: v} }
+
+func binary(left ast.Expression, op string, right ast.Expression) *ast.BinaryOpExpr {
+	return &ast.BinaryOpExpr{Left: left, Operator: op, Right: right}
+}
+
+func call(name string, args ...ast.Expression) *ast.CallExpr {
+	return &ast.CallExpr{Callee: ident(name), Arguments: args}
+}
+
+func function(name string, params int, clauses ...*ast.FunctionClause) *ast.FunctionDefStmt {
+	signature := &types.FunctionType{ReturnType: intType}
+	for i := 0; i < params; i++ {
+		signature.ParameterTypes = append(signature.ParameterTypes, types.ParameterType{Type: intType})
+	}
+	return &ast.FunctionDefStmt{Name: name, Signature: signature, Clauses: clauses}
+}
+
+func newInterpreter(t *testing.T, statements ...ast.AstNode) *Interpreter {
+	t.Helper()
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			if err := table.RegisterFunction(s); err != nil {
+				t.Fatalf("RegisterFunction error: %v", err)
+			}
+		case *ast.TypeDeclStmt:
+			if err := table.RegisterType(s); err != nil {
+				t.Fatalf("RegisterType error: %v", err)
+			}
+		case *ast.ImplStmt:
+			if err := table.RegisterImpl(s); err != nil {
+				t.Fatalf("RegisterImpl error: %v", err)
+			}
+		}
+	}
+	in := New(&ast.Program{Statements: statements}, table)
+	if err := in.Init(); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+	return in
+}
+
+//	def fib: (Int) -> Int = {
+//	    (n) if n < 2 => n,
+//	    (n) => fib(n-2) + fib(n-1),
+//	}
+func fibFunction() *ast.FunctionDefStmt {
+	return function("fib", 1,
+		&ast.FunctionClause{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+			Guard:      &ast.GuardExpr{Condition: &ast.BooleanBinaryOpExpr{Left: ident("n"), Operator: ast.BooleanBinaryOpLT, Right: integer(2)}},
+			Body:       ident("n"),
+		},
+		&ast.FunctionClause{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+			Body:       binary(call("fib", binary(ident("n"), "-", integer(2))), "+", call("fib", binary(ident("n"), "-", integer(1)))),
+		},
+	)
+}
+
+func TestInterpreter_ClausesAndGuards(t *testing.T) {
+	in := newInterpreter(t, fibFunction())
+	value, err := in.Call("fib", int64(10))
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if value != int64(55) {
+		t.Fatalf("Expected fib(10) = 55. Got %s", FormatValue(value))
+	}
+}
+
+func TestInterpreter_LiteralPatterns(t *testing.T) {
+	isZero := function("is_zero", 1,
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.LiteralPattern{Value: "0"}}, Body: &ast.BooleanLiteralExpr{Value: true}},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "_"}}, Body: &ast.BooleanLiteralExpr{Value: false}},
+	)
+	isMax := function("is_max", 1,
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.LiteralPattern{Value: "0xFF"}}, Body: &ast.BooleanLiteralExpr{Value: true}},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.LiteralPattern{Value: "1_000"}}, Body: &ast.BooleanLiteralExpr{Value: true}},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "_"}}, Body: &ast.BooleanLiteralExpr{Value: false}},
+	)
+	in := newInterpreter(t, isZero, isMax)
+	for arg, expected := range map[int64]bool{0: true, 3: false} {
+		if value, err := in.Call("is_zero", arg); err != nil || value != expected {
+			t.Fatalf("Expected is_zero(%d) = %t. Got %v, %v", arg, expected, value, err)
+		}
+	}
+	for arg, expected := range map[int64]bool{255: true, 1000: true, 0: false} {
+		if value, err := in.Call("is_max", arg); err != nil || value != expected {
+			t.Fatalf("Expected is_max(%d) = %t. Got %v, %v", arg, expected, value, err)
+		}
+	}
+}
+
+func TestInterpreter_RangePatterns(t *testing.T) {
+	// def kind: (String) -> Int = { ('0'..='9') => 1, ('a'..='z') => 2, (_) => 0 }
+	kind := function("kind", 1,
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.RangePattern{Low: "'0'", High: "'9'"}}, Body: integer(1)},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.RangePattern{Low: "'a'", High: "'z'"}}, Body: integer(2)},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "_"}}, Body: integer(0)},
+	)
+	// def sign: (Int) -> Int = { (-9..=-1) => -1, (0x0..=0x9) => 1, (_) => 0 }
+	sign := function("sign", 1,
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.RangePattern{Low: "-9", High: "-1"}}, Body: integer(-1)},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.RangePattern{Low: "0x0", High: "0x9"}}, Body: integer(1)},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "_"}}, Body: integer(0)},
+	)
+	in := newInterpreter(t, kind, sign)
+	for arg, expected := range map[string]int64{"7": 1, "q": 2, "Q": 0, "ab": 0} {
+		if value, err := in.Call("kind", arg); err != nil || value != expected {
+			t.Fatalf("Expected kind(%q) = %d. Got %v, %v", arg, expected, value, err)
+		}
+	}
+	for arg, expected := range map[int64]int64{-9: -1, -1: -1, 0: 1, 9: 1, 10: 0, -10: 0} {
+		if value, err := in.Call("sign", arg); err != nil || value != expected {
+			t.Fatalf("Expected sign(%d) = %d. Got %v, %v", arg, expected, value, err)
+		}
+	}
+}
+
+func TestInterpreter_AsPatterns(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType},
+		"y": {Name: "y", Type: intType},
+	}}}
+	// def total: (Point) -> Int = (p @ Point { x }) => x + p.y
+	total := function("total", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.AsPattern{Name: "p", Pattern: &ast.StructPattern{TypeName: "Point", Fields: []*ast.StructPatternField{{Name: "x"}}}}},
+		Body:       binary(ident("x"), "+", &ast.MemberAccessExpr{Object: ident("p"), Member: "y"}),
+	})
+	in := newInterpreter(t, point, total)
+	value, err := in.Call("total", &StructValue{Type: "Point", Fields: map[string]Value{"x": int64(1), "y": int64(2)}})
+	if err != nil || value != int64(3) {
+		t.Fatalf("Expected total(Point { x: 1, y: 2 }) = 3. Got %v, %v", value, err)
+	}
+}
+
+func TestInterpreter_ConstructorAndArrayPatterns(t *testing.T) {
+	// def sum: (Array<Int>) -> Int = { ([]) => 0, ([x, ...rest]) => x + sum(rest) }
+	sum := function("sum", 1,
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.ArrayPattern{}}, Body: integer(0)},
+		&ast.FunctionClause{
+			Parameters: []ast.Pattern{&ast.ArrayPattern{Elements: []ast.Pattern{&ast.IdentifierPattern{Name: "x"}}, Rest: &ast.IdentifierPattern{Name: "rest"}}},
+			Body:       binary(ident("x"), "+", call("sum", ident("rest"))),
+		},
+	)
+	// def or_zero: (Maybe) -> Int = { (Maybe.Some(n)) => n, (_) => 0 }
+	orZero := function("or_zero", 1,
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.ConstructorPattern{Name: "Maybe.Some", Arguments: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}}}}, Body: ident("n")},
+		&ast.FunctionClause{Parameters: []ast.Pattern{&ast.WildcardPattern{}}, Body: integer(0)},
+	)
+	in := newInterpreter(t, sum, orZero)
+	numbers := &ArrayValue{Elements: []Value{int64(1), int64(2), int64(3)}}
+	if value, err := in.Call("sum", numbers); err != nil || value != int64(6) {
+		t.Fatalf("Expected sum([1, 2, 3]) = 6. Got %v, %v", value, err)
+	}
+	if len(numbers.Elements) != 3 {
+		t.Fatalf("Expected matching to leave the array alone. Got %s", FormatValue(numbers))
+	}
+	// def sum_of_literal: () -> Int = () => sum([4, 5])
+	sumOfLiteral := function("sum_of_literal", 0, &ast.FunctionClause{
+		Body: call("sum", &ast.ArrayLiteralExpr{Elements: []ast.Expression{integer(4), integer(5)}}),
+	})
+	in = newInterpreter(t, sum, orZero, sumOfLiteral)
+	if value, err := in.Call("sum_of_literal"); err != nil || value != int64(9) {
+		t.Fatalf("Expected sum([4, 5]) = 9. Got %v, %v", value, err)
+	}
+	some := &DataValue{Type: "Maybe", Constructor: "Some", Args: []Value{int64(7)}}
+	none := &DataValue{Type: "Maybe", Constructor: "None"}
+	if value, err := in.Call("or_zero", some); err != nil || value != int64(7) {
+		t.Fatalf("Expected or_zero(Some(7)) = 7. Got %v, %v", value, err)
+	}
+	if value, err := in.Call("or_zero", none); err != nil || value != int64(0) {
+		t.Fatalf("Expected or_zero(None) = 0. Got %v, %v", value, err)
+	}
+}
+
+func TestInterpreter_StructsAndDefaults(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType},
+		"y": {Name: "y", Type: intType, DefaultValue: integer(7)},
+	}}}
+	// def y_of: (Int) -> Int = (n) => Point { x: n }.y
+	yOf := function("y_of", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Body: &ast.MemberAccessExpr{
+			Object: &ast.StructLiteralExpr{TypeName: "Point", Fields: []*ast.StructLiteralField{{Name: "x", Value: ident("n")}}},
+			Member: "y",
+		},
+	})
+	// def show: (Int) -> String = (n) => render(r"{x}\{{{y}}}", Point { x: n })
+	show := function("show", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Body: call("render", &ast.RawStringLiteralExpr{Value: `{x}\{{{y}}}`},
+			&ast.StructLiteralExpr{TypeName: "Point", Fields: []*ast.StructLiteralField{{Name: "x", Value: ident("n")}}}),
+	})
+	in := newInterpreter(t, point, yOf, show)
+	if value, err := in.Call("y_of", int64(1)); err != nil || value != int64(7) {
+		t.Fatalf("Expected the default y of 7. Got %v, %v", value, err)
+	}
+	if value, err := in.Call("show", int64(1)); err != nil || value != `1\{7}` {
+		t.Fatalf("Expected the rendered point 1\\{7}. Got %v, %v", value, err)
+	}
+}
+
+func TestInterpreter_DefaultsSeeEarlierFields(t *testing.T) {
+	// struct Range { lo: Int, hi: Int = lo + 10, span: Int = hi - lo }
+	field := func(col int) ast.Location {
+		return ast.Location{StartLine: 1, StartCol: col, EndLine: 1, EndCol: col + 2}
+	}
+	rng := &ast.TypeDeclStmt{Name: "Range", Type: types.StructType{Name: "Range", Fields: map[string]types.StructField{
+		"lo":   {Name: "lo", Type: intType},
+		"hi":   {Name: "hi", Type: intType, DefaultValue: binary(ident("lo"), "+", integer(10))},
+		"span": {Name: "span", Type: intType, DefaultValue: binary(ident("hi"), "-", ident("lo"))},
+	}}, FieldLocations: map[string]ast.Location{"lo": field(16), "hi": field(25), "span": field(43)}}
+	// def span_of: (Int) -> Int = (hi) => Range { lo: hi }.span
+	spanOf := function("span_of", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "hi"}},
+		Body: &ast.MemberAccessExpr{
+			Object: &ast.StructLiteralExpr{TypeName: "Range", Fields: []*ast.StructLiteralField{{Name: "lo", Value: ident("hi")}}},
+			Member: "span",
+		},
+	})
+	in := newInterpreter(t, rng, spanOf)
+	for i := 0; i < 20; i++ {
+		if value, err := in.Call("span_of", int64(1)); err != nil || value != int64(10) {
+			t.Fatalf("Expected span 10 from the defaults, not from the hi at the literal. Got %v, %v", value, err)
+		}
+	}
+}
+
+func TestInterpreter_TraitMethods(t *testing.T) {
+	point := &ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: intType},
+	}}}
+	// impl Scale for Point { def scaled: (Point, Int) -> Int = (p, k) => p.x * k }
+	scale := &ast.ImplStmt{Trait: "Scale", TypeName: "Point", Methods: []*ast.FunctionDefStmt{
+		function("scaled", 2, &ast.FunctionClause{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "p"}, &ast.IdentifierPattern{Name: "k"}},
+			Body:       binary(&ast.MemberAccessExpr{Object: ident("p"), Member: "x"}, "*", ident("k")),
+		}),
+	}}
+	// def triple_x: (Int) -> Int = (n) => Point { x: n }.scaled(3)
+	tripleX := function("triple_x", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Body: &ast.CallExpr{
+			Callee: &ast.MemberAccessExpr{
+				Object: &ast.StructLiteralExpr{TypeName: "Point", Fields: []*ast.StructLiteralField{{Name: "x", Value: ident("n")}}},
+				Member: "scaled",
+			},
+			Arguments: []ast.Expression{integer(3)},
+		},
+	})
+	in := newInterpreter(t, point, scale, tripleX)
+	if value, err := in.Call("triple_x", int64(5)); err != nil || value != int64(15) {
+		t.Fatalf("Expected Point { x: 5 }.scaled(3) = 15. Got %v, %v", value, err)
+	}
+}
+
+func TestInterpreter_Builtins(t *testing.T) {
+	// def check: (Int) -> Int = (n) => debug(n) + assert(n > 0, "positive")
+	checked := function("check", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Body: call("assert", &ast.BooleanBinaryOpExpr{Left: call("debug", ident("n")), Operator: ast.BooleanBinaryOpGT, Right: integer(0)},
+			&ast.StringLiteralExpr{Value: "positive"}),
+	})
+	in := newInterpreter(t, checked)
+	var output bytes.Buffer
+	in.SetOutput(&output)
+
+	if _, err := in.Call("check", int64(3)); err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if !strings.Contains(output.String(), "3") {
+		t.Fatalf("Expected debug to print 3. Got %q", output.String())
+	}
+	_, err := in.Call("check", int64(-1))
+	if _, ok := err.(*RuntimeError); !ok || !strings.Contains(err.Error(), "assertion failed: positive") {
+		t.Fatalf("Expected a failed assertion. Got %v", err)
+	}
+
+	// def limits: (Int) -> Int = (n) => debug(debug(Inf) == debug(NaN))
+	limits := function("limits", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Body:       call("debug", &ast.BooleanBinaryOpExpr{Left: call("debug", ident("Inf")), Operator: ast.BooleanBinaryOpEq, Right: call("debug", ident("NaN"))}),
+	})
+	in = newInterpreter(t, limits)
+	output.Reset()
+	in.SetOutput(&output)
+	if _, err := in.Call("limits", int64(0)); err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if !strings.Contains(output.String(), "Inf\n") || !strings.Contains(output.String(), "NaN\n") || !strings.Contains(output.String(), "false\n") {
+		t.Fatalf("Expected Inf, NaN and false printed. Got %q", output.String())
+	}
+}
+
+// resource logs when it is dropped
+type resource struct {
+	name    string
+	dropped *[]string
+}
+
+func (r *resource) Drop() { *r.dropped = append(*r.dropped, r.name) }
+
+func TestInterpreter_DropsOwnedValues(t *testing.T) {
+	resourceType := types.UnresolvedType{Name: "Resource"}
+	// def size: (ref Resource) -> Int = (r) => 1
+	size := function("size", 1, &ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "r"}}, Body: integer(1)})
+	size.Signature.ParameterTypes[0] = types.ParameterType{Modifier: types.Ref, Type: resourceType}
+	// def finish: (own Resource, own Resource) -> Int = (f, g) => size(f)
+	body := call("size", ident("f"))
+	body.Callee.SetType(size.Signature)
+	finish := function("finish", 2, &ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "f"}, &ast.IdentifierPattern{Name: "g"}}, Body: body})
+	finish.Signature.ParameterTypes = []types.ParameterType{{Modifier: types.Own, Type: resourceType}, {Modifier: types.Own, Type: resourceType}}
+
+	in := newInterpreter(t, size, finish)
+	in.SetOwnership(ownership.Analyze(in.program))
+	var dropped []string
+	if _, err := in.Call("finish", &resource{"f", &dropped}, &resource{"g", &dropped}); err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if strings.Join(dropped, ",") != "g,f" {
+		t.Fatalf("Expected the unused g dropped on entry, then f after size(f). Got %v", dropped)
+	}
+}
+
+func TestInterpreter_ObservesTopLevelExpressions(t *testing.T) {
+	// let x = 2
+	// x * 21
+	// x + 1
+	first := &ast.ExpressionStmt{Expression: binary(ident("x"), "*", integer(21))}
+	second := &ast.ExpressionStmt{Expression: binary(ident("x"), "+", integer(1))}
+	in := New(&ast.Program{Statements: []ast.AstNode{
+		&ast.VarDeclStmt{Keyword: "let", Name: "x", Value: integer(2)},
+		first,
+		second,
+	}}, symbols.NewSymbolTable())
+
+	var observed []string
+	in.ObserveExpressions(func(stmt *ast.ExpressionStmt, value Value) {
+		if stmt != first && stmt != second {
+			t.Fatalf("Unexpected statement %v", stmt)
+		}
+		observed = append(observed, FormatValue(value))
+	})
+	if err := in.Init(); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+	if strings.Join(observed, ",") != "42,3" {
+		t.Fatalf("Expected 42 then 3. Got %v", observed)
+	}
+}
+
+func TestInterpreter_Externs(t *testing.T) {
+	// extern def scale: (Int32, Float) -> Float = "go:math.Scale"
+	scale := &ast.FunctionDefStmt{Name: "scale", Extern: "go:math.Scale", Signature: &types.FunctionType{
+		ParameterTypes: []types.ParameterType{{Type: types.PrimitiveType{Name: types.Int32}}, {Type: types.PrimitiveType{Name: types.Float}}},
+		ReturnType:     types.PrimitiveType{Name: types.Float},
+	}}
+	// extern def check: (String) -> Unit = "go:check"
+	checkFn := &ast.FunctionDefStmt{Name: "check", Extern: "go:check", Signature: &types.FunctionType{
+		ParameterTypes: []types.ParameterType{{Type: types.PrimitiveType{Name: types.String}}},
+		ReturnType:     types.PrimitiveType{Name: types.Unit},
+	}}
+	table := symbols.NewSymbolTable()
+	for _, fn := range []*ast.FunctionDefStmt{scale, checkFn} {
+		if err := table.RegisterFunction(fn); err != nil {
+			t.Fatalf("RegisterFunction error: %v", err)
+		}
+	}
+	in := New(&ast.Program{Statements: []ast.AstNode{scale, checkFn}}, table)
+
+	if err := in.RegisterExtern("go:math.Scale", func(n int32, f float64) float64 { return float64(n) * f }); err != nil {
+		t.Fatalf("RegisterExtern error: %v", err)
+	}
+	if err := in.Init(); err == nil || !strings.Contains(err.Error(), "no Go function registered for go:check") {
+		t.Fatalf("Expected Init to report the missing extern. Got %v", err)
+	}
+	if err := in.RegisterExtern("go:check", func(s string) int { return len(s) }); err != nil {
+		t.Fatalf("RegisterExtern error: %v", err)
+	}
+	if err := in.Init(); err == nil || !strings.Contains(err.Error(), "a Unit result must have no Go result") {
+		t.Fatalf("Expected Init to reject the Go result. Got %v", err)
+	}
+	in.RegisterExtern("go:check", func(s string) error {
+		if s == "" {
+			return errors.New("empty")
+		}
+		return nil
+	})
+	if err := in.Init(); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+
+	if value, err := in.Call("scale", int64(3), 1.5); err != nil || value != 4.5 {
+		t.Fatalf("Expected 4.5. Got %v, %v", value, err)
+	}
+	if _, err := in.Call("check", ""); err == nil || !strings.Contains(err.Error(), "check: empty") {
+		t.Fatalf("Expected the Go error as a runtime error. Got %v", err)
+	}
+}
+
+func TestInterpreter_JSONRoundTrip(t *testing.T) {
+	// data Status = Pending | Shipped(Int)
+	status := types.DataType{Name: "Status", Constructors: map[string]types.DataTypeConstructor{
+		"Pending": {Name: "Pending"},
+		"Shipped": {Name: "Shipped", Params: []types.Type{intType}},
+	}}
+	// struct Order = { id: Int, status: Status, notes: String = "" }
+	order := types.StructType{Name: "Order", Fields: map[string]types.StructField{
+		"id":     {Name: "id", Type: intType},
+		"status": {Name: "status", Type: types.UnresolvedType{Name: "Status"}},
+		"notes":  {Name: "notes", Type: types.PrimitiveType{Name: types.String}, DefaultValue: &ast.StringLiteralExpr{Value: ""}},
+	}}
+	in := newInterpreter(t,
+		&ast.TypeDeclStmt{Name: "Status", Type: status, Derives: []string{"Serialize", "Deserialize"}},
+		&ast.TypeDeclStmt{Name: "Order", Type: order, Derives: []string{"Serialize", "Deserialize"}},
+	)
+	orderType := types.UnresolvedType{Name: "Order"}
+
+	value := in.fromJSON(`{"id": 7, "status": {"Shipped": [3]}}`, orderType, ast.Location{})
+	text := in.toJSON(value, ast.Location{})
+	if text != `{"id":7,"notes":"","status":{"Shipped":[3]}}` {
+		t.Fatalf("Expected the default notes filled in. Got %s", text)
+	}
+	if again := in.fromJSON(text, orderType, ast.Location{}); !Equal(again, value) {
+		t.Fatalf("Expected %s to round-trip. Got %s", FormatValue(value), FormatValue(again))
+	}
+	if pending := in.fromJSON(`"Pending"`, types.UnresolvedType{Name: "Status"}, ast.Location{}); in.toJSON(pending, ast.Location{}) != `"Pending"` {
+		t.Fatalf("Expected a nullary constructor as its name. Got %s", FormatValue(pending))
+	}
+
+	err := func() (err error) {
+		defer func() { err, _ = recover().(*RuntimeError) }()
+		in.fromJSON(`{"id": 1.5, "status": "Pending"}`, orderType, ast.Location{})
+		return nil
+	}()
+	if err == nil || !strings.Contains(err.Error(), "from_json: Order: id: 1.5 is not an integer") {
+		t.Fatalf("Expected a decoding error. Got %v", err)
+	}
+}
+
+func TestInterpreter_LazyBindings(t *testing.T) {
+	lazy := func(name string, value ast.Expression) *ast.VarDeclStmt {
+		return &ast.VarDeclStmt{Keyword: "let", IsLazy: true, Name: name, Value: value}
+	}
+	// let area = side * side
+	// lazy let side = debug(4)
+	// lazy let unread = debug(0)
+	var out bytes.Buffer
+	in := New(&ast.Program{Statements: []ast.AstNode{
+		&ast.VarDeclStmt{Keyword: "let", Name: "area", Value: binary(ident("side"), "*", ident("side"))},
+		lazy("side", call("debug", integer(4))),
+		lazy("unread", call("debug", integer(0))),
+	}}, symbols.NewSymbolTable())
+	in.SetOutput(&out)
+	if err := in.Init(); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+	if area, _ := in.Global("area"); area != int64(16) {
+		t.Fatalf("Expected area 16 from the lazy side. Got %v", area)
+	}
+	if out.String() != "[0:0] 4\n[0:0] 0\n" {
+		t.Fatalf("Expected side forced once when read and unread at the end of Init. Got %q", out.String())
+	}
+
+	// lazy let a = b + 1
+	// lazy let b = a
+	// let c = a
+	in = New(&ast.Program{Statements: []ast.AstNode{
+		lazy("a", binary(ident("b"), "+", integer(1))),
+		lazy("b", ident("a")),
+		&ast.VarDeclStmt{Keyword: "let", Name: "c", Value: ident("a")},
+	}}, symbols.NewSymbolTable())
+	err := in.Init()
+	if err == nil || !strings.Contains(err.Error(), "initialization cycle: a -> b -> a") {
+		t.Fatalf("Expected the cycle of lazy bindings reported. Got %v", err)
+	}
+}
+
+func TestInterpreter_NestedFunctions(t *testing.T) {
+	x := []ast.Pattern{&ast.IdentifierPattern{Name: "x"}}
+	// def scale: (Int) -> Int = {
+	//	(n) => {
+	//		def shift: (Int) -> Int = { (x) => x + n }
+	//		def twice: (Int) -> Int = { (x) => shift(shift(x)) }
+	//		twice(n)
+	//	}
+	// }
+	scale := function("scale", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Functions: []*ast.FunctionDefStmt{
+			function("shift", 1, &ast.FunctionClause{Parameters: x, Body: binary(ident("x"), "+", ident("n"))}),
+			function("twice", 1, &ast.FunctionClause{Parameters: x, Body: call("shift", call("shift", ident("x")))}),
+		},
+		Body: call("twice", ident("n")),
+	})
+	in := newInterpreter(t, scale)
+	for arg, expected := range map[int64]int64{1: 3, 5: 15} {
+		if value, err := in.Call("scale", arg); err != nil || value != expected {
+			t.Fatalf("Expected scale(%d) = %d with shift capturing n. Got %v, %v", arg, expected, value, err)
+		}
+	}
+}
+
+func TestInterpreter_Destructuring(t *testing.T) {
+	tupleType := types.TupleType{Elements: []types.Type{intType, intType}}
+	// extern def divmod: (Int, Int) -> (Int, Int) = "go:divmod"
+	// let (q, r) = divmod(17, 5)
+	// let (_, m) = divmod(4, 2)
+	divmod := &ast.FunctionDefStmt{Name: "divmod", Extern: "go:divmod", Signature: &types.FunctionType{
+		ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}},
+		ReturnType:     tupleType,
+	}}
+	names := func(names ...string) *ast.TuplePattern {
+		pattern := &ast.TuplePattern{}
+		for _, name := range names {
+			pattern.Elements = append(pattern.Elements, &ast.IdentifierPattern{Name: name})
+		}
+		return pattern
+	}
+	table := symbols.NewSymbolTable()
+	table.RegisterFunction(divmod)
+	in := New(&ast.Program{Statements: []ast.AstNode{
+		divmod,
+		&ast.DestructuringDeclStmt{Keyword: "let", Pattern: names("q", "r"), Value: call("divmod", integer(17), integer(5))},
+		&ast.DestructuringDeclStmt{Keyword: "let", Pattern: names("_", "m"), Value: call("divmod", integer(4), integer(2))},
+	}}, table)
+
+	if err := in.RegisterExtern("go:divmod", func(a, b int64) int64 { return a / b }); err != nil {
+		t.Fatalf("RegisterExtern error: %v", err)
+	}
+	if err := in.Init(); err == nil || !strings.Contains(err.Error(), "expected 2 results for (Int, Int)") {
+		t.Fatalf("Expected Init to reject a single Go result. Got %v", err)
+	}
+	in.RegisterExtern("go:divmod", func(a, b int64) (int64, int64) { return a / b, a % b })
+	if err := in.Init(); err != nil {
+		t.Fatalf("Init error: %v", err)
+	}
+	for name, expected := range map[string]int64{"q": 3, "r": 2, "m": 0} {
+		if value, _ := in.Global(name); value != expected {
+			t.Fatalf("Expected %s = %d. Got %v", name, expected, value)
+		}
+	}
+	if _, ok := in.Global("_"); ok {
+		t.Fatalf("Expected _ to bind nothing")
+	}
+	if value, _ := in.Call("divmod", int64(7), int64(2)); FormatValue(value) != "(3, 1)" {
+		t.Fatalf("Expected the tuple (3, 1). Got %s", FormatValue(value))
+	}
+}
+
+func TestInterpreter_TupleLiterals(t *testing.T) {
+	// def divmod: (Int, Int) -> (Int, Int) = (a, b) => (a / b, a % b)
+	// let (q, r) = divmod(17, 5)
+	divmod := function("divmod", 2, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "a"}, &ast.IdentifierPattern{Name: "b"}},
+		Body:       &ast.TupleLiteralExpr{Elements: []ast.Expression{binary(ident("a"), "/", ident("b")), binary(ident("a"), "%", ident("b"))}},
+	})
+	divmod.Signature.ReturnType = types.TupleType{Elements: []types.Type{intType, intType}}
+	in := newInterpreter(t, divmod, &ast.DestructuringDeclStmt{Keyword: "let",
+		Pattern: &ast.TuplePattern{Elements: []ast.Pattern{&ast.IdentifierPattern{Name: "q"}, &ast.IdentifierPattern{Name: "r"}}},
+		Value:   call("divmod", integer(17), integer(5)),
+	})
+
+	for name, expected := range map[string]int64{"q": 3, "r": 2} {
+		if value, _ := in.Global(name); value != expected {
+			t.Fatalf("Expected %s = %d. Got %v", name, expected, value)
+		}
+	}
+	if value, _ := in.Call("divmod", int64(7), int64(2)); FormatValue(value) != "(3, 1)" {
+		t.Fatalf("Expected the tuple (3, 1). Got %s", FormatValue(value))
+	}
+}
+
+func TestInterpreter_Lambdas(t *testing.T) {
+	// def apply_twice: ((Int) -> Int, Int) -> Int = (f, x) => f(f(x))
+	// def add_twice: (Int, Int) -> Int = (n, x) => apply_twice((y) => y + n, x)
+	applyTwice := function("apply_twice", 2, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "f"}, &ast.IdentifierPattern{Name: "x"}},
+		Body:       call("f", call("f", ident("x"))),
+	})
+	addTwice := function("add_twice", 2, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}, &ast.IdentifierPattern{Name: "x"}},
+		Body: call("apply_twice", &ast.LambdaExpr{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "y"}},
+			Body:       binary(ident("y"), "+", ident("n")),
+		}, ident("x")),
+	})
+	in := newInterpreter(t, applyTwice, addTwice)
+
+	value, err := in.Call("add_twice", int64(3), int64(1))
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	if value != int64(7) {
+		t.Fatalf("Expected the lambda to add the n it captured twice, 7. Got %s", FormatValue(value))
+	}
+}
+
+// inspected encodes the inspection of value the way clients receive it
+func inspected(t *testing.T, value Value) string {
+	t.Helper()
+	var out strings.Builder
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(Inspect(value)); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+func TestInspect(t *testing.T) {
+	value := &StructValue{Type: "Reading", Fields: map[string]Value{
+		"at":     &TupleValue{Elements: []Value{int64(3), math.Inf(1)}},
+		"sensor": &DataValue{Type: "Sensor", Constructor: "Probe", Args: []Value{"t1"}},
+		"tags":   &ArrayValue{},
+		"ok":     true,
+	}}
+	data := inspected(t, value)
+	expected := `{"kind":"struct","text":"Reading { at: (3, Inf), ok: true, sensor: Probe(\"t1\"), tags: [] }","type":"Reading","fields":[` +
+		`{"name":"at","value":{"kind":"tuple","text":"(3, Inf)","elements":[{"kind":"int","text":"3","value":3},{"kind":"float","text":"Inf"}]}},` +
+		`{"name":"ok","value":{"kind":"bool","text":"true","value":true}},` +
+		`{"name":"sensor","value":{"kind":"data","text":"Probe(\"t1\")","type":"Sensor","constructor":"Probe","elements":[{"kind":"string","text":"\"t1\"","value":"t1"}]}},` +
+		`{"name":"tags","value":{"kind":"array","text":"[]"}}]}`
+	if data != expected {
+		t.Fatalf("Expected %s. Got %s", expected, data)
+	}
+
+	// def adder: (Int) -> (Int) -> Int = { (n) => { def shift = { (x) => x + n }  shift } }
+	adder := function("adder", 1, &ast.FunctionClause{
+		Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+		Functions: []*ast.FunctionDefStmt{
+			function("shift", 1, &ast.FunctionClause{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "x"}}, Body: binary(ident("x"), "+", ident("n"))}),
+		},
+		Body: ident("shift"),
+	})
+	shift, err := newInterpreter(t, adder).Call("adder", int64(2))
+	if err != nil {
+		t.Fatalf("Call error: %v", err)
+	}
+	data = inspected(t, shift)
+	expected = `{"kind":"closure","text":"<function shift>","name":"shift","arity":1,"captured":[` +
+		`{"name":"n","value":{"kind":"int","text":"2","value":2}},` +
+		`{"name":"shift","value":{"kind":"closure","text":"<function shift>","name":"shift","arity":1}}]}`
+	if data != expected {
+		t.Fatalf("Expected the closure with what it captured. Got %s", data)
+	}
+}
+
+func TestMapValue_InsertionOrder(t *testing.T) {
+	scores := NewMap()
+	for _, name := range []string{"carol", "alice", "bob"} {
+		scores.Set(name, int64(len(name)))
+	}
+	scores.Set("alice", int64(10)) // keeps its place
+	scores.Delete("carol")
+	scores.Set("carol", int64(7)) // moves to the end
+	if text := FormatValue(scores); text != `{"alice": 10, "bob": 3, "carol": 7}` {
+		t.Fatalf("Expected entries in insertion order. Got %s", text)
+	}
+	if value, ok := scores.Get("bob"); !ok || value != int64(3) {
+		t.Fatalf("Expected bob = 3. Got %v, %v", value, ok)
+	}
+
+	reordered := NewMap()
+	for _, name := range []string{"carol", "bob", "alice"} {
+		value, _ := scores.Get(name)
+		reordered.Set(name, value)
+	}
+	if !Equal(scores, reordered) || FormatValue(reordered) == FormatValue(scores) {
+		t.Fatalf("Expected maps with the same entries to be equal in any order")
+	}
+
+	// keys Go cannot compare are found by value
+	origins := NewMap()
+	origins.Set(&StructValue{Type: "Point", Fields: map[string]Value{"x": int64(0)}}, "origin")
+	if value, ok := origins.Get(&StructValue{Type: "Point", Fields: map[string]Value{"x": int64(0)}}); !ok || value != "origin" {
+		t.Fatalf("Expected a struct key to be found by value. Got %v, %v", value, ok)
+	}
+	if plain := Plain(origins); FormatValue(origins) != `{Point { x: 0 }: "origin"}` || len(plain.([]any)) != 1 {
+		t.Fatalf("Expected a map of struct keys as pairs. Got %v", plain)
+	}
+	if data := inspected(t, scores); !strings.HasPrefix(data, `{"kind":"map","text":"{\"alice\": 10, \"bob\": 3, \"carol\": 7}","entries":[{"key":{"kind":"string","text":"\"alice\"","value":"alice"}`) {
+		t.Fatalf("Expected the entries inspected in order. Got %s", data)
+	}
+}
@@ -0,0 +1,292 @@
+package interp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// Plain converts a value to JSON-compatible Go values, the encoding of to_json
+// and lyra eval. Structs become objects; data values become the constructor name
+// when nullary, otherwise an object with the constructor name as its only key
+// holding the fields or the argument list. A map with String keys becomes an
+// object, any other map a list of [key, value] pairs in insertion order.
+func Plain(value Value) any {
+	switch v := value.(type) {
+	case Unit:
+		return nil
+	case *StructValue:
+		return plainFields(v.Fields)
+	case *DataValue:
+		switch {
+		case v.Fields != nil:
+			return map[string]any{v.Constructor: plainFields(v.Fields)}
+		case len(v.Args) == 0:
+			return v.Constructor
+		}
+		args := make([]any, len(v.Args))
+		for i, arg := range v.Args {
+			args[i] = Plain(arg)
+		}
+		return map[string]any{v.Constructor: args}
+	case *ArrayValue:
+		elements := make([]any, len(v.Elements))
+		for i, element := range v.Elements {
+			elements[i] = Plain(element)
+		}
+		return elements
+	case *MapValue:
+		object := make(map[string]any, v.Len())
+		pairs := make([]any, 0, v.Len())
+		for key, value := range v.Entries {
+			if name, ok := key.(string); ok && object != nil {
+				object[name] = Plain(value)
+			} else {
+				object = nil
+			}
+			pairs = append(pairs, []any{Plain(key), Plain(value)})
+		}
+		if object != nil {
+			return object
+		}
+		return pairs
+	}
+	return value
+}
+
+func plainFields(fields map[string]Value) map[string]any {
+	plain := make(map[string]any, len(fields))
+	for name, field := range fields {
+		plain[name] = Plain(field)
+	}
+	return plain
+}
+
+func (in *Interpreter) toJSON(value Value, loc ast.Location) string {
+	data, err := json.Marshal(Plain(value))
+	if err != nil {
+		fail(loc, "to_json: %v", err)
+	}
+	return string(data)
+}
+
+// fromJSON decodes text as a value of t, the checked type of the from_json call
+func (in *Interpreter) fromJSON(text string, t types.Type, loc ast.Location) Value {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var data any
+	if err := decoder.Decode(&data); err != nil {
+		fail(loc, "from_json: %v", err)
+	}
+	if decoder.More() {
+		fail(loc, "from_json: unexpected data after the value")
+	}
+	value, err := in.decode(data, t)
+	if err != nil {
+		fail(loc, "from_json: %v", err)
+	}
+	return value
+}
+
+func (in *Interpreter) decode(data any, t types.Type) (Value, error) {
+	switch t := t.(type) {
+	case types.PrimitiveType:
+		return decodePrimitive(data, t)
+	case types.ArrayType:
+		elements, ok := data.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %s", describe(data))
+		}
+		array := &ArrayValue{Elements: make([]Value, len(elements))}
+		for i, element := range elements {
+			value, err := in.decode(element, t.ElementType)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			array.Elements[i] = value
+		}
+		return array, nil
+	case types.UnresolvedType:
+		return in.decodeNamed(data, t.Name)
+	case types.StructType:
+		return in.decodeNamed(data, t.Name)
+	case types.DataType:
+		return in.decodeNamed(data, t.Name)
+	case types.GenericType, nil:
+		return plainValue(data), nil
+	}
+	return nil, fmt.Errorf("cannot decode %s", t.GetName())
+}
+
+func (in *Interpreter) decodeNamed(data any, name string) (Value, error) {
+	decl, ok := in.table.Types[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined type %s", name)
+	}
+	switch t := decl.Type.(type) {
+	case types.StructType:
+		fields, err := in.decodeFields(data, t.Fields, decl.FieldOrder())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &StructValue{Type: name, Fields: fields}, nil
+	case types.DataType:
+		return in.decodeData(data, t)
+	}
+	return in.decode(data, decl.Type)
+}
+
+func (in *Interpreter) decodeFields(data any, declared map[string]types.StructField, order []string) (map[string]Value, error) {
+	object, ok := data.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %s", describe(data))
+	}
+	fields := make(map[string]Value, len(declared))
+	for key, raw := range object {
+		field, ok := declared[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", key)
+		}
+		value, err := in.decode(raw, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		fields[key] = value
+	}
+	in.applyDefaults(fields, declared, order)
+	for _, name := range sortedNames(declared) {
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("missing field %s", name)
+		}
+	}
+	return fields, nil
+}
+
+func (in *Interpreter) decodeData(data any, t types.DataType) (Value, error) {
+	if name, ok := data.(string); ok {
+		ctor, ok := t.Constructors[name]
+		if !ok || ctor.Fields != nil || len(ctor.Params) > 0 {
+			return nil, fmt.Errorf("%s has no constructor %s without arguments", t.Name, name)
+		}
+		return &DataValue{Type: t.Name, Constructor: name}, nil
+	}
+	object, ok := data.(map[string]any)
+	if !ok || len(object) != 1 {
+		return nil, fmt.Errorf("expected a constructor of %s, got %s", t.Name, describe(data))
+	}
+	for name, payload := range object {
+		ctor, ok := t.Constructors[name]
+		if !ok {
+			return nil, fmt.Errorf("%s has no constructor %s", t.Name, name)
+		}
+		if ctor.Fields != nil {
+			fields, err := in.decodeFields(payload, ctor.Fields, sortedNames(ctor.Fields))
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.Name, name, err)
+			}
+			return &DataValue{Type: t.Name, Constructor: name, Fields: fields}, nil
+		}
+		raw, ok := payload.([]any)
+		if !ok || len(raw) != len(ctor.Params) {
+			return nil, fmt.Errorf("%s.%s expects %d arguments in an array", t.Name, name, len(ctor.Params))
+		}
+		args := make([]Value, len(raw))
+		for i, arg := range raw {
+			value, err := in.decode(arg, ctor.Params[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s argument %d: %w", t.Name, name, i+1, err)
+			}
+			args[i] = value
+		}
+		return &DataValue{Type: t.Name, Constructor: name, Args: args}, nil
+	}
+	return nil, nil // unreachable: object has one key
+}
+
+func decodePrimitive(data any, t types.PrimitiveType) (Value, error) {
+	name := string(t.Name)
+	switch {
+	case t.Name == types.Bool:
+		if b, ok := data.(bool); ok {
+			return b, nil
+		}
+	case t.Name == types.String:
+		if s, ok := data.(string); ok {
+			return s, nil
+		}
+	case t.Name == types.Unit:
+		if data == nil {
+			return Unit{}, nil
+		}
+	case strings.HasPrefix(name, "Float"):
+		if n, ok := data.(json.Number); ok {
+			return n.Float64()
+		}
+	case t.IsNumericType():
+		if n, ok := data.(json.Number); ok {
+			i, err := n.Int64()
+			if err != nil {
+				return nil, fmt.Errorf("%s is not an integer", n)
+			}
+			if strings.HasPrefix(name, "UInt") && i < 0 {
+				return nil, fmt.Errorf("%d is negative", i)
+			}
+			return i, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %s", name, describe(data))
+}
+
+// plainValue converts decoded JSON of unknown type: integral numbers become Int
+func plainValue(data any) Value {
+	switch v := data.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		array := &ArrayValue{Elements: make([]Value, len(v))}
+		for i, element := range v {
+			array.Elements[i] = plainValue(element)
+		}
+		return array
+	case nil:
+		return Unit{}
+	}
+	return data
+}
+
+func describe(data any) string {
+	switch v := data.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case json.Number:
+		return "number " + v.String()
+	case string:
+		quoted, _ := json.Marshal(v)
+		return "string " + string(quoted)
+	}
+	var b bytes.Buffer
+	fmt.Fprint(&b, data)
+	return b.String()
+}
+
+func sortedNames(fields map[string]types.StructField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
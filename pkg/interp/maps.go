@@ -0,0 +1,82 @@
+package interp
+
+// MapValue is a map that iterates in insertion order: Entries lists the keys in
+// the order they were first set, and setting an existing key keeps its place, so
+// a program sees the same order on every run whatever the keys hash to. Deleting
+// a key and setting it again moves it to the end. Maps come from the host program
+// until the language has map literals.
+type MapValue struct {
+	keys   []Value
+	values []Value
+	index  map[any]int // position of each key, by mapKey
+}
+
+// NewMap returns an empty map
+func NewMap() *MapValue {
+	return &MapValue{index: make(map[any]int)}
+}
+
+// compositeKey stands for a key that Go cannot compare, such as a struct value,
+// by its rendering, which is the same for equal values
+type compositeKey struct {
+	text string
+}
+
+// mapKey is what the index of a map holds for key: the primitives themselves and
+// the rendering of the others
+func mapKey(key Value) any {
+	switch key.(type) {
+	case int64, float64, string, bool, Unit:
+		return key
+	}
+	return compositeKey{FormatValue(key)}
+}
+
+// Len returns the number of entries
+func (m *MapValue) Len() int {
+	return len(m.keys)
+}
+
+// Get returns the value of key
+func (m *MapValue) Get(key Value) (Value, bool) {
+	i, ok := m.index[mapKey(key)]
+	if !ok {
+		return nil, false
+	}
+	return m.values[i], true
+}
+
+// Set sets the value of key, adding it after the others if it is new
+func (m *MapValue) Set(key, value Value) {
+	if i, ok := m.index[mapKey(key)]; ok {
+		m.values[i] = value
+		return
+	}
+	m.index[mapKey(key)] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+}
+
+// Delete removes key, keeping the order of the others
+func (m *MapValue) Delete(key Value) {
+	i, ok := m.index[mapKey(key)]
+	if !ok {
+		return
+	}
+	delete(m.index, mapKey(key))
+	m.keys = append(m.keys[:i], m.keys[i+1:]...)
+	m.values = append(m.values[:i], m.values[i+1:]...)
+	for j := i; j < len(m.keys); j++ {
+		m.index[mapKey(m.keys[j])] = j
+	}
+}
+
+// Entries calls yield with each key and value in insertion order until it
+// returns false; `for key, value := range m.Entries` walks the map
+func (m *MapValue) Entries(yield func(key, value Value) bool) {
+	for i, key := range m.keys {
+		if !yield(key, m.values[i]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package interp
+
+import (
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// match reports whether value matches pattern, adding the names it binds to bindings
+func (in *Interpreter) match(pattern ast.Pattern, value Value, bindings env) bool {
+	switch p := pattern.(type) {
+	case *ast.IdentifierPattern:
+		if p.Name != "_" {
+			bindings[p.Name] = value
+		}
+		return true
+	case *ast.WildcardPattern:
+		return true
+	case *ast.AsPattern:
+		bindings[p.Name] = value
+		return in.match(p.Pattern, value, bindings)
+	case *ast.LiteralPattern:
+		text, ok := p.Value.(string)
+		if !ok {
+			return Equal(p.Value, value)
+		}
+		return Equal(literalValue(text), value)
+	case *ast.RangePattern:
+		low, high, err := p.Bounds()
+		if err != nil {
+			return false
+		}
+		var n int64
+		switch v := value.(type) {
+		case int64:
+			if p.IsCharacter() {
+				return false
+			}
+			n = v
+		case string:
+			runes := []rune(v)
+			if !p.IsCharacter() || len(runes) != 1 {
+				return false
+			}
+			n = int64(runes[0])
+		default:
+			return false
+		}
+		return low <= n && n <= high
+	case *ast.StructPattern:
+		var typeName string
+		var fields map[string]Value
+		switch v := value.(type) {
+		case *StructValue:
+			typeName, fields = v.Type, v.Fields
+		case *DataValue:
+			typeName, fields = v.Constructor, v.Fields
+		default:
+			return false
+		}
+		if typeName != p.TypeName {
+			return false
+		}
+		for _, field := range p.Fields {
+			fieldValue, ok := fields[field.Name]
+			if !ok {
+				return false
+			}
+			if field.Pattern == nil {
+				bindings[field.Name] = fieldValue
+				continue
+			}
+			if !in.match(field.Pattern, fieldValue, bindings) {
+				return false
+			}
+		}
+		return true
+	case *ast.ConstructorPattern:
+		data, ok := value.(*DataValue)
+		name := p.Name
+		if _, ctor, qualified := strings.Cut(p.Name, "."); qualified {
+			name = ctor
+		}
+		if !ok || data.Constructor != name || len(data.Args) != len(p.Arguments) {
+			return false
+		}
+		for i, argument := range p.Arguments {
+			if !in.match(argument, data.Args[i], bindings) {
+				return false
+			}
+		}
+		return true
+	case *ast.ArrayPattern:
+		array, ok := value.(*ArrayValue)
+		if !ok || len(array.Elements) < len(p.Elements) || (p.Rest == nil && len(array.Elements) != len(p.Elements)) {
+			return false
+		}
+		for i, element := range p.Elements {
+			if !in.match(element, array.Elements[i], bindings) {
+				return false
+			}
+		}
+		if p.Rest != nil {
+			rest := append([]Value(nil), array.Elements[len(p.Elements):]...)
+			return in.match(p.Rest, &ArrayValue{Elements: rest}, bindings)
+		}
+		return true
+	case *ast.TuplePattern:
+		tuple, ok := value.(*TupleValue)
+		if !ok || len(tuple.Elements) != len(p.Elements) {
+			return false
+		}
+		for i, element := range p.Elements {
+			if !in.match(element, tuple.Elements[i], bindings) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
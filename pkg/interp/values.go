@@ -0,0 +1,215 @@
+package interp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// Value is a runtime value: int64, float64, string, bool, Unit or one of the
+// pointer types below
+type Value any
+
+// Unit is the value of expressions evaluated for their effect
+type Unit struct{}
+
+// Dropper is a value holding a resource that is released when its owner drops it
+// (see SetOwnership)
+type Dropper interface {
+	Drop()
+}
+
+// StructValue is an instance of a struct
+type StructValue struct {
+	Type   string
+	Fields map[string]Value
+}
+
+// DataValue is a value built by a data constructor; positional constructors
+// fill Args, record-style constructors fill Fields
+type DataValue struct {
+	Type        string
+	Constructor string
+	Args        []Value
+	Fields      map[string]Value
+}
+
+// ArrayValue is an array; arrays come from the host program embedding Lyra until
+// the language has array literals
+type ArrayValue struct {
+	Elements []Value
+}
+
+// TupleValue is a tuple. A function returning (Int, Int) returns one TupleValue,
+// never several values: only at the Go boundary does a tuple become several Go
+// results of an extern (see RegisterExtern).
+type TupleValue struct {
+	Elements []Value
+}
+
+// Function is a callable value: a user function, a constructor or a builtin
+type Function struct {
+	Name     string
+	Arity    int // -1 for variadic builtins
+	call     func(in *Interpreter, args []Value, loc ast.Location) Value
+	captured env // the bindings a nested function captured, nil for the others
+}
+
+// FormatValue renders a value the way it would be written in source
+func FormatValue(v Value) string {
+	switch val := v.(type) {
+	case nil:
+		return "<nil>"
+	case Unit:
+		return "()"
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		if math.IsInf(val, 1) {
+			return "Inf" // as the constant is written, not Go's +Inf
+		}
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case *StructValue:
+		return val.Type + " " + formatFields(val.Fields)
+	case *DataValue:
+		if val.Fields != nil {
+			return val.Constructor + " " + formatFields(val.Fields)
+		}
+		if len(val.Args) == 0 {
+			return val.Constructor
+		}
+		args := make([]string, len(val.Args))
+		for i, arg := range val.Args {
+			args[i] = FormatValue(arg)
+		}
+		return fmt.Sprintf("%s(%s)", val.Constructor, strings.Join(args, ", "))
+	case *ArrayValue:
+		elements := make([]string, len(val.Elements))
+		for i, element := range val.Elements {
+			elements[i] = FormatValue(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *TupleValue:
+		elements := make([]string, len(val.Elements))
+		for i, element := range val.Elements {
+			elements[i] = FormatValue(element)
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	case *MapValue:
+		entries := make([]string, 0, val.Len())
+		for key, value := range val.Entries {
+			entries = append(entries, FormatValue(key)+": "+FormatValue(value))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case *Function:
+		return "<function " + val.Name + ">"
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFields(fields map[string]Value) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + FormatValue(fields[name])
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+// Equal compares values structurally
+func Equal(a, b Value) bool {
+	switch av := a.(type) {
+	case *StructValue:
+		bv, ok := b.(*StructValue)
+		return ok && av.Type == bv.Type && fieldsEqual(av.Fields, bv.Fields)
+	case *DataValue:
+		bv, ok := b.(*DataValue)
+		if !ok || av.Type != bv.Type || av.Constructor != bv.Constructor || len(av.Args) != len(bv.Args) {
+			return false
+		}
+		for i := range av.Args {
+			if !Equal(av.Args[i], bv.Args[i]) {
+				return false
+			}
+		}
+		return fieldsEqual(av.Fields, bv.Fields)
+	case *ArrayValue:
+		bv, ok := b.(*ArrayValue)
+		return ok && elementsEqual(av.Elements, bv.Elements)
+	case *TupleValue:
+		bv, ok := b.(*TupleValue)
+		return ok && elementsEqual(av.Elements, bv.Elements)
+	case *MapValue:
+		// maps with the same entries are equal whatever order they were set in
+		bv, ok := b.(*MapValue)
+		if !ok || av.Len() != bv.Len() {
+			return false
+		}
+		equal := true
+		for key, value := range av.Entries {
+			other, found := bv.Get(key)
+			if equal = found && Equal(value, other); !equal {
+				break
+			}
+		}
+		return equal
+	case *Function:
+		return a == b
+	}
+	return a == b
+}
+
+func elementsEqual(a, b []Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldsEqual(a, b map[string]Value) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, ok := b[name]; !ok || !Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalValue parses the source text of a literal pattern or string literal
+func literalValue(text string) Value {
+	if i, err := ast.ParseInteger(text); err == nil {
+		return i
+	}
+	if u, err := ast.ParseUnsigned(text); err == nil {
+		return int64(u) // past the Int64 values, kept as the bits of the UInt64
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if s, err := ast.Unescape(text); err == nil {
+		return s
+	}
+	return text
+}
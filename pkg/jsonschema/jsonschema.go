@@ -0,0 +1,228 @@
+package jsonschema
+
+/*
+Jsonschema converts Lyra types to JSON Schema (draft 2020-12) so payloads can be
+validated against types declared in Lyra. Values are expected in the encoding of
+lyra eval (interp.Plain): structs are objects, arrays are arrays, and a data
+value is its constructor name when the constructor is nullary and otherwise an
+object whose single key is the constructor name, holding the argument list or
+the fields. The constructors of a data type become a oneOf.
+
+Every type a selected type refers to is emitted under $defs; generic parameters
+accept any value.
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document or subschema
+type Schema = map[string]any
+
+type generator struct {
+	table *symbols.SymbolTable
+	defs  map[string]Schema
+}
+
+// Generate returns a schema defining the named types and the types they refer to.
+// With a single name the schema validates that type; otherwise each type is
+// referenced as #/$defs/Name.
+func Generate(table *symbols.SymbolTable, names ...string) (Schema, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no types selected")
+	}
+	g := &generator{table: table, defs: make(map[string]Schema)}
+	for _, name := range names {
+		if _, ok := table.Types[name]; !ok {
+			return nil, fmt.Errorf("undefined type %s", name)
+		}
+		if err := g.define(name); err != nil {
+			return nil, err
+		}
+	}
+
+	schema := Schema{"$schema": Draft, "$defs": g.defs}
+	if len(names) == 1 {
+		schema["$ref"] = defPath(names[0])
+	}
+	return schema, nil
+}
+
+// PublicTypes returns the names of the pub struct and data types of a table in order
+func PublicTypes(table *symbols.SymbolTable) []string {
+	var names []string
+	for name, decl := range table.Types {
+		switch decl.Type.(type) {
+		case types.StructType, types.DataType:
+			if decl.IsPublic {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func ref(name string) Schema {
+	return Schema{"$ref": defPath(name)}
+}
+
+func defPath(name string) string {
+	return "#/$defs/" + name
+}
+
+// define adds the schema of a declared type to $defs
+func (g *generator) define(name string) error {
+	if _, done := g.defs[name]; done {
+		return nil
+	}
+	decl := g.table.Types[name]
+	g.defs[name] = Schema{} // placeholder so recursive types terminate
+
+	var schema Schema
+	var err error
+	switch t := decl.Type.(type) {
+	case types.StructType:
+		schema, err = g.object(t.Fields)
+	case types.DataType:
+		schema, err = g.data(t)
+	default:
+		schema, err = g.schema(t)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	schema["title"] = name
+	g.defs[name] = schema
+	return nil
+}
+
+func (g *generator) object(fields map[string]types.StructField) (Schema, error) {
+	properties := Schema{}
+	required := make([]string, 0)
+	for name, field := range fields {
+		property, err := g.schema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		properties[name] = property
+		if field.DefaultValue == nil {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	return Schema{"type": "object", "properties": properties, "required": required, "additionalProperties": false}, nil
+}
+
+func (g *generator) data(t types.DataType) (Schema, error) {
+	names := make([]string, 0, len(t.Constructors))
+	for name := range t.Constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	oneOf := make([]Schema, 0, len(names))
+	for _, name := range names {
+		ctor := t.Constructors[name]
+		var payload Schema
+		switch {
+		case ctor.Fields != nil:
+			object, err := g.object(ctor.Fields)
+			if err != nil {
+				return nil, fmt.Errorf("constructor %s: %w", name, err)
+			}
+			payload = object
+		case len(ctor.Params) > 0:
+			items := make([]Schema, len(ctor.Params))
+			for i, param := range ctor.Params {
+				item, err := g.schema(param)
+				if err != nil {
+					return nil, fmt.Errorf("constructor %s: %w", name, err)
+				}
+				items[i] = item
+			}
+			payload = Schema{"type": "array", "prefixItems": items, "items": false, "minItems": len(items)}
+		default:
+			oneOf = append(oneOf, Schema{"const": name})
+			continue
+		}
+		oneOf = append(oneOf, Schema{
+			"type":                 "object",
+			"properties":           Schema{name: payload},
+			"required":             []string{name},
+			"additionalProperties": false,
+		})
+	}
+	return Schema{"oneOf": oneOf}, nil
+}
+
+// schema returns the schema of a type used by a field or a constructor
+func (g *generator) schema(t types.Type) (Schema, error) {
+	switch t := t.(type) {
+	case types.PrimitiveType:
+		return primitive(t)
+	case types.ArrayType:
+		items, err := g.schema(t.ElementType)
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "array", "items": items}, nil
+	case types.TupleType:
+		items := make([]Schema, len(t.Elements))
+		for i, element := range t.Elements {
+			item, err := g.schema(element)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return Schema{"type": "array", "prefixItems": items, "items": false, "minItems": len(items)}, nil
+	case types.GenericType:
+		return Schema{}, nil
+	case types.UnresolvedType:
+		return g.named(t.Name)
+	case types.StructType:
+		return g.named(t.Name)
+	case types.DataType:
+		return g.named(t.Name)
+	case nil:
+		return Schema{}, nil
+	}
+	return nil, fmt.Errorf("%s cannot be described by a JSON schema", t.GetName())
+}
+
+func (g *generator) named(name string) (Schema, error) {
+	if _, ok := g.table.Types[name]; !ok {
+		return nil, fmt.Errorf("undefined type %s", name)
+	}
+	if err := g.define(name); err != nil {
+		return nil, err
+	}
+	return ref(name), nil
+}
+
+func primitive(t types.PrimitiveType) (Schema, error) {
+	name := string(t.Name)
+	switch {
+	case t.Name == types.Bool:
+		return Schema{"type": "boolean"}, nil
+	case t.Name == types.String:
+		return Schema{"type": "string"}, nil
+	case strings.HasPrefix(name, "Float"):
+		return Schema{"type": "number"}, nil
+	case strings.HasPrefix(name, "UInt"):
+		return Schema{"type": "integer", "minimum": 0}, nil
+	case t.IsNumericType():
+		return Schema{"type": "integer"}, nil
+	case t.Name == types.Unit:
+		return Schema{"type": "null"}, nil
+	}
+	return nil, fmt.Errorf("%s has no JSON representation", name)
+}
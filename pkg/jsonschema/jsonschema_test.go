@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestGenerate_StructsAndData(t *testing.T) {
+	intType := types.PrimitiveType{Name: types.Int}
+	// pub struct Order { id: UInt, items: [Item], status: Status, note: String = "" }
+	// struct Item { sku: String, quantity: Int }
+	// pub data Status = Pending | Shipped(Int) | Cancelled { reason: String }
+	decls := []*ast.TypeDeclStmt{
+		{Name: "Order", IsPublic: true, Type: types.StructType{Name: "Order", Fields: map[string]types.StructField{
+			"id":     {Name: "id", Type: types.PrimitiveType{Name: types.UInt}},
+			"items":  {Name: "items", Type: types.ArrayType{ElementType: types.UnresolvedType{Name: "Item"}}},
+			"status": {Name: "status", Type: types.UnresolvedType{Name: "Status"}},
+			"note":   {Name: "note", Type: types.PrimitiveType{Name: types.String}, DefaultValue: ""},
+		}}},
+		{Name: "Item", Type: types.StructType{Name: "Item", Fields: map[string]types.StructField{
+			"sku":      {Name: "sku", Type: types.PrimitiveType{Name: types.String}},
+			"quantity": {Name: "quantity", Type: intType},
+		}}},
+		{Name: "Status", IsPublic: true, Type: types.DataType{Name: "Status", Constructors: map[string]types.DataTypeConstructor{
+			"Pending":   {Name: "Pending"},
+			"Shipped":   {Name: "Shipped", Params: []types.Type{intType}},
+			"Cancelled": {Name: "Cancelled", Fields: map[string]types.StructField{"reason": {Name: "reason", Type: types.PrimitiveType{Name: types.String}}}},
+		}}},
+	}
+	table := symbols.NewSymbolTable()
+	for _, decl := range decls {
+		if err := table.RegisterType(decl); err != nil {
+			t.Fatalf("RegisterType error: %v", err)
+		}
+	}
+	if names := PublicTypes(table); !reflect.DeepEqual(names, []string{"Order", "Status"}) {
+		t.Fatalf("Expected the pub types Order and Status. Got %v", names)
+	}
+
+	schema, err := Generate(table, "Order")
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	got, _ := json.Marshal(schema)
+	expected := `{"$defs":{` +
+		`"Item":{"additionalProperties":false,"properties":{"quantity":{"type":"integer"},"sku":{"type":"string"}},"required":["quantity","sku"],"title":"Item","type":"object"},` +
+		`"Order":{"additionalProperties":false,"properties":{"id":{"minimum":0,"type":"integer"},"items":{"items":{"$ref":"#/$defs/Item"},"type":"array"},"note":{"type":"string"},"status":{"$ref":"#/$defs/Status"}},"required":["id","items","status"],"title":"Order","type":"object"},` +
+		`"Status":{"oneOf":[` +
+		`{"additionalProperties":false,"properties":{"Cancelled":{"additionalProperties":false,"properties":{"reason":{"type":"string"}},"required":["reason"],"type":"object"}},"required":["Cancelled"],"type":"object"},` +
+		`{"const":"Pending"},` +
+		`{"additionalProperties":false,"properties":{"Shipped":{"items":false,"minItems":1,"prefixItems":[{"type":"integer"}],"type":"array"}},"required":["Shipped"],"type":"object"}` +
+		`],"title":"Status"}},` +
+		`"$ref":"#/$defs/Order","$schema":"https://json-schema.org/draft/2020-12/schema"}`
+	if string(got) != expected {
+		t.Fatalf("Expected\n%s\nGot\n%s", expected, got)
+	}
+}
+
+func TestGenerate_UndefinedType(t *testing.T) {
+	if _, err := Generate(symbols.NewSymbolTable(), "Missing"); err == nil {
+		t.Fatalf("Expected an error for an undefined type")
+	}
+}
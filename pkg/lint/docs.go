@@ -0,0 +1,100 @@
+package lint
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// paramTagPattern matches a parameter tag in a doc comment: @param name
+var paramTagPattern = regexp.MustCompile(`@param\s+([A-Za-z_][A-Za-z0-9_]*)`)
+
+// checkDocs reports pub functions and types without a doc comment (doc-missing)
+// and @param tags naming a parameter the function does not have (doc-params). A
+// doc comment is the run of // lines directly above a declaration.
+func checkDocs(l *linter) {
+	lines := bytes.Split(l.result.Source, []byte("\n"))
+	for _, stmt := range l.result.Program.Statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			doc, ok := docComment(lines, s.Location.StartLine)
+			if !ok {
+				if s.IsPublic {
+					l.warn("doc-missing", diagnostics.IncompleteDoc, s.NameLocation, "public function %s has no doc comment", s.Name)
+				}
+				continue
+			}
+			params := parameterNames(s)
+			for _, match := range paramTagPattern.FindAllStringSubmatch(doc, -1) {
+				if !params[match[1]] {
+					l.warn("doc-params", diagnostics.IncompleteDoc, s.NameLocation,
+						"doc comment of %s documents @param %s, which %s does not have", s.Name, match[1], s.Name)
+				}
+			}
+		case *ast.TypeDeclStmt:
+			if _, ok := docComment(lines, s.Location.StartLine); !ok && s.IsPublic {
+				l.warn("doc-missing", diagnostics.IncompleteDoc, s.Location, "public type %s has no doc comment", s.Name)
+			}
+		}
+	}
+}
+
+// docComment returns the text of the // lines directly above line (one-based)
+func docComment(lines [][]byte, line int) (string, bool) {
+	var doc []string
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		text := strings.TrimSpace(string(lines[i]))
+		if !strings.HasPrefix(text, "//") {
+			break
+		}
+		doc = append([]string{strings.TrimSpace(strings.TrimPrefix(text, "//"))}, doc...)
+	}
+	return strings.Join(doc, "\n"), len(doc) > 0
+}
+
+// parameterNames returns the names the clauses of fn bind to their parameters
+func parameterNames(fn *ast.FunctionDefStmt) map[string]bool {
+	names := make(map[string]bool)
+	var bind func(pattern ast.Pattern)
+	bind = func(pattern ast.Pattern) {
+		switch p := pattern.(type) {
+		case *ast.IdentifierPattern:
+			names[p.Name] = true
+		case *ast.AsPattern:
+			names[p.Name] = true
+			bind(p.Pattern)
+		case *ast.StructPattern:
+			for _, field := range p.Fields {
+				if field.Pattern == nil {
+					names[field.Name] = true
+				} else {
+					bind(field.Pattern)
+				}
+			}
+		case *ast.TuplePattern:
+			for _, element := range p.Elements {
+				bind(element)
+			}
+		case *ast.ConstructorPattern:
+			for _, argument := range p.Arguments {
+				bind(argument)
+			}
+		case *ast.ArrayPattern:
+			for _, element := range p.Elements {
+				bind(element)
+			}
+			if p.Rest != nil {
+				bind(p.Rest)
+			}
+		}
+	}
+	for _, clause := range fn.Clauses {
+		for _, param := range clause.Parameters {
+			bind(param)
+		}
+	}
+	return names
+}
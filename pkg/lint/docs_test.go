@@ -0,0 +1,57 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const docSource = `// Area of a rectangle
+// @param w the width
+// @param height the height
+pub def area: (Int, Int) -> Int = (width, height) => width * height
+
+pub struct Size { width: Int }
+
+// internal helpers need no docs
+def double: (Int) -> Int = (n) => n * 2
+`
+
+func TestDocs_MissingAndUnknownParams(t *testing.T) {
+	intType := types.PrimitiveType{Name: types.Int}
+	line := func(n int) ast.AstBase { return ast.AstBase{Location: ast.Location{StartLine: n, StartCol: 1}} }
+	params := func(names ...string) []ast.Pattern {
+		patterns := make([]ast.Pattern, len(names))
+		for i, name := range names {
+			patterns[i] = &ast.IdentifierPattern{Name: name}
+		}
+		return patterns
+	}
+	result := analyzed(t,
+		&ast.FunctionDefStmt{
+			AstBase: line(4), Name: "area", IsPublic: true,
+			NameLocation: ast.Location{StartLine: 4, StartCol: 9},
+			Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}, ReturnType: intType},
+			Clauses:      []*ast.FunctionClause{{Parameters: params("width", "height"), Body: &ast.IntegerLiteralExpr{Value: 0}}},
+		},
+		&ast.TypeDeclStmt{AstBase: line(6), Name: "Size", IsPublic: true, Type: types.StructType{Name: "Size"}},
+		&ast.FunctionDefStmt{
+			AstBase: line(9), Name: "double",
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+			Clauses:   []*ast.FunctionClause{{Parameters: params("n"), Body: &ast.IntegerLiteralExpr{Value: 0}}},
+		},
+	)
+	result.Source = []byte(docSource)
+
+	diagnostics := Run(result, DefaultConfig())
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected an unknown @param and an undocumented type. Got %v", diagnostics)
+	}
+	if diagnostics[0].Rule != "doc-params" || diagnostics[0].Message != "doc comment of area documents @param w, which area does not have" {
+		t.Fatalf("Unexpected warning %v", diagnostics[0])
+	}
+	if diagnostics[1].Rule != "doc-missing" || diagnostics[1].Message != "public type Size has no doc comment" {
+		t.Fatalf("Unexpected warning %v", diagnostics[1])
+	}
+}
@@ -0,0 +1,153 @@
+package lint
+
+/*
+Lint reports code that is valid but likely to be hard to read or maintain. Each
+rule has a name (used to disable it in the config) and a diagnostic code (for
+lyra explain). Lint runs on an analyzed file and never changes its analysis, so
+`lyra lint` and the language server share the same results.
+
+The config is a JSON file, lyra-lint.json by default:
+
+	{
+	    "disabled": ["nesting"],
+	    "metrics": {"complexity": 15, "parameters": 6},
+	    "literals": {"numbers": 3}
+	}
+
+Settings left out keep their defaults; a limit of 0 turns its rule off. The rules are
+
+	complexity, clauses, nesting, parameters    function metrics (metrics.go)
+	type-names, generic-names, snake-case       naming conventions (naming.go)
+	magic-numbers, duplicate-strings            repeated literals (literals.go)
+	doc-missing, doc-params                     doc comments (docs.go)
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// DefaultConfigFile is the config lyra lint reads when no -config is given
+const DefaultConfigFile = "lyra-lint.json"
+
+type Config struct {
+	Disabled []string      `json:"disabled"` // names of rules not to run
+	Metrics  MetricLimits  `json:"metrics"`
+	Literals LiteralLimits `json:"literals"`
+}
+
+// MetricLimits are the largest values a function may have before it is reported
+type MetricLimits struct {
+	Complexity int `json:"complexity"`
+	Clauses    int `json:"clauses"`
+	Nesting    int `json:"nesting"`
+	Parameters int `json:"parameters"`
+}
+
+// LiteralLimits are how often the same literal may appear before it should be named
+type LiteralLimits struct {
+	Numbers int `json:"numbers"`
+	Strings int `json:"strings"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Metrics:  MetricLimits{Complexity: 10, Clauses: 8, Nesting: 4, Parameters: 5},
+		Literals: LiteralLimits{Numbers: 2, Strings: 1},
+	}
+}
+
+// LoadConfig reads a config file over the defaults; a missing file gives the defaults
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Diagnostic is a lint warning
+type Diagnostic struct {
+	Rule     string
+	Code     diagnostics.Code
+	Severity diagnostics.Severity
+	Message  string
+	Location ast.Location
+	Fix      *Fix // nil when the warning cannot be fixed automatically
+}
+
+// Fix is an automatic fix for a diagnostic
+type Fix struct {
+	Title string
+	Edits []refactor.TextEdit
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%d:%d: %s: %s [%s %s]", d.Location.StartLine, d.Location.StartCol, d.Severity, d.Message, d.Code, d.Rule)
+}
+
+type linter struct {
+	result      *analyzer.Result
+	config      Config
+	diagnostics []Diagnostic
+}
+
+var checks = []func(l *linter){
+	checkMetrics,
+	checkNaming,
+	checkLiterals,
+	checkDocs,
+}
+
+// Run lints an analyzed file, returning its diagnostics in source order
+func Run(result *analyzer.Result, config Config) []Diagnostic {
+	l := &linter{result: result, config: config, diagnostics: make([]Diagnostic, 0)}
+	for _, check := range checks {
+		check(l)
+	}
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		a, b := l.diagnostics[i].Location, l.diagnostics[j].Location
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.StartCol < b.StartCol
+	})
+	return l.diagnostics
+}
+
+func (l *linter) enabled(rule string) bool {
+	return !slices.Contains(l.config.Disabled, rule)
+}
+
+// warn reports a diagnostic of rule, returning it so a fix can be attached; nil
+// when the rule is disabled
+func (l *linter) warn(rule string, code diagnostics.Code, loc ast.Location, format string, args ...any) *Diagnostic {
+	if !l.enabled(rule) {
+		return nil
+	}
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		Rule:     rule,
+		Code:     code,
+		Severity: diagnostics.Warning,
+		Message:  fmt.Sprintf(format, args...),
+		Location: loc,
+	})
+	return &l.diagnostics[len(l.diagnostics)-1]
+}
@@ -0,0 +1,189 @@
+package lint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// literal is a number or string literal and every place it appears
+type literal struct {
+	key         string // the value, e.g. 42 or "not found"
+	typeName    string
+	occurrences []ast.Expression
+	statement   ast.AstNode // first statement it appears in, where the const goes
+}
+
+// checkLiterals reports numbers repeated more often than the magic-numbers limit
+// (0 and 1 are never magic) and strings repeated more often than the
+// duplicate-strings limit. The fix declares a const just above the first
+// statement using the literal and replaces every occurrence with its name.
+// Literals in const declarations are the names for them, so are not counted.
+func checkLiterals(l *linter) {
+	limits := l.config.Literals
+	literals := make(map[string]*literal)
+	var order []*literal
+	record := func(stmt ast.AstNode) func(expr ast.Expression) {
+		return func(expr ast.Expression) {
+			var key, typeName string
+			switch e := expr.(type) {
+			case *ast.IntegerLiteralExpr:
+				if e.Value == 0 || e.Value == 1 {
+					return
+				}
+				key, typeName = e.GetName(), "Int"
+			case *ast.FloatLiteralExpr:
+				if e.Value == 0 || e.Value == 1 {
+					return
+				}
+				key, typeName = strconv.FormatFloat(e.Value, 'g', -1, 64), "Float"
+			case *ast.StringLiteralExpr:
+				key, typeName = strconv.Quote(e.Value), "String"
+			case *ast.RawStringLiteralExpr:
+				key, typeName = strconv.Quote(e.Value), "String"
+			case *ast.MultilineStringLiteralExpr:
+				key, typeName = strconv.Quote(e.Value), "String"
+			default:
+				return
+			}
+			lit, ok := literals[typeName+" "+key]
+			if !ok {
+				lit = &literal{key: key, typeName: typeName, statement: stmt}
+				literals[typeName+" "+key] = lit
+				order = append(order, lit)
+			}
+			lit.occurrences = append(lit.occurrences, expr)
+		}
+	}
+
+	for _, stmt := range l.result.Program.Statements {
+		visit := record(stmt)
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			if s.Keyword != "const" {
+				walk(s.Value, visit)
+			}
+		case *ast.VarAssignStmt:
+			walk(s.Value, visit)
+		case *ast.ExpressionStmt:
+			walk(s.Expression, visit)
+		case *ast.FunctionDefStmt:
+			for _, clause := range s.Clauses {
+				if clause.Guard != nil {
+					walk(clause.Guard.Condition, visit)
+				}
+				walk(clause.Body, visit)
+			}
+		}
+	}
+
+	for _, lit := range order {
+		rule, limit, what := "magic-numbers", limits.Numbers, "number"
+		if lit.typeName == "String" {
+			rule, limit, what = "duplicate-strings", limits.Strings, "string"
+		}
+		if limit <= 0 || len(lit.occurrences) <= limit {
+			continue
+		}
+		d := l.warn(rule, diagnostics.RepeatedLiteral, lit.occurrences[0].GetLocation(),
+			"%s %s appears %d times; name it with a const", what, lit.key, len(lit.occurrences))
+		if d != nil {
+			d.Fix = l.extractConst(lit)
+		}
+	}
+}
+
+// extractConst builds the fix declaring lit as a const, nil if its source is unknown
+func (l *linter) extractConst(lit *literal) *Fix {
+	text, err := refactor.Text(l.result.Source, lit.occurrences[0].GetLocation())
+	if err != nil || text == "" {
+		return nil
+	}
+	name := l.constName(lit)
+	line := lit.statement.GetLocation().StartLine
+	edits := []refactor.TextEdit{{
+		Location: ast.Location{StartLine: line, StartCol: 1, EndLine: line, EndCol: 1},
+		NewText:  fmt.Sprintf("const %s: %s = %s\n", name, lit.typeName, text),
+	}}
+	for _, occurrence := range lit.occurrences {
+		edits = append(edits, refactor.TextEdit{Location: occurrence.GetLocation(), NewText: name})
+	}
+	return &Fix{Title: fmt.Sprintf("Extract %s into const %s", text, name), Edits: edits}
+}
+
+// constName derives a snake_case name from a string's words, or from a number's
+// digits, that no top-level declaration already uses
+func (l *linter) constName(lit *literal) string {
+	base := "value_" + strings.NewReplacer(".", "_", "-", "minus_", "+", "").Replace(lit.key)
+	if lit.typeName == "String" {
+		words := strings.FieldsFunc(strings.ToLower(strings.Trim(lit.key, `"`)), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) > 4 {
+			words = words[:4]
+		}
+		base = "text"
+		if len(words) > 0 && !unicode.IsDigit(rune(words[0][0])) {
+			base = strings.Join(words, "_")
+		}
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := l.result.Table.GlobalScope.Lookup(name); !taken {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// walk calls visit for expr and every expression within it
+func walk(expr ast.Expression, visit func(ast.Expression)) {
+	if expr == nil {
+		return
+	}
+	visit(expr)
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		walk(e.Callee, visit)
+		for _, argument := range e.Arguments {
+			walk(argument, visit)
+		}
+	case *ast.BinaryOpExpr:
+		walk(e.Left, visit)
+		walk(e.Right, visit)
+	case *ast.BooleanBinaryOpExpr:
+		walk(e.Left, visit)
+		walk(e.Right, visit)
+	case *ast.GuardExpr:
+		walk(e.Condition, visit)
+	case *ast.IfThenExpr:
+		walk(e.Condition, visit)
+		walk(e.Then, visit)
+		walk(e.Else, visit)
+	case *ast.IfBlockExpr:
+		walk(e.Condition, visit)
+		walk(e.Then, visit)
+		walk(e.Else, visit)
+	case *ast.MemberAccessExpr:
+		walk(e.Object, visit)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			walk(field.Value, visit)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			walk(element, visit)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			walk(element, visit)
+		}
+	case *ast.LambdaExpr:
+		walk(e.Body, visit)
+	}
+}
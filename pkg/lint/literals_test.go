@@ -0,0 +1,82 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const literalSource = `def a: (Int) -> Int = (n) => n * 42
+def b: (Int) -> Int = (n) => n + 42 + 42
+let greeting: String = "hi"
+let other: String = "hi"
+`
+
+func literalAt(line, col, length int) ast.ExprBase {
+	return ast.ExprBase{AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}}}
+}
+
+func lintLiterals(t *testing.T) []Diagnostic {
+	intType := types.PrimitiveType{Name: types.Int}
+	stringType := types.PrimitiveType{Name: types.String}
+	fortyTwo := func(line, col int) *ast.IntegerLiteralExpr {
+		return &ast.IntegerLiteralExpr{ExprBase: literalAt(line, col, 2), Value: 42}
+	}
+	function := func(name string, line int, body ast.Expression) *ast.FunctionDefStmt {
+		return &ast.FunctionDefStmt{
+			AstBase:   ast.AstBase{Location: ast.Location{StartLine: line, StartCol: 1}},
+			Name:      name,
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+			Clauses:   []*ast.FunctionClause{{Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}}, Body: body}},
+		}
+	}
+	greeting := func(name string, line, col int) *ast.VarDeclStmt {
+		return &ast.VarDeclStmt{
+			AstBase: ast.AstBase{Location: ast.Location{StartLine: line, StartCol: 1}},
+			Keyword: "let", Name: name, Type: stringType,
+			Value: &ast.StringLiteralExpr{ExprBase: literalAt(line, col, 4), Value: "hi", Text: `"hi"`},
+		}
+	}
+	result := analyzed(t,
+		function("a", 1, &ast.BinaryOpExpr{Left: ident("n"), Operator: "*", Right: fortyTwo(1, 34)}),
+		function("b", 2, &ast.BinaryOpExpr{Left: &ast.BinaryOpExpr{Left: ident("n"), Operator: "+", Right: fortyTwo(2, 34)}, Operator: "+", Right: fortyTwo(2, 39)}),
+		greeting("greeting", 3, 24),
+		greeting("other", 4, 21),
+		&ast.VarDeclStmt{Keyword: "const", Name: "hi", Type: stringType, Value: &ast.StringLiteralExpr{Value: "hi"}},
+	)
+	result.Source = []byte(literalSource)
+	return Run(result, DefaultConfig())
+}
+
+func TestLiterals_RepeatedNumbersAndStrings(t *testing.T) {
+	diagnostics := lintLiterals(t)
+	if len(diagnostics) != 2 {
+		t.Fatalf("Expected warnings for 42 and \"hi\". Got %v", diagnostics)
+	}
+	if diagnostics[0].Message != "number 42 appears 3 times; name it with a const" || diagnostics[0].Rule != "magic-numbers" {
+		t.Fatalf("Unexpected number warning %v", diagnostics[0])
+	}
+	if diagnostics[1].Message != `string "hi" appears 2 times; name it with a const` || diagnostics[1].Rule != "duplicate-strings" {
+		t.Fatalf("Unexpected string warning %v", diagnostics[1])
+	}
+
+	fixed, err := refactor.Apply([]byte(literalSource), diagnostics[0].Fix.Edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if !strings.HasPrefix(string(fixed), "const value_42: Int = 42\ndef a: (Int) -> Int = (n) => n * value_42\ndef b: (Int) -> Int = (n) => n + value_42 + value_42\n") {
+		t.Fatalf("Expected 42 extracted into value_42. Got:\n%s", fixed)
+	}
+
+	// hi is taken by the const declared at the end
+	fixed, err = refactor.Apply([]byte(literalSource), diagnostics[1].Fix.Edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if !strings.HasSuffix(string(fixed), "const hi_2: String = \"hi\"\nlet greeting: String = hi_2\nlet other: String = hi_2\n") {
+		t.Fatalf("Expected \"hi\" extracted into hi_2. Got:\n%s", fixed)
+	}
+}
@@ -0,0 +1,126 @@
+package lint
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// FunctionMetrics measures how hard a function is to follow
+type FunctionMetrics struct {
+	Name     string       `json:"name"`
+	Location ast.Location `json:"-"`
+	Line     int          `json:"line"`
+	// Complexity is the cyclomatic complexity: one path through the function plus
+	// one for every further clause, guard, if and short-circuit operator
+	Complexity int `json:"complexity"`
+	Clauses    int `json:"clauses"`
+	Nesting    int `json:"nesting"` // deepest nesting of ifs
+	Parameters int `json:"parameters"`
+}
+
+// Metrics measures every function of a program in declaration order
+func Metrics(program *ast.Program) []FunctionMetrics {
+	metrics := make([]FunctionMetrics, 0)
+	for _, stmt := range program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok {
+			continue
+		}
+		m := FunctionMetrics{
+			Name:       fn.Name,
+			Location:   fn.NameLocation,
+			Line:       fn.NameLocation.StartLine,
+			Complexity: 1,
+			Clauses:    len(fn.Clauses),
+		}
+		if fn.Signature != nil {
+			m.Parameters = len(fn.Signature.ParameterTypes)
+		}
+		for i, clause := range fn.Clauses {
+			if i > 0 {
+				m.Complexity++
+			}
+			if len(clause.Parameters) > m.Parameters {
+				m.Parameters = len(clause.Parameters)
+			}
+			if clause.Guard != nil {
+				m.Complexity++
+				measure(clause.Guard.Condition, 0, &m)
+			}
+			measure(clause.Body, 0, &m)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+// measure adds the decisions within expr to m; depth is the number of enclosing ifs
+func measure(expr ast.Expression, depth int, m *FunctionMetrics) {
+	switch e := expr.(type) {
+	case *ast.IfThenExpr:
+		measureIf(e.Condition, e.Then, e.Else, depth, m)
+	case *ast.IfBlockExpr:
+		measureIf(e.Condition, e.Then, e.Else, depth, m)
+	case *ast.BooleanBinaryOpExpr:
+		if e.Operator == ast.BooleanBinaryOpAnd || e.Operator == ast.BooleanBinaryOpOr {
+			m.Complexity++
+		}
+		measure(e.Left, depth, m)
+		measure(e.Right, depth, m)
+	case *ast.BinaryOpExpr:
+		measure(e.Left, depth, m)
+		measure(e.Right, depth, m)
+	case *ast.CallExpr:
+		measure(e.Callee, depth, m)
+		for _, argument := range e.Arguments {
+			measure(argument, depth, m)
+		}
+	case *ast.MemberAccessExpr:
+		measure(e.Object, depth, m)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			measure(field.Value, depth, m)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			measure(element, depth, m)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			measure(element, depth, m)
+		}
+	case *ast.LambdaExpr:
+		measure(e.Body, depth, m)
+	}
+}
+
+func measureIf(condition, then, otherwise ast.Expression, depth int, m *FunctionMetrics) {
+	m.Complexity++
+	m.Nesting = max(m.Nesting, depth+1)
+	measure(condition, depth+1, m)
+	measure(then, depth+1, m)
+	measure(otherwise, depth+1, m)
+}
+
+// checkMetrics reports functions over the configured limits
+func checkMetrics(l *linter) {
+	limits := l.config.Metrics
+	for _, m := range Metrics(l.result.Program) {
+		if limits.Complexity > 0 && m.Complexity > limits.Complexity {
+			l.warn("complexity", diagnostics.FunctionTooComplex, m.Location,
+				"%s has a cyclomatic complexity of %d (limit %d)", m.Name, m.Complexity, limits.Complexity)
+		}
+		if limits.Clauses > 0 && m.Clauses > limits.Clauses {
+			l.warn("clauses", diagnostics.FunctionTooComplex, m.Location,
+				"%s has %d clauses (limit %d)", m.Name, m.Clauses, limits.Clauses)
+		}
+		if limits.Nesting > 0 && m.Nesting > limits.Nesting {
+			l.warn("nesting", diagnostics.FunctionTooComplex, m.Location,
+				"%s nests ifs %d deep (limit %d)", m.Name, m.Nesting, limits.Nesting)
+		}
+		if limits.Parameters > 0 && m.Parameters > limits.Parameters {
+			l.warn("parameters", diagnostics.FunctionTooComplex, m.Location,
+				"%s takes %d parameters (limit %d)", m.Name, m.Parameters, limits.Parameters)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// analyzed registers statements and indexes their references, as the analyzer would
+func analyzed(t *testing.T, statements ...ast.AstNode) *analyzer.Result {
+	t.Helper()
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements {
+		var err error
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			err = table.RegisterFunction(s)
+		case *ast.VarDeclStmt:
+			err = table.RegisterVariable(s)
+		case *ast.TypeDeclStmt:
+			err = table.RegisterType(s)
+		}
+		if err != nil {
+			t.Fatalf("register error: %v", err)
+		}
+	}
+	program := &ast.Program{Statements: statements}
+	return &analyzer.Result{Program: program, Table: table, Index: refs.Build(program, table)}
+}
+
+func ident(name string) *ast.IdentifierExpr { return &ast.IdentifierExpr{Name: name} }
+
+func compare(left string, op ast.BooleanBinaryOp, right int64) *ast.BooleanBinaryOpExpr {
+	return &ast.BooleanBinaryOpExpr{Left: ident(left), Operator: op, Right: &ast.IntegerLiteralExpr{Value: right}}
+}
+
+// classify is:
+//
+//	def classify: (Int, Int) -> Int = {
+//	    (a, b) if a < 0 || b < 0 => 0,
+//	    (a, b) => if a > b then (if a > 10 then 2 else 1) else 3,
+//	}
+func classify() *ast.FunctionDefStmt {
+	intType := types.PrimitiveType{Name: types.Int}
+	params := []ast.Pattern{&ast.IdentifierPattern{Name: "a"}, &ast.IdentifierPattern{Name: "b"}}
+	return &ast.FunctionDefStmt{
+		Name:         "classify",
+		NameLocation: ast.Location{StartLine: 1, StartCol: 5},
+		Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{
+			{
+				Parameters: params,
+				Guard:      &ast.GuardExpr{Condition: &ast.BooleanBinaryOpExpr{Left: compare("a", ast.BooleanBinaryOpLT, 0), Operator: ast.BooleanBinaryOpOr, Right: compare("b", ast.BooleanBinaryOpLT, 0)}},
+				Body:       &ast.IntegerLiteralExpr{Value: 0},
+			},
+			{
+				Parameters: params,
+				Body: &ast.IfThenExpr{
+					Condition: &ast.BooleanBinaryOpExpr{Left: ident("a"), Operator: ast.BooleanBinaryOpGT, Right: ident("b")},
+					Then:      &ast.IfThenExpr{Condition: compare("a", ast.BooleanBinaryOpGT, 10), Then: &ast.IntegerLiteralExpr{Value: 2}, Else: &ast.IntegerLiteralExpr{Value: 1}},
+					Else:      &ast.IntegerLiteralExpr{Value: 3},
+				},
+			},
+		},
+	}
+}
+
+func TestMetrics_Function(t *testing.T) {
+	metrics := Metrics(&ast.Program{Statements: []ast.AstNode{classify()}})
+	if len(metrics) != 1 {
+		t.Fatalf("Expected metrics for one function. Got %v", metrics)
+	}
+	// 1 + second clause + guard + || + two ifs
+	expected := FunctionMetrics{Name: "classify", Location: ast.Location{StartLine: 1, StartCol: 5}, Line: 1, Complexity: 6, Clauses: 2, Nesting: 2, Parameters: 2}
+	if metrics[0] != expected {
+		t.Fatalf("Expected %+v. Got %+v", expected, metrics[0])
+	}
+}
+
+func TestRun_ConfiguredLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultConfigFile)
+	config := `{"disabled": ["clauses"], "metrics": {"complexity": 5, "clauses": 1, "nesting": 1}}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if loaded.Metrics.Parameters != DefaultConfig().Metrics.Parameters {
+		t.Fatalf("Expected the parameter limit to keep its default. Got %d", loaded.Metrics.Parameters)
+	}
+
+	diagnostics := Run(analyzed(t, classify()), loaded)
+	rules := ""
+	for _, d := range diagnostics {
+		rules += d.Rule + " "
+	}
+	if rules != "complexity nesting " {
+		t.Fatalf("Expected complexity and nesting warnings only. Got %v", diagnostics)
+	}
+	if diagnostics[0].Error() != "1:5: warning: classify has a cyclomatic complexity of 6 (limit 5) [LYR0019 complexity]" {
+		t.Fatalf("Unexpected message %q", diagnostics[0].Error())
+	}
+}
@@ -0,0 +1,111 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// snakeCasePattern matches function, variable and parameter names; leading
+// underscores mark names that are deliberately unused
+var snakeCasePattern = regexp.MustCompile(`^_*[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// checkNaming enforces the naming conventions:
+//
+//   - types and constructors start with an uppercase letter (type-names)
+//   - generic parameters are lowercase (generic-names)
+//   - functions, variables and parameters are snake_case (snake-case)
+//
+// snake-case warnings carry a fix renaming the symbol and its references. Types
+// and generics are only reported: their uses are not indexed, so they cannot be
+// renamed safely.
+func checkNaming(l *linter) {
+	for _, stmt := range l.result.Program.Statements {
+		switch s := stmt.(type) {
+		case *ast.TypeDeclStmt:
+			if !startsUpper(s.Name) {
+				l.warn("type-names", diagnostics.NamingConvention, s.Location,
+					"type %s should start with an uppercase letter", s.Name)
+			}
+			l.checkGenerics(s.Name, s.GenericParams, s.Location)
+		case *ast.FunctionDefStmt:
+			l.checkGenerics(s.Name, s.GenericParams, s.NameLocation)
+		}
+	}
+	for _, ctors := range l.result.Table.Constructors {
+		for _, ctor := range ctors {
+			if !startsUpper(ctor.Name) {
+				l.warn("type-names", diagnostics.NamingConvention, ctor.Location,
+					"constructor %s.%s should start with an uppercase letter", ctor.DataType, ctor.Name)
+			}
+		}
+	}
+
+	for _, ref := range l.result.Index.All() {
+		if ref.Kind != refs.Definition || ref.Target.Name == "_" || snakeCasePattern.MatchString(ref.Target.Name) {
+			continue
+		}
+		var what string
+		switch ref.Target.Kind {
+		case refs.TargetFunction:
+			what = "function"
+		case refs.TargetVariable:
+			what = "variable"
+		case refs.TargetLocal:
+			what = "parameter"
+		default:
+			continue
+		}
+		suggested := snakeCase(ref.Target.Name)
+		d := l.warn("snake-case", diagnostics.NamingConvention, ref.Location, "%s %s should be snake_case: %s", what, ref.Target.Name, suggested)
+		if d == nil {
+			continue
+		}
+		if edits, err := refactor.RenameSymbol(l.result.Table, l.result.Index, ref.Target, suggested); err == nil && len(edits) > 0 {
+			d.Fix = &Fix{
+				Title: fmt.Sprintf("Rename %s to %s", ref.Target.Name, suggested),
+				Edits: edits,
+			}
+		}
+	}
+}
+
+func (l *linter) checkGenerics(owner string, generics []string, loc ast.Location) {
+	for _, name := range generics {
+		if strings.ToLower(name) != name {
+			l.warn("generic-names", diagnostics.NamingConvention, loc,
+				"generic parameter %s of %s should be lowercase", name, owner)
+		}
+	}
+}
+
+func startsUpper(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// snakeCase converts camelCase and PascalCase names, keeping acronyms together:
+// parseHTTPRequest becomes parse_http_request
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && runes[i-1] != '_' {
+			previousLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"parseHeader":      "parse_header",
+		"ParseHeader":      "parse_header",
+		"parseHTTPRequest": "parse_http_request",
+		"utf8Decode":       "utf8_decode",
+		"_unusedValue":     "_unused_value",
+	} {
+		if got := snakeCase(name); got != expected {
+			t.Fatalf("Expected snakeCase(%q) = %q. Got %q", name, expected, got)
+		}
+	}
+}
+
+func TestNaming_Warnings(t *testing.T) {
+	// struct point { x: Int }
+	// def parseLine<T>: (String) -> Int = (rawLine) => rawLine
+	source := "struct point { x: Int }\ndef parseLine<T>: (String) -> Int = (rawLine) => rawLine\n"
+	point := &ast.TypeDeclStmt{
+		AstBase: ast.AstBase{Location: ast.Location{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 24}},
+		Name:    "point",
+		Type:    types.StructType{Name: "point", Fields: map[string]types.StructField{"x": {Name: "x", Type: types.PrimitiveType{Name: types.Int}}}},
+	}
+	at := func(col, length int) ast.Location {
+		return ast.Location{StartLine: 2, StartCol: col, EndLine: 2, EndCol: col + length}
+	}
+	parseLine := &ast.FunctionDefStmt{
+		Name:          "parseLine",
+		NameLocation:  at(5, 9),
+		GenericParams: []string{"T"},
+		Signature:     &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.PrimitiveType{Name: types.String}}}, ReturnType: types.PrimitiveType{Name: types.Int}},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: at(38, 7)}, Name: "rawLine"}},
+			Body:       &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(50, 7)}}, Name: "rawLine"},
+		}},
+	}
+
+	diagnostics := Run(analyzed(t, point, parseLine), DefaultConfig())
+	rules := ""
+	for _, d := range diagnostics {
+		rules += d.Rule + " "
+	}
+	if rules != "type-names generic-names snake-case snake-case " {
+		t.Fatalf("Expected warnings for point, parseLine, T and rawLine. Got %v", diagnostics)
+	}
+	if diagnostics[0].Fix != nil || diagnostics[1].Fix != nil {
+		t.Fatalf("Expected types and generics to have no fix")
+	}
+
+	fixed := []byte(source)
+	for _, d := range []Diagnostic{diagnostics[3], diagnostics[2]} {
+		var err error
+		if fixed, err = refactor.Apply(fixed, d.Fix.Edits); err != nil {
+			t.Fatalf("Apply error: %v", err)
+		}
+	}
+	expected := "struct point { x: Int }\ndef parse_line<T>: (String) -> Int = (raw_line) => raw_line\n"
+	if string(fixed) != expected {
+		t.Fatalf("Expected the fixes to rename parseLine and rawLine. Got %q", fixed)
+	}
+}
@@ -0,0 +1,83 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// aliasActions offers to introduce a type alias for the tuple annotation at the
+// start of rng when the open documents spell it more than once. Each document
+// spelling it declares the alias, since documents are analyzed on their own,
+// and names it in place of every spelling; the alias is called Tuple, or Tuple2
+// and so on when taken, for the user to rename.
+func (s *Server) aliasActions(uri string, doc *analyzer.Result, rng Range) []CodeAction {
+	tuples, err := refactor.TupleAnnotations(doc.Source, doc.Program)
+	if err != nil {
+		return nil
+	}
+	line, col := fromPosition(rng.Start)
+	tuple := ""
+	for _, annotation := range tuples {
+		if covers(annotation.Location, line, col) {
+			tuple = annotation.Type // the innermost, outer tuples coming first
+		}
+	}
+	if tuple == "" {
+		return nil
+	}
+
+	s.settleAll()
+	var spelling []string
+	occurrences := 0
+	for other, open := range s.documents.open {
+		if open == nil || open.Program == nil || open.Table == nil {
+			continue
+		}
+		annotations, err := refactor.TupleAnnotations(open.Source, open.Program)
+		if err != nil {
+			continue
+		}
+		found := 0
+		for _, annotation := range annotations {
+			if annotation.Type == tuple {
+				found++
+			}
+		}
+		if found > 0 {
+			spelling = append(spelling, other)
+			occurrences += found
+		}
+	}
+	if occurrences < 2 {
+		return nil
+	}
+	sort.Strings(spelling)
+
+	name := "Tuple"
+	for n := 2; aliasTaken(s.documents.open, spelling, name); n++ {
+		name = fmt.Sprintf("Tuple%d", n)
+	}
+	edit := &WorkspaceEdit{Changes: make(map[string][]TextEdit, len(spelling))}
+	for _, other := range spelling {
+		open := s.documents.open[other]
+		edits, err := refactor.IntroduceAlias(open.Source, open.Program, open.Table, tuple, name)
+		if err != nil {
+			return nil
+		}
+		edit.Changes[other] = workspaceEdit(other, edits).Changes[other]
+	}
+	return []CodeAction{{Title: fmt.Sprintf("Introduce type alias %s for %s", name, tuple), Kind: "refactor.extract", Edit: edit}}
+}
+
+// aliasTaken reports whether one of the documents at uris declares a type name
+func aliasTaken(documents map[string]*analyzer.Result, uris []string, name string) bool {
+	for _, uri := range uris {
+		if _, ok := documents[uri].Table.Types[name]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// annotatedLines analyzes documents of one declaration per line, locating the
+// annotation of each let and the signature of each def between ": " and " = ";
+// a line struct Name declares an empty struct
+func annotatedLines(source []byte) (*analyzer.Result, error) {
+	table := symbols.NewSymbolTable()
+	program := &ast.Program{}
+	for i, line := range strings.Split(string(source), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name := strings.TrimSuffix(fields[1], ":")
+		if fields[0] == "struct" {
+			decl := &ast.TypeDeclStmt{Name: name, Type: types.StructType{Name: name}}
+			table.RegisterType(decl)
+			program.Statements = append(program.Statements, decl)
+			continue
+		}
+		start, end := strings.Index(line, ": ")+2, strings.Index(line, " = ")
+		annotation := at(i+1, start+1, end-start)
+		switch fields[0] {
+		case "let":
+			program.Statements = append(program.Statements, &ast.VarDeclStmt{Keyword: "let", Name: name, TypeLocation: annotation})
+		case "def":
+			program.Statements = append(program.Statements, &ast.FunctionDefStmt{Name: name, SignatureLocation: annotation})
+		}
+	}
+	return &analyzer.Result{Source: source, Program: program, Table: table, Index: refs.Build(program, table)}, nil
+}
+
+func TestServer_IntroduceAliasAction(t *testing.T) {
+	document := func(name string) string { return "file:///" + name + ".lyra" }
+	open := func(name, text string) map[string]any {
+		return notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: document(name), Text: text}})
+	}
+	actionsAt := func(id int, name string, line, character int) map[string]any {
+		return call(id, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: document(name)},
+			Range:        Range{Start: Position{Line: line, Character: character}, End: Position{Line: line, Character: character}},
+		})
+	}
+	responses, _ := exchange(t, annotatedLines,
+		call(1, "initialize", map[string]any{}),
+		open("points", "let origin: (Float, Float) = zero()\ndef norm: ((Float, Float)) -> Float = (p) => len(p)\n"),
+		open("segments", "struct Tuple\ndef mid: ((Float,Float), (Float, Float)) -> (Float, Float) = (a, b) => half(a, b)\n"),
+		open("counts", "let n: (Int, Int) = pair()\n"),
+		actionsAt(2, "points", 0, 14),
+		actionsAt(3, "counts", 0, 9),
+		notify("exit", nil),
+	)
+
+	var actions []CodeAction
+	if err := json.Unmarshal(responses[2], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Title != "Introduce type alias Tuple2 for (Float, Float)" || actions[0].Edit == nil {
+		t.Fatalf("Expected an action introducing Tuple2, Tuple being taken. Got %+v", actions)
+	}
+	changes := actions[0].Edit.Changes
+	if len(changes) != 2 || changes[document("counts")] != nil {
+		t.Fatalf("Expected only the documents spelling the tuple changed. Got %+v", changes)
+	}
+	points := changes[document("points")]
+	if len(points) != 3 || points[0].NewText != "type Tuple2 = (Float, Float)\n\n" || points[0].Range != (Range{}) {
+		t.Fatalf("Expected the alias declared atop points and both spellings replaced. Got %+v", points)
+	}
+	if points[2].NewText != "Tuple2" || points[2].Range != (Range{Start: Position{Line: 1, Character: 11}, End: Position{Line: 1, Character: 25}}) {
+		t.Fatalf("Expected the tuple parameter of norm replaced. Got %+v", points[2])
+	}
+	if segments := changes[document("segments")]; len(segments) != 4 {
+		t.Fatalf("Expected the alias declared in segments and its three spellings replaced. Got %+v", segments)
+	}
+
+	if err := json.Unmarshal(responses[3], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("Expected no action for a tuple spelled once. Got %+v", actions)
+	}
+}
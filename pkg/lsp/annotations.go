@@ -0,0 +1,44 @@
+package lsp
+
+import (
+	"bytes"
+
+	"github.com/Lyra-Language/lyra/pkg/format"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// canonicalAnnotationsCommand rewrites the type annotations of every open
+// document in canonical form and formats the result; it takes no arguments
+const canonicalAnnotationsCommand = "lyra.canonicalizeAnnotations"
+
+// canonicalizeAnnotations returns a workspace edit replacing each open document
+// whose annotations or layout change with its canonical, formatted text
+func (s *Server) canonicalizeAnnotations() (any, error) {
+	s.settleAll()
+	edit := &WorkspaceEdit{Changes: make(map[string][]TextEdit)}
+	for uri, doc := range s.documents.open {
+		if doc.Program == nil {
+			continue
+		}
+		edits, err := refactor.CanonicalAnnotations(doc.Source, doc.Program)
+		if err != nil {
+			return nil, err
+		}
+		canonical, err := refactor.Apply(doc.Source, edits)
+		if err != nil {
+			return nil, err
+		}
+		canonical = format.Source(canonical, format.DefaultOptions)
+		if bytes.Equal(canonical, doc.Source) {
+			continue
+		}
+		edit.Changes[uri] = []TextEdit{{Range: wholeDocument(doc.Source), NewText: string(canonical)}}
+	}
+	return edit, nil
+}
+
+// wholeDocument returns the range covering all of source
+func wholeDocument(source []byte) Range {
+	lines := bytes.Split(source, []byte("\n"))
+	return Range{End: Position{Line: len(lines) - 1, Character: len(lines[len(lines)-1])}}
+}
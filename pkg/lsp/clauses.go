@@ -0,0 +1,43 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// clauseActions offers to sort the clauses of a function overlapping rng. If the
+// new order lets a clause run before a guarded clause it overlaps with, the title
+// says so, as the function may then behave differently. A function of one clause
+// can be converted between the `= (x) => …` and `= { (x) => …, }` forms.
+func (s *Server) clauseActions(uri string, doc *analyzer.Result, rng Range) []CodeAction {
+	var actions []CodeAction
+	rewrite := func(title string, edits []refactor.TextEdit) {
+		actions = append(actions, CodeAction{Title: title, Kind: "refactor.rewrite", Edit: workspaceEdit(uri, edits)})
+	}
+	for _, stmt := range doc.Program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || !overlaps(toRange(fn.Location), rng) {
+			continue
+		}
+		if edits, err := refactor.ToClauseBlock(doc.Source, fn); err == nil {
+			rewrite(fmt.Sprintf("Convert %s to a block of clauses", fn.Name), edits)
+		}
+		if edits, err := refactor.ToSingleClause(doc.Source, fn); err == nil {
+			rewrite(fmt.Sprintf("Convert %s to a single clause", fn.Name), edits)
+		}
+		edits, warnings, err := refactor.SortClauses(doc.Source, fn)
+		if err != nil || len(edits) == 0 {
+			continue
+		}
+		title := fmt.Sprintf("Sort clauses of %s", fn.Name)
+		if len(warnings) > 0 {
+			title += " (may change behavior: " + strings.Join(warnings, "; ") + ")"
+		}
+		rewrite(title, edits)
+	}
+	return actions
+}
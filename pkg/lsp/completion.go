@@ -0,0 +1,318 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// Completion ranks, best first
+const (
+	rankValue    = iota // a value of the expected type
+	rankProducer        // a function or constructor returning the expected type
+	rankOther
+)
+
+// completion answers textDocument/completion with every name in scope, ranked by
+// the type the checker expects at the cursor: values of that type first, then
+// functions and constructors producing it, then the rest. After `name.` it offers
+// the fields of name's struct type instead, and in a type annotation the types.
+func (s *Server) completion(params json.RawMessage) (any, error) {
+	var p CompletionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line, col := fromPosition(p.Position)
+	expectation := checker.ExpectedAt(doc.Program, doc.Table, line, col)
+	c := completer{table: doc.Table, expected: expectation.Type, snippets: s.snippets}
+
+	offset := offsetOf(doc.Source, line, col)
+	if chain, ok := memberChain(doc.Source[:offset]); ok {
+		c.addFields(chain, expectation)
+	} else if typeContext(doc.Source[:offset]) {
+		c.addTypes(doc.Program, line)
+	} else {
+		c.addNames(expectation)
+	}
+
+	sort.Slice(c.items, func(i, j int) bool { return c.items[i].SortText < c.items[j].SortText })
+	if len(c.items) > 0 && c.expected != nil && c.items[0].SortText[0] == '0'+rankValue {
+		c.items[0].Preselect = true
+	}
+	return CompletionList{Items: c.items}, nil
+}
+
+// completionKinds maps the kinds of Lyra declarations to LSP completion kinds
+var completionKinds = map[symbols.SymbolKind]CompletionItemKind{
+	symbols.SymbolFunction:      CompletionFunction,
+	symbols.SymbolStruct:        CompletionStruct,
+	symbols.SymbolData:          CompletionEnum,
+	symbols.SymbolConstructor:   CompletionConstructor,
+	symbols.SymbolTrait:         CompletionInterface,
+	symbols.SymbolMethod:        CompletionMethod,
+	symbols.SymbolVariable:      CompletionVariable,
+	symbols.SymbolConstant:      CompletionConstant,
+	symbols.SymbolTypeParameter: CompletionTypeParameter,
+	symbols.SymbolAlias:         CompletionTypeParameter,
+}
+
+type completer struct {
+	table    *symbols.SymbolTable
+	expected types.Type
+	snippets bool
+	items    []CompletionItem
+	seen     map[string]bool
+}
+
+// addNames offers the variables, functions and constructors in scope, leaving out
+// the declarations of other files the document does not see
+func (c *completer) addNames(expectation checker.Expectation) {
+	for name, t := range expectation.Locals {
+		c.add(name, CompletionVariable, t, nil)
+	}
+	for name, fn := range c.table.Functions {
+		if _, shadowed := expectation.Locals[name]; shadowed || fn.Signature == nil || !c.table.GlobalScope.Sees(name) {
+			continue
+		}
+		c.add(name, completionKinds[symbols.KindOf(fn)], fn.Signature, fn.Signature.ReturnType).call(name, fn.Signature, c.snippets)
+	}
+	for name, named := range c.table.GlobalScope.Visible() {
+		if _, shadowed := expectation.Locals[name]; shadowed || !c.table.GlobalScope.Sees(name) {
+			continue
+		}
+		if decl, ok := named.(*ast.VarDeclStmt); ok {
+			c.add(name, completionKinds[symbols.KindOf(decl)], decl.Type, nil)
+		}
+	}
+	for name, ctors := range c.table.Constructors {
+		for _, ctor := range ctors {
+			if c.table.GlobalScope.Sees(ctor.DataType) {
+				c.addConstructor(name, ctor)
+			}
+		}
+	}
+	for _, name := range checker.Builtins {
+		if _, taken := c.seen[name]; !taken {
+			c.add(name, CompletionFunction, nil, nil)
+		}
+	}
+}
+
+// addFields offers the fields of the struct reached by a chain of names, p.origin
+func (c *completer) addFields(chain []string, expectation checker.Expectation) {
+	t, ok := expectation.Locals[chain[0]]
+	if !ok {
+		named, found := c.table.GlobalScope.Lookup(chain[0])
+		decl, isVar := named.(*ast.VarDeclStmt)
+		if !found || !isVar {
+			return
+		}
+		t = decl.Type
+	}
+	for _, member := range chain[1:] {
+		structType, ok := c.structType(t)
+		if !ok {
+			return
+		}
+		t = structType.Fields[member].Type
+	}
+	structType, ok := c.structType(t)
+	if !ok {
+		return
+	}
+	for name, field := range structType.Fields {
+		c.add(name, CompletionField, field.Type, nil)
+	}
+}
+
+// addTypes offers the primitive types and the declared ones the document sees, and
+// the generic parameters of the declaration at line
+func (c *completer) addTypes(program *ast.Program, line int) {
+	for _, stmt := range program.Statements {
+		loc := stmt.GetLocation()
+		if line < loc.StartLine || line > loc.EndLine {
+			continue
+		}
+		var params []string
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			params = s.GenericParams
+		case *ast.TypeDeclStmt:
+			params = s.GenericParams
+		}
+		for _, name := range params {
+			c.insert(name, completionKinds[symbols.SymbolTypeParameter], nil, rankValue)
+		}
+	}
+	for name, decl := range c.table.Types {
+		if !c.table.GlobalScope.Sees(name) {
+			continue
+		}
+		c.insert(name, completionKinds[symbols.KindOf(decl)], nil, rankValue)
+	}
+	for _, name := range types.PrimitiveTypeNames {
+		c.insert(string(name), CompletionKeyword, nil, rankOther)
+	}
+}
+
+func (c *completer) structType(t types.Type) (types.StructType, bool) {
+	switch st := t.(type) {
+	case types.StructType:
+		return st, true
+	case types.UnresolvedType:
+		if decl, ok := c.table.Types[st.Name]; ok {
+			structType, ok := decl.Type.(types.StructType)
+			return structType, ok
+		}
+	}
+	return types.StructType{}, false
+}
+
+// add ranks a name by its type t, or by produces, the type it returns when called
+func (c *completer) add(name string, kind CompletionItemKind, t, produces types.Type) *CompletionItem {
+	rank := rankOther
+	switch {
+	case checker.Fits(c.table, c.expected, t):
+		rank = rankValue
+	case checker.Fits(c.table, c.expected, produces):
+		rank = rankProducer
+	}
+	return c.insert(name, kind, t, rank)
+}
+
+func (c *completer) addConstructor(name string, ctor *ast.DataConstructorDecl) {
+	if ctor.Signature == nil {
+		c.insert(name, CompletionConstructor, nil, rankOther)
+		return
+	}
+	// constructors of the expected data type come first: nullary ones are values of it
+	if len(ctor.Signature.ParameterTypes) == 0 {
+		c.add(name, CompletionConstructor, ctor.Signature.ReturnType, nil)
+		return
+	}
+	c.add(name, CompletionConstructor, ctor.Signature, ctor.Signature.ReturnType).call(name, ctor.Signature, c.snippets)
+}
+
+func (c *completer) insert(name string, kind CompletionItemKind, t types.Type, rank int) *CompletionItem {
+	if c.seen == nil {
+		c.seen = make(map[string]bool)
+	}
+	c.seen[name] = true
+	item := CompletionItem{Label: name, Kind: kind, SortText: fmt.Sprintf("%d_%s", rank, name)}
+	if t != nil {
+		item.Detail = t.GetName()
+	}
+	c.items = append(c.items, item)
+	return &c.items[len(c.items)-1]
+}
+
+// call makes item insert a call of name, with a placeholder for each parameter
+// named by its type if the client takes snippets: area(${1:Shape})
+func (item *CompletionItem) call(name string, sig *types.FunctionType, snippets bool) {
+	if !snippets {
+		return
+	}
+	placeholders := make([]string, len(sig.ParameterTypes))
+	escape := strings.NewReplacer(`\`, `\\`, "$", `\$`, "}", `\}`)
+	for i, param := range sig.ParameterTypes {
+		placeholders[i] = fmt.Sprintf("${%d:%s}", i+1, escape.Replace(param.GetName()))
+	}
+	item.InsertText = name + "(" + strings.Join(placeholders, ", ") + ")"
+	item.InsertTextFormat = InsertTextSnippet
+}
+
+// offsetOf returns the byte offset of a one-based line and column, clamped to source
+func offsetOf(source []byte, line, col int) int {
+	offset := 0
+	for ; line > 1 && offset < len(source); line-- {
+		next := bytes.IndexByte(source[offset:], '\n')
+		if next < 0 {
+			return len(source)
+		}
+		offset += next + 1
+	}
+	return min(offset+col-1, len(source))
+}
+
+// trimWord drops the partly typed name before the cursor
+func trimWord(before []byte) []byte {
+	return bytes.TrimRightFunc(before, isNameRune)
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// memberChain returns the names before the cursor in `p.origin.|`, false if
+// the cursor does not follow a dot after a name
+func memberChain(before []byte) ([]string, bool) {
+	rest := trimWord(before)
+	var chain []string
+	for len(rest) > 0 && rest[len(rest)-1] == '.' {
+		rest = rest[:len(rest)-1]
+		name := trimWord(rest)
+		if len(name) == len(rest) {
+			break
+		}
+		chain = append([]string{string(rest[len(name):])}, chain...)
+		rest = name
+	}
+	if len(chain) == 0 || !unicode.IsLetter(rune(chain[0][0])) && chain[0][0] != '_' {
+		return nil, false
+	}
+	return chain, true
+}
+
+var (
+	signatureStart = regexp.MustCompile(`(?m)^\s*(pub\s+)?(extern\s+)?(pure\s+)?(async\s+)?def\s+\w+\s*(<[^>]*>)?\s*:[^=\n]*$`)
+	declaredName   = regexp.MustCompile(`\b(let|var|const)\s+\w+\s*:$`)
+	structStart    = regexp.MustCompile(`(\bstruct\s+\w+\s*(<[^>]*>)?|\bdata\s[^{}]*[=|]\s*\w+)\s*$`) // or record constructor
+)
+
+// typeContext reports whether the cursor is in a type annotation: after the `:`
+// of a declaration or struct field, after `->`, inside a function signature, or
+// in the `[` or `<` of an annotation
+func typeContext(before []byte) bool {
+	text := bytes.TrimRight(trimWord(before), " \t")
+	switch {
+	case bytes.HasSuffix(text, []byte("->")), signatureStart.Match(lastLine(text)):
+		return true
+	case bytes.HasSuffix(text, []byte("[")), bytes.HasSuffix(text, []byte("<")):
+		return typeContext(text[:len(text)-1])
+	case bytes.HasSuffix(text, []byte(":")):
+		if declaredName.Match(text) {
+			return true
+		}
+		// a field of a struct declaration rather than of a struct literal
+		depth := 0
+		for i := len(text) - 1; i >= 0; i-- {
+			switch text[i] {
+			case '}':
+				depth++
+			case '{':
+				if depth == 0 {
+					return structStart.Match(text[:i])
+				}
+				depth--
+			}
+		}
+	}
+	return false
+}
+
+func lastLine(text []byte) []byte {
+	return text[bytes.LastIndexByte(text, '\n')+1:]
+}
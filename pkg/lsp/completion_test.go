@@ -0,0 +1,148 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const shapesSource = "data Shape = Circle(Float) | Empty\n" +
+	"def area: (Shape) -> Float = (s) => 0.0\n" +
+	"let unit: Float = 1.0\n" +
+	"def scale: (Float, Shape) -> Float = (k, s) => area(s)\n"
+
+func TestServer_CompletionRankedByExpectedType(t *testing.T) {
+	completeAt := func(line, character int) CompletionParams {
+		return CompletionParams{TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: line, Character: character}}}
+	}
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, shapesSource),
+		call(2, "textDocument/completion", completeAt(3, 52)), // area(|s)
+		call(3, "textDocument/completion", completeAt(2, 18)), // let unit: Float = |1.0
+		notify("exit", nil),
+	)
+
+	labels := func(id int) []string {
+		var list CompletionList
+		if err := json.Unmarshal(responses[id], &list); err != nil {
+			t.Fatalf("invalid completion result: %v", err)
+		}
+		result := make([]string, len(list.Items))
+		for i, item := range list.Items {
+			result[i] = item.Label
+		}
+		if !list.Items[0].Preselect {
+			t.Fatalf("Expected the best match preselected. Got %+v", list.Items[0])
+		}
+		return result
+	}
+	expectPrefix := func(id int, expected ...string) {
+		got := labels(id)
+		for i, label := range expected {
+			if i >= len(got) || got[i] != label {
+				t.Fatalf("Expected completions to start with %v. Got %v", expected, got)
+			}
+		}
+	}
+	// a Shape is expected: the local s and the nullary Empty, then Circle(...)
+	expectPrefix(2, "Empty", "s", "Circle", "area", "assert")
+	// a Float is expected: unit, then the functions returning Float
+	expectPrefix(3, "unit", "area", "scale", "Circle")
+}
+
+// segmentSource is the text of segmentResult, the last two lines being typed
+const segmentSource = "struct Point { x: Float, y: Float }\n" +
+	"struct Segment { from: Point }\n" +
+	"let seg: Segment = todo()\n" +
+	"let y: Float = seg.from.\n" +
+	"def length<T>: (Seg"
+
+func segmentResult(source []byte) (*analyzer.Result, error) {
+	floatType := types.PrimitiveType{Name: types.Float}
+	table := symbols.NewSymbolTable()
+	point := &ast.TypeDeclStmt{AstBase: ast.AstBase{Location: at(1, 1, 35)}, Name: "Point", Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+		"x": {Name: "x", Type: floatType},
+		"y": {Name: "y", Type: floatType},
+	}}}
+	segment := &ast.TypeDeclStmt{AstBase: ast.AstBase{Location: at(2, 1, 30)}, Name: "Segment", Type: types.StructType{Name: "Segment", Fields: map[string]types.StructField{
+		"from": {Name: "from", Type: types.UnresolvedType{Name: "Point"}},
+	}}}
+	seg := &ast.VarDeclStmt{AstBase: ast.AstBase{Location: at(3, 1, 25)}, Keyword: "let", Name: "seg", Type: types.UnresolvedType{Name: "Segment"}}
+	length := &ast.FunctionDefStmt{AstBase: ast.AstBase{Location: at(5, 1, 19)}, Name: "length", GenericParams: []string{"T"}}
+	table.RegisterType(point)
+	table.RegisterType(segment)
+	table.RegisterVariable(seg)
+	return &analyzer.Result{Source: source, Program: &ast.Program{Statements: []ast.AstNode{point, segment, seg, length}}, Table: table}, nil
+}
+
+func TestServer_CompletionOfFieldsAndTypes(t *testing.T) {
+	completeAt := func(line, character int) CompletionParams {
+		return CompletionParams{TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: line, Character: character}}}
+	}
+	responses := sessionWith(t, segmentResult,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: segmentSource}}),
+		call(2, "textDocument/completion", completeAt(3, 24)), // seg.from.|
+		call(3, "textDocument/completion", completeAt(4, 19)), // (Seg|
+		call(4, "textDocument/completion", completeAt(0, 18)), // struct Point { x: |Float
+		call(5, "textDocument/completion", completeAt(2, 19)), // let seg: Segment = |todo()
+		notify("exit", nil),
+	)
+
+	items := func(id int) []CompletionItem {
+		var list CompletionList
+		if err := json.Unmarshal(responses[id], &list); err != nil {
+			t.Fatalf("invalid completion result: %v", err)
+		}
+		return list.Items
+	}
+	fields := items(2)
+	if len(fields) != 2 || fields[0].Label != "x" || fields[0].Kind != CompletionField || fields[1].Label != "y" {
+		t.Fatalf("Expected the fields x and y of Point. Got %+v", fields)
+	}
+	for _, id := range []int{3, 4} {
+		typeItems := items(id)
+		if len(typeItems) < 3 || typeItems[0].Label != "Point" || typeItems[1].Label != "Segment" || typeItems[0].Kind != CompletionStruct {
+			t.Fatalf("Expected the declared types first in request %d. Got %+v", id, typeItems)
+		}
+		if id == 3 && typeItems[2].Label != "T" {
+			t.Fatalf("Expected the generic parameter T of length. Got %+v", typeItems[2])
+		}
+	}
+	if values := items(5); len(values) == 0 || values[0].Kind == CompletionStruct {
+		t.Fatalf("Expected values, not types, after =. Got %+v", values)
+	}
+}
+
+func TestServer_CompletionSnippets(t *testing.T) {
+	var capabilities ClientCapabilities
+	capabilities.TextDocument.Completion.CompletionItem.SnippetSupport = true
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", InitializeParams{Capabilities: capabilities}),
+		openDocument(testURI, shapesSource),
+		call(2, "textDocument/completion", CompletionParams{TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: 3, Character: 52}}}),
+		notify("exit", nil),
+	)
+
+	var list CompletionList
+	if err := json.Unmarshal(responses[2], &list); err != nil {
+		t.Fatalf("invalid completion result: %v", err)
+	}
+	inserts := make(map[string]string)
+	for _, item := range list.Items {
+		if item.InsertTextFormat == InsertTextSnippet {
+			inserts[item.Label] = item.InsertText
+		}
+	}
+	if inserts["scale"] != "scale(${1:Float}, ${2:Shape})" || inserts["Circle"] != "Circle(${1:Float})" {
+		t.Fatalf("Expected calls with a placeholder per parameter. Got %v", inserts)
+	}
+	if _, ok := inserts["Empty"]; ok {
+		t.Fatalf("Expected the nullary Empty to be inserted as is. Got %q", inserts["Empty"])
+	}
+}
@@ -0,0 +1,148 @@
+package lsp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// declaredType is a data type by the document declaring it
+type declaredType struct {
+	uri  string
+	name string
+}
+
+// dataTypes returns the constructors of each data type doc declares
+func dataTypes(doc *analyzer.Result) map[string]map[string]bool {
+	declared := make(map[string]map[string]bool)
+	if doc == nil || doc.Program == nil {
+		return declared
+	}
+	for _, stmt := range doc.Program.Statements {
+		decl, ok := stmt.(*ast.TypeDeclStmt)
+		if !ok {
+			continue
+		}
+		if dataType, ok := decl.Type.(types.DataType); ok {
+			declared[decl.Name] = make(map[string]bool, len(dataType.Constructors))
+			for name := range dataType.Constructors {
+				declared[decl.Name][name] = true
+			}
+		}
+	}
+	return declared
+}
+
+// noteConstructors records the constructors the new analysis of the document
+// at uri adds to the data types it declares, and forgets those it no longer
+// declares. It returns the data types that gained a constructor, sorted.
+func (s *Server) noteConstructors(uri string, before, after *analyzer.Result) []string {
+	old, current := dataTypes(before), dataTypes(after)
+	for key, added := range s.addedConstructors {
+		if key.uri != uri {
+			continue
+		}
+		for ctor := range added {
+			if !current[key.name][ctor] {
+				delete(added, ctor)
+			}
+		}
+		if len(added) == 0 {
+			delete(s.addedConstructors, key)
+		}
+	}
+	var gained []string
+	for name, ctors := range current {
+		known, existed := old[name]
+		if !existed {
+			continue // no match relies on the constructors of a new type
+		}
+		for ctor := range ctors {
+			if known[ctor] {
+				continue
+			}
+			key := declaredType{uri, name}
+			if s.addedConstructors[key] == nil {
+				s.addedConstructors[key] = make(map[string]bool)
+			}
+			s.addedConstructors[key][ctor] = true
+			gained = append(gained, name)
+		}
+	}
+	sort.Strings(gained)
+	return slices.Compact(gained)
+}
+
+// newConstructors returns the constructors added by an edit to the data type a
+// match of the document at uri depends on, sorted, which the match does not
+// handle. The type is the one the document declares under that name, or if it
+// declares none, any the other documents declare.
+func (s *Server) newConstructors(uri string, doc *analyzer.Result, match checker.Match) []string {
+	var missing []string
+	for key, added := range s.addedConstructors {
+		if key.name != match.DataType || key.uri != uri && dataTypes(doc)[match.DataType] != nil {
+			continue
+		}
+		for ctor := range added {
+			if !match.Handles(ctor) {
+				missing = append(missing, ctor)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return slices.Compact(missing)
+}
+
+// newConstructorDiagnostics warns at each match of a document leaving out a
+// constructor an edit added to its data type. It returns the locations of the
+// matches warned about, where the checker's own warning that the clauses are
+// not exhaustive is left out as the same news.
+func (s *Server) newConstructorDiagnostics(uri string, doc *analyzer.Result) ([]Diagnostic, map[ast.Location]bool) {
+	var published []Diagnostic
+	warned := make(map[ast.Location]bool)
+	for _, match := range doc.Matches {
+		for _, ctor := range s.newConstructors(uri, doc, match) {
+			message := fmt.Sprintf("new constructor %s not handled: the clauses of %s match %s without it", ctor, match.Function, match.DataType)
+			published = append(published, analysisDiagnostic(match.Location, diagnostics.Warning, diagnostics.NonExhaustiveClauses, message))
+			warned[match.Location] = true
+		}
+	}
+	return published, warned
+}
+
+// republishMatches publishes again the diagnostics of the open documents other
+// than the one at uri with a match on one of the data types it gained a
+// constructor for, and declaring no type of that name themselves
+func (s *Server) republishMatches(uri string, gained []string) error {
+	affected := make(map[string]bool, len(gained))
+	for _, name := range gained {
+		affected[name] = true
+	}
+	uris := make([]string, 0, len(s.documents.open))
+	for other := range s.documents.open {
+		uris = append(uris, other)
+	}
+	sort.Strings(uris)
+	for _, other := range uris {
+		doc := s.documents.open[other]
+		if other == uri {
+			continue
+		}
+		declared := dataTypes(doc)
+		for _, match := range doc.Matches {
+			if affected[match.DataType] && declared[match.DataType] == nil {
+				if err := s.publishDiagnostics(other, doc); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// shapeVersions checks, by their text, documents declaring or matching
+// data Shape = Circle | Square, the + versions adding Triangle:
+//
+//	shapes    the type, and def describe: (Shape) -> Int = (Circle) => 1 | (Square) => 2
+//	shapes+   the type with Triangle, and describe
+//	area      def area: (Shape) -> Int = (Circle) => 1 | (Square) => 2, Shape declared elsewhere
+//	area+     area with a (Triangle) => 3 clause
+//	own       its own Shape and def own_area matching Circle and Square
+func shapeVersions(source []byte) (*analyzer.Result, error) {
+	intType := types.PrimitiveType{Name: types.Int}
+	shapeType := types.UnresolvedType{Name: "Shape"}
+	matchOn := func(name string, ctors ...string) *ast.FunctionDefStmt {
+		fn := &ast.FunctionDefStmt{Name: name, NameLocation: at(2, 5, len(name)),
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: shapeType}}, ReturnType: intType}}
+		for i, ctor := range ctors {
+			fn.Clauses = append(fn.Clauses, &ast.FunctionClause{
+				Parameters: []ast.Pattern{&ast.ConstructorPattern{Name: ctor}},
+				Body:       &ast.IntegerLiteralExpr{Value: int64(i + 1)},
+			})
+		}
+		return fn
+	}
+	table := symbols.NewSymbolTable()
+	var statements []ast.AstNode
+	declare := func(ctors ...string) {
+		shape := &ast.TypeDeclStmt{Name: "Shape", Type: types.DataType{Name: "Shape", Constructors: map[string]types.DataTypeConstructor{}}}
+		for i, ctor := range ctors {
+			shape.Type.(types.DataType).Constructors[ctor] = types.DataTypeConstructor{Name: ctor}
+			table.RegisterConstructor(&ast.DataConstructorDecl{Name: ctor, NameLocation: at(1, 14+9*i, len(ctor)), DataType: "Shape",
+				Signature: &types.FunctionType{ReturnType: shapeType}})
+		}
+		table.RegisterType(shape)
+		statements = append(statements, shape)
+	}
+	text := string(source)
+	switch strings.TrimSuffix(text, "+") {
+	case "shapes":
+		if text == "shapes+" {
+			declare("Circle", "Square", "Triangle")
+		} else {
+			declare("Circle", "Square")
+		}
+		statements = append(statements, matchOn("describe", "Circle", "Square"))
+	case "area":
+		if text == "area+" {
+			statements = append(statements, matchOn("area", "Circle", "Square", "Triangle"))
+		} else {
+			statements = append(statements, matchOn("area", "Circle", "Square"))
+		}
+	case "own":
+		declare("Circle", "Square")
+		statements = append(statements, matchOn("own_area", "Circle", "Square"))
+	}
+	for _, stmt := range statements {
+		if fn, ok := stmt.(*ast.FunctionDefStmt); ok {
+			table.RegisterFunction(fn)
+		}
+	}
+	program := &ast.Program{Statements: statements}
+	check := checker.NewChecker(program, table)
+	result := &analyzer.Result{Source: source, Program: program, Table: table}
+	for _, err := range check.Check() {
+		result.Errors = append(result.Errors, err)
+	}
+	result.Matches = check.Matches()
+	return result, nil
+}
+
+func TestServer_NewConstructorsFlagMatches(t *testing.T) {
+	document := func(name string) string { return "file:///" + name + ".lyra" }
+	open := func(name, text string) map[string]any {
+		return notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: document(name), Text: text}})
+	}
+	change := func(name, text string) map[string]any {
+		return notify("textDocument/didChange", DidChangeTextDocumentParams{TextDocument: VersionedTextDocumentIdentifier{URI: document(name)},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: text}}})
+	}
+	symbols := func(id int, name string) map[string]any {
+		return call(id, "textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: document(name)}})
+	}
+	_, notifications := exchange(t, shapeVersions,
+		call(1, "initialize", map[string]any{}),
+		open("shapes", "shapes"),
+		open("area", "area"),
+		open("own", "own"),
+		change("shapes", "shapes+"),
+		symbols(2, "shapes"), // settles the edit
+		change("area", "area+"),
+		symbols(3, "area"),
+		notify("exit", nil),
+	)
+
+	var published []PublishDiagnosticsParams
+	for _, raw := range notifications["textDocument/publishDiagnostics"] {
+		var params PublishDiagnosticsParams
+		if err := json.Unmarshal(raw, &params); err != nil {
+			t.Fatalf("invalid publishDiagnostics params: %v", err)
+		}
+		published = append(published, params)
+	}
+	// opening the three documents, the edit of shapes, area republished for
+	// it, the edit of area; own declares its own Shape and is left alone
+	var uris []string
+	for _, params := range published {
+		uris = append(uris, strings.TrimPrefix(params.URI, "file:///"))
+	}
+	if strings.Join(uris, " ") != "shapes.lyra area.lyra own.lyra shapes.lyra area.lyra area.lyra" {
+		t.Fatalf("Expected shapes and then area published again after the edit of shapes. Got %v", uris)
+	}
+	flagged := func(params PublishDiagnosticsParams) []string {
+		var messages []string
+		for _, diagnostic := range params.Diagnostics {
+			if diagnostic.Code == "LYR0039" {
+				messages = append(messages, diagnostic.Message)
+			}
+		}
+		return messages
+	}
+	if messages := flagged(published[3]); len(messages) != 1 || !strings.HasPrefix(messages[0], "new constructor Triangle not handled: the clauses of describe") {
+		t.Fatalf("Expected describe flagged for Triangle in place of the checker's warning. Got %v", messages)
+	}
+	if messages := flagged(published[4]); len(messages) != 1 || !strings.HasPrefix(messages[0], "new constructor Triangle not handled: the clauses of area") {
+		t.Fatalf("Expected area, in another document, flagged for Triangle. Got %v", messages)
+	}
+	if messages := flagged(published[5]); len(messages) != 0 {
+		t.Fatalf("Expected area to be clear once it handles Triangle. Got %v", messages)
+	}
+}
@@ -0,0 +1,84 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/Lyra-Language/lyra/pkg/coverage"
+)
+
+// uncovered answers lyra/uncovered: the clauses and ifs of an open document that
+// an lcov report (lyra test -coverprofile) records as not or only partly run,
+// for clients to decorate
+func (s *Server) uncovered(params json.RawMessage) (any, error) {
+	var p UncoveredParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	path, err := uriPath(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := os.Open(p.Profile)
+	if err != nil {
+		return nil, err
+	}
+	defer report.Close()
+	files, err := coverage.ParseLCOV(report)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Profile, err)
+	}
+
+	ranges := make([]UncoveredRange, 0)
+	file, ok := files[path]
+	if !ok {
+		return ranges, nil
+	}
+	lines := map[coverage.SiteKind]map[int]bool{
+		coverage.SiteClause: set(file.Lines),
+		coverage.SiteBranch: set(file.Branches),
+	}
+	seen := make(map[coverage.Site]bool)
+	for _, site := range coverage.NewProfile(doc.Program).Sites {
+		// both branches of an if share its location
+		key := coverage.Site{Kind: site.Kind, Location: site.Location}
+		if !lines[site.Kind][site.Location.StartLine] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		kind := "clause"
+		if site.Kind == coverage.SiteBranch {
+			kind = "branch"
+		}
+		ranges = append(ranges, UncoveredRange{Range: toRange(site.Location), Kind: kind})
+	}
+	return ranges, nil
+}
+
+func set(lines []int) map[int]bool {
+	result := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		result[line] = true
+	}
+	return result
+}
+
+// uriPath converts a file:// URI to the absolute path lcov reports use
+func uriPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("not a file URI: %s", uri)
+	}
+	return filepath.FromSlash(u.Path), nil
+}
@@ -0,0 +1,48 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+const branchSource = `def sign: (Int) -> Int = {
+    (n) if n < 0 => -1,
+    (n) => if n > 0 then 1 else 0,
+}
+`
+
+func TestServer_Uncovered(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "sign.lyra")
+	profile := filepath.Join(dir, "lcov.info")
+	report := "TN:\nSF:" + source + "\nBRDA:3,0,0,0\nBRDA:3,0,1,4\nDA:2,0\nDA:3,4\nend_of_record\n"
+	if err := os.WriteFile(profile, []byte(report), 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	uri := "file://" + filepath.ToSlash(source)
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(uri, branchSource),
+		call(2, "lyra/uncovered", UncoveredParams{TextDocument: TextDocumentIdentifier{URI: uri}, Profile: profile}),
+		notify("exit", nil),
+	)
+
+	var ranges []UncoveredRange
+	if err := json.Unmarshal(responses[2], &ranges); err != nil {
+		t.Fatalf("invalid uncovered result: %v", err)
+	}
+	if len(ranges) != 2 {
+		t.Fatalf("Expected the first clause and the if. Got %+v", ranges)
+	}
+	if ranges[0].Kind != "clause" || ranges[0].Range.Start != (Position{Line: 1, Character: 4}) {
+		t.Fatalf("Expected the clause at 1:4. Got %+v", ranges[0])
+	}
+	if ranges[1].Kind != "branch" || ranges[1].Range.Start != (Position{Line: 2, Character: 11}) {
+		t.Fatalf("Expected the if at 2:11. Got %+v", ranges[1])
+	}
+}
@@ -0,0 +1,43 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// safeDeleteCommand deletes the function or variable at a position if nothing
+// refers to it; its one argument is a TextDocumentPositionParams
+const safeDeleteCommand = "lyra.safeDelete"
+
+func (s *Server) safeDelete(arguments []json.RawMessage) (any, error) {
+	if len(arguments) != 1 {
+		return nil, fmt.Errorf("%s expects a document position", safeDeleteCommand)
+	}
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(arguments[0], &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.Index.ReferenceAt(fromPosition(p.Position))
+	if !ok {
+		return nil, fmt.Errorf("no symbol at %d:%d", p.Position.Line+1, p.Position.Character+1)
+	}
+
+	edits, blocking, err := refactor.SafeDelete(doc.Source, doc.Program, doc.Index, ref.Target)
+	if err != nil {
+		return nil, err
+	}
+	result := SafeDeleteResult{Blocking: make([]Location, len(blocking))}
+	for i, r := range blocking {
+		result.Blocking[i] = Location{URI: p.TextDocument.URI, Range: toRange(r.Location)}
+	}
+	if len(blocking) == 0 {
+		result.Edit = workspaceEdit(p.TextDocument.URI, edits)
+	}
+	return result, nil
+}
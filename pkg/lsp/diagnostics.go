@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/collector"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/ownership"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// publishDiagnostics sends the collector, checker and ownership errors of a
+// document, warnings for its matches missing constructors just added to their
+// data types, and its lint warnings if the settings ask for them, replacing
+// those the client shows for it. The settings decide their severities and how
+// many are sent.
+func (s *Server) publishDiagnostics(uri string, doc *analyzer.Result) error {
+	published, warned := s.newConstructorDiagnostics(uri, doc)
+	for _, err := range doc.Errors {
+		var typeErr checker.TypeError
+		if errors.As(err, &typeErr) && typeErr.Code == diagnostics.NonExhaustiveClauses && warned[typeErr.Location] {
+			continue
+		}
+		published = append(published, toDiagnostic(uri, err))
+	}
+	if s.reporting.lint {
+		published = append(published, s.lintDiagnostics(doc)...)
+	}
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: s.reporting.filter(published)})
+}
+
+// toDiagnostic converts an analysis error with its code and severity. A type
+// mismatch relates the expected and actual types, a use after move the move, an
+// initialization cycle its references. An error of no known kind, like a parse
+// failure, is put at the document's start.
+func toDiagnostic(uri string, err error) Diagnostic {
+	var (
+		typeErr    checker.TypeError
+		collectErr collector.Error
+		moveErr    ownership.MoveError
+	)
+	switch {
+	case errors.As(err, &typeErr):
+		diagnostic := analysisDiagnostic(typeErr.Location, typeErr.Severity, typeErr.Code, typeErr.Message)
+		if typeErr.Expected != nil {
+			diagnostic.related(uri, typeErr.Location, "expected "+typeErr.Expected.GetName())
+		}
+		if typeErr.Actual != nil {
+			diagnostic.related(uri, typeErr.Location, "found "+typeErr.Actual.GetName())
+		}
+		for _, related := range typeErr.Related {
+			diagnostic.related(uri, related.Location, related.Message)
+		}
+		return diagnostic
+	case errors.As(err, &collectErr):
+		return analysisDiagnostic(collectErr.Location, diagnostics.Error, collectErr.Code, collectErr.Message)
+	case errors.As(err, &moveErr):
+		diagnostic := analysisDiagnostic(moveErr.Used, diagnostics.Error, diagnostics.UseAfterMove, "use of moved value "+moveErr.Name)
+		diagnostic.related(uri, moveErr.Moved, fmt.Sprintf("%s moved here", moveErr.Name))
+		return diagnostic
+	}
+	return Diagnostic{Severity: int(diagnostics.Error), Source: "lyra", Message: err.Error()}
+}
+
+func analysisDiagnostic(loc ast.Location, severity diagnostics.Severity, code diagnostics.Code, message string) Diagnostic {
+	diagnostic := Diagnostic{Severity: int(severity), Code: string(code), Source: "lyra", Message: message}
+	if loc.StartLine > 0 {
+		diagnostic.Range = toRange(loc)
+	}
+	return diagnostic
+}
+
+func (d *Diagnostic) related(uri string, loc ast.Location, message string) {
+	d.RelatedInformation = append(d.RelatedInformation, DiagnosticRelatedInformation{
+		Location: Location{URI: uri, Range: toRange(loc)},
+		Message:  message,
+	})
+}
@@ -0,0 +1,116 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+// diagnosedSource has an error of each kind: a type mismatch, a comparison with
+// NaN, a name declared twice and a use after move
+const diagnosedSource = "def consume: (own [Int]) -> Int = (xs) => 0\n" +
+	"var count: Int = \"one\"\n" +
+	"let same: Bool = 1.5 == NaN\n" +
+	"let count: Int = 2\n" +
+	"let xs: [Int] = []\n" +
+	"let a: Int = consume(xs)\n" +
+	"let b: Int = consume(xs)\n"
+
+// diagnosed analyzes documents with analyzer.Analyze but fails for "broken", as
+// a parse failure would
+func diagnosed(source []byte) (*analyzer.Result, error) {
+	if string(source) == "broken" {
+		return nil, errors.New("syntax error")
+	}
+	return analyzer.Analyze(source)
+}
+
+func TestServer_PublishesDiagnostics(t *testing.T) {
+	_, notifications := exchange(t, diagnosed,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, diagnosedSource),
+		notify("textDocument/didChange", DidChangeTextDocumentParams{TextDocument: VersionedTextDocumentIdentifier{URI: testURI}, ContentChanges: []TextDocumentContentChangeEvent{{Text: "broken"}}}),
+		notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}}),
+		notify("exit", nil),
+	)
+
+	published := notifications["textDocument/publishDiagnostics"]
+	if len(published) != 3 {
+		t.Fatalf("Expected diagnostics published on open, change and close. Got %d", len(published))
+	}
+	var opened, broken, closed PublishDiagnosticsParams
+	for i, params := range []*PublishDiagnosticsParams{&opened, &broken, &closed} {
+		if err := json.Unmarshal(published[i], params); err != nil {
+			t.Fatalf("invalid publishDiagnostics params: %v", err)
+		}
+	}
+
+	if len(opened.Diagnostics) != 4 {
+		t.Fatalf("Expected 4 diagnostics. Got %+v", opened.Diagnostics)
+	}
+	byCode := make(map[string]Diagnostic)
+	for _, diagnostic := range opened.Diagnostics {
+		byCode[diagnostic.Code] = diagnostic
+	}
+	mismatch, nan, duplicate, moved := byCode["LYR0003"], byCode["LYR0028"], byCode["LYR0030"], byCode["LYR0018"]
+	if mismatch.Code != "LYR0003" || mismatch.Severity != 1 || len(mismatch.RelatedInformation) != 2 ||
+		mismatch.RelatedInformation[0].Message != "expected Int" || mismatch.RelatedInformation[1].Message != "found String" {
+		t.Fatalf("Expected a mismatch relating Int and String. Got %+v", mismatch)
+	}
+	if nan.Severity != 2 || nan.Code != "LYR0028" {
+		t.Fatalf("Expected a warning. Got %+v", nan)
+	}
+	if duplicate.Code != "LYR0030" || duplicate.Range.Start != (Position{Line: 3, Character: 4}) {
+		t.Fatalf("Expected the duplicate declaration at its name. Got %+v", duplicate)
+	}
+	if moved.Code != "LYR0018" || len(moved.RelatedInformation) != 1 || moved.RelatedInformation[0].Location.Range.Start != (Position{Line: 5, Character: 21}) {
+		t.Fatalf("Expected the use after move to relate the move. Got %+v", moved)
+	}
+
+	if len(broken.Diagnostics) != 1 || broken.Diagnostics[0].Message != "syntax error" {
+		t.Fatalf("Expected the analysis failure published. Got %+v", broken.Diagnostics)
+	}
+	if closed.URI != testURI || len(closed.Diagnostics) != 0 {
+		t.Fatalf("Expected the diagnostics cleared on close. Got %+v", closed)
+	}
+}
+
+func TestServer_SettingsShapeDiagnostics(t *testing.T) {
+	limit := 2
+	var settings, invalid DidChangeConfigurationParams
+	settings.Settings.Lyra = Settings{
+		Strictness:     "strict",
+		Severity:       map[string]string{"LYR0003": "warning", "LYR0030": "off"},
+		MaxDiagnostics: &limit,
+	}
+	invalid.Settings.Lyra = Settings{Strictness: "lenient", Severity: map[string]string{"LYR9999": "off"}}
+	_, notifications := exchange(t, diagnosed,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, diagnosedSource),
+		notify("workspace/didChangeConfiguration", settings),
+		notify("workspace/didChangeConfiguration", invalid),
+		notify("exit", nil),
+	)
+
+	published := notifications["textDocument/publishDiagnostics"]
+	if len(published) != 2 {
+		t.Fatalf("Expected diagnostics published on open and again for the valid settings. Got %d", len(published))
+	}
+	var republished PublishDiagnosticsParams
+	if err := json.Unmarshal(published[1], &republished); err != nil {
+		t.Fatalf("invalid publishDiagnostics params: %v", err)
+	}
+	// the mismatch is now a warning, the NaN warning an error and the duplicate
+	// hidden; of the three left, the two errors are kept
+	var shown []string
+	for _, d := range republished.Diagnostics {
+		shown = append(shown, fmt.Sprintf("%s:%d", d.Code, d.Severity))
+	}
+	if strings.Join(shown, " ") != "LYR0028:1 LYR0018:1" {
+		t.Fatalf("Expected the NaN comparison and the use after move as errors. Got %v", shown)
+	}
+}
@@ -0,0 +1,83 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+)
+
+// explainCommand shows the explanation of a diagnostic code; clients run it from
+// the code action offered on every diagnostic that has a code
+const explainCommand = "lyra.explain"
+
+func (s *Server) codeAction(params json.RawMessage) (any, error) {
+	var p CodeActionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	actions := make([]CodeAction, 0)
+	for _, diagnostic := range p.Context.Diagnostics {
+		explanation, err := diagnostics.Explain(diagnostics.Code(diagnostic.Code))
+		if err != nil {
+			continue
+		}
+		actions = append(actions, CodeAction{
+			Title:       fmt.Sprintf("Explain %s: %s", explanation.Code, explanation.Title),
+			Diagnostics: []Diagnostic{diagnostic},
+			Command: &Command{
+				Title:     "Explain " + string(explanation.Code),
+				Command:   explainCommand,
+				Arguments: []any{explanation.Code},
+			},
+		})
+	}
+	if doc, err := s.document(p.TextDocument.URI); err == nil {
+		actions = append(actions, s.lintFixes(p.TextDocument.URI, doc, p.Range)...)
+		actions = append(actions, s.reorderActions(p.TextDocument.URI, doc, p.Range)...)
+		actions = append(actions, s.clauseActions(p.TextDocument.URI, doc, p.Range)...)
+		actions = append(actions, s.typeFixes(p.TextDocument.URI, doc, p.Range)...)
+		actions = append(actions, s.aliasActions(p.TextDocument.URI, doc, p.Range)...)
+	}
+	return actions, nil
+}
+
+// executeCommand runs lyra.explain, lyra.safeDelete, lyra.canonicalizeAnnotations
+// or lyra.markPure
+func (s *Server) executeCommand(params json.RawMessage) (any, error) {
+	var p ExecuteCommandParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	switch p.Command {
+	case explainCommand:
+		return s.explain(p.Arguments)
+	case safeDeleteCommand:
+		return s.safeDelete(p.Arguments)
+	case canonicalAnnotationsCommand:
+		return s.canonicalizeAnnotations()
+	case markPureCommand:
+		return s.markPure(p.Arguments)
+	}
+	return nil, fmt.Errorf("unknown command %s", p.Command)
+}
+
+// explain returns the explanation of a diagnostic code with a markdown rendering
+func (s *Server) explain(arguments []json.RawMessage) (any, error) {
+	if len(arguments) != 1 {
+		return nil, fmt.Errorf("%s expects a diagnostic code", explainCommand)
+	}
+	var code diagnostics.Code
+	if err := json.Unmarshal(arguments[0], &code); err != nil {
+		return nil, err
+	}
+	explanation, err := diagnostics.Explain(code)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		diagnostics.Explanation
+		Markdown string `json:"markdown"`
+	}{explanation, explanation.Markdown()}, nil
+}
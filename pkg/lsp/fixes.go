@@ -0,0 +1,279 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// typeFixes offers quick fixes for the analysis of a document within rng: a
+// literal of the wrong type is rewritten as the type expected of it, an argument
+// gets the modifier marker its parameter declares, a function a pure one calls
+// is declared pure if it can be, a filled hole or a declaration without an
+// annotation gets the type written out, a function gets clauses for the
+// constructors its clauses do not match, and an unused local is prefixed with `_`
+func (s *Server) typeFixes(uri string, doc *analyzer.Result, rng Range) []CodeAction {
+	var actions []CodeAction
+	fix := func(title, kind string, err error, edits []refactor.TextEdit) {
+		action := CodeAction{Title: title, Kind: kind, Edit: workspaceEdit(uri, edits)}
+		if err != nil {
+			action.Diagnostics = []Diagnostic{toDiagnostic(uri, err)}
+		}
+		actions = append(actions, action)
+	}
+
+	for _, err := range doc.Errors {
+		var typeErr checker.TypeError
+		if !errors.As(err, &typeErr) || !overlaps(toRange(typeErr.Location), rng) {
+			continue
+		}
+		switch typeErr.Code {
+		case diagnostics.TypeMismatch, diagnostics.ArgumentType:
+			expected, ok := typeErr.Expected.(types.PrimitiveType)
+			expr := expressionAt(doc.Program, typeErr.Location.StartLine, typeErr.Location.StartCol)
+			if !ok || expr == nil || expr.GetLocation() != typeErr.Location {
+				continue
+			}
+			if text, ok := literalAs(expr, expected.Name); ok {
+				fix(fmt.Sprintf("Change %s to %s", expr.GetName(), text), "quickfix", err,
+					[]refactor.TextEdit{{Location: typeErr.Location, NewText: text}})
+			}
+		case diagnostics.ArgumentModifier:
+			if title, edit, ok := markArgument(doc, typeErr.Location); ok {
+				fix(title, "quickfix", err, []refactor.TextEdit{edit})
+			}
+		case diagnostics.PurityViolation:
+			if title, edit, ok := markCalleePure(doc, typeErr.Location); ok {
+				fix(title, "quickfix", err, []refactor.TextEdit{edit})
+			}
+		case diagnostics.TypedHole:
+			if typeErr.Severity != diagnostics.Information {
+				continue
+			}
+			if title, edit, ok := fillHole(doc.Program, typeErr.Location); ok {
+				fix(title, "quickfix", err, []refactor.TextEdit{edit})
+			}
+		}
+	}
+
+	for _, stmt := range doc.Program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			if s.Type != nil || s.Value == nil || s.Value.GetType() == nil || !overlaps(toRange(s.NameLocation), rng) {
+				continue
+			}
+			annotation := s.Value.GetType().GetName()
+			fix(fmt.Sprintf("Annotate %s as %s", s.Name, annotation), "quickfix", nil, []refactor.TextEdit{{
+				Location: ast.Location{StartLine: s.NameLocation.EndLine, StartCol: s.NameLocation.EndCol, EndLine: s.NameLocation.EndLine, EndCol: s.NameLocation.EndCol},
+				NewText:  ": " + annotation,
+			}})
+		case *ast.FunctionDefStmt:
+			if !overlaps(toRange(s.Location), rng) {
+				continue
+			}
+			if names, clauses := missingClauses(doc, s); len(clauses) > 0 {
+				if edits, err := refactor.AddClauses(doc.Source, s, clauses); err == nil {
+					fix(fmt.Sprintf("Add clauses of %s for %s", s.Name, strings.Join(names, ", ")), "quickfix", nil, edits)
+				}
+			}
+		}
+	}
+
+	if doc.Index == nil {
+		return actions
+	}
+	for _, ref := range doc.Index.All() {
+		if ref.Kind != refs.Definition || ref.Target.Kind != refs.TargetLocal || strings.HasPrefix(ref.Target.Name, "_") ||
+			!overlaps(toRange(ref.Location), rng) || len(doc.Index.References(ref.Target, refs.Read, refs.Write, refs.Call)) > 0 {
+			continue
+		}
+		if edits, err := refactor.RenameSymbol(doc.Table, doc.Index, ref.Target, "_"+ref.Target.Name); err == nil {
+			fix(fmt.Sprintf("Prefix unused %s with _", ref.Target.Name), "quickfix", nil, edits)
+		}
+	}
+	return actions
+}
+
+// markArgument fixes the marker of the argument at loc to the modifier of its
+// parameter: inserted before an unmarked argument, replaced, or removed along
+// with the space after it when the parameter has none
+func markArgument(doc *analyzer.Result, loc ast.Location) (string, refactor.TextEdit, bool) {
+	var call *ast.CallExpr
+	index := -1
+	for _, root := range expressionRoots(doc.Program) {
+		walkExpressions(root, func(e ast.Expression) {
+			if c, ok := e.(*ast.CallExpr); ok {
+				for i, argument := range c.Arguments {
+					if argument.GetLocation() == loc {
+						call, index = c, i
+					}
+				}
+			}
+		})
+	}
+	if call == nil {
+		return "", refactor.TextEdit{}, false
+	}
+	var parameters []types.ParameterType
+	switch fn := call.Callee.GetType().(type) {
+	case *types.FunctionType:
+		parameters = fn.ParameterTypes
+	case types.FunctionType:
+		parameters = fn.ParameterTypes
+	}
+	if index >= len(parameters) {
+		return "", refactor.TextEdit{}, false
+	}
+	name := call.Arguments[index].GetName()
+	marker, modifier := call.Modifier(index), parameters[index].Modifier
+	if marker == "" {
+		return fmt.Sprintf("Mark %s as %s", name, modifier), refactor.TextEdit{
+			Location: ast.Location{StartLine: loc.StartLine, StartCol: loc.StartCol, EndLine: loc.StartLine, EndCol: loc.StartCol},
+			NewText:  string(modifier) + " ",
+		}, true
+	}
+
+	// the marker precedes the argument on its line, separated by spaces
+	line := doc.Source[offsetOf(doc.Source, loc.StartLine, 1):offsetOf(doc.Source, loc.StartLine, loc.StartCol)]
+	spaced := strings.TrimRight(string(line), " \t")
+	if !strings.HasSuffix(spaced, string(marker)) || len(spaced) == len(line) {
+		return "", refactor.TextEdit{}, false
+	}
+	start := len(spaced) - len(marker) + 1
+	if modifier == "" {
+		return fmt.Sprintf("Remove the %s marker of %s", marker, name), refactor.TextEdit{
+			Location: ast.Location{StartLine: loc.StartLine, StartCol: start, EndLine: loc.StartLine, EndCol: loc.StartCol},
+		}, true
+	}
+	return fmt.Sprintf("Mark %s as %s", name, modifier), refactor.TextEdit{
+		Location: ast.Location{StartLine: loc.StartLine, StartCol: start, EndLine: loc.StartLine, EndCol: len(spaced) + 1},
+		NewText:  string(modifier),
+	}, true
+}
+
+// literalAs rewrites a literal as a literal of type name, if it has one that
+// means the same: 1 as 1.0, 2.0 as 2, 3 as "3" or "4" as 4
+func literalAs(expr ast.Expression, name types.PrimitiveTypeName) (string, bool) {
+	isFloat := name == types.Float || name == types.Float16 || name == types.Float32 || name == types.Float64
+	isInteger := !isFloat && types.PrimitiveType{Name: name}.IsNumericType()
+	switch e := expr.(type) {
+	case *ast.IntegerLiteralExpr:
+		switch {
+		case isFloat && e.Text == "":
+			return fmt.Sprintf("%d.0", e.Value), true
+		case name == types.String:
+			return strconv.Quote(e.GetName()), true
+		}
+	case *ast.FloatLiteralExpr:
+		switch {
+		case isInteger && e.Value == math.Trunc(e.Value) && math.Abs(e.Value) < math.MaxInt64:
+			return strconv.FormatInt(int64(e.Value), 10), true
+		case name == types.String:
+			return strconv.Quote(e.GetName()), true
+		}
+	case *ast.BooleanLiteralExpr:
+		if name == types.String {
+			return strconv.Quote(e.GetName()), true
+		}
+	case *ast.StringLiteralExpr:
+		switch {
+		case isInteger:
+			if value, err := ast.ParseInteger(e.Value); err == nil {
+				return strconv.FormatInt(value, 10), true
+			}
+		case isFloat:
+			value, err := strconv.ParseFloat(strings.ReplaceAll(e.Value, "_", ""), 64)
+			if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+				return "", false
+			}
+			text := strconv.FormatFloat(value, 'g', -1, 64)
+			if !strings.ContainsAny(text, ".e") {
+				text += ".0"
+			}
+			return text, true
+		case name == types.Bool:
+			if e.Value == "true" || e.Value == "false" {
+				return e.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// fillHole writes out the type the checker filled a hole reported at loc with:
+// the annotation of a declaration or the signature of a function
+func fillHole(program *ast.Program, loc ast.Location) (string, refactor.TextEdit, bool) {
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			if s.NameLocation == loc && s.TypeLocation != (ast.Location{}) && s.Type != nil && !isHoleType(s.Type) {
+				return fmt.Sprintf("Annotate %s as %s", s.Name, s.Type.GetName()),
+					refactor.TextEdit{Location: s.TypeLocation, NewText: s.Type.GetName()}, true
+			}
+		case *ast.FunctionDefStmt:
+			if s.NameLocation == loc && s.SignatureLocation != (ast.Location{}) && s.Signature != nil && !isHoleType(s.Signature.ReturnType) {
+				return fmt.Sprintf("Write the return type of %s: %s", s.Name, s.Signature.ReturnType.GetName()),
+					refactor.TextEdit{Location: s.SignatureLocation, NewText: s.Signature.GetName()}, true
+			}
+		}
+	}
+	return "", refactor.TextEdit{}, false
+}
+
+func isHoleType(t types.Type) bool {
+	_, ok := t.(types.HoleType)
+	return ok
+}
+
+// missingClauses returns the constructors of a data type parameter of fn that
+// no clause matches, with a clause for each: `(Node { left, right }, _) => ???`.
+// Patterns only destructure by field, so a constructor without fields is matched
+// with `Empty {}`. Nothing is missing once a clause binds the parameter to a
+// name without a guard.
+func missingClauses(doc *analyzer.Result, fn *ast.FunctionDefStmt) ([]string, []string) {
+	if fn.Signature == nil {
+		return nil, nil
+	}
+	for i, param := range fn.Signature.ParameterTypes {
+		var patterns []ast.Pattern
+		matched := false
+		for _, clause := range fn.Clauses {
+			if i >= len(clause.Parameters) || clause.Guard != nil {
+				continue
+			}
+			patterns = append(patterns, clause.Parameters[i])
+			_, isStruct := clause.Parameters[i].(*ast.StructPattern)
+			matched = matched || isStruct
+		}
+		coverage, ok := checker.CoverPatterns(doc.Table, param.Type, patterns)
+		if !ok || !matched || len(coverage.Constructors) == 0 {
+			continue
+		}
+		var names, clauses []string
+		for _, ctor := range coverage.Constructors {
+			pattern := armPattern(ctor)
+			if len(ctor.Fields) == 0 {
+				pattern = ctor.Name + " {}"
+			}
+			parameters := make([]string, len(fn.Signature.ParameterTypes))
+			for j := range parameters {
+				parameters[j] = "_"
+			}
+			parameters[i] = pattern
+			names = append(names, ctor.Name)
+			clauses = append(clauses, fmt.Sprintf("(%s) => ???", strings.Join(parameters, ", ")))
+		}
+		return names, clauses
+	}
+	return nil, nil
+}
@@ -0,0 +1,104 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+const shapeDeclaration = "data Shape = Circle { radius: Float } | Empty\n"
+
+// fixesSource is a program where 1 is not a Float, Empty has no clause of name
+// and radius is never read
+const fixesSource = shapeDeclaration + "let ratio: Float = 1\ndef name: (Shape) -> String = {\n\t(Circle { radius }) => \"circle\",\n}\n"
+
+func TestServer_TypeQuickFixes(t *testing.T) {
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, fixesSource),
+		call(2, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 4, Character: 1}},
+		}),
+		notify("exit", nil),
+	)
+
+	actions, applied := appliedFixes(t, fixesSource, responses[2])
+
+	expected := map[string]string{
+		"Change 1 to 1.0":               shapeDeclaration + "let ratio: Float = 1.0\ndef name: (Shape) -> String = {\n\t(Circle { radius }) => \"circle\",\n}\n",
+		"Add clauses of name for Empty": shapeDeclaration + "let ratio: Float = 1\ndef name: (Shape) -> String = {\n\t(Circle { radius }) => \"circle\",\n\t(Empty {}) => ???,\n}\n",
+		"Prefix unused radius with _":   shapeDeclaration + "let ratio: Float = 1\ndef name: (Shape) -> String = {\n\t(Circle { radius: _radius }) => \"circle\",\n}\n",
+	}
+	for title, source := range expected {
+		if applied[title] != source {
+			t.Fatalf("Expected %q to give:\n%s\nGot:\n%s\n(actions: %v)", title, source, applied[title], actions)
+		}
+	}
+}
+
+// appliedFixes decodes a codeAction response and applies each action to source,
+// by title
+func appliedFixes(t *testing.T, source string, response json.RawMessage) ([]CodeAction, map[string]string) {
+	t.Helper()
+	var actions []CodeAction
+	if err := json.Unmarshal(response, &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	applied := make(map[string]string)
+	for _, action := range actions {
+		if action.Edit == nil {
+			continue
+		}
+		var edits []refactor.TextEdit
+		for _, edit := range action.Edit.Changes[testURI] {
+			loc := ast.Location{
+				StartLine: edit.Range.Start.Line + 1, StartCol: edit.Range.Start.Character + 1,
+				EndLine: edit.Range.End.Line + 1, EndCol: edit.Range.End.Character + 1,
+			}
+			edits = append(edits, refactor.TextEdit{Location: loc, NewText: edit.NewText})
+		}
+		result, err := refactor.Apply([]byte(source), edits)
+		if err != nil {
+			t.Fatalf("Apply error for %q: %v", action.Title, err)
+		}
+		applied[action.Title] = string(result)
+	}
+	return actions, applied
+}
+
+// markersDeclarations declares push taking a mut array, peek a ref array and
+// size an array with no modifier
+const markersDeclarations = "def push: (mut [Int], Int) -> Int = (xs, x) => x\n" +
+	"def peek: (ref [Int]) -> Int = (xs) => 0\n" +
+	"def size: ([Int]) -> Int = (xs) => 0\n" +
+	"var xs: [Int] = []\nvar ys: [Int] = []\nvar zs: [Int] = []\n"
+
+const markersSource = markersDeclarations + "push(xs, 1)\npeek(mut ys)\nsize(ref  zs)\n"
+
+func TestServer_ArgumentModifierFixes(t *testing.T) {
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, markersSource),
+		call(2, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 9, Character: 0}},
+		}),
+		notify("exit", nil),
+	)
+
+	actions, applied := appliedFixes(t, markersSource, responses[2])
+	expected := map[string]string{
+		"Mark xs as mut":              markersDeclarations + "push(mut xs, 1)\npeek(mut ys)\nsize(ref  zs)\n",
+		"Mark ys as ref":              markersDeclarations + "push(xs, 1)\npeek(ref ys)\nsize(ref  zs)\n",
+		"Remove the ref marker of zs": markersDeclarations + "push(xs, 1)\npeek(mut ys)\nsize(zs)\n",
+	}
+	for title, source := range expected {
+		if applied[title] != source {
+			t.Fatalf("Expected %q to give:\n%s\nGot:\n%s\n(actions: %v)", title, source, applied[title], actions)
+		}
+	}
+}
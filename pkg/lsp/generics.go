@@ -0,0 +1,281 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// inlayHintKinds are the kinds of inlay hints the client wants
+type inlayHintKinds struct {
+	parameterTypes bool
+	typeArguments  bool
+}
+
+// apply turns on or off the kinds options sets
+func (k *inlayHintKinds) apply(options *InlayHintOptions) {
+	if options == nil {
+		return
+	}
+	if options.ParameterTypes != nil {
+		k.parameterTypes = *options.ParameterTypes
+	}
+	if options.TypeArguments != nil {
+		k.typeArguments = *options.TypeArguments
+	}
+}
+
+// inlayHint answers textDocument/inlayHint with the types of the parameters of
+// function clauses, `(n: Int) if n < 2`, and the type arguments of each call to
+// a generic function, `id<Int>(5)`, within range. Each kind can be turned off
+// through initializationOptions.inlayHints or the lyra.inlayHints setting.
+func (s *Server) inlayHint(params json.RawMessage) (any, error) {
+	var p InlayHintParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	hints := make([]InlayHint, 0)
+	if s.inlayHints.parameterTypes {
+		hints = append(hints, parameterTypeHints(doc.Program, p.Range)...)
+	}
+	if !s.inlayHints.typeArguments {
+		return hints, nil
+	}
+	for _, root := range expressionRoots(doc.Program) {
+		walkExpressions(root, func(expr ast.Expression) {
+			call, ok := expr.(*ast.CallExpr)
+			if !ok || !overlaps(toRange(call.Callee.GetLocation()), p.Range) {
+				return
+			}
+			if fn, bound := instantiation(doc, call); len(bound) > 0 {
+				end := toRange(call.Callee.GetLocation()).End
+				hints = append(hints, InlayHint{Position: end, Label: typeArguments(fn, bound), Kind: InlayHintType})
+			}
+		})
+	}
+	return hints, nil
+}
+
+// parameterTypeHints writes the type of each clause parameter bound to a name
+// after it; literals and struct patterns already show what they match
+func parameterTypeHints(program *ast.Program, rng Range) []InlayHint {
+	var hints []InlayHint
+	for _, stmt := range program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || fn.Signature == nil || !overlaps(toRange(fn.Location), rng) {
+			continue
+		}
+		for _, clause := range fn.Clauses {
+			for i, param := range clause.Parameters {
+				ident, ok := param.(*ast.IdentifierPattern)
+				if !ok || i >= len(fn.Signature.ParameterTypes) || ident.Location == (ast.Location{}) || !overlaps(toRange(ident.Location), rng) {
+					continue
+				}
+				hints = append(hints, InlayHint{
+					Position: toRange(ident.Location).End,
+					Label:    ": " + fn.Signature.ParameterTypes[i].Type.GetName(),
+					Kind:     InlayHintType,
+				})
+			}
+		}
+	}
+	return hints
+}
+
+// genericHover describes the generic parameter named at a one-based line and
+// column of a function's signature, "t declared on sum<t>"
+func genericHover(program *ast.Program, line, col int) (*Hover, bool) {
+	for _, stmt := range program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok {
+			continue
+		}
+		for _, name := range fn.TypeNames {
+			if covers(name.Location, line, col) && isGenericParam(fn, name.Name) {
+				nameRange := toRange(name.Location)
+				value := "```lyra\n" + name.Name + "\n```\n" + declaredOn(fn, name.Name)
+				return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &nameRange}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// genericNotes explains the generic parameters in the hover of expr: where the
+// parameters its type mentions are declared and, if expr is the callee of a call
+// to a generic function, what they stand for in that call
+func genericNotes(doc *analyzer.Result, expr ast.Expression, line, col int) (string, []string) {
+	text := expr.GetType().GetName()
+	var notes []string
+	if call := callOf(doc.Program, expr); call != nil {
+		if fn, bound := instantiation(doc, call); len(bound) > 0 {
+			text = instantiate(expr.GetType(), bound).GetName()
+			notes = append(notes, formatInstantiation(fn, bound)+" for this call")
+		}
+	}
+	if fn := functionAt(doc.Program, line, col); fn != nil {
+		for _, name := range fn.GenericParams {
+			if mentions(expr.GetType(), name) {
+				notes = append(notes, declaredOn(fn, name))
+			}
+		}
+	}
+	return text, notes
+}
+
+// instantiation returns what the generic parameters of the function call calls
+// stand for: as the checker solved them or, in a call it left unchecked, as
+// unifying the parameters with the types of the arguments solves them
+func instantiation(doc *analyzer.Result, call *ast.CallExpr) (*ast.FunctionDefStmt, map[string]types.Type) {
+	callee, ok := call.Callee.(*ast.IdentifierExpr)
+	if !ok {
+		return nil, nil
+	}
+	fn, ok := doc.Table.Functions[callee.Name]
+	if !ok || fn.Signature == nil || len(fn.GenericParams) == 0 {
+		return nil, nil
+	}
+	if call.TypeArguments != nil {
+		return fn, call.TypeArguments
+	}
+	var vars []types.TypeVar
+	instance := types.Instantiate(*fn.Signature, func(origin string) types.TypeVar {
+		vars = append(vars, types.TypeVar{ID: len(vars) + 1, Origin: origin})
+		return vars[len(vars)-1]
+	}).(types.FunctionType)
+	solved := types.Substitution{}
+	for i, param := range instance.ParameterTypes {
+		if i < len(call.Arguments) && call.Arguments[i] != nil {
+			// an argument that does not fit leaves the parameters to the others
+			_ = types.Unify(param.Type, call.Arguments[i].GetType(), solved)
+		}
+	}
+	bound := make(map[string]types.Type)
+	for _, v := range vars {
+		if t, ok := solved[v.ID]; ok {
+			bound[v.Origin] = types.Generalize(t, solved)
+		}
+	}
+	return fn, bound
+}
+
+// instantiate replaces the generic parameters of t that bound has types for
+func instantiate(t types.Type, bound map[string]types.Type) types.Type {
+	solved, next := types.Substitution{}, 0
+	instance := types.Instantiate(t, func(origin string) types.TypeVar {
+		next++
+		v := types.TypeVar{ID: next, Origin: origin}
+		if concrete, ok := bound[origin]; ok {
+			solved[v.ID] = concrete
+		}
+		return v
+	})
+	return types.Generalize(instance, solved)
+}
+
+// formatInstantiation lists the bound generic parameters of fn in the order
+// they are declared: "t = Int, u = String"
+func formatInstantiation(fn *ast.FunctionDefStmt, bound map[string]types.Type) string {
+	var pairs []string
+	for _, name := range fn.GenericParams {
+		if t, ok := bound[name]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s = %s", name, t.GetName()))
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// typeArguments lists what each generic parameter of fn stands for, in the
+// order they are declared, as the call would write it: "<Int, String>". A
+// parameter the arguments leave open is written `_`.
+func typeArguments(fn *ast.FunctionDefStmt, bound map[string]types.Type) string {
+	arguments := make([]string, len(fn.GenericParams))
+	for i, name := range fn.GenericParams {
+		arguments[i] = "_"
+		if t, ok := bound[name]; ok {
+			arguments[i] = t.GetName()
+		}
+	}
+	return "<" + strings.Join(arguments, ", ") + ">"
+}
+
+func declaredOn(fn *ast.FunctionDefStmt, name string) string {
+	return fmt.Sprintf("%s declared on %s<%s>", name, fn.Name, strings.Join(fn.GenericParams, ", "))
+}
+
+func isGenericParam(fn *ast.FunctionDefStmt, name string) bool {
+	for _, param := range fn.GenericParams {
+		if param == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mentions reports whether t refers to the generic parameter name
+func mentions(t types.Type, name string) bool {
+	switch t := t.(type) {
+	case types.GenericType:
+		return t.Name == name
+	case types.ArrayType:
+		return mentions(t.ElementType, name)
+	case types.TupleType:
+		for _, element := range t.Elements {
+			if mentions(element, name) {
+				return true
+			}
+		}
+	case *types.FunctionType:
+		return t != nil && mentions(*t, name)
+	case types.FunctionType:
+		for _, parameter := range t.ParameterTypes {
+			if mentions(parameter.Type, name) {
+				return true
+			}
+		}
+		return mentions(t.ReturnType, name)
+	}
+	return false
+}
+
+// functionAt returns the function defined around a one-based line and column
+func functionAt(program *ast.Program, line, col int) *ast.FunctionDefStmt {
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*ast.FunctionDefStmt); ok && covers(fn.Location, line, col) {
+			return fn
+		}
+	}
+	return nil
+}
+
+// callOf returns the call whose callee is expr, nil if expr is not a callee
+func callOf(program *ast.Program, expr ast.Expression) *ast.CallExpr {
+	var found *ast.CallExpr
+	for _, root := range expressionRoots(program) {
+		walkExpressions(root, func(e ast.Expression) {
+			if call, ok := e.(*ast.CallExpr); ok && call.Callee == expr {
+				found = call
+			}
+		})
+	}
+	return found
+}
+
+// walkExpressions calls visit with expr and each expression within it
+func walkExpressions(expr ast.Expression, visit func(ast.Expression)) {
+	if expr == nil {
+		return
+	}
+	visit(expr)
+	for _, child := range subexpressions(expr) {
+		walkExpressions(child, visit)
+	}
+}
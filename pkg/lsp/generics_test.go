@@ -0,0 +1,134 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+const identitySource = "def id<t>: (t) -> t = (x) => x\nlet n: Int = id(1)\n"
+
+// uncheckedCalls analyzes source as if the checker had left its calls unchecked,
+// without the type arguments it solves
+func uncheckedCalls(source []byte) (*analyzer.Result, error) {
+	result, err := analyzer.Analyze(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range expressionRoots(result.Program) {
+		walkExpressions(root, func(expr ast.Expression) {
+			if call, ok := expr.(*ast.CallExpr); ok {
+				call.TypeArguments = nil
+			}
+		})
+	}
+	return result, nil
+}
+
+func TestServer_GenericHoverAndInlayHints(t *testing.T) {
+	position := func(line, character int) TextDocumentPositionParams {
+		return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: line, Character: character}}
+	}
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: identitySource}}),
+		call(2, "textDocument/hover", position(0, 12)), // (|t)
+		call(3, "textDocument/hover", position(0, 29)), // => |x
+		call(4, "textDocument/hover", position(1, 14)), // i|d(1)
+		call(5, "textDocument/inlayHint", InlayHintParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 2, Character: 0}},
+		}),
+		notify("exit", nil),
+	)
+
+	for id, expected := range map[int]string{
+		2: "```lyra\nt\n```\nt declared on id<t>",
+		3: "```lyra\nx: t\n```\nt declared on id<t>",
+		4: "```lyra\nid: (Int) -> Int\n```\nt = Int for this call\n\ninferred: pure",
+	} {
+		var hover Hover
+		if err := json.Unmarshal(responses[id], &hover); err != nil {
+			t.Fatalf("invalid hover result: %v", err)
+		}
+		if hover.Contents.Value != expected {
+			t.Fatalf("Expected hover %q for request %d. Got %q", expected, id, hover.Contents.Value)
+		}
+	}
+
+	var hints []InlayHint
+	if err := json.Unmarshal(responses[5], &hints); err != nil {
+		t.Fatalf("invalid inlay hint result: %v", err)
+	}
+	expected := []InlayHint{
+		{Position: Position{Line: 0, Character: 24}, Label: ": t", Kind: InlayHintType},
+		{Position: Position{Line: 1, Character: 15}, Label: "<Int>", Kind: InlayHintType},
+	}
+	if len(hints) != len(expected) || hints[0] != expected[0] || hints[1] != expected[1] {
+		t.Fatalf("Expected %+v. Got %+v", expected, hints)
+	}
+}
+
+func TestServer_InlayHintKindsToggle(t *testing.T) {
+	off := false
+	everything := InlayHintParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Range:        Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 2, Character: 0}},
+	}
+	var settings DidChangeConfigurationParams
+	settings.Settings.Lyra.InlayHints = &InlayHintOptions{TypeArguments: &off}
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", InitializeParams{InitializationOptions: &InitializationOptions{InlayHints: &InlayHintOptions{ParameterTypes: &off}}}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: identitySource}}),
+		call(2, "textDocument/inlayHint", everything),
+		notify("workspace/didChangeConfiguration", settings),
+		call(3, "textDocument/inlayHint", everything),
+		notify("exit", nil),
+	)
+
+	for id, expected := range map[int]string{2: "<Int>", 3: ""} {
+		var hints []InlayHint
+		if err := json.Unmarshal(responses[id], &hints); err != nil {
+			t.Fatalf("invalid inlay hint result: %v", err)
+		}
+		labels := ""
+		for _, hint := range hints {
+			labels += hint.Label
+		}
+		if labels != expected {
+			t.Fatalf("Expected hints %q for request %d. Got %+v", expected, id, hints)
+		}
+	}
+}
+
+func TestServer_UncheckedCallInstantiation(t *testing.T) {
+	source := "def first<a, b>: (a, b) -> a = (x, y) => x\nlet n: Int = first(1, \"one\")\n"
+	responses := sessionWith(t, uncheckedCalls,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: source}}),
+		call(2, "textDocument/hover", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: 1, Character: 14}}),
+		call(3, "textDocument/inlayHint", InlayHintParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 1, Character: 0}, End: Position{Line: 2, Character: 0}},
+		}),
+		notify("exit", nil),
+	)
+
+	var hover Hover
+	if err := json.Unmarshal(responses[2], &hover); err != nil {
+		t.Fatalf("invalid hover result: %v", err)
+	}
+	if expected := "```lyra\nfirst: (Int, String) -> Int\n```\na = Int, b = String for this call"; !strings.HasPrefix(hover.Contents.Value, expected) {
+		t.Fatalf("Expected the parameters solved from the arguments. Got %q", hover.Contents.Value)
+	}
+	var hints []InlayHint
+	if err := json.Unmarshal(responses[3], &hints); err != nil {
+		t.Fatalf("invalid inlay hint result: %v", err)
+	}
+	if len(hints) != 1 || hints[0].Label != "<Int, String>" {
+		t.Fatalf("Expected the type arguments unified from the arguments. Got %+v", hints)
+	}
+}
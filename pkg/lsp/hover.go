@@ -0,0 +1,157 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// hover answers textDocument/hover with the checked type of the innermost
+// expression at a position, wherever it is: in a body, a guard, an argument or
+// the default value of a struct field. Null if the expression has no type.
+// Generic parameters are explained: where a parameter the type mentions is
+// declared and, on the callee of a call, what each stands for in that call. A
+// generic parameter in a signature is shown with the function declaring it. The
+// name of a function and the callee of a call show whether the function is
+// pure, declared or inferred.
+func (s *Server) hover(params json.RawMessage) (any, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line, col := fromPosition(p.Position)
+	expr := expressionAt(doc.Program, line, col)
+	if expr == nil {
+		if hover, ok := functionHover(doc.Program, line, col); ok {
+			return hover, nil
+		}
+		if hover, ok := genericHover(doc.Program, line, col); ok {
+			return hover, nil
+		}
+		return nil, nil
+	}
+	if expr.GetType() == nil {
+		return nil, nil
+	}
+	text, notes := genericNotes(doc, expr, line, col)
+	if call := callOf(doc.Program, expr); call != nil {
+		if fn, ok := doc.Table.Functions[call.Callee.GetName()]; ok {
+			notes = append(notes, purityNote(fn, inferPurity(doc.Program)))
+		}
+	}
+	if ident, ok := expr.(*ast.IdentifierExpr); ok {
+		text = ident.Name + ": " + text
+	}
+	value := "```lyra\n" + text + "\n```"
+	if len(notes) > 0 {
+		value += "\n" + strings.Join(notes, "\n\n")
+	}
+	exprRange := toRange(expr.GetLocation())
+	return Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &exprRange}, nil
+}
+
+// expressionAt returns the innermost expression of program at a one-based line
+// and column, nil if there is none
+func expressionAt(program *ast.Program, line, col int) ast.Expression {
+	for _, root := range expressionRoots(program) {
+		if expr := innermost(root, line, col); expr != nil {
+			return expr
+		}
+	}
+	return nil
+}
+
+// expressionRoots returns the outermost expressions of program: the values of
+// declarations and assignments, clause guards and bodies and field defaults
+func expressionRoots(program *ast.Program) []ast.Expression {
+	var roots []ast.Expression
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			roots = append(roots, s.Value)
+		case *ast.VarAssignStmt:
+			roots = append(roots, s.Value)
+		case *ast.ExpressionStmt:
+			roots = append(roots, s.Expression)
+		case *ast.ReturnStmt:
+			roots = append(roots, s.Value)
+		case *ast.FunctionDefStmt:
+			for _, clause := range s.Clauses {
+				if clause.Guard != nil {
+					roots = append(roots, clause.Guard.Condition)
+				}
+				roots = append(roots, clause.Body)
+			}
+		case *ast.TypeDeclStmt:
+			if structType, ok := s.Type.(types.StructType); ok {
+				for _, field := range structType.Fields {
+					if value, ok := field.DefaultValue.(ast.Expression); ok {
+						roots = append(roots, value)
+					}
+				}
+			}
+		}
+	}
+	return roots
+}
+
+func innermost(expr ast.Expression, line, col int) ast.Expression {
+	if expr == nil || !covers(expr.GetLocation(), line, col) {
+		return nil
+	}
+	for _, child := range subexpressions(expr) {
+		if inner := innermost(child, line, col); inner != nil {
+			return inner
+		}
+	}
+	return expr
+}
+
+// subexpressions returns the direct subexpressions of expr
+func subexpressions(expr ast.Expression) []ast.Expression {
+	var children []ast.Expression
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		children = append([]ast.Expression{e.Callee}, e.Arguments...)
+	case *ast.BinaryOpExpr:
+		children = []ast.Expression{e.Left, e.Right}
+	case *ast.BooleanBinaryOpExpr:
+		children = []ast.Expression{e.Left, e.Right}
+	case *ast.GuardExpr:
+		children = []ast.Expression{e.Condition}
+	case *ast.IfThenExpr:
+		children = []ast.Expression{e.Condition, e.Then, e.Else}
+	case *ast.IfBlockExpr:
+		children = []ast.Expression{e.Condition, e.Then, e.Else}
+	case *ast.MemberAccessExpr:
+		children = []ast.Expression{e.Object}
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			children = append(children, field.Value)
+		}
+	case *ast.ArrayLiteralExpr:
+		children = e.Elements
+	case *ast.TupleLiteralExpr:
+		children = e.Elements
+	case *ast.LambdaExpr:
+		children = []ast.Expression{e.Body}
+	}
+	return children
+}
+
+// covers reports whether loc contains a one-based line and column
+func covers(loc ast.Location, line, col int) bool {
+	if loc.StartLine == 0 || line < loc.StartLine || line > loc.EndLine {
+		return false
+	}
+	if line == loc.StartLine && col < loc.StartCol {
+		return false
+	}
+	return line != loc.EndLine || col < loc.EndCol
+}
@@ -0,0 +1,48 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+const guardSource = "let limit: Int = 3\n" +
+	"struct Box { size: Int = limit }\n" +
+	"def big: (Int) -> Bool = (n) if n > limit => true\n"
+
+func TestServer_HoverInGuardsAndDefaults(t *testing.T) {
+	position := func(line, character int) TextDocumentPositionParams {
+		return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: line, Character: character}}
+	}
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, guardSource),
+		call(2, "textDocument/hover", position(2, 32)),                        // if |n > limit
+		call(3, "textDocument/hover", position(1, 27)),                        // size: Int = l|imit
+		call(4, "textDocument/hover", position(0, 0)),                         // |let
+		call(5, "textDocument/completion", CompletionParams{position(2, 36)}), // n > |limit
+		notify("exit", nil),
+	)
+
+	for id, expected := range map[int]string{2: "```lyra\nn: Int\n```", 3: "```lyra\nlimit: Int\n```"} {
+		var hover Hover
+		if err := json.Unmarshal(responses[id], &hover); err != nil {
+			t.Fatalf("invalid hover result: %v", err)
+		}
+		if hover.Contents.Value != expected {
+			t.Fatalf("Expected hover %q for request %d. Got %q", expected, id, hover.Contents.Value)
+		}
+	}
+	if string(responses[4]) != "null" {
+		t.Fatalf("Expected no hover outside expressions. Got %s", responses[4])
+	}
+
+	var list CompletionList
+	if err := json.Unmarshal(responses[5], &list); err != nil {
+		t.Fatalf("invalid completion result: %v", err)
+	}
+	if len(list.Items) == 0 || list.Items[0].Label != "limit" || !list.Items[0].Preselect {
+		t.Fatalf("Expected the Int limit preselected in the guard. Got %+v", list.Items)
+	}
+}
@@ -0,0 +1,37 @@
+package lsp
+
+import (
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/lint"
+)
+
+// lintFixes returns a quick fix for each fixable lint warning overlapping rng
+func (s *Server) lintFixes(uri string, doc *analyzer.Result, rng Range) []CodeAction {
+	var actions []CodeAction
+	for _, d := range lint.Run(doc, s.lintConfig()) {
+		if d.Fix == nil || !overlaps(toRange(d.Location), rng) {
+			continue
+		}
+		actions = append(actions, CodeAction{
+			Title: d.Fix.Title,
+			Kind:  "quickfix",
+			Diagnostics: []Diagnostic{{
+				Range:    toRange(d.Location),
+				Severity: int(d.Severity),
+				Code:     string(d.Code),
+				Source:   "lyra lint",
+				Message:  d.Message,
+			}},
+			Edit: workspaceEdit(uri, d.Fix.Edits),
+		})
+	}
+	return actions
+}
+
+func overlaps(a, b Range) bool {
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+func before(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
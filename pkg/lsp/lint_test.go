@@ -0,0 +1,44 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+const camelSource = "var maxCount: Int = 0\nmaxCount = maxCount\n"
+
+func TestServer_LintQuickFix(t *testing.T) {
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, camelSource),
+		call(2, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 0, Character: 6}, End: Position{Line: 0, Character: 6}},
+		}),
+		call(3, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 1, Character: 0}, End: Position{Line: 1, Character: 0}},
+		}),
+		notify("exit", nil),
+	)
+
+	var actions []CodeAction
+	if err := json.Unmarshal(responses[2], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Title != "Rename maxCount to max_count" || actions[0].Edit == nil {
+		t.Fatalf("Expected a rename quick fix. Got %+v", actions)
+	}
+	if edits := actions[0].Edit.Changes[testURI]; len(edits) != 3 || edits[0].NewText != "max_count" {
+		t.Fatalf("Expected the declaration, write and read renamed. Got %+v", edits)
+	}
+
+	if err := json.Unmarshal(responses[3], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("Expected no fix away from the declaration. Got %+v", actions)
+	}
+}
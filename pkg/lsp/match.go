@@ -0,0 +1,96 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// matchOpening is a line ending in `match x {`, just typed
+var matchOpening = regexp.MustCompile(`^(\s*).*\bmatch\s+([a-z_][A-Za-z0-9_]*)\s*\{$`)
+
+// onTypeFormatting answers textDocument/onTypeFormatting: typing the "{" of
+// `match x {` for x of a data type fills in one arm per constructor, each with
+// a ??? hole for its body
+func (s *Server) onTypeFormatting(params json.RawMessage) (any, error) {
+	var p DocumentOnTypeFormattingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	edits := make([]TextEdit, 0)
+	if p.Ch != "{" {
+		return edits, nil
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(doc.Source), "\n")
+	if p.Position.Line >= len(lines) {
+		return edits, nil
+	}
+	line := lines[p.Position.Line]
+	if p.Position.Character > len(line) {
+		return edits, nil
+	}
+	opening := matchOpening.FindStringSubmatch(line[:p.Position.Character])
+	if opening == nil {
+		return edits, nil
+	}
+	indent, name := opening[1], opening[2]
+
+	row, col := fromPosition(p.Position)
+	expectation := checker.ExpectedAt(doc.Program, doc.Table, row, col)
+	t, ok := expectation.Locals[name]
+	if !ok {
+		if named, found := doc.Table.GlobalScope.Lookup(name); found {
+			if decl, isVar := named.(*ast.VarDeclStmt); isVar {
+				t = decl.Type
+			}
+		}
+	}
+	ctors, ok := checker.MissingConstructors(doc.Table, t, nil)
+	if !ok || len(ctors) == 0 {
+		return edits, nil
+	}
+
+	var b strings.Builder
+	for _, ctor := range ctors {
+		fmt.Fprintf(&b, "\n%s\t%s => ???,", indent, armPattern(ctor))
+	}
+	b.WriteString("\n" + indent)
+	if !strings.HasPrefix(strings.TrimSpace(line[p.Position.Character:]), "}") {
+		b.WriteString("}") // the editor did not close the brace
+	}
+	at := Range{Start: p.Position, End: p.Position}
+	return append(edits, TextEdit{Range: at, NewText: b.String()}), nil
+}
+
+// armPattern is the pattern of a match arm binding every part of a constructor:
+// Empty, Circle(value), Pair(a, b), Node { left, right }
+func armPattern(ctor types.DataTypeConstructor) string {
+	switch {
+	case len(ctor.Fields) > 0:
+		names := make([]string, 0, len(ctor.Fields))
+		for name := range ctor.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("%s { %s }", ctor.Name, strings.Join(names, ", "))
+	case len(ctor.Params) == 1:
+		return ctor.Name + "(value)"
+	case len(ctor.Params) > 1:
+		names := make([]string, len(ctor.Params))
+		for i := range names {
+			names[i] = string(rune('a' + i))
+		}
+		return fmt.Sprintf("%s(%s)", ctor.Name, strings.Join(names, ", "))
+	}
+	return ctor.Name
+}
@@ -0,0 +1,64 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestServer_MatchScaffolding(t *testing.T) {
+	// the text being typed does not parse yet, so the document keeps the analysis
+	// of shapesSource, the clause of scale now spanning `match s {` on line 4
+	shapes := analyzed(t, shapesSource)
+	shapes.Table.Functions["scale"].Clauses[0].Location = at(4, 38, 30)
+	typing := func(source []byte) (*analyzer.Result, error) {
+		result := *shapes
+		result.Source = source
+		return &result, nil
+	}
+	const source = "data Shape = Circle(Float) | Empty\n" +
+		"def area: (Shape) -> Float = (s) => 0.0\n" +
+		"let unit: Float = 1.0\n" +
+		"def scale: (Float, Shape) -> Float = (k, s) => match s {}\n" +
+		"    match k {"
+	typed := func(line, character int) DocumentOnTypeFormattingParams {
+		return DocumentOnTypeFormattingParams{
+			TextDocumentPositionParams: TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: line, Character: character}},
+			Ch:                         "{",
+		}
+	}
+	responses := sessionWith(t, typing,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: source}}),
+		call(2, "textDocument/onTypeFormatting", typed(3, 56)),
+		call(3, "textDocument/onTypeFormatting", typed(4, 13)),
+		notify("exit", nil),
+	)
+
+	var edits []TextEdit
+	if err := json.Unmarshal(responses[2], &edits); err != nil {
+		t.Fatalf("invalid onTypeFormatting result: %v", err)
+	}
+	expected := "\n\tCircle(value) => ???,\n\tEmpty => ???,\n"
+	if len(edits) != 1 || edits[0].NewText != expected || edits[0].Range.Start != (Position{Line: 3, Character: 56}) {
+		t.Fatalf("Expected the arms of Shape before the closing brace. Got %+v", edits)
+	}
+	if err := json.Unmarshal(responses[3], &edits); err != nil || len(edits) != 0 {
+		t.Fatalf("Expected no arms for k, which is not in scope. Got %+v, %v", edits, err)
+	}
+}
+
+func TestArmPattern(t *testing.T) {
+	intType := types.PrimitiveType{Name: types.Int}
+	for expected, ctor := range map[string]types.DataTypeConstructor{
+		"Nil":                  {Name: "Nil"},
+		"Pair(a, b)":           {Name: "Pair", Params: []types.Type{intType, intType}},
+		"Node { left, right }": {Name: "Node", Fields: map[string]types.StructField{"right": {Type: intType}, "left": {Type: intType}}},
+	} {
+		if got := armPattern(ctor); got != expected {
+			t.Fatalf("Expected %s. Got %s", expected, got)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package lsp
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// documentSymbol answers textDocument/documentSymbol with the outline of a
+// document: its types, with the fields of structs and the constructors of data
+// types as children, its trait implementations with their methods, its
+// functions and its top-level variables
+func (s *Server) documentSymbol(params json.RawMessage) (any, error) {
+	var p DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	outline := make([]DocumentSymbol, 0, len(doc.Program.Statements))
+	for _, stmt := range doc.Program.Statements {
+		switch s := stmt.(type) {
+		case *ast.TypeDeclStmt:
+			outline = append(outline, typeSymbol(s, doc.Table))
+		case *ast.FunctionDefStmt:
+			outline = append(outline, functionSymbol(s, symbols.SymbolFunction))
+		case *ast.ImplStmt:
+			symbol := outlineSymbol(s.GetName(), symbolKinds[symbols.KindOf(s)], s.Location, s.TypeLocation)
+			for _, method := range s.Methods {
+				symbol.Children = append(symbol.Children, functionSymbol(method, symbols.SymbolMethod))
+			}
+			outline = append(outline, symbol)
+		case *ast.VarDeclStmt:
+			symbol := outlineSymbol(s.Name, symbolKinds[symbols.KindOf(s)], s.Location, s.NameLocation)
+			if s.Type != nil {
+				symbol.Detail = s.Type.GetName()
+			}
+			outline = append(outline, symbol)
+		}
+	}
+	return outline, nil
+}
+
+// functionSymbol is the outline entry of a function or method, detailed with
+// its signature
+func functionSymbol(fn *ast.FunctionDefStmt, kind symbols.SymbolKind) DocumentSymbol {
+	symbol := outlineSymbol(fn.Name, symbolKinds[kind], fn.Location, fn.NameLocation)
+	if fn.Signature != nil {
+		symbol.Detail = fn.Signature.GetName()
+	}
+	return symbol
+}
+
+func typeSymbol(decl *ast.TypeDeclStmt, table *symbols.SymbolTable) DocumentSymbol {
+	symbol := outlineSymbol(decl.Name, symbolKinds[symbols.KindOf(decl)], decl.Location, decl.NameLocation)
+	switch t := decl.Type.(type) {
+	case types.StructType:
+		for name, loc := range decl.FieldLocations {
+			field := outlineSymbol(name, SymbolField, loc, loc)
+			if fieldType := t.Fields[name].Type; fieldType != nil {
+				field.Detail = fieldType.GetName()
+			}
+			symbol.Children = append(symbol.Children, field)
+		}
+	case types.DataType:
+		for _, ctors := range table.Constructors {
+			for _, ctor := range ctors {
+				if ctor.DataType != decl.Name {
+					continue
+				}
+				member := outlineSymbol(ctor.Name, symbolKinds[symbols.KindOf(ctor)], ctor.Location, ctor.Location)
+				if ctor.Signature != nil && len(ctor.Signature.ParameterTypes) > 0 {
+					member.Detail = ctor.Signature.GetName()
+				}
+				symbol.Children = append(symbol.Children, member)
+			}
+		}
+	}
+	sort.Slice(symbol.Children, func(i, j int) bool {
+		a, b := symbol.Children[i].SelectionRange.Start, symbol.Children[j].SelectionRange.Start
+		if a != b {
+			return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+		}
+		return symbol.Children[i].Name < symbol.Children[j].Name
+	})
+	return symbol
+}
+
+// symbolKinds maps the kinds of Lyra declarations to LSP symbol kinds
+var symbolKinds = map[symbols.SymbolKind]SymbolKind{
+	symbols.SymbolFunction:      SymbolFunction,
+	symbols.SymbolStruct:        SymbolStruct,
+	symbols.SymbolData:          SymbolEnum,
+	symbols.SymbolConstructor:   SymbolEnumMember,
+	symbols.SymbolTrait:         SymbolInterface,
+	symbols.SymbolMethod:        SymbolMethod,
+	symbols.SymbolVariable:      SymbolVariable,
+	symbols.SymbolConstant:      SymbolConstant,
+	symbols.SymbolTypeParameter: SymbolTypeParameter,
+	symbols.SymbolAlias:         SymbolTypeParameter,
+}
+
+// outlineSymbol is a symbol spanning loc, selecting its name at nameLoc, or all
+// of loc when the name's location is not known
+func outlineSymbol(name string, kind SymbolKind, loc, nameLoc ast.Location) DocumentSymbol {
+	if nameLoc == (ast.Location{}) {
+		nameLoc = loc
+	}
+	return DocumentSymbol{Name: name, Kind: kind, Range: toRange(loc), SelectionRange: toRange(nameLoc)}
+}
+
+// workspaceSymbol answers workspace/symbol with the top-level types, functions
+// and variables of the open documents whose names contain the query, ignoring
+// case, by document then in source order. Functions defined inside others are
+// only listed if the nestedSymbols setting asks for them.
+func (s *Server) workspaceSymbol(params json.RawMessage) (any, error) {
+	var p WorkspaceSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	s.settleAll()
+	uris := make([]string, 0, len(s.documents.open))
+	for uri := range s.documents.open {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	query := strings.ToLower(p.Query)
+	found := make([]SymbolInformation, 0)
+	add := func(uri, name string, kind symbols.SymbolKind, loc ast.Location, container string) {
+		if strings.Contains(strings.ToLower(name), query) {
+			found = append(found, SymbolInformation{Name: name, Kind: symbolKinds[kind], Location: Location{URI: uri, Range: toRange(loc)}, ContainerName: container})
+		}
+	}
+	var addNested func(uri string, fn *ast.FunctionDefStmt)
+	addNested = func(uri string, fn *ast.FunctionDefStmt) {
+		for _, clause := range fn.Clauses {
+			for _, nested := range clause.Functions {
+				add(uri, nested.Name, symbols.SymbolFunction, nested.NameLocation, fn.Name)
+				addNested(uri, nested)
+			}
+		}
+	}
+	for _, uri := range uris {
+		for _, stmt := range s.documents.open[uri].Program.Statements {
+			switch decl := stmt.(type) {
+			case *ast.TypeDeclStmt:
+				add(uri, decl.Name, symbols.KindOf(decl), decl.NameLocation, "")
+			case *ast.FunctionDefStmt:
+				add(uri, decl.Name, symbols.KindOf(decl), decl.NameLocation, "")
+				if s.nested {
+					addNested(uri, decl)
+				}
+			case *ast.ImplStmt:
+				for _, method := range decl.Methods {
+					add(uri, method.Name, symbols.SymbolMethod, method.NameLocation, decl.GetName())
+				}
+			case *ast.VarDeclStmt:
+				add(uri, decl.Name, symbols.KindOf(decl), decl.NameLocation, "")
+			}
+		}
+	}
+	return found, nil
+}
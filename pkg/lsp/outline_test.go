@@ -0,0 +1,115 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const outlineSource = "struct Point { y: Int, x: Int }\n" +
+	"data Shape = Circle(Int) | Dot\n" +
+	"const origin: Point = Point { y: 0, x: 0 }\n" +
+	"def area: (Shape) -> Int = (s) => 0\n"
+
+func TestServer_DocumentSymbol(t *testing.T) {
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, outlineSource),
+		call(2, "textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: testURI}}),
+		notify("exit", nil),
+	)
+
+	var outline []DocumentSymbol
+	if err := json.Unmarshal(responses[2], &outline); err != nil {
+		t.Fatalf("invalid documentSymbol result: %v", err)
+	}
+	describe := func(symbols []DocumentSymbol) []string {
+		var result []string
+		for _, symbol := range symbols {
+			result = append(result, symbol.Name+":"+symbol.Detail)
+		}
+		return result
+	}
+	expected := []string{"Point:", "Shape:", "origin:Point", "area:(Shape) -> Int"}
+	if got := describe(outline); len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] || got[2] != expected[2] || got[3] != expected[3] {
+		t.Fatalf("Expected the outline %v. Got %v", expected, got)
+	}
+	if outline[2].Kind != SymbolConstant || outline[2].SelectionRange.Start != (Position{Line: 2, Character: 6}) {
+		t.Fatalf("Expected the constant origin selected by its name. Got %+v", outline[2])
+	}
+	if fields := describe(outline[0].Children); len(fields) != 2 || fields[0] != "y:Int" || fields[1] != "x:Int" {
+		t.Fatalf("Expected the fields of Point in declaration order. Got %v", fields)
+	}
+	if ctors := describe(outline[1].Children); len(ctors) != 2 || ctors[0] != "Circle:(Int) -> Shape" || ctors[1] != "Dot:" {
+		t.Fatalf("Expected the constructors of Shape. Got %v", ctors)
+	}
+}
+
+func TestServer_WorkspaceSymbol(t *testing.T) {
+	// outlineSource, with area defining radius
+	nestedSource := strings.Replace(outlineSource, "(s) => 0\n", "(s) => {\n    def radius: (Int) -> Int = (r) => r\n    radius(0)\n}\n", 1)
+	nested := true
+	var settings DidChangeConfigurationParams
+	settings.Settings.Lyra.NestedSymbols = &nested
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, nestedSource),
+		call(2, "workspace/symbol", WorkspaceSymbolParams{Query: "A"}),
+		notify("workspace/didChangeConfiguration", settings),
+		call(3, "workspace/symbol", WorkspaceSymbolParams{Query: "A"}),
+		notify("exit", nil),
+	)
+
+	for id, expected := range map[int]string{2: "Shape area", 3: "Shape area radius(area)"} {
+		var found []SymbolInformation
+		if err := json.Unmarshal(responses[id], &found); err != nil {
+			t.Fatalf("invalid workspace/symbol result: %v", err)
+		}
+		var names []string
+		for _, symbol := range found {
+			if symbol.ContainerName != "" {
+				symbol.Name += "(" + symbol.ContainerName + ")"
+			}
+			names = append(names, symbol.Name)
+		}
+		if got := strings.Join(names, " "); got != expected {
+			t.Fatalf("Expected the symbols %q for request %d. Got %q", expected, id, got)
+		}
+	}
+}
+
+func TestServer_DocumentSymbolImpl(t *testing.T) {
+	// impl Show for Point { def show: (Point) -> String = (p) => "point" }
+	implResult := func(source []byte) (*analyzer.Result, error) {
+		point := types.UnresolvedType{Name: "Point"}
+		show := &ast.FunctionDefStmt{AstBase: ast.AstBase{Location: at(1, 23, 44)}, Name: "show", NameLocation: at(1, 27, 4),
+			Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: point}}, ReturnType: types.PrimitiveType{Name: types.String}}}
+		impl := &ast.ImplStmt{AstBase: ast.AstBase{Location: at(1, 1, 68)}, Trait: "Show", TypeName: "Point", TypeLocation: at(1, 15, 5),
+			Methods: []*ast.FunctionDefStmt{show}}
+		table := symbols.NewSymbolTable()
+		table.RegisterImpl(impl)
+		return &analyzer.Result{Source: source, Program: &ast.Program{Statements: []ast.AstNode{impl}}, Table: table}, nil
+	}
+	responses := sessionWith(t, implResult,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: "..."}}),
+		call(2, "textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: testURI}}),
+		notify("exit", nil),
+	)
+
+	var outline []DocumentSymbol
+	if err := json.Unmarshal(responses[2], &outline); err != nil {
+		t.Fatalf("invalid documentSymbol result: %v", err)
+	}
+	if len(outline) != 1 || outline[0].Name != "Show for Point" || outline[0].Kind != SymbolInterface {
+		t.Fatalf("Expected the implementation of Show. Got %+v", outline)
+	}
+	if methods := outline[0].Children; len(methods) != 1 || methods[0].Name != "show" || methods[0].Kind != SymbolMethod || methods[0].Detail != "(Point) -> String" {
+		t.Fatalf("Expected the method show. Got %+v", methods)
+	}
+}
@@ -0,0 +1,504 @@
+package lsp
+
+// Subset of the Language Server Protocol types used by the server.
+// Positions are zero-based; Lyra's ast.Location is one-based.
+
+import "encoding/json"
+
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// TextDocumentContentChangeEvent only supports full-document sync
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
+	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context ReferenceContext `json:"context"`
+}
+
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+	// Kinds is a Lyra extension restricting results to some reference kinds
+	// ("definition", "read", "write", "call"), e.g. writes-only to track mutations
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+type DocumentHighlightKind int
+
+const (
+	HighlightText  DocumentHighlightKind = 1
+	HighlightRead  DocumentHighlightKind = 2
+	HighlightWrite DocumentHighlightKind = 3
+)
+
+type DocumentHighlight struct {
+	Range Range                 `json:"range"`
+	Kind  DocumentHighlightKind `json:"kind"`
+}
+
+type Diagnostic struct {
+	Range              Range                          `json:"range"`
+	Severity           int                            `json:"severity,omitempty"`
+	Code               string                         `json:"code,omitempty"`
+	Source             string                         `json:"source,omitempty"`
+	Message            string                         `json:"message"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
+
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+
+type CodeActionContext struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type Command struct {
+	Title     string `json:"title"`
+	Command   string `json:"command"`
+	Arguments []any  `json:"arguments,omitempty"`
+}
+
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []Diagnostic   `json:"diagnostics,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Command     *Command       `json:"command,omitempty"`
+}
+
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+type WorkspaceEdit struct {
+	Changes map[string][]TextEdit `json:"changes"`
+}
+
+type CodeLensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type CodeLens struct {
+	Range   Range    `json:"range"`
+	Command *Command `json:"command,omitempty"`
+}
+
+// CodeLensOptions advertises code lenses; the server resolves them up front
+type CodeLensOptions struct {
+	ResolveProvider bool `json:"resolveProvider"`
+}
+
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+type ExecuteCommandOptions struct {
+	Commands []string `json:"commands"`
+}
+
+// SafeDeleteResult is the result of the lyra.safeDelete command: the edit
+// deleting the symbol, or the references that keep it from being deleted
+type SafeDeleteResult struct {
+	Edit     *WorkspaceEdit `json:"edit,omitempty"`
+	Blocking []Location     `json:"blocking"`
+}
+
+// UncoveredParams are the parameters of the lyra/uncovered extension request
+type UncoveredParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Profile      string                 `json:"profile"` // path of an lcov report
+}
+
+type UncoveredRange struct {
+	Range Range  `json:"range"`
+	Kind  string `json:"kind"` // "clause" or "branch"
+}
+
+type TextDocumentSyncKind int
+
+const (
+	SyncNone TextDocumentSyncKind = 0
+	SyncFull TextDocumentSyncKind = 1
+)
+
+type ServerCapabilities struct {
+	TextDocumentSync                 TextDocumentSyncKind             `json:"textDocumentSync"`
+	HoverProvider                    bool                             `json:"hoverProvider,omitempty"`
+	DefinitionProvider               bool                             `json:"definitionProvider,omitempty"`
+	ReferencesProvider               bool                             `json:"referencesProvider,omitempty"`
+	DocumentHighlightProvider        bool                             `json:"documentHighlightProvider,omitempty"`
+	DocumentSymbolProvider           bool                             `json:"documentSymbolProvider,omitempty"`
+	RenameProvider                   bool                             `json:"renameProvider,omitempty"`
+	CodeActionProvider               bool                             `json:"codeActionProvider,omitempty"`
+	CompletionProvider               *CompletionOptions               `json:"completionProvider,omitempty"`
+	SignatureHelpProvider            *SignatureHelpOptions            `json:"signatureHelpProvider,omitempty"`
+	DocumentOnTypeFormattingProvider *DocumentOnTypeFormattingOptions `json:"documentOnTypeFormattingProvider,omitempty"`
+	InlayHintProvider                bool                             `json:"inlayHintProvider,omitempty"`
+	CodeLensProvider                 *CodeLensOptions                 `json:"codeLensProvider,omitempty"`
+	ExecuteCommandProvider           *ExecuteCommandOptions           `json:"executeCommandProvider,omitempty"`
+	WorkspaceSymbolProvider          bool                             `json:"workspaceSymbolProvider,omitempty"`
+}
+
+type ServerInfo struct {
+	Name string `json:"name"`
+}
+
+type InitializeParams struct {
+	RootURI               string                 `json:"rootUri,omitempty"`
+	Capabilities          ClientCapabilities     `json:"capabilities"`
+	InitializationOptions *InitializationOptions `json:"initializationOptions,omitempty"`
+}
+
+// ClientCapabilities holds the client capabilities the server looks at
+type ClientCapabilities struct {
+	TextDocument struct {
+		Completion struct {
+			CompletionItem struct {
+				SnippetSupport bool `json:"snippetSupport,omitempty"`
+			} `json:"completionItem"`
+		} `json:"completion"`
+	} `json:"textDocument"`
+}
+
+type InitializationOptions struct {
+	// RecentDocuments is how many closed documents keep their full analysis;
+	// older ones keep only their public API until they are needed again
+	RecentDocuments *int `json:"recentDocuments,omitempty"`
+	// AnalysisDelay is how many milliseconds to wait after an edit before
+	// analyzing the document, so a burst of keystrokes is analyzed once
+	AnalysisDelay *int `json:"analysisDelay,omitempty"`
+	// InlayHints turns kinds of inlay hints on or off; all are shown by default
+	InlayHints *InlayHintOptions `json:"inlayHints,omitempty"`
+}
+
+// InlayHintOptions toggles each kind of inlay hint, leaving those unset as they are
+type InlayHintOptions struct {
+	ParameterTypes *bool `json:"parameterTypes,omitempty"` // (n: Int) after clause parameters
+	TypeArguments  *bool `json:"typeArguments,omitempty"`  // id<Int>(5) at calls to generic functions
+}
+
+// DidChangeConfigurationParams carries the settings of the client, the server's
+// under "lyra"
+type DidChangeConfigurationParams struct {
+	Settings struct {
+		Lyra Settings `json:"lyra"`
+	} `json:"settings"`
+}
+
+// Settings are the workspace settings of the server; those left out keep their
+// values
+type Settings struct {
+	InlayHints *InlayHintOptions `json:"inlayHints,omitempty"`
+	// Severity overrides the severity of diagnostics by code: "error",
+	// "warning", "info", "hint", or "off" to hide them
+	Severity map[string]string `json:"severity,omitempty"`
+	Lint     *LintSettings     `json:"lint,omitempty"`
+	// MaxDiagnostics caps the diagnostics published for a document, the most
+	// severe kept; 0 publishes them all
+	MaxDiagnostics *int `json:"maxDiagnostics,omitempty"`
+	// Strictness is how warnings of the analysis are shown: "lenient" as hints,
+	// "standard" as warnings or "strict" as errors
+	Strictness string `json:"strictness,omitempty"`
+	// NestedSymbols lists the functions defined inside others in workspace
+	// symbol search, which leaves them out by default
+	NestedSymbols *bool `json:"nestedSymbols,omitempty"`
+}
+
+// LintSettings publish lint warnings along with the diagnostics of the analysis
+type LintSettings struct {
+	Enabled  *bool    `json:"enabled,omitempty"`
+	Disabled []string `json:"disabled,omitempty"` // rules not to run, besides those lyra-lint.json disables
+}
+
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   ServerInfo         `json:"serverInfo"`
+}
+
+// DocumentViewParams are the parameters of the lyra/typedAst, lyra/callGraph
+// and lyra/traitMatrix extension requests
+type DocumentViewParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// TypedNode is a statement or expression of the lyra/typedAst result
+type TypedNode struct {
+	Kind     string      `json:"kind"` // AST node type, e.g. CallExpr
+	Name     string      `json:"name,omitempty"`
+	Type     string      `json:"type,omitempty"` // checked type; empty when unknown
+	Range    Range       `json:"range"`
+	Children []TypedNode `json:"children,omitempty"`
+}
+
+type CallGraph struct {
+	Nodes []CallGraphNode `json:"nodes"`
+	Edges []CallGraphEdge `json:"edges"`
+}
+
+type CallGraphNode struct {
+	Name   string `json:"name"`
+	Range  Range  `json:"range"`
+	Public bool   `json:"public,omitempty"`
+	Extern bool   `json:"extern,omitempty"`
+}
+
+type CallGraphEdge struct {
+	Caller string  `json:"caller"`
+	Callee string  `json:"callee"`
+	Sites  []Range `json:"sites"`
+}
+
+type TraitMatrix struct {
+	Traits []string         `json:"traits"`
+	Rows   []TraitMatrixRow `json:"rows"`
+}
+
+type TraitMatrixRow struct {
+	Type       string          `json:"type"`
+	Range      Range           `json:"range"`
+	Implements map[string]bool `json:"implements"` // trait -> derived or implemented
+	// Status tells how each trait is implemented: derived, implemented or missing
+	Status map[string]string `json:"status"`
+}
+
+// CheckerTraceParams are the parameters of the lyra/checkerTrace extension request
+type CheckerTraceParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        *Range                 `json:"range,omitempty"` // only trace expressions inside it
+}
+
+// DocumentStoreStats is the result of the lyra/documentStore extension request
+type DocumentStoreStats struct {
+	Capacity       int `json:"capacity"`       // recentDocuments
+	Open           int `json:"open"`           // open documents, analyzed in full
+	Recent         int `json:"recent"`         // closed documents analyzed in full
+	Summarized     int `json:"summarized"`     // closed documents reduced to what other files see
+	SummarySymbols int `json:"summarySymbols"` // visible symbols kept for them
+	Reloads        int `json:"reloads"`        // summarized documents analyzed again
+	Evictions      int `json:"evictions"`
+	MethodLookups  int `json:"methodLookups"`   // of documents held in full, see symbols.MethodCacheStats
+	MethodHits     int `json:"methodCacheHits"` // of those, answered from the cache
+}
+
+// TraceItem is one checker decision (see checker.TraceEntry)
+type TraceItem struct {
+	Depth    int               `json:"depth"`
+	Rule     string            `json:"rule"`
+	Node     string            `json:"node,omitempty"`
+	Range    Range             `json:"range"`
+	Expected string            `json:"expected,omitempty"`
+	Result   string            `json:"result,omitempty"`
+	Bindings map[string]string `json:"bindings,omitempty"`
+	Note     string            `json:"note,omitempty"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"` // "markdown" or "plaintext"
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+type InlayHintParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+// InlayHintKind is the LSP InlayHintKind
+type InlayHintKind int
+
+const (
+	InlayHintType      InlayHintKind = 1
+	InlayHintParameter InlayHintKind = 2
+)
+
+type InlayHint struct {
+	Position Position      `json:"position"`
+	Label    string        `json:"label"`
+	Kind     InlayHintKind `json:"kind,omitempty"`
+}
+
+type SignatureHelp struct {
+	Signatures      []SignatureInformation `json:"signatures"`
+	ActiveSignature int                    `json:"activeSignature"`
+	ActiveParameter int                    `json:"activeParameter"`
+}
+
+type SignatureInformation struct {
+	Label         string                 `json:"label"`
+	Documentation string                 `json:"documentation,omitempty"`
+	Parameters    []ParameterInformation `json:"parameters"`
+}
+
+// ParameterInformation labels a parameter by its start and end offsets in the
+// signature's label
+type ParameterInformation struct {
+	Label [2]int `json:"label"`
+}
+
+type RenameParams struct {
+	TextDocumentPositionParams
+	NewName string `json:"newName"`
+}
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SymbolKind int
+
+const (
+	SymbolMethod        SymbolKind = 6
+	SymbolField         SymbolKind = 8
+	SymbolEnum          SymbolKind = 10 // data types
+	SymbolInterface     SymbolKind = 11 // traits
+	SymbolFunction      SymbolKind = 12
+	SymbolVariable      SymbolKind = 13
+	SymbolConstant      SymbolKind = 14
+	SymbolEnumMember    SymbolKind = 22 // data constructors
+	SymbolStruct        SymbolKind = 23
+	SymbolTypeParameter SymbolKind = 26
+)
+
+// DocumentSymbol is an entry of the outline of a document
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"` // type or signature
+	Kind           SymbolKind       `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"` // the name
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+// SymbolInformation is a symbol found by workspace/symbol
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"` // the function defining a nested function
+}
+
+type CompletionParams struct {
+	TextDocumentPositionParams
+}
+
+type CompletionItemKind int
+
+const (
+	CompletionMethod        CompletionItemKind = 2
+	CompletionFunction      CompletionItemKind = 3
+	CompletionConstructor   CompletionItemKind = 4
+	CompletionField         CompletionItemKind = 5
+	CompletionVariable      CompletionItemKind = 6
+	CompletionInterface     CompletionItemKind = 8  // traits
+	CompletionEnum          CompletionItemKind = 13 // data types
+	CompletionKeyword       CompletionItemKind = 14 // primitive types
+	CompletionConstant      CompletionItemKind = 21
+	CompletionStruct        CompletionItemKind = 22
+	CompletionTypeParameter CompletionItemKind = 25
+)
+
+// InsertTextSnippet marks insert text with ${1:placeholder} tab stops
+const InsertTextSnippet = 2
+
+type CompletionItem struct {
+	Label            string             `json:"label"`
+	Kind             CompletionItemKind `json:"kind,omitempty"`
+	Detail           string             `json:"detail,omitempty"` // type of the completed name
+	SortText         string             `json:"sortText,omitempty"`
+	Preselect        bool               `json:"preselect,omitempty"`
+	InsertText       string             `json:"insertText,omitempty"`
+	InsertTextFormat int                `json:"insertTextFormat,omitempty"`
+}
+
+type CompletionList struct {
+	IsIncomplete bool             `json:"isIncomplete"`
+	Items        []CompletionItem `json:"items"`
+}
+
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+type DocumentOnTypeFormattingParams struct {
+	TextDocumentPositionParams
+	Ch string `json:"ch"` // the character typed
+}
+
+type SignatureHelpOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
+}
+
+type DocumentOnTypeFormattingOptions struct {
+	FirstTriggerCharacter string `json:"firstTriggerCharacter"`
+}
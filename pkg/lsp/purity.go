@@ -0,0 +1,229 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// markPureCommand declares the function at a position pure; its one argument is
+// a TextDocumentPositionParams and its result the WorkspaceEdit to apply
+const markPureCommand = "lyra.markPure"
+
+// purity is what a function is inferred to do besides computing its result
+type purity struct {
+	impure bool
+	reason string // why it is impure: "calls debug at line 12"
+}
+
+func (p purity) String() string {
+	if p.impure {
+		return "inferred: impure (" + p.reason + ")"
+	}
+	return "inferred: pure"
+}
+
+// inferPurity infers the purity of each function of program. A function is
+// impure if it is extern or async, or calls debug() or an impure function;
+// calls through a parameter or a local are assumed pure.
+func inferPurity(program *ast.Program) map[string]purity {
+	functions := make(map[string]*ast.FunctionDefStmt)
+	for _, stmt := range program.Statements {
+		if fn, ok := stmt.(*ast.FunctionDefStmt); ok {
+			functions[fn.Name] = fn
+		}
+	}
+
+	inferred := make(map[string]purity, len(functions))
+	for name, fn := range functions {
+		switch {
+		case fn.IsExtern():
+			inferred[name] = purity{true, "is implemented by the host"}
+		case fn.IsAsync:
+			inferred[name] = purity{true, "is async"}
+		default:
+			inferred[name] = purity{}
+		}
+	}
+	// a function becomes impure through the first impure call found, until no
+	// function changes
+	for changed := true; changed; {
+		changed = false
+		for name, fn := range functions {
+			if inferred[name].impure {
+				continue
+			}
+			for _, call := range calls(fn) {
+				callee := call.Callee.(*ast.IdentifierExpr)
+				_, declared := functions[callee.Name]
+				if (!declared && callee.Name == "debug") || inferred[callee.Name].impure {
+					inferred[name] = purity{true, fmt.Sprintf("calls %s at line %d", callee.Name, call.Location.StartLine)}
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return inferred
+}
+
+// calls returns the calls of the clauses of fn to a function named directly, in
+// source order, those of its nested functions before the body defining them
+func calls(fn *ast.FunctionDefStmt) []*ast.CallExpr {
+	var found []*ast.CallExpr
+	visit := func(expr ast.Expression) {
+		if call, ok := expr.(*ast.CallExpr); ok {
+			if _, named := call.Callee.(*ast.IdentifierExpr); named {
+				found = append(found, call)
+			}
+		}
+	}
+	for _, clause := range fn.Clauses {
+		if clause.Guard != nil {
+			walkExpressions(clause.Guard.Condition, visit)
+		}
+		for _, nested := range clause.Functions {
+			found = append(found, calls(nested)...)
+		}
+		walkExpressions(clause.Body, visit)
+	}
+	return found
+}
+
+// purityNote describes the purity of fn for its hover: declared, or inferred
+// with the call that makes it impure
+func purityNote(fn *ast.FunctionDefStmt, inferred map[string]purity) string {
+	if fn.IsPure {
+		return "declared pure"
+	}
+	return inferred[fn.Name].String()
+}
+
+// functionHover shows the signature and purity of the function whose name is at
+// a one-based line and column
+func functionHover(program *ast.Program, line, col int) (*Hover, bool) {
+	for _, stmt := range program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || !covers(fn.NameLocation, line, col) {
+			continue
+		}
+		text := "def " + fn.Name
+		if fn.Signature != nil {
+			text += ": " + fn.Signature.GetName()
+		}
+		nameRange := toRange(fn.NameLocation)
+		value := "```lyra\n" + text + "\n```\n" + purityNote(fn, inferPurity(program))
+		return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &nameRange}, true
+	}
+	return nil, false
+}
+
+// codeLens answers textDocument/codeLens with "mark as pure" above each function
+// that is not declared pure but is inferred to be
+func (s *Server) codeLens(params json.RawMessage) (any, error) {
+	var p CodeLensParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	inferred := inferPurity(doc.Program)
+	lenses := make([]CodeLens, 0)
+	for _, stmt := range doc.Program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || fn.IsPure || inferred[fn.Name].impure || fn.NameLocation == (ast.Location{}) {
+			continue
+		}
+		position := TextDocumentPositionParams{TextDocument: p.TextDocument, Position: toRange(fn.NameLocation).Start}
+		lenses = append(lenses, CodeLens{
+			Range:   toRange(fn.NameLocation),
+			Command: &Command{Title: "mark as pure", Command: markPureCommand, Arguments: []any{position}},
+		})
+	}
+	return lenses, nil
+}
+
+func (s *Server) markPure(arguments []json.RawMessage) (any, error) {
+	if len(arguments) != 1 {
+		return nil, fmt.Errorf("%s expects a document position", markPureCommand)
+	}
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(arguments[0], &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line, col := fromPosition(p.Position)
+	for _, stmt := range doc.Program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || !covers(fn.NameLocation, line, col) {
+			continue
+		}
+		if fn.IsPure {
+			return nil, fmt.Errorf("%s is already declared pure", fn.Name)
+		}
+		if inferred := inferPurity(doc.Program)[fn.Name]; inferred.impure {
+			return nil, fmt.Errorf("%s cannot be pure: it %s", fn.Name, inferred.reason)
+		}
+		def, ok := defKeyword(doc.Source, fn)
+		if !ok {
+			return nil, fmt.Errorf("cannot find the def of %s", fn.Name)
+		}
+		return workspaceEdit(p.TextDocument.URI, []refactor.TextEdit{{Location: def, NewText: "pure "}}), nil
+	}
+	return nil, fmt.Errorf("no function at %d:%d", line, col)
+}
+
+// markCalleePure fixes the call at loc, which a pure function may not make, by
+// declaring the function it calls pure, when that function is inferred to be
+func markCalleePure(doc *analyzer.Result, loc ast.Location) (string, refactor.TextEdit, bool) {
+	var callee *ast.IdentifierExpr
+	for _, root := range expressionRoots(doc.Program) {
+		walkExpressions(root, func(e ast.Expression) {
+			if call, ok := e.(*ast.CallExpr); ok && call.Location == loc {
+				callee, _ = call.Callee.(*ast.IdentifierExpr)
+			}
+		})
+	}
+	if callee == nil {
+		return "", refactor.TextEdit{}, false
+	}
+	fn, ok := doc.Table.Functions[callee.Name]
+	if !ok || fn.IsPure || inferPurity(doc.Program)[fn.Name].impure {
+		return "", refactor.TextEdit{}, false
+	}
+	def, ok := defKeyword(doc.Source, fn)
+	if !ok {
+		return "", refactor.TextEdit{}, false
+	}
+	return fmt.Sprintf("Mark %s as pure", fn.Name), refactor.TextEdit{Location: def, NewText: "pure "}, true
+}
+
+// defKeyword returns the empty location just before the def keyword of fn, where
+// the pure modifier goes: after pub. Functions that are async or extern are
+// never pure, so no modifier comes between.
+func defKeyword(source []byte, fn *ast.FunctionDefStmt) (ast.Location, bool) {
+	lines := strings.Split(string(source), "\n")
+	if fn.NameLocation.StartLine < 1 || fn.NameLocation.StartLine > len(lines) {
+		return ast.Location{}, false
+	}
+	line := lines[fn.NameLocation.StartLine-1]
+	if fn.NameLocation.StartCol-1 > len(line) {
+		return ast.Location{}, false
+	}
+	before := line[:fn.NameLocation.StartCol-1]
+	at := strings.LastIndex(before, "def")
+	if at < 0 {
+		return ast.Location{}, false
+	}
+	row, col := fn.NameLocation.StartLine, at+1
+	return ast.Location{StartLine: row, StartCol: col, EndLine: row, EndCol: col}, true
+}
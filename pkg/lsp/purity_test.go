@@ -0,0 +1,78 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+// puritySource is a program where loud prints, shout calls loud, and the pure
+// quad and yell call twice and loud, neither declared pure
+const puritySource = "def twice: (Int) -> Int = (n) => n * 2\ndef shout: (Int) -> Int = (n) => loud(n)\ndef loud: (Int) -> Int = (n) => debug(n)\n" +
+	"pure def quad: (Int) -> Int = (n) => twice(n)\npure def yell: (Int) -> Int = (n) => loud(n)\n"
+
+func TestServer_PurityHoverAndLens(t *testing.T) {
+	position := func(line, character int) TextDocumentPositionParams {
+		return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: line, Character: character}}
+	}
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, puritySource),
+		call(2, "textDocument/hover", position(0, 5)),  // tw|ice
+		call(3, "textDocument/hover", position(1, 5)),  // sh|out
+		call(4, "textDocument/hover", position(1, 34)), // l|oud(n)
+		call(5, "textDocument/codeLens", CodeLensParams{TextDocument: TextDocumentIdentifier{URI: testURI}}),
+		call(6, "workspace/executeCommand", map[string]any{"command": markPureCommand, "arguments": []any{position(0, 4)}}),
+		notify("exit", nil),
+	)
+
+	for id, expected := range map[int]string{
+		2: "```lyra\ndef twice: (Int) -> Int\n```\ninferred: pure",
+		3: "```lyra\ndef shout: (Int) -> Int\n```\ninferred: impure (calls loud at line 2)",
+		4: "```lyra\nloud: (Int) -> Int\n```\ninferred: impure (calls debug at line 3)",
+	} {
+		var hover Hover
+		if err := json.Unmarshal(responses[id], &hover); err != nil {
+			t.Fatalf("invalid hover result: %v", err)
+		}
+		if hover.Contents.Value != expected {
+			t.Fatalf("Expected hover %q for request %d. Got %q", expected, id, hover.Contents.Value)
+		}
+	}
+
+	var lenses []CodeLens
+	if err := json.Unmarshal(responses[5], &lenses); err != nil {
+		t.Fatalf("invalid code lens result: %v", err)
+	}
+	if len(lenses) != 1 || lenses[0].Range.Start != (Position{Line: 0, Character: 4}) || lenses[0].Command.Command != markPureCommand {
+		t.Fatalf("Expected a single mark as pure lens on twice. Got %+v", lenses)
+	}
+
+	var edit WorkspaceEdit
+	if err := json.Unmarshal(responses[6], &edit); err != nil {
+		t.Fatalf("invalid workspace edit: %v", err)
+	}
+	edits := edit.Changes[testURI]
+	if len(edits) != 1 || edits[0].NewText != "pure " || edits[0].Range != (Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 0}}) {
+		t.Fatalf("Expected pure inserted before def twice. Got %+v", edits)
+	}
+}
+
+func TestServer_MarkCalleePure(t *testing.T) {
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, puritySource),
+		call(2, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 3, Character: 0}, End: Position{Line: 5, Character: 0}},
+		}),
+		notify("exit", nil),
+	)
+
+	// loud prints, so yell cannot be fixed by declaring it pure
+	actions, applied := appliedFixes(t, puritySource, responses[2])
+	if len(applied) != 1 || applied["Mark twice as pure"] != "pure "+puritySource {
+		t.Fatalf("Expected only twice marked pure. Got %v", actions)
+	}
+}
@@ -0,0 +1,127 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+)
+
+// definition resolves the variable, function, field, type or constructor named at
+// a position to where it is declared; null if nothing is named there
+func (s *Server) definition(params json.RawMessage) (any, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.Index.ReferenceAt(fromPosition(p.Position))
+	if !ok {
+		return nil, nil
+	}
+	definitions := doc.Index.References(ref.Target, refs.Definition)
+	if len(definitions) == 0 {
+		return nil, nil
+	}
+	return Location{URI: p.TextDocument.URI, Range: toRange(definitions[0].Location)}, nil
+}
+
+func (s *Server) references(params json.RawMessage) (any, error) {
+	var p ReferenceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, ok := doc.Index.ReferenceAt(fromPosition(p.Position))
+	if !ok {
+		return []Location{}, nil
+	}
+
+	var kinds []refs.Kind
+	for _, name := range p.Context.Kinds {
+		kind, err := refs.ParseKind(name)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 && !p.Context.IncludeDeclaration {
+		kinds = []refs.Kind{refs.Read, refs.Write, refs.Call}
+	}
+
+	locations := make([]Location, 0)
+	for _, r := range doc.Index.References(ref.Target, kinds...) {
+		locations = append(locations, Location{URI: p.TextDocument.URI, Range: toRange(r.Location)})
+	}
+	return locations, nil
+}
+
+func (s *Server) documentHighlight(params json.RawMessage) (any, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, ok := doc.Index.ReferenceAt(fromPosition(p.Position))
+	if !ok {
+		return []DocumentHighlight{}, nil
+	}
+	highlights := make([]DocumentHighlight, 0)
+	for _, r := range doc.Index.References(ref.Target) {
+		highlights = append(highlights, DocumentHighlight{Range: toRange(r.Location), Kind: highlightKind(r.Kind)})
+	}
+	return highlights, nil
+}
+
+// highlightKind colors definitions and writes alike, reads as reads, and calls
+// as plain text so they stand apart from value reads
+func highlightKind(kind refs.Kind) DocumentHighlightKind {
+	switch kind {
+	case refs.Definition, refs.Write:
+		return HighlightWrite
+	case refs.Read:
+		return HighlightRead
+	}
+	return HighlightText
+}
+
+// rename answers textDocument/rename for the field, function, variable, local or
+// data constructor named at a position. Documents cannot import each other yet,
+// so every reference is in the same document. A rename that would collide with
+// or capture another symbol fails with the conflicting location.
+func (s *Server) rename(params json.RawMessage) (any, error) {
+	var p RenameParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.Index.ReferenceAt(fromPosition(p.Position))
+	if !ok {
+		return nil, fmt.Errorf("nothing to rename at %d:%d", p.Position.Line+1, p.Position.Character+1)
+	}
+	var edits []refactor.TextEdit
+	if ref.Target.Kind == refs.TargetField {
+		edits, err = refactor.RenameField(doc.Table, doc.Index, ref.Target.Container, ref.Target.Name, p.NewName)
+	} else {
+		edits, err = refactor.RenameSymbol(doc.Table, doc.Index, ref.Target, p.NewName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return workspaceEdit(p.TextDocument.URI, edits), nil
+}
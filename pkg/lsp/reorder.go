@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/refactor"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// reorderActions offers to sort the fields of a struct declaration overlapping
+// rng, and to reorder a struct's fields to match a literal of it overlapping rng.
+// Either way every literal of the struct is reordered along with the declaration.
+func (s *Server) reorderActions(uri string, doc *analyzer.Result, rng Range) []CodeAction {
+	var actions []CodeAction
+	offer := func(title, structName string, order []string) {
+		edits, err := refactor.ReorderFields(doc.Source, doc.Program, doc.Table, structName, order)
+		if err != nil || len(edits) == 0 {
+			return
+		}
+		actions = append(actions, CodeAction{Title: title, Kind: "refactor.rewrite", Edit: workspaceEdit(uri, edits)})
+	}
+
+	for _, stmt := range doc.Program.Statements {
+		decl, ok := stmt.(*ast.TypeDeclStmt)
+		if !ok {
+			continue
+		}
+		if _, isStruct := decl.Type.(types.StructType); !isStruct {
+			continue
+		}
+		current, err := refactor.FieldOrder(doc.Table, decl.Name)
+		if err != nil {
+			continue
+		}
+		if overlaps(toRange(decl.Location), rng) && !sort.StringsAreSorted(current) {
+			sorted := append([]string(nil), current...)
+			sort.Strings(sorted)
+			offer(fmt.Sprintf("Sort fields of %s", decl.Name), decl.Name, sorted)
+		}
+		for _, literal := range refactor.StructLiterals(doc.Program, decl.Name) {
+			if !overlaps(toRange(literal.Location), rng) {
+				continue
+			}
+			if order := literalOrder(literal, current); !slices.Equal(order, current) {
+				offer(fmt.Sprintf("Reorder fields of %s to match this literal", decl.Name), decl.Name, order)
+			}
+			break
+		}
+	}
+	return actions
+}
+
+// literalOrder returns the fields of literal in the order it lists them, followed
+// by the fields it leaves to their defaults in declaration order
+func literalOrder(literal *ast.StructLiteralExpr, current []string) []string {
+	fields := append([]*ast.StructLiteralField(nil), literal.Fields...)
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i].NameLocation, fields[j].NameLocation
+		return a.StartLine < b.StartLine || (a.StartLine == b.StartLine && a.StartCol < b.StartCol)
+	})
+	order := make([]string, 0, len(current))
+	listed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		order = append(order, field.Name)
+		listed[field.Name] = true
+	}
+	for _, name := range current {
+		if !listed[name] {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// workspaceEdit converts edits of the document at uri to a workspace edit
+func workspaceEdit(uri string, edits []refactor.TextEdit) *WorkspaceEdit {
+	changes := make([]TextEdit, len(edits))
+	for i, edit := range edits {
+		changes[i] = TextEdit{Range: toRange(edit.Location), NewText: edit.NewText}
+	}
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{uri: changes}}
+}
@@ -0,0 +1,48 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+const pointSource = "struct Point { x: Int, y: Int }\nlet p: Point = Point { y: 2, x: 1 }\n"
+
+func TestServer_ReorderFieldsAction(t *testing.T) {
+	responses := sessionWith(t, analyzer.Analyze,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, pointSource),
+		call(2, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 1, Character: 20}, End: Position{Line: 1, Character: 20}},
+		}),
+		call(3, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Range:        Range{Start: Position{Line: 0, Character: 3}, End: Position{Line: 0, Character: 3}},
+		}),
+		notify("exit", nil),
+	)
+
+	var actions []CodeAction
+	if err := json.Unmarshal(responses[2], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Title != "Reorder fields of Point to match this literal" || actions[0].Edit == nil {
+		t.Fatalf("Expected a reorder action. Got %+v", actions)
+	}
+	edits := actions[0].Edit.Changes[testURI]
+	if len(edits) != 2 || edits[0].NewText != "y: Int" || edits[1].NewText != "x: Int" {
+		t.Fatalf("Expected the declaration's fields swapped and the literal left alone. Got %+v", edits)
+	}
+	if edits[0].Range != (Range{Start: Position{Line: 0, Character: 15}, End: Position{Line: 0, Character: 21}}) {
+		t.Fatalf("Expected the first field replaced. Got %+v", edits[0].Range)
+	}
+
+	if err := json.Unmarshal(responses[3], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("Expected no action for already sorted fields. Got %+v", actions)
+	}
+}
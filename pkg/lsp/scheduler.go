@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"context"
+	"time"
+)
+
+// defaultAnalysisDelay is how long the server waits after an edit before
+// analyzing, unless the client sets initializationOptions.analysisDelay
+const defaultAnalysisDelay = 200 * time.Millisecond
+
+// analysisJob is the latest edit of a document, waiting for the edits to pause
+// or under analysis on its worker goroutine
+type analysisJob struct {
+	text   string
+	cancel context.CancelFunc
+}
+
+// schedule analyzes the new text of a document on a worker goroutine once no
+// other edit has come for s.delay. The analysis of older text, waiting or in
+// flight, is cancelled: its result would be out of date. The caller holds s.mu.
+func (s *Server) schedule(uri, text string) {
+	s.cancel(uri)
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &analysisJob{text: text, cancel: cancel}
+	s.pending[uri] = job
+
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		defer cancel()
+		timer := time.NewTimer(s.delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return
+		}
+		result, err := s.analyze(ctx, []byte(text))
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pending[uri] != job {
+			return // cancelled, or settled by a request meanwhile
+		}
+		delete(s.pending, uri)
+		_ = s.store(uri, result, err) // a failed analysis is published as a diagnostic
+	}()
+}
+
+// settle analyzes the pending edit of a document right away, so a request sees
+// the text the client has. The caller holds s.mu.
+func (s *Server) settle(uri string) error {
+	job, ok := s.pending[uri]
+	if !ok {
+		return nil
+	}
+	s.cancel(uri)
+	return s.update(uri, job.text)
+}
+
+// settleAll settles every document with a pending edit; a document that fails to
+// analyze is left out of the store, as after an edit
+func (s *Server) settleAll() {
+	for uri := range s.pending {
+		_ = s.settle(uri)
+	}
+}
+
+// cancel drops the pending edit of a document, stopping its analysis
+func (s *Server) cancel(uri string) {
+	if job, ok := s.pending[uri]; ok {
+		job.cancel()
+		delete(s.pending, uri)
+	}
+}
+
+// stopAnalyses cancels every pending analysis and waits for the workers to exit
+func (s *Server) stopAnalyses() {
+	s.mu.Lock()
+	for uri := range s.pending {
+		s.cancel(uri)
+	}
+	s.mu.Unlock()
+	s.workers.Wait()
+}
@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+// waitForDocument waits until the server holds an analysis of uri with source text
+func waitForDocument(t *testing.T, server *Server, uri, text string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		server.mu.Lock()
+		doc, ok := server.documents.get(uri)
+		server.mu.Unlock()
+		if ok && string(doc.Source) == text {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %s analyzed as %q in the background", uri, text)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduler_DebouncesEdits(t *testing.T) {
+	var out bytes.Buffer
+	server := NewServer(&bytes.Buffer{}, &out)
+	analyzed := make(chan string, 8)
+	server.analyze = func(_ context.Context, source []byte) (*analyzer.Result, error) {
+		analyzed <- string(source)
+		return counterResult(source)
+	}
+	server.delay = 20 * time.Millisecond
+
+	server.mu.Lock()
+	for _, text := range []string{"c", "co", "cou"} {
+		server.schedule(testURI, text)
+	}
+	server.mu.Unlock()
+	waitForDocument(t, server, testURI, "cou")
+	server.stopAnalyses()
+
+	close(analyzed)
+	var texts []string
+	for text := range analyzed {
+		texts = append(texts, text)
+	}
+	if len(texts) != 1 || texts[0] != "cou" {
+		t.Fatalf("Expected only the last edit analyzed. Got %q", texts)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("textDocument/publishDiagnostics")) {
+		t.Fatalf("Expected the background analysis to publish diagnostics. Got %s", out.String())
+	}
+}
+
+func TestScheduler_CancelsOutdatedAnalyses(t *testing.T) {
+	server := NewServer(&bytes.Buffer{}, &bytes.Buffer{})
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	server.analyze = func(ctx context.Context, source []byte) (*analyzer.Result, error) {
+		if string(source) == "slow" {
+			close(started)
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+		return counterResult(source)
+	}
+	server.delay = 0
+
+	server.mu.Lock()
+	server.schedule(testURI, "slow")
+	server.mu.Unlock()
+	<-started
+	server.mu.Lock()
+	server.schedule(testURI, "fast")
+	server.mu.Unlock()
+
+	if err := <-cancelled; err != context.Canceled {
+		t.Fatalf("Expected the analysis of the older text cancelled. Got %v", err)
+	}
+	waitForDocument(t, server, testURI, "fast")
+	server.stopAnalyses()
+}
+
+func TestScheduler_RequestsSettlePendingEdits(t *testing.T) {
+	server := NewServer(&bytes.Buffer{}, &bytes.Buffer{})
+	server.analyze = func(_ context.Context, source []byte) (*analyzer.Result, error) { return counterResult(source) }
+	server.delay = time.Hour
+	defer server.stopAnalyses()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	server.schedule(testURI, "count")
+	doc, err := server.document(testURI)
+	if err != nil {
+		t.Fatalf("document error: %v", err)
+	}
+	if string(doc.Source) != "count" || len(server.pending) != 0 {
+		t.Fatalf("Expected the pending edit analyzed for the request. Got %q with %d pending", doc.Source, len(server.pending))
+	}
+}
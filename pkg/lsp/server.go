@@ -0,0 +1,301 @@
+package lsp
+
+/*
+Server is a Language Server Protocol server for Lyra speaking JSON-RPC over a
+reader/writer pair (stdin/stdout for editors). Documents are fully re-analyzed on
+every change, in the background once the edits pause (see scheduler.go); each
+handler answers from the analyzer.Result of the latest text of a document.
+*/
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/lint"
+)
+
+type handler func(s *Server, params json.RawMessage) (any, error)
+
+var handlers = map[string]handler{
+	"initialize":                       (*Server).initialize,
+	"initialized":                      nil,
+	"shutdown":                         (*Server).shutdown,
+	"textDocument/didOpen":             (*Server).didOpen,
+	"textDocument/didChange":           (*Server).didChange,
+	"textDocument/didClose":            (*Server).didClose,
+	"textDocument/hover":               (*Server).hover,
+	"textDocument/definition":          (*Server).definition,
+	"textDocument/references":          (*Server).references,
+	"textDocument/documentHighlight":   (*Server).documentHighlight,
+	"textDocument/documentSymbol":      (*Server).documentSymbol,
+	"textDocument/rename":              (*Server).rename,
+	"textDocument/codeAction":          (*Server).codeAction,
+	"textDocument/completion":          (*Server).completion,
+	"textDocument/signatureHelp":       (*Server).signatureHelp,
+	"textDocument/onTypeFormatting":    (*Server).onTypeFormatting,
+	"textDocument/inlayHint":           (*Server).inlayHint,
+	"textDocument/codeLens":            (*Server).codeLens,
+	"workspace/didChangeConfiguration": (*Server).didChangeConfiguration,
+	"workspace/executeCommand":         (*Server).executeCommand,
+	"workspace/symbol":                 (*Server).workspaceSymbol,
+	"lyra/uncovered":                   (*Server).uncovered,
+	"lyra/typedAst":                    (*Server).typedAst,
+	"lyra/callGraph":                   (*Server).callGraph,
+	"lyra/traitMatrix":                 (*Server).traitMatrix,
+	"lyra/checkerTrace":                (*Server).checkerTrace,
+	"lyra/documentStore":               (*Server).documentStore,
+}
+
+type Server struct {
+	reader *bufio.Reader
+	writer io.Writer
+
+	// analyze and analyzeTraced are swappable so tests can feed hand-built results
+	analyze       func(ctx context.Context, source []byte) (*analyzer.Result, error)
+	analyzeTraced func(source []byte) (*analyzer.Result, error)
+
+	// mu is held while a message is handled and while a background analysis
+	// stores its result, so handlers never see a document change under them
+	mu         sync.Mutex
+	delay      time.Duration           // quiet time after an edit before it is analyzed
+	pending    map[string]*analysisJob // edits waiting for or under analysis, by uri
+	workers    sync.WaitGroup
+	documents  *documentStore
+	lint       lint.Config // read from lyra-lint.json in the workspace root
+	snippets   bool        // the client takes completions with placeholders
+	inlayHints inlayHintKinds
+	reporting  diagnosticSettings // from the lyra settings of the workspace
+	nested     bool               // workspace/symbol lists nested functions
+
+	// addedConstructors holds the constructors edits added to the data types of
+	// documents, whose matches elsewhere are warned until they handle them
+	addedConstructors map[declaredType]map[string]bool
+
+	shuttingDown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		reader:        bufio.NewReader(in),
+		writer:        out,
+		analyze:       analyzer.AnalyzeInteractive,
+		analyzeTraced: analyzer.AnalyzeTraced,
+		delay:         defaultAnalysisDelay,
+		pending:       make(map[string]*analysisJob),
+		documents:     newDocumentStore(defaultRecentDocuments),
+		lint:          lint.DefaultConfig(),
+		inlayHints:    inlayHintKinds{parameterTypes: true, typeArguments: true},
+		reporting:     diagnosticSettings{strictness: standard},
+
+		addedConstructors: make(map[declaredType]map[string]bool),
+	}
+}
+
+// Run serves requests until the client sends exit or closes the connection.
+// Analyses still running when it returns are cancelled and waited for.
+func (s *Server) Run() error {
+	defer s.stopAnalyses()
+	for {
+		body, err := readMessage(s.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			s.mu.Lock()
+			err := s.reply(nil, nil, &responseError{Code: codeParseError, Message: err.Error()})
+			s.mu.Unlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		s.mu.Lock()
+		err = s.dispatch(req)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) dispatch(req request) error {
+	h, known := handlers[req.Method]
+	if !known {
+		if req.ID == nil {
+			return nil // unknown notifications are ignored
+		}
+		return s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method})
+	}
+
+	var result any
+	var err error
+	if h != nil {
+		result, err = h(s, req.Params)
+	}
+	if req.ID == nil {
+		return nil
+	}
+	if err != nil {
+		code := codeInternalError
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			code = codeInvalidParams
+		}
+		return s.reply(req.ID, nil, &responseError{Code: code, Message: err.Error()})
+	}
+	return s.reply(req.ID, result, nil)
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, respErr *responseError) error {
+	return writeMessage(s.writer, response{JSONRPC: "2.0", ID: id, Result: result, Error: respErr})
+}
+
+// notify sends a notification, which the client does not answer
+func (s *Server) notify(method string, params any) error {
+	return writeMessage(s.writer, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p InitializeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if p.RootURI != "" {
+		root, err := uriPath(p.RootURI)
+		if err != nil {
+			return nil, err
+		}
+		if s.lint, err = lint.LoadConfig(filepath.Join(root, lint.DefaultConfigFile)); err != nil {
+			return nil, err
+		}
+	}
+	s.snippets = p.Capabilities.TextDocument.Completion.CompletionItem.SnippetSupport
+	if p.InitializationOptions != nil && p.InitializationOptions.RecentDocuments != nil {
+		if *p.InitializationOptions.RecentDocuments < 0 {
+			return nil, fmt.Errorf("recentDocuments must not be negative")
+		}
+		s.documents.resize(*p.InitializationOptions.RecentDocuments)
+	}
+	if p.InitializationOptions != nil && p.InitializationOptions.AnalysisDelay != nil {
+		if *p.InitializationOptions.AnalysisDelay < 0 {
+			return nil, fmt.Errorf("analysisDelay must not be negative")
+		}
+		s.delay = time.Duration(*p.InitializationOptions.AnalysisDelay) * time.Millisecond
+	}
+	if p.InitializationOptions != nil {
+		s.inlayHints.apply(p.InitializationOptions.InlayHints)
+	}
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:                 SyncFull,
+			HoverProvider:                    true,
+			DefinitionProvider:               true,
+			ReferencesProvider:               true,
+			DocumentHighlightProvider:        true,
+			DocumentSymbolProvider:           true,
+			RenameProvider:                   true,
+			CodeActionProvider:               true,
+			CompletionProvider:               &CompletionOptions{TriggerCharacters: []string{"(", ","}},
+			SignatureHelpProvider:            &SignatureHelpOptions{TriggerCharacters: []string{"(", ","}},
+			DocumentOnTypeFormattingProvider: &DocumentOnTypeFormattingOptions{FirstTriggerCharacter: "{"},
+			InlayHintProvider:                true,
+			CodeLensProvider:                 &CodeLensOptions{},
+			ExecuteCommandProvider:           &ExecuteCommandOptions{Commands: []string{explainCommand, safeDeleteCommand, canonicalAnnotationsCommand, markPureCommand}},
+			WorkspaceSymbolProvider:          true,
+		},
+		ServerInfo: ServerInfo{Name: "lyra-lsp"},
+	}, nil
+}
+
+func (s *Server) shutdown(params json.RawMessage) (any, error) {
+	s.shuttingDown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (any, error) {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) (any, error) {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+	// full sync: the last change holds the whole document
+	s.schedule(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	return nil, nil
+}
+
+func (s *Server) didClose(params json.RawMessage) (any, error) {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	// the closed document keeps the analysis of its last text; if that fails,
+	// the failure is published and the document forgotten
+	_ = s.settle(p.TextDocument.URI)
+	s.documents.close(p.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+// update analyzes a document's new text right away and publishes its
+// diagnostics, dropping any pending edit
+func (s *Server) update(uri, text string) error {
+	s.cancel(uri)
+	result, err := s.analyze(context.Background(), []byte(text))
+	return s.store(uri, result, err)
+}
+
+// store records the analysis of a document and publishes its diagnostics, or the
+// error that kept it from being analyzed
+func (s *Server) store(uri string, result *analyzer.Result, err error) error {
+	if err != nil {
+		s.documents.forget(uri)
+		if err := s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: []Diagnostic{toDiagnostic(uri, err)}}); err != nil {
+			return err
+		}
+		return fmt.Errorf("analyzing %s: %w", uri, err)
+	}
+	before, _ := s.documents.get(uri)
+	gained := s.noteConstructors(uri, before, result)
+	s.documents.put(uri, result)
+	if err := s.publishDiagnostics(uri, result); err != nil {
+		return err
+	}
+	return s.republishMatches(uri, gained)
+}
+
+// toRange converts a one-based ast.Location to a zero-based LSP range
+func toRange(loc ast.Location) Range {
+	return Range{
+		Start: Position{Line: loc.StartLine - 1, Character: loc.StartCol - 1},
+		End:   Position{Line: loc.EndLine - 1, Character: loc.EndCol - 1},
+	}
+}
+
+// fromPosition converts a zero-based LSP position to a one-based line and column
+func fromPosition(pos Position) (line, col int) {
+	return pos.Line + 1, pos.Character + 1
+}
@@ -0,0 +1,232 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const testURI = "file:///test.lyra"
+
+func at(line, col, length int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+}
+
+// counterResult is the analysis of:
+//
+//	var count: Int = 0
+//	count = count
+func counterResult(source []byte) (*analyzer.Result, error) {
+	table := symbols.NewSymbolTable()
+	intType := types.PrimitiveType{Name: types.Int}
+	program := &ast.Program{Statements: []ast.AstNode{
+		&ast.VarDeclStmt{Keyword: "var", Name: "count", NameLocation: at(1, 5, 5), Type: intType, Value: &ast.IntegerLiteralExpr{Value: 0}},
+		&ast.VarAssignStmt{Name: "count", NameLocation: at(2, 1, 5), Value: &ast.IdentifierExpr{
+			ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: at(2, 9, 5)}},
+			Name:     "count",
+		}},
+	}}
+	return &analyzer.Result{Source: source, Program: program, Table: table, Index: refs.Build(program, table)}, nil
+}
+
+// session runs the server over the given requests and returns its responses by id
+func session(t *testing.T, messages ...any) map[int]json.RawMessage {
+	return sessionWith(t, counterResult, messages...)
+}
+
+// sessionWith is session with documents analyzed by analyze
+func sessionWith(t *testing.T, analyze func(source []byte) (*analyzer.Result, error), messages ...any) map[int]json.RawMessage {
+	responses, _ := exchange(t, analyze, messages...)
+	return responses
+}
+
+// exchange runs a session, returning the server's responses by id and the
+// params of its notifications by method, in the order they were sent
+func exchange(t *testing.T, analyze func(source []byte) (*analyzer.Result, error), messages ...any) (map[int]json.RawMessage, map[string][]json.RawMessage) {
+	var in, out bytes.Buffer
+	for _, message := range messages {
+		if err := writeMessage(&in, message); err != nil {
+			t.Fatalf("writeMessage error: %v", err)
+		}
+	}
+	server := NewServer(&in, &out)
+	server.analyze = func(_ context.Context, source []byte) (*analyzer.Result, error) { return analyze(source) }
+	server.analyzeTraced = analyze
+	if err := server.Run(); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+
+	responses := make(map[int]json.RawMessage)
+	notifications := make(map[string][]json.RawMessage)
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+		var resp struct {
+			ID     int             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+			Error  *responseError  `json:"error"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatalf("invalid response %s: %v", body, err)
+		}
+		if resp.Method != "" {
+			notifications[resp.Method] = append(notifications[resp.Method], resp.Params)
+			continue
+		}
+		if resp.Error != nil {
+			t.Fatalf("request %d failed: %s", resp.ID, resp.Error.Message)
+		}
+		responses[resp.ID] = resp.Result
+	}
+	return responses, notifications
+}
+
+// analyzed runs the analyzer on source, for tests reading a result outside a
+// session; sessions analyze with analyzer.Analyze itself
+func analyzed(t *testing.T, source string) *analyzer.Result {
+	t.Helper()
+	result, err := analyzer.Analyze([]byte(source))
+	if err != nil {
+		t.Fatalf("Analyze error: %v", err)
+	}
+	return result
+}
+
+// openDocument opens the document at uri holding text
+func openDocument(uri, text string) map[string]any {
+	return notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Text: text}})
+}
+
+func call(id int, method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func TestServer_ReferencesFilteredByKind(t *testing.T) {
+	position := TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: 1, Character: 9}}
+	responses := session(t,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: "..."}}),
+		call(2, "textDocument/references", ReferenceParams{TextDocumentPositionParams: position, Context: ReferenceContext{IncludeDeclaration: true}}),
+		call(3, "textDocument/references", ReferenceParams{TextDocumentPositionParams: position, Context: ReferenceContext{Kinds: []string{"write"}}}),
+		call(4, "textDocument/documentHighlight", position),
+		notify("exit", nil),
+	)
+
+	var all, writes []Location
+	if err := json.Unmarshal(responses[2], &all); err != nil {
+		t.Fatalf("invalid references result: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("Expected 3 references including the declaration, got %d", len(all))
+	}
+	if err := json.Unmarshal(responses[3], &writes); err != nil {
+		t.Fatalf("invalid references result: %v", err)
+	}
+	if len(writes) != 1 || writes[0].Range.Start != (Position{Line: 1, Character: 0}) {
+		t.Fatalf("Expected the single write at 1:0. Got %v", writes)
+	}
+
+	var highlights []DocumentHighlight
+	if err := json.Unmarshal(responses[4], &highlights); err != nil {
+		t.Fatalf("invalid highlight result: %v", err)
+	}
+	kinds := ""
+	for _, h := range highlights {
+		kinds += fmt.Sprint(h.Kind)
+	}
+	if kinds != "323" {
+		t.Fatalf("Expected definition, read and write highlights (323). Got %s", kinds)
+	}
+}
+
+func TestServer_Definition(t *testing.T) {
+	document := TextDocumentIdentifier{URI: testURI}
+	responses := session(t,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: "..."}}),
+		call(2, "textDocument/definition", TextDocumentPositionParams{TextDocument: document, Position: Position{Line: 1, Character: 10}}),
+		call(3, "textDocument/definition", TextDocumentPositionParams{TextDocument: document, Position: Position{Line: 1, Character: 6}}),
+		notify("exit", nil),
+	)
+
+	var definition Location
+	if err := json.Unmarshal(responses[2], &definition); err != nil {
+		t.Fatalf("invalid definition result: %v", err)
+	}
+	if definition.URI != testURI || definition.Range.Start != (Position{Line: 0, Character: 4}) {
+		t.Fatalf("Expected count to be defined at 0:4. Got %+v", definition)
+	}
+	if string(responses[3]) != "null" {
+		t.Fatalf("Expected no definition between names. Got %s", responses[3])
+	}
+}
+
+func TestServer_Rename(t *testing.T) {
+	responses := session(t,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: "..."}}),
+		call(2, "textDocument/rename", RenameParams{
+			TextDocumentPositionParams: TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: 1, Character: 0}},
+			NewName:                    "total",
+		}),
+		notify("exit", nil),
+	)
+
+	var edit WorkspaceEdit
+	if err := json.Unmarshal(responses[2], &edit); err != nil {
+		t.Fatalf("invalid rename result: %v", err)
+	}
+	if changes := edit.Changes[testURI]; len(changes) != 3 || changes[0].NewText != "total" {
+		t.Fatalf("Expected the declaration, write and read of count renamed. Got %+v", changes)
+	}
+}
+
+func TestServer_ExplainCodeAction(t *testing.T) {
+	diagnostic := Diagnostic{Code: "LYR0003", Message: "cannot use String as Int in declaration of x"}
+	responses := session(t,
+		call(1, "initialize", map[string]any{}),
+		call(2, "textDocument/codeAction", CodeActionParams{
+			TextDocument: TextDocumentIdentifier{URI: testURI},
+			Context:      CodeActionContext{Diagnostics: []Diagnostic{diagnostic, {Message: "no code"}}},
+		}),
+		call(3, "workspace/executeCommand", map[string]any{"command": explainCommand, "arguments": []string{"LYR0003"}}),
+		notify("exit", nil),
+	)
+
+	var actions []CodeAction
+	if err := json.Unmarshal(responses[2], &actions); err != nil {
+		t.Fatalf("invalid code action result: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Command == nil || actions[0].Command.Command != explainCommand {
+		t.Fatalf("Expected a single explain action. Got %+v", actions)
+	}
+
+	var explanation struct {
+		Code     string `json:"code"`
+		Markdown string `json:"markdown"`
+	}
+	if err := json.Unmarshal(responses[3], &explanation); err != nil {
+		t.Fatalf("invalid executeCommand result: %v", err)
+	}
+	if explanation.Code != "LYR0003" || explanation.Markdown == "" {
+		t.Fatalf("Expected the explanation of LYR0003. Got %+v", explanation)
+	}
+}
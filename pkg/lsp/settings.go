@@ -0,0 +1,170 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/lint"
+)
+
+// Strictness levels of the lyra.strictness setting
+const (
+	lenient  = "lenient"
+	standard = "standard"
+	strict   = "strict"
+)
+
+// off is the severity of a diagnostic the settings hide
+const off diagnostics.Severity = 0
+
+// diagnosticSettings decide which diagnostics are published and how
+type diagnosticSettings struct {
+	severity       map[diagnostics.Code]diagnostics.Severity // overrides by code
+	lint           bool                                      // publish lint warnings
+	lintDisabled   []string
+	maxDiagnostics int // 0 for no limit
+	strictness     string
+}
+
+var severities = map[string]diagnostics.Severity{
+	"error":   diagnostics.Error,
+	"warning": diagnostics.Warning,
+	"info":    diagnostics.Information,
+	"hint":    diagnostics.Hint,
+	"off":     off,
+}
+
+// apply takes the settings set in settings, leaving the others as they are. It
+// fails without changing anything if a setting is invalid.
+func (d *diagnosticSettings) apply(settings Settings) error {
+	next := *d
+	if settings.Severity != nil {
+		next.severity = make(map[diagnostics.Code]diagnostics.Severity, len(settings.Severity))
+		for code, name := range settings.Severity {
+			if _, err := diagnostics.Explain(diagnostics.Code(code)); err != nil {
+				return err
+			}
+			severity, ok := severities[name]
+			if !ok {
+				return fmt.Errorf("invalid severity %q for %s: expected error, warning, info, hint or off", name, code)
+			}
+			next.severity[diagnostics.Code(code)] = severity
+		}
+	}
+	if settings.Lint != nil {
+		if settings.Lint.Enabled != nil {
+			next.lint = *settings.Lint.Enabled
+		}
+		if settings.Lint.Disabled != nil {
+			next.lintDisabled = settings.Lint.Disabled
+		}
+	}
+	if settings.MaxDiagnostics != nil {
+		if *settings.MaxDiagnostics < 0 {
+			return fmt.Errorf("maxDiagnostics must not be negative")
+		}
+		next.maxDiagnostics = *settings.MaxDiagnostics
+	}
+	switch settings.Strictness {
+	case "":
+	case lenient, standard, strict:
+		next.strictness = settings.Strictness
+	default:
+		return fmt.Errorf("invalid strictness %q: expected lenient, standard or strict", settings.Strictness)
+	}
+	*d = next
+	return nil
+}
+
+// filter adjusts the severity of each diagnostic, first by strictness then by
+// the override for its code, drops those turned off and keeps the most severe
+// up to the limit, in their order
+func (d *diagnosticSettings) filter(published []Diagnostic) []Diagnostic {
+	kept := make([]Diagnostic, 0, len(published))
+	for _, diagnostic := range published {
+		severity := diagnostics.Severity(diagnostic.Severity)
+		switch {
+		case d.strictness == strict && severity == diagnostics.Warning:
+			severity = diagnostics.Error
+		case d.strictness == lenient && severity == diagnostics.Warning:
+			severity = diagnostics.Hint
+		}
+		if override, ok := d.severity[diagnostics.Code(diagnostic.Code)]; ok {
+			severity = override
+		}
+		if severity == off {
+			continue
+		}
+		diagnostic.Severity = int(severity)
+		kept = append(kept, diagnostic)
+	}
+	if d.maxDiagnostics == 0 || len(kept) <= d.maxDiagnostics {
+		return kept
+	}
+	order := make([]int, len(kept))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return kept[order[i]].Severity < kept[order[j]].Severity })
+	order = order[:d.maxDiagnostics]
+	sort.Ints(order)
+	capped := make([]Diagnostic, len(order))
+	for i, index := range order {
+		capped[i] = kept[index]
+	}
+	return capped
+}
+
+// lintConfig is the config of lyra-lint.json with the rules the settings disable
+func (s *Server) lintConfig() lint.Config {
+	config := s.lint
+	config.Disabled = append(append([]string(nil), config.Disabled...), s.reporting.lintDisabled...)
+	return config
+}
+
+// lintDiagnostics converts the lint warnings of a document
+func (s *Server) lintDiagnostics(doc *analyzer.Result) []Diagnostic {
+	var published []Diagnostic
+	for _, d := range lint.Run(doc, s.lintConfig()) {
+		published = append(published, Diagnostic{
+			Range:    toRange(d.Location),
+			Severity: int(d.Severity),
+			Code:     string(d.Code),
+			Source:   "lyra lint",
+			Message:  d.Message,
+		})
+	}
+	return published
+}
+
+// didChangeConfiguration takes the settings of the client and publishes the
+// diagnostics of the open documents again under them
+func (s *Server) didChangeConfiguration(params json.RawMessage) (any, error) {
+	var p DidChangeConfigurationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if err := s.reporting.apply(p.Settings.Lyra); err != nil {
+		return nil, err
+	}
+	s.inlayHints.apply(p.Settings.Lyra.InlayHints)
+	if p.Settings.Lyra.NestedSymbols != nil {
+		s.nested = *p.Settings.Lyra.NestedSymbols
+	}
+
+	s.settleAll()
+	uris := make([]string, 0, len(s.documents.open))
+	for uri := range s.documents.open {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	for _, uri := range uris {
+		if err := s.publishDiagnostics(uri, s.documents.open[uri]); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,157 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// signatureHelp answers textDocument/signatureHelp inside the arguments of a call
+// of a function or data constructor, with a signature per candidate (a constructor
+// name may belong to several data types) and the parameter at the cursor active.
+// The arguments are found in the text, as they are mostly unparsable while typed.
+func (s *Server) signatureHelp(params json.RawMessage) (any, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	line, col := fromPosition(p.Position)
+	callee, active, ok := callAt(doc.Source[:offsetOf(doc.Source, line, col)])
+	if !ok {
+		return nil, nil
+	}
+
+	var signatures []SignatureInformation
+	name := callee[len(callee)-1]
+	if fn, ok := doc.Table.Functions[name]; ok && fn.Signature != nil && len(callee) == 1 {
+		signature := signatureInformation(name, fn.Signature)
+		signature.Documentation = tupleResult(fn)
+		signatures = append(signatures, signature)
+	}
+	var ctors []*ast.DataConstructorDecl
+	if len(callee) == 2 {
+		if ctor, ok := doc.Table.LookupQualifiedConstructor(callee[0], name); ok {
+			ctors = append(ctors, ctor)
+		}
+	} else {
+		ctors = doc.Table.LookupConstructor(name)
+	}
+	for _, ctor := range ctors {
+		if ctor.Signature != nil && len(ctor.Signature.ParameterTypes) > 0 {
+			signatures = append(signatures, signatureInformation(name, ctor.Signature))
+		}
+	}
+	if len(signatures) == 0 {
+		return nil, nil
+	}
+	return SignatureHelp{Signatures: signatures, ActiveParameter: active}, nil
+}
+
+// signatureInformation labels a signature `name(mut Int, Int) -> Int`, each
+// parameter by its offsets in the label, since types repeat
+func signatureInformation(name string, sig *types.FunctionType) SignatureInformation {
+	var label strings.Builder
+	label.WriteString(name + "(")
+	parameters := make([]ParameterInformation, len(sig.ParameterTypes))
+	for i, param := range sig.ParameterTypes {
+		if i > 0 {
+			label.WriteString(", ")
+		}
+		start := label.Len()
+		label.WriteString(param.GetName())
+		parameters[i] = ParameterInformation{Label: [2]int{start, label.Len()}}
+	}
+	label.WriteString(")")
+	if sig.ReturnType != nil {
+		label.WriteString(" -> " + sig.ReturnType.GetName())
+	}
+	return SignatureInformation{Label: label.String(), Parameters: parameters}
+}
+
+// tupleResult documents a function returning a tuple: the tuple is one value,
+// destructured by the caller, and for an extern the Go function's results
+func tupleResult(fn *ast.FunctionDefStmt) string {
+	tuple, ok := fn.Signature.ReturnType.(types.TupleType)
+	if !ok {
+		return ""
+	}
+	names := make([]string, len(tuple.Elements))
+	for i := range names {
+		names[i] = string(rune('a' + i%26))
+	}
+	doc := fmt.Sprintf("Returns one %s value; destructure it with let (%s) = %s(...).",
+		tuple.GetName(), strings.Join(names, ", "), fn.Name)
+	if fn.IsExtern() {
+		doc += fmt.Sprintf(" The Go function returns it as %d results.", len(tuple.Elements))
+	}
+	return doc
+}
+
+// callAt returns the callee of the innermost unclosed call in before, as a name
+// or a qualified constructor (Shape.Circle), and the index of the argument the
+// end of before is in. Brackets and commas in strings and comments do not count.
+func callAt(before []byte) (callee []string, active int, ok bool) {
+	type open struct {
+		bracket byte
+		at      int
+		commas  int
+	}
+	var stack []open
+	for i := 0; i < len(before); i++ {
+		switch c := before[i]; {
+		case c == '"':
+			for i++; i < len(before) && before[i] != '"'; i++ {
+				if before[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && i+1 < len(before) && before[i+1] == '/':
+			for i < len(before) && before[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(before) && before[i+1] == '*':
+			end := strings.Index(string(before[i+2:]), "*/")
+			if end < 0 {
+				return nil, 0, false
+			}
+			i += end + 3
+		case c == '(' || c == '[' || c == '{':
+			stack = append(stack, open{bracket: c, at: i})
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case c == ',' && len(stack) > 0:
+			stack[len(stack)-1].commas++
+		}
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].bracket != '(' {
+			continue
+		}
+		rest := before[:stack[i].at]
+		name := string(rest[len(trimWord(rest)):])
+		if name == "" || unicode.IsDigit(rune(name[0])) {
+			// a parenthesized expression or a clause's parameters, not a call
+			continue
+		}
+		callee = []string{name}
+		if rest = trimWord(rest); len(rest) > 0 && rest[len(rest)-1] == '.' {
+			rest = rest[:len(rest)-1]
+			if dataType := string(rest[len(trimWord(rest)):]); dataType != "" {
+				callee = []string{dataType, name}
+			}
+		}
+		return callee, stack[i].commas, true
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,107 @@
+package lsp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const stackDeclarations = "struct Stack {}\n" +
+	"def push: (mut Stack, Int) -> Unit = (s, n) => todo()\n" +
+	"data Shape = Circle(Float) | Empty\n"
+
+// stackResult is the analysis of stackDeclarations whatever the text of the
+// document, which is cut off mid-call and so does not parse; signature help reads
+// the call from the text
+func stackResult(t *testing.T) func(source []byte) (*analyzer.Result, error) {
+	declared := analyzed(t, stackDeclarations)
+	return func(source []byte) (*analyzer.Result, error) {
+		result := *declared
+		result.Source = source
+		return &result, nil
+	}
+}
+
+func TestServer_SignatureHelp(t *testing.T) {
+	texts := []string{
+		`push(stack, "(,"`,           // in the second argument, past a string
+		"push((1 + 2)\n\t// (, \n\t", // in the first, past a parenthesized expression and a comment
+		"let s: Shape = Shape.Circle(",
+		"def f: (Int) -> Int = (n",
+		"Empty(",
+	}
+	var messages []any
+	messages = append(messages, call(1, "initialize", map[string]any{}))
+	for i, text := range texts {
+		uri := testURI + string(rune('a'+i))
+		lines := strings.Split(text, "\n")
+		last := len(lines) - 1
+		messages = append(messages,
+			notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Text: text}}),
+			call(i+2, "textDocument/signatureHelp", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: Position{Line: last, Character: len(lines[last])}}))
+	}
+	messages = append(messages, notify("exit", nil))
+	responses := sessionWith(t, stackResult(t), messages...)
+
+	help := func(id int) *SignatureHelp {
+		var result *SignatureHelp
+		if err := json.Unmarshal(responses[id], &result); err != nil {
+			t.Fatalf("invalid signatureHelp result: %v", err)
+		}
+		return result
+	}
+	for id, active := range map[int]int{2: 1, 3: 0} {
+		result := help(id)
+		if result == nil || len(result.Signatures) != 1 || result.ActiveParameter != active {
+			t.Fatalf("Expected push with parameter %d active for %q. Got %+v", active, texts[id-2], result)
+		}
+		sig := result.Signatures[0]
+		first := sig.Parameters[0].Label
+		if sig.Label != "push(mut Stack, Int) -> Unit" || sig.Label[first[0]:first[1]] != "mut Stack" {
+			t.Fatalf("Expected push's signature with its modifier. Got %+v", sig)
+		}
+	}
+	if result := help(4); result == nil || result.Signatures[0].Label != "Circle(Float) -> Shape" {
+		t.Fatalf("Expected Shape.Circle's signature. Got %+v", result)
+	}
+	for _, id := range []int{5, 6} {
+		if result := help(id); result != nil {
+			t.Fatalf("Expected no signature help for %q. Got %+v", texts[id-2], result)
+		}
+	}
+}
+
+func TestServer_SignatureHelpTupleResult(t *testing.T) {
+	// extern def divmod: (Int, Int) -> (Int, Int) = "go:divmod"
+	divmodResult := func(source []byte) (*analyzer.Result, error) {
+		intType := types.PrimitiveType{Name: types.Int}
+		table := symbols.NewSymbolTable()
+		table.RegisterFunction(&ast.FunctionDefStmt{Name: "divmod", Extern: "go:divmod", Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}},
+			ReturnType:     types.TupleType{Elements: []types.Type{intType, intType}},
+		}})
+		return &analyzer.Result{Source: source, Program: &ast.Program{}, Table: table}, nil
+	}
+	text := "let (q, r) = divmod(7, "
+	responses := sessionWith(t, divmodResult,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: testURI, Text: text}}),
+		call(2, "textDocument/signatureHelp", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{Line: 0, Character: len(text)}}),
+		notify("exit", nil),
+	)
+
+	var result *SignatureHelp
+	if err := json.Unmarshal(responses[2], &result); err != nil || result == nil || len(result.Signatures) != 1 {
+		t.Fatalf("Expected divmod's signature. Got %s", responses[2])
+	}
+	sig := result.Signatures[0]
+	expected := "Returns one (Int, Int) value; destructure it with let (a, b) = divmod(...). The Go function returns it as 2 results."
+	if sig.Label != "divmod(Int, Int) -> (Int, Int)" || sig.Documentation != expected || result.ActiveParameter != 1 {
+		t.Fatalf("Expected divmod's tuple result documented. Got %+v", result)
+	}
+}
@@ -0,0 +1,185 @@
+package lsp
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/apidiff"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// defaultRecentDocuments is how many closed documents keep their full analysis
+// unless the client sets initializationOptions.recentDocuments
+const defaultRecentDocuments = 16
+
+// documentStore holds the analysis of the documents the server has seen. Open
+// documents and the most recently used closed ones, up to capacity, keep their
+// full analysis; older closed documents keep only their public API and are
+// analyzed again from disk when next needed.
+type documentStore struct {
+	capacity  int
+	open      map[string]*analyzer.Result
+	recent    *list.List // of *closedDocument, most recently used first
+	closed    map[string]*list.Element
+	summaries map[string]apidiff.API
+
+	reloads   int
+	evictions int
+}
+
+type closedDocument struct {
+	uri    string
+	result *analyzer.Result
+}
+
+func newDocumentStore(capacity int) *documentStore {
+	return &documentStore{
+		capacity:  capacity,
+		open:      make(map[string]*analyzer.Result),
+		recent:    list.New(),
+		closed:    make(map[string]*list.Element),
+		summaries: make(map[string]apidiff.API),
+	}
+}
+
+// put records the analysis of an open document
+func (d *documentStore) put(uri string, result *analyzer.Result) {
+	d.forget(uri)
+	d.open[uri] = result
+}
+
+// close keeps the analysis of a document the client closed among the recent ones
+func (d *documentStore) close(uri string) {
+	result, ok := d.open[uri]
+	if !ok {
+		return
+	}
+	delete(d.open, uri)
+	d.keep(uri, result)
+}
+
+// get returns the full analysis of an open or recently used document
+func (d *documentStore) get(uri string) (*analyzer.Result, bool) {
+	if result, ok := d.open[uri]; ok {
+		return result, true
+	}
+	if element, ok := d.closed[uri]; ok {
+		d.recent.MoveToFront(element)
+		return element.Value.(*closedDocument).result, true
+	}
+	return nil, false
+}
+
+// reload records the analysis of an evicted document read again from disk
+func (d *documentStore) reload(uri string, result *analyzer.Result) {
+	d.reloads++
+	d.forget(uri)
+	d.keep(uri, result)
+}
+
+// forget drops everything known about a document
+func (d *documentStore) forget(uri string) {
+	delete(d.open, uri)
+	delete(d.summaries, uri)
+	if element, ok := d.closed[uri]; ok {
+		d.recent.Remove(element)
+		delete(d.closed, uri)
+	}
+}
+
+func (d *documentStore) keep(uri string, result *analyzer.Result) {
+	d.closed[uri] = d.recent.PushFront(&closedDocument{uri: uri, result: result})
+	d.evict()
+}
+
+// evict reduces the least recently used closed documents beyond capacity to
+// what other files see of them, their pub, pub(package) and pub(module)
+// declarations
+func (d *documentStore) evict() {
+	for d.recent.Len() > d.capacity {
+		doc := d.recent.Remove(d.recent.Back()).(*closedDocument)
+		delete(d.closed, doc.uri)
+		d.evictions++
+		summary := make(apidiff.API)
+		if doc.result.Program != nil {
+			for name, symbol := range apidiff.Collect(doc.result.Program) {
+				if symbol.Visibility != ast.VisibilityFile {
+					summary[name] = symbol
+				}
+			}
+		}
+		d.summaries[doc.uri] = summary
+	}
+}
+
+// resize changes the capacity, evicting documents if it shrinks
+func (d *documentStore) resize(capacity int) {
+	d.capacity = capacity
+	d.evict()
+}
+
+func (d *documentStore) stats() DocumentStoreStats {
+	stats := DocumentStoreStats{
+		Capacity:   d.capacity,
+		Open:       len(d.open),
+		Recent:     d.recent.Len(),
+		Summarized: len(d.summaries),
+		Reloads:    d.reloads,
+		Evictions:  d.evictions,
+	}
+	for _, summary := range d.summaries {
+		stats.SummarySymbols += len(summary)
+	}
+	results := make([]*analyzer.Result, 0, len(d.open)+d.recent.Len())
+	for _, result := range d.open {
+		results = append(results, result)
+	}
+	for e := d.recent.Front(); e != nil; e = e.Next() {
+		results = append(results, e.Value.(*closedDocument).result)
+	}
+	for _, result := range results {
+		methods := result.Table.MethodCacheStats()
+		stats.MethodLookups += methods.Hits + methods.Misses
+		stats.MethodHits += methods.Hits
+	}
+	return stats
+}
+
+// document returns the full analysis of the latest text of a document, analyzing
+// a document whose analysis was evicted again from disk
+func (s *Server) document(uri string) (*analyzer.Result, error) {
+	if err := s.settle(uri); err != nil {
+		return nil, err
+	}
+	if doc, ok := s.documents.get(uri); ok {
+		return doc, nil
+	}
+	if _, evicted := s.documents.summaries[uri]; !evicted {
+		return nil, fmt.Errorf("document not open: %s", uri)
+	}
+	path, err := uriPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := s.analyze(context.Background(), source)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing %s: %w", uri, err)
+	}
+	s.documents.reload(uri, doc)
+	return doc, nil
+}
+
+// documentStore answers lyra/documentStore: how many documents are held in full
+// and how many only by their public API, for users tuning recentDocuments
+func (s *Server) documentStore(params json.RawMessage) (any, error) {
+	s.settleAll()
+	return s.documents.stats(), nil
+}
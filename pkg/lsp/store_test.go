@@ -0,0 +1,56 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServer_EvictedDocumentReloadedFromDisk(t *testing.T) {
+	dir := t.TempDir()
+	var uris []string
+	for _, name := range []string{"a.lyra", "b.lyra"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("..."), 0o644); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+		uris = append(uris, "file://"+filepath.ToSlash(path))
+	}
+	recent := 1
+	messages := []any{call(1, "initialize", InitializeParams{InitializationOptions: &InitializationOptions{RecentDocuments: &recent}})}
+	for _, uri := range uris {
+		messages = append(messages,
+			notify("textDocument/didOpen", DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Text: "..."}}),
+			notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}))
+	}
+	messages = append(messages,
+		call(2, "lyra/documentStore", nil),
+		call(3, "textDocument/definition", TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uris[0]}, Position: Position{Line: 1, Character: 10}}),
+		call(4, "lyra/documentStore", nil),
+		notify("exit", nil))
+	responses := session(t, messages...)
+
+	var before, after DocumentStoreStats
+	if err := json.Unmarshal(responses[2], &before); err != nil {
+		t.Fatalf("invalid documentStore result: %v", err)
+	}
+	if before != (DocumentStoreStats{Capacity: 1, Recent: 1, Summarized: 1, Evictions: 1}) {
+		t.Fatalf("Expected a.lyra to be summarized once b.lyra was closed. Got %+v", before)
+	}
+
+	var definition Location
+	if err := json.Unmarshal(responses[3], &definition); err != nil {
+		t.Fatalf("invalid definition result: %v", err)
+	}
+	if definition.URI != uris[0] {
+		t.Fatalf("Expected a definition in %s. Got %+v", uris[0], definition)
+	}
+
+	if err := json.Unmarshal(responses[4], &after); err != nil {
+		t.Fatalf("invalid documentStore result: %v", err)
+	}
+	if after != (DocumentStoreStats{Capacity: 1, Recent: 1, Summarized: 1, Reloads: 1, Evictions: 2}) {
+		t.Fatalf("Expected a.lyra to be reloaded, evicting b.lyra. Got %+v", after)
+	}
+}
@@ -0,0 +1,228 @@
+package lsp
+
+// Lyra-specific requests backing the views of the editor extension: the typed
+// AST, the call graph, the trait matrix and the checker trace of a document. Results are plain JSON
+// so the extension can render them without knowing the analyzer.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/checker"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+func (s *Server) viewDocument(params json.RawMessage) (*analyzer.Result, error) {
+	var p DocumentViewParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	return s.document(p.TextDocument.URI)
+}
+
+// typedAst answers lyra/typedAst with the statements of the document as a tree
+// of nodes annotated with their checked types
+func (s *Server) typedAst(params json.RawMessage) (any, error) {
+	doc, err := s.viewDocument(params)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]TypedNode, 0, len(doc.Program.Statements))
+	for _, stmt := range doc.Program.Statements {
+		nodes = append(nodes, statementNode(stmt))
+	}
+	return nodes, nil
+}
+
+func statementNode(stmt ast.AstNode) TypedNode {
+	node := TypedNode{Kind: kindOf(stmt), Range: toRange(stmt.GetLocation())}
+	if named, ok := stmt.(ast.Named); ok {
+		node.Name = named.GetName()
+	}
+	switch s := stmt.(type) {
+	case *ast.TypeDeclStmt:
+		if s.Type != nil {
+			node.Type = s.Type.GetName()
+		}
+	case *ast.VarDeclStmt:
+		if s.Type != nil {
+			node.Type = s.Type.GetName()
+		}
+		node.add(s.Value)
+	case *ast.VarAssignStmt:
+		node.add(s.Value)
+	case *ast.FunctionDefStmt:
+		if s.Signature != nil {
+			node.Type = s.Signature.GetName()
+		}
+		for _, clause := range s.Clauses {
+			child := TypedNode{Kind: "FunctionClause", Range: toRange(clause.Location)}
+			for _, param := range clause.Parameters {
+				child.Children = append(child.Children, TypedNode{Kind: kindOf(param), Name: param.GetName(), Range: toRange(param.GetLocation())})
+			}
+			if clause.Guard != nil {
+				child.add(clause.Guard)
+			}
+			child.add(clause.Body)
+			node.Children = append(node.Children, child)
+		}
+	case *ast.ExpressionStmt:
+		node.add(s.Expression)
+	case *ast.ReturnStmt:
+		node.add(s.Value)
+	}
+	return node
+}
+
+func (n *TypedNode) add(expr ast.Expression) {
+	if expr != nil {
+		n.Children = append(n.Children, expressionNode(expr))
+	}
+}
+
+func expressionNode(expr ast.Expression) TypedNode {
+	node := TypedNode{Kind: kindOf(expr), Name: expr.GetName(), Range: toRange(expr.GetLocation())}
+	if t := expr.GetType(); t != nil {
+		node.Type = t.GetName()
+	}
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		node.add(e.Callee)
+		for _, argument := range e.Arguments {
+			node.add(argument)
+		}
+	case *ast.BinaryOpExpr:
+		node.add(e.Left)
+		node.add(e.Right)
+	case *ast.BooleanBinaryOpExpr:
+		node.add(e.Left)
+		node.add(e.Right)
+	case *ast.GuardExpr:
+		node.add(e.Condition)
+	case *ast.IfThenExpr:
+		node.add(e.Condition)
+		node.add(e.Then)
+		node.add(e.Else)
+	case *ast.IfBlockExpr:
+		node.add(e.Condition)
+		node.add(e.Then)
+		node.add(e.Else)
+	case *ast.MemberAccessExpr:
+		node.add(e.Object)
+	case *ast.StructLiteralExpr:
+		for _, field := range e.Fields {
+			node.add(field.Value)
+		}
+	case *ast.ArrayLiteralExpr:
+		for _, element := range e.Elements {
+			node.add(element)
+		}
+	case *ast.TupleLiteralExpr:
+		for _, element := range e.Elements {
+			node.add(element)
+		}
+	case *ast.LambdaExpr:
+		node.add(e.Body)
+	}
+	return node
+}
+
+// kindOf names a node by its AST type: *ast.CallExpr is CallExpr
+func kindOf(node any) string {
+	name := fmt.Sprintf("%T", node)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// callGraph answers lyra/callGraph with the functions of the document and the
+// calls between them, one edge per caller and callee holding every call site
+func (s *Server) callGraph(params json.RawMessage) (any, error) {
+	doc, err := s.viewDocument(params)
+	if err != nil {
+		return nil, err
+	}
+	graph := CallGraph{Nodes: make([]CallGraphNode, 0), Edges: make([]CallGraphEdge, 0)}
+	for _, stmt := range doc.Program.Statements {
+		if fn, ok := stmt.(*ast.FunctionDefStmt); ok {
+			graph.Nodes = append(graph.Nodes, CallGraphNode{Name: fn.Name, Range: toRange(fn.NameLocation), Public: fn.IsPublic, Extern: fn.IsExtern()})
+		}
+	}
+	if doc.Index == nil {
+		return graph, nil
+	}
+	edges := make(map[[2]string]int)
+	for _, ref := range doc.Index.All() {
+		if ref.Kind != refs.Call || ref.Target.Kind != refs.TargetFunction || ref.Enclosing == "" {
+			continue
+		}
+		key := [2]string{ref.Enclosing, ref.Target.Name}
+		i, ok := edges[key]
+		if !ok {
+			i = len(graph.Edges)
+			edges[key] = i
+			graph.Edges = append(graph.Edges, CallGraphEdge{Caller: ref.Enclosing, Callee: ref.Target.Name})
+		}
+		graph.Edges[i].Sites = append(graph.Edges[i].Sites, toRange(ref.Location))
+	}
+	return graph, nil
+}
+
+// traitMatrix answers lyra/traitMatrix with which declared types implement which
+// derivable traits, and how
+func (s *Server) traitMatrix(params json.RawMessage) (any, error) {
+	doc, err := s.viewDocument(params)
+	if err != nil {
+		return nil, err
+	}
+	matrix := TraitMatrix{Traits: checker.DerivableTraits, Rows: make([]TraitMatrixRow, 0)}
+	for _, stmt := range doc.Program.Statements {
+		decl, ok := stmt.(*ast.TypeDeclStmt)
+		if !ok {
+			continue
+		}
+		row := TraitMatrixRow{Type: decl.Name, Range: toRange(decl.Location), Implements: make(map[string]bool), Status: make(map[string]string)}
+		for _, trait := range matrix.Traits {
+			status := checker.TraitStatusOf(doc.Table, decl, trait)
+			row.Status[trait] = string(status)
+			row.Implements[trait] = status != checker.TraitMissing
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	return matrix, nil
+}
+
+// checkerTrace answers lyra/checkerTrace by re-checking the document with
+// tracing on. With a range, only decisions about expressions inside it are kept.
+func (s *Server) checkerTrace(params json.RawMessage) (any, error) {
+	var p CheckerTraceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	traced, err := s.analyzeTraced(doc.Source)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TraceItem, 0, len(traced.Trace))
+	for _, entry := range traced.Trace {
+		r := toRange(entry.Location)
+		if p.Range != nil && !within(r, *p.Range) {
+			continue
+		}
+		items = append(items, TraceItem{
+			Depth: entry.Depth, Rule: entry.Rule, Node: entry.Node, Range: r,
+			Expected: entry.Expected, Result: entry.Result, Bindings: entry.Bindings, Note: entry.Note,
+		})
+	}
+	return items, nil
+}
+
+// within reports whether inner lies inside outer
+func within(inner, outer Range) bool {
+	return !before(inner.Start, outer.Start) && !before(outer.End, inner.End)
+}
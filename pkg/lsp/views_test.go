@@ -0,0 +1,76 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+)
+
+const viewsSource = "@derive(Serialize) struct Point {}\n" +
+	"def one: () -> Int = () => 1\n" +
+	"pub def two: () -> Int = () => one() + one()\n"
+
+func TestServer_Views(t *testing.T) {
+	document := DocumentViewParams{TextDocument: TextDocumentIdentifier{URI: testURI}}
+	responses := sessionWith(t, analyzer.AnalyzeTraced,
+		call(1, "initialize", map[string]any{}),
+		openDocument(testURI, viewsSource),
+		call(2, "lyra/typedAst", document),
+		call(3, "lyra/callGraph", document),
+		call(4, "lyra/traitMatrix", document),
+		call(5, "lyra/checkerTrace", CheckerTraceParams{TextDocument: document.TextDocument, Range: &Range{
+			Start: Position{Line: 2, Character: 0}, End: Position{Line: 2, Character: 50},
+		}}),
+		notify("exit", nil),
+	)
+
+	var nodes []TypedNode
+	if err := json.Unmarshal(responses[2], &nodes); err != nil {
+		t.Fatalf("invalid typedAst result: %v", err)
+	}
+	if len(nodes) != 3 || nodes[2].Kind != "FunctionDefStmt" || nodes[2].Type != "() -> Int" {
+		t.Fatalf("Expected the three statements with two typed. Got %+v", nodes)
+	}
+	body := nodes[2].Children[0].Children[0]
+	if body.Kind != "BinaryOpExpr" || body.Type != "Int" || len(body.Children) != 2 || body.Children[0].Kind != "CallExpr" {
+		t.Fatalf("Expected the typed sum of two calls. Got %+v", body)
+	}
+
+	var graph CallGraph
+	if err := json.Unmarshal(responses[3], &graph); err != nil {
+		t.Fatalf("invalid callGraph result: %v", err)
+	}
+	if len(graph.Nodes) != 2 || !graph.Nodes[1].Public {
+		t.Fatalf("Expected nodes one and two. Got %+v", graph.Nodes)
+	}
+	if len(graph.Edges) != 1 || graph.Edges[0].Caller != "two" || graph.Edges[0].Callee != "one" || len(graph.Edges[0].Sites) != 2 {
+		t.Fatalf("Expected one edge from two to one with two sites. Got %+v", graph.Edges)
+	}
+
+	var matrix TraitMatrix
+	if err := json.Unmarshal(responses[4], &matrix); err != nil {
+		t.Fatalf("invalid traitMatrix result: %v", err)
+	}
+	if len(matrix.Rows) != 1 || !matrix.Rows[0].Implements["Serialize"] || matrix.Rows[0].Implements["Deserialize"] ||
+		matrix.Rows[0].Status["Serialize"] != "derived" || matrix.Rows[0].Status["Deserialize"] != "missing" {
+		t.Fatalf("Expected Point to derive only Serialize. Got %+v", matrix)
+	}
+
+	var trace []TraceItem
+	if err := json.Unmarshal(responses[5], &trace); err != nil {
+		t.Fatalf("invalid checkerTrace result: %v", err)
+	}
+	calls := 0
+	for _, item := range trace {
+		if item.Range.Start.Line != 2 {
+			t.Fatalf("Expected only decisions on line 3. Got %+v", item)
+		}
+		if item.Node == "CallExpr one()" && item.Result == "Int" {
+			calls++
+		}
+	}
+	if calls != 2 || trace[0].Range.Start != (Position{Line: 2, Character: 31}) {
+		t.Fatalf("Expected both calls of one typed Int, the sum first. Got %+v", trace)
+	}
+}
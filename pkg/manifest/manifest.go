@@ -0,0 +1,128 @@
+package manifest
+
+/*
+Manifest reads the package manifest, lyra.json at the root of a module:
+
+	{ "name": "geometry", "version": "1.4.0" }
+
+Releases are the git tags named v<version>; lyra publish --check compares the
+public API of the manifest version with that of the last release before it.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const FileName = "lyra.json"
+
+type Manifest struct {
+	Name    string  `json:"name"`
+	Version Version `json:"version"`
+}
+
+// Load reads the manifest at path
+func Load(path string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", path, err)
+	}
+	if m.Name == "" {
+		return m, fmt.Errorf("%s: missing package name", path)
+	}
+	return m, nil
+}
+
+// Version is a semantic version without pre-release or build metadata
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// ParseVersion parses major.minor.patch, with an optional leading v
+func ParseVersion(text string) (Version, error) {
+	parts := strings.Split(strings.TrimPrefix(text, "v"), ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: expected major.minor.patch", text)
+	}
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q: %q is not a number", text, part)
+		}
+		numbers[i] = n
+	}
+	return Version{numbers[0], numbers[1], numbers[2]}, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 as v is lower than, equal to or higher than other
+func (v Version) Compare(other Version) int {
+	for _, d := range [3]int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsMajorBump reports whether v may break the API of last. Before 1.0.0 the
+// minor version is the major one, as in Cargo.
+func (v Version) IsMajorBump(last Version) bool {
+	if last.Major == 0 && v.Major == 0 {
+		return v.Minor > last.Minor
+	}
+	return v.Major > last.Major
+}
+
+func (v Version) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+func (v *Version) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return errors.New("version must be a string")
+	}
+	parsed, err := ParseVersion(text)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// LastRelease returns the highest of tags (v<version>) lower than v; tags that are
+// not versions are ignored
+func (v Version) LastRelease(tags []string) (Version, string, bool) {
+	var last Version
+	var lastTag string
+	found := false
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "v") {
+			continue
+		}
+		version, err := ParseVersion(tag)
+		if err != nil || version.Compare(v) >= 0 {
+			continue
+		}
+		if !found || version.Compare(last) > 0 {
+			last, lastTag, found = version, tag, true
+		}
+	}
+	return last, lastTag, found
+}
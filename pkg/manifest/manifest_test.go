@@ -0,0 +1,50 @@
+package manifest
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVersion_Parse(t *testing.T) {
+	var m Manifest
+	if err := json.Unmarshal([]byte(`{"name": "geometry", "version": "v1.4.2"}`), &m); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if m.Version != (Version{1, 4, 2}) {
+		t.Fatalf("Expected version 1.4.2. Got %v", m.Version)
+	}
+	for _, text := range []string{"1.4", "1.x.0", "1.-1.0"} {
+		if _, err := ParseVersion(text); err == nil {
+			t.Fatalf("Expected %q to be rejected", text)
+		}
+	}
+}
+
+func TestVersion_LastRelease(t *testing.T) {
+	tags := []string{"v0.9.0", "v1.2.0", "v1.10.0", "release-2", "v2.0.0"}
+	last, tag, ok := Version{2, 0, 0}.LastRelease(tags)
+	if !ok || last != (Version{1, 10, 0}) || tag != "v1.10.0" {
+		t.Fatalf("Expected v1.10.0. Got %v %q %v", last, tag, ok)
+	}
+	if _, _, ok := (Version{0, 1, 0}).LastRelease(tags); ok {
+		t.Fatalf("Expected no release before 0.1.0")
+	}
+}
+
+func TestVersion_IsMajorBump(t *testing.T) {
+	cases := []struct {
+		last, next Version
+		major      bool
+	}{
+		{Version{1, 2, 0}, Version{2, 0, 0}, true},
+		{Version{1, 2, 0}, Version{1, 3, 0}, false},
+		{Version{0, 2, 1}, Version{0, 3, 0}, true},
+		{Version{0, 2, 1}, Version{0, 2, 2}, false},
+		{Version{0, 2, 1}, Version{1, 0, 0}, true},
+	}
+	for _, c := range cases {
+		if got := c.next.IsMajorBump(c.last); got != c.major {
+			t.Fatalf("Expected %v -> %v major bump %v. Got %v", c.last, c.next, c.major, got)
+		}
+	}
+}
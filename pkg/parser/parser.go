@@ -7,14 +7,46 @@ import (
 	sitter "github.com/tree-sitter/go-tree-sitter"
 )
 
-func Parse(text string) (*sitter.Tree, error) {
-	language := sitter.NewLanguage(lyra_parser.Language())
-	if language == nil {
+// Language loads the Lyra tree-sitter grammar
+func Language() (*sitter.Language, error) {
+	grammar := lyra_parser.Language()
+	if grammar == nil {
 		return nil, errors.New("failed to load lyra grammar")
 	}
+	return sitter.NewLanguage(grammar), nil
+}
+
+func Parse(text string) (*sitter.Tree, error) {
+	language, err := Language()
+	if err != nil {
+		return nil, err
+	}
 	parser := sitter.NewParser()
 	if err := parser.SetLanguage(language); err != nil {
 		return nil, err
 	}
 	return parser.Parse([]byte(text), nil), nil
 }
+
+// NodeKind is a node kind of the grammar: a named node (struct_type) or an
+// anonymous token ("def", "=>")
+type NodeKind struct {
+	Name  string
+	Named bool
+}
+
+// NodeKinds lists the visible node kinds of the grammar, so tools derived from
+// it (like highlighting definitions) follow the parser
+func NodeKinds() ([]NodeKind, error) {
+	language, err := Language()
+	if err != nil {
+		return nil, err
+	}
+	var kinds []NodeKind
+	for id := uint16(0); uint32(id) < language.NodeKindCount(); id++ {
+		if language.NodeKindIsVisible(id) {
+			kinds = append(kinds, NodeKind{Name: language.NodeKindForId(id), Named: language.NodeKindIsNamed(id)})
+		}
+	}
+	return kinds, nil
+}
@@ -0,0 +1,217 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// WriteTyped renders a checked program as source with every expression annotated
+// by its checked type, e.g. `def sum: (Int, Int) -> Int = (a, b) => ((a : Int) + (b : Int)) : Int`.
+// Expressions the checker could not type are annotated with `?`.
+func WriteTyped(w io.Writer, program *ast.Program) error {
+	var b strings.Builder
+	for _, stmt := range program.Statements {
+		writeTypedStatement(&b, stmt)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeTypedStatement(b *strings.Builder, stmt ast.AstNode) {
+	switch s := stmt.(type) {
+	case *ast.TypeDeclStmt:
+		b.WriteString(typeDecl(s) + "\n")
+	case *ast.VarDeclStmt:
+		declared := ""
+		if s.Type != nil {
+			declared = ": " + s.Type.GetName()
+		}
+		keyword := s.Keyword
+		if s.IsLazy {
+			keyword = "lazy " + keyword
+		}
+		fmt.Fprintf(b, "%s %s%s = %s\n", keyword, s.Name, declared, typed(s.Value))
+	case *ast.DestructuringDeclStmt:
+		fmt.Fprintf(b, "%s %s = %s\n", s.Keyword, s.Pattern.GetName(), typed(s.Value))
+	case *ast.VarAssignStmt:
+		fmt.Fprintf(b, "%s = %s\n", s.Name, typed(s.Value))
+	case *ast.FunctionDefStmt:
+		writeTypedFunction(b, s)
+	case *ast.ExpressionStmt:
+		b.WriteString(typed(s.Expression) + "\n")
+	case *ast.ReturnStmt:
+		b.WriteString("return " + typed(s.Value) + "\n")
+	}
+}
+
+func writeTypedFunction(b *strings.Builder, fn *ast.FunctionDefStmt) {
+	if visibility := symbols.VisibilityOf(fn); visibility != ast.VisibilityFile {
+		b.WriteString(visibility.String() + " ")
+	}
+	if fn.IsExtern() {
+		b.WriteString("extern ")
+	}
+	b.WriteString("def " + fn.Name)
+	if len(fn.GenericParams) > 0 {
+		b.WriteString("<" + strings.Join(fn.GenericParams, ", ") + ">")
+	}
+	signature := "?"
+	if fn.Signature != nil {
+		signature = fn.Signature.GetName()
+	}
+	b.WriteString(": " + signature + " = ")
+
+	if fn.IsExtern() {
+		b.WriteString(strconv.Quote(fn.Extern) + "\n")
+		return
+	}
+	if len(fn.Clauses) == 1 {
+		b.WriteString(clause(fn.Clauses[0]) + "\n")
+		return
+	}
+	b.WriteString("{\n")
+	for _, c := range fn.Clauses {
+		b.WriteString("  " + clause(c) + ",\n")
+	}
+	b.WriteString("}\n")
+}
+
+func clause(c *ast.FunctionClause) string {
+	parameters := make([]string, len(c.Parameters))
+	for i, parameter := range c.Parameters {
+		parameters[i] = parameter.GetName()
+	}
+	guard := ""
+	if c.Guard != nil {
+		guard = " if " + typed(c.Guard.Condition)
+	}
+	return fmt.Sprintf("(%s)%s => %s", strings.Join(parameters, ", "), guard, typed(c.Body))
+}
+
+func typeDecl(decl *ast.TypeDeclStmt) string {
+	visibility := ""
+	if v := symbols.VisibilityOf(decl); v != ast.VisibilityFile {
+		visibility = v.String() + " "
+	}
+	if decl.IsAlias && decl.Type != nil {
+		return fmt.Sprintf("%stype %s = %s", visibility, decl.Name, decl.Type.GetName())
+	}
+	switch t := decl.Type.(type) {
+	case types.StructType:
+		names := make([]string, 0, len(t.Fields))
+		for name := range t.Fields {
+			names = append(names, name)
+		}
+		// declaration order when known
+		sort.Slice(names, func(i, j int) bool {
+			a, b := decl.FieldLocations[names[i]], decl.FieldLocations[names[j]]
+			if a.StartLine != b.StartLine {
+				return a.StartLine < b.StartLine
+			}
+			if a.StartCol != b.StartCol {
+				return a.StartCol < b.StartCol
+			}
+			return names[i] < names[j]
+		})
+		fields := make([]string, len(names))
+		for i, name := range names {
+			fields[i] = name + ": " + t.Fields[name].Type.GetName()
+		}
+		return fmt.Sprintf("%sstruct %s { %s }", visibility, decl.Name, strings.Join(fields, ", "))
+	case types.DataType:
+		names := make([]string, 0, len(t.Constructors))
+		for name := range t.Constructors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Sprintf("%sdata %s = %s", visibility, decl.Name, strings.Join(names, " | "))
+	}
+	return fmt.Sprintf("%stype %s", visibility, decl.Name)
+}
+
+// typed renders an expression followed by its checked type
+func typed(expr ast.Expression) string {
+	if expr == nil {
+		return "?"
+	}
+	typeName := "?"
+	if t := expr.GetType(); t != nil {
+		typeName = t.GetName()
+	}
+	text := untyped(expr)
+	if isCompound(expr) {
+		text = "(" + text + ")"
+	}
+	return text + " : " + typeName
+}
+
+// nested renders a subexpression, parenthesized so its annotation reads unambiguously
+func nested(expr ast.Expression) string {
+	return "(" + typed(expr) + ")"
+}
+
+func isCompound(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.IntegerLiteralExpr, *ast.FloatLiteralExpr, *ast.StringLiteralExpr, *ast.RawStringLiteralExpr, *ast.BooleanLiteralExpr, *ast.IdentifierExpr:
+		return false
+	}
+	return true
+}
+
+func untyped(expr ast.Expression) string {
+	switch e := expr.(type) {
+	case *ast.BinaryOpExpr:
+		return fmt.Sprintf("%s %s %s", nested(e.Left), e.Operator, nested(e.Right))
+	case *ast.BooleanBinaryOpExpr:
+		return fmt.Sprintf("%s %s %s", nested(e.Left), e.Operator, nested(e.Right))
+	case *ast.CallExpr:
+		arguments := make([]string, len(e.Arguments))
+		for i, argument := range e.Arguments {
+			arguments[i] = typed(argument)
+			if modifier := e.Modifier(i); modifier != "" {
+				arguments[i] = string(modifier) + " " + arguments[i]
+			}
+		}
+		return fmt.Sprintf("%s(%s)", e.Callee.GetName(), strings.Join(arguments, ", "))
+	case *ast.MemberAccessExpr:
+		return fmt.Sprintf("%s.%s", nested(e.Object), e.Member)
+	case *ast.IfThenExpr:
+		return fmt.Sprintf("if %s then %s else %s", nested(e.Condition), nested(e.Then), nested(e.Else))
+	case *ast.IfBlockExpr:
+		return fmt.Sprintf("if %s { %s } else { %s }", nested(e.Condition), typed(e.Then), typed(e.Else))
+	case *ast.StructLiteralExpr:
+		fields := make([]string, len(e.Fields))
+		for i, field := range e.Fields {
+			fields[i] = field.Name + ": " + typed(field.Value)
+		}
+		return fmt.Sprintf("%s { %s }", e.TypeName, strings.Join(fields, ", "))
+	case *ast.ArrayLiteralExpr:
+		elements := make([]string, len(e.Elements))
+		for i, element := range e.Elements {
+			elements[i] = typed(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *ast.TupleLiteralExpr:
+		elements := make([]string, len(e.Elements))
+		for i, element := range e.Elements {
+			elements[i] = typed(element)
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	case *ast.LambdaExpr:
+		parameters := make([]string, len(e.Parameters))
+		for i, parameter := range e.Parameters {
+			parameters[i] = parameter.GetName()
+		}
+		return "(" + strings.Join(parameters, ", ") + ") => " + typed(e.Body)
+	case *ast.GuardExpr:
+		return typed(e.Condition)
+	}
+	return expr.GetName()
+}
@@ -0,0 +1,34 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestWriteTyped(t *testing.T) {
+	intType := types.PrimitiveType{Name: types.Int}
+	typedIdent := func(name string) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{Type: intType}, Name: name}
+	}
+	sum := &ast.FunctionDefStmt{
+		Name:      "sum",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "a"}, &ast.IdentifierPattern{Name: "b"}},
+			Body:       &ast.BinaryOpExpr{ExprBase: ast.ExprBase{Type: intType}, Left: typedIdent("a"), Operator: "+", Right: typedIdent("b")},
+		}},
+	}
+	unchecked := &ast.VarDeclStmt{Keyword: "let", Name: "x", Value: &ast.IdentifierExpr{Name: "y"}}
+
+	var out strings.Builder
+	if err := WriteTyped(&out, &ast.Program{Statements: []ast.AstNode{sum, unchecked}}); err != nil {
+		t.Fatalf("WriteTyped error: %v", err)
+	}
+	expected := "def sum: (Int, Int) -> Int = (a, b) => ((a : Int) + (b : Int)) : Int\nlet x = y : ?\n"
+	if out.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
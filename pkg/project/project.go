@@ -0,0 +1,290 @@
+package project
+
+/*
+Project is the model of a multi-file Lyra project: the .lyra files under its
+root, the nearest directory at or above the working directory holding lyra.toml,
+the package manifest lyra.json or a .git directory. Each directory is a module.
+A file sees its own declarations, then the functions and types the other files
+of its module declare pub, pub(package) or pub(module), then what it imports
+with use from other modules, which declare it pub or pub(package); anything else
+it declares is private to it. Declaring the same visible name in two files of a
+module is a conflict reported in both, with the location of the other
+declaration.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/analyzer/collector"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/diagnostics"
+	"github.com/Lyra-Language/lyra/pkg/manifest"
+)
+
+// ConfigFile marks the root of a project
+const ConfigFile = "lyra.toml"
+
+// rootMarkers are the entries that make a directory the root of a project, the
+// first found going up from the start winning
+var rootMarkers = []string{ConfigFile, manifest.FileName, ".git"}
+
+// ErrNoRoot is returned by FindRoot when no directory up to the file system
+// root holds a root marker
+var ErrNoRoot = errors.New("no lyra.toml, lyra.json or .git found")
+
+type Project struct {
+	Root      string
+	Files     []*File                         // in path order
+	Modules   map[string]*symbols.SymbolTable // the declarations each module makes visible to its files, by path
+	Conflicts []Conflict                      // by module and name
+}
+
+// Conflict is a visible name declared by more than one file of a module
+type Conflict struct {
+	Module       string
+	Name         string
+	Declarations []Declaration // in path order; the module keeps the first
+}
+
+// Declaration is where a file declares a name
+type Declaration struct {
+	Path     string
+	Location ast.Location // of the name
+}
+
+// File is a source file of a project with its analysis against the symbols it sees
+type File struct {
+	Path   string
+	Module string // dotted path of the directory holding the file, "" at the root
+	Result *analyzer.Result
+}
+
+// FindRoot returns the nearest directory at or above start holding a root marker
+func FindRoot(start string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, marker := range rootMarkers {
+			if _, err := os.Stat(filepath.Join(dir, marker)); err == nil {
+				return dir, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("%s: %w", start, ErrNoRoot)
+		}
+		dir = parent
+	}
+}
+
+// Sources lists the .lyra files under root in lexical order, skipping hidden
+// directories
+func Sources(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".lyra") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// Load finds the root of the project around dir and analyzes its sources
+// against one symbol table
+func Load(dir string) (*Project, error) {
+	root, err := FindRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := Sources(root)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*analyzer.Collected, len(paths))
+	for i, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if files[i], err = analyzer.Collect(source); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return Link(root, paths, files), nil
+}
+
+// Link checks collected files, at paths, each against the symbols it sees: its
+// own declarations, then the public declarations of the other files of its
+// module (the directory holding it) and last those of other modules it imports
+// with use, so private declarations stay in their file. A public name declared
+// by several files of a module is a conflict: the module keeps the first
+// declaration, in path order, and every file declaring the name reports the
+// others with their locations.
+func Link(root string, paths []string, files []*analyzer.Collected) *Project {
+	project := &Project{Root: root, Modules: make(map[string]*symbols.SymbolTable)}
+	modules := make([]string, len(files))
+	visible := make([]*symbols.SymbolTable, len(files))
+	type moduleName struct{ module, name string }
+	declarations := make(map[moduleName][]Declaration)
+	declaredBy := make(map[moduleName][]int) // the files declaring each name
+	for i, file := range files {
+		modules[i], visible[i] = project.moduleOf(paths[i]), file.Table.VisibleAt(ast.VisibilityModule)
+		if _, ok := project.Modules[modules[i]]; !ok {
+			project.Modules[modules[i]] = symbols.NewSymbolTable()
+		}
+		project.Modules[modules[i]].Merge(visible[i])
+		for name, node := range visible[i].GlobalScope.Symbols {
+			key := moduleName{modules[i], name}
+			declarations[key] = append(declarations[key], Declaration{Path: paths[i], Location: nameLocation(node)})
+			declaredBy[key] = append(declaredBy[key], i)
+		}
+	}
+	for key, declared := range declarations {
+		if len(declared) > 1 {
+			project.Conflicts = append(project.Conflicts, Conflict{Module: key.module, Name: key.name, Declarations: declared})
+		}
+	}
+	sort.Slice(project.Conflicts, func(i, j int) bool {
+		a, b := project.Conflicts[i], project.Conflicts[j]
+		return a.Module < b.Module || a.Module == b.Module && a.Name < b.Name
+	})
+	for _, conflict := range project.Conflicts {
+		for i, declaration := range conflict.Declarations {
+			file := files[declaredBy[moduleName{conflict.Module, conflict.Name}][i]]
+			message := fmt.Sprintf("symbol %q already defined in %s", conflict.Name, project.position(conflict.Declarations[0]))
+			if i == 0 {
+				others := make([]string, 0, len(conflict.Declarations)-1)
+				for _, other := range conflict.Declarations[1:] {
+					others = append(others, project.position(other))
+				}
+				message = fmt.Sprintf("symbol %q is also defined in %s", conflict.Name, strings.Join(others, ", "))
+			}
+			file.Errors = append(file.Errors, collector.Error{Code: diagnostics.DuplicateDeclaration, Location: declaration.Location, Message: message})
+		}
+	}
+	for i, file := range files {
+		siblings := symbols.NewSymbolTable()
+		for j := range files {
+			if j != i && modules[j] == modules[i] {
+				siblings.Merge(visible[j])
+			}
+		}
+		table := symbols.Layer(file.Table, siblings, project.imports(file), nil)
+		project.Files = append(project.Files, &File{Path: paths[i], Module: modules[i], Result: file.CheckWith(table)})
+	}
+	return project
+}
+
+// imports returns the symbols a file brings in with use, reporting those no
+// module of the project declares, or declares pub(module)
+func (p *Project) imports(file *analyzer.Collected) *symbols.SymbolTable {
+	imported := symbols.NewSymbolTable()
+	for _, stmt := range file.Program.Statements {
+		use, ok := stmt.(*ast.UseStmt)
+		if !ok {
+			continue
+		}
+		module, ok := p.Modules[use.ModulePath()]
+		if !ok {
+			file.Errors = append(file.Errors, collector.Error{Code: diagnostics.UndefinedName, Location: use.Location,
+				Message: fmt.Sprintf("no module %s in the project", use.ModulePath())})
+			continue
+		}
+		node, ok := module.GlobalScope.Symbols[use.Name]
+		if !ok {
+			file.Errors = append(file.Errors, collector.Error{Code: diagnostics.UndefinedName, Location: use.NameLocation,
+				Message: fmt.Sprintf("%s has no public symbol %s", use.ModulePath(), use.Name)})
+			continue
+		}
+		if symbols.VisibilityOf(node) < ast.VisibilityPackage {
+			file.Errors = append(file.Errors, collector.Error{Code: diagnostics.UndefinedName, Location: use.NameLocation,
+				Message: fmt.Sprintf("%s.%s is pub(module), visible only in module %s", use.ModulePath(), use.Name, use.ModulePath())})
+			continue
+		}
+		imported.Import(module, use.Name)
+	}
+	return imported
+}
+
+// moduleOf returns the dotted path of the directory holding path relative to
+// the root, as use names modules: geometry.shapes for geometry/shapes/circle.lyra
+func (p *Project) moduleOf(path string) string {
+	dir := filepath.Dir(p.relative(path))
+	if dir == "." {
+		return ""
+	}
+	return strings.ReplaceAll(filepath.ToSlash(dir), "/", ".")
+}
+
+// Dependents returns the files other than the one at path that see a name diff
+// lists, which need checking again after an edit of that file: the other files
+// of its module and the files importing the name. diff compares the visible
+// declarations of the file before and after,
+// symbols.Diff(before.VisibleAt(ast.VisibilityModule), after.VisibleAt(ast.VisibilityModule)).
+func (p *Project) Dependents(path string, diff symbols.TableDiff) []*File {
+	module := p.moduleOf(path)
+	changed := make(map[string]bool)
+	for _, name := range diff.Names() {
+		changed[name] = true
+	}
+	var dependents []*File
+	for _, file := range p.Files {
+		if file.Path == path || len(changed) == 0 {
+			continue
+		}
+		if file.Module == module {
+			dependents = append(dependents, file)
+			continue
+		}
+		for _, stmt := range file.Result.Program.Statements {
+			if use, ok := stmt.(*ast.UseStmt); ok && use.ModulePath() == module && changed[use.Name] {
+				dependents = append(dependents, file)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// position renders a declaration as path:line:col, the path relative to the root
+func (p *Project) position(d Declaration) string {
+	return fmt.Sprintf("%s:%d:%d", p.relative(d.Path), d.Location.StartLine, d.Location.StartCol)
+}
+
+// relative returns path relative to the root of the project, if it can
+func (p *Project) relative(path string) string {
+	if rel, err := filepath.Rel(p.Root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// nameLocation returns where a declaration names its symbol
+func nameLocation(node ast.Named) ast.Location {
+	switch n := node.(type) {
+	case *ast.TypeDeclStmt:
+		return n.NameLocation
+	case *ast.FunctionDefStmt:
+		return n.NameLocation
+	case *ast.VarDeclStmt:
+		return n.NameLocation
+	}
+	return node.GetLocation()
+}
@@ -0,0 +1,254 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// letFile is a collected file declaring let name: Int = value
+func letFile(name string, value ast.Expression) *analyzer.Collected {
+	decl := &ast.VarDeclStmt{
+		Keyword:      "let",
+		Name:         name,
+		NameLocation: ast.Location{StartLine: 1, StartCol: 5, EndLine: 1, EndCol: 5 + len(name)},
+		Type:         types.PrimitiveType{Name: types.Int},
+		Value:        value,
+	}
+	table := symbols.NewSymbolTable()
+	table.RegisterVariable(decl)
+	return &analyzer.Collected{Program: &ast.Program{Statements: []ast.AstNode{decl}}, Table: table}
+}
+
+// collected is a file declaring statements
+func collected(statements ...ast.AstNode) *analyzer.Collected {
+	table := symbols.NewSymbolTable()
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			table.RegisterVariable(s)
+		case *ast.FunctionDefStmt:
+			table.RegisterFunction(s)
+		}
+	}
+	return &analyzer.Collected{Program: &ast.Program{Statements: statements}, Table: table}
+}
+
+// pubAnswer is pub def name: () -> Int = () => 42
+func pubAnswer(name string) *ast.FunctionDefStmt {
+	return &ast.FunctionDefStmt{
+		Name:         name,
+		NameLocation: ast.Location{StartLine: 1, StartCol: 9, EndLine: 1, EndCol: 9 + len(name)},
+		IsPublic:     true,
+		Signature:    &types.FunctionType{ReturnType: types.PrimitiveType{Name: types.Int}},
+		Clauses:      []*ast.FunctionClause{{Body: &ast.IntegerLiteralExpr{Value: 42}}},
+	}
+}
+
+// let is let name = value, with the type the checker infers
+func let(name string, value ast.Expression) *ast.VarDeclStmt {
+	return &ast.VarDeclStmt{Keyword: "let", Name: name, NameLocation: ast.Location{StartLine: 1, StartCol: 5, EndLine: 1, EndCol: 5 + len(name)}, Value: value}
+}
+
+func fileErrors(project *Project) [][]error {
+	errs := make([][]error, len(project.Files))
+	for i, file := range project.Files {
+		errs[i] = file.Result.Errors
+	}
+	return errs
+}
+
+func TestLink_ResolvesPublicNamesAcrossTheModule(t *testing.T) {
+	answer := collected(pubAnswer("answer"))
+	call := collected(let("doubled", &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "answer"}}))
+	project := Link("/p", []string{"/p/a.lyra", "/p/b.lyra"}, []*analyzer.Collected{answer, call})
+
+	for _, file := range project.Files {
+		if len(file.Result.Errors) != 0 {
+			t.Fatalf("Expected %s to see the public declarations of its module. Got %v", file.Path, file.Result.Errors)
+		}
+	}
+	if _, ok := project.Modules[""].Functions["answer"]; !ok {
+		t.Fatalf("Expected the root module to declare answer")
+	}
+	named, _ := project.Files[1].Result.Table.GlobalScope.Lookup("answer")
+	if named != answer.Program.Statements[0] {
+		t.Fatalf("Expected answer to resolve to the declaration in a.lyra. Got %v", named)
+	}
+}
+
+func TestLink_KeepsPrivateDeclarationsInTheirFile(t *testing.T) {
+	first := letFile("answer", &ast.IntegerLiteralExpr{Value: 42})
+	second := collected(let("answer", &ast.IntegerLiteralExpr{Value: 7}), let("doubled", &ast.IdentifierExpr{Name: "answer"}))
+	third := letFile("tripled", &ast.IdentifierExpr{Name: "answer"})
+	project := Link("/p", []string{"/p/a.lyra", "/p/b.lyra", "/p/c.lyra"}, []*analyzer.Collected{first, second, third})
+
+	errs := fileErrors(project)
+	if len(errs[0]) != 0 || len(errs[1]) != 0 || len(project.Conflicts) != 0 {
+		t.Fatalf("Expected private declarations of the same name not to conflict. Got %v, %v", errs, project.Conflicts)
+	}
+	named, _ := project.Files[1].Result.Table.GlobalScope.Lookup("answer")
+	if named != second.Program.Statements[0] {
+		t.Fatalf("Expected b.lyra to see its own answer. Got %v", named)
+	}
+	if len(errs[2]) != 1 || !strings.Contains(errs[2][0].Error(), "answer") {
+		t.Fatalf("Expected answer undefined in c.lyra. Got %v", errs[2])
+	}
+}
+
+func TestLink_FileHidesModule(t *testing.T) {
+	shared := collected(pubAnswer("answer"))
+	own := collected(let("answer", &ast.IntegerLiteralExpr{Value: 7}), let("doubled", &ast.IdentifierExpr{Name: "answer"}))
+	project := Link("/p", []string{"/p/a.lyra", "/p/b.lyra"}, []*analyzer.Collected{shared, own})
+
+	table := project.Files[1].Result.Table
+	if named, _ := table.GlobalScope.Lookup("answer"); named != own.Program.Statements[0] {
+		t.Fatalf("Expected the file's answer to hide the module's. Got %v", named)
+	}
+	if _, ok := table.Functions["answer"]; ok {
+		t.Fatalf("Expected the hidden function out of the quick lookup tables")
+	}
+	if table.GlobalScope.Kind != symbols.ScopeFile || table.GlobalScope.Parent.Kind != symbols.ScopeModule {
+		t.Fatalf("Expected the file scope nested in the module scope")
+	}
+}
+
+func TestLink_Imports(t *testing.T) {
+	shapes := collected(pubAnswer("area"))
+	main := collected(
+		&ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: "area"},
+		&ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: "perimeter", NameLocation: ast.Location{StartLine: 2, StartCol: 22}},
+		&ast.UseStmt{Module: []string{"geometry", "solids"}, Name: "volume", AstBase: ast.AstBase{Location: ast.Location{StartLine: 3, StartCol: 1}}},
+		let("size", &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "area"}}),
+	)
+	other := letFile("unseen", &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "area"}})
+	project := Link("/p", []string{"/p/geometry/shapes/square.lyra", "/p/main.lyra", "/p/util/other.lyra"}, []*analyzer.Collected{shapes, main, other})
+
+	if module := project.Files[0].Module; module != "geometry.shapes" {
+		t.Fatalf("Expected square.lyra in geometry.shapes. Got %q", module)
+	}
+	errs := fileErrors(project)
+	if len(errs[1]) != 2 || !strings.Contains(errs[1][0].Error(), "2:22: geometry.shapes has no public symbol perimeter") ||
+		!strings.Contains(errs[1][1].Error(), "3:1: no module geometry.solids in the project") {
+		t.Fatalf("Expected the unknown imports reported. Got %v", errs[1])
+	}
+	if len(errs[2]) != 1 || !strings.Contains(errs[2][0].Error(), "area") {
+		t.Fatalf("Expected area undefined in util/other.lyra, which does not import it. Got %v", errs[2])
+	}
+}
+
+func TestLink_Visibility(t *testing.T) {
+	restricted := func(name string, visibility ast.Visibility) *ast.FunctionDefStmt {
+		fn := pubAnswer(name)
+		fn.IsPublic, fn.Visibility = false, visibility
+		return fn
+	}
+	shapes := collected(restricted("helper", ast.VisibilityModule), restricted("area", ast.VisibilityPackage), restricted("secret", ast.VisibilityFile))
+	sibling := collected(
+		let("size", &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "area"}}),
+		let("help", &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "helper"}}),
+	)
+	main := collected(
+		&ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: "area"},
+		&ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: "helper", NameLocation: ast.Location{StartLine: 2, StartCol: 22}},
+		&ast.UseStmt{Module: []string{"geometry", "shapes"}, Name: "secret", NameLocation: ast.Location{StartLine: 3, StartCol: 22}},
+	)
+	project := Link("/p", []string{"/p/geometry/shapes/square.lyra", "/p/geometry/shapes/circle.lyra", "/p/main.lyra"}, []*analyzer.Collected{shapes, sibling, main})
+
+	errs := fileErrors(project)
+	if len(errs[1]) != 0 {
+		t.Fatalf("Expected circle.lyra to see the pub(module) and pub(package) functions of its module. Got %v", errs[1])
+	}
+	if len(errs[2]) != 2 || !strings.Contains(errs[2][0].Error(), "2:22: geometry.shapes.helper is pub(module), visible only in module geometry.shapes") ||
+		!strings.Contains(errs[2][1].Error(), "3:22: geometry.shapes has no public symbol secret") {
+		t.Fatalf("Expected the imports of helper and secret reported. Got %v", errs[2])
+	}
+}
+
+func TestLink_ReportsDuplicatesAcrossFiles(t *testing.T) {
+	first := collected(pubAnswer("answer"))
+	second := collected(pubAnswer("answer"))
+	elsewhere := collected(pubAnswer("answer"))
+	project := Link("/p", []string{"/p/a.lyra", "/p/b.lyra", "/p/lib/c.lyra"}, []*analyzer.Collected{first, second, elsewhere})
+
+	if len(project.Conflicts) != 1 || project.Conflicts[0].Name != "answer" || len(project.Conflicts[0].Declarations) != 2 {
+		t.Fatalf("Expected answer to conflict between the files of the root module. Got %v", project.Conflicts)
+	}
+	if named := project.Modules[""].GlobalScope.Symbols["answer"]; named != first.Program.Statements[0] {
+		t.Fatalf("Expected the module to keep the first declaration")
+	}
+	errs := fileErrors(project)
+	if len(errs[0]) != 1 || !strings.Contains(errs[0][0].Error(), `symbol "answer" is also defined in b.lyra:1:9`) {
+		t.Fatalf("Expected answer reported as also defined in b.lyra. Got %v", errs[0])
+	}
+	if len(errs[1]) != 1 || !strings.Contains(errs[1][0].Error(), `symbol "answer" already defined in a.lyra:1:9`) {
+		t.Fatalf("Expected answer reported as already defined in a.lyra. Got %v", errs[1])
+	}
+	if len(errs[2]) != 0 {
+		t.Fatalf("Expected answer in another module not to conflict. Got %v", errs[2])
+	}
+}
+
+func TestFindRootAndSources(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"lyra.toml", "main.lyra", "lib/util.lyra", "lib/notes.txt", ".cache/old.lyra"} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll error: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatalf("WriteFile error: %v", err)
+		}
+	}
+
+	found, err := FindRoot(filepath.Join(root, "lib"))
+	if err != nil || found != root {
+		t.Fatalf("Expected root %s. Got %s, %v", root, found, err)
+	}
+	sources, err := Sources(root)
+	if err != nil {
+		t.Fatalf("Sources error: %v", err)
+	}
+	expected := []string{filepath.Join(root, "lib/util.lyra"), filepath.Join(root, "main.lyra")}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Fatalf("Expected %v. Got %v", expected, sources)
+	}
+}
+
+func TestProject_Dependents(t *testing.T) {
+	square := collected(pubAnswer("area"))
+	circle := collected(pubAnswer("circumference"))
+	importer := collected(&ast.UseStmt{Module: []string{"shapes"}, Name: "area"}, let("size", &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "area"}}))
+	unrelated := collected(pubAnswer("answer"))
+	paths := []string{"/p/main.lyra", "/p/shapes/circle.lyra", "/p/shapes/square.lyra", "/p/util/answer.lyra"}
+	project := Link("/p", paths, []*analyzer.Collected{importer, circle, square, unrelated})
+
+	edited := collected(pubAnswer("area"), pubAnswer("perimeter"))
+	diff := symbols.Diff(square.Table.Public(), edited.Table.Public())
+	var dependents []string
+	for _, file := range project.Dependents("/p/shapes/square.lyra", diff) {
+		dependents = append(dependents, file.Path)
+	}
+	if expected := []string{"/p/shapes/circle.lyra"}; !reflect.DeepEqual(dependents, expected) {
+		t.Fatalf("Expected only the module to see perimeter. Got %v", dependents)
+	}
+
+	diff = symbols.TableDiff{Changed: []string{"area"}}
+	dependents = nil
+	for _, file := range project.Dependents("/p/shapes/square.lyra", diff) {
+		dependents = append(dependents, file.Path)
+	}
+	if expected := []string{"/p/main.lyra", "/p/shapes/circle.lyra"}; !reflect.DeepEqual(dependents, expected) {
+		t.Fatalf("Expected the module and the importer of area. Got %v", dependents)
+	}
+	if dependents := project.Dependents("/p/shapes/square.lyra", symbols.TableDiff{}); len(dependents) != 0 {
+		t.Fatalf("Expected nothing to recheck for an unchanged API. Got %v", dependents)
+	}
+}
@@ -0,0 +1,204 @@
+package protoimport
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// scalars maps protobuf scalar types to Lyra types
+var scalars = map[string]string{
+	"double":   "Float64",
+	"float":    "Float32",
+	"int32":    "Int32",
+	"sint32":   "Int32",
+	"sfixed32": "Int32",
+	"int64":    "Int64",
+	"sint64":   "Int64",
+	"sfixed64": "Int64",
+	"uint32":   "UInt32",
+	"fixed32":  "UInt32",
+	"uint64":   "UInt64",
+	"fixed64":  "UInt64",
+	"bool":     "Bool",
+	"string":   "String",
+	"bytes":    "Array<UInt8>",
+}
+
+// Generate writes the Lyra declarations of file. Every message becomes a pub
+// struct and every enum a pub data type; nested declarations are flattened by
+// prefixing the enclosing names (Order.Item becomes OrderItem). Field numbers
+// and enum values are kept as @field(n) and @value(n) attributes. A oneof
+// becomes a data type with one constructor per case, held by a field named
+// after the oneof.
+//
+// Map fields have no Lyra equivalent yet and are reported as errors, as are
+// references to types the file does not declare (e.g. from imports).
+func Generate(file *File, source string) (string, error) {
+	g := &generator{file: file, names: make(map[string]string)}
+	g.declare("", file.Messages, file.Enums)
+
+	fmt.Fprintf(&g.out, "// Code generated by lyra import-proto from %s. DO NOT EDIT.\n", source)
+	for _, enum := range file.Enums {
+		g.enum("", enum)
+	}
+	for _, message := range file.Messages {
+		g.message("", message)
+	}
+	if len(g.errors) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(g.errors, "\n"))
+	}
+	return g.out.String(), nil
+}
+
+type generator struct {
+	file   *File
+	names  map[string]string // proto full name without the package -> Lyra name
+	out    strings.Builder
+	errors []string
+}
+
+// declare records the Lyra name of every message and enum before generating,
+// so references may come before declarations
+func (g *generator) declare(scope string, messages []*Message, enums []*Enum) {
+	for _, enum := range enums {
+		g.names[join(scope, enum.Name)] = lyraName(scope, enum.Name)
+	}
+	for _, message := range messages {
+		full := join(scope, message.Name)
+		g.names[full] = lyraName(scope, message.Name)
+		g.declare(full, message.Messages, message.Enums)
+	}
+}
+
+func (g *generator) enum(scope string, enum *Enum) {
+	prefix := upperSnake(enum.Name) + "_"
+	values := make([]string, len(enum.Values))
+	for i, value := range enum.Values {
+		name := value.Name
+		if rest := strings.TrimPrefix(name, prefix); rest != name && rest != "" && unicode.IsLetter(rune(rest[0])) {
+			name = rest
+		}
+		values[i] = fmt.Sprintf("@value(%d) %s", value.Number, pascal(name))
+	}
+	fmt.Fprintf(&g.out, "\npub data %s = %s\n", lyraName(scope, enum.Name), strings.Join(values, " | "))
+}
+
+func (g *generator) message(scope string, message *Message) {
+	full := join(scope, message.Name)
+	name := lyraName(scope, message.Name)
+
+	fmt.Fprintf(&g.out, "\npub struct %s {", name)
+	if len(message.Fields) > 0 || len(message.Oneofs) > 0 {
+		g.out.WriteString("\n")
+	}
+	for _, field := range message.Fields {
+		fmt.Fprintf(&g.out, "\t@field(%d) %s: %s,\n", field.Number, field.Name, g.fieldType(full, message, field))
+	}
+	for _, oneof := range message.Oneofs {
+		fmt.Fprintf(&g.out, "\t%s: %s,\n", oneof.Name, name+pascal(oneof.Name))
+	}
+	g.out.WriteString("}\n")
+
+	for _, oneof := range message.Oneofs {
+		cases := make([]string, len(oneof.Fields))
+		for i, field := range oneof.Fields {
+			cases[i] = fmt.Sprintf("@field(%d) %s(%s)", field.Number, pascal(field.Name), g.fieldType(full, message, field))
+		}
+		fmt.Fprintf(&g.out, "\npub data %s = %s\n", name+pascal(oneof.Name), strings.Join(cases, " | "))
+	}
+	for _, enum := range message.Enums {
+		g.enum(full, enum)
+	}
+	for _, nested := range message.Messages {
+		g.message(full, nested)
+	}
+}
+
+func (g *generator) fieldType(scope string, message *Message, field *Field) string {
+	if field.Map {
+		g.errorf(field, "%s.%s: map fields are not supported", message.Name, field.Name)
+		return "Unit"
+	}
+	t, ok := scalars[field.Type]
+	if !ok {
+		if t, ok = g.resolve(scope, field.Type); !ok {
+			g.errorf(field, "%s.%s: undefined type %s", message.Name, field.Name, field.Type)
+			return "Unit"
+		}
+	}
+	if field.Repeated {
+		return "Array<" + t + ">"
+	}
+	return t
+}
+
+// resolve follows protobuf scoping: a relative reference is looked up in the
+// enclosing scope, then each scope outwards; a leading dot makes it absolute
+func (g *generator) resolve(scope, ref string) (string, bool) {
+	if strings.HasPrefix(ref, ".") {
+		ref = strings.TrimPrefix(ref[1:], g.file.Package+".")
+		name, ok := g.names[ref]
+		return name, ok
+	}
+	if g.file.Package != "" {
+		if name, ok := g.names[strings.TrimPrefix(ref, g.file.Package+".")]; ok {
+			return name, true
+		}
+	}
+	for {
+		if name, ok := g.names[join(scope, ref)]; ok {
+			return name, true
+		}
+		if scope == "" {
+			return "", false
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+func (g *generator) errorf(field *Field, format string, args ...any) {
+	g.errors = append(g.errors, fmt.Sprintf("line %d: ", field.Line)+fmt.Sprintf(format, args...))
+}
+
+func join(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+// lyraName flattens a nested declaration: Order.Item.Kind becomes OrderItemKind
+func lyraName(scope, name string) string {
+	return strings.ReplaceAll(scope, ".", "") + pascal(name)
+}
+
+// pascal converts snake_case and UPPER_SNAKE names to PascalCase
+func pascal(name string) string {
+	if !strings.Contains(name, "_") && strings.ToUpper(name) != name {
+		return strings.ToUpper(name[:1]) + name[1:]
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + strings.ToLower(part[1:]))
+		}
+	}
+	return b.String()
+}
+
+// upperSnake converts PascalCase to UPPER_SNAKE, the conventional prefix of enum values
+func upperSnake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
@@ -0,0 +1,439 @@
+package protoimport
+
+/*
+Protoimport translates Protocol Buffers schemas (proto2 and proto3 .proto files)
+into Lyra type declarations so Lyra programs can be typed against existing
+service schemas. Parse reads the subset of the language that describes data:
+messages, enums and oneofs, nested to any depth. Services, options, reserved
+ranges and extensions are read and skipped.
+*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// File is a parsed .proto file
+type File struct {
+	Package  string
+	Messages []*Message
+	Enums    []*Enum
+}
+
+type Message struct {
+	Name     string
+	Fields   []*Field
+	Oneofs   []*Oneof
+	Messages []*Message
+	Enums    []*Enum
+}
+
+// Field is a message field or a oneof case
+type Field struct {
+	Name     string
+	Type     string // scalar name or message/enum reference as written
+	Number   int
+	Repeated bool
+	Map      bool // map<K, V>; Type holds the value type
+	Oneof    *Oneof
+	Line     int
+}
+
+type Oneof struct {
+	Name   string
+	Fields []*Field
+}
+
+type Enum struct {
+	Name   string
+	Values []EnumValue
+}
+
+type EnumValue struct {
+	Name   string
+	Number int
+}
+
+// Parse reads a .proto file; errors carry the line number
+func Parse(source []byte) (*File, error) {
+	p := &parser{tokens: tokenize(string(source))}
+	file, err := p.file()
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line(), err)
+	}
+	return file, nil
+}
+
+type token struct {
+	text string
+	line int
+}
+
+// tokenize splits source into identifiers (with dots), numbers, strings and
+// single punctuation characters, dropping comments
+func tokenize(source string) []token {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(source[i:], "//"):
+			for i < len(source) && source[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "/*"):
+			end := strings.Index(source[i+2:], "*/")
+			if end < 0 {
+				end = len(source) - i - 4
+			}
+			line += strings.Count(source[i:i+end+4], "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			start := i
+			for i++; i < len(source) && source[i] != c; i++ {
+				if source[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			if i > len(source) {
+				i = len(source)
+			}
+			tokens = append(tokens, token{source[start:i], line})
+		case isWordByte(c) || c == '-' && i+1 < len(source) && isWordByte(source[i+1]):
+			start := i
+			for i++; i < len(source) && (isWordByte(source[i]) || source[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{source[start:i], line})
+		case c == '.' && i+1 < len(source) && isWordByte(source[i+1]):
+			start := i // fully qualified reference
+			for i++; i < len(source) && (isWordByte(source[i]) || source[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{source[start:i], line})
+		default:
+			tokens = append(tokens, token{string(c), line})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// line is the line of the last token read, the one an error is about
+func (p *parser) line() int {
+	if p.pos > 0 {
+		return p.tokens[p.pos-1].line
+	}
+	return 1
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	text := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return text
+}
+
+func (p *parser) expect(text string) error {
+	if got := p.next(); got != text {
+		return fmt.Errorf("expected %q but got %s", text, describe(got))
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	text := p.next()
+	if text == "" || !(unicode.IsLetter(rune(text[0])) || text[0] == '_' || text[0] == '.') {
+		return "", fmt.Errorf("expected a name but got %s", describe(text))
+	}
+	return text, nil
+}
+
+func (p *parser) number() (int, error) {
+	text := p.next()
+	n, err := strconv.ParseInt(text, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number but got %s", describe(text))
+	}
+	return int(n), nil
+}
+
+func describe(text string) string {
+	if text == "" {
+		return "end of file"
+	}
+	return strconv.Quote(text)
+}
+
+func (p *parser) file() (*File, error) {
+	file := &File{}
+	for p.peek() != "" {
+		switch p.peek() {
+		case "syntax", "edition", "import", "option":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case "package":
+			p.next()
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			file.Package = name
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "message":
+			message, err := p.message()
+			if err != nil {
+				return nil, err
+			}
+			file.Messages = append(file.Messages, message)
+		case "enum":
+			enum, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			file.Enums = append(file.Enums, enum)
+		case "service", "extend":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case ";":
+			p.next()
+		default:
+			return nil, fmt.Errorf("unexpected %s", describe(p.next()))
+		}
+	}
+	return file, nil
+}
+
+// skipStatement skips to the end of a statement: its ";" or its balanced block
+func (p *parser) skipStatement() error {
+	depth := 0
+	for {
+		switch p.next() {
+		case "":
+			return fmt.Errorf("unexpected end of file")
+		case "{":
+			depth++
+		case "}":
+			if depth--; depth == 0 {
+				return nil
+			}
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+func (p *parser) message() (*Message, error) {
+	p.next() // message
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{Name: name}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.peek() != "}" {
+		switch p.peek() {
+		case "":
+			return nil, fmt.Errorf("message %s is not closed", name)
+		case "message":
+			nested, err := p.message()
+			if err != nil {
+				return nil, err
+			}
+			message.Messages = append(message.Messages, nested)
+		case "enum":
+			enum, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			message.Enums = append(message.Enums, enum)
+		case "oneof":
+			if err := p.oneof(message); err != nil {
+				return nil, err
+			}
+		case "option", "reserved", "extensions", "extend":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case ";":
+			p.next()
+		default:
+			field, err := p.field()
+			if err != nil {
+				return nil, err
+			}
+			message.Fields = append(message.Fields, field)
+		}
+	}
+	p.next() // }
+	return message, nil
+}
+
+func (p *parser) oneof(message *Message) error {
+	p.next() // oneof
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	oneof := &Oneof{Name: name}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for p.peek() != "}" {
+		switch p.peek() {
+		case "":
+			return fmt.Errorf("oneof %s is not closed", name)
+		case "option":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+			continue
+		}
+		field, err := p.field()
+		if err != nil {
+			return err
+		}
+		field.Oneof = oneof
+		oneof.Fields = append(oneof.Fields, field)
+	}
+	p.next() // }
+	message.Oneofs = append(message.Oneofs, oneof)
+	return nil
+}
+
+// field reads [label] type name = number [options];
+func (p *parser) field() (*Field, error) {
+	field := &Field{}
+	if p.pos < len(p.tokens) {
+		field.Line = p.tokens[p.pos].line
+	}
+	switch p.peek() {
+	case "repeated":
+		field.Repeated = true
+		p.next()
+	case "optional", "required":
+		p.next()
+	}
+	if p.peek() == "map" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "<" {
+		p.pos += 2
+		if _, err := p.ident(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		field.Map = true
+	}
+	typ, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	field.Type = typ
+	if field.Map {
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+	if field.Name, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if field.Number, err = p.number(); err != nil {
+		return nil, err
+	}
+	if p.peek() == "[" {
+		if err := p.skipOptions(); err != nil {
+			return nil, err
+		}
+	}
+	return field, p.expect(";")
+}
+
+func (p *parser) enum() (*Enum, error) {
+	p.next() // enum
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	enum := &Enum{Name: name}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for p.peek() != "}" {
+		switch p.peek() {
+		case "":
+			return nil, fmt.Errorf("enum %s is not closed", name)
+		case "option", "reserved":
+			if err := p.skipStatement(); err != nil {
+				return nil, err
+			}
+			continue
+		case ";":
+			p.next()
+			continue
+		}
+		value := EnumValue{}
+		if value.Name, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		if value.Number, err = p.number(); err != nil {
+			return nil, err
+		}
+		if p.peek() == "[" {
+			if err := p.skipOptions(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+		enum.Values = append(enum.Values, value)
+	}
+	p.next() // }
+	return enum, nil
+}
+
+func (p *parser) skipOptions() error {
+	for p.next() != "]" {
+		if p.peek() == "" {
+			return fmt.Errorf("options are not closed")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package protoimport
+
+import (
+	"strings"
+	"testing"
+)
+
+const orders = `syntax = "proto3";
+package shop.v1;
+
+import "google/protobuf/empty.proto";
+
+/* An order placed by a customer */
+message Order {
+  int64 id = 1;
+  repeated Item items = 2 [packed = true];
+  Status status = 3;
+  oneof payment {
+    string card_token = 4;
+    Voucher voucher = 5;
+  }
+
+  message Item {
+    string sku = 1;
+    uint32 quantity = 2;
+  }
+  reserved 6, 7;
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_SHIPPED = 1;
+}
+
+message Voucher { bytes code = 1; }
+
+service Orders {
+  rpc Place(Order) returns (google.protobuf.Empty) { option idempotency_level = IDEMPOTENT; }
+}
+`
+
+func TestGenerate_Declarations(t *testing.T) {
+	file, err := Parse([]byte(orders))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	got, err := Generate(file, "orders.proto")
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	expected := `// Code generated by lyra import-proto from orders.proto. DO NOT EDIT.
+
+pub data Status = @value(0) Unspecified | @value(1) Shipped
+
+pub struct Order {
+	@field(1) id: Int64,
+	@field(2) items: Array<OrderItem>,
+	@field(3) status: Status,
+	payment: OrderPayment,
+}
+
+pub data OrderPayment = @field(4) CardToken(String) | @field(5) Voucher(Voucher)
+
+pub struct OrderItem {
+	@field(1) sku: String,
+	@field(2) quantity: UInt32,
+}
+
+pub struct Voucher {
+	@field(1) code: Array<UInt8>,
+}
+`
+	if got != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	if _, err := Parse([]byte("message A {\n  int32 id = ;\n}")); err == nil || err.Error() != `line 2: expected a number but got ";"` {
+		t.Fatalf("Expected a syntax error on line 2. Got %v", err)
+	}
+
+	file, err := Parse([]byte("message A {\n  map<string, int32> counts = 1;\n  google.protobuf.Timestamp at = 2;\n}"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	_, err = Generate(file, "a.proto")
+	expected := []string{
+		"line 2: A.counts: map fields are not supported",
+		"line 3: A.at: undefined type google.protobuf.Timestamp",
+	}
+	if err == nil || err.Error() != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q. Got %v", expected, err)
+	}
+}
@@ -0,0 +1,185 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+)
+
+// TupleAnnotation is a tuple type written inside an annotation
+type TupleAnnotation struct {
+	Location ast.Location
+	Type     string // spelled canonically: (Float, [Int])
+}
+
+// TupleAnnotations returns the tuple types written in the annotations of
+// top-level variables and the signatures of functions, in source order, outer
+// tuples before the tuples they hold. Tuples with typed holes are left out.
+func TupleAnnotations(source []byte, program *ast.Program) ([]TupleAnnotation, error) {
+	lines := lineStarts(source)
+	var tuples []TupleAnnotation
+	for _, stmt := range program.Statements {
+		var loc ast.Location
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			loc = s.TypeLocation
+		case *ast.FunctionDefStmt:
+			loc = s.SignatureLocation
+		}
+		if loc == (ast.Location{}) {
+			continue
+		}
+		start, end, err := offsets(source, lines, loc)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range tupleGroups(source[start:end]) {
+			tuples = append(tuples, TupleAnnotation{
+				Location: location(lines, start+group.start, start+group.end),
+				Type:     group.text,
+			})
+		}
+	}
+	return tuples, nil
+}
+
+// IntroduceAlias declares type name = tuple in program and names it in place of
+// each annotation spelling tuple, the declaration going after the use statements
+// opening the file. tuple is spelled as TupleAnnotations spells it.
+func IntroduceAlias(source []byte, program *ast.Program, table *symbols.SymbolTable, tuple, name string) ([]TextEdit, error) {
+	if _, taken := table.Types[name]; taken {
+		return nil, fmt.Errorf("type %s already exists", name)
+	}
+	tuples, err := TupleAnnotations(source, program)
+	if err != nil {
+		return nil, err
+	}
+	var edits []TextEdit
+	for _, annotation := range tuples {
+		if annotation.Type == tuple {
+			edits = append(edits, TextEdit{Location: annotation.Location, NewText: name})
+		}
+	}
+	if len(edits) == 0 {
+		return nil, fmt.Errorf("no annotation spells %s", tuple)
+	}
+	declaration := fmt.Sprintf("type %s = %s", name, tuple)
+	var after *ast.UseStmt
+	for _, stmt := range program.Statements {
+		use, ok := stmt.(*ast.UseStmt)
+		if !ok {
+			break
+		}
+		after = use
+	}
+	if after != nil {
+		end := ast.Location{StartLine: after.Location.EndLine, StartCol: after.Location.EndCol, EndLine: after.Location.EndLine, EndCol: after.Location.EndCol}
+		return append([]TextEdit{{Location: end, NewText: "\n\n" + declaration}}, edits...), nil
+	}
+	start := ast.Location{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 1}
+	return append([]TextEdit{{Location: start, NewText: declaration + "\n\n"}}, edits...), nil
+}
+
+// tupleGroup is a tuple type within an annotation, by byte offsets into it
+type tupleGroup struct {
+	start, end int
+	text       string
+}
+
+// tupleGroups finds the parenthesized types of an annotation holding more than
+// one element at their top level. Parentheses followed by -> are the parameters
+// of a function type rather than a tuple.
+func tupleGroups(annotation []byte) []tupleGroup {
+	var groups []tupleGroup
+	var open []int // offsets of the parentheses not closed yet
+	for i, c := range annotation {
+		switch c {
+		case '(':
+			open = append(open, i)
+		case ')':
+			if len(open) == 0 {
+				continue
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			rest := strings.TrimLeft(string(annotation[i+1:]), " \t\r\n")
+			if strings.HasPrefix(rest, "->") || !topLevelComma(annotation[start+1:i]) {
+				continue
+			}
+			text := canonicalTuple(string(annotation[start : i+1]))
+			if strings.Contains(text, "?") || holeName(text) {
+				continue
+			}
+			groups = append(groups, tupleGroup{start, i + 1, text})
+		}
+	}
+	// found as they close, so inner tuples come first
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].start < groups[j].start })
+	return groups
+}
+
+// topLevelComma reports whether the inside of parentheses separates elements,
+// with a comma outside any nested parentheses, brackets or angle brackets
+func topLevelComma(inside []byte) bool {
+	depth := 0
+	for i, c := range inside {
+		switch {
+		case c == '(' || c == '[' || c == '<':
+			depth++
+		case c == ')' || c == ']' || c == '>' && (i == 0 || inside[i-1] != '-'):
+			depth--
+		case c == ',' && depth == 0:
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalTuple spells a tuple type without spaces but those separating words,
+// after commas and around arrows
+func canonicalTuple(text string) string {
+	word := func(c byte) bool {
+		return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			j := i
+			for j < len(text) && strings.IndexByte(" \t\r\n", text[j]) >= 0 {
+				j++
+			}
+			if b.Len() > 0 && word(b.String()[b.Len()-1]) && j < len(text) && word(text[j]) {
+				b.WriteByte(' ')
+			}
+			i = j - 1
+		case c == ',':
+			b.WriteString(", ")
+		case c == '-' && i+1 < len(text) && text[i+1] == '>':
+			b.WriteString(" -> ")
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// holeName reports whether a tuple type holds _, a typed hole
+func holeName(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' {
+			continue
+		}
+		before := i == 0 || strings.IndexByte("(, [<", text[i-1]) >= 0
+		after := i+1 == len(text) || strings.IndexByte("), ]>", text[i+1]) >= 0
+		if before && after {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestTupleAnnotations(t *testing.T) {
+	source := `let origin: (Float,  Float) = zero()
+def split: ((Float, Float), Int) -> (Int, [(Float, Float)]) = (p, n) => cut(p, n)
+def add: (Int, Int) -> Int = (a, b) => a + b
+let later: (_, Int) = pair()
+`
+	program := &ast.Program{Statements: []ast.AstNode{
+		&ast.VarDeclStmt{Name: "origin", TypeLocation: span(1, 13, 15)},
+		&ast.FunctionDefStmt{Name: "split", SignatureLocation: span(2, 12, 48)},
+		&ast.FunctionDefStmt{Name: "add", SignatureLocation: span(3, 10, 17)},
+		&ast.VarDeclStmt{Name: "later", TypeLocation: span(4, 12, 8)},
+	}}
+
+	tuples, err := TupleAnnotations([]byte(source), program)
+	if err != nil {
+		t.Fatalf("TupleAnnotations error: %v", err)
+	}
+	expected := []TupleAnnotation{
+		{Location: span(1, 13, 15), Type: "(Float, Float)"},
+		{Location: span(2, 13, 14), Type: "(Float, Float)"},
+		{Location: span(2, 37, 23), Type: "(Int, [(Float, Float)])"},
+		{Location: span(2, 44, 14), Type: "(Float, Float)"},
+	}
+	if len(tuples) != len(expected) {
+		t.Fatalf("Expected %v. Got %v", expected, tuples)
+	}
+	for i := range expected {
+		if tuples[i] != expected[i] {
+			t.Fatalf("Expected %v at %d. Got %v", expected[i], i, tuples[i])
+		}
+	}
+}
+
+func TestIntroduceAlias(t *testing.T) {
+	source := `use geometry.Angle
+
+let origin: (Float, Float) = zero()
+def scale: ((Float,Float), Float) -> (Float, Float) = (p, k) => mul(p, k)
+`
+	program := &ast.Program{Statements: []ast.AstNode{
+		&ast.UseStmt{AstBase: ast.AstBase{Location: span(1, 1, 18)}, Module: []string{"geometry"}, Name: "Angle"},
+		&ast.VarDeclStmt{Name: "origin", TypeLocation: span(3, 13, 14)},
+		&ast.FunctionDefStmt{Name: "scale", SignatureLocation: span(4, 12, 40)},
+	}}
+
+	edits, err := IntroduceAlias([]byte(source), program, symbols.NewSymbolTable(), "(Float, Float)", "Point")
+	if err != nil {
+		t.Fatalf("IntroduceAlias error: %v", err)
+	}
+	result, err := Apply([]byte(source), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := `use geometry.Angle
+
+type Point = (Float, Float)
+
+let origin: Point = zero()
+def scale: (Point, Float) -> Point = (p, k) => mul(p, k)
+`
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestIntroduceAlias_NameTaken(t *testing.T) {
+	source := "let origin: (Float, Float) = zero()\n"
+	program := &ast.Program{Statements: []ast.AstNode{
+		&ast.VarDeclStmt{Name: "origin", TypeLocation: span(1, 13, 14)},
+	}}
+	table := symbols.NewSymbolTable()
+	table.RegisterType(&ast.TypeDeclStmt{Name: "Point", Type: types.StructType{Name: "Point"}})
+
+	if _, err := IntroduceAlias([]byte(source), program, table, "(Float, Float)", "Point"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("Expected an error for a taken name. Got %v", err)
+	}
+	edits, err := IntroduceAlias([]byte(source), program, table, "(Float, Float)", "Vec")
+	if err != nil {
+		t.Fatalf("IntroduceAlias error: %v", err)
+	}
+	result, err := Apply([]byte(source), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if expected := "type Vec = (Float, Float)\n\nlet origin: Vec = zero()\n"; string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
@@ -0,0 +1,72 @@
+package refactor
+
+import (
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// CanonicalType spells t the way annotations are canonically written: as the type
+// printer does, with the arguments of generic types separated by ", " and no
+// other space inside the angle brackets
+func CanonicalType(t types.Type) string {
+	name := t.GetName()
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '<':
+			depth++
+		case c == '>' && depth > 0 && (i == 0 || name[i-1] != '-'):
+			depth--
+		case depth > 0 && (c == ' ' || c == '\t' || c == '\n'):
+			continue
+		case depth > 0 && c == ',':
+			b.WriteString(", ")
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// CanonicalAnnotations rewrites the annotations of top-level variables and the
+// signatures of functions that are not spelled canonically. Annotations the
+// printer cannot spell, such as typed holes, are left as written.
+func CanonicalAnnotations(source []byte, program *ast.Program) ([]TextEdit, error) {
+	var edits []TextEdit
+	rewrite := func(t types.Type, loc ast.Location) error {
+		if t == nil || loc == (ast.Location{}) {
+			return nil
+		}
+		canonical := CanonicalType(t)
+		if strings.Contains(canonical, "?") {
+			return nil
+		}
+		text, err := Text(source, loc)
+		if err != nil {
+			return err
+		}
+		if text != canonical {
+			edits = append(edits, TextEdit{Location: loc, NewText: canonical})
+		}
+		return nil
+	}
+	for _, stmt := range program.Statements {
+		var err error
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			err = rewrite(s.Type, s.TypeLocation)
+		case *ast.FunctionDefStmt:
+			if s.Signature != nil {
+				err = rewrite(*s.Signature, s.SignatureLocation)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return edits, nil
+}
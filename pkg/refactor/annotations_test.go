@@ -0,0 +1,46 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+func TestCanonicalAnnotations(t *testing.T) {
+	source := `let pairs: Box<Int,Array< Int >> = make()
+def add: (Int,Int)->Int = (a, b) => a + b
+let ok: Int = 1
+let later: _ = 1
+`
+	program := &ast.Program{Statements: []ast.AstNode{
+		&ast.VarDeclStmt{Name: "pairs", Type: types.UnresolvedType{Name: "Box<Int,Array< Int >>"}, TypeLocation: span(1, 12, 21)},
+		&ast.FunctionDefStmt{
+			Name:              "add",
+			Signature:         &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}}, ReturnType: intType},
+			SignatureLocation: span(2, 10, 14),
+		},
+		&ast.VarDeclStmt{Name: "ok", Type: intType, TypeLocation: span(3, 9, 3)},
+		&ast.VarDeclStmt{Name: "later", Type: types.HoleType{}, TypeLocation: span(4, 12, 1)},
+	}}
+
+	edits, err := CanonicalAnnotations([]byte(source), program)
+	if err != nil {
+		t.Fatalf("CanonicalAnnotations error: %v", err)
+	}
+	result, err := Apply([]byte(source), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := `let pairs: Box<Int, Array<Int>> = make()
+def add: (Int, Int) -> Int = (a, b) => a + b
+let ok: Int = 1
+let later: _ = 1
+`
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+	if len(edits) != 2 {
+		t.Fatalf("Expected only the two non-canonical annotations rewritten. Got %v", edits)
+	}
+}
@@ -0,0 +1,279 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// clause ranks: clauses are tried in order, so the most specific go first
+const (
+	literalClause  = iota // matches literal values, e.g. (0)
+	refinedClause         // has a guard or destructures its parameters
+	catchAllClause        // binds every parameter to a name, without a guard
+)
+
+// SortClauses reorders the clauses of fn so that clauses matching literals come
+// first and the catch-all last, keeping the order of clauses of the same kind,
+// and lays the clause block out one clause per line. It returns no edits if the
+// clauses are already in order. A warning is returned for each guarded clause
+// that a clause it overlaps with would now be tried before: inputs its guard
+// used to take may go to the other clause instead.
+func SortClauses(source []byte, fn *ast.FunctionDefStmt) ([]TextEdit, []string, error) {
+	if len(fn.Clauses) < 2 {
+		return nil, nil, nil
+	}
+	order := make([]int, len(fn.Clauses))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return clauseRank(fn.Clauses[order[i]]) < clauseRank(fn.Clauses[order[j]])
+	})
+	if sort.IntsAreSorted(order) {
+		return nil, nil, nil
+	}
+
+	var warnings []string
+	for after, moved := range order {
+		for _, passed := range order[after+1:] {
+			earlier := fn.Clauses[passed]
+			if passed < moved && earlier.Guard != nil && clausesOverlap(earlier, fn.Clauses[moved]) {
+				warnings = append(warnings, fmt.Sprintf(
+					"clause %d would run before clause %d, whose guard may also have matched its inputs", moved+1, passed+1))
+			}
+		}
+	}
+
+	lines := lineStarts(source)
+	openBrace, closeBrace, texts, err := clauseBlock(source, lines, fn)
+	if err != nil {
+		return nil, nil, err
+	}
+	prefix := indent(source, lines, fn)
+	var block strings.Builder
+	block.WriteString("{\n")
+	for _, i := range order {
+		fmt.Fprintf(&block, "%s\t%s,\n", prefix, texts[i])
+	}
+	block.WriteString(prefix + "}")
+	return []TextEdit{{Location: location(lines, openBrace, closeBrace+1), NewText: block.String()}}, warnings, nil
+}
+
+// ToClauseBlock rewrites a function written as a single clause,
+// `= (x) => x + 1`, as a block of clauses ready for another to be added
+func ToClauseBlock(source []byte, fn *ast.FunctionDefStmt) ([]TextEdit, error) {
+	if len(fn.Clauses) != 1 {
+		return nil, fmt.Errorf("%s is already a block of %d clauses", fn.Name, len(fn.Clauses))
+	}
+	lines := lineStarts(source)
+	start, end, err := offsets(source, lines, fn.Clauses[0].Location)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(strings.TrimRight(string(source[:start]), " \t\r\n"), "=") {
+		return nil, fmt.Errorf("%s is already a block of 1 clause", fn.Name)
+	}
+	text := strings.TrimSpace(string(source[start:end]))
+	prefix := indent(source, lines, fn)
+	return []TextEdit{{
+		Location: fn.Clauses[0].Location,
+		NewText:  fmt.Sprintf("{\n%s\t%s,\n%s}", prefix, text, prefix),
+	}}, nil
+}
+
+// ToSingleClause rewrites a block holding a single clause, guard included, as
+// that clause
+func ToSingleClause(source []byte, fn *ast.FunctionDefStmt) ([]TextEdit, error) {
+	if len(fn.Clauses) != 1 {
+		return nil, fmt.Errorf("%s has %d clauses", fn.Name, len(fn.Clauses))
+	}
+	lines := lineStarts(source)
+	openBrace, closeBrace, texts, err := clauseBlock(source, lines, fn)
+	if err != nil {
+		return nil, err
+	}
+	return []TextEdit{{Location: location(lines, openBrace, closeBrace+1), NewText: texts[0]}}, nil
+}
+
+// AddClauses appends clauses, given as source text, to the clauses of fn, laying
+// the block out one clause per line. A function written as a single clause is
+// turned into a block.
+func AddClauses(source []byte, fn *ast.FunctionDefStmt, clauses []string) ([]TextEdit, error) {
+	if len(fn.Clauses) == 0 {
+		return nil, fmt.Errorf("%s has no clauses", fn.Name)
+	}
+	lines := lineStarts(source)
+	prefix := indent(source, lines, fn)
+	layout := func(texts []string) string {
+		var block strings.Builder
+		block.WriteString("{\n")
+		for _, text := range texts {
+			fmt.Fprintf(&block, "%s\t%s,\n", prefix, text)
+		}
+		return block.String() + prefix + "}"
+	}
+	openBrace, closeBrace, texts, err := clauseBlock(source, lines, fn)
+	if err == nil {
+		return []TextEdit{{Location: location(lines, openBrace, closeBrace+1), NewText: layout(append(texts, clauses...))}}, nil
+	}
+	start, end, offsetErr := offsets(source, lines, fn.Clauses[0].Location)
+	if offsetErr != nil || len(fn.Clauses) != 1 || !strings.HasSuffix(strings.TrimRight(string(source[:start]), " \t\r\n"), "=") {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(source[start:end]))
+	return []TextEdit{{Location: fn.Clauses[0].Location, NewText: layout(append([]string{text}, clauses...))}}, nil
+}
+
+// clauseBlock returns the offsets of the braces around the clauses of fn and the
+// text of each clause. The block is replaced as a whole, so only whitespace and
+// commas may lie between the braces and the clauses.
+func clauseBlock(source []byte, lines []int, fn *ast.FunctionDefStmt) (openBrace, closeBrace int, texts []string, err error) {
+	texts = make([]string, len(fn.Clauses))
+	previous := 0
+	for i, clause := range fn.Clauses {
+		start, end, err := offsets(source, lines, clause.Location)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if i == 0 {
+			before := strings.TrimRight(string(source[:start]), " \t\r\n")
+			if !strings.HasSuffix(before, "{") {
+				return 0, 0, nil, fmt.Errorf("the clauses of %s are not in a { } block", fn.Name)
+			}
+			openBrace = len(before) - 1
+			previous = openBrace + 1
+		}
+		if !separator(source[previous:start]) {
+			return 0, 0, nil, fmt.Errorf("cannot rewrite the clauses of %s: there are comments between them", fn.Name)
+		}
+		texts[i] = strings.TrimSpace(string(source[start:end]))
+		previous = end
+	}
+	closeBrace = strings.IndexByte(string(source[previous:]), '}')
+	if closeBrace < 0 || !separator(source[previous:previous+closeBrace]) {
+		return 0, 0, nil, fmt.Errorf("cannot rewrite the clauses of %s: there are comments between them", fn.Name)
+	}
+	return openBrace, previous + closeBrace, texts, nil
+}
+
+// indent returns the indentation of the line fn starts on
+func indent(source []byte, lines []int, fn *ast.FunctionDefStmt) string {
+	line := string(source[lines[fn.Location.StartLine-1]:])
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// separator reports whether text holds nothing but whitespace and commas
+func separator(text []byte) bool {
+	return strings.Trim(string(text), " \t\r\n,") == ""
+}
+
+func clauseRank(clause *ast.FunctionClause) int {
+	rank := catchAllClause
+	if clause.Guard != nil {
+		rank = refinedClause
+	}
+	for _, parameter := range clause.Parameters {
+		if as, ok := parameter.(*ast.AsPattern); ok {
+			parameter = as.Pattern
+		}
+		switch p := parameter.(type) {
+		case *ast.LiteralPattern, *ast.RangePattern:
+			return literalClause
+		case *ast.StructPattern:
+			if matchesLiteral(p) {
+				return literalClause
+			}
+			rank = refinedClause
+		}
+	}
+	return rank
+}
+
+// matchesLiteral reports whether a field of pattern, at any depth, is a literal
+func matchesLiteral(pattern *ast.StructPattern) bool {
+	for _, field := range pattern.Fields {
+		inner := field.Pattern
+		if as, ok := inner.(*ast.AsPattern); ok {
+			inner = as.Pattern
+		}
+		switch p := inner.(type) {
+		case *ast.LiteralPattern:
+			return true
+		case *ast.StructPattern:
+			if matchesLiteral(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// clausesOverlap reports whether some arguments match the parameters of both clauses
+func clausesOverlap(a, b *ast.FunctionClause) bool {
+	if len(a.Parameters) != len(b.Parameters) {
+		return false
+	}
+	for i := range a.Parameters {
+		if !patternsOverlap(a.Parameters[i], b.Parameters[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func patternsOverlap(a, b ast.Pattern) bool {
+	if a == nil || b == nil {
+		return true // shorthand struct field
+	}
+	if as, ok := a.(*ast.AsPattern); ok {
+		return patternsOverlap(as.Pattern, b)
+	}
+	if as, ok := b.(*ast.AsPattern); ok {
+		return patternsOverlap(a, as.Pattern)
+	}
+	if matchesAny(a) || matchesAny(b) {
+		return true
+	}
+	switch p := a.(type) {
+	case *ast.LiteralPattern:
+		q, ok := b.(*ast.LiteralPattern)
+		return ok && p.Value == q.Value
+	case *ast.StructPattern:
+		q, ok := b.(*ast.StructPattern)
+		if !ok || p.TypeName != q.TypeName {
+			return false
+		}
+		for _, field := range p.Fields {
+			for _, other := range q.Fields {
+				if field.Name == other.Name && !patternsOverlap(field.Pattern, other.Pattern) {
+					return false
+				}
+			}
+		}
+		return true
+	case *ast.ConstructorPattern:
+		q, ok := b.(*ast.ConstructorPattern)
+		if !ok || p.Name != q.Name || len(p.Arguments) != len(q.Arguments) {
+			return false
+		}
+		for i, argument := range p.Arguments {
+			if !patternsOverlap(argument, q.Arguments[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// matchesAny reports whether pattern matches every value
+func matchesAny(pattern ast.Pattern) bool {
+	switch pattern.(type) {
+	case *ast.IdentifierPattern, *ast.WildcardPattern:
+		return true
+	}
+	return false
+}
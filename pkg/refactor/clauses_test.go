@@ -0,0 +1,167 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+func span(line, col, length int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + length}
+}
+
+func clause(location ast.Location, parameter ast.Pattern, guarded bool) *ast.FunctionClause {
+	c := &ast.FunctionClause{AstBase: ast.AstBase{Location: location}, Parameters: []ast.Pattern{parameter}}
+	if guarded {
+		c.Guard = &ast.GuardExpr{Condition: &ast.IdentifierExpr{Name: "n"}}
+	}
+	return c
+}
+
+// describeFunction builds the AST of a function with the clauses of
+//
+//	def describe: (Int) -> String = {
+//		(n) => "many",
+//		(0) => "zero",
+//		(n) if n < 0 => "negative",
+//		(1) => "one",
+//	}
+func describeFunction() *ast.FunctionDefStmt {
+	n := &ast.IdentifierPattern{Name: "n"}
+	return &ast.FunctionDefStmt{
+		AstBase: ast.AstBase{Location: ast.Location{StartLine: 1, StartCol: 1, EndLine: 6, EndCol: 2}},
+		Name:    "describe",
+		Clauses: []*ast.FunctionClause{
+			clause(span(2, 2, 13), n, false),
+			clause(span(3, 2, 13), &ast.LiteralPattern{Value: int64(0)}, false),
+			clause(span(4, 2, 26), n, true),
+			clause(span(5, 2, 12), &ast.LiteralPattern{Value: int64(1)}, false),
+		},
+	}
+}
+
+func TestSortClauses_LiteralsFirstCatchAllLast(t *testing.T) {
+	source := `def describe: (Int) -> String = {
+	(n) => "many",
+	(0) => "zero",
+	(n) if n < 0 => "negative",
+	(1) => "one"
+}
+`
+	edits, warnings, err := SortClauses([]byte(source), describeFunction())
+	if err != nil {
+		t.Fatalf("SortClauses error: %v", err)
+	}
+	result, err := Apply([]byte(source), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := `def describe: (Int) -> String = {
+	(0) => "zero",
+	(1) => "one",
+	(n) if n < 0 => "negative",
+	(n) => "many",
+}
+`
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+	if len(warnings) != 1 || warnings[0] != "clause 4 would run before clause 3, whose guard may also have matched its inputs" {
+		t.Fatalf("Expected a warning that (1) now runs before the guarded clause. Got %v", warnings)
+	}
+}
+
+func TestSortClauses_SortedOrCommented(t *testing.T) {
+	fn := describeFunction()
+	fn.Clauses = fn.Clauses[2:]
+	fn.Clauses[0], fn.Clauses[1] = fn.Clauses[1], fn.Clauses[0]
+	edits, _, err := SortClauses(nil, fn)
+	if err != nil || len(edits) != 0 {
+		t.Fatalf("Expected no edits for clauses already in order. Got %v (%v)", edits, err)
+	}
+
+	source := `def describe: (Int) -> String = {
+	(n) => "many", // the usual case
+	(0) => "zero",
+	(n) if n < 0 => "negative",
+	(1) => "one",
+}
+`
+	_, _, err = SortClauses([]byte(source), describeFunction())
+	if err == nil || err.Error() != "cannot rewrite the clauses of describe: there are comments between them" {
+		t.Fatalf("Expected comments to prevent reordering. Got %v", err)
+	}
+}
+
+func TestClauseForms_RoundTrip(t *testing.T) {
+	single := "\tdef fib: (Int) -> Int = (n) if n < 2 => n\n"
+	fn := &ast.FunctionDefStmt{
+		AstBase: ast.AstBase{Location: span(1, 2, 41)},
+		Name:    "fib",
+		Clauses: []*ast.FunctionClause{clause(span(1, 26, 17), &ast.IdentifierPattern{Name: "n"}, true)},
+	}
+	if _, err := ToSingleClause([]byte(single), fn); err == nil {
+		t.Fatalf("Expected a single clause not to convert to one")
+	}
+	edits, err := ToClauseBlock([]byte(single), fn)
+	if err != nil {
+		t.Fatalf("ToClauseBlock error: %v", err)
+	}
+	block, err := Apply([]byte(single), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := "\tdef fib: (Int) -> Int = {\n\t\t(n) if n < 2 => n,\n\t}\n"
+	if string(block) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, block)
+	}
+
+	fn.Clauses[0].Location = span(2, 3, 17)
+	if _, err := ToClauseBlock(block, fn); err == nil {
+		t.Fatalf("Expected a block not to convert to one")
+	}
+	edits, err = ToSingleClause(block, fn)
+	if err != nil {
+		t.Fatalf("ToSingleClause error: %v", err)
+	}
+	result, err := Apply(block, edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if string(result) != single {
+		t.Fatalf("Expected the single clause back:\n%s\nGot:\n%s", single, result)
+	}
+}
+
+func TestAddClauses_BlockAndSingleClause(t *testing.T) {
+	fn := describeFunction()
+	fn.Clauses = fn.Clauses[1:2]
+	fn.Clauses[0].Location = span(2, 2, 13)
+	block := "def describe: (Int) -> String = {\n\t(0) => \"zero\"\n}\n"
+	edits, err := AddClauses([]byte(block), fn, []string{"(n) => ???"})
+	if err != nil {
+		t.Fatalf("AddClauses error: %v", err)
+	}
+	result, err := Apply([]byte(block), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := "def describe: (Int) -> String = {\n\t(0) => \"zero\",\n\t(n) => ???,\n}\n"
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+
+	single := "def describe: (Int) -> String = (0) => \"zero\"\n"
+	fn.Clauses[0].Location = span(1, 33, 13)
+	edits, err = AddClauses([]byte(single), fn, []string{"(n) => ???"})
+	if err != nil {
+		t.Fatalf("AddClauses error: %v", err)
+	}
+	result, err = Apply([]byte(single), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
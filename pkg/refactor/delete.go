@@ -0,0 +1,85 @@
+package refactor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// SafeDelete deletes the declaration of a top-level function or variable, with
+// the comment lines just above it, if nothing else refers to it. Otherwise it
+// returns no edits and the references that block the deletion; a function
+// calling itself does not block its own deletion.
+func SafeDelete(source []byte, program *ast.Program, index *refs.Index, target refs.Target) ([]TextEdit, []refs.Reference, error) {
+	if target.Kind != refs.TargetFunction && target.Kind != refs.TargetVariable {
+		return nil, nil, fmt.Errorf("safe delete supports top-level functions and variables, not %s", target.Name)
+	}
+	decl := declaration(program, target)
+	if decl == nil {
+		return nil, nil, fmt.Errorf("no declaration of %s", target.Name)
+	}
+
+	var blocking []refs.Reference
+	for _, ref := range index.References(target, refs.Read, refs.Write, refs.Call) {
+		if target.Kind == refs.TargetFunction && ref.Enclosing == target.Name {
+			continue
+		}
+		blocking = append(blocking, ref)
+	}
+	if len(blocking) > 0 {
+		return nil, blocking, nil
+	}
+
+	lines := lineStarts(source)
+	start, end, err := offsets(source, lines, decl.GetLocation())
+	if err != nil {
+		return nil, nil, err
+	}
+	// the trimmed text of a one-based line, "" past the end
+	text := func(line int) string {
+		if line < 1 || line > len(lines) {
+			return ""
+		}
+		end := len(source)
+		if line < len(lines) {
+			end = lines[line]
+		}
+		return strings.TrimSpace(string(source[lines[line-1]:end]))
+	}
+	// take whole lines when the declaration has its lines to itself, along with
+	// its comment, without leaving two blank lines where it was
+	first, last := decl.GetLocation().StartLine, decl.GetLocation().EndLine
+	lineEnd := end + strings.IndexByte(string(source[end:])+"\n", '\n')
+	if strings.TrimSpace(string(source[lines[first-1]:start])) == "" && strings.TrimSpace(string(source[end:lineEnd])) == "" {
+		for first > 1 && strings.HasPrefix(text(first-1), "//") {
+			first--
+		}
+		if text(first-1) == "" && text(last+1) == "" && last < len(lines) {
+			last++
+		}
+		start, end = lines[first-1], len(source)
+		if last < len(lines) {
+			end = lines[last]
+		}
+	}
+	return []TextEdit{{Location: location(lines, start, end), NewText: ""}}, nil, nil
+}
+
+// declaration returns the top-level statement declaring target
+func declaration(program *ast.Program, target refs.Target) ast.AstNode {
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			if target.Kind == refs.TargetFunction && s.Name == target.Name {
+				return s
+			}
+		case *ast.VarDeclStmt:
+			if target.Kind == refs.TargetVariable && s.Name == target.Name {
+				return s
+			}
+		}
+	}
+	return nil
+}
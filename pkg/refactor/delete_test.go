@@ -0,0 +1,73 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const spinSource = `let used: Int = 1
+
+// loops forever
+def spin: (Int) -> Int = (n) => spin(n)
+
+let x: Int = used
+`
+
+func ident(name string, location ast.Location) *ast.IdentifierExpr {
+	return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: location}}, Name: name}
+}
+
+// spinProgram builds the AST of spinSource
+func spinProgram(t *testing.T) (*ast.Program, *refs.Index) {
+	table := symbols.NewSymbolTable()
+	used := &ast.VarDeclStmt{AstBase: ast.AstBase{Location: span(1, 1, 17)}, Keyword: "let", Name: "used", NameLocation: span(1, 5, 4), Type: intType, Value: &ast.IntegerLiteralExpr{Value: 1}}
+	spin := &ast.FunctionDefStmt{
+		AstBase:      ast.AstBase{Location: span(4, 1, 39)},
+		Name:         "spin",
+		NameLocation: span(4, 5, 4),
+		Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{PatternBase: ast.PatternBase{Location: span(4, 27, 1)}, Name: "n"}},
+			Body:       &ast.CallExpr{Callee: ident("spin", span(4, 33, 4)), Arguments: []ast.Expression{ident("n", span(4, 38, 1))}},
+		}},
+	}
+	x := &ast.VarDeclStmt{AstBase: ast.AstBase{Location: span(6, 1, 17)}, Keyword: "let", Name: "x", NameLocation: span(6, 5, 1), Type: intType, Value: ident("used", span(6, 14, 4))}
+	if err := table.RegisterFunction(spin); err != nil {
+		t.Fatalf("RegisterFunction error: %v", err)
+	}
+	program := &ast.Program{Statements: []ast.AstNode{used, spin, x}}
+	return program, refs.Build(program, table)
+}
+
+func TestSafeDelete_DeletesUnusedDeclaration(t *testing.T) {
+	program, index := spinProgram(t)
+
+	edits, blocking, err := SafeDelete([]byte(spinSource), program, index, refs.FunctionTarget("spin"))
+	if err != nil || len(blocking) != 0 {
+		t.Fatalf("Expected spin, which only calls itself, to be deleted. Got %v (%v)", blocking, err)
+	}
+	result, err := Apply([]byte(spinSource), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := "let used: Int = 1\n\nlet x: Int = used\n"
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestSafeDelete_ReportsBlockingReferences(t *testing.T) {
+	program, index := spinProgram(t)
+
+	edits, blocking, err := SafeDelete([]byte(spinSource), program, index, refs.VariableTarget("used"))
+	if err != nil {
+		t.Fatalf("SafeDelete error: %v", err)
+	}
+	if len(edits) != 0 || len(blocking) != 1 || blocking[0].Location != span(6, 14, 4) || blocking[0].Kind != refs.Read {
+		t.Fatalf("Expected the read of used in x to block deleting it. Got edits %v, blocking %v", edits, blocking)
+	}
+}
@@ -0,0 +1,82 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+)
+
+// Apply applies edits to source. Locations are one-based lines and byte columns,
+// as the collector records them; edits must not overlap.
+func Apply(source []byte, edits []TextEdit) ([]byte, error) {
+	lines := lineStarts(source)
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, edit := range edits {
+		start, end, err := offsets(source, lines, edit.Location)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span{start, end, edit.NewText})
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	result := make([]byte, 0, len(source))
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return nil, fmt.Errorf("overlapping edits at byte %d", s.start)
+		}
+		result = append(result, source[last:s.start]...)
+		result = append(result, s.text...)
+		last = s.end
+	}
+	return append(result, source[last:]...), nil
+}
+
+// Text returns the source text at loc
+func Text(source []byte, loc ast.Location) (string, error) {
+	start, end, err := offsets(source, lineStarts(source), loc)
+	if err != nil {
+		return "", err
+	}
+	return string(source[start:end]), nil
+}
+
+func lineStarts(source []byte) []int {
+	starts := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// offsets converts loc to a byte range of source
+func offsets(source []byte, lines []int, loc ast.Location) (start, end int, err error) {
+	offset := func(line, col int) (int, error) {
+		if line < 1 || line > len(lines) {
+			return 0, fmt.Errorf("line %d out of range", line)
+		}
+		o := lines[line-1] + col - 1
+		if col < 1 || o > len(source) {
+			return 0, fmt.Errorf("column %d out of range on line %d", col, line)
+		}
+		return o, nil
+	}
+	if start, err = offset(loc.StartLine, loc.StartCol); err != nil {
+		return 0, 0, err
+	}
+	if end, err = offset(loc.EndLine, loc.EndCol); err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		return 0, 0, fmt.Errorf("location %d:%d-%d:%d ends before it starts", loc.StartLine, loc.StartCol, loc.EndLine, loc.EndCol)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,147 @@
+package refactor
+
+import (
+	"fmt"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// TextEdit replaces the source text at Location with NewText
+type TextEdit struct {
+	Location ast.Location
+	NewText  string
+}
+
+// RenameField renames a struct field, returning edits for its declaration, member
+// accesses, struct literals, struct patterns and default values that refer to it
+func RenameField(table *symbols.SymbolTable, index *refs.Index, structName, oldName, newName string) ([]TextEdit, error) {
+	decl, ok := table.Types[structName]
+	if !ok {
+		return nil, fmt.Errorf("undefined type: %s", structName)
+	}
+	structType, ok := decl.Type.(types.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", structName)
+	}
+	if _, ok := structType.Fields[oldName]; !ok {
+		return nil, fmt.Errorf("struct %s has no field %s", structName, oldName)
+	}
+	if oldName == newName {
+		return nil, nil
+	}
+	if _, ok := structType.Fields[newName]; ok {
+		loc := decl.FieldLocations[newName]
+		return nil, fmt.Errorf("cannot rename %s.%s: struct %s already has a field %s at %d:%d",
+			structName, oldName, structName, newName, loc.StartLine, loc.StartCol)
+	}
+
+	references := index.References(refs.FieldTarget(structName, oldName))
+	edits := make([]TextEdit, 0, len(references))
+	for _, ref := range references {
+		newText := newName
+		if ref.Shorthand {
+			// keep the value/binding name: `{ x }` becomes `{ new_name: x }`
+			newText = fmt.Sprintf("%s: %s", newName, oldName)
+		}
+		edits = append(edits, TextEdit{Location: ref.Location, NewText: newText})
+	}
+	return edits, nil
+}
+
+// RenameSymbol renames a function, top-level variable, local binding or data
+// constructor, returning edits for its definition and every reference to it. A
+// constructor is renamed in constructions, patterns and qualified references
+// (Shape.Circle), but not where a same-named constructor of another data type is meant.
+//
+// A rename is refused, with the location of the conflicting declaration, if
+// newName is already declared where the symbol is, or if it would change what a
+// name refers to: a reference to a function or variable inside a clause binding
+// a local of the new name, or a local hiding a function or variable its clause uses.
+func RenameSymbol(table *symbols.SymbolTable, index *refs.Index, target refs.Target, newName string) ([]TextEdit, error) {
+	switch target.Kind {
+	case refs.TargetFunction, refs.TargetVariable:
+		if named, exists := table.GlobalScope.Lookup(newName); exists && newName != target.Name {
+			loc := named.GetLocation()
+			return nil, fmt.Errorf("cannot rename %s: %s is already declared at %d:%d", target.Name, newName, loc.StartLine, loc.StartCol)
+		}
+		for _, ref := range index.References(target, refs.Read, refs.Write, refs.Call) {
+			for _, local := range index.All() {
+				if local.Kind == refs.Definition && local.Target.Kind == refs.TargetLocal && local.Target.Name == newName &&
+					local.Target.Container == ref.Enclosing && sameClause(table, ref.Enclosing, ref.Location, local.Location) {
+					return nil, fmt.Errorf("cannot rename %s: the reference at %d:%d would refer to the %s bound at %d:%d",
+						target.Name, ref.Location.StartLine, ref.Location.StartCol, newName, local.Location.StartLine, local.Location.StartCol)
+				}
+			}
+		}
+	case refs.TargetLocal:
+		for _, ref := range index.All() {
+			if ref.Kind == refs.Definition && ref.Target.Kind == refs.TargetLocal &&
+				ref.Target.Container == target.Container && ref.Target.Name == newName && ref.Target != target {
+				return nil, fmt.Errorf("cannot rename %s: %s already binds %s at %d:%d",
+					target.Name, target.Container, newName, ref.Location.StartLine, ref.Location.StartCol)
+			}
+		}
+		for _, ref := range index.All() {
+			global := ref.Target.Kind == refs.TargetFunction || ref.Target.Kind == refs.TargetVariable
+			if global && ref.Kind != refs.Definition && ref.Target.Name == newName && ref.Enclosing == target.Container &&
+				sameClause(table, target.Container, target.Binding, ref.Location) {
+				return nil, fmt.Errorf("cannot rename %s: it would hide the %s used at %d:%d",
+					target.Name, newName, ref.Location.StartLine, ref.Location.StartCol)
+			}
+		}
+	case refs.TargetConstructor:
+		if ctor, exists := table.LookupQualifiedConstructor(target.Container, newName); exists && newName != target.Name {
+			loc := ctor.GetLocation()
+			return nil, fmt.Errorf("cannot rename %s.%s: %s already has a constructor %s at %d:%d",
+				target.Container, target.Name, target.Container, newName, loc.StartLine, loc.StartCol)
+		}
+	default:
+		return nil, fmt.Errorf("cannot rename %s: only functions, variables, locals and constructors can be renamed", target.Name)
+	}
+	if target.Name == newName {
+		return nil, nil
+	}
+
+	// a local bound or read through field shorthand (`{ x }`) keeps the field name;
+	// the fields of constructor patterns are only known by the local they bind
+	shorthand := make(map[ast.Location]string)
+	for _, ref := range index.All() {
+		if ref.Shorthand && (ref.Target.Kind == refs.TargetField || ref.Target.Kind == refs.TargetLocal) {
+			shorthand[ref.Location] = ref.Target.Name
+		}
+	}
+
+	references := index.References(target)
+	edits := make([]TextEdit, 0, len(references))
+	for _, ref := range references {
+		newText := newName
+		if field, ok := shorthand[ref.Location]; ok {
+			newText = fmt.Sprintf("%s: %s", field, newName)
+		}
+		edits = append(edits, TextEdit{Location: ref.Location, NewText: newText})
+	}
+	return edits, nil
+}
+
+// sameClause reports whether a and b lie in the same clause of function fn,
+// assuming they do when its clauses have no locations
+func sameClause(table *symbols.SymbolTable, fn string, a, b ast.Location) bool {
+	decl, ok := table.Functions[fn]
+	if !ok {
+		return false
+	}
+	for _, clause := range decl.Clauses {
+		if within(a, clause.Location) {
+			return within(b, clause.Location)
+		}
+	}
+	return true
+}
+
+// within reports whether loc starts inside outer
+func within(loc, outer ast.Location) bool {
+	return outer != (ast.Location{}) && !precedes(loc, outer) && !precedes(ast.Location{StartLine: outer.EndLine, StartCol: outer.EndCol}, loc)
+}
@@ -0,0 +1,317 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer/refs"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+func loc(line, col int) ast.Location {
+	return ast.Location{StartLine: line, StartCol: col, EndLine: line, EndCol: col + 1}
+}
+
+// pointProgram builds the AST of:
+//
+//	struct Point { x: Int, y: Int = x }
+//	let p: Point = Point { x: 1, y: 2 }
+//	let a: Int = p.x
+//	def get_x: (Point) -> Int = (Point { x }) => x
+func pointProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	table := symbols.NewSymbolTable()
+	pointDecl := &ast.TypeDeclStmt{
+		Name: "Point",
+		Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+			"x": {Name: "x", Type: intType},
+			"y": {Name: "y", Type: intType, DefaultValue: &ast.IdentifierExpr{
+				ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(1, 33)}},
+				Name:     "x",
+			}},
+		}},
+		FieldLocations: map[string]ast.Location{"x": loc(1, 16), "y": loc(1, 24)},
+	}
+	pDecl := &ast.VarDeclStmt{
+		Keyword: "let",
+		Name:    "p",
+		Type:    types.UnresolvedType{Name: "Point"},
+		Value: &ast.StructLiteralExpr{
+			TypeName: "Point",
+			Fields: []*ast.StructLiteralField{
+				{Name: "x", NameLocation: loc(2, 24), Value: &ast.IntegerLiteralExpr{Value: 1}},
+				{Name: "y", NameLocation: loc(2, 30), Value: &ast.IntegerLiteralExpr{Value: 2}},
+			},
+		},
+	}
+	aDecl := &ast.VarDeclStmt{
+		Keyword: "let",
+		Name:    "a",
+		Type:    intType,
+		Value: &ast.MemberAccessExpr{
+			Object:         &ast.IdentifierExpr{Name: "p"},
+			Member:         "x",
+			MemberLocation: loc(3, 16),
+		},
+	}
+	getX := &ast.FunctionDefStmt{
+		Name: "get_x",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: types.UnresolvedType{Name: "Point"}}},
+			ReturnType:     intType,
+		},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.StructPattern{
+				TypeName: "Point",
+				Fields:   []*ast.StructPatternField{{Name: "x", NameLocation: loc(4, 39)}},
+			}},
+			Body: &ast.IdentifierExpr{Name: "x"},
+		}},
+	}
+	if err := table.RegisterType(pointDecl); err != nil {
+		t.Fatalf("RegisterType error: %v", err)
+	}
+	return &ast.Program{Statements: []ast.AstNode{pointDecl, pDecl, aDecl, getX}}, table
+}
+
+func TestRenameField_UpdatesAllReferences(t *testing.T) {
+	program, table := pointProgram(t)
+	index := refs.Build(program, table)
+
+	edits, err := RenameField(table, index, "Point", "x", "left")
+	if err != nil {
+		t.Fatalf("RenameField error: %v", err)
+	}
+
+	expected := map[ast.Location]string{
+		loc(1, 16): "left",    // declaration
+		loc(1, 33): "left",    // default value of y
+		loc(2, 24): "left",    // struct literal
+		loc(3, 16): "left",    // member access
+		loc(4, 39): "left: x", // shorthand struct pattern keeps the binding
+	}
+	if len(edits) != len(expected) {
+		t.Fatalf("Expected %d edits, got %d: %v", len(expected), len(edits), edits)
+	}
+	for _, edit := range edits {
+		newText, ok := expected[edit.Location]
+		if !ok {
+			t.Fatalf("Unexpected edit at %v", edit.Location)
+		}
+		if edit.NewText != newText {
+			t.Fatalf("Edit at %v should be %q. Got %q", edit.Location, newText, edit.NewText)
+		}
+	}
+}
+
+func TestRenameField_CompoundDefaults(t *testing.T) {
+	// let w: Int = 3
+	// struct Box { w: Int, h: Int = w + 1, area: Int = w * (h - w) }
+	w := func(col int) *ast.IdentifierExpr {
+		return &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: loc(2, col)}}, Name: "w"}
+	}
+	global := &ast.VarDeclStmt{Keyword: "let", Name: "w", NameLocation: loc(1, 5), Type: intType, Value: &ast.IntegerLiteralExpr{Value: 3}}
+	boxDecl := &ast.TypeDeclStmt{
+		Name: "Box",
+		Type: types.StructType{Name: "Box", Fields: map[string]types.StructField{
+			"w": {Name: "w", Type: intType},
+			"h": {Name: "h", Type: intType, DefaultValue: &ast.BinaryOpExpr{Left: w(31), Operator: "+", Right: &ast.IntegerLiteralExpr{Value: 1}}},
+			"area": {Name: "area", Type: intType, DefaultValue: &ast.BinaryOpExpr{Left: w(50), Operator: "*",
+				Right: &ast.BinaryOpExpr{Left: &ast.IdentifierExpr{Name: "h"}, Operator: "-", Right: w(59)}}},
+		}},
+		FieldLocations: map[string]ast.Location{"w": loc(2, 14), "h": loc(2, 22), "area": loc(2, 38)},
+	}
+	table := symbols.NewSymbolTable()
+	if err := table.RegisterVariable(global); err != nil {
+		t.Fatalf("RegisterVariable error: %v", err)
+	}
+	if err := table.RegisterType(boxDecl); err != nil {
+		t.Fatalf("RegisterType error: %v", err)
+	}
+	index := refs.Build(&ast.Program{Statements: []ast.AstNode{global, boxDecl}}, table)
+
+	edits, err := RenameField(table, index, "Box", "w", "width")
+	if err != nil {
+		t.Fatalf("RenameField error: %v", err)
+	}
+	var got []ast.Location
+	for _, edit := range edits {
+		got = append(got, edit.Location)
+	}
+	expected := []ast.Location{loc(2, 14), loc(2, 31), loc(2, 50), loc(2, 59)} // declaration and the uses in defaults
+	if len(got) != len(expected) {
+		t.Fatalf("Expected edits at %v. Got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected edits at %v. Got %v", expected, got)
+		}
+	}
+	if reads := index.References(refs.VariableTarget("w"), refs.Read); len(reads) != 0 {
+		t.Fatalf("Expected the defaults to read the field, not the global w. Got %v", reads)
+	}
+}
+
+func TestRenameField_ConflictsWithExistingField(t *testing.T) {
+	program, table := pointProgram(t)
+	index := refs.Build(program, table)
+
+	_, err := RenameField(table, index, "Point", "x", "y")
+	if err == nil {
+		t.Fatalf("Expected a conflict renaming x to y")
+	}
+	expectedMessage := "cannot rename Point.x: struct Point already has a field y at 1:24"
+	if err.Error() != expectedMessage {
+		t.Fatalf("Unexpected error. Expected %q. Got %q", expectedMessage, err.Error())
+	}
+}
+
+// shapeProgram builds the AST of:
+//
+//	data Shape = Circle { r: Int } | Dot
+//	data Token = Circle | Square
+//	let c: Shape = Circle { r: 1 }
+//	let q: Shape = Shape.Circle
+//	let t: Token = Circle
+//	def radius: (Shape) -> Int = (Circle { r }) => r
+func shapeProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	table := symbols.NewSymbolTable()
+	shape := types.UnresolvedType{Name: "Shape"}
+	token := types.UnresolvedType{Name: "Token"}
+	declare := func(name string, nameLoc ast.Location, ctors map[string]ast.Location) *ast.TypeDeclStmt {
+		dataType := types.DataType{Name: name, Constructors: make(map[string]types.DataTypeConstructor)}
+		for ctor := range ctors {
+			dataType.Constructors[ctor] = types.DataTypeConstructor{Name: ctor}
+		}
+		decl := &ast.TypeDeclStmt{Name: name, NameLocation: nameLoc, Type: dataType}
+		if err := table.RegisterType(decl); err != nil {
+			t.Fatalf("RegisterType error: %v", err)
+		}
+		for ctor, loc := range ctors {
+			if err := table.RegisterConstructor(&ast.DataConstructorDecl{Name: ctor, NameLocation: loc, DataType: name}); err != nil {
+				t.Fatalf("RegisterConstructor error: %v", err)
+			}
+		}
+		return decl
+	}
+	shapeDecl := declare("Shape", span(1, 6, 5), map[string]ast.Location{"Circle": span(1, 14, 6), "Dot": span(1, 33, 3)})
+	tokenDecl := declare("Token", span(2, 6, 5), map[string]ast.Location{"Circle": span(2, 14, 6), "Square": span(2, 23, 6)})
+
+	literal := &ast.StructLiteralExpr{
+		ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: span(3, 16, 15)}},
+		TypeName: "Circle",
+		Fields:   []*ast.StructLiteralField{{Name: "r", NameLocation: span(3, 25, 1), Value: &ast.IntegerLiteralExpr{Value: 1}}},
+	}
+	literal.SetType(shape)
+	qualified := &ast.MemberAccessExpr{Object: ident("Shape", span(4, 16, 5)), Member: "Circle", MemberLocation: span(4, 22, 6)}
+	tokenCircle := ident("Circle", span(5, 16, 6))
+	tokenCircle.SetType(token)
+	radius := &ast.FunctionDefStmt{
+		Name:      "radius",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: shape}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.StructPattern{
+				PatternBase: ast.PatternBase{Location: span(6, 31, 10)},
+				TypeName:    "Circle",
+				Fields:      []*ast.StructPatternField{{Name: "r", NameLocation: span(6, 40, 1)}},
+			}},
+			Body: ident("r", span(6, 47, 1)),
+		}},
+	}
+	return &ast.Program{Statements: []ast.AstNode{
+		shapeDecl,
+		tokenDecl,
+		&ast.VarDeclStmt{Keyword: "let", Name: "c", Type: shape, Value: literal},
+		&ast.VarDeclStmt{Keyword: "let", Name: "q", Type: shape, Value: qualified},
+		&ast.VarDeclStmt{Keyword: "let", Name: "t", Type: token, Value: tokenCircle},
+		radius,
+	}}, table
+}
+
+func TestRenameSymbol_Constructor(t *testing.T) {
+	program, table := shapeProgram(t)
+	index := refs.Build(program, table)
+
+	edits, err := RenameSymbol(table, index, refs.ConstructorTarget("Shape", "Circle"), "Round")
+	if err != nil {
+		t.Fatalf("RenameSymbol error: %v", err)
+	}
+	// the declaration, the literal, the qualified reference and the pattern,
+	// but not Token's Circle on lines 2 and 5
+	expected := map[ast.Location]bool{span(1, 14, 6): true, span(3, 16, 6): true, span(4, 22, 6): true, span(6, 31, 6): true}
+	if len(edits) != len(expected) {
+		t.Fatalf("Expected %d edits. Got %v", len(expected), edits)
+	}
+	for _, edit := range edits {
+		if !expected[edit.Location] || edit.NewText != "Round" {
+			t.Fatalf("Unexpected edit %+v", edit)
+		}
+	}
+
+	if _, err := RenameSymbol(table, index, refs.ConstructorTarget("Shape", "Circle"), "Dot"); err == nil {
+		t.Fatalf("Expected renaming Circle to the existing Dot to fail")
+	}
+}
+
+// clampProgram builds the AST of:
+//
+//	let limit: Int = 10
+//	def clamp: (Int) -> Int = (n) => limit
+func clampProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	table := symbols.NewSymbolTable()
+	limit := &ast.VarDeclStmt{
+		AstBase:      ast.AstBase{Location: ast.Location{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 20}},
+		Keyword:      "let",
+		Name:         "limit",
+		NameLocation: span(1, 5, 5),
+		Type:         intType,
+		Value:        &ast.IntegerLiteralExpr{Value: 10},
+	}
+	clamp := &ast.FunctionDefStmt{
+		Name:         "clamp",
+		NameLocation: span(2, 5, 5),
+		Signature:    &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: intType}}, ReturnType: intType},
+		Clauses: []*ast.FunctionClause{{
+			AstBase: ast.AstBase{Location: ast.Location{StartLine: 2, StartCol: 27, EndLine: 2, EndCol: 39}},
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{
+				PatternBase: ast.PatternBase{Location: span(2, 28, 1)},
+				Name:        "n",
+			}},
+			Body: &ast.IdentifierExpr{ExprBase: ast.ExprBase{AstBase: ast.AstBase{Location: span(2, 34, 5)}}, Name: "limit"},
+		}},
+	}
+	if err := table.RegisterVariable(limit); err != nil {
+		t.Fatalf("RegisterVariable error: %v", err)
+	}
+	if err := table.RegisterFunction(clamp); err != nil {
+		t.Fatalf("RegisterFunction error: %v", err)
+	}
+	return &ast.Program{Statements: []ast.AstNode{limit, clamp}}, table
+}
+
+func TestRenameSymbol_RefusesCapture(t *testing.T) {
+	program, table := clampProgram(t)
+	index := refs.Build(program, table)
+
+	_, err := RenameSymbol(table, index, refs.VariableTarget("limit"), "n")
+	if err == nil || !strings.Contains(err.Error(), "2:34") || !strings.Contains(err.Error(), "2:28") {
+		t.Fatalf("Expected renaming limit to n to be refused at 2:34 for the n bound at 2:28. Got %v", err)
+	}
+	_, err = RenameSymbol(table, index, refs.VariableTarget("limit"), "clamp")
+	if err == nil || !strings.Contains(err.Error(), "already declared") {
+		t.Fatalf("Expected renaming limit to clamp to be refused. Got %v", err)
+	}
+
+	n := refs.Target{Kind: refs.TargetLocal, Container: "clamp", Name: "n", Binding: span(2, 28, 1)}
+	_, err = RenameSymbol(table, index, n, "limit")
+	if err == nil || !strings.Contains(err.Error(), "2:34") {
+		t.Fatalf("Expected renaming n to limit to be refused for hiding limit at 2:34. Got %v", err)
+	}
+	edits, err := RenameSymbol(table, index, n, "x")
+	if err != nil || len(edits) != 1 {
+		t.Fatalf("Expected renaming n to x to edit its binding. Got %v, %v", edits, err)
+	}
+}
@@ -0,0 +1,237 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// FieldOrder returns the fields of struct structName in declaration order
+func FieldOrder(table *symbols.SymbolTable, structName string) ([]string, error) {
+	decl, ok := table.Types[structName]
+	if !ok {
+		return nil, fmt.Errorf("undefined type: %s", structName)
+	}
+	if _, ok := decl.Type.(types.StructType); !ok {
+		return nil, fmt.Errorf("%s is not a struct", structName)
+	}
+	names := make([]string, 0, len(decl.FieldLocations))
+	for name := range decl.FieldLocations {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return precedes(decl.FieldLocations[names[i]], decl.FieldLocations[names[j]])
+	})
+	return names, nil
+}
+
+// ReorderFields reorders the fields of struct structName to order, returning edits
+// for its declaration and for every literal of it in program, whose fields are
+// put in the same relative order. Each field moves with its attributes, type and
+// default value; separators and comments stay where they are.
+func ReorderFields(source []byte, program *ast.Program, table *symbols.SymbolTable, structName string, order []string) ([]TextEdit, error) {
+	current, err := FieldOrder(table, structName)
+	if err != nil {
+		return nil, err
+	}
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, ok := table.Types[structName].FieldLocations[name]; !ok {
+			return nil, fmt.Errorf("struct %s has no field %s", structName, name)
+		}
+		if _, duplicate := rank[name]; duplicate {
+			return nil, fmt.Errorf("field %s is listed twice", name)
+		}
+		rank[name] = i
+	}
+	if len(order) != len(current) {
+		return nil, fmt.Errorf("the new order of %s lists %d of its %d fields", structName, len(order), len(current))
+	}
+
+	lines := lineStarts(source)
+	var edits []TextEdit
+	permute := func(names []string, locations map[string]ast.Location) error {
+		entries := make([]entry, len(names))
+		for i, name := range names {
+			start, _, err := offsets(source, lines, locations[name])
+			if err != nil {
+				return err
+			}
+			entries[i] = fieldEntry(source, start)
+			entries[i].name = name
+		}
+		sorted := append([]entry(nil), entries...)
+		sort.SliceStable(sorted, func(i, j int) bool { return rank[sorted[i].name] < rank[sorted[j].name] })
+		for i, slot := range entries {
+			if sorted[i].name == slot.name {
+				continue
+			}
+			edits = append(edits, TextEdit{
+				Location: location(lines, slot.start, slot.end),
+				NewText:  string(source[sorted[i].start:sorted[i].end]),
+			})
+		}
+		return nil
+	}
+
+	if err := permute(current, table.Types[structName].FieldLocations); err != nil {
+		return nil, err
+	}
+	for _, literal := range StructLiterals(program, structName) {
+		names := make([]string, len(literal.Fields))
+		locations := make(map[string]ast.Location, len(literal.Fields))
+		for i, field := range literal.Fields {
+			names[i] = field.Name
+			locations[field.Name] = field.NameLocation
+		}
+		sort.SliceStable(names, func(i, j int) bool { return precedes(locations[names[i]], locations[names[j]]) })
+		if err := permute(names, locations); err != nil {
+			return nil, err
+		}
+	}
+	return edits, nil
+}
+
+// entry is the byte range of one field of a struct declaration or literal
+type entry struct {
+	name       string
+	start, end int
+}
+
+// fieldEntry returns the field whose name starts at offset name: back to the
+// separator before it, so that attributes such as @field(1) come along, and on to
+// the separator after its type, value or default, without surrounding space
+func fieldEntry(source []byte, name int) entry {
+	start, depth := name, 0
+	for start > 0 {
+		c := source[start-1]
+		if c == ')' || c == ']' {
+			depth++
+		} else if (c == '(' || c == '[') && depth > 0 {
+			depth--
+		} else if depth == 0 && (c == '{' || c == ',' || c == '\n') {
+			break
+		}
+		start--
+	}
+	for start < name && (source[start] == ' ' || source[start] == '\t') {
+		start++
+	}
+
+	end, depth, inString := name, 0, false
+scan:
+	for ; end < len(source); end++ {
+		c := source[end]
+		switch {
+		case inString:
+			if c == '\\' {
+				end++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case strings.IndexByte("([{", c) >= 0:
+			depth++
+		case depth > 0 && strings.IndexByte(")]}", c) >= 0:
+			depth--
+		case depth == 0 && strings.IndexByte(",}\n", c) >= 0:
+			break scan
+		case c == '/' && end+1 < len(source) && (source[end+1] == '/' || source[end+1] == '*'):
+			break scan
+		}
+	}
+	for end > name && (source[end-1] == ' ' || source[end-1] == '\t') {
+		end--
+	}
+	return entry{start: start, end: end}
+}
+
+// location converts a byte range back to a one-based location
+func location(lines []int, start, end int) ast.Location {
+	position := func(offset int) (line, col int) {
+		line = sort.Search(len(lines), func(i int) bool { return lines[i] > offset })
+		return line, offset - lines[line-1] + 1
+	}
+	startLine, startCol := position(start)
+	endLine, endCol := position(end)
+	return ast.Location{StartLine: startLine, StartCol: startCol, EndLine: endLine, EndCol: endCol}
+}
+
+func precedes(a, b ast.Location) bool {
+	return a.StartLine < b.StartLine || (a.StartLine == b.StartLine && a.StartCol < b.StartCol)
+}
+
+// StructLiterals returns the literals of struct structName in program, outermost first
+func StructLiterals(program *ast.Program, structName string) []*ast.StructLiteralExpr {
+	var literals []*ast.StructLiteralExpr
+	var walk func(expr ast.Expression)
+	walk = func(expr ast.Expression) {
+		switch e := expr.(type) {
+		case *ast.StructLiteralExpr:
+			if e.TypeName == structName {
+				literals = append(literals, e)
+			}
+			for _, field := range e.Fields {
+				walk(field.Value)
+			}
+		case *ast.CallExpr:
+			walk(e.Callee)
+			for _, argument := range e.Arguments {
+				walk(argument)
+			}
+		case *ast.BinaryOpExpr:
+			walk(e.Left)
+			walk(e.Right)
+		case *ast.BooleanBinaryOpExpr:
+			walk(e.Left)
+			walk(e.Right)
+		case *ast.GuardExpr:
+			walk(e.Condition)
+		case *ast.IfThenExpr:
+			walk(e.Condition)
+			walk(e.Then)
+			walk(e.Else)
+		case *ast.IfBlockExpr:
+			walk(e.Condition)
+			walk(e.Then)
+			walk(e.Else)
+		case *ast.MemberAccessExpr:
+			walk(e.Object)
+		case *ast.ArrayLiteralExpr:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *ast.TupleLiteralExpr:
+			for _, element := range e.Elements {
+				walk(element)
+			}
+		case *ast.LambdaExpr:
+			walk(e.Body)
+		}
+	}
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.VarDeclStmt:
+			walk(s.Value)
+		case *ast.VarAssignStmt:
+			walk(s.Value)
+		case *ast.ExpressionStmt:
+			walk(s.Expression)
+		case *ast.ReturnStmt:
+			walk(s.Value)
+		case *ast.FunctionDefStmt:
+			for _, clause := range s.Clauses {
+				if clause.Guard != nil {
+					walk(clause.Guard.Condition)
+				}
+				walk(clause.Body)
+			}
+		}
+	}
+	return literals
+}
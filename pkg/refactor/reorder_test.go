@@ -0,0 +1,86 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+const unorderedSource = `struct Point {
+	y: Int = max(1, 2), // vertical
+	x: Int,
+}
+let p: Point = Point { y: 2, x: 1 }
+let q: Point = Point { x: 3 }
+`
+
+// unorderedProgram builds the AST of unorderedSource
+func unorderedProgram(t *testing.T) (*ast.Program, *symbols.SymbolTable) {
+	table := symbols.NewSymbolTable()
+	pointDecl := &ast.TypeDeclStmt{
+		Name: "Point",
+		Type: types.StructType{Name: "Point", Fields: map[string]types.StructField{
+			"x": {Name: "x", Type: intType},
+			"y": {Name: "y", Type: intType},
+		}},
+		FieldLocations: map[string]ast.Location{"y": loc(2, 2), "x": loc(3, 2)},
+	}
+	pDecl := &ast.VarDeclStmt{Keyword: "let", Name: "p", Type: types.UnresolvedType{Name: "Point"}, Value: &ast.StructLiteralExpr{
+		TypeName: "Point",
+		Fields: []*ast.StructLiteralField{
+			{Name: "y", NameLocation: loc(5, 24), Value: &ast.IntegerLiteralExpr{Value: 2}},
+			{Name: "x", NameLocation: loc(5, 30), Value: &ast.IntegerLiteralExpr{Value: 1}},
+		},
+	}}
+	qDecl := &ast.VarDeclStmt{Keyword: "let", Name: "q", Type: types.UnresolvedType{Name: "Point"}, Value: &ast.StructLiteralExpr{
+		TypeName: "Point",
+		Fields:   []*ast.StructLiteralField{{Name: "x", NameLocation: loc(6, 24), Value: &ast.IntegerLiteralExpr{Value: 3}}},
+	}}
+	if err := table.RegisterType(pointDecl); err != nil {
+		t.Fatalf("RegisterType error: %v", err)
+	}
+	return &ast.Program{Statements: []ast.AstNode{pointDecl, pDecl, qDecl}}, table
+}
+
+func TestReorderFields_UpdatesDeclarationAndLiterals(t *testing.T) {
+	program, table := unorderedProgram(t)
+
+	order, err := FieldOrder(table, "Point")
+	if err != nil || len(order) != 2 || order[0] != "y" || order[1] != "x" {
+		t.Fatalf("Expected the declaration order [y x]. Got %v (%v)", order, err)
+	}
+
+	edits, err := ReorderFields([]byte(unorderedSource), program, table, "Point", []string{"x", "y"})
+	if err != nil {
+		t.Fatalf("ReorderFields error: %v", err)
+	}
+	result, err := Apply([]byte(unorderedSource), edits)
+	if err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	expected := `struct Point {
+	x: Int, // vertical
+	y: Int = max(1, 2),
+}
+let p: Point = Point { x: 1, y: 2 }
+let q: Point = Point { x: 3 }
+`
+	if string(result) != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, result)
+	}
+}
+
+func TestReorderFields_RejectsIncompleteOrder(t *testing.T) {
+	program, table := unorderedProgram(t)
+
+	_, err := ReorderFields([]byte(unorderedSource), program, table, "Point", []string{"x"})
+	if err == nil || err.Error() != "the new order of Point lists 1 of its 2 fields" {
+		t.Fatalf("Expected an incomplete order to be rejected. Got %v", err)
+	}
+	_, err = ReorderFields([]byte(unorderedSource), program, table, "Point", []string{"x", "z"})
+	if err == nil || err.Error() != "struct Point has no field z" {
+		t.Fatalf("Expected an unknown field to be rejected. Got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxHistory is the number of entries kept in the history file
+const maxHistory = 1000
+
+// History is the list of previous entries, persisted one quoted entry per line so
+// multiline entries survive
+type History struct {
+	path    string // "" keeps the history in memory
+	entries []string
+}
+
+// LoadHistory reads the history file at path; a missing file is an empty history
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+	if path == "" {
+		return h, nil
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if entry, err := strconv.Unquote(scanner.Text()); err == nil {
+			h.entries = append(h.entries, entry)
+		}
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	return h, scanner.Err()
+}
+
+// Entries returns the entries, oldest first
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add records an entry unless it repeats the previous one; the history file is
+// best effort and write errors are ignored
+func (h *History) Add(entry string) {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, strconv.Quote(entry))
+}
+
+// Recall expands !! (the last entry), !n (the n-th entry) and !prefix (the last
+// entry starting with prefix)
+func (h *History) Recall(reference string) (string, error) {
+	key := strings.TrimPrefix(reference, "!")
+	if len(h.entries) == 0 {
+		return "", errors.New("history is empty")
+	}
+	if key == "!" {
+		return h.entries[len(h.entries)-1], nil
+	}
+	if n, err := strconv.Atoi(key); err == nil {
+		if n < 1 || n > len(h.entries) {
+			return "", fmt.Errorf("no history entry %d", n)
+		}
+		return h.entries[n-1], nil
+	}
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if strings.HasPrefix(h.entries[i], key) {
+			return h.entries[i], nil
+		}
+	}
+	return "", fmt.Errorf("no history entry starts with %s", key)
+}
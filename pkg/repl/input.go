@@ -0,0 +1,50 @@
+package repl
+
+import "strings"
+
+// Incomplete reports whether an entry continues on the next line: a bracket or
+// block comment is still open, or its last line ends with =, => or a comma
+func Incomplete(entry string) bool {
+	depth := 0
+	inString, inComment := false, false
+	for i := 0; i < len(entry); i++ {
+		c := entry[i]
+		switch {
+		case inComment:
+			if c == '*' && i+1 < len(entry) && entry[i+1] == '/' {
+				inComment = false
+				i++
+			}
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(entry) && entry[i+1] == '/':
+			for i < len(entry) && entry[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(entry) && entry[i+1] == '*':
+			inComment = true
+			i++
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		}
+	}
+	if depth > 0 || inComment {
+		return true
+	}
+
+	last := entry[strings.LastIndex(entry, "\n")+1:]
+	if comment := strings.Index(last, "//"); comment >= 0 && !strings.Contains(last[:comment], `"`) {
+		last = last[:comment]
+	}
+	last = strings.TrimSpace(last)
+	return strings.HasSuffix(last, "=") && !strings.HasSuffix(last, "==") ||
+		strings.HasSuffix(last, "=>") || strings.HasSuffix(last, ",")
+}
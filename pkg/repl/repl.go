@@ -0,0 +1,346 @@
+package repl
+
+/*
+Repl is the interactive `lyra repl`. Entries accumulate into a session: a
+declaration (def, struct, let, ...) is kept once it analyzes cleanly, and an
+expression is evaluated by analyzing the session followed by a binding of it,
+then running the whole program in the interpreter. Top-level statements of the
+session therefore run again for every evaluation; expressions are never kept.
+
+An entry continues over several lines while brackets are open or a line ends in
+=, => or a comma. Meta commands start with a colon (see help); !! and !n repeat
+entries from the history.
+*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// resultName is the binding an evaluated expression is assigned to
+const resultName = "repl_result"
+
+const help = `:type expr     show the type of an expression
+:inspect expr  show the value of an expression as JSON (see interp.Inspection)
+:browse [file] list the declarations of the session or of a file
+:load file     add the declarations of a file to the session
+:reset         forget every declaration
+:history       list previous entries (!! repeats the last, !n the n-th)
+:quit          leave the repl`
+
+// declarationKeywords start the entries kept in the session
+var declarationKeywords = map[string]bool{
+	"def": true, "pub": true, "pure": true, "async": true,
+	"let": true, "var": true, "const": true,
+	"struct": true, "data": true, "type": true,
+}
+
+var errQuit = errors.New("quit")
+
+type REPL struct {
+	input   *bufio.Scanner
+	output  io.Writer
+	history *History
+
+	// analyze is swappable so tests can feed hand-built results
+	analyze    func(source []byte) (*analyzer.Result, error)
+	session    []string // accepted declarations in entry order
+	statements int      // top-level statements of the session
+}
+
+func New(in io.Reader, out io.Writer, history *History) *REPL {
+	return &REPL{
+		input:   bufio.NewScanner(in),
+		output:  out,
+		history: history,
+		analyze: analyzer.Analyze,
+	}
+}
+
+// Run reads and evaluates entries until :quit or the end of the input
+func (r *REPL) Run() error {
+	for {
+		entry, ok := r.read()
+		if !ok {
+			return r.input.Err()
+		}
+		if err := r.Eval(entry); err == errQuit {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(r.output, err)
+		}
+	}
+}
+
+// read reads an entry, prompting for continuation lines while it is incomplete
+func (r *REPL) read() (string, bool) {
+	prompt := "lyra> "
+	var lines []string
+	for {
+		fmt.Fprint(r.output, prompt)
+		if !r.input.Scan() {
+			return strings.Join(lines, "\n"), len(lines) > 0
+		}
+		lines = append(lines, r.input.Text())
+		entry := strings.Join(lines, "\n")
+		if !Incomplete(entry) {
+			return entry, true
+		}
+		prompt = "...   "
+	}
+}
+
+// Eval evaluates one entry: a meta command, a history recall, a declaration or
+// an expression
+func (r *REPL) Eval(entry string) error {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return nil
+	}
+	if strings.HasPrefix(entry, "!") {
+		recalled, err := r.history.Recall(entry)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.output, recalled)
+		entry = recalled
+	}
+	r.history.Add(entry)
+
+	if strings.HasPrefix(entry, ":") {
+		command, argument, _ := strings.Cut(entry[1:], " ")
+		return r.command(command, strings.TrimSpace(argument))
+	}
+	if declarationKeywords[firstWord(entry)] {
+		return r.declare(entry)
+	}
+	return r.evaluate(entry)
+}
+
+func (r *REPL) command(name, argument string) error {
+	switch name {
+	case "type", "t":
+		result, err := r.analyzeExpression(argument)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.output, "%s : %s\n", argument, typeName(resultBinding(result).Value.GetType()))
+	case "inspect", "i":
+		result, err := r.analyzeExpression(argument)
+		if err != nil {
+			return err
+		}
+		in, err := r.run(result)
+		if err != nil {
+			return err
+		}
+		value, _ := in.Global(resultName)
+		encoder := json.NewEncoder(r.output)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(interp.Inspect(value))
+	case "browse", "b":
+		source := r.source()
+		if argument != "" {
+			file, err := os.ReadFile(argument)
+			if err != nil {
+				return err
+			}
+			source = string(file)
+		}
+		result, err := r.check(source)
+		if err != nil {
+			return err
+		}
+		for _, line := range browse(result.Program) {
+			fmt.Fprintln(r.output, line)
+		}
+	case "load", "l":
+		file, err := os.ReadFile(argument)
+		if err != nil {
+			return err
+		}
+		return r.declare(string(file))
+	case "reset":
+		r.session, r.statements = nil, 0
+	case "history":
+		for i, entry := range r.history.Entries() {
+			fmt.Fprintf(r.output, "%4d  %s\n", i+1, strings.ReplaceAll(entry, "\n", "\n      "))
+		}
+	case "help", "h", "?":
+		fmt.Fprintln(r.output, help)
+	case "quit", "q":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command :%s (try :help)", name)
+	}
+	return nil
+}
+
+// declare adds declarations to the session once they analyze cleanly and shows
+// the values of the bindings they introduce
+func (r *REPL) declare(declarations string) error {
+	result, err := r.check(r.source() + declarations + "\n")
+	if err != nil {
+		return err
+	}
+	in, err := r.run(result)
+	if err != nil {
+		return err
+	}
+	r.session = append(r.session, declarations)
+
+	// the entry's statements follow those of the session
+	statements := result.Program.Statements[r.statements:]
+	r.statements = len(result.Program.Statements)
+	for _, stmt := range statements {
+		if decl, ok := stmt.(*ast.VarDeclStmt); ok {
+			value, _ := in.Global(decl.Name)
+			fmt.Fprintf(r.output, "%s = %s\n", decl.Name, interp.FormatValue(value))
+		}
+	}
+	return nil
+}
+
+// evaluate runs the session with an expression bound to resultName and prints its value
+func (r *REPL) evaluate(expression string) error {
+	result, err := r.analyzeExpression(expression)
+	if err != nil {
+		return err
+	}
+	in, err := r.run(result)
+	if err != nil {
+		return err
+	}
+	value, _ := in.Global(resultName)
+	if _, unit := value.(interp.Unit); !unit {
+		fmt.Fprintln(r.output, interp.FormatValue(value))
+	}
+	return nil
+}
+
+func (r *REPL) analyzeExpression(expression string) (*analyzer.Result, error) {
+	if expression == "" {
+		return nil, errors.New("expected an expression")
+	}
+	result, err := r.check(r.source() + "let " + resultName + " = " + expression + "\n")
+	if err != nil {
+		return nil, err
+	}
+	if resultBinding(result) == nil {
+		return nil, fmt.Errorf("not an expression: %s", expression)
+	}
+	return result, nil
+}
+
+// check analyzes source and returns its first error, ignoring warnings
+func (r *REPL) check(source string) (*analyzer.Result, error) {
+	result, err := r.analyze([]byte(source))
+	if err != nil {
+		return nil, err
+	}
+	if err := result.FirstError(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *REPL) run(result *analyzer.Result) (*interp.Interpreter, error) {
+	in := interp.New(result.Program, result.Table)
+	in.SetOutput(r.output)
+	in.SetOwnership(result.Ownership)
+	return in, in.Init()
+}
+
+// source is the program made of the session's declarations
+func (r *REPL) source() string {
+	var b strings.Builder
+	for _, declarations := range r.session {
+		b.WriteString(declarations)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// resultBinding finds the binding of an evaluated expression
+func resultBinding(result *analyzer.Result) *ast.VarDeclStmt {
+	statements := result.Program.Statements
+	if len(statements) == 0 {
+		return nil
+	}
+	decl, ok := statements[len(statements)-1].(*ast.VarDeclStmt)
+	if !ok || decl.Name != resultName {
+		return nil
+	}
+	return decl
+}
+
+// browse describes the top-level declarations of a program, one per line
+func browse(program *ast.Program) []string {
+	var lines []string
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *ast.FunctionDefStmt:
+			line := "def " + s.Name
+			if len(s.GenericParams) > 0 {
+				line += "<" + strings.Join(s.GenericParams, ", ") + ">"
+			}
+			if s.Signature != nil {
+				line += ": " + s.Signature.GetName()
+			}
+			lines = append(lines, visibility(s)+line)
+		case *ast.TypeDeclStmt:
+			kind := "type"
+			switch s.Type.(type) {
+			case types.StructType:
+				kind = "struct"
+			case types.DataType:
+				kind = "data"
+			}
+			lines = append(lines, visibility(s)+kind+" "+s.Name)
+		case *ast.VarDeclStmt:
+			t := s.Type
+			if t == nil && s.Value != nil {
+				t = s.Value.GetType()
+			}
+			lines = append(lines, fmt.Sprintf("%s %s: %s", s.Keyword, s.Name, typeName(t)))
+		}
+	}
+	return lines
+}
+
+func visibility(node ast.Named) string {
+	if v := symbols.VisibilityOf(node); v != ast.VisibilityFile {
+		return v.String() + " "
+	}
+	return ""
+}
+
+func typeName(t types.Type) string {
+	if t == nil {
+		return "?"
+	}
+	return t.GetName()
+}
+
+// firstWord returns the leading identifier of an entry
+func firstWord(entry string) string {
+	end := strings.IndexFunc(entry, func(c rune) bool {
+		return !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9')
+	})
+	if end < 0 {
+		return entry
+	}
+	return entry[:end]
+}
@@ -0,0 +1,127 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var intType = types.PrimitiveType{Name: types.Int}
+
+// bindingsResult stands in for the analyzer: every line of source is
+// `let name = operand [+ operand]` with integer or identifier operands
+func bindingsResult(source []byte) (*analyzer.Result, error) {
+	program := &ast.Program{}
+	for _, line := range strings.Split(string(source), "\n") {
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "let "), " = ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected line %q", line)
+		}
+		operands := strings.Split(value, " + ")
+		expr := operand(operands[0])
+		for _, right := range operands[1:] {
+			expr = &ast.BinaryOpExpr{ExprBase: ast.ExprBase{Type: intType}, Left: expr, Operator: "+", Right: operand(right)}
+		}
+		program.Statements = append(program.Statements, &ast.VarDeclStmt{Keyword: "let", Name: name, Value: expr})
+	}
+	return &analyzer.Result{Source: source, Program: program, Table: symbols.NewSymbolTable()}, nil
+}
+
+func operand(text string) ast.Expression {
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return &ast.IntegerLiteralExpr{ExprBase: ast.ExprBase{Type: intType}, Value: n}
+	}
+	return &ast.IdentifierExpr{ExprBase: ast.ExprBase{Type: intType}, Name: text}
+}
+
+func run(t *testing.T, input string) string {
+	t.Helper()
+	history, err := LoadHistory("")
+	if err != nil {
+		t.Fatalf("LoadHistory error: %v", err)
+	}
+	var out bytes.Buffer
+	r := New(strings.NewReader(input), &out, history)
+	r.analyze = bindingsResult
+	if err := r.Run(); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	return strings.NewReplacer("lyra> ", "", "...   ", "").Replace(out.String())
+}
+
+func TestREPL_DeclarationsAndExpressions(t *testing.T) {
+	output := run(t, "let x = 40\nx + 2\n:type x + 1\n:browse\n:reset\n:browse\n:quit\nx\n")
+	expected := "x = 40\n42\nx + 1 : Int\nlet x: Int\n"
+	if output != expected {
+		t.Fatalf("Expected %q. Got %q", expected, output)
+	}
+}
+
+func TestREPL_Inspect(t *testing.T) {
+	output := run(t, "let x = 40\n:inspect x + 2\n")
+	expected := "x = 40\n{\n  \"kind\": \"int\",\n  \"text\": \"42\",\n  \"value\": 42\n}\n"
+	if output != expected {
+		t.Fatalf("Expected %q. Got %q", expected, output)
+	}
+}
+
+func TestREPL_HistoryRecall(t *testing.T) {
+	output := run(t, "let x = 1\nx + 1\n!!\n!1\n:history\n")
+	expected := "x = 1\n2\nx + 1\n2\nlet x = 1\nx = 1\n" +
+		"   1  let x = 1\n   2  x + 1\n   3  let x = 1\n   4  :history\n"
+	if output != expected {
+		t.Fatalf("Expected %q. Got %q", expected, output)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	cases := map[string]bool{
+		"def f: (Int) -> Int = {":              true,
+		"def f: (Int) -> Int = {\n(n) => n,":   true,
+		"def f: (Int) -> Int = {\n(n) => n\n}": false,
+		"def f: (Int) -> Int =":                true,
+		"def f: (Int) -> Int = (n) =>":         true,
+		"x == y":                               false,
+		`"(" + x`:                              false,
+		"f(x, // )":                            true,
+		"/* (":                                 true,
+	}
+	for entry, incomplete := range cases {
+		if got := Incomplete(entry); got != incomplete {
+			t.Fatalf("Expected Incomplete(%q) = %v. Got %v", entry, incomplete, got)
+		}
+	}
+}
+
+func TestHistory_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory error: %v", err)
+	}
+	h.Add("def f: (Int) -> Int = {\n(n) => n\n}")
+	h.Add("f(1)")
+	h.Add("f(1)")
+
+	reloaded, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory error: %v", err)
+	}
+	if len(reloaded.Entries()) != 2 || reloaded.Entries()[0] != h.Entries()[0] {
+		t.Fatalf("Expected the two distinct entries back. Got %q", reloaded.Entries())
+	}
+	if entry, err := reloaded.Recall("!def"); err != nil || entry != h.Entries()[0] {
+		t.Fatalf("Expected !def to recall the definition. Got %q, %v", entry, err)
+	}
+}
@@ -0,0 +1,45 @@
+package testrunner
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/Lyra-Language/lyra/pkg/interp"
+)
+
+// jsonEvent is a line of the JSON report: the outcome of a test, or of a file
+// whose tests could not run
+type jsonEvent struct {
+	File     string             `json:"file"`
+	Test     string             `json:"test,omitempty"`
+	Passed   bool               `json:"passed"`
+	Seconds  float64            `json:"seconds"`
+	Error    string             `json:"error,omitempty"`
+	Returned *interp.Inspection `json:"returned,omitempty"`
+}
+
+// WriteJSON writes results as JSON lines, one per test, for tools reading the
+// results of lyra test. A test returning a value other than true or Unit carries
+// it as an interp.Inspection.
+func WriteJSON(w io.Writer, files []FileResult) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, file := range files {
+		if file.Err != nil {
+			if err := encoder.Encode(jsonEvent{File: file.Path, Seconds: file.Duration.Seconds(), Error: file.Err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, test := range file.Tests {
+			event := jsonEvent{File: file.Path, Test: test.Name, Passed: test.Passed(), Seconds: test.Duration.Seconds(), Returned: test.Returned}
+			if test.Err != nil {
+				event.Error = test.Err.Error()
+			}
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
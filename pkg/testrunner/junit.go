@@ -0,0 +1,69 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+	Error    *junitMessage   `xml:"error,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes results as a JUnit XML report, one test suite per file
+func WriteJUnit(w io.Writer, files []FileResult) error {
+	report := junitTestSuites{}
+	for _, file := range files {
+		suite := junitTestSuite{Name: file.Path, Tests: len(file.Tests), Time: seconds(file.Duration.Seconds())}
+		if file.Err != nil {
+			suite.Errors = 1
+			suite.Error = &junitMessage{Message: file.Err.Error()}
+		}
+		for _, test := range file.Tests {
+			testCase := junitTestCase{Name: test.Name, ClassName: file.Path, Time: seconds(test.Duration.Seconds())}
+			if !test.Passed() {
+				suite.Failures++
+				testCase.Failure = &junitMessage{Message: test.Err.Error()}
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,150 @@
+package testrunner
+
+/*
+Testrunner discovers and runs the tests of Lyra files for `lyra test`. A test is a
+top-level function whose name starts with test_ and that takes no parameters:
+
+	def test_fib: () -> Bool = () => fib(10) == 55
+
+It passes when it returns true or Unit (e.g. a final assert) and fails when it
+returns false or raises a runtime error. Tests declared pure cannot observe each
+other, so they run in parallel; the rest run one at a time in file order.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/coverage"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+)
+
+const testPrefix = "test_"
+
+type Options struct {
+	Run      *regexp.Regexp // only run tests whose name matches, nil runs all
+	Parallel int            // maximum pure tests running at once, <= 1 runs them sequentially
+	Cover    bool           // record which clauses and branches ran into FileResult.Coverage
+}
+
+// TestResult is the outcome of a single test
+type TestResult struct {
+	Name     string
+	Duration time.Duration
+	Err      error // nil when the test passed
+	// Returned is what a failing test returned when it was neither true nor Unit
+	Returned *interp.Inspection
+}
+
+func (r TestResult) Passed() bool { return r.Err == nil }
+
+// FileResult is the outcome of the tests of a file
+type FileResult struct {
+	Path     string
+	Duration time.Duration
+	Tests    []TestResult
+	Err      error             // set when the file could not be analyzed or initialized; no tests ran
+	Coverage *coverage.Profile // nil unless Options.Cover is set
+}
+
+func (r FileResult) Passed() bool {
+	if r.Err != nil {
+		return false
+	}
+	for _, test := range r.Tests {
+		if !test.Passed() {
+			return false
+		}
+	}
+	return true
+}
+
+// Discover returns the tests of a program in declaration order
+func Discover(program *ast.Program) []*ast.FunctionDefStmt {
+	var tests []*ast.FunctionDefStmt
+	for _, stmt := range program.Statements {
+		fn, ok := stmt.(*ast.FunctionDefStmt)
+		if !ok || !strings.HasPrefix(fn.Name, testPrefix) {
+			continue
+		}
+		if fn.Signature != nil && len(fn.Signature.ParameterTypes) > 0 {
+			continue
+		}
+		tests = append(tests, fn)
+	}
+	return tests
+}
+
+// RunFile runs the tests of an analyzed file
+func RunFile(path string, result *analyzer.Result, opts Options) FileResult {
+	start := time.Now()
+	file := FileResult{Path: path}
+	defer func() { file.Duration = time.Since(start) }()
+
+	if err := result.FirstError(); err != nil {
+		file.Err = err
+		return file
+	}
+	in := interp.New(result.Program, result.Table)
+	in.SetOwnership(result.Ownership)
+	if opts.Cover {
+		file.Coverage = coverage.NewProfile(result.Program)
+		in.SetCoverage(file.Coverage)
+	}
+	if err := in.Init(); err != nil {
+		file.Err = err
+		return file
+	}
+
+	var tests []*ast.FunctionDefStmt
+	for _, test := range Discover(result.Program) {
+		if opts.Run == nil || opts.Run.MatchString(test.Name) {
+			tests = append(tests, test)
+		}
+	}
+	file.Tests = make([]TestResult, len(tests))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(opts.Parallel, 1))
+	for i, test := range tests {
+		if !test.IsPure || opts.Parallel <= 1 {
+			file.Tests[i] = runTest(in, test.Name)
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			file.Tests[i] = runTest(in, test.Name)
+			<-slots
+		}()
+	}
+	wg.Wait()
+	return file
+}
+
+func runTest(in *interp.Interpreter, name string) TestResult {
+	start := time.Now()
+	value, err := in.Call(name)
+	result := TestResult{Name: name, Duration: time.Since(start), Err: err}
+	if err == nil {
+		switch v := value.(type) {
+		case bool:
+			if !v {
+				result.Err = errors.New("returned false")
+			}
+		case interp.Unit:
+		default:
+			returned := interp.Inspect(value)
+			result.Err = fmt.Errorf("returned %s, expected Bool or Unit", returned.Text)
+			result.Returned = &returned
+		}
+	}
+	return result
+}
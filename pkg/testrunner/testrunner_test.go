@@ -0,0 +1,110 @@
+package testrunner
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+var boolType = types.PrimitiveType{Name: types.Bool}
+
+// test declares `def name: () -> Bool = () => body`
+func test(name string, pure bool, body ast.Expression) *ast.FunctionDefStmt {
+	return &ast.FunctionDefStmt{
+		Name:      name,
+		Signature: &types.FunctionType{ReturnType: boolType},
+		Clauses:   []*ast.FunctionClause{{Body: body}},
+		IsPure:    pure,
+	}
+}
+
+func result(t *testing.T, functions ...*ast.FunctionDefStmt) *analyzer.Result {
+	t.Helper()
+	table := symbols.NewSymbolTable()
+	program := &ast.Program{}
+	for _, fn := range functions {
+		if err := table.RegisterFunction(fn); err != nil {
+			t.Fatalf("RegisterFunction error: %v", err)
+		}
+		program.Statements = append(program.Statements, fn)
+	}
+	return &analyzer.Result{Program: program, Table: table}
+}
+
+func boolean(v bool) *ast.BooleanLiteralExpr { return &ast.BooleanLiteralExpr{Value: v} }
+
+func TestRunFile_FilterAndParallel(t *testing.T) {
+	file := result(t,
+		test("test_true", true, boolean(true)),
+		test("test_false", true, boolean(false)),
+		test("test_todo", false, &ast.CallExpr{Callee: &ast.IdentifierExpr{Name: "todo"}}),
+		test("helper", false, boolean(false)),
+	)
+
+	all := RunFile("a.lyra", file, Options{Parallel: 4})
+	if len(all.Tests) != 3 {
+		t.Fatalf("Expected 3 tests (helper is not a test). Got %d", len(all.Tests))
+	}
+	outcomes := ""
+	for _, test := range all.Tests {
+		if test.Passed() {
+			outcomes += "P"
+		} else {
+			outcomes += "F"
+		}
+	}
+	if outcomes != "PFF" || all.Passed() {
+		t.Fatalf("Expected pass, fail, fail in declaration order. Got %s", outcomes)
+	}
+
+	filtered := RunFile("a.lyra", file, Options{Run: regexp.MustCompile("true")})
+	if len(filtered.Tests) != 1 || filtered.Tests[0].Name != "test_true" || !filtered.Passed() {
+		t.Fatalf("Expected only test_true to run. Got %+v", filtered.Tests)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	file := RunFile("a.lyra", result(t, test("test_true", false, boolean(true)), test("test_false", false, boolean(false))), Options{})
+
+	var out strings.Builder
+	if err := WriteJUnit(&out, []FileResult{file}); err != nil {
+		t.Fatalf("WriteJUnit error: %v", err)
+	}
+	report := out.String()
+	for _, expected := range []string{
+		`<testsuite name="a.lyra" tests="2" failures="1" errors="0"`,
+		`<testcase name="test_true" classname="a.lyra"`,
+		`<failure message="returned false"></failure>`,
+	} {
+		if !strings.Contains(report, expected) {
+			t.Fatalf("Expected report to contain %s. Got:\n%s", expected, report)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	answer := &ast.FunctionDefStmt{
+		Name:      "test_answer",
+		Signature: &types.FunctionType{ReturnType: types.PrimitiveType{Name: types.Int}},
+		Clauses:   []*ast.FunctionClause{{Body: &ast.IntegerLiteralExpr{Value: 42}}},
+	}
+	file := RunFile("a.lyra", result(t, test("test_true", false, boolean(true)), answer), Options{})
+
+	var out strings.Builder
+	if err := WriteJSON(&out, []FileResult{file}); err != nil {
+		t.Fatalf("WriteJSON error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"file":"a.lyra","test":"test_true","passed":true,`) {
+		t.Fatalf("Expected a line per test, test_true passing. Got:\n%s", out.String())
+	}
+	returned := `"error":"returned 42, expected Bool or Unit","returned":{"kind":"int","text":"42","value":42}}`
+	if !strings.HasSuffix(lines[1], returned) {
+		t.Fatalf("Expected test_answer to carry the value it returned. Got %s", lines[1])
+	}
+}
@@ -0,0 +1,21 @@
+package types
+
+import "fmt"
+
+// HoleType is a placeholder (`_` or `?`) in a type annotation; the checker
+// fills it with the inferred type and reports what it found
+type HoleType struct{}
+
+func (HoleType) typeNode() {}
+
+func (h HoleType) IsNumericType() bool {
+	return false
+}
+
+func (h HoleType) GetName() string {
+	return "?"
+}
+
+func (h HoleType) Print(indent string) {
+	fmt.Printf("%sHoleType\n", indent)
+}
@@ -0,0 +1,289 @@
+package types
+
+import "fmt"
+
+// TypeVar is a type not known yet, which unification solves: the checker gives
+// each generic parameter of a function a fresh variable at every call, so the
+// arguments decide what the parameter stands for there
+type TypeVar struct {
+	ID     int
+	Origin string // the generic parameter it stands for, if any
+}
+
+func (TypeVar) typeNode() {}
+
+func (v TypeVar) IsNumericType() bool {
+	return false
+}
+
+func (v TypeVar) GetName() string {
+	if v.Origin != "" {
+		return v.Origin
+	}
+	return fmt.Sprintf("?%d", v.ID)
+}
+
+func (v TypeVar) Print(indent string) {
+	fmt.Printf("%sTypeVar(%s)\n", indent, v.GetName())
+}
+
+// Substitution records the types unification solved variables to, by ID
+type Substitution map[int]Type
+
+// Apply replaces the solved variables of t with their types, following chains
+// of variables solved to variables
+func (s Substitution) Apply(t Type) Type {
+	switch t := t.(type) {
+	case TypeVar:
+		if solved, ok := s[t.ID]; ok {
+			return s.Apply(solved)
+		}
+	case ArrayType:
+		return ArrayType{ElementType: s.Apply(t.ElementType)}
+	case TupleType:
+		elements := make([]Type, len(t.Elements))
+		for i, element := range t.Elements {
+			elements[i] = s.Apply(element)
+		}
+		return TupleType{Elements: elements}
+	case *FunctionType:
+		if t != nil {
+			applied := s.Apply(*t).(FunctionType)
+			return &applied
+		}
+	case FunctionType:
+		parameters := make([]ParameterType, len(t.ParameterTypes))
+		for i, parameter := range t.ParameterTypes {
+			parameters[i] = ParameterType{Modifier: parameter.Modifier, Type: s.Apply(parameter.Type)}
+		}
+		return FunctionType{ParameterTypes: parameters, ReturnType: s.Apply(t.ReturnType)}
+	}
+	return t
+}
+
+// UnifyError is a pair of types unification could not make equal
+type UnifyError struct {
+	Expected, Actual Type
+	Infinite         bool // Actual would have to contain Expected, a variable
+}
+
+func (e *UnifyError) Error() string {
+	if e.Infinite {
+		return fmt.Sprintf("%s would have to contain itself in %s", e.Expected.GetName(), e.Actual.GetName())
+	}
+	return fmt.Sprintf("expected %s but got %s", e.Expected.GetName(), e.Actual.GetName())
+}
+
+// Unify solves the variables of expected and actual so that both are the same
+// type, adding what it solves to s. Generic parameters, holes and nil stand for
+// types the checker accepts anywhere, and Never fits any expected type.
+func Unify(expected, actual Type, s Substitution) error {
+	expected, actual = s.Apply(expected), s.Apply(actual)
+	if v, ok := expected.(TypeVar); ok {
+		return bindVar(v, actual, s)
+	}
+	if v, ok := actual.(TypeVar); ok {
+		return bindVar(v, expected, s)
+	}
+	if accepted(expected) || accepted(actual) {
+		return nil
+	}
+	if f, ok := expected.(*FunctionType); ok {
+		expected = *f
+	}
+	if f, ok := actual.(*FunctionType); ok {
+		actual = *f
+	}
+	mismatch := &UnifyError{Expected: expected, Actual: actual}
+	switch e := expected.(type) {
+	case ArrayType:
+		a, ok := actual.(ArrayType)
+		if !ok {
+			return mismatch
+		}
+		return Unify(e.ElementType, a.ElementType, s)
+	case TupleType:
+		a, ok := actual.(TupleType)
+		if !ok || len(a.Elements) != len(e.Elements) {
+			return mismatch
+		}
+		for i := range e.Elements {
+			if err := Unify(e.Elements[i], a.Elements[i], s); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FunctionType:
+		a, ok := actual.(FunctionType)
+		if !ok || len(a.ParameterTypes) != len(e.ParameterTypes) {
+			return mismatch
+		}
+		for i := range e.ParameterTypes {
+			if err := Unify(e.ParameterTypes[i].Type, a.ParameterTypes[i].Type, s); err != nil {
+				return err
+			}
+		}
+		return Unify(e.ReturnType, a.ReturnType, s)
+	case StructType, DataType, UnresolvedType:
+		// named types are the same type when they have the same name
+		switch actual.(type) {
+		case StructType, DataType, UnresolvedType:
+			if expected.GetName() == actual.GetName() {
+				return nil
+			}
+		}
+		return mismatch
+	}
+	if !TypesEqual(expected, actual) {
+		return mismatch
+	}
+	return nil
+}
+
+func bindVar(v TypeVar, t Type, s Substitution) error {
+	if other, ok := t.(TypeVar); ok && other.ID == v.ID {
+		return nil
+	}
+	if t == nil {
+		return nil
+	}
+	if occurs(v, t) {
+		return &UnifyError{Expected: v, Actual: t, Infinite: true}
+	}
+	s[v.ID] = t
+	return nil
+}
+
+// occurs reports whether the variable v appears in t
+func occurs(v TypeVar, t Type) bool {
+	switch t := t.(type) {
+	case TypeVar:
+		return t.ID == v.ID
+	case ArrayType:
+		return occurs(v, t.ElementType)
+	case TupleType:
+		for _, element := range t.Elements {
+			if occurs(v, element) {
+				return true
+			}
+		}
+	case *FunctionType:
+		return t != nil && occurs(v, *t)
+	case FunctionType:
+		for _, parameter := range t.ParameterTypes {
+			if occurs(v, parameter.Type) {
+				return true
+			}
+		}
+		return occurs(v, t.ReturnType)
+	}
+	return false
+}
+
+// accepted reports whether t is accepted wherever a type is expected
+func accepted(t Type) bool {
+	switch t := t.(type) {
+	case nil, GenericType, HoleType:
+		return true
+	case PrimitiveType:
+		return t.Name == Never
+	}
+	return false
+}
+
+// Instantiate gives each generic parameter of t a fresh variable, made by fresh,
+// so that unifying the result solves the parameters for one use of t
+func Instantiate(t Type, fresh func(origin string) TypeVar) Type {
+	vars := make(map[string]Type)
+	var instantiate func(Type) Type
+	instantiate = func(t Type) Type {
+		switch t := t.(type) {
+		case GenericType:
+			if _, ok := vars[t.Name]; !ok {
+				vars[t.Name] = fresh(t.Name)
+			}
+			return vars[t.Name]
+		case ArrayType:
+			return ArrayType{ElementType: instantiate(t.ElementType)}
+		case TupleType:
+			elements := make([]Type, len(t.Elements))
+			for i, element := range t.Elements {
+				elements[i] = instantiate(element)
+			}
+			return TupleType{Elements: elements}
+		case *FunctionType:
+			if t != nil {
+				instantiated := instantiate(*t).(FunctionType)
+				return &instantiated
+			}
+		case FunctionType:
+			parameters := make([]ParameterType, len(t.ParameterTypes))
+			for i, parameter := range t.ParameterTypes {
+				parameters[i] = ParameterType{Modifier: parameter.Modifier, Type: instantiate(parameter.Type)}
+			}
+			return FunctionType{ParameterTypes: parameters, ReturnType: instantiate(t.ReturnType)}
+		}
+		return t
+	}
+	return instantiate(t)
+}
+
+// Generalize applies s to t and turns the variables still unsolved back into
+// the generic parameters they were made for, so a binding whose type the
+// arguments did not decide, like that of empty(), stays generic
+func Generalize(t Type, s Substitution) Type {
+	var generalize func(Type) Type
+	generalize = func(t Type) Type {
+		switch t := t.(type) {
+		case TypeVar:
+			return GenericType{Name: t.GetName()}
+		case ArrayType:
+			return ArrayType{ElementType: generalize(t.ElementType)}
+		case TupleType:
+			elements := make([]Type, len(t.Elements))
+			for i, element := range t.Elements {
+				elements[i] = generalize(element)
+			}
+			return TupleType{Elements: elements}
+		case *FunctionType:
+			if t != nil {
+				generalized := generalize(*t).(FunctionType)
+				return &generalized
+			}
+		case FunctionType:
+			parameters := make([]ParameterType, len(t.ParameterTypes))
+			for i, parameter := range t.ParameterTypes {
+				parameters[i] = ParameterType{Modifier: parameter.Modifier, Type: generalize(parameter.Type)}
+			}
+			return FunctionType{ParameterTypes: parameters, ReturnType: generalize(t.ReturnType)}
+		}
+		return t
+	}
+	return generalize(s.Apply(t))
+}
+
+// HasTypeVars reports whether t contains a variable
+func HasTypeVars(t Type) bool {
+	switch t := t.(type) {
+	case TypeVar:
+		return true
+	case ArrayType:
+		return HasTypeVars(t.ElementType)
+	case TupleType:
+		for _, element := range t.Elements {
+			if HasTypeVars(element) {
+				return true
+			}
+		}
+	case *FunctionType:
+		return t != nil && HasTypeVars(*t)
+	case FunctionType:
+		for _, parameter := range t.ParameterTypes {
+			if HasTypeVars(parameter.Type) {
+				return true
+			}
+		}
+		return HasTypeVars(t.ReturnType)
+	}
+	return false
+}
@@ -21,8 +21,16 @@ const (
 	Float64 PrimitiveTypeName = "Float64"
 	Bool    PrimitiveTypeName = "Bool"
 	String  PrimitiveTypeName = "String"
+	Unit    PrimitiveTypeName = "Unit"  // the type of expressions evaluated for their effect, e.g. assert(...)
+	Never   PrimitiveTypeName = "Never" // the type of expressions that never produce a value, e.g. todo()
 )
 
+// PrimitiveTypeNames lists the primitive types that annotations may name
+var PrimitiveTypeNames = []PrimitiveTypeName{
+	Int, Int8, Int16, Int32, Int64, UInt, UInt8, UInt16, UInt32, UInt64,
+	Float, Float16, Float32, Float64, Bool, String, Unit, Never,
+}
+
 type PrimitiveType struct {
 	Name PrimitiveTypeName
 }
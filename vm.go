@@ -0,0 +1,156 @@
+package lyra
+
+/*
+Lyra embeds the interpreter in Go programs, e.g. to use Lyra as a typed scripting
+or configuration language:
+
+	vm := lyra.NewVM()
+	vm.Bind("config", Config{Name: "api", Port: 8080})
+	vm.Register("go:time.UnixNano", func() int64 { return time.Now().UnixNano() })
+	if err := vm.Run(source); err != nil { ... }
+	var limits Limits
+	err := vm.Get("limits", &limits)
+
+Bound Go values become top-level bindings of the program. Their Lyra types are
+synthesized from the Go types: a struct becomes a struct type of the same name
+whose fields are the exported Go fields in snake_case (or named by a `lyra:"name"`
+tag), slices and arrays become arrays, and numbers, bools and strings map to the
+matching primitive types. Maps are rejected until Lyra has a map type.
+
+A tuple such as the (Int, Int) of divmod is a single value inside Lyra, returned,
+bound and destructured whole. Only registered Go functions see it as several
+values: an extern returning a tuple is a Go function with one result per element.
+*/
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/interp"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+// VM runs a Lyra program with values and functions supplied by the host
+type VM struct {
+	prelude analyzer.Prelude
+	types   map[reflect.Type]types.Type // synthesized types by Go type
+	names   map[string]reflect.Type     // Go type of each synthesized struct name
+	externs map[string]any
+	output  io.Writer
+
+	interp *interp.Interpreter // the last program run
+}
+
+func NewVM() *VM {
+	return &VM{
+		types:   make(map[reflect.Type]types.Type),
+		names:   make(map[string]reflect.Type),
+		externs: make(map[string]any),
+		output:  os.Stderr,
+	}
+}
+
+// SetOutput sets where debug() prints; stderr by default
+func (vm *VM) SetOutput(w io.Writer) {
+	vm.output = w
+}
+
+// Bind declares a top-level binding name holding a copy of the Go value
+func (vm *VM) Bind(name string, value any) error {
+	if name == "" {
+		return fmt.Errorf("binding needs a name")
+	}
+	t, err := vm.synthesize(reflect.TypeOf(value))
+	if err != nil {
+		return fmt.Errorf("binding %s: %w", name, err)
+	}
+	converted, err := toLyra(reflect.ValueOf(value))
+	if err != nil {
+		return fmt.Errorf("binding %s: %w", name, err)
+	}
+	vm.prelude = append(vm.prelude, &ast.VarDeclStmt{
+		Keyword: "let",
+		Name:    name,
+		Type:    t,
+		Value:   &ast.HostValueExpr{ExprBase: ast.ExprBase{Type: t}, Value: converted},
+	})
+	return nil
+}
+
+// Register implements the extern declarations with target ("go:time.UnixNano")
+// by the Go function fn (see interp.RegisterExtern)
+func (vm *VM) Register(target string, fn any) error {
+	if reflect.TypeOf(fn) == nil || reflect.TypeOf(fn).Kind() != reflect.Func {
+		return fmt.Errorf("extern %s: %T is not a function", target, fn)
+	}
+	vm.externs[target] = fn
+	return nil
+}
+
+// Run analyzes source with the bindings in scope and evaluates its top-level
+// statements. Analysis errors other than warnings stop it before evaluation.
+func (vm *VM) Run(source []byte) error {
+	result, err := analyzer.AnalyzeWith(source, vm.prelude)
+	if err != nil {
+		return err
+	}
+	if err := result.FirstError(); err != nil {
+		return err
+	}
+	return vm.run(result)
+}
+
+func (vm *VM) run(result *analyzer.Result) error {
+	in := interp.New(result.Program, result.Table)
+	in.SetOutput(vm.output)
+	in.SetOwnership(result.Ownership)
+	for target, fn := range vm.externs {
+		if err := in.RegisterExtern(target, fn); err != nil {
+			return err
+		}
+	}
+	if err := in.Init(); err != nil {
+		return err
+	}
+	vm.interp = in
+	return nil
+}
+
+// Get stores the value of the top-level binding name of the last program run
+// into target, a pointer to a Go value of a matching type
+func (vm *VM) Get(name string, target any) error {
+	if vm.interp == nil {
+		return fmt.Errorf("no program has run")
+	}
+	value, ok := vm.interp.Global(name)
+	if !ok {
+		return fmt.Errorf("undefined: %s", name)
+	}
+	return decode(value, target)
+}
+
+// Call calls the top-level function name of the last program run with Go
+// arguments and returns its result as a Go value. A tuple result is one value
+// too, an []any of its elements; Get stores one into a struct field by field.
+func (vm *VM) Call(name string, args ...any) (any, error) {
+	if vm.interp == nil {
+		return nil, fmt.Errorf("no program has run")
+	}
+	values := make([]interp.Value, len(args))
+	for i, arg := range args {
+		value, err := toLyra(reflect.ValueOf(arg))
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i+1, err)
+		}
+		values[i] = value
+	}
+	result, err := vm.interp.Call(name, values...)
+	if err != nil {
+		return nil, err
+	}
+	return fromLyra(result), nil
+}
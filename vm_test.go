@@ -0,0 +1,168 @@
+package lyra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Lyra-Language/lyra/pkg/analyzer"
+	"github.com/Lyra-Language/lyra/pkg/ast"
+	"github.com/Lyra-Language/lyra/pkg/ast/symbols"
+	"github.com/Lyra-Language/lyra/pkg/types"
+)
+
+type Endpoint struct {
+	Path    string
+	Methods []string
+}
+
+type Config struct {
+	Name      string
+	HTTPPort  int `lyra:"port"`
+	Weight    float32
+	Endpoints []Endpoint
+	secret    string
+}
+
+// analyzed stands in for the analyzer, which needs the grammar: it registers the
+// prelude and the statements in a fresh table
+func analyzed(t *testing.T, prelude analyzer.Prelude, statements ...ast.AstNode) *analyzer.Result {
+	t.Helper()
+	table := symbols.NewSymbolTable()
+	program := &ast.Program{Statements: append(append([]ast.AstNode{}, prelude...), statements...)}
+	for _, stmt := range program.Statements {
+		var err error
+		switch s := stmt.(type) {
+		case *ast.TypeDeclStmt:
+			err = table.RegisterType(s)
+		case *ast.VarDeclStmt:
+			err = table.RegisterVariable(s)
+		case *ast.FunctionDefStmt:
+			err = table.RegisterFunction(s)
+		}
+		if err != nil {
+			t.Fatalf("register error: %v", err)
+		}
+	}
+	return &analyzer.Result{Program: program, Table: table}
+}
+
+func TestVM_BindSynthesizesTypes(t *testing.T) {
+	vm := NewVM()
+	config := Config{Name: "api", HTTPPort: 8080, Weight: 0.5, Endpoints: []Endpoint{{Path: "/", Methods: []string{"GET"}}}, secret: "x"}
+	if err := vm.Bind("config", config); err != nil {
+		t.Fatalf("Bind error: %v", err)
+	}
+	if err := vm.Bind("limits", map[string]int{}); err == nil || !strings.Contains(err.Error(), "no map type") {
+		t.Fatalf("Expected maps to be rejected. Got %v", err)
+	}
+
+	var declared []string
+	for _, decl := range vm.prelude {
+		switch d := decl.(type) {
+		case *ast.TypeDeclStmt:
+			fields := d.Type.(types.StructType).Fields
+			names := make([]string, 0, len(fields))
+			for _, name := range []string{"name", "port", "weight", "endpoints", "path", "methods", "secret"} {
+				if field, ok := fields[name]; ok {
+					names = append(names, name+": "+field.Type.GetName())
+				}
+			}
+			declared = append(declared, d.Name+" { "+strings.Join(names, ", ")+" }")
+		case *ast.VarDeclStmt:
+			declared = append(declared, "let "+d.Name+": "+d.Type.GetName())
+		}
+	}
+	expected := []string{
+		"Endpoint { path: String, methods: Array<String> }",
+		"Config { name: String, port: Int, weight: Float32, endpoints: Array<Endpoint> }",
+		"let config: Config",
+	}
+	if !reflect.DeepEqual(declared, expected) {
+		t.Fatalf("Expected %q. Got %q", expected, declared)
+	}
+}
+
+func TestVM_RunAndGet(t *testing.T) {
+	vm := NewVM()
+	config := Config{Name: "api", HTTPPort: 8080, Endpoints: []Endpoint{{Path: "/health", Methods: []string{"GET", "HEAD"}}}}
+	if err := vm.Bind("config", config); err != nil {
+		t.Fatalf("Bind error: %v", err)
+	}
+
+	// let port = config.port + 1
+	// def twice: (Int) -> Int = (n) => n * 2
+	port := &ast.VarDeclStmt{Keyword: "let", Name: "port", Value: &ast.BinaryOpExpr{
+		Left:     &ast.MemberAccessExpr{Object: &ast.IdentifierExpr{Name: "config"}, Member: "port"},
+		Operator: "+",
+		Right:    &ast.IntegerLiteralExpr{Value: 1},
+	}}
+	twice := &ast.FunctionDefStmt{
+		Name:      "twice",
+		Signature: &types.FunctionType{ParameterTypes: []types.ParameterType{{Type: types.PrimitiveType{Name: types.Int}}}, ReturnType: types.PrimitiveType{Name: types.Int}},
+		Clauses: []*ast.FunctionClause{{
+			Parameters: []ast.Pattern{&ast.IdentifierPattern{Name: "n"}},
+			Body:       &ast.BinaryOpExpr{Left: &ast.IdentifierExpr{Name: "n"}, Operator: "*", Right: &ast.IntegerLiteralExpr{Value: 2}},
+		}},
+	}
+	if err := vm.run(analyzed(t, vm.prelude, port, twice)); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	var next uint16
+	if err := vm.Get("port", &next); err != nil || next != 8081 {
+		t.Fatalf("Expected port 8081. Got %d, %v", next, err)
+	}
+	var roundTrip Config
+	if err := vm.Get("config", &roundTrip); err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if !reflect.DeepEqual(roundTrip, config) {
+		t.Fatalf("Expected %+v back. Got %+v", config, roundTrip)
+	}
+	if result, err := vm.Call("twice", 21); err != nil || result != int64(42) {
+		t.Fatalf("Expected 42. Got %v, %v", result, err)
+	}
+}
+
+func TestVM_TupleResults(t *testing.T) {
+	vm := NewVM()
+	if err := vm.Register("go:divmod", func(a, b int) (int, int) { return a / b, a % b }); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+
+	// extern def divmod: (Int, Int) -> (Int, Int) = "go:divmod"
+	// let qr = divmod(7, 2)
+	intType := types.PrimitiveType{Name: types.Int}
+	divmod := &ast.FunctionDefStmt{
+		Name:   "divmod",
+		Extern: "go:divmod",
+		Signature: &types.FunctionType{
+			ParameterTypes: []types.ParameterType{{Type: intType}, {Type: intType}},
+			ReturnType:     types.TupleType{Elements: []types.Type{intType, intType}},
+		},
+	}
+	qr := &ast.VarDeclStmt{Keyword: "let", Name: "qr", Value: &ast.CallExpr{
+		Callee:    &ast.IdentifierExpr{Name: "divmod"},
+		Arguments: []ast.Expression{&ast.IntegerLiteralExpr{Value: 7}, &ast.IntegerLiteralExpr{Value: 2}},
+	}}
+	if err := vm.run(analyzed(t, vm.prelude, divmod, qr)); err != nil {
+		t.Fatalf("run error: %v", err)
+	}
+
+	if result, err := vm.Call("divmod", 9, 4); err != nil || !reflect.DeepEqual(result, []any{int64(2), int64(1)}) {
+		t.Fatalf("Expected the tuple as [2 1]. Got %v, %v", result, err)
+	}
+	var split struct{ Quotient, Remainder uint8 }
+	if err := vm.Get("qr", &split); err != nil || split.Quotient != 3 || split.Remainder != 1 {
+		t.Fatalf("Expected the tuple stored field by field as {3 1}. Got %+v, %v", split, err)
+	}
+	var pair [2]int
+	if err := vm.Get("qr", &pair); err != nil || pair != [2]int{3, 1} {
+		t.Fatalf("Expected the tuple stored element by element as [3 1]. Got %v, %v", pair, err)
+	}
+	var triple struct{ A, B, C int }
+	if err := vm.Get("qr", &triple); err == nil || !strings.Contains(err.Error(), "cannot store 2 elements") {
+		t.Fatalf("Expected a tuple of 2 not to fill 3 fields. Got %v", err)
+	}
+}